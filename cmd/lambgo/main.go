@@ -4,11 +4,29 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"runtime"
 
+	"github.com/JosiahWitt/lambgo/internal/audit"
+	"github.com/JosiahWitt/lambgo/internal/binsize"
 	"github.com/JosiahWitt/lambgo/internal/builder"
+	"github.com/JosiahWitt/lambgo/internal/buildtimes"
 	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/depgraph"
+	"github.com/JosiahWitt/lambgo/internal/devserver"
+	"github.com/JosiahWitt/lambgo/internal/filewatch"
+	"github.com/JosiahWitt/lambgo/internal/gitdiff"
 	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/licenses"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/JosiahWitt/lambgo/internal/ociimage"
+	"github.com/JosiahWitt/lambgo/internal/reproducible"
 	"github.com/JosiahWitt/lambgo/internal/runcmd"
+	"github.com/JosiahWitt/lambgo/internal/runtimeapi"
+	"github.com/JosiahWitt/lambgo/internal/sbom"
+	"github.com/JosiahWitt/lambgo/internal/scaffold"
+	"github.com/JosiahWitt/lambgo/internal/signing"
+	"github.com/JosiahWitt/lambgo/internal/sysmem"
+	"github.com/JosiahWitt/lambgo/internal/versioninfo"
 	"github.com/JosiahWitt/lambgo/internal/zipper"
 )
 
@@ -19,16 +37,67 @@ import (
 var Version = "0.2.0"
 
 func main() {
+	logger := log.New(os.Stdout, "", 0)
+	runner := &runcmd.Runner{}
+	launcher := &runtimeapi.Launcher{Logs: os.Stderr}
+
+	// Git is run for versionInjection during dry runs too, so the printed commands contain the real values
+	versionInfo := &versioninfo.Reader{Cmd: runner}
+	memory := &sysmem.Reader{FS: os.DirFS("/")}
+	sizeAnalyzer := &binsize.Analyzer{}
+
+	lambdaBuilder := &builder.LambdaBuilder{
+		Cmd:         runner,
+		Zip:         &zipper.Zip{},
+		Image:       &ociimage.Writer{},
+		Manifest:    &manifest.Store{},
+		BuildTimes:  &buildtimes.Store{},
+		SBOM:        &sbom.Writer{},
+		Licenses:    &licenses.Collector{Cmd: runner},
+		Signer:      &signing.Signer{},
+		VersionInfo: versionInfo,
+		Logger:      logger,
+
+		NumCPU:          runtime.NumCPU(),
+		AvailableMemory: memory.Available,
+		SizeAnalyzer:    sizeAnalyzer,
+	}
+
 	app := cmd.App{
 		Version: Version,
 
 		Getwd:            os.Getwd,
 		LambgoFileLoader: &lambgofile.Loader{FS: os.DirFS("/")},
-		Builder: &builder.LambdaBuilder{
-			Cmd:    &runcmd.Runner{},
-			Zip:    &zipper.Zip{},
-			Logger: log.New(os.Stdout, "", 0),
+		Builder:          lambdaBuilder,
+		DryRunBuilder: &builder.LambdaBuilder{
+			Cmd:         &runcmd.Recorder{Logger: logger},
+			Zip:         &zipper.Recorder{Logger: logger},
+			Image:       &ociimage.Recorder{Logger: logger},
+			Manifest:    &manifest.Recorder{Logger: logger},
+			BuildTimes:  &buildtimes.Recorder{Logger: logger},
+			SBOM:        &sbom.Recorder{Logger: logger},
+			Licenses:    &licenses.Recorder{Logger: logger},
+			Signer:      &signing.Recorder{Logger: logger},
+			VersionInfo: versionInfo,
+			Logger:      logger,
+
+			// The commands are printed one at a time, but use the -p flag and memory limit of the real builds
+			NumCPU:          runtime.NumCPU(),
+			AvailableMemory: memory.Available,
+			Sequential:      true,
 		},
+		Scaffolder:        &scaffold.Scaffolder{},
+		Launcher:          launcher,
+		DevServer:         &devserver.Server{Launcher: launcher, Logger: logger},
+		Watcher:           &filewatch.Watcher{},
+		Grapher:           &depgraph.Grapher{Cmd: runner},
+		Differ:            &gitdiff.Differ{Cmd: runner},
+		Verifier:          &reproducible.Verifier{Builder: lambdaBuilder, Logger: logger},
+		Auditor:           &audit.Auditor{},
+		SizeAnalyzer:      sizeAnalyzer,
+		SignatureVerifier: &signing.Verifier{},
+		Logger:            logger,
+		Stdin:             os.Stdin,
 	}
 
 	if err := app.Run(os.Args); err != nil {
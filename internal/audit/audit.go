@@ -0,0 +1,254 @@
+// Package audit checks the modules built into each binary against a local database of vulnerabilities in the OSV format.
+package audit
+
+import (
+	"debug/buildinfo"
+	"errors"
+	"io/fs"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/builder"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"golang.org/x/mod/semver"
+)
+
+type ErkCannotAudit struct{ erk.DefaultKind }
+
+var (
+	ErrCannotReadDatabase   = erk.New(ErkCannotAudit{}, "Cannot read the vulnerability database '{{.path}}': {{.err}}")
+	ErrInvalidDatabaseEntry = erk.New(ErkCannotAudit{}, "Cannot parse the vulnerability database entry '{{.path}}': {{.err}}")
+	ErrBinaryNotBuilt       = erk.New(ErkCannotAudit{}, "Cannot find the binary '{{.path}}'. Run `lambgo build` before auditing.")
+	ErrCannotReadBuildInfo  = erk.New(ErkCannotAudit{}, "Cannot read the build information embedded in '{{.path}}': {{.err}}")
+	ErrCannotReadSymbols    = erk.New(ErkCannotAudit{}, "Cannot read the symbols of '{{.path}}': {{.err}}")
+)
+
+// standardLibrary is the name of the Go standard library in OSV entries.
+const standardLibrary = "stdlib"
+
+// Severity of a vulnerability, as rated by the GitHub Advisory Database.
+type Severity string
+
+const (
+	SeverityLow      Severity = "low"
+	SeverityMedium   Severity = "medium"
+	SeverityHigh     Severity = "high"
+	SeverityCritical Severity = "critical"
+
+	// SeverityUnknown is used when neither the entry nor its aliases in the database are rated.
+	SeverityUnknown Severity = "unknown"
+)
+
+// Severities that can be used as the threshold for failing, from lowest to highest.
+//
+//nolint:gochecknoglobals // Used for validating flags
+var Severities = []Severity{SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+
+// AtLeast returns true when the severity is at or above the threshold.
+// Unknown severities are always at or above the threshold, so unrated vulnerabilities are not ignored.
+func (s Severity) AtLeast(threshold Severity) bool {
+	if s == SeverityUnknown {
+		return true
+	}
+
+	return slices.Index(Severities, s) >= slices.Index(Severities, threshold)
+}
+
+// Report of the vulnerabilities found in a binary.
+type Report struct {
+	Binary *builder.Binary
+
+	// Findings in the binary, sorted by module and ID.
+	Findings []*Finding
+}
+
+// Finding is a vulnerability affecting the version of a module built into a binary.
+type Finding struct {
+	ID       string
+	Aliases  []string
+	Summary  string
+	Severity Severity
+
+	// Module that is vulnerable, which is stdlib for the Go standard library.
+	Module  string
+	Version string
+
+	// FixedVersion of the module, which is empty when there is no fix.
+	FixedVersion string
+
+	// Symbols that are vulnerable and in the binary, as package.Symbol. When the entry does not list the
+	// vulnerable symbols of a package, the package path is listed instead. It is empty when the entry does not
+	// list the vulnerable packages, or none of the vulnerable symbols are in the binary.
+	Symbols []string
+
+	// PossiblyInlined are the vulnerable packages that are in the binary, when none of their vulnerable symbols are.
+	// Functions that are always inlined are not in the symbol table, so the vulnerable symbols may still be called.
+	PossiblyInlined []string
+
+	// Reachable is true when the vulnerable symbols are in the binary, they may have been inlined, or the entry does not
+	// list the vulnerable packages. The Go linker removes unreachable functions, so when none of the listed symbols
+	// or their packages are in the binary, the vulnerable code cannot be called.
+	Reachable bool
+}
+
+type AuditorAPI interface {
+	Audit(config *lambgofile.Config, databasePath string) ([]*Report, error)
+}
+
+// Auditor reads the modules and symbols built into binaries, and checks them against an OSV database.
+// It does not access the network, so it can be used in air-gapped environments.
+type Auditor struct {
+	// ReadBuildInfo embedded in a binary by the go command. Defaults to buildinfo.ReadFile.
+	ReadBuildInfo func(path string) (*debug.BuildInfo, error)
+
+	// ReadSymbols returns the names of the functions in a binary. Defaults to reading the Go symbol table of an ELF binary.
+	ReadSymbols func(path string) ([]string, error)
+}
+
+var _ AuditorAPI = &Auditor{}
+
+// Audit the already built Lambdas, extensions, and layer packages in the config, against the
+// vulnerability database in the directory at databasePath.
+func (a *Auditor) Audit(config *lambgofile.Config, databasePath string) ([]*Report, error) {
+	db, err := loadDatabase(databasePath)
+	if err != nil {
+		return nil, err
+	}
+
+	binaries := builder.Binaries(config)
+	reports := make([]*Report, 0, len(binaries))
+	for _, binary := range binaries {
+		report, err := a.auditBinary(db, binary)
+		if err != nil {
+			return nil, err
+		}
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+func (a *Auditor) auditBinary(db *database, binary *builder.Binary) (*Report, error) {
+	if _, err := os.Stat(binary.OutPath); errors.Is(err, fs.ErrNotExist) {
+		return nil, erk.WithParams(ErrBinaryNotBuilt, erk.Params{"path": binary.OutPath})
+	}
+
+	info, err := a.readBuildInfo(binary.OutPath)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadBuildInfo, err, erk.Params{"path": binary.OutPath})
+	}
+
+	names, err := a.readSymbols(binary.OutPath)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadSymbols, err, erk.Params{"path": binary.OutPath})
+	}
+
+	symbols := newSymbolIndex(names)
+	report := &Report{Binary: binary}
+	for _, module := range modules(info) {
+		for _, entry := range db.byModule[module.Path] {
+			affected := entry.affects(module.Path, module.Version)
+			if affected == nil {
+				continue
+			}
+
+			finding := &Finding{
+				ID:           entry.ID,
+				Aliases:      entry.Aliases,
+				Summary:      entry.Summary,
+				Severity:     db.severity(entry),
+				Module:       module.Path,
+				Version:      module.Version,
+				FixedVersion: affected.fixedVersion(module.Version),
+				Reachable:    true,
+			}
+
+			if affected.EcosystemSpecific != nil && len(affected.EcosystemSpecific.Imports) > 0 {
+				for _, imported := range affected.EcosystemSpecific.Imports {
+					found := symbols.find(imported.Path, imported.Symbols)
+					if len(found) == 0 && symbols.contains(imported.Path) {
+						finding.PossiblyInlined = append(finding.PossiblyInlined, imported.Path)
+					}
+
+					finding.Symbols = append(finding.Symbols, found...)
+				}
+
+				finding.Reachable = len(finding.Symbols) > 0 || len(finding.PossiblyInlined) > 0
+			}
+
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+
+	report.Findings = removeAliasedFindings(report.Findings)
+	return report, nil
+}
+
+func (a *Auditor) readBuildInfo(path string) (*debug.BuildInfo, error) {
+	if a.ReadBuildInfo == nil {
+		return buildinfo.ReadFile(path)
+	}
+
+	return a.ReadBuildInfo(path)
+}
+
+func (a *Auditor) readSymbols(path string) ([]string, error) {
+	if a.ReadSymbols == nil {
+		return readSymbols(path)
+	}
+
+	return a.ReadSymbols(path)
+}
+
+// modules built into the binary, including the standard library, with semver versions.
+// Replaced modules are checked as their replacement, since that is the code in the binary.
+// Modules without a version, such as the main module or local replacements, are skipped.
+func modules(info *debug.BuildInfo) []*debug.Module {
+	var built []*debug.Module
+	if version := goSemver(info.GoVersion); version != "" {
+		built = append(built, &debug.Module{Path: standardLibrary, Version: version})
+	}
+
+	for _, module := range append([]*debug.Module{&info.Main}, info.Deps...) {
+		if module.Replace != nil {
+			module = module.Replace
+		}
+
+		if version := canonicalVersion(module.Version); module.Version != "" && module.Path != "" && semver.IsValid(version) {
+			built = append(built, &debug.Module{Path: module.Path, Version: version})
+		}
+	}
+
+	slices.SortStableFunc(built, func(a, b *debug.Module) int { return strings.Compare(a.Path, b.Path) })
+	return built
+}
+
+// removeAliasedFindings when another finding lists them as an alias, keeping the entry from the
+// Go vulnerability database, since it lists the vulnerable symbols. Findings are sorted by module and ID.
+func removeAliasedFindings(findings []*Finding) []*Finding {
+	var kept []*Finding
+	for _, finding := range findings {
+		aliased := slices.ContainsFunc(findings, func(other *Finding) bool {
+			return other != finding && other.Module == finding.Module &&
+				strings.HasPrefix(other.ID, goEntryPrefix) && slices.Contains(other.Aliases, finding.ID)
+		})
+
+		if !aliased {
+			kept = append(kept, finding)
+		}
+	}
+
+	slices.SortFunc(kept, func(a, b *Finding) int {
+		if byModule := strings.Compare(a.Module, b.Module); byModule != 0 {
+			return byModule
+		}
+
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	return kept
+}
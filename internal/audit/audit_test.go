@@ -0,0 +1,417 @@
+package audit_test
+
+import (
+	"debug/elf"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/audit"
+	"github.com/JosiahWitt/lambgo/internal/builder"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+)
+
+func TestAudit(t *testing.T) {
+	ensure := ensure.New(t)
+
+	exampleError := errors.New("something went wrong")
+
+	// database entries, by their path relative to the database directory
+	type entries map[string]string
+
+	database := entries{
+		"index/db.json": `{"modified": "2024-05-06T07:08:09Z"}`,
+		"ID/GO-2024-0001.json": `{
+			"id": "GO-2024-0001",
+			"aliases": ["GHSA-aaaa-aaaa-aaaa"],
+			"summary": "Stack exhaustion when parsing in github.com/my/parser",
+			"affected": [{
+				"package": {"ecosystem": "Go", "name": "github.com/my/parser"},
+				"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.2.0"}, {"introduced": "1.3.0"}, {"fixed": "1.3.1"}]}],
+				"ecosystem_specific": {"imports": [{"path": "github.com/my/parser/v1", "symbols": ["Parse", "Decoder.Decode"]}]}
+			}]
+		}`,
+		"ID/GO-2024-0002.json": `{
+			"id": "GO-2024-0002",
+			"summary": "Panic when formatting in github.com/my/parser",
+			"affected": [{
+				"package": {"ecosystem": "Go", "name": "github.com/my/parser"},
+				"ranges": [{"type": "SEMVER", "events": [{"introduced": "1.0.0"}, {"last_affected": "1.1.0"}]}],
+				"ecosystem_specific": {"imports": [{"path": "github.com/my/parser/v1", "symbols": ["Format"]}]}
+			}]
+		}`,
+		"ID/GO-2024-0003.json": `{
+			"id": "GO-2024-0003",
+			"summary": "Request smuggling in net/http",
+			"affected": [{
+				"package": {"ecosystem": "Go", "name": "stdlib"},
+				"ranges": [{"type": "SEMVER", "events": [{"introduced": "1.23.0-0"}, {"fixed": "1.23.5"}]}],
+				"ecosystem_specific": {"imports": [{"path": "net/http"}]}
+			}]
+		}`,
+		"ID/GO-2024-0004.json": `{
+			"id": "GO-2024-0004",
+			"withdrawn": "2024-06-01T00:00:00Z",
+			"affected": [{
+				"package": {"ecosystem": "Go", "name": "github.com/my/parser"},
+				"versions": ["1.1.0"]
+			}]
+		}`,
+		"osv/GHSA-aaaa-aaaa-aaaa.json": `{
+			"id": "GHSA-aaaa-aaaa-aaaa",
+			"aliases": ["CVE-2024-0001"],
+			"affected": [{
+				"package": {"ecosystem": "Go", "name": "github.com/my/parser"},
+				"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.2.0"}]}]
+			}],
+			"database_specific": {"severity": "HIGH"}
+		}`,
+		"osv/GHSA-bbbb-bbbb-bbbb.json": `{
+			"id": "GHSA-bbbb-bbbb-bbbb",
+			"summary": "Secrets are logged by github.com/my/logger",
+			"affected": [{
+				"package": {"ecosystem": "Go", "name": "github.com/my/logger"},
+				"versions": ["v0.5.0"]
+			}],
+			"database_specific": {"severity": "MODERATE"}
+		}`,
+		"osv/GHSA-cccc-cccc-cccc.json": `{
+			"id": "GHSA-cccc-cccc-cccc",
+			"summary": "Vulnerable npm package",
+			"affected": [{"package": {"ecosystem": "npm", "name": "github.com/my/parser"}, "versions": ["1.1.0"]}],
+			"database_specific": {"severity": "CRITICAL"}
+		}`,
+		"README.md": "Not an entry",
+	}
+
+	parser := &debug.Module{Path: "github.com/my/parser", Version: "v1.1.0"}
+	logger := &debug.Module{Path: "github.com/my/logger", Version: "v0.4.0", Replace: &debug.Module{Path: "github.com/my/logger", Version: "v0.5.0"}}
+	localReplacement := &debug.Module{Path: "github.com/my/shared", Version: "v1.0.0", Replace: &debug.Module{Path: "../shared"}}
+
+	buildInfos := map[string]*debug.BuildInfo{
+		"out/lambdas/api": {
+			GoVersion: "go1.23.4",
+			Main:      debug.Module{Path: "github.com/my/app", Version: "(devel)"},
+			Deps:      []*debug.Module{parser, logger, localReplacement},
+		},
+		"out/lambdas/worker": {
+			GoVersion: "go1.23.5",
+			Main:      debug.Module{Path: "github.com/my/app", Version: "(devel)"},
+			Deps:      []*debug.Module{{Path: "github.com/my/parser", Version: "v1.2.0"}},
+		},
+		"out/layers/tools/cmd/converter": {
+			GoVersion: "go1.24rc1",
+			Main:      debug.Module{Path: "github.com/my/parser", Version: "v1.3.0"},
+		},
+	}
+
+	symbols := map[string][]string{
+		"out/lambdas/api": {
+			"main.main",
+			"github.com/my/parser/v1.(*Decoder).Decode.func1",
+			"github.com/my/parser/v1.Format[go.shape.string]",
+			"net/http.(*Client).Do",
+		},
+		"out/lambdas/worker": {"main.main"},
+		"out/layers/tools/cmd/converter": {
+			"github.com/my/parser/v1.Parse",
+			"github.com/my/parser/v1.ParseFile",
+		},
+	}
+
+	apiBinary := &builder.Binary{Kind: manifest.KindLambda, BuildPath: "lambdas/api"}
+	workerBinary := &builder.Binary{Kind: manifest.KindLambda, BuildPath: "lambdas/worker"}
+	converterBinary := &builder.Binary{Kind: manifest.KindLayer, BuildPath: "cmd/converter", Layer: "tools"}
+
+	config := &lambgofile.Config{
+		OutDirectory: "out",
+		Lambdas:      []*lambgofile.Lambda{{Path: "lambdas/api"}, {Path: "lambdas/worker"}},
+		Layers: []*lambgofile.Layer{
+			{Name: "tools", Packages: []*lambgofile.LayerPackage{{Lambda: lambgofile.Lambda{Path: "cmd/converter"}}}},
+		},
+	}
+
+	table := []struct {
+		Name            string
+		Database        entries
+		Built           []string
+		ReadBuildInfo   error
+		ReadSymbols     error
+		ExpectedReports []*audit.Report
+		ExpectedError   error
+	}{
+		{
+			Name:     "with vulnerabilities",
+			Database: database,
+			Built:    []string{"out/lambdas/api", "out/lambdas/worker", "out/layers/tools/cmd/converter"},
+			ExpectedReports: []*audit.Report{
+				{
+					Binary: apiBinary,
+					Findings: []*audit.Finding{
+						{
+							ID:        "GHSA-bbbb-bbbb-bbbb",
+							Summary:   "Secrets are logged by github.com/my/logger",
+							Severity:  audit.SeverityMedium,
+							Module:    "github.com/my/logger",
+							Version:   "v0.5.0",
+							Reachable: true,
+						},
+						{
+							ID:           "GO-2024-0001",
+							Aliases:      []string{"GHSA-aaaa-aaaa-aaaa"},
+							Summary:      "Stack exhaustion when parsing in github.com/my/parser",
+							Severity:     audit.SeverityHigh,
+							Module:       "github.com/my/parser",
+							Version:      "v1.1.0",
+							FixedVersion: "v1.2.0",
+							Symbols:      []string{"github.com/my/parser/v1.Decoder.Decode"},
+							Reachable:    true,
+						},
+						{
+							ID:        "GO-2024-0002",
+							Summary:   "Panic when formatting in github.com/my/parser",
+							Severity:  audit.SeverityUnknown,
+							Module:    "github.com/my/parser",
+							Version:   "v1.1.0",
+							Symbols:   []string{"github.com/my/parser/v1.Format"},
+							Reachable: true,
+						},
+						{
+							ID:           "GO-2024-0003",
+							Summary:      "Request smuggling in net/http",
+							Severity:     audit.SeverityUnknown,
+							Module:       "stdlib",
+							Version:      "v1.23.4",
+							FixedVersion: "v1.23.5",
+							Symbols:      []string{"net/http"},
+							Reachable:    true,
+						},
+					},
+				},
+				{
+					Binary: workerBinary,
+				},
+				{
+					Binary: converterBinary,
+					Findings: []*audit.Finding{
+						{
+							ID:           "GO-2024-0001",
+							Aliases:      []string{"GHSA-aaaa-aaaa-aaaa"},
+							Summary:      "Stack exhaustion when parsing in github.com/my/parser",
+							Severity:     audit.SeverityHigh,
+							Module:       "github.com/my/parser",
+							Version:      "v1.3.0",
+							FixedVersion: "v1.3.1",
+							Symbols:      []string{"github.com/my/parser/v1.Parse"},
+							Reachable:    true,
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name: "with vulnerable symbols that are not in the binary",
+			Database: entries{
+				"GO-2024-0005.json": `{
+					"id": "GO-2024-0005",
+					"affected": [{
+						"package": {"ecosystem": "Go", "name": "stdlib"},
+						"versions": ["1.23.4"],
+						"ecosystem_specific": {"imports": [{"path": "net/mail", "symbols": ["ParseAddress"]}, {"path": "net/smtp"}]}
+					}],
+					"database_specific": {"severity": "LOW"}
+				}`,
+			},
+			Built: []string{"out/lambdas/api", "out/lambdas/worker", "out/layers/tools/cmd/converter"},
+			ExpectedReports: []*audit.Report{
+				{
+					Binary: apiBinary,
+					Findings: []*audit.Finding{
+						{ID: "GO-2024-0005", Severity: audit.SeverityLow, Module: "stdlib", Version: "v1.23.4"},
+					},
+				},
+				{Binary: workerBinary},
+				{Binary: converterBinary},
+			},
+		},
+
+		{
+			Name: "with vulnerable symbols that may be inlined into other functions of their package",
+			Database: entries{
+				"GO-2024-0006.json": `{
+					"id": "GO-2024-0006",
+					"affected": [{
+						"package": {"ecosystem": "Go", "name": "stdlib"},
+						"versions": ["1.23.4"],
+						"ecosystem_specific": {"imports": [{"path": "net/http", "symbols": ["Server.Serve"]}, {"path": "net/smtp", "symbols": ["Dial"]}]}
+					}],
+					"database_specific": {"severity": "LOW"}
+				}`,
+			},
+			Built: []string{"out/lambdas/api", "out/lambdas/worker", "out/layers/tools/cmd/converter"},
+			ExpectedReports: []*audit.Report{
+				{
+					Binary: apiBinary,
+					Findings: []*audit.Finding{
+						{
+							ID:              "GO-2024-0006",
+							Severity:        audit.SeverityLow,
+							Module:          "stdlib",
+							Version:         "v1.23.4",
+							PossiblyInlined: []string{"net/http"},
+							Reachable:       true,
+						},
+					},
+				},
+				{Binary: workerBinary},
+				{Binary: converterBinary},
+			},
+		},
+
+		{
+			Name:          "when the database does not exist",
+			Built:         []string{"out/lambdas/api", "out/lambdas/worker", "out/layers/tools/cmd/converter"},
+			ExpectedError: audit.ErrCannotReadDatabase,
+		},
+
+		{
+			Name:          "with invalid database entry",
+			Database:      entries{"GO-2024-0001.json": `{"id": `},
+			Built:         []string{"out/lambdas/api", "out/lambdas/worker", "out/layers/tools/cmd/converter"},
+			ExpectedError: audit.ErrInvalidDatabaseEntry,
+		},
+
+		{
+			Name:          "when a binary has not been built",
+			Database:      database,
+			Built:         []string{"out/lambdas/api", "out/layers/tools/cmd/converter"},
+			ExpectedError: audit.ErrBinaryNotBuilt,
+		},
+
+		{
+			Name:          "when the build info cannot be read",
+			Database:      database,
+			Built:         []string{"out/lambdas/api", "out/lambdas/worker", "out/layers/tools/cmd/converter"},
+			ReadBuildInfo: exampleError,
+			ExpectedError: audit.ErrCannotReadBuildInfo,
+		},
+
+		{
+			Name:          "when the symbols cannot be read",
+			Database:      database,
+			Built:         []string{"out/lambdas/api", "out/lambdas/worker", "out/layers/tools/cmd/converter"},
+			ReadSymbols:   exampleError,
+			ExpectedError: audit.ErrCannotReadSymbols,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		rootPath := ensure.T().TempDir()
+		databasePath := filepath.Join(rootPath, "vulndb")
+		writeFiles(ensure, databasePath, entry.Database)
+
+		for _, path := range entry.Built {
+			writeFiles(ensure, rootPath, map[string]string{path: "binary"})
+		}
+
+		subject := &audit.Auditor{
+			ReadBuildInfo: func(path string) (*debug.BuildInfo, error) {
+				if entry.ReadBuildInfo != nil {
+					return nil, entry.ReadBuildInfo
+				}
+
+				return buildInfos[relativePath(ensure, rootPath, path)], nil
+			},
+			ReadSymbols: func(path string) ([]string, error) {
+				if entry.ReadSymbols != nil {
+					return nil, entry.ReadSymbols
+				}
+
+				return symbols[relativePath(ensure, rootPath, path)], nil
+			},
+		}
+
+		entryConfig := *config
+		entryConfig.RootPath = rootPath
+
+		reports, err := subject.Audit(&entryConfig, databasePath)
+		ensure(err).IsError(entry.ExpectedError)
+
+		for _, report := range entry.ExpectedReports {
+			outPath := filepath.Join(rootPath, "out", report.Binary.BuildPath)
+			if report.Binary.Layer != "" {
+				outPath = filepath.Join(rootPath, "out", "layers", report.Binary.Layer, report.Binary.BuildPath)
+			}
+
+			report.Binary.OutPath = outPath
+		}
+
+		ensure(reports).Equals(entry.ExpectedReports)
+	})
+}
+
+func TestAuditExecutable(t *testing.T) {
+	ensure := ensure.New(t)
+
+	executable, err := os.Executable()
+	ensure(err).IsNotError()
+
+	if file, err := elf.Open(executable); err != nil {
+		t.Skip("the test binary is not an ELF binary")
+	} else {
+		file.Close()
+	}
+
+	databasePath := t.TempDir()
+	writeFiles(ensure, databasePath, map[string]string{
+		"GO-2024-0001.json": `{
+			"id": "GO-2024-0001",
+			"affected": [{
+				"package": {"ecosystem": "Go", "name": "github.com/JosiahWitt/erk"},
+				"ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}]}],
+				"ecosystem_specific": {"imports": [{"path": "github.com/JosiahWitt/erk", "symbols": ["Error.Error", "WrapWith", "NotInTheBinary"]}]}
+			}],
+			"database_specific": {"severity": "CRITICAL"}
+		}`,
+	})
+
+	config := &lambgofile.Config{
+		RootPath:     filepath.Dir(executable),
+		OutDirectory: ".",
+		Lambdas:      []*lambgofile.Lambda{{Path: filepath.Base(executable)}},
+	}
+
+	subject := &audit.Auditor{}
+	reports, err := subject.Audit(config, databasePath)
+	ensure(err).IsNotError()
+	ensure(len(reports)).Equals(1)
+	ensure(len(reports[0].Findings)).Equals(1)
+
+	finding := reports[0].Findings[0]
+	ensure(finding.ID).Equals("GO-2024-0001")
+	ensure(finding.Severity).Equals(audit.SeverityCritical)
+	ensure(finding.Symbols).Equals([]string{"github.com/JosiahWitt/erk.Error.Error", "github.com/JosiahWitt/erk.WrapWith"})
+	ensure(finding.Reachable).IsTrue()
+}
+
+func writeFiles(ensure ensuring.E, dir string, files map[string]string) {
+	for path, contents := range files {
+		fullPath := filepath.Join(dir, path)
+		ensure(os.MkdirAll(filepath.Dir(fullPath), 0o755)).IsNotError()
+		ensure(os.WriteFile(fullPath, []byte(contents), 0o600)).IsNotError()
+	}
+}
+
+func relativePath(ensure ensuring.E, rootPath, path string) string {
+	relPath, err := filepath.Rel(rootPath, path)
+	ensure(err).IsNotError()
+	return relPath
+}
@@ -0,0 +1,309 @@
+package audit
+
+import (
+	"encoding/json"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"golang.org/x/mod/semver"
+)
+
+const (
+	// osvEcosystemGo is the ecosystem of Go modules and the standard library in OSV entries.
+	osvEcosystemGo = "Go"
+
+	// osvRangeSemver is the only type of range used by Go modules.
+	osvRangeSemver = "SEMVER"
+
+	// osvIndexDirectory of the Go vulnerability database contains indexes, instead of entries.
+	osvIndexDirectory = "index"
+
+	// goEntryPrefix is the prefix of the IDs of entries from the Go vulnerability database.
+	goEntryPrefix = "GO-"
+)
+
+// osvEntry is a vulnerability in the OSV format, as defined by https://ossf.github.io/osv-schema/.
+// Only the fields used for auditing Go binaries are included.
+type osvEntry struct {
+	ID               string           `json:"id"`
+	Aliases          []string         `json:"aliases"`
+	Summary          string           `json:"summary"`
+	Withdrawn        string           `json:"withdrawn"`
+	Affected         []*osvAffected   `json:"affected"`
+	DatabaseSpecific *osvDatabaseInfo `json:"database_specific"`
+}
+
+type osvDatabaseInfo struct {
+	// Severity is set by the GitHub Advisory Database, and is one of LOW, MODERATE, HIGH, or CRITICAL.
+	Severity string `json:"severity"`
+}
+
+type osvAffected struct {
+	Package           *osvPackage       `json:"package"`
+	Ranges            []*osvRange       `json:"ranges"`
+	Versions          []string          `json:"versions"`
+	EcosystemSpecific *osvGoEcosystemV1 `json:"ecosystem_specific"`
+}
+
+type osvPackage struct {
+	Ecosystem string `json:"ecosystem"`
+	Name      string `json:"name"`
+}
+
+type osvRange struct {
+	Type   string      `json:"type"`
+	Events []*osvEvent `json:"events"`
+}
+
+type osvEvent struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+}
+
+// osvGoEcosystemV1 lists the vulnerable packages and symbols within the module.
+type osvGoEcosystemV1 struct {
+	Imports []*osvImport `json:"imports"`
+}
+
+type osvImport struct {
+	Path    string   `json:"path"`
+	Symbols []string `json:"symbols"`
+}
+
+// database of OSV entries, indexed by the module they affect.
+type database struct {
+	byModule map[string][]*osvEntry
+	byID     map[string]*osvEntry
+}
+
+// loadDatabase from the JSON files in the directory, which can be an extracted copy of the
+// Go vulnerability database (https://vuln.go.dev), an OSV export (https://osv.dev), or both.
+func loadDatabase(dir string) (*database, error) {
+	db := &database{
+		byModule: make(map[string][]*osvEntry),
+		byID:     make(map[string]*osvEntry),
+	}
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			if path != dir && entry.Name() == osvIndexDirectory {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if filepath.Ext(path) != ".json" {
+			return nil
+		}
+
+		return db.loadEntry(path)
+	})
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadDatabase, err, erk.Params{"path": dir})
+	}
+
+	return db, nil
+}
+
+func (db *database) loadEntry(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	entry := &osvEntry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return erk.WrapWith(ErrInvalidDatabaseEntry, err, erk.Params{"path": path})
+	}
+
+	if entry.ID == "" || entry.Withdrawn != "" {
+		return nil
+	}
+
+	db.byID[entry.ID] = entry
+
+	var modules []string
+	for _, affected := range entry.Affected {
+		if affected.Package == nil || affected.Package.Ecosystem != osvEcosystemGo || slices.Contains(modules, affected.Package.Name) {
+			continue
+		}
+
+		modules = append(modules, affected.Package.Name)
+		db.byModule[affected.Package.Name] = append(db.byModule[affected.Package.Name], entry)
+	}
+
+	return nil
+}
+
+// severity of the entry, which is looked up from its aliases when it does not have one.
+// Entries from the Go vulnerability database do not include a severity, but the GitHub
+// Advisory Database entries they alias do.
+func (db *database) severity(entry *osvEntry) Severity {
+	if severity := entry.severity(); severity != SeverityUnknown {
+		return severity
+	}
+
+	for _, alias := range entry.Aliases {
+		if aliased, ok := db.byID[alias]; ok {
+			if severity := aliased.severity(); severity != SeverityUnknown {
+				return severity
+			}
+		}
+	}
+
+	return SeverityUnknown
+}
+
+func (entry *osvEntry) severity() Severity {
+	if entry.DatabaseSpecific == nil {
+		return SeverityUnknown
+	}
+
+	switch strings.ToUpper(entry.DatabaseSpecific.Severity) {
+	case "LOW":
+		return SeverityLow
+	case "MODERATE", "MEDIUM":
+		return SeverityMedium
+	case "HIGH":
+		return SeverityHigh
+	case "CRITICAL":
+		return SeverityCritical
+	default:
+		return SeverityUnknown
+	}
+}
+
+// affects returns the affected module when the version of the module is vulnerable.
+func (entry *osvEntry) affects(modulePath, version string) *osvAffected {
+	for _, affected := range entry.Affected {
+		if affected.Package == nil || affected.Package.Ecosystem != osvEcosystemGo || affected.Package.Name != modulePath {
+			continue
+		}
+
+		if affected.affectsVersion(version) {
+			return affected
+		}
+	}
+
+	return nil
+}
+
+func (affected *osvAffected) affectsVersion(version string) bool {
+	for _, affectedVersion := range affected.Versions {
+		if semver.Compare(canonicalVersion(affectedVersion), version) == 0 {
+			return true
+		}
+	}
+
+	for _, versionRange := range affected.Ranges {
+		if versionRange.Type == osvRangeSemver && versionRange.affectsVersion(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// affectsVersion evaluates the events in order of their versions, as required by the OSV schema.
+func (versionRange *osvRange) affectsVersion(version string) bool {
+	events := slices.Clone(versionRange.Events)
+	slices.SortStableFunc(events, func(a, b *osvEvent) int {
+		return semver.Compare(a.version(), b.version())
+	})
+
+	affected := false
+	for _, event := range events {
+		switch {
+		case event.Introduced != "":
+			if event.Introduced == "0" || semver.Compare(version, canonicalVersion(event.Introduced)) >= 0 {
+				affected = true
+			}
+		case event.Fixed != "":
+			if semver.Compare(version, canonicalVersion(event.Fixed)) >= 0 {
+				affected = false
+			}
+		case event.LastAffected != "":
+			if semver.Compare(version, canonicalVersion(event.LastAffected)) > 0 {
+				affected = false
+			}
+		}
+	}
+
+	return affected
+}
+
+func (event *osvEvent) version() string {
+	switch {
+	case event.Introduced == "0":
+		return "v0.0.0"
+	case event.Introduced != "":
+		return canonicalVersion(event.Introduced)
+	case event.Fixed != "":
+		return canonicalVersion(event.Fixed)
+	default:
+		return canonicalVersion(event.LastAffected)
+	}
+}
+
+// fixedVersion is the earliest version after the version that fixes the vulnerability, or empty when there is none.
+func (affected *osvAffected) fixedVersion(version string) string {
+	fixed := ""
+	for _, versionRange := range affected.Ranges {
+		for _, event := range versionRange.Events {
+			canonical := canonicalVersion(event.Fixed)
+			if event.Fixed == "" || semver.Compare(canonical, version) <= 0 {
+				continue
+			}
+
+			if fixed == "" || semver.Compare(canonical, fixed) < 0 {
+				fixed = canonical
+			}
+		}
+	}
+
+	return fixed
+}
+
+// canonicalVersion adds the v prefix required by semver, since OSV versions of Go modules do not include it.
+func canonicalVersion(version string) string {
+	if strings.HasPrefix(version, "v") {
+		return version
+	}
+
+	return "v" + version
+}
+
+// goSemver converts a Go release (eg. go1.23.4 or go1.24rc1) to semver, which is how the standard library
+// is versioned in OSV entries. It returns an empty string when the release cannot be converted.
+func goSemver(goVersion string) string {
+	version, _, _ := strings.Cut(strings.TrimPrefix(goVersion, "go"), " ")
+
+	prerelease := ""
+	for _, tag := range []string{"rc", "beta"} {
+		if before, after, ok := strings.Cut(version, tag); ok {
+			version, prerelease = before, "-"+tag+"."+after
+			break
+		}
+	}
+
+	if strings.Count(version, ".") == 1 {
+		version += ".0"
+	}
+
+	version = "v" + version + prerelease
+	if !semver.IsValid(version) {
+		return ""
+	}
+
+	return version
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"debug/elf"
+	"debug/gosym"
+	"errors"
+	"strings"
+)
+
+var errNoSymbolTable = errors.New("the binary does not contain a Go symbol table (.gopclntab)")
+
+// readSymbols returns the names of the functions in an ELF binary, from the table the Go runtime uses
+// for stack traces. It is kept when binaries are stripped, so it lists every function linked into the binary.
+func readSymbols(path string) ([]string, error) {
+	file, err := elf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	pclntab := file.Section(".gopclntab")
+	text := file.Section(".text")
+	if pclntab == nil || text == nil {
+		return nil, errNoSymbolTable
+	}
+
+	data, err := pclntab.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := gosym.NewTable(nil, gosym.NewLineTable(data, text.Addr))
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(table.Funcs))
+	for _, fn := range table.Funcs {
+		names = append(names, fn.Name)
+	}
+
+	return names, nil
+}
+
+// symbolIndex of the functions in a binary, by the import path of their package.
+type symbolIndex map[string][]string
+
+// newSymbolIndex from the function names, which are normalized to the form used by OSV entries.
+// For example, net/http.(*Client).do.func1 is indexed as Client.do.func1 in net/http.
+func newSymbolIndex(names []string) symbolIndex {
+	index := make(symbolIndex)
+	for _, name := range names {
+		packagePath, symbol, ok := splitSymbol(name)
+		if !ok {
+			continue
+		}
+
+		index[packagePath] = append(index[packagePath], symbol)
+	}
+
+	return index
+}
+
+// find the symbols of the package that are in the binary. The symbols are returned as they are listed in the
+// OSV entry, and all symbols of the package are returned when no symbols are listed, since the whole package
+// is vulnerable.
+func (index symbolIndex) find(packagePath string, symbols []string) []string {
+	binarySymbols := index[packagePath]
+	if len(symbols) == 0 {
+		if len(binarySymbols) == 0 {
+			return nil
+		}
+
+		return []string{packagePath}
+	}
+
+	var found []string
+	for _, symbol := range symbols {
+		for _, binarySymbol := range binarySymbols {
+			// Closures are named after the function containing them (eg. Parse.func1)
+			if binarySymbol == symbol || strings.HasPrefix(binarySymbol, symbol+".func") {
+				found = append(found, packagePath+"."+symbol)
+				break
+			}
+		}
+	}
+
+	return found
+}
+
+// contains any symbols of the package.
+func (index symbolIndex) contains(packagePath string) bool {
+	return len(index[packagePath]) > 0
+}
+
+// splitSymbol into its package path and symbol. The linker escapes dots in the last element
+// of the package path (eg. gopkg.in/yaml%2ev3), so the first dot after the last slash ends it.
+// Slashes within type arguments (eg. Map[github.com/my/app.Key]) are ignored.
+func splitSymbol(name string) (string, string, bool) {
+	beforeTypeArgs, _, _ := strings.Cut(name, "[")
+	lastSlash := strings.LastIndex(beforeTypeArgs, "/")
+	dot := strings.Index(name[lastSlash+1:], ".")
+	if dot < 0 {
+		return "", "", false
+	}
+
+	dot += lastSlash + 1
+	packagePath := strings.ReplaceAll(name[:dot], "%2e", ".")
+	return packagePath, normalizeSymbol(name[dot+1:]), true
+}
+
+// normalizeSymbol removes pointer receivers and type parameters, so (*Reader[...]).Read becomes Reader.Read.
+func normalizeSymbol(symbol string) string {
+	var normalized strings.Builder
+
+	depth := 0
+	for _, r := range symbol {
+		switch {
+		case r == '[':
+			depth++
+		case r == ']':
+			depth--
+		case depth > 0, r == '(', r == ')', r == '*':
+		default:
+			normalized.WriteRune(r)
+		}
+	}
+
+	return normalized.String()
+}
@@ -0,0 +1,216 @@
+// Package binsize breaks down the size of built binaries by section, Go package, and symbol.
+package binsize
+
+import (
+	"debug/elf"
+	"debug/gosym"
+	"errors"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+)
+
+type ErkCannotAnalyze struct{ erk.DefaultKind }
+
+var (
+	ErrBinaryNotBuilt = erk.New(ErkCannotAnalyze{}, "Cannot find the binary '{{.path}}'. Run `lambgo build` first.")
+	ErrCannotAnalyze  = erk.New(ErkCannotAnalyze{}, "Cannot read the symbols of '{{.path}}': {{.err}}")
+)
+
+// MetadataPackage contains the symbols that the linker generates for the runtime,
+// such as type descriptors (type:*) and function metadata (go:func.*), which do not belong to a package.
+const MetadataPackage = "(runtime metadata)"
+
+var errNoSymbols = errors.New("the binary does not contain a symbol table (.symtab) or a Go symbol table (.gopclntab)")
+
+type AnalyzerAPI interface {
+	Analyze(path string) (*Analysis, error)
+}
+
+// Analyzer reads the sizes of the symbols in ELF binaries.
+type Analyzer struct{}
+
+var _ AnalyzerAPI = &Analyzer{}
+
+// Analysis of the size of a binary.
+type Analysis struct {
+	Path     string
+	FileSize int64
+
+	// Stripped is true when the binary does not have an ELF symbol table (eg. it was built with -ldflags=-s),
+	// so only the sizes of functions are known, from the table the Go runtime uses for stack traces.
+	Stripped bool
+
+	// Sections, Packages, and Symbols are sorted from largest to smallest.
+	Sections []*Size
+	Packages []*Size
+	Symbols  []*Symbol
+}
+
+// Size of a section or package in the binary.
+type Size struct {
+	Name string
+	Size int64
+}
+
+// Symbol is a function or variable in the binary.
+type Symbol struct {
+	Name    string
+	Package string
+	Size    int64
+}
+
+// Analyze the binary at path. Only sections and symbols stored in the file are counted, so zero-initialized
+// variables (eg. in .bss) are skipped, since they do not take up any space until the binary is run.
+func (a *Analyzer) Analyze(path string) (*Analysis, error) {
+	stat, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, erk.WithParams(ErrBinaryNotBuilt, erk.Params{"path": path})
+	}
+
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotAnalyze, err, erk.Params{"path": path})
+	}
+
+	file, err := elf.Open(path)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotAnalyze, err, erk.Params{"path": path})
+	}
+	defer file.Close()
+
+	analysis := &Analysis{Path: path, FileSize: stat.Size()}
+	for _, section := range file.Sections {
+		if section.Type != elf.SHT_NOBITS && section.Size > 0 {
+			analysis.Sections = append(analysis.Sections, &Size{Name: section.Name, Size: int64(section.Size)}) //nolint:gosec // Sections are smaller than the file
+		}
+	}
+
+	symbols, err := readSymbols(file)
+	if errors.Is(err, elf.ErrNoSymbols) {
+		analysis.Stripped = true
+		symbols, err = readFunctions(file)
+	}
+
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotAnalyze, err, erk.Params{"path": path})
+	}
+
+	analysis.Symbols = symbols
+	analysis.Packages = packageSizes(symbols)
+
+	sortSizes(analysis.Sections)
+	slices.SortStableFunc(analysis.Symbols, func(a, b *Symbol) int {
+		return compareSizes(a.Size, b.Size, a.Name, b.Name)
+	})
+
+	return analysis, nil
+}
+
+// readSymbols from the ELF symbol table, which includes functions and variables.
+func readSymbols(file *elf.File) ([]*Symbol, error) {
+	elfSymbols, err := file.Symbols()
+	if err != nil {
+		return nil, err
+	}
+
+	var symbols []*Symbol
+	for _, elfSymbol := range elfSymbols {
+		symbolType := elf.ST_TYPE(elfSymbol.Info)
+		if elfSymbol.Size == 0 || (symbolType != elf.STT_FUNC && symbolType != elf.STT_OBJECT) {
+			continue
+		}
+
+		if int(elfSymbol.Section) >= len(file.Sections) || file.Sections[elfSymbol.Section].Type == elf.SHT_NOBITS {
+			continue
+		}
+
+		symbols = append(symbols, newSymbol(elfSymbol.Name, int64(elfSymbol.Size))) //nolint:gosec // Symbols are smaller than the file
+	}
+
+	return symbols, nil
+}
+
+// readFunctions from the table the Go runtime uses for stack traces, which is kept when binaries are stripped.
+func readFunctions(file *elf.File) ([]*Symbol, error) {
+	pclntab := file.Section(".gopclntab")
+	text := file.Section(".text")
+	if pclntab == nil || text == nil {
+		return nil, errNoSymbols
+	}
+
+	data, err := pclntab.Data()
+	if err != nil {
+		return nil, err
+	}
+
+	table, err := gosym.NewTable(nil, gosym.NewLineTable(data, text.Addr))
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make([]*Symbol, 0, len(table.Funcs))
+	for _, fn := range table.Funcs {
+		symbols = append(symbols, newSymbol(fn.Name, int64(fn.End-fn.Entry))) //nolint:gosec // Functions are smaller than the file
+	}
+
+	return symbols, nil
+}
+
+func newSymbol(name string, size int64) *Symbol {
+	return &Symbol{Name: name, Package: packageOf(name), Size: size}
+}
+
+// packageOf the symbol. The linker escapes dots in the last element of the package path
+// (eg. gopkg.in/yaml%2ev3), so the first dot after the last slash ends it.
+// Slashes within type arguments (eg. Map[github.com/my/app.Key]) are ignored.
+func packageOf(name string) string {
+	if strings.HasPrefix(name, "type:") || strings.HasPrefix(name, "go:") {
+		return MetadataPackage
+	}
+
+	beforeTypeArgs, _, _ := strings.Cut(name, "[")
+	lastSlash := strings.LastIndex(beforeTypeArgs, "/")
+	dot := strings.Index(name[lastSlash+1:], ".")
+	if dot < 0 {
+		return MetadataPackage
+	}
+
+	return strings.ReplaceAll(name[:lastSlash+1+dot], "%2e", ".")
+}
+
+func packageSizes(symbols []*Symbol) []*Size {
+	sizes := make(map[string]int64)
+	for _, symbol := range symbols {
+		sizes[symbol.Package] += symbol.Size
+	}
+
+	packages := make([]*Size, 0, len(sizes))
+	for name, size := range sizes {
+		packages = append(packages, &Size{Name: name, Size: size})
+	}
+
+	sortSizes(packages)
+	return packages
+}
+
+func sortSizes(sizes []*Size) {
+	slices.SortStableFunc(sizes, func(a, b *Size) int {
+		return compareSizes(a.Size, b.Size, a.Name, b.Name)
+	})
+}
+
+// compareSizes from largest to smallest, and then by name, so the order is stable.
+func compareSizes(aSize, bSize int64, aName, bName string) int {
+	if aSize != bSize {
+		if aSize > bSize {
+			return -1
+		}
+
+		return 1
+	}
+
+	return strings.Compare(aName, bName)
+}
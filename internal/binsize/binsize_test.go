@@ -0,0 +1,88 @@
+package binsize_test
+
+import (
+	"debug/elf"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/binsize"
+)
+
+func TestAnalyze(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("when the binary is missing", func(ensure ensuring.E) {
+		path := filepath.Join(ensure.T().TempDir(), "missing")
+
+		subject := &binsize.Analyzer{}
+		analysis, err := subject.Analyze(path)
+		ensure(err).IsError(binsize.ErrBinaryNotBuilt)
+		ensure(analysis).IsNil()
+	})
+
+	ensure.Run("when the binary is not an ELF binary", func(ensure ensuring.E) {
+		path := filepath.Join(ensure.T().TempDir(), "bootstrap")
+		ensure(os.WriteFile(path, []byte("#!/bin/sh\n"), 0o600)).IsNotError()
+
+		subject := &binsize.Analyzer{}
+		analysis, err := subject.Analyze(path)
+		ensure(err).IsError(binsize.ErrCannotAnalyze)
+		ensure(analysis).IsNil()
+	})
+}
+
+func TestAnalyzeExecutable(t *testing.T) {
+	ensure := ensure.New(t)
+
+	executable, err := os.Executable()
+	ensure(err).IsNotError()
+
+	if file, err := elf.Open(executable); err != nil {
+		t.Skip("the test binary is not an ELF binary")
+	} else {
+		file.Close()
+	}
+
+	stat, err := os.Stat(executable)
+	ensure(err).IsNotError()
+
+	subject := &binsize.Analyzer{}
+	analysis, err := subject.Analyze(executable)
+	ensure(err).IsNotError()
+	ensure(analysis.Path).Equals(executable)
+	ensure(analysis.FileSize).Equals(stat.Size())
+
+	// go test links test binaries without the ELF symbol table, so the functions are read from the Go symbol table
+	if analysis.Stripped {
+		ensure(slices.ContainsFunc(analysis.Sections, func(section *binsize.Size) bool { return section.Name == ".symtab" })).IsFalse()
+	}
+
+	ensure(slices.ContainsFunc(analysis.Sections, func(section *binsize.Size) bool { return section.Name == ".text" })).IsTrue()
+	ensure(slices.ContainsFunc(analysis.Sections, func(section *binsize.Size) bool { return section.Name == ".bss" })).IsFalse()
+
+	packageIndex := slices.IndexFunc(analysis.Packages, func(pkg *binsize.Size) bool { return pkg.Name == "github.com/JosiahWitt/erk" })
+	ensure(packageIndex >= 0).IsTrue()
+
+	symbolIndex := slices.IndexFunc(analysis.Symbols, func(symbol *binsize.Symbol) bool { return symbol.Name == "github.com/JosiahWitt/erk.WrapWith" })
+	ensure(symbolIndex >= 0).IsTrue()
+	ensure(analysis.Symbols[symbolIndex].Package).Equals("github.com/JosiahWitt/erk")
+
+	var symbolsSize, packagesSize int64
+	for _, symbol := range analysis.Symbols {
+		symbolsSize += symbol.Size
+	}
+
+	for _, pkg := range analysis.Packages {
+		packagesSize += pkg.Size
+	}
+
+	ensure(packagesSize).Equals(symbolsSize)
+	ensure(packagesSize <= analysis.FileSize).IsTrue()
+
+	ensure(slices.IsSortedFunc(analysis.Packages, func(a, b *binsize.Size) int { return int(b.Size - a.Size) })).IsTrue()
+	ensure(slices.IsSortedFunc(analysis.Symbols, func(a, b *binsize.Symbol) int { return int(b.Size - a.Size) })).IsTrue()
+}
@@ -0,0 +1,102 @@
+package binsize
+
+import (
+	"slices"
+	"strings"
+)
+
+// Comparison of the sizes of a binary between two builds.
+type Comparison struct {
+	Before *Analysis
+	After  *Analysis
+
+	// Packages and Symbols whose size changed, sorted by the largest change in either direction.
+	// Packages and symbols that were added or removed have a size of zero in the other build.
+	Packages []*Change
+	Symbols  []*Change
+}
+
+// Change in the size of a package or symbol between two builds.
+type Change struct {
+	Name   string
+	Before int64
+	After  int64
+}
+
+// Delta is the number of bytes added to the package or symbol, which is negative when it shrank.
+func (c *Change) Delta() int64 {
+	return c.After - c.Before
+}
+
+// Compare the analyses of two builds of a binary.
+func Compare(before, after *Analysis) *Comparison {
+	beforeSymbols := make([]*Size, 0, len(before.Symbols))
+	for _, symbol := range before.Symbols {
+		beforeSymbols = append(beforeSymbols, &Size{Name: symbol.Name, Size: symbol.Size})
+	}
+
+	afterSymbols := make([]*Size, 0, len(after.Symbols))
+	for _, symbol := range after.Symbols {
+		afterSymbols = append(afterSymbols, &Size{Name: symbol.Name, Size: symbol.Size})
+	}
+
+	return &Comparison{
+		Before:   before,
+		After:    after,
+		Packages: changes(before.Packages, after.Packages),
+		Symbols:  changes(beforeSymbols, afterSymbols),
+	}
+}
+
+// changes between the sizes. Sizes with the same name are summed, since some symbols (eg. static
+// functions from cgo) can appear more than once.
+func changes(before, after []*Size) []*Change {
+	byName := make(map[string]*Change)
+	changeFor := func(name string) *Change {
+		change, ok := byName[name]
+		if !ok {
+			change = &Change{Name: name}
+			byName[name] = change
+		}
+
+		return change
+	}
+
+	for _, size := range before {
+		changeFor(size.Name).Before += size.Size
+	}
+
+	for _, size := range after {
+		changeFor(size.Name).After += size.Size
+	}
+
+	changed := make([]*Change, 0, len(byName))
+	for _, change := range byName {
+		if change.Delta() != 0 {
+			changed = append(changed, change)
+		}
+	}
+
+	slices.SortFunc(changed, func(a, b *Change) int {
+		aDelta, bDelta := abs(a.Delta()), abs(b.Delta())
+		if aDelta != bDelta {
+			if aDelta > bDelta {
+				return -1
+			}
+
+			return 1
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return changed
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
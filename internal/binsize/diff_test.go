@@ -0,0 +1,61 @@
+package binsize_test
+
+import (
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/lambgo/internal/binsize"
+)
+
+func TestCompare(t *testing.T) {
+	ensure := ensure.New(t)
+
+	before := &binsize.Analysis{
+		FileSize: 1000,
+		Packages: []*binsize.Size{
+			{Name: "runtime", Size: 500},
+			{Name: "encoding/json", Size: 200},
+			{Name: "github.com/my/app", Size: 100},
+		},
+		Symbols: []*binsize.Symbol{
+			{Name: "runtime.main", Package: "runtime", Size: 500},
+			{Name: "encoding/json.Marshal", Package: "encoding/json", Size: 200},
+			{Name: "github.com/my/app.main", Package: "github.com/my/app", Size: 100},
+		},
+	}
+
+	after := &binsize.Analysis{
+		FileSize: 1200,
+		Packages: []*binsize.Size{
+			{Name: "runtime", Size: 500},
+			{Name: "github.com/my/app", Size: 150},
+			{Name: "github.com/my/yaml", Size: 300},
+		},
+		Symbols: []*binsize.Symbol{
+			{Name: "runtime.main", Package: "runtime", Size: 500},
+			{Name: "github.com/my/yaml.Unmarshal", Package: "github.com/my/yaml", Size: 200},
+			{Name: "github.com/my/yaml.static", Package: "github.com/my/yaml", Size: 50},
+			{Name: "github.com/my/yaml.static", Package: "github.com/my/yaml", Size: 50},
+			{Name: "github.com/my/app.main", Package: "github.com/my/app", Size: 150},
+		},
+	}
+
+	comparison := binsize.Compare(before, after)
+	ensure(comparison).Equals(&binsize.Comparison{
+		Before: before,
+		After:  after,
+		Packages: []*binsize.Change{
+			{Name: "github.com/my/yaml", Before: 0, After: 300},
+			{Name: "encoding/json", Before: 200, After: 0},
+			{Name: "github.com/my/app", Before: 100, After: 150},
+		},
+		Symbols: []*binsize.Change{
+			{Name: "encoding/json.Marshal", Before: 200, After: 0},
+			{Name: "github.com/my/yaml.Unmarshal", Before: 0, After: 200},
+			{Name: "github.com/my/yaml.static", Before: 0, After: 100},
+			{Name: "github.com/my/app.main", Before: 100, After: 150},
+		},
+	})
+
+	ensure(comparison.Packages[1].Delta()).Equals(int64(-200))
+}
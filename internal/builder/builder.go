@@ -2,13 +2,25 @@ package builder
 
 import (
 	"log"
+	"path"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/JosiahWitt/erk"
 	"github.com/JosiahWitt/erk/erg"
+	"github.com/JosiahWitt/lambgo/internal/binsize"
+	"github.com/JosiahWitt/lambgo/internal/buildtimes"
 	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/licenses"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/JosiahWitt/lambgo/internal/ociimage"
 	"github.com/JosiahWitt/lambgo/internal/runcmd"
+	"github.com/JosiahWitt/lambgo/internal/sbom"
+	"github.com/JosiahWitt/lambgo/internal/signing"
+	"github.com/JosiahWitt/lambgo/internal/versioninfo"
 	"github.com/JosiahWitt/lambgo/internal/zipper"
 )
 
@@ -22,57 +34,135 @@ var (
 
 	ErrGoBuildDependenciesFailed = erk.New(ErkBuildError{}, "Unable to build dependencies for all Lambdas with `go build`: {{.err}}")
 
-	ErrGoBuildFailed = erk.New(ErkBuildError{}, "Unable to build '{{.buildPath}}' with `go build`: {{.err}}")
-	ErrZipFailed     = erk.New(ErkBuildError{}, "Unable to zip '{{.buildPath}}' to '{{.buildPath}}.zip': {{.err}}")
+	ErrGoBuildFailed  = erk.New(ErkBuildError{}, "Unable to build '{{.buildPath}}' with `go build`: {{.err}}")
+	ErrZipFailed      = erk.New(ErkBuildError{}, "Unable to zip '{{.buildPath}}' to '{{.buildPath}}.zip': {{.err}}")
+	ErrImageFailed    = erk.New(ErkBuildError{}, "Unable to write '{{.buildPath}}' as an OCI image to '{{.imagePath}}': {{.err}}")
+	ErrSBOMFailed     = erk.New(ErkBuildError{}, "Unable to write the SBOM for '{{.buildPath}}' to '{{.sbomPath}}': {{.err}}")
+	ErrLicensesFailed = erk.New(ErkBuildError{}, "Unable to collect the licenses for '{{.buildPath}}': {{.err}}")
+	ErrSigningFailed  = erk.New(ErkBuildError{}, "Unable to sign '{{.buildPath}}.zip': {{.err}}")
+	ErrSizeFailed     = erk.New(ErkBuildError{}, "Unable to analyze the size of '{{.buildPath}}': {{.err}}")
+
+	ErrLayerGlobFailed       = erk.New(ErkBuildError{}, "Unable to find files matching '{{.glob}}' for layer '{{.layer}}': {{.err}}")
+	ErrLayerGlobNoMatches    = erk.New(ErkBuildError{}, "No files match '{{.glob}}' for layer '{{.layer}}'")
+	ErrLayerZipFailed        = erk.New(ErkBuildError{}, "Unable to zip layer '{{.layer}}' to '{{.zipPath}}': {{.err}}")
+	ErrLayerSBOMFailed       = erk.New(ErkBuildError{}, "Unable to write the SBOM for layer '{{.layer}}' to '{{.sbomPath}}': {{.err}}")
+	ErrLayerLicensesFailed   = erk.New(ErkBuildError{}, "Unable to collect the licenses for layer '{{.layer}}': {{.err}}")
+	ErrLayerSigningFailed    = erk.New(ErkBuildError{}, "Unable to sign layer '{{.layer}}' at '{{.zipPath}}': {{.err}}")
+	ErrManifestWriteFailed   = erk.New(ErkBuildError{}, "Unable to update the build manifest '{{.path}}': {{.err}}")
+	ErrBuildTimesWriteFailed = erk.New(ErkBuildError{}, "Unable to update the build times '{{.path}}': {{.err}}")
+	ErrVersionInfoFailed     = erk.New(ErkBuildError{}, "Unable to read the values for versionInjection: {{.err}}")
+)
+
+const (
+	// extensionsDirectory is where Lambda looks for external extensions within a layer.
+	extensionsDirectory = "extensions"
+
+	// hostDirectory within the outDirectory is where Lambdas are built for the host, so they can be run locally.
+	hostDirectory = "host"
 )
 
 type LambdaBuilderAPI interface {
 	BuildBinaries(config *lambgofile.Config) error
+	BuildForHost(config *lambgofile.Config, lambda *lambgofile.Lambda) (string, error)
 }
 
 type LambdaBuilder struct {
-	Cmd    runcmd.RunnerAPI
-	Zip    zipper.ZipAPI
-	Logger *log.Logger
+	Cmd         runcmd.RunnerAPI
+	Zip         zipper.ZipAPI
+	Image       ociimage.WriterAPI
+	Manifest    manifest.StoreAPI
+	SBOM        sbom.WriterAPI
+	Licenses    licenses.CollectorAPI
+	Signer      signing.SignerAPI
+	VersionInfo versioninfo.ReaderAPI
+	Logger      *log.Logger
+
+	// NumCPU is split between the concurrent `go build` commands with -p. They use all the CPUs when it is zero.
+	NumCPU int
+
+	// AvailableMemory returns the bytes of memory available for building, and whether it is known.
+	// The number of concurrent builds is not limited by memory when it is nil.
+	AvailableMemory func() (uint64, bool)
+
+	// BuildTimes records how long each `go build` took, so the slowest targets are built first next time.
+	// Targets are built in the order of the config when it is nil.
+	BuildTimes buildtimes.StoreAPI
+
+	// Now is used to time each `go build`, which is recorded in BuildTimes. Defaults to time.Now.
+	Now func() time.Time
+
+	// SizeAnalyzer breaks down the size of each binary, which is recorded in the manifest for `lambgo size --diff`.
+	// Sizes are not recorded when it is nil.
+	SizeAnalyzer binsize.AnalyzerAPI
+
+	// Sequential runs one command at a time, so the output of each Lambda stays together (eg. for dry runs).
+	// The -p flag and the memory limit are still computed for the concurrent builds, so the commands are the same.
+	Sequential bool
 }
 
 var _ LambdaBuilderAPI = &LambdaBuilder{}
 
 // BuildBinaries defined in the config.
 func (b *LambdaBuilder) BuildBinaries(config *lambgofile.Config) error {
-	if config.OutDirectory == "" {
-		config.OutDirectory = "tmp"
+	setDefaultOutDirectory(config)
+
+	versionInfo, err := b.readVersionInfo(config)
+	if err != nil {
+		return err
 	}
 
 	sharedParams := &sharedBuilderParams{
-		config: config,
-		errors: erg.NewAs(ErrMultipleBuildFailures),
+		config:      config,
+		versionInfo: versionInfo,
+		errors:      erg.NewAs(ErrMultipleBuildFailures),
+		durations:   make(map[string]time.Duration),
 	}
 
-	b.Logger.Println("Building Lambda Dependencies...")
-	if err := b.buildDependencies(config); err != nil {
-		return err
+	targets := buildTargets(config)
+	b.scheduleTargets(config, targets)
+
+	// NumParallel may have been computed for more targets than the config contains
+	// (eg. when rebuilding the affected Lambdas in watch mode), so it is clamped
+	numParallel := min(max(config.NumParallel, 1), max(len(targets), 1))
+	numParallel = b.limitByMemory(numParallel)
+	sharedParams.procsFlag = b.procsFlag(numParallel)
+
+	var grouped map[*buildTarget]bool
+	if config.GroupBuilds {
+		grouped = b.buildGroups(sharedParams, targets, numParallel)
+	}
+
+	// Targets of failed groups are not included, since building their dependencies would fail without reporting which target failed
+	ungrouped := slices.DeleteFunc(slices.Clone(targets), func(target *buildTarget) bool {
+		_, ok := grouped[target]
+		return ok
+	})
+	if !config.GroupBuilds || len(ungrouped) > 1 {
+		b.Logger.Println("Building Lambda Dependencies...")
+		if err := b.buildDependencies(config, ungrouped); err != nil {
+			return err
+		}
 	}
 
 	ch := make(chan *builderParams)
-	for range config.NumParallel {
+	for range b.numWorkers(numParallel) {
 		go b.launchBuilder(ch)
 	}
 
 	b.Logger.Println()
-	if len(config.Lambdas) == 1 {
+	if len(targets) == 1 {
 		b.Logger.Println("Building 1 Lambda")
-	} else if config.NumParallel == 1 {
-		b.Logger.Printf("Building %d Lambdas one at a time:\n", len(config.Lambdas))
-	} else if config.NumParallel == len(config.Lambdas) {
-		b.Logger.Printf("Building %d Lambdas all at once:\n", len(config.Lambdas))
+	} else if b.numWorkers(numParallel) == 1 {
+		b.Logger.Printf("Building %d Lambdas one at a time:\n", len(targets))
+	} else if numParallel == len(targets) {
+		b.Logger.Printf("Building %d Lambdas all at once:\n", len(targets))
 	} else {
-		b.Logger.Printf("Building %d Lambdas in parallel groups of %d:\n", len(config.Lambdas), config.NumParallel)
+		b.Logger.Printf("Building %d Lambdas in parallel groups of %d:\n", len(targets), numParallel)
 	}
 
-	for _, lambda := range config.Lambdas {
+	for _, target := range targets {
 		sharedParams.wg.Add(1)
-		ch <- &builderParams{lambda: lambda, sharedBuilderParams: sharedParams}
+		ch <- &builderParams{buildTarget: target, sharedBuilderParams: sharedParams, built: grouped[target]}
 	}
 
 	sharedParams.wg.Wait()
@@ -82,39 +172,200 @@ func (b *LambdaBuilder) BuildBinaries(config *lambgofile.Config) error {
 		return sharedParams.errors
 	}
 
-	return nil
+	artifacts := sharedParams.artifacts
+	for _, layer := range config.Layers {
+		artifact, err := b.zipLayer(config, layer)
+		if err != nil {
+			return err
+		}
+
+		artifacts = append(artifacts, artifact)
+	}
+
+	manifest.SortArtifacts(artifacts)
+	manifestPath := manifest.PathFor(config.OutDirectory)
+	if err := b.Manifest.Update(manifestPath, artifacts); err != nil {
+		return erk.WrapWith(ErrManifestWriteFailed, err, erk.Params{
+			"path": manifestPath,
+		})
+	}
+
+	return b.updateBuildTimes(config, sharedParams.durations)
 }
 
-func (b *LambdaBuilder) buildDependencies(config *lambgofile.Config) error {
-	// Skip building dependencies when there is only one Lambda, otherwise it will
-	// build the executable instead of only populating the build cache
-	if len(config.Lambdas) < 2 { //nolint:mnd
-		return nil
+// BuildForHost builds the Lambda for the host's operating system and architecture, so it can be run locally.
+// It returns the absolute path to the binary, which is not zipped.
+func (b *LambdaBuilder) BuildForHost(config *lambgofile.Config, lambda *lambgofile.Lambda) (string, error) {
+	setDefaultOutDirectory(config)
+
+	outPath := filepath.Join(config.OutDirectory, hostDirectory, lambda.Path)
+	if !filepath.IsAbs(outPath) {
+		outPath = filepath.Join(config.RootPath, outPath)
 	}
 
-	buildPaths := make([]string, 0, len(config.Lambdas))
-	for _, lambda := range config.Lambdas {
-		buildPaths = append(buildPaths, "./"+lambda.Path)
+	versionInfo, err := b.readVersionInfo(config)
+	if err != nil {
+		return "", err
 	}
 
-	_, err := b.Cmd.Exec(&runcmd.ExecParams{
-		PWD:  config.RootPath,
+	envVars := buildEnvVars(config)
+	envVars["GOOS"] = runtime.GOOS
+	envVars["GOARCH"] = runtime.GOARCH
+
+	pwd, args := goBuildArgs(config, versionInfo, outPath, lambda)
+	_, err = b.Cmd.Exec(&runcmd.ExecParams{
+		PWD:  pwd,
 		CMD:  "go",
-		Args: append([]string{"build", "-trimpath"}, buildPaths...),
+		Args: args,
 
-		EnvVars: buildEnvVars(config),
+		EnvVars: envVars,
 	})
 	if err != nil {
-		return erk.WrapAs(ErrGoBuildDependenciesFailed, err)
+		return "", erk.WrapWith(ErrGoBuildFailed, err, erk.Params{
+			"buildPath": lambda.Path,
+		})
+	}
+
+	return outPath, nil
+}
+
+func (b *LambdaBuilder) buildDependencies(config *lambgofile.Config, targets []*buildTarget) error {
+	// Skip building dependencies when there is only one Lambda, otherwise it will
+	// build the executable instead of only populating the build cache
+	if len(targets) < 2 { //nolint:mnd
+		return nil
+	}
+
+	// In a workspace, packages are built from the directory of their module, so they are grouped by module
+	var pwds []string
+	buildPathsByPWD := make(map[string][]string)
+	for _, target := range targets {
+		pwd, buildPath := goPackage(config, target.lambda)
+		if _, ok := buildPathsByPWD[pwd]; !ok {
+			pwds = append(pwds, pwd)
+		}
+
+		buildPathsByPWD[pwd] = append(buildPathsByPWD[pwd], buildPath)
+	}
+
+	for _, pwd := range pwds {
+		buildPaths := buildPathsByPWD[pwd]
+		if len(buildPaths) < 2 { //nolint:mnd
+			continue
+		}
+
+		_, err := b.Cmd.Exec(&runcmd.ExecParams{
+			PWD:  pwd,
+			CMD:  "go",
+			Args: append([]string{"build", "-trimpath"}, buildPaths...),
+
+			EnvVars: buildEnvVars(config),
+		})
+		if err != nil {
+			return erk.WrapAs(ErrGoBuildDependenciesFailed, err)
+		}
 	}
 
 	return nil
 }
 
+// buildTarget is a main package to build.
+// Lambdas, extensions, and layer packages are all built as targets.
+type buildTarget struct {
+	lambda  *lambgofile.Lambda
+	outPath string
+
+	// zippedFileName is the name of the binary within its zip.
+	// It is empty for layer packages, since they are zipped with the rest of their layer.
+	zippedFileName string
+	kind           manifest.ArtifactKind
+
+	// layer containing the package, which is nil for Lambdas and extensions.
+	layer *lambgofile.Layer
+}
+
+func buildTargets(config *lambgofile.Config) []*buildTarget {
+	targets := make([]*buildTarget, 0, len(config.Lambdas)+len(config.Extensions))
+
+	for _, lambda := range config.Lambdas {
+		zippedFileName := filepath.Base(lambda.Path)
+		if config.ZippedFileName != "" {
+			zippedFileName = config.ZippedFileName
+		}
+
+		targets = append(targets, &buildTarget{
+			lambda:         lambda,
+			outPath:        buildOutPath(config, lambda.Path),
+			zippedFileName: zippedFileName,
+			kind:           manifest.KindLambda,
+		})
+	}
+
+	for _, extension := range config.Extensions {
+		targets = append(targets, &buildTarget{
+			lambda:         &extension.Lambda,
+			outPath:        buildOutPath(config, extension.Path),
+			zippedFileName: path.Join(extensionsDirectory, extension.Name),
+			kind:           manifest.KindExtension,
+		})
+	}
+
+	for _, layer := range config.Layers {
+		for _, layerPackage := range layer.Packages {
+			targets = append(targets, &buildTarget{
+				lambda:  &layerPackage.Lambda,
+				outPath: layerPackageOutPath(config, layer, layerPackage),
+				kind:    manifest.KindLayer,
+				layer:   layer,
+			})
+		}
+	}
+
+	return targets
+}
+
+// Binary built for a Lambda, extension, or layer package.
+type Binary struct {
+	Kind manifest.ArtifactKind
+
+	// BuildPath of the main package, relative to the root of the module.
+	BuildPath string
+
+	// Layer containing the package, which is empty for Lambdas and extensions.
+	Layer string
+
+	// OutPath of the built binary, which is absolute.
+	OutPath string
+}
+
+// Binaries built by BuildBinaries for the config, in the order of the config.
+func Binaries(config *lambgofile.Config) []*Binary {
+	setDefaultOutDirectory(config)
+
+	targets := buildTargets(config)
+	binaries := make([]*Binary, 0, len(targets))
+	for _, target := range targets {
+		binary := &Binary{Kind: target.kind, BuildPath: target.lambda.Path, OutPath: target.outPath}
+		if !filepath.IsAbs(binary.OutPath) {
+			binary.OutPath = filepath.Join(config.RootPath, binary.OutPath)
+		}
+
+		if target.layer != nil {
+			binary.Layer = target.layer.Name
+		}
+
+		binaries = append(binaries, binary)
+	}
+
+	return binaries
+}
+
 type builderParams struct {
 	*sharedBuilderParams
+	*buildTarget
 
-	lambda *lambgofile.Lambda
+	// built is true when the binary was already built with its group, so it only needs to be packaged.
+	built bool
 }
 
 func (b *LambdaBuilder) launchBuilder(ch chan *builderParams) {
@@ -124,65 +375,291 @@ func (b *LambdaBuilder) launchBuilder(ch chan *builderParams) {
 }
 
 type sharedBuilderParams struct {
-	config *lambgofile.Config
+	config      *lambgofile.Config
+	versionInfo *versioninfo.Info
+
+	// procsFlag is passed to each concurrent `go build`, to split the CPUs between them.
+	procsFlag []string
+
+	wg        sync.WaitGroup
+	errors    error
+	artifacts []*manifest.Artifact
+	durations map[string]time.Duration
+	mu        sync.Mutex
+}
+
+// recordDuration of the `go build` for the binary at outPath.
+func (p *sharedBuilderParams) recordDuration(outPath string, duration time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	wg       sync.WaitGroup
-	errors   error
-	errorsMu sync.Mutex
+	p.durations[relativeToRoot(p.config, outPath)] = duration
 }
 
 func (b *LambdaBuilder) buildBinaryAsync(params *builderParams) {
 	defer params.wg.Done()
 
-	if err := b.buildBinary(params.config, params.lambda); err != nil {
-		params.errorsMu.Lock()
-		defer params.errorsMu.Unlock()
+	artifact, err := b.buildBinary(params)
+
+	params.mu.Lock()
+	defer params.mu.Unlock()
+
+	if err != nil {
 		params.errors = erg.Append(params.errors, err)
 		return
 	}
 
-	b.Logger.Printf(" - Built: '%s' -> '%s.zip'\n", params.lambda.Path, buildOutPath(params.config, params.lambda.Path))
+	// Layer packages are logged and recorded when their layer is zipped
+	if artifact == nil {
+		return
+	}
+
+	params.artifacts = append(params.artifacts, artifact)
+	b.Logger.Printf(" - Built: '%s' -> '%s'\n", params.lambda.Path, artifact.ZipPath)
 }
 
-func (b *LambdaBuilder) buildBinary(config *lambgofile.Config, lambda *lambgofile.Lambda) error {
-	outPath := buildOutPath(config, lambda.Path)
+func (b *LambdaBuilder) buildBinary(params *builderParams) (*manifest.Artifact, error) {
+	config := params.config
+	target := params.buildTarget
+	lambda := target.lambda
+	outPath := target.outPath
+
+	if !params.built {
+		pwd, args := goBuildArgs(config, params.versionInfo, outPath, lambda)
+		start := b.now()
+		_, err := b.Cmd.Exec(&runcmd.ExecParams{
+			PWD:  pwd,
+			CMD:  "go",
+			Args: slices.Insert(args, 1, params.procsFlag...),
+
+			EnvVars: buildEnvVars(config),
+		})
+		if err != nil {
+			return nil, erk.WrapWith(ErrGoBuildFailed, err, erk.Params{
+				"buildPath": lambda.Path,
+			})
+		}
 
-	fullArgs := []string{"build", "-trimpath", "-o", outPath}
-	fullArgs = append(fullArgs, lambda.BuildFlags...)
-	fullArgs = append(fullArgs, "./"+lambda.Path)
+		params.recordDuration(outPath, b.now().Sub(start))
+	}
 
-	_, err := b.Cmd.Exec(&runcmd.ExecParams{
-		PWD:  config.RootPath,
-		CMD:  "go",
-		Args: fullArgs,
+	if target.zippedFileName == "" {
+		return nil, nil //nolint:nilnil // Layer packages are zipped with their layer
+	}
 
-		EnvVars: buildEnvVars(config),
-	})
+	size, err := b.binarySize(lambda.Path, outPath)
 	if err != nil {
-		return erk.WrapWith(ErrGoBuildFailed, err, erk.Params{
-			"buildPath": lambda.Path,
-		})
+		return nil, err
 	}
 
-	zippedFileName := filepath.Base(outPath)
-	if config.ZippedFileName != "" {
-		zippedFileName = config.ZippedFileName
+	entries := []*manifest.Entry{{Name: target.zippedFileName, Source: outPath, Size: size}}
+	var licensesFile *zipper.File
+	if config.Licenses != nil {
+		licensesFile, err = b.collectLicenses(config, lambda.Path, []string{outPath}, outPath)
+		if err != nil {
+			return nil, erk.WrapWith(ErrLicensesFailed, err, erk.Params{
+				"buildPath": lambda.Path,
+			})
+		}
 	}
 
-	if err := b.Zip.ZipFile(outPath, zippedFileName); err != nil {
-		return erk.WrapWith(ErrZipFailed, err, erk.Params{
+	if licensesFile != nil {
+		entries = append(entries, &manifest.Entry{Name: licensesFile.ZippedName, Source: licensesFile.Path})
+		err = b.Zip.ZipFiles(outPath+".zip", []*zipper.File{
+			{Path: outPath, ZippedName: target.zippedFileName, Mode: zipper.ExecutableMode},
+			licensesFile,
+		})
+	} else {
+		err = b.Zip.ZipFile(outPath, target.zippedFileName)
+	}
+
+	if err != nil {
+		return nil, erk.WrapWith(ErrZipFailed, err, erk.Params{
 			"buildPath": lambda.Path,
 		})
 	}
 
-	return nil
+	artifact := &manifest.Artifact{
+		Kind:    target.kind,
+		Name:    lambda.Path,
+		ZipPath: outPath + ".zip",
+		Entries: entries,
+	}
+
+	if config.Signing != nil {
+		artifact.Signature, err = b.signZip(config, artifact.ZipPath)
+		if err != nil {
+			return nil, erk.WrapWith(ErrSigningFailed, err, erk.Params{
+				"buildPath": lambda.Path,
+			})
+		}
+	}
+
+	// Extensions are deployed as layers, so only Lambdas can be container images
+	if config.Image != nil && target.kind == manifest.KindLambda {
+		image, err := b.writeImage(config, outPath)
+		if err != nil {
+			return nil, erk.WrapWith(ErrImageFailed, err, erk.Params{
+				"buildPath": lambda.Path,
+				"imagePath": imageOutPath(config, outPath),
+			})
+		}
+
+		artifact.Image = image
+	}
+
+	if config.SBOM != nil {
+		sbomPath := sbomOutPath(config, outPath)
+		if err := b.writeSBOM(config, lambda.Path, []string{outPath}, sbomPath); err != nil {
+			return nil, erk.WrapWith(ErrSBOMFailed, err, erk.Params{
+				"buildPath": lambda.Path,
+				"sbomPath":  sbomPath,
+			})
+		}
+
+		artifact.SBOMPath = sbomPath
+	}
+
+	return artifact, nil
+}
+
+func (b *LambdaBuilder) writeImage(config *lambgofile.Config, outPath string) (*manifest.Image, error) {
+	imagePath := imageOutPath(config, outPath)
+
+	digest, err := b.Image.WriteImage(&ociimage.ImageParams{
+		BinaryPath:      outPath,
+		ImageBinaryPath: config.Image.BinaryPath,
+		OutPath:         imagePath,
+		Tarball:         config.Image.Format == lambgofile.ImageFormatTarball,
+		OS:              config.Goos,
+		Architecture:    config.Goarch,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &manifest.Image{Path: imagePath, Digest: digest}, nil
+}
+
+func (b *LambdaBuilder) writeSBOM(config *lambgofile.Config, name string, binaryPaths []string, sbomPath string) error {
+	return b.SBOM.WriteSBOM(&sbom.Params{
+		Name:        name,
+		BinaryPaths: binaryPaths,
+		OutPath:     sbomPath,
+		CycloneDX:   config.SBOM.Format == lambgofile.SBOMFormatCycloneDX,
+	})
+}
+
+// signZip with the signing key, and write the detached signature next to it.
+func (b *LambdaBuilder) signZip(config *lambgofile.Config, zipPath string) (*manifest.Signature, error) {
+	keyPath := config.Signing.KeyFile
+	if keyPath != "" && !filepath.IsAbs(keyPath) {
+		keyPath = filepath.Join(config.RootPath, keyPath)
+	}
+
+	return b.Signer.Sign(&signing.Params{
+		ZipPath: zipPath,
+		KeyPath: keyPath,
+		OutPath: zipPath + signing.Extension,
+	})
+}
+
+// collectLicenses of the binaries, which are checked against the disallowed licenses.
+// When the licenses are bundled, the file to add to the zip is returned, otherwise it is nil.
+func (b *LambdaBuilder) collectLicenses(config *lambgofile.Config, name string, binaryPaths []string, outPath string) (*zipper.File, error) {
+	params := &licenses.Params{
+		Name:        name,
+		RootPath:    config.RootPath,
+		BinaryPaths: binaryPaths,
+		Disallowed:  config.Licenses.Disallowed,
+	}
+
+	if config.Licenses.Bundle {
+		params.OutPath = outPath + "." + licenses.ZippedFileName
+	}
+
+	if err := b.Licenses.Collect(params); err != nil {
+		return nil, err
+	}
+
+	if params.OutPath == "" {
+		return nil, nil //nolint:nilnil // The licenses are only checked
+	}
+
+	return &zipper.File{Path: params.OutPath, ZippedName: licenses.ZippedFileName, Mode: zipper.RegularMode}, nil
+}
+
+// sbomOutPath is next to the zip, with an extension for the format of the SBOM.
+func sbomOutPath(config *lambgofile.Config, outPath string) string {
+	if config.SBOM.Format == lambgofile.SBOMFormatCycloneDX {
+		return outPath + ".cdx.json"
+	}
+
+	return outPath + ".spdx.json"
+}
+
+func imageOutPath(config *lambgofile.Config, outPath string) string {
+	if config.Image.Format == lambgofile.ImageFormatTarball {
+		return outPath + ".oci.tar"
+	}
+
+	return outPath + ".oci"
+}
+
+// goBuildArgs returns the directory to run go build from, and its arguments.
+func goBuildArgs(config *lambgofile.Config, versionInfo *versioninfo.Info, outPath string, lambda *lambgofile.Lambda) (string, []string) {
+	pwd, buildPath := goPackage(config, lambda)
+
+	// The outPath is relative to RootPath, which is not the working directory for Lambdas in other workspace modules
+	if pwd != config.RootPath && !filepath.IsAbs(outPath) {
+		outPath = filepath.Join(config.RootPath, outPath)
+	}
+
+	fullArgs := []string{"build"}
+	fullArgs = append(fullArgs, lambdaBuildFlags(config, versionInfo, outPath, lambda)...)
+	fullArgs = append(fullArgs, buildPath)
+
+	return pwd, fullArgs
+}
+
+// goPackage returns the directory of the module containing the Lambda, and the relative package path within it.
+// Outside of workspaces, the module is always at RootPath.
+func goPackage(config *lambgofile.Config, lambda *lambgofile.Lambda) (string, string) {
+	if lambda.Module == "" || lambda.Module == "." {
+		return config.RootPath, "./" + lambda.Path
+	}
+
+	pwd := filepath.Join(config.RootPath, lambda.Module)
+	relPath, err := filepath.Rel(lambda.Module, lambda.Path)
+	if err != nil || relPath == "." {
+		return pwd, "."
+	}
+
+	return pwd, "./" + filepath.ToSlash(relPath)
 }
 
 func buildEnvVars(config *lambgofile.Config) map[string]string {
-	return map[string]string{
+	envVars := map[string]string{
 		"GOOS":   config.Goos,
 		"GOARCH": config.Goarch,
 	}
+
+	// GOWORK is set, so the workspace is used even when building from the directory of a module within it
+	if config.Workspace != nil {
+		envVars["GOWORK"] = config.Workspace.FilePath
+	}
+
+	if config.GoCache != "" {
+		envVars["GOCACHE"] = config.GoCache
+	}
+
+	return envVars
+}
+
+func setDefaultOutDirectory(config *lambgofile.Config) {
+	if config.OutDirectory == "" {
+		config.OutDirectory = lambgofile.DefaultOutDirectory
+	}
 }
 
 func buildOutPath(config *lambgofile.Config, buildPath string) string {
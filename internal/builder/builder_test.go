@@ -1,18 +1,39 @@
 package builder_test
 
 import (
+	"bytes"
 	"errors"
 	"io"
 	"log"
+	"os"
+	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/JosiahWitt/ensure"
 	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/binsize"
 	"github.com/JosiahWitt/lambgo/internal/builder"
 	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/licenses"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_binsize"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_buildtimes"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_licenses"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_manifest"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_ociimage"
 	"github.com/JosiahWitt/lambgo/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_sbom"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_signing"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_versioninfo"
 	"github.com/JosiahWitt/lambgo/internal/mocks/mock_zipper"
+	"github.com/JosiahWitt/lambgo/internal/ociimage"
 	"github.com/JosiahWitt/lambgo/internal/runcmd"
+	"github.com/JosiahWitt/lambgo/internal/sbom"
+	"github.com/JosiahWitt/lambgo/internal/signing"
+	"github.com/JosiahWitt/lambgo/internal/versioninfo"
+	"github.com/JosiahWitt/lambgo/internal/zipper"
 	"github.com/golang/mock/gomock"
 )
 
@@ -20,8 +41,14 @@ func TestBuildBinaries(t *testing.T) {
 	ensure := ensure.New(t)
 
 	type Mocks struct {
-		Cmd *mock_runcmd.MockRunnerAPI
-		Zip *mock_zipper.MockZipAPI
+		Cmd         *mock_runcmd.MockRunnerAPI
+		Zip         *mock_zipper.MockZipAPI
+		Image       *mock_ociimage.MockWriterAPI
+		Manifest    *mock_manifest.MockStoreAPI
+		SBOM        *mock_sbom.MockWriterAPI
+		Licenses    *mock_licenses.MockCollectorAPI
+		Signer      *mock_signing.MockSignerAPI
+		VersionInfo *mock_versioninfo.MockReaderAPI
 	}
 
 	mockBuildDependencies := func(m *Mocks, lambdaPaths ...string) *gomock.Call {
@@ -37,6 +64,10 @@ func TestBuildBinaries(t *testing.T) {
 		}).Return("", nil)
 	}
 
+	mockUpdateManifest := func(m *Mocks, outDirectory string, artifacts ...*manifest.Artifact) *gomock.Call {
+		return m.Manifest.EXPECT().Update(outDirectory+"/lambgo-manifest.json", artifacts).Return(nil)
+	}
+
 	table := []struct {
 		Name          string
 		Config        *lambgofile.Config
@@ -99,6 +130,12 @@ func TestBuildBinaries(t *testing.T) {
 						},
 					}).Return("", nil),
 					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path3", "path3").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "lambdas/path1", "out/dir/lambdas/path1", "path1"),
+						makeArtifact(manifest.KindLambda, "lambdas/path2", "out/dir/lambdas/path2", "path2"),
+						makeArtifact(manifest.KindLambda, "lambdas/path3", "out/dir/lambdas/path3", "path3"),
+					),
 				}
 			},
 		},
@@ -142,6 +179,11 @@ func TestBuildBinaries(t *testing.T) {
 						},
 					}).Return("", nil),
 					m.Zip.EXPECT().ZipFile("tmp/lambdas/path2", "path2").Return(nil),
+
+					mockUpdateManifest(m, "tmp",
+						makeArtifact(manifest.KindLambda, "lambdas/path1", "tmp/lambdas/path1", "path1"),
+						makeArtifact(manifest.KindLambda, "lambdas/path2", "tmp/lambdas/path2", "path2"),
+					),
 				}
 			},
 		},
@@ -185,6 +227,11 @@ func TestBuildBinaries(t *testing.T) {
 						},
 					}).Return("", nil),
 					m.Zip.EXPECT().ZipFile("tmp/lambdas/path2", "path2").Return(nil),
+
+					mockUpdateManifest(m, "tmp",
+						makeArtifact(manifest.KindLambda, "lambdas/path1", "tmp/lambdas/path1", "path1"),
+						makeArtifact(manifest.KindLambda, "lambdas/path2", "tmp/lambdas/path2", "path2"),
+					),
 				}
 			},
 		},
@@ -230,6 +277,11 @@ func TestBuildBinaries(t *testing.T) {
 						},
 					}).Return("", nil),
 					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path2", "bootstrap").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "lambdas/path1", "out/dir/lambdas/path1", "bootstrap"),
+						makeArtifact(manifest.KindLambda, "lambdas/path2", "out/dir/lambdas/path2", "bootstrap"),
+					),
 				}
 			},
 		},
@@ -259,6 +311,10 @@ func TestBuildBinaries(t *testing.T) {
 						},
 					}).Return("", nil),
 					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path1", "path1").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "lambdas/path1", "out/dir/lambdas/path1", "path1"),
+					),
 				}
 			},
 		},
@@ -312,206 +368,2358 @@ func TestBuildBinaries(t *testing.T) {
 						},
 					}).Return("", nil),
 					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path2", "path2").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "lambdas/path1", "out/dir/lambdas/path1", "path1"),
+						makeArtifact(manifest.KindLambda, "lambdas/path2", "out/dir/lambdas/path2", "path2"),
+					),
 				}
 			},
 		},
 
 		{
-			Name: "with per-lambda custom buildFlags",
+			Name: "with Lambdas in workspace modules",
 			Config: &lambgofile.Config{
 				RootPath:     "/my/root",
 				OutDirectory: "out/dir",
 				Goos:         "linux",
 				Goarch:       "amd64",
 				Lambdas: []*lambgofile.Lambda{
-					{Path: "lambdas/api", BuildFlags: []string{"-tags", "prod"}},
-					{Path: "lambdas/worker", BuildFlags: []string{"-default", "-flags"}},
+					{Path: "services/billing/cmd/charge", Module: "services/billing"},
+					{Path: "tools/migrate", Module: "."},
+					{Path: "services/billing/cmd/refund", Module: "services/billing"},
+					{Path: "services/search", Module: "services/search"},
 				},
+				Workspace: &lambgofile.Workspace{FilePath: "/my/root/go.work"},
 			},
 
 			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				workspaceEnvVars := map[string]string{
+					"GOOS":   "linux",
+					"GOARCH": "amd64",
+					"GOWORK": "/my/root/go.work",
+				}
+
 				return []*gomock.Call{
-					mockBuildDependencies(m, "./lambdas/api", "./lambdas/worker"),
+					// Only the billing module has more than one package, so the other modules skip building dependencies
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root/services/billing",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "./cmd/charge", "./cmd/refund"},
+						EnvVars: workspaceEnvVars,
+					}).Return("", nil),
 
 					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
-						PWD:  "/my/root",
-						CMD:  "go",
-						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/api", "-tags", "prod", "./lambdas/api"},
+						PWD:     "/my/root/services/billing",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "/my/root/out/dir/services/billing/cmd/charge", "./cmd/charge"},
+						EnvVars: workspaceEnvVars,
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/services/billing/cmd/charge", "charge").Return(nil),
 
-						EnvVars: map[string]string{
-							"GOOS":   "linux",
-							"GOARCH": "amd64",
-						},
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "out/dir/tools/migrate", "./tools/migrate"},
+						EnvVars: workspaceEnvVars,
 					}).Return("", nil),
-					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+					m.Zip.EXPECT().ZipFile("out/dir/tools/migrate", "migrate").Return(nil),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root/services/billing",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "/my/root/out/dir/services/billing/cmd/refund", "./cmd/refund"},
+						EnvVars: workspaceEnvVars,
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/services/billing/cmd/refund", "refund").Return(nil),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root/services/search",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "/my/root/out/dir/services/search", "."},
+						EnvVars: workspaceEnvVars,
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/services/search", "search").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "services/billing/cmd/charge", "out/dir/services/billing/cmd/charge", "charge"),
+						makeArtifact(manifest.KindLambda, "services/billing/cmd/refund", "out/dir/services/billing/cmd/refund", "refund"),
+						makeArtifact(manifest.KindLambda, "services/search", "out/dir/services/search", "search"),
+						makeArtifact(manifest.KindLambda, "tools/migrate", "out/dir/tools/migrate", "migrate"),
+					),
+				}
+			},
+		},
+
+		{
+			Name: "with GOCACHE",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				GoCache:      "/tmp/lambgo-reproducible-123/first-gocache",
+				Lambdas:      []*lambgofile.Lambda{{Path: "lambdas/api"}},
+			},
 
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
 					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
 						PWD:  "/my/root",
 						CMD:  "go",
-						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/worker", "-default", "-flags", "./lambdas/worker"},
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/api", "./lambdas/api"},
 
 						EnvVars: map[string]string{
-							"GOOS":   "linux",
-							"GOARCH": "amd64",
+							"GOOS":    "linux",
+							"GOARCH":  "amd64",
+							"GOCACHE": "/tmp/lambgo-reproducible-123/first-gocache",
 						},
 					}).Return("", nil),
-					m.Zip.EXPECT().ZipFile("out/dir/lambdas/worker", "worker").Return(nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+
+					mockUpdateManifest(m, "out/dir", makeArtifact(manifest.KindLambda, "lambdas/api", "out/dir/lambdas/api", "api")),
 				}
 			},
 		},
 
 		{
-			Name: "with error running go build for the dependencies",
+			Name: "with versionInjection",
 			Config: &lambgofile.Config{
 				RootPath:     "/my/root",
 				OutDirectory: "out/dir",
 				Goos:         "linux",
 				Goarch:       "amd64",
 				Lambdas: []*lambgofile.Lambda{
-					{Path: "lambdas/path1"},
-					{Path: "lambdas/path2"},
+					{Path: "lambdas/api", BuildFlags: []string{"-tags", "prod", "-ldflags=-s -w"}},
+					{Path: "lambdas/worker"},
+				},
+				VersionInjection: &lambgofile.VersionInjection{
+					Commit:     "main.Commit",
+					Dirty:      "main.Dirty",
+					LambdaPath: "example.com/app/internal/build.Lambda",
 				},
 			},
-			ExpectedError: builder.ErrGoBuildDependenciesFailed,
 
 			AssembleMocks: func(m *Mocks) []*gomock.Call {
 				return []*gomock.Call{
+					m.VersionInfo.EXPECT().
+						Read("/my/root", &lambgofile.VersionInjection{
+							Commit:     "main.Commit",
+							Dirty:      "main.Dirty",
+							LambdaPath: "example.com/app/internal/build.Lambda",
+						}).
+						Return(&versioninfo.Info{Commit: "4f2a9c1", Dirty: "false"}, nil),
+
+					mockBuildDependencies(m, "./lambdas/api", "./lambdas/worker"),
+
 					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
-						PWD:  "/my/root",
-						CMD:  "go",
-						Args: []string{"build", "-trimpath", "./lambdas/path1", "./lambdas/path2"},
+						PWD: "/my/root",
+						CMD: "go",
+						Args: []string{
+							"build", "-trimpath", "-o", "out/dir/lambdas/api", "-tags", "prod",
+							"-ldflags=-s -w -X main.Commit=4f2a9c1 -X main.Dirty=false -X example.com/app/internal/build.Lambda=lambdas/api",
+							"./lambdas/api",
+						},
 
 						EnvVars: map[string]string{
 							"GOOS":   "linux",
 							"GOARCH": "amd64",
 						},
-					}).Return("", errors.New("unable to build dependencies")),
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD: "/my/root",
+						CMD: "go",
+						Args: []string{
+							"build", "-trimpath", "-o", "out/dir/lambdas/worker",
+							"-ldflags=-X main.Commit=4f2a9c1 -X main.Dirty=false -X example.com/app/internal/build.Lambda=lambdas/worker",
+							"./lambdas/worker",
+						},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/worker", "worker").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "lambdas/api", "out/dir/lambdas/api", "api"),
+						makeArtifact(manifest.KindLambda, "lambdas/worker", "out/dir/lambdas/worker", "worker"),
+					),
 				}
 			},
 		},
 
 		{
-			Name: "with error running go build for a Lambda",
+			Name: "with per-lambda custom buildFlags",
 			Config: &lambgofile.Config{
 				RootPath:     "/my/root",
 				OutDirectory: "out/dir",
 				Goos:         "linux",
 				Goarch:       "amd64",
 				Lambdas: []*lambgofile.Lambda{
-					{Path: "lambdas/path1"},
-					{Path: "lambdas/path2"},
+					{Path: "lambdas/api", BuildFlags: []string{"-tags", "prod"}},
+					{Path: "lambdas/worker", BuildFlags: []string{"-default", "-flags"}},
 				},
 			},
-			ExpectedError: builder.ErrGoBuildFailed,
 
 			AssembleMocks: func(m *Mocks) []*gomock.Call {
 				return []*gomock.Call{
-					mockBuildDependencies(m, "./lambdas/path1", "./lambdas/path2"),
+					mockBuildDependencies(m, "./lambdas/api", "./lambdas/worker"),
 
 					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
 						PWD:  "/my/root",
 						CMD:  "go",
-						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path1", "./lambdas/path1"},
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/api", "-tags", "prod", "./lambdas/api"},
 
 						EnvVars: map[string]string{
 							"GOOS":   "linux",
 							"GOARCH": "amd64",
 						},
-					}).Return("", errors.New("something is wrong 1")),
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
 
 					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
 						PWD:  "/my/root",
 						CMD:  "go",
-						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path2", "./lambdas/path2"},
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/worker", "-default", "-flags", "./lambdas/worker"},
 
 						EnvVars: map[string]string{
 							"GOOS":   "linux",
 							"GOARCH": "amd64",
 						},
-					}).Return("", errors.New("something is wrong 2")),
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/worker", "worker").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "lambdas/api", "out/dir/lambdas/api", "api"),
+						makeArtifact(manifest.KindLambda, "lambdas/worker", "out/dir/lambdas/worker", "worker"),
+					),
 				}
 			},
 		},
 
 		{
-			Name: "with error zipping file",
+			Name: "with extensions",
 			Config: &lambgofile.Config{
-				RootPath:     "/my/root",
-				OutDirectory: "out/dir",
-				Goos:         "linux",
-				Goarch:       "amd64",
+				RootPath:       "/my/root",
+				OutDirectory:   "out/dir",
+				ZippedFileName: "bootstrap",
+				Goos:           "linux",
+				Goarch:         "amd64",
 				Lambdas: []*lambgofile.Lambda{
-					{Path: "lambdas/path1"},
-					{Path: "lambdas/path2"},
+					{Path: "lambdas/api"},
+				},
+				Extensions: []*lambgofile.Extension{
+					{Lambda: lambgofile.Lambda{Path: "extensions/telemetry", BuildFlags: []string{"-tags", "prod"}}, Name: "telemetry-agent"},
 				},
 			},
-			ExpectedError: builder.ErrZipFailed,
 
 			AssembleMocks: func(m *Mocks) []*gomock.Call {
 				return []*gomock.Call{
-					mockBuildDependencies(m, "./lambdas/path1", "./lambdas/path2"),
+					mockBuildDependencies(m, "./lambdas/api", "./extensions/telemetry"),
 
 					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
 						PWD:  "/my/root",
 						CMD:  "go",
-						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path1", "./lambdas/path1"},
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/api", "./lambdas/api"},
 
 						EnvVars: map[string]string{
 							"GOOS":   "linux",
 							"GOARCH": "amd64",
 						},
 					}).Return("", nil),
-					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path1", "path1").Return(errors.New("something went wrong 1")),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "bootstrap").Return(nil),
 
 					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
 						PWD:  "/my/root",
 						CMD:  "go",
-						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path2", "./lambdas/path2"},
+						Args: []string{"build", "-trimpath", "-o", "out/dir/extensions/telemetry", "-tags", "prod", "./extensions/telemetry"},
 
 						EnvVars: map[string]string{
 							"GOOS":   "linux",
 							"GOARCH": "amd64",
 						},
 					}).Return("", nil),
-					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path2", "path2").Return(errors.New("something went wrong 2")),
+					m.Zip.EXPECT().ZipFile("out/dir/extensions/telemetry", "extensions/telemetry-agent").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindExtension, "extensions/telemetry", "out/dir/extensions/telemetry", "extensions/telemetry-agent"),
+						makeArtifact(manifest.KindLambda, "lambdas/api", "out/dir/lambdas/api", "bootstrap"),
+					),
 				}
 			},
 		},
-	}
 
-	ensure.Run("when parallel mode disabled", func(ensure ensuring.E) {
-		ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
-			entry := table[i]
-			entry.Subject.Logger = log.New(io.Discard, "", 0)
-			entry.Config.NumParallel = 1
-			gomock.InOrder(entry.AssembleMocks(entry.Mocks)...)
+		{
+			Name: "with image output",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "arm64",
+				Image:        &lambgofile.Image{Format: lambgofile.ImageFormatTarball, BinaryPath: "/var/runtime/bootstrap"},
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/api"},
+				},
+				Extensions: []*lambgofile.Extension{
+					{Lambda: lambgofile.Lambda{Path: "extensions/telemetry"}, Name: "telemetry"},
+				},
+			},
 
-			err := entry.Subject.BuildBinaries(entry.Config)
-			ensure(err).IsError(err)
-		})
-	})
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				envVars := map[string]string{"GOOS": "linux", "GOARCH": "arm64"}
 
-	ensure.Run("when Lambdas are built in parallel in groups", func(ensure ensuring.E) {
-		ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
-			entry := table[i]
-			entry.Subject.Logger = log.New(io.Discard, "", 0)
-			entry.Config.NumParallel = 2
-			entry.AssembleMocks(entry.Mocks)
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "./lambdas/api", "./extensions/telemetry"},
+						EnvVars: envVars,
+					}).Return("", nil),
 
-			err := entry.Subject.BuildBinaries(entry.Config)
-			ensure(err).IsError(err)
-		})
-	})
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "out/dir/lambdas/api", "./lambdas/api"},
+						EnvVars: envVars,
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+					m.Image.EXPECT().WriteImage(&ociimage.ImageParams{
+						BinaryPath:      "out/dir/lambdas/api",
+						ImageBinaryPath: "/var/runtime/bootstrap",
+						OutPath:         "out/dir/lambdas/api.oci.tar",
+						Tarball:         true,
+						OS:              "linux",
+						Architecture:    "arm64",
+					}).Return("sha256:abc", nil),
 
-	ensure.Run("when all Lambdas are built in parallel at the same time", func(ensure ensuring.E) {
-		ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
-			entry := table[i]
-			entry.Subject.Logger = log.New(io.Discard, "", 0)
-			entry.Config.NumParallel = len(entry.Config.Lambdas)
-			entry.AssembleMocks(entry.Mocks)
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "out/dir/extensions/telemetry", "./extensions/telemetry"},
+						EnvVars: envVars,
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/extensions/telemetry", "extensions/telemetry").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindExtension, "extensions/telemetry", "out/dir/extensions/telemetry", "extensions/telemetry"),
+						&manifest.Artifact{
+							Kind:    manifest.KindLambda,
+							Name:    "lambdas/api",
+							ZipPath: "out/dir/lambdas/api.zip",
+							Entries: []*manifest.Entry{{Name: "api", Source: "out/dir/lambdas/api"}},
+							Image:   &manifest.Image{Path: "out/dir/lambdas/api.oci.tar", Digest: "sha256:abc"},
+						},
+					),
+				}
+			},
+		},
 
-			err := entry.Subject.BuildBinaries(entry.Config)
-			ensure(err).IsError(err)
-		})
+		{
+			Name: "with sbom output",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				SBOM:         &lambgofile.SBOM{Format: lambgofile.SBOMFormatCycloneDX},
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/api"},
+				},
+				Extensions: []*lambgofile.Extension{
+					{Lambda: lambgofile.Lambda{Path: "extensions/telemetry"}, Name: "telemetry"},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				envVars := map[string]string{"GOOS": "linux", "GOARCH": "amd64"}
+
+				return []*gomock.Call{
+					mockBuildDependencies(m, "./lambdas/api", "./extensions/telemetry"),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "out/dir/lambdas/api", "./lambdas/api"},
+						EnvVars: envVars,
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+					m.SBOM.EXPECT().WriteSBOM(&sbom.Params{
+						Name:        "lambdas/api",
+						BinaryPaths: []string{"out/dir/lambdas/api"},
+						OutPath:     "out/dir/lambdas/api.cdx.json",
+						CycloneDX:   true,
+					}).Return(nil),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "out/dir/extensions/telemetry", "./extensions/telemetry"},
+						EnvVars: envVars,
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/extensions/telemetry", "extensions/telemetry").Return(nil),
+					m.SBOM.EXPECT().WriteSBOM(&sbom.Params{
+						Name:        "extensions/telemetry",
+						BinaryPaths: []string{"out/dir/extensions/telemetry"},
+						OutPath:     "out/dir/extensions/telemetry.cdx.json",
+						CycloneDX:   true,
+					}).Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						&manifest.Artifact{
+							Kind:     manifest.KindExtension,
+							Name:     "extensions/telemetry",
+							ZipPath:  "out/dir/extensions/telemetry.zip",
+							Entries:  []*manifest.Entry{{Name: "extensions/telemetry", Source: "out/dir/extensions/telemetry"}},
+							SBOMPath: "out/dir/extensions/telemetry.cdx.json",
+						},
+						&manifest.Artifact{
+							Kind:     manifest.KindLambda,
+							Name:     "lambdas/api",
+							ZipPath:  "out/dir/lambdas/api.zip",
+							Entries:  []*manifest.Entry{{Name: "api", Source: "out/dir/lambdas/api"}},
+							SBOMPath: "out/dir/lambdas/api.cdx.json",
+						},
+					),
+				}
+			},
+		},
+
+		{
+			Name: "with bundled licenses",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Licenses:     &lambgofile.Licenses{Bundle: true, Disallowed: []string{licenses.GPL30}},
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/api"},
+				},
+				Extensions: []*lambgofile.Extension{
+					{Lambda: lambgofile.Lambda{Path: "extensions/telemetry"}, Name: "telemetry"},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				envVars := map[string]string{"GOOS": "linux", "GOARCH": "amd64"}
+
+				return []*gomock.Call{
+					mockBuildDependencies(m, "./lambdas/api", "./extensions/telemetry"),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "out/dir/lambdas/api", "./lambdas/api"},
+						EnvVars: envVars,
+					}).Return("", nil),
+					m.Licenses.EXPECT().Collect(&licenses.Params{
+						Name:        "lambdas/api",
+						RootPath:    "/my/root",
+						BinaryPaths: []string{"out/dir/lambdas/api"},
+						OutPath:     "out/dir/lambdas/api.THIRD_PARTY_LICENSES",
+						Disallowed:  []string{licenses.GPL30},
+					}).Return(nil),
+					m.Zip.EXPECT().ZipFiles("out/dir/lambdas/api.zip", []*zipper.File{
+						{Path: "out/dir/lambdas/api", ZippedName: "api", Mode: zipper.ExecutableMode},
+						{Path: "out/dir/lambdas/api.THIRD_PARTY_LICENSES", ZippedName: "THIRD_PARTY_LICENSES", Mode: zipper.RegularMode},
+					}).Return(nil),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "out/dir/extensions/telemetry", "./extensions/telemetry"},
+						EnvVars: envVars,
+					}).Return("", nil),
+					m.Licenses.EXPECT().Collect(&licenses.Params{
+						Name:        "extensions/telemetry",
+						RootPath:    "/my/root",
+						BinaryPaths: []string{"out/dir/extensions/telemetry"},
+						OutPath:     "out/dir/extensions/telemetry.THIRD_PARTY_LICENSES",
+						Disallowed:  []string{licenses.GPL30},
+					}).Return(nil),
+					m.Zip.EXPECT().ZipFiles("out/dir/extensions/telemetry.zip", []*zipper.File{
+						{Path: "out/dir/extensions/telemetry", ZippedName: "extensions/telemetry", Mode: zipper.ExecutableMode},
+						{Path: "out/dir/extensions/telemetry.THIRD_PARTY_LICENSES", ZippedName: "THIRD_PARTY_LICENSES", Mode: zipper.RegularMode},
+					}).Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						&manifest.Artifact{
+							Kind:    manifest.KindExtension,
+							Name:    "extensions/telemetry",
+							ZipPath: "out/dir/extensions/telemetry.zip",
+							Entries: []*manifest.Entry{
+								{Name: "extensions/telemetry", Source: "out/dir/extensions/telemetry"},
+								{Name: "THIRD_PARTY_LICENSES", Source: "out/dir/extensions/telemetry.THIRD_PARTY_LICENSES"},
+							},
+						},
+						&manifest.Artifact{
+							Kind:    manifest.KindLambda,
+							Name:    "lambdas/api",
+							ZipPath: "out/dir/lambdas/api.zip",
+							Entries: []*manifest.Entry{
+								{Name: "api", Source: "out/dir/lambdas/api"},
+								{Name: "THIRD_PARTY_LICENSES", Source: "out/dir/lambdas/api.THIRD_PARTY_LICENSES"},
+							},
+						},
+					),
+				}
+			},
+		},
+
+		{
+			Name: "with licenses that are only checked",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Licenses:     &lambgofile.Licenses{Disallowed: []string{licenses.AGPL30}},
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/api"},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "out/dir/lambdas/api", "./lambdas/api"},
+						EnvVars: map[string]string{"GOOS": "linux", "GOARCH": "amd64"},
+					}).Return("", nil),
+					m.Licenses.EXPECT().Collect(&licenses.Params{
+						Name:        "lambdas/api",
+						RootPath:    "/my/root",
+						BinaryPaths: []string{"out/dir/lambdas/api"},
+						Disallowed:  []string{licenses.AGPL30},
+					}).Return(nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						&manifest.Artifact{
+							Kind:    manifest.KindLambda,
+							Name:    "lambdas/api",
+							ZipPath: "out/dir/lambdas/api.zip",
+							Entries: []*manifest.Entry{{Name: "api", Source: "out/dir/lambdas/api"}},
+						},
+					),
+				}
+			},
+		},
+
+		{
+			Name: "with signing",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Signing:      &lambgofile.Signing{KeyFile: "keys/signing.pem"},
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/api"},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				signature := &manifest.Signature{
+					Path:      "out/dir/lambdas/api.zip.sig",
+					Algorithm: "ed25519",
+					KeyID:     "sha256:abc",
+					ZipDigest: "sha256:def",
+				}
+
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "out/dir/lambdas/api", "./lambdas/api"},
+						EnvVars: map[string]string{"GOOS": "linux", "GOARCH": "amd64"},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+					m.Signer.EXPECT().Sign(&signing.Params{
+						ZipPath: "out/dir/lambdas/api.zip",
+						KeyPath: "/my/root/keys/signing.pem",
+						OutPath: "out/dir/lambdas/api.zip.sig",
+					}).Return(signature, nil),
+
+					mockUpdateManifest(m, "out/dir",
+						&manifest.Artifact{
+							Kind:      manifest.KindLambda,
+							Name:      "lambdas/api",
+							ZipPath:   "out/dir/lambdas/api.zip",
+							Entries:   []*manifest.Entry{{Name: "api", Source: "out/dir/lambdas/api"}},
+							Signature: signature,
+						},
+					),
+				}
+			},
+		},
+
+		{
+			Name: "with versionInjection, and several -ldflags",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				ModulePath:   "example.com/app",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/api", BuildFlags: []string{"-ldflags=-s", "-ldflags=all=-w"}},
+					{Path: "lambdas/worker", BuildFlags: []string{"-ldflags=-s", "-ldflags", "-w", "-v"}},
+					{Path: "lambdas/cron", BuildFlags: []string{"-ldflags", "all=-w"}},
+					{Path: "lambdas/tool", BuildFlags: []string{"-ldflags=-s", "-ldflags=./tools/...=-w"}},
+					{Path: "lambdas/other", BuildFlags: []string{"-ldflags=-s", "-ldflags=example.com/app/lambdas/...=-w"}},
+				},
+				VersionInjection: &lambgofile.VersionInjection{Commit: "main.Commit"},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				expectBuild := func(name string, flags ...string) *gomock.Call {
+					args := append([]string{"build", "-trimpath", "-o", "out/dir/lambdas/" + name}, flags...)
+
+					return m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    append(args, "./lambdas/"+name),
+						EnvVars: map[string]string{"GOOS": "linux", "GOARCH": "amd64"},
+					}).Return("", nil)
+				}
+
+				return []*gomock.Call{
+					m.VersionInfo.EXPECT().
+						Read("/my/root", &lambgofile.VersionInjection{Commit: "main.Commit"}).
+						Return(&versioninfo.Info{Commit: "4f2a9c1"}, nil),
+
+					mockBuildDependencies(m, "./lambdas/api", "./lambdas/worker", "./lambdas/cron", "./lambdas/tool", "./lambdas/other"),
+
+					// The go command links with the last -ldflags matching the Lambda, so the others are kept as they are
+					expectBuild("api", "-ldflags=-s", "-ldflags=all=-w -X main.Commit=4f2a9c1"),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+
+					expectBuild("worker", "-ldflags=-s", "-ldflags=-w -X main.Commit=4f2a9c1", "-v"),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/worker", "worker").Return(nil),
+
+					expectBuild("cron", "-ldflags=all=-w -X main.Commit=4f2a9c1"),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/cron", "cron").Return(nil),
+
+					// The -ldflags with a package pattern that does not match the Lambda is kept separate
+					expectBuild("tool", "-ldflags=-s -X main.Commit=4f2a9c1", "-ldflags=./tools/...=-w"),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/tool", "tool").Return(nil),
+
+					expectBuild("other", "-ldflags=-s", "-ldflags=example.com/app/lambdas/...=-w -X main.Commit=4f2a9c1"),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/other", "other").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "lambdas/api", "out/dir/lambdas/api", "api"),
+						makeArtifact(manifest.KindLambda, "lambdas/cron", "out/dir/lambdas/cron", "cron"),
+						makeArtifact(manifest.KindLambda, "lambdas/other", "out/dir/lambdas/other", "other"),
+						makeArtifact(manifest.KindLambda, "lambdas/tool", "out/dir/lambdas/tool", "tool"),
+						makeArtifact(manifest.KindLambda, "lambdas/worker", "out/dir/lambdas/worker", "worker"),
+					),
+				}
+			},
+		},
+
+		{
+			Name: "with buildOptions tags, and -tags in buildFlags",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{
+						Path:         "lambdas/api",
+						BuildFlags:   []string{"-tags", "old", "-v", "-tags=lambda,prod"},
+						BuildOptions: &lambgofile.BuildOptions{Tags: []string{"prod", "api"}},
+					},
+					{
+						Path:         "lambdas/worker",
+						BuildFlags:   []string{"-tags", "worker"},
+						BuildOptions: &lambgofile.BuildOptions{Race: true},
+					},
+					{
+						Path:         "lambdas/cron",
+						BuildFlags:   []string{"-tags=cron"},
+						BuildOptions: &lambgofile.BuildOptions{Tags: []string{"prod"}, ExtraFlags: []string{"-tags", "lambda", "-v"}},
+					},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "./lambdas/api", "./lambdas/worker", "./lambdas/cron"},
+						EnvVars: map[string]string{"GOOS": "linux", "GOARCH": "amd64"},
+					}).Return("", nil),
+
+					// Like the go command, only the last -tags of the buildFlags is used
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-tags=prod,api,lambda", "-o", "out/dir/lambdas/api", "-v", "./lambdas/api"},
+						EnvVars: map[string]string{"GOOS": "linux", "GOARCH": "amd64"},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+
+					// Without tags in the buildOptions, the buildFlags are used as they are
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-race", "-o", "out/dir/lambdas/worker", "-tags", "worker", "./lambdas/worker"},
+						EnvVars: map[string]string{"GOOS": "linux", "GOARCH": "amd64"},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/worker", "worker").Return(nil),
+
+					// The -tags of the extraFlags are also added to the tags
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-tags=prod,lambda,cron", "-v", "-o", "out/dir/lambdas/cron", "./lambdas/cron"},
+						EnvVars: map[string]string{"GOOS": "linux", "GOARCH": "amd64"},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/cron", "cron").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "lambdas/api", "out/dir/lambdas/api", "api"),
+						makeArtifact(manifest.KindLambda, "lambdas/cron", "out/dir/lambdas/cron", "cron"),
+						makeArtifact(manifest.KindLambda, "lambdas/worker", "out/dir/lambdas/worker", "worker"),
+					),
+				}
+			},
+		},
+
+		{
+			Name: "with buildOptions, and -ldflags with a package pattern",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{
+						Path:         "lambdas/api",
+						BuildFlags:   []string{"-ldflags=all=-w"},
+						BuildOptions: &lambgofile.BuildOptions{LDFlags: "-s", Trimpath: true},
+					},
+					{
+						Path:         "lambdas/worker",
+						BuildOptions: &lambgofile.BuildOptions{LDFlags: "-s", ExtraFlags: []string{"-ldflags=./tools/...=-w"}},
+					},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					mockBuildDependencies(m, "./lambdas/api", "./lambdas/worker"),
+
+					// The ldflags of the buildOptions are added to the -ldflags of the buildFlags, since the go command only uses the last one
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-trimpath", "-o", "out/dir/lambdas/api", "-ldflags=all=-s -w", "./lambdas/api"},
+						EnvVars: map[string]string{"GOOS": "linux", "GOARCH": "amd64"},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+
+					// The -ldflags of the extraFlags does not match the Lambda, so the ldflags are passed after it
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:     "/my/root",
+						CMD:     "go",
+						Args:    []string{"build", "-ldflags=./tools/...=-w", "-o", "out/dir/lambdas/worker", "-ldflags=-s", "./lambdas/worker"},
+						EnvVars: map[string]string{"GOOS": "linux", "GOARCH": "amd64"},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/worker", "worker").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "lambdas/api", "out/dir/lambdas/api", "api"),
+						makeArtifact(manifest.KindLambda, "lambdas/worker", "out/dir/lambdas/worker", "worker"),
+					),
+				}
+			},
+		},
+
+		{
+			Name: "with buildOptions",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{
+						Path:       "lambdas/api",
+						BuildFlags: []string{"-ldflags", "-X main.Env=prod", "-v"},
+						BuildOptions: &lambgofile.BuildOptions{
+							Tags:       []string{"prod", "api"},
+							LDFlags:    "-s -w",
+							GCFlags:    "all=-N -l",
+							Trimpath:   true,
+							Mod:        "readonly",
+							ExtraFlags: []string{"-p", "4"},
+						},
+					},
+					{
+						Path:         "lambdas/worker",
+						BuildOptions: &lambgofile.BuildOptions{Race: true},
+					},
+				},
+				VersionInjection: &lambgofile.VersionInjection{Commit: "main.Commit"},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.VersionInfo.EXPECT().
+						Read("/my/root", &lambgofile.VersionInjection{Commit: "main.Commit"}).
+						Return(&versioninfo.Info{Commit: "4f2a9c1"}, nil),
+
+					mockBuildDependencies(m, "./lambdas/api", "./lambdas/worker"),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD: "/my/root",
+						CMD: "go",
+						Args: []string{
+							"build", "-trimpath", "-mod=readonly", "-tags=prod,api", "-gcflags=all=-N -l", "-p", "4",
+							"-o", "out/dir/lambdas/api", "-ldflags=-s -w -X main.Env=prod -X main.Commit=4f2a9c1", "-v",
+							"./lambdas/api",
+						},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD: "/my/root",
+						CMD: "go",
+						Args: []string{
+							"build", "-race", "-o", "out/dir/lambdas/worker", "-ldflags=-X main.Commit=4f2a9c1", "./lambdas/worker",
+						},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/worker", "worker").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "lambdas/api", "out/dir/lambdas/api", "api"),
+						makeArtifact(manifest.KindLambda, "lambdas/worker", "out/dir/lambdas/worker", "worker"),
+					),
+				}
+			},
+		},
+
+		{
+			Name: "with grouped builds",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				GroupBuilds:  true,
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/path1"},
+					{Path: "lambdas/path2"},
+					{Path: "lambdas/path3", BuildFlags: []string{"-tags", "prod"}},
+					{Path: "lambdas/api/v2"},
+				},
+				Extensions: []*lambgofile.Extension{
+					{Lambda: lambgofile.Lambda{Path: "extensions/telemetry"}, Name: "telemetry"},
+					{Lambda: lambgofile.Lambda{Path: "extensions/logs"}, Name: "logs"},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/", "./lambdas/path1", "./lambdas/path2"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/extensions/", "./extensions/telemetry", "./extensions/logs"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+
+					mockBuildDependencies(m, "./lambdas/path3", "./lambdas/api/v2"),
+
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path1", "path1").Return(nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path2", "path2").Return(nil),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path3", "-tags", "prod", "./lambdas/path3"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path3", "path3").Return(nil),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/api/v2", "./lambdas/api/v2"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/api/v2", "v2").Return(nil),
+
+					m.Zip.EXPECT().ZipFile("out/dir/extensions/telemetry", "extensions/telemetry").Return(nil),
+					m.Zip.EXPECT().ZipFile("out/dir/extensions/logs", "extensions/logs").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindExtension, "extensions/logs", "out/dir/extensions/logs", "extensions/logs"),
+						makeArtifact(manifest.KindExtension, "extensions/telemetry", "out/dir/extensions/telemetry", "extensions/telemetry"),
+						makeArtifact(manifest.KindLambda, "lambdas/api/v2", "out/dir/lambdas/api/v2", "v2"),
+						makeArtifact(manifest.KindLambda, "lambdas/path1", "out/dir/lambdas/path1", "path1"),
+						makeArtifact(manifest.KindLambda, "lambdas/path2", "out/dir/lambdas/path2", "path2"),
+						makeArtifact(manifest.KindLambda, "lambdas/path3", "out/dir/lambdas/path3", "path3"),
+					),
+				}
+			},
+		},
+
+		{
+			Name: "with grouped builds in a workspace module",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				GroupBuilds:  true,
+				Workspace:    &lambgofile.Workspace{FilePath: "/my/root/go.work"},
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "services/search/lambdas/index", Module: "services/search"},
+					{Path: "services/search/lambdas/query", Module: "services/search"},
+				},
+			},
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root/services/search",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "/my/root/out/dir/services/search/lambdas/", "./lambdas/index", "./lambdas/query"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+							"GOWORK": "/my/root/go.work",
+						},
+					}).Return("", nil),
+
+					m.Zip.EXPECT().ZipFile("out/dir/services/search/lambdas/index", "index").Return(nil),
+					m.Zip.EXPECT().ZipFile("out/dir/services/search/lambdas/query", "query").Return(nil),
+
+					mockUpdateManifest(m, "out/dir",
+						makeArtifact(manifest.KindLambda, "services/search/lambdas/index", "out/dir/services/search/lambdas/index", "index"),
+						makeArtifact(manifest.KindLambda, "services/search/lambdas/query", "out/dir/services/search/lambdas/query", "query"),
+					),
+				}
+			},
+		},
+
+		{
+			Name: "with error reading the values for versionInjection",
+			Config: &lambgofile.Config{
+				RootPath:         "/my/root",
+				OutDirectory:     "out/dir",
+				Lambdas:          []*lambgofile.Lambda{{Path: "lambdas/path1"}},
+				VersionInjection: &lambgofile.VersionInjection{Commit: "main.Commit"},
+			},
+			ExpectedError: builder.ErrVersionInfoFailed,
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.VersionInfo.EXPECT().Read("/my/root", gomock.Any()).Return(nil, errors.New("not a git repository")),
+				}
+			},
+		},
+
+		{
+			Name: "with error running go build for the dependencies",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/path1"},
+					{Path: "lambdas/path2"},
+				},
+			},
+			ExpectedError: builder.ErrGoBuildDependenciesFailed,
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "./lambdas/path1", "./lambdas/path2"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", errors.New("unable to build dependencies")),
+				}
+			},
+		},
+
+		{
+			Name: "with error running go build for a Lambda",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/path1"},
+					{Path: "lambdas/path2"},
+				},
+			},
+			ExpectedError: builder.ErrGoBuildFailed,
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					mockBuildDependencies(m, "./lambdas/path1", "./lambdas/path2"),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path1", "./lambdas/path1"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", errors.New("something is wrong 1")),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path2", "./lambdas/path2"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", errors.New("something is wrong 2")),
+				}
+			},
+		},
+
+		{
+			Name: "with error running a grouped go build",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				GroupBuilds:  true,
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/path1"},
+					{Path: "lambdas/path2"},
+				},
+			},
+			ExpectedError: builder.ErrGoBuildFailed,
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/", "./lambdas/path1", "./lambdas/path2"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", errors.New("something is wrong")),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path1", "./lambdas/path1"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path1", "path1").Return(nil),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path2", "./lambdas/path2"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", errors.New("something is wrong 2")),
+				}
+			},
+		},
+
+		{
+			Name: "with error zipping file",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/path1"},
+					{Path: "lambdas/path2"},
+				},
+			},
+			ExpectedError: builder.ErrZipFailed,
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					mockBuildDependencies(m, "./lambdas/path1", "./lambdas/path2"),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path1", "./lambdas/path1"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path1", "path1").Return(errors.New("something went wrong 1")),
+
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path2", "./lambdas/path2"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path2", "path2").Return(errors.New("something went wrong 2")),
+				}
+			},
+		},
+
+		{
+			Name: "with error writing the image",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Image:        &lambgofile.Image{Format: lambgofile.ImageFormatLayout, BinaryPath: "/var/runtime/bootstrap"},
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/path1"},
+				},
+			},
+			ExpectedError: builder.ErrImageFailed,
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path1", "./lambdas/path1"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path1", "path1").Return(nil),
+					m.Image.EXPECT().WriteImage(&ociimage.ImageParams{
+						BinaryPath:      "out/dir/lambdas/path1",
+						ImageBinaryPath: "/var/runtime/bootstrap",
+						OutPath:         "out/dir/lambdas/path1.oci",
+						OS:              "linux",
+						Architecture:    "amd64",
+					}).Return("", errors.New("something went wrong")),
+				}
+			},
+		},
+
+		{
+			Name: "with error writing the sbom",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				SBOM:         &lambgofile.SBOM{Format: lambgofile.SBOMFormatSPDX},
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/path1"},
+				},
+			},
+			ExpectedError: builder.ErrSBOMFailed,
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path1", "./lambdas/path1"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path1", "path1").Return(nil),
+					m.SBOM.EXPECT().WriteSBOM(&sbom.Params{
+						Name:        "lambdas/path1",
+						BinaryPaths: []string{"out/dir/lambdas/path1"},
+						OutPath:     "out/dir/lambdas/path1.spdx.json",
+					}).Return(errors.New("something went wrong")),
+				}
+			},
+		},
+
+		{
+			Name: "with disallowed licenses",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Licenses:     &lambgofile.Licenses{Bundle: true, Disallowed: []string{licenses.GPL30}},
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/path1"},
+				},
+			},
+			ExpectedError: builder.ErrLicensesFailed,
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path1", "./lambdas/path1"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Licenses.EXPECT().Collect(gomock.Any()).Return(errors.New("something went wrong")),
+				}
+			},
+		},
+
+		{
+			Name: "with error signing the zip",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Signing:      &lambgofile.Signing{KeyFile: "/keys/signing.pem"},
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/path1"},
+				},
+			},
+			ExpectedError: builder.ErrSigningFailed,
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path1", "./lambdas/path1"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path1", "path1").Return(nil),
+					m.Signer.EXPECT().Sign(&signing.Params{
+						ZipPath: "out/dir/lambdas/path1.zip",
+						KeyPath: "/keys/signing.pem",
+						OutPath: "out/dir/lambdas/path1.zip.sig",
+					}).Return(nil, errors.New("something went wrong")),
+				}
+			},
+		},
+
+		{
+			Name: "with error updating the manifest",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/path1"},
+				},
+			},
+			ExpectedError: builder.ErrManifestWriteFailed,
+
+			AssembleMocks: func(m *Mocks) []*gomock.Call {
+				return []*gomock.Call{
+					m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+						PWD:  "/my/root",
+						CMD:  "go",
+						Args: []string{"build", "-trimpath", "-o", "out/dir/lambdas/path1", "./lambdas/path1"},
+
+						EnvVars: map[string]string{
+							"GOOS":   "linux",
+							"GOARCH": "amd64",
+						},
+					}).Return("", nil),
+					m.Zip.EXPECT().ZipFile("out/dir/lambdas/path1", "path1").Return(nil),
+
+					m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", gomock.Any()).Return(errors.New("something went wrong")),
+				}
+			},
+		},
+	}
+
+	ensure.Run("when parallel mode disabled", func(ensure ensuring.E) {
+		ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+			entry := table[i]
+			entry.Subject.Logger = log.New(io.Discard, "", 0)
+			entry.Config.NumParallel = 1
+			gomock.InOrder(entry.AssembleMocks(entry.Mocks)...)
+
+			err := entry.Subject.BuildBinaries(entry.Config)
+			ensure(err).IsError(err)
+		})
+	})
+
+	ensure.Run("when Lambdas are built in parallel in groups", func(ensure ensuring.E) {
+		ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+			entry := table[i]
+			entry.Subject.Logger = log.New(io.Discard, "", 0)
+			entry.Config.NumParallel = 2
+			entry.AssembleMocks(entry.Mocks)
+
+			err := entry.Subject.BuildBinaries(entry.Config)
+			ensure(err).IsError(err)
+		})
+	})
+
+	ensure.Run("when all Lambdas are built in parallel at the same time", func(ensure ensuring.E) {
+		ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+			entry := table[i]
+			entry.Subject.Logger = log.New(io.Discard, "", 0)
+			entry.Config.NumParallel = len(entry.Config.Lambdas) + len(entry.Config.Extensions)
+			entry.AssembleMocks(entry.Mocks)
+
+			err := entry.Subject.BuildBinaries(entry.Config)
+			ensure(err).IsError(err)
+		})
+	})
+}
+
+func makeArtifact(kind manifest.ArtifactKind, name, outPath, zippedFileName string) *manifest.Artifact {
+	return &manifest.Artifact{
+		Kind:    kind,
+		Name:    name,
+		ZipPath: outPath + ".zip",
+		Entries: []*manifest.Entry{{Name: zippedFileName, Source: outPath}},
+	}
+}
+
+func TestBuildBinariesWithLayers(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Cmd      *mock_runcmd.MockRunnerAPI
+		Zip      *mock_zipper.MockZipAPI
+		Manifest *mock_manifest.MockStoreAPI
+		SBOM     *mock_sbom.MockWriterAPI
+		Licenses *mock_licenses.MockCollectorAPI
+		Signer   *mock_signing.MockSignerAPI
+		Size     *mock_binsize.MockAnalyzerAPI
+	}
+
+	setupRoot := func(ensure ensuring.E) string {
+		rootPath := ensure.T().TempDir()
+
+		for _, filePath := range []string{"assets/a.json", "assets/b.json", "data/nested/c.txt"} {
+			fullPath := filepath.Join(rootPath, filePath)
+			ensure(os.MkdirAll(filepath.Dir(fullPath), 0o755)).IsNotError()
+			ensure(os.WriteFile(fullPath, []byte(filePath), 0o600)).IsNotError()
+		}
+
+		return rootPath
+	}
+
+	makeConfig := func(rootPath string, files ...*lambgofile.LayerFiles) *lambgofile.Config {
+		return &lambgofile.Config{
+			NumParallel:  1,
+			RootPath:     rootPath,
+			OutDirectory: "out/dir",
+			Goos:         "linux",
+			Goarch:       "amd64",
+			Layers: []*lambgofile.Layer{
+				{
+					Name: "shared",
+					Packages: []*lambgofile.LayerPackage{
+						{Lambda: lambgofile.Lambda{Path: "tools/converter"}, Destination: "bin/"},
+						{Lambda: lambgofile.Lambda{Path: "tools/helper", BuildFlags: []string{"-tags", "prod"}}, Destination: ""},
+					},
+					Files: files,
+				},
+			},
+		}
+	}
+
+	expectBuildPackages := func(m *Mocks, rootPath string) {
+		envVars := map[string]string{"GOOS": "linux", "GOARCH": "amd64"}
+
+		gomock.InOrder(
+			m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+				PWD:     rootPath,
+				CMD:     "go",
+				Args:    []string{"build", "-trimpath", "./tools/converter", "./tools/helper"},
+				EnvVars: envVars,
+			}).Return("", nil),
+			m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+				PWD:     rootPath,
+				CMD:     "go",
+				Args:    []string{"build", "-trimpath", "-o", "out/dir/layers/shared/tools/converter", "./tools/converter"},
+				EnvVars: envVars,
+			}).Return("", nil),
+			m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+				PWD:     rootPath,
+				CMD:     "go",
+				Args:    []string{"build", "-trimpath", "-o", "out/dir/layers/shared/tools/helper", "-tags", "prod", "./tools/helper"},
+				EnvVars: envVars,
+			}).Return("", nil),
+		)
+	}
+
+	ensure.Run("when building layer packages and files", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+		}
+
+		expectedFiles := []*zipper.File{
+			{Path: "out/dir/layers/shared/tools/converter", ZippedName: "bin/converter", Mode: zipper.ExecutableMode},
+			{Path: "out/dir/layers/shared/tools/helper", ZippedName: "helper", Mode: zipper.ExecutableMode},
+			{Path: filepath.Join(rootPath, "assets/a.json"), ZippedName: "lib/data/a.json", Mode: zipper.RegularMode},
+			{Path: filepath.Join(rootPath, "assets/b.json"), ZippedName: "lib/data/b.json", Mode: zipper.RegularMode},
+			{Path: filepath.Join(rootPath, "data/nested/c.txt"), ZippedName: "nested/c.txt", Mode: zipper.RegularMode},
+		}
+
+		expectBuildPackages(m, rootPath)
+		m.Zip.EXPECT().ZipFiles("out/dir/layers/shared.zip", expectedFiles).Return(nil)
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", []*manifest.Artifact{
+			{
+				Kind:    manifest.KindLayer,
+				Name:    "shared",
+				ZipPath: "out/dir/layers/shared.zip",
+				Entries: []*manifest.Entry{
+					{Name: "bin/converter", Source: "out/dir/layers/shared/tools/converter"},
+					{Name: "helper", Source: "out/dir/layers/shared/tools/helper"},
+					{Name: "lib/data/a.json", Source: "assets/a.json"},
+					{Name: "lib/data/b.json", Source: "assets/b.json"},
+					{Name: "nested/c.txt", Source: "data/nested/c.txt"},
+				},
+			},
+		}).Return(nil)
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(makeConfig(rootPath,
+			&lambgofile.LayerFiles{Glob: "assets/*.json", Destination: "lib/data/"},
+			&lambgofile.LayerFiles{Glob: "data/nested", Destination: ""},
+		))
+		ensure(err).IsNotError()
+	})
+
+	ensure.Run("when recording the sizes of the layer packages", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+			Size:     mock_binsize.NewMockAnalyzerAPI(ensure.GoMockController()),
+		}
+
+		expectBuildPackages(m, rootPath)
+		m.Size.EXPECT().Analyze("out/dir/layers/shared/tools/converter").Return(&binsize.Analysis{
+			FileSize: 2048,
+			Packages: []*binsize.Size{{Name: "main", Size: 1024}},
+		}, nil)
+		m.Size.EXPECT().Analyze("out/dir/layers/shared/tools/helper").Return(&binsize.Analysis{FileSize: 1024}, nil)
+		m.Zip.EXPECT().ZipFiles("out/dir/layers/shared.zip", gomock.Any()).Return(nil)
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", []*manifest.Artifact{
+			{
+				Kind:    manifest.KindLayer,
+				Name:    "shared",
+				ZipPath: "out/dir/layers/shared.zip",
+				Entries: []*manifest.Entry{
+					{
+						Name:   "bin/converter",
+						Source: "out/dir/layers/shared/tools/converter",
+						Size: &manifest.BinarySize{
+							BuildPath: "tools/converter",
+							FileSize:  2048,
+							Sections:  []*manifest.Size{},
+							Packages:  []*manifest.Size{{Name: "main", Size: 1024}},
+						},
+					},
+					{
+						Name:   "helper",
+						Source: "out/dir/layers/shared/tools/helper",
+						Size: &manifest.BinarySize{
+							BuildPath: "tools/helper",
+							FileSize:  1024,
+							Sections:  []*manifest.Size{},
+							Packages:  []*manifest.Size{},
+						},
+					},
+					{Name: "lib/data/a.json", Source: "assets/a.json"},
+					{Name: "lib/data/b.json", Source: "assets/b.json"},
+				},
+			},
+		}).Return(nil)
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, SizeAnalyzer: m.Size, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(makeConfig(rootPath, &lambgofile.LayerFiles{Glob: "assets/*.json", Destination: "lib/data/"}))
+		ensure(err).IsNotError()
+	})
+
+	ensure.Run("when the size of a layer package cannot be analyzed", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+			Size:     mock_binsize.NewMockAnalyzerAPI(ensure.GoMockController()),
+		}
+
+		expectBuildPackages(m, rootPath)
+		m.Size.EXPECT().Analyze("out/dir/layers/shared/tools/converter").Return(nil, errors.New("something went wrong"))
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, SizeAnalyzer: m.Size, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(makeConfig(rootPath))
+		ensure(err).IsError(builder.ErrSizeFailed)
+	})
+
+	ensure.Run("when writing an SBOM for the layer packages", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+			SBOM:     mock_sbom.NewMockWriterAPI(ensure.GoMockController()),
+		}
+
+		config := makeConfig(rootPath)
+		config.SBOM = &lambgofile.SBOM{Format: lambgofile.SBOMFormatSPDX}
+		config.Layers = append(config.Layers, &lambgofile.Layer{Name: "assets", Files: []*lambgofile.LayerFiles{{Glob: "assets/*.json"}}})
+
+		expectBuildPackages(m, rootPath)
+		m.Zip.EXPECT().ZipFiles("out/dir/layers/shared.zip", gomock.Any()).Return(nil)
+		m.SBOM.EXPECT().WriteSBOM(&sbom.Params{
+			Name:        "shared",
+			BinaryPaths: []string{"out/dir/layers/shared/tools/converter", "out/dir/layers/shared/tools/helper"},
+			OutPath:     "out/dir/layers/shared.spdx.json",
+		}).Return(nil)
+
+		// Layers with only files do not have an SBOM
+		m.Zip.EXPECT().ZipFiles("out/dir/layers/assets.zip", gomock.Any()).Return(nil)
+
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", []*manifest.Artifact{
+			{
+				Kind:    manifest.KindLayer,
+				Name:    "assets",
+				ZipPath: "out/dir/layers/assets.zip",
+				Entries: []*manifest.Entry{
+					{Name: "a.json", Source: "assets/a.json"},
+					{Name: "b.json", Source: "assets/b.json"},
+				},
+			},
+			{
+				Kind:    manifest.KindLayer,
+				Name:    "shared",
+				ZipPath: "out/dir/layers/shared.zip",
+				Entries: []*manifest.Entry{
+					{Name: "bin/converter", Source: "out/dir/layers/shared/tools/converter"},
+					{Name: "helper", Source: "out/dir/layers/shared/tools/helper"},
+				},
+				SBOMPath: "out/dir/layers/shared.spdx.json",
+			},
+		}).Return(nil)
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, SBOM: m.SBOM, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(config)
+		ensure(err).IsNotError()
+	})
+
+	ensure.Run("when the SBOM for the layer cannot be written", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+			SBOM:     mock_sbom.NewMockWriterAPI(ensure.GoMockController()),
+		}
+
+		config := makeConfig(rootPath)
+		config.SBOM = &lambgofile.SBOM{Format: lambgofile.SBOMFormatCycloneDX}
+
+		expectBuildPackages(m, rootPath)
+		m.Zip.EXPECT().ZipFiles("out/dir/layers/shared.zip", gomock.Any()).Return(nil)
+		m.SBOM.EXPECT().WriteSBOM(gomock.Any()).Return(errors.New("something went wrong"))
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, SBOM: m.SBOM, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(config)
+		ensure(err).IsError(builder.ErrLayerSBOMFailed)
+	})
+
+	ensure.Run("when bundling the licenses of the layer packages", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+			Licenses: mock_licenses.NewMockCollectorAPI(ensure.GoMockController()),
+		}
+
+		config := makeConfig(rootPath)
+		config.Licenses = &lambgofile.Licenses{Bundle: true}
+		config.Layers = append(config.Layers, &lambgofile.Layer{Name: "assets", Files: []*lambgofile.LayerFiles{{Glob: "assets/*.json"}}})
+
+		expectBuildPackages(m, rootPath)
+		m.Licenses.EXPECT().Collect(&licenses.Params{
+			Name:        "shared",
+			RootPath:    rootPath,
+			BinaryPaths: []string{"out/dir/layers/shared/tools/converter", "out/dir/layers/shared/tools/helper"},
+			OutPath:     "out/dir/layers/shared.THIRD_PARTY_LICENSES",
+		}).Return(nil)
+		m.Zip.EXPECT().ZipFiles("out/dir/layers/shared.zip", []*zipper.File{
+			{Path: "out/dir/layers/shared/tools/converter", ZippedName: "bin/converter", Mode: zipper.ExecutableMode},
+			{Path: "out/dir/layers/shared/tools/helper", ZippedName: "helper", Mode: zipper.ExecutableMode},
+			{Path: "out/dir/layers/shared.THIRD_PARTY_LICENSES", ZippedName: "THIRD_PARTY_LICENSES", Mode: zipper.RegularMode},
+		}).Return(nil)
+
+		// Layers with only files do not have any licenses to collect
+		m.Zip.EXPECT().ZipFiles("out/dir/layers/assets.zip", gomock.Any()).Return(nil)
+
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", []*manifest.Artifact{
+			{
+				Kind:    manifest.KindLayer,
+				Name:    "assets",
+				ZipPath: "out/dir/layers/assets.zip",
+				Entries: []*manifest.Entry{
+					{Name: "a.json", Source: "assets/a.json"},
+					{Name: "b.json", Source: "assets/b.json"},
+				},
+			},
+			{
+				Kind:    manifest.KindLayer,
+				Name:    "shared",
+				ZipPath: "out/dir/layers/shared.zip",
+				Entries: []*manifest.Entry{
+					{Name: "bin/converter", Source: "out/dir/layers/shared/tools/converter"},
+					{Name: "helper", Source: "out/dir/layers/shared/tools/helper"},
+					{Name: "THIRD_PARTY_LICENSES", Source: "out/dir/layers/shared.THIRD_PARTY_LICENSES"},
+				},
+			},
+		}).Return(nil)
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, Licenses: m.Licenses, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(config)
+		ensure(err).IsNotError()
+	})
+
+	ensure.Run("when the layer packages use disallowed licenses", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+			Licenses: mock_licenses.NewMockCollectorAPI(ensure.GoMockController()),
+		}
+
+		config := makeConfig(rootPath)
+		config.Licenses = &lambgofile.Licenses{Disallowed: []string{licenses.Unknown}}
+
+		expectBuildPackages(m, rootPath)
+		m.Licenses.EXPECT().Collect(gomock.Any()).Return(errors.New("something went wrong"))
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, Licenses: m.Licenses, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(config)
+		ensure(err).IsError(builder.ErrLayerLicensesFailed)
+	})
+
+	ensure.Run("when signing the layer", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+			Signer:   mock_signing.NewMockSignerAPI(ensure.GoMockController()),
+		}
+
+		config := makeConfig(rootPath)
+		config.Signing = &lambgofile.Signing{KeyFile: "keys/signing.pem"}
+
+		signature := &manifest.Signature{
+			Path:      "out/dir/layers/shared.zip.sig",
+			Algorithm: "ecdsa-p256-sha256",
+			KeyID:     "sha256:abc",
+			ZipDigest: "sha256:def",
+		}
+
+		expectBuildPackages(m, rootPath)
+		m.Zip.EXPECT().ZipFiles("out/dir/layers/shared.zip", gomock.Any()).Return(nil)
+		m.Signer.EXPECT().Sign(&signing.Params{
+			ZipPath: "out/dir/layers/shared.zip",
+			KeyPath: filepath.Join(rootPath, "keys/signing.pem"),
+			OutPath: "out/dir/layers/shared.zip.sig",
+		}).Return(signature, nil)
+
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", []*manifest.Artifact{
+			{
+				Kind:    manifest.KindLayer,
+				Name:    "shared",
+				ZipPath: "out/dir/layers/shared.zip",
+				Entries: []*manifest.Entry{
+					{Name: "bin/converter", Source: "out/dir/layers/shared/tools/converter"},
+					{Name: "helper", Source: "out/dir/layers/shared/tools/helper"},
+				},
+				Signature: signature,
+			},
+		}).Return(nil)
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, Signer: m.Signer, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(config)
+		ensure(err).IsNotError()
+	})
+
+	ensure.Run("when the layer cannot be signed", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+			Signer:   mock_signing.NewMockSignerAPI(ensure.GoMockController()),
+		}
+
+		config := makeConfig(rootPath)
+		config.Signing = &lambgofile.Signing{KeyFile: "keys/signing.pem"}
+
+		expectBuildPackages(m, rootPath)
+		m.Zip.EXPECT().ZipFiles("out/dir/layers/shared.zip", gomock.Any()).Return(nil)
+		m.Signer.EXPECT().Sign(gomock.Any()).Return(nil, errors.New("something went wrong"))
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, Signer: m.Signer, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(config)
+		ensure(err).IsError(builder.ErrLayerSigningFailed)
+	})
+
+	ensure.Run("when a matched file is executable", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		ensure(os.MkdirAll(filepath.Join(rootPath, "scripts"), 0o755)).IsNotError()
+		ensure(os.WriteFile(filepath.Join(rootPath, "scripts/run.sh"), []byte("#!/bin/sh\n"), 0o755)).IsNotError()
+		ensure(os.WriteFile(filepath.Join(rootPath, "scripts/README.md"), []byte("scripts"), 0o600)).IsNotError()
+
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+		}
+
+		expectBuildPackages(m, rootPath)
+		m.Zip.EXPECT().ZipFiles("out/dir/layers/shared.zip", []*zipper.File{
+			{Path: "out/dir/layers/shared/tools/converter", ZippedName: "bin/converter", Mode: zipper.ExecutableMode},
+			{Path: "out/dir/layers/shared/tools/helper", ZippedName: "helper", Mode: zipper.ExecutableMode},
+			{Path: filepath.Join(rootPath, "scripts/README.md"), ZippedName: "scripts/README.md", Mode: zipper.RegularMode},
+			{Path: filepath.Join(rootPath, "scripts/run.sh"), ZippedName: "scripts/run.sh", Mode: zipper.ExecutableMode},
+		}).Return(nil)
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", gomock.Any()).Return(nil)
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(makeConfig(rootPath, &lambgofile.LayerFiles{Glob: "scripts"}))
+		ensure(err).IsNotError()
+	})
+
+	ensure.Run("when a glob does not match any files", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+		}
+
+		expectBuildPackages(m, rootPath)
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(makeConfig(rootPath, &lambgofile.LayerFiles{Glob: "missing/*.json"}))
+		ensure(err).IsError(builder.ErrLayerGlobNoMatches)
+	})
+
+	ensure.Run("when the layer cannot be zipped", func(ensure ensuring.E) {
+		rootPath := setupRoot(ensure)
+		m := &Mocks{
+			Cmd:      mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:      mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest: mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+		}
+
+		expectBuildPackages(m, rootPath)
+		m.Zip.EXPECT().ZipFiles("out/dir/layers/shared.zip", gomock.Any()).Return(errors.New("something went wrong"))
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(makeConfig(rootPath))
+		ensure(err).IsError(builder.ErrLayerZipFailed)
+	})
+}
+
+func TestBuildBinariesScheduling(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Cmd        *mock_runcmd.MockRunnerAPI
+		Zip        *mock_zipper.MockZipAPI
+		Manifest   *mock_manifest.MockStoreAPI
+		BuildTimes *mock_buildtimes.MockStoreAPI
+	}
+
+	envVars := map[string]string{"GOOS": "linux", "GOARCH": "amd64"}
+
+	newMocks := func(ensure ensuring.E) *Mocks {
+		return &Mocks{
+			Cmd:        mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:        mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest:   mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+			BuildTimes: mock_buildtimes.NewMockStoreAPI(ensure.GoMockController()),
+		}
+	}
+
+	newConfig := func(outDirectory string, numParallel int) *lambgofile.Config {
+		return &lambgofile.Config{
+			NumParallel:  numParallel,
+			RootPath:     "/my/root",
+			OutDirectory: outDirectory,
+			Goos:         "linux",
+			Goarch:       "amd64",
+			Lambdas: []*lambgofile.Lambda{
+				{Path: "lambdas/path1"},
+				{Path: "lambdas/path2"},
+				{Path: "lambdas/path3"},
+			},
+		}
+	}
+
+	expectBuild := func(m *Mocks, outDirectory, name string, procsFlag ...string) *gomock.Call {
+		args := append([]string{"build"}, procsFlag...)
+		args = append(args, "-trimpath", "-o", outDirectory+"/lambdas/"+name, "./lambdas/"+name)
+
+		return m.Cmd.EXPECT().Exec(&runcmd.ExecParams{PWD: "/my/root", CMD: "go", Args: args, EnvVars: envVars}).Return("", nil)
+	}
+
+	ensure.Run("when a previous build recorded durations, it builds the slowest first", func(ensure ensuring.E) {
+		m := newMocks(ensure)
+		m.BuildTimes.EXPECT().Read("out/dir/.lambgo-build-times.json").Return(map[string]time.Duration{
+			"out/dir/lambdas/path1": 100 * time.Millisecond,
+			"out/dir/lambdas/path2": 300 * time.Millisecond,
+		}, nil)
+
+		gomock.InOrder(
+			// The new Lambda is built first, since its duration is unknown
+			m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+				PWD:     "/my/root",
+				CMD:     "go",
+				Args:    []string{"build", "-trimpath", "./lambdas/path3", "./lambdas/path2", "./lambdas/path1"},
+				EnvVars: envVars,
+			}).Return("", nil),
+			expectBuild(m, "out/dir", "path3"),
+			m.Zip.EXPECT().ZipFile("out/dir/lambdas/path3", "path3").Return(nil),
+			expectBuild(m, "out/dir", "path2"),
+			m.Zip.EXPECT().ZipFile("out/dir/lambdas/path2", "path2").Return(nil),
+			expectBuild(m, "out/dir", "path1"),
+			m.Zip.EXPECT().ZipFile("out/dir/lambdas/path1", "path1").Return(nil),
+		)
+
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", []*manifest.Artifact{
+			makeArtifact(manifest.KindLambda, "lambdas/path1", "out/dir/lambdas/path1", "path1"),
+			makeArtifact(manifest.KindLambda, "lambdas/path2", "out/dir/lambdas/path2", "path2"),
+			makeArtifact(manifest.KindLambda, "lambdas/path3", "out/dir/lambdas/path3", "path3"),
+		}).Return(nil)
+		m.BuildTimes.EXPECT().Update("out/dir/.lambgo-build-times.json", map[string]time.Duration{
+			"out/dir/lambdas/path1": 1500 * time.Millisecond,
+			"out/dir/lambdas/path2": 1500 * time.Millisecond,
+			"out/dir/lambdas/path3": 1500 * time.Millisecond,
+		}).Return(nil)
+
+		// Each call to Now advances the clock, so each go build takes 1.5s
+		now := time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC)
+		subject := &builder.LambdaBuilder{
+			Cmd:        m.Cmd,
+			Zip:        m.Zip,
+			Manifest:   m.Manifest,
+			BuildTimes: m.BuildTimes,
+			Logger:     log.New(io.Discard, "", 0),
+			Now: func() time.Time {
+				now = now.Add(1500 * time.Millisecond)
+				return now
+			},
+		}
+
+		err := subject.BuildBinaries(newConfig("out/dir", 1))
+		ensure(err).IsNotError()
+	})
+
+	ensure.Run("with an absolute outDirectory, the durations are relative to the module root", func(ensure ensuring.E) {
+		m := newMocks(ensure)
+		m.BuildTimes.EXPECT().Read("/my/root/out/.lambgo-build-times.json").Return(map[string]time.Duration{
+			"out/lambdas/path1": 300 * time.Millisecond,
+			"out/lambdas/path2": 100 * time.Millisecond,
+			"out/lambdas/path3": 200 * time.Millisecond,
+		}, nil)
+
+		gomock.InOrder(
+			m.Cmd.EXPECT().Exec(gomock.Any()).Return("", nil),
+			expectBuild(m, "/my/root/out", "path1"),
+			m.Zip.EXPECT().ZipFile("/my/root/out/lambdas/path1", "path1").Return(nil),
+			expectBuild(m, "/my/root/out", "path3"),
+			m.Zip.EXPECT().ZipFile("/my/root/out/lambdas/path3", "path3").Return(nil),
+			expectBuild(m, "/my/root/out", "path2"),
+			m.Zip.EXPECT().ZipFile("/my/root/out/lambdas/path2", "path2").Return(nil),
+		)
+
+		m.Manifest.EXPECT().Update("/my/root/out/lambgo-manifest.json", gomock.Any()).Return(nil)
+		m.BuildTimes.EXPECT().Update("/my/root/out/.lambgo-build-times.json", map[string]time.Duration{
+			"out/lambdas/path1": 0,
+			"out/lambdas/path2": 0,
+			"out/lambdas/path3": 0,
+		}).Return(nil)
+
+		fixedTime := time.Date(2009, 11, 10, 23, 0, 0, 0, time.UTC)
+		subject := &builder.LambdaBuilder{
+			Cmd:        m.Cmd,
+			Zip:        m.Zip,
+			Manifest:   m.Manifest,
+			BuildTimes: m.BuildTimes,
+			Logger:     log.New(io.Discard, "", 0),
+			Now:        func() time.Time { return fixedTime },
+		}
+
+		err := subject.BuildBinaries(newConfig("/my/root/out", 1))
+		ensure(err).IsNotError()
+	})
+
+	ensure.Run("when the build times cannot be read, it builds in the order of the config", func(ensure ensuring.E) {
+		m := newMocks(ensure)
+		m.BuildTimes.EXPECT().Read("out/dir/.lambgo-build-times.json").Return(nil, errors.New("invalid JSON"))
+
+		gomock.InOrder(
+			m.Cmd.EXPECT().Exec(gomock.Any()).Return("", nil),
+			expectBuild(m, "out/dir", "path1"),
+			m.Zip.EXPECT().ZipFile("out/dir/lambdas/path1", "path1").Return(nil),
+			expectBuild(m, "out/dir", "path2"),
+			m.Zip.EXPECT().ZipFile("out/dir/lambdas/path2", "path2").Return(nil),
+			expectBuild(m, "out/dir", "path3"),
+			m.Zip.EXPECT().ZipFile("out/dir/lambdas/path3", "path3").Return(nil),
+		)
+
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", gomock.Any()).Return(nil)
+		m.BuildTimes.EXPECT().Update("out/dir/.lambgo-build-times.json", gomock.Any()).Return(nil)
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, BuildTimes: m.BuildTimes, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(newConfig("out/dir", 1))
+		ensure(err).IsNotError()
+	})
+
+	ensure.Run("when the build times cannot be updated", func(ensure ensuring.E) {
+		m := newMocks(ensure)
+		m.BuildTimes.EXPECT().Read("out/dir/.lambgo-build-times.json").Return(map[string]time.Duration{}, nil)
+		m.Cmd.EXPECT().Exec(gomock.Any()).Return("", nil).Times(4)
+		m.Zip.EXPECT().ZipFile(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", gomock.Any()).Return(nil)
+		m.BuildTimes.EXPECT().Update("out/dir/.lambgo-build-times.json", gomock.Any()).Return(errors.New("read-only file system"))
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, BuildTimes: m.BuildTimes, Logger: log.New(io.Discard, "", 0)}
+		err := subject.BuildBinaries(newConfig("out/dir", 1))
+		ensure(err).IsError(builder.ErrBuildTimesWriteFailed)
+	})
+
+	ensure.Run("when building in parallel, it splits the CPUs and limits the builds by memory", func(ensure ensuring.E) {
+		m := newMocks(ensure)
+		m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+			PWD:     "/my/root",
+			CMD:     "go",
+			Args:    []string{"build", "-trimpath", "./lambdas/path1", "./lambdas/path2", "./lambdas/path3"},
+			EnvVars: envVars,
+		}).Return("", nil)
+
+		for _, name := range []string{"path1", "path2", "path3"} {
+			expectBuild(m, "out/dir", name, "-p", "4")
+			m.Zip.EXPECT().ZipFile("out/dir/lambdas/"+name, name).Return(nil)
+		}
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", gomock.Any()).Return(nil)
+
+		output := &bytes.Buffer{}
+		subject := &builder.LambdaBuilder{
+			Cmd:             m.Cmd,
+			Zip:             m.Zip,
+			Manifest:        m.Manifest,
+			Logger:          log.New(output, "", 0),
+			NumCPU:          8,
+			AvailableMemory: func() (uint64, bool) { return 5 << 29, true }, // 2.5 GiB
+		}
+
+		err := subject.BuildBinaries(newConfig("out/dir", 3))
+		ensure(err).IsNotError()
+		ensure(output.String()).Contains("Limiting to 2 builds in parallel, since 2.5 GiB of memory is available\n")
+		ensure(output.String()).Contains("Building 3 Lambdas in parallel groups of 2:\n")
+	})
+
+	ensure.Run("when the available memory is unknown, it does not limit the builds", func(ensure ensuring.E) {
+		m := newMocks(ensure)
+		m.Cmd.EXPECT().Exec(gomock.Any()).Return("", nil)
+
+		for _, name := range []string{"path1", "path2", "path3"} {
+			expectBuild(m, "out/dir", name, "-p", "2")
+			m.Zip.EXPECT().ZipFile("out/dir/lambdas/"+name, name).Return(nil)
+		}
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", gomock.Any()).Return(nil)
+
+		output := &bytes.Buffer{}
+		subject := &builder.LambdaBuilder{
+			Cmd:             m.Cmd,
+			Zip:             m.Zip,
+			Manifest:        m.Manifest,
+			Logger:          log.New(output, "", 0),
+			NumCPU:          6,
+			AvailableMemory: func() (uint64, bool) { return 0, false },
+		}
+
+		err := subject.BuildBinaries(newConfig("out/dir", 3))
+		ensure(err).IsNotError()
+		ensure(output.String()).Contains("Building 3 Lambdas all at once:\n")
+	})
+
+	ensure.Run("when building groups, it splits the CPUs between the groups", func(ensure ensuring.E) {
+		config := newConfig("out/dir", 4)
+		config.GroupBuilds = true
+		config.Extensions = []*lambgofile.Extension{
+			{Lambda: lambgofile.Lambda{Path: "extensions/telemetry"}, Name: "telemetry"},
+			{Lambda: lambgofile.Lambda{Path: "extensions/logs"}, Name: "logs"},
+		}
+
+		m := newMocks(ensure)
+		m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+			PWD:     "/my/root",
+			CMD:     "go",
+			Args:    []string{"build", "-p", "4", "-trimpath", "-o", "out/dir/lambdas/", "./lambdas/path1", "./lambdas/path2", "./lambdas/path3"},
+			EnvVars: envVars,
+		}).Return("", nil)
+		m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+			PWD:     "/my/root",
+			CMD:     "go",
+			Args:    []string{"build", "-p", "4", "-trimpath", "-o", "out/dir/extensions/", "./extensions/telemetry", "./extensions/logs"},
+			EnvVars: envVars,
+		}).Return("", nil)
+
+		m.Zip.EXPECT().ZipFile(gomock.Any(), gomock.Any()).Return(nil).Times(5)
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", gomock.Any()).Return(nil)
+
+		subject := &builder.LambdaBuilder{
+			Cmd:      m.Cmd,
+			Zip:      m.Zip,
+			Manifest: m.Manifest,
+			Logger:   log.New(io.Discard, "", 0),
+			NumCPU:   8,
+		}
+
+		err := subject.BuildBinaries(config)
+		ensure(err).IsNotError()
+	})
+}
+
+func TestBuildBinariesSizes(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Cmd          *mock_runcmd.MockRunnerAPI
+		Zip          *mock_zipper.MockZipAPI
+		Manifest     *mock_manifest.MockStoreAPI
+		SizeAnalyzer *mock_binsize.MockAnalyzerAPI
+	}
+
+	envVars := map[string]string{"GOOS": "linux", "GOARCH": "amd64"}
+
+	newMocks := func(ensure ensuring.E) *Mocks {
+		return &Mocks{
+			Cmd:          mock_runcmd.NewMockRunnerAPI(ensure.GoMockController()),
+			Zip:          mock_zipper.NewMockZipAPI(ensure.GoMockController()),
+			Manifest:     mock_manifest.NewMockStoreAPI(ensure.GoMockController()),
+			SizeAnalyzer: mock_binsize.NewMockAnalyzerAPI(ensure.GoMockController()),
+		}
+	}
+
+	config := func() *lambgofile.Config {
+		return &lambgofile.Config{
+			NumParallel:  1,
+			RootPath:     "/my/root",
+			OutDirectory: "out/dir",
+			Goos:         "linux",
+			Goarch:       "amd64",
+			Lambdas:      []*lambgofile.Lambda{{Path: "lambdas/api"}},
+		}
+	}
+
+	ensure.Run("when the size of each binary is recorded in the manifest", func(ensure ensuring.E) {
+		m := newMocks(ensure)
+
+		gomock.InOrder(
+			m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+				PWD:     "/my/root",
+				CMD:     "go",
+				Args:    []string{"build", "-trimpath", "-o", "out/dir/lambdas/api", "./lambdas/api"},
+				EnvVars: envVars,
+			}).Return("", nil),
+			m.SizeAnalyzer.EXPECT().Analyze("out/dir/lambdas/api").Return(&binsize.Analysis{
+				Path:     "out/dir/lambdas/api",
+				FileSize: 4096,
+				Stripped: true,
+				Sections: []*binsize.Size{{Name: ".text", Size: 2048}, {Name: ".rodata", Size: 1024}},
+				Packages: []*binsize.Size{{Name: "runtime", Size: 1536}, {Name: "main", Size: 512}},
+				Symbols:  []*binsize.Symbol{{Name: "main.main", Package: "main", Size: 512}},
+			}, nil),
+			m.Zip.EXPECT().ZipFile("out/dir/lambdas/api", "api").Return(nil),
+		)
+
+		m.Manifest.EXPECT().Update("out/dir/lambgo-manifest.json", []*manifest.Artifact{
+			{
+				Kind:    manifest.KindLambda,
+				Name:    "lambdas/api",
+				ZipPath: "out/dir/lambdas/api.zip",
+				Entries: []*manifest.Entry{
+					{
+						Name:   "api",
+						Source: "out/dir/lambdas/api",
+						Size: &manifest.BinarySize{
+							BuildPath: "lambdas/api",
+							FileSize:  4096,
+							Stripped:  true,
+							Sections:  []*manifest.Size{{Name: ".text", Size: 2048}, {Name: ".rodata", Size: 1024}},
+							Packages:  []*manifest.Size{{Name: "runtime", Size: 1536}, {Name: "main", Size: 512}},
+						},
+					},
+				},
+			},
+		}).Return(nil)
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, SizeAnalyzer: m.SizeAnalyzer, Logger: log.New(io.Discard, "", 0)}
+		ensure(subject.BuildBinaries(config())).IsNotError()
+	})
+
+	ensure.Run("when the size of a binary cannot be analyzed", func(ensure ensuring.E) {
+		m := newMocks(ensure)
+
+		gomock.InOrder(
+			m.Cmd.EXPECT().Exec(gomock.Any()).Return("", nil),
+			m.SizeAnalyzer.EXPECT().Analyze("out/dir/lambdas/api").Return(nil, errors.New("something went wrong")),
+		)
+
+		subject := &builder.LambdaBuilder{Cmd: m.Cmd, Zip: m.Zip, Manifest: m.Manifest, SizeAnalyzer: m.SizeAnalyzer, Logger: log.New(io.Discard, "", 0)}
+		ensure(subject.BuildBinaries(config())).IsError(builder.ErrSizeFailed)
+	})
+}
+
+func TestBuildForHost(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Cmd *mock_runcmd.MockRunnerAPI
+	}
+
+	hostEnvVars := map[string]string{
+		"GOOS":   runtime.GOOS,
+		"GOARCH": runtime.GOARCH,
+	}
+
+	table := []struct {
+		Name         string
+		Config       *lambgofile.Config
+		Lambda       *lambgofile.Lambda
+		ExpectedPath string
+
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *builder.LambdaBuilder
+	}{
+		{
+			Name:         "with relative outDirectory",
+			Config:       &lambgofile.Config{RootPath: "/my/root", OutDirectory: "out/dir", Goos: "linux", Goarch: "arm64"},
+			Lambda:       &lambgofile.Lambda{Path: "lambdas/api", BuildFlags: []string{"-tags", "local"}},
+			ExpectedPath: "/my/root/out/dir/host/lambdas/api",
+			SetupMocks: func(m *Mocks) {
+				m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+					PWD:     "/my/root",
+					CMD:     "go",
+					Args:    []string{"build", "-trimpath", "-o", "/my/root/out/dir/host/lambdas/api", "-tags", "local", "./lambdas/api"},
+					EnvVars: hostEnvVars,
+				}).Return("", nil)
+			},
+		},
+
+		{
+			Name:         "with absolute outDirectory",
+			Config:       &lambgofile.Config{RootPath: "/my/root", OutDirectory: "/abs/out"},
+			Lambda:       &lambgofile.Lambda{Path: "lambdas/api"},
+			ExpectedPath: "/abs/out/host/lambdas/api",
+			SetupMocks: func(m *Mocks) {
+				m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+					PWD:     "/my/root",
+					CMD:     "go",
+					Args:    []string{"build", "-trimpath", "-o", "/abs/out/host/lambdas/api", "./lambdas/api"},
+					EnvVars: hostEnvVars,
+				}).Return("", nil)
+			},
+		},
+
+		{
+			Name:         "with default outDirectory",
+			Config:       &lambgofile.Config{RootPath: "/my/root"},
+			Lambda:       &lambgofile.Lambda{Path: "lambdas/api"},
+			ExpectedPath: "/my/root/tmp/host/lambdas/api",
+			SetupMocks: func(m *Mocks) {
+				m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+					PWD:     "/my/root",
+					CMD:     "go",
+					Args:    []string{"build", "-trimpath", "-o", "/my/root/tmp/host/lambdas/api", "./lambdas/api"},
+					EnvVars: hostEnvVars,
+				}).Return("", nil)
+			},
+		},
+
+		{
+			Name: "with Lambda in workspace module",
+			Config: &lambgofile.Config{
+				RootPath:  "/my/root",
+				Workspace: &lambgofile.Workspace{FilePath: "/my/root/go.work"},
+			},
+			Lambda:       &lambgofile.Lambda{Path: "services/search/cmd/api", Module: "services/search"},
+			ExpectedPath: "/my/root/tmp/host/services/search/cmd/api",
+			SetupMocks: func(m *Mocks) {
+				m.Cmd.EXPECT().Exec(&runcmd.ExecParams{
+					PWD:  "/my/root/services/search",
+					CMD:  "go",
+					Args: []string{"build", "-trimpath", "-o", "/my/root/tmp/host/services/search/cmd/api", "./cmd/api"},
+					EnvVars: map[string]string{
+						"GOOS":   runtime.GOOS,
+						"GOARCH": runtime.GOARCH,
+						"GOWORK": "/my/root/go.work",
+					},
+				}).Return("", nil)
+			},
+		},
+
+		{
+			Name:          "with build error",
+			Config:        &lambgofile.Config{RootPath: "/my/root", OutDirectory: "tmp"},
+			Lambda:        &lambgofile.Lambda{Path: "lambdas/api"},
+			ExpectedError: builder.ErrGoBuildFailed,
+			SetupMocks: func(m *Mocks) {
+				m.Cmd.EXPECT().Exec(gomock.Any()).Return("", errors.New("compile error"))
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		binaryPath, err := entry.Subject.BuildForHost(entry.Config, entry.Lambda)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(binaryPath).Equals(entry.ExpectedPath)
+	})
+}
+
+func TestBinaries(t *testing.T) {
+	ensure := ensure.New(t)
+
+	table := []struct {
+		Name             string
+		Config           *lambgofile.Config
+		ExpectedBinaries []*builder.Binary
+	}{
+		{
+			Name: "with Lambdas, extensions, and layer packages",
+			Config: &lambgofile.Config{
+				RootPath:     "/my/root",
+				OutDirectory: "out/dir",
+				Lambdas:      []*lambgofile.Lambda{{Path: "lambdas/api"}},
+				Extensions:   []*lambgofile.Extension{{Lambda: lambgofile.Lambda{Path: "extensions/telemetry"}, Name: "telemetry"}},
+				Layers: []*lambgofile.Layer{
+					{Name: "shared", Packages: []*lambgofile.LayerPackage{{Lambda: lambgofile.Lambda{Path: "tools/converter"}}}},
+				},
+			},
+			ExpectedBinaries: []*builder.Binary{
+				{Kind: manifest.KindLambda, BuildPath: "lambdas/api", OutPath: "/my/root/out/dir/lambdas/api"},
+				{Kind: manifest.KindExtension, BuildPath: "extensions/telemetry", OutPath: "/my/root/out/dir/extensions/telemetry"},
+				{Kind: manifest.KindLayer, BuildPath: "tools/converter", Layer: "shared", OutPath: "/my/root/out/dir/layers/shared/tools/converter"},
+			},
+		},
+
+		{
+			Name:   "with absolute outDirectory",
+			Config: &lambgofile.Config{RootPath: "/my/root", OutDirectory: "/abs/out", Lambdas: []*lambgofile.Lambda{{Path: "lambdas/api"}}},
+			ExpectedBinaries: []*builder.Binary{
+				{Kind: manifest.KindLambda, BuildPath: "lambdas/api", OutPath: "/abs/out/lambdas/api"},
+			},
+		},
+
+		{
+			Name:   "with default outDirectory",
+			Config: &lambgofile.Config{RootPath: "/my/root", Lambdas: []*lambgofile.Lambda{{Path: "lambdas/api"}}},
+			ExpectedBinaries: []*builder.Binary{
+				{Kind: manifest.KindLambda, BuildPath: "lambdas/api", OutPath: "/my/root/tmp/lambdas/api"},
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		ensure(builder.Binaries(entry.Config)).Equals(entry.ExpectedBinaries)
 	})
 }
@@ -0,0 +1,136 @@
+package builder
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/runcmd"
+	"github.com/JosiahWitt/lambgo/internal/versioninfo"
+)
+
+// majorVersionPattern matches the major version suffix of an import path, which the go command skips when naming binaries.
+var majorVersionPattern = regexp.MustCompile(`^v[0-9]+$`)
+
+// buildGroup is the targets built with a single `go build -o <dir>/` command.
+type buildGroup struct {
+	pwd     string
+	args    []string
+	targets []*buildTarget
+}
+
+// buildGroups builds the targets that share a module, output directory, and build flags with one `go build` per group,
+// which avoids starting a go command and planning the build for each target.
+// It returns whether each grouped target was built. When a group fails, its targets are built separately, so the error can be attributed.
+func (b *LambdaBuilder) buildGroups(params *sharedBuilderParams, targets []*buildTarget, numParallel int) map[*buildTarget]bool {
+	config := params.config
+	groups := groupTargets(config, params.versionInfo, targets)
+	built := make(map[*buildTarget]bool)
+	if len(groups) == 0 {
+		return built
+	}
+
+	numGrouped := 0
+	for _, group := range groups {
+		numGrouped += len(group.targets)
+	}
+
+	if len(groups) == 1 {
+		b.Logger.Printf("Building %d Lambdas with 1 `go build`...\n", numGrouped)
+	} else {
+		b.Logger.Printf("Building %d Lambdas with %d `go build`s...\n", numGrouped, len(groups))
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+	)
+
+	numConcurrent := min(numParallel, len(groups))
+	procsFlag := b.procsFlag(numConcurrent)
+
+	semaphore := make(chan struct{}, b.numWorkers(numConcurrent))
+	for _, group := range groups {
+		wg.Add(1)
+		semaphore <- struct{}{}
+
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			start := b.now()
+			_, err := b.Cmd.Exec(&runcmd.ExecParams{
+				PWD:  group.pwd,
+				CMD:  "go",
+				Args: slices.Insert(slices.Clone(group.args), 1, procsFlag...),
+
+				EnvVars: buildEnvVars(config),
+			})
+			duration := b.now().Sub(start)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				b.Logger.Printf(" - Unable to build %d Lambdas together, so building them separately\n", len(group.targets))
+			}
+
+			for _, target := range group.targets {
+				built[target] = err == nil
+				if err == nil {
+					params.recordDuration(target.outPath, duration)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	return built
+}
+
+// groupTargets by their module, output directory, and build flags, in the order they are built.
+// Groups with a single target are omitted, since they are built like any other target.
+func groupTargets(config *lambgofile.Config, versionInfo *versioninfo.Info, targets []*buildTarget) []*buildGroup {
+	var groups []*buildGroup
+	groupsByKey := make(map[string]*buildGroup)
+
+	for _, target := range targets {
+		pwd, buildPath := goPackage(config, target.lambda)
+		if !canGroup(config, buildPath, target.outPath) {
+			continue
+		}
+
+		// The trailing separator tells the go command to write each binary into the directory
+		outDirectory := filepath.Dir(target.outPath)
+		if pwd != config.RootPath && !filepath.IsAbs(outDirectory) {
+			outDirectory = filepath.Join(config.RootPath, outDirectory)
+		}
+		outDirectory += string(filepath.Separator)
+
+		flags := lambdaBuildFlags(config, versionInfo, outDirectory, target.lambda)
+		key := pwd + "\x00" + strings.Join(flags, "\x00")
+
+		group, ok := groupsByKey[key]
+		if !ok {
+			group = &buildGroup{pwd: pwd, args: append([]string{"build"}, flags...)}
+			groupsByKey[key] = group
+			groups = append(groups, group)
+		}
+
+		group.args = append(group.args, buildPath)
+		group.targets = append(group.targets, target)
+	}
+
+	return slices.DeleteFunc(groups, func(group *buildGroup) bool { return len(group.targets) < 2 }) //nolint:mnd
+}
+
+// canGroup when the go command names the binary like its outPath, which is the last element of the package path.
+// Packages at the root of a module are named after the module path, major version suffixes are skipped,
+// and Windows binaries have an .exe extension, so those are always built separately.
+func canGroup(config *lambgofile.Config, buildPath, outPath string) bool {
+	name := filepath.Base(buildPath)
+	return buildPath != "." && config.Goos != "windows" && !majorVersionPattern.MatchString(name) && name == filepath.Base(outPath)
+}
@@ -0,0 +1,192 @@
+package builder
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/JosiahWitt/lambgo/internal/zipper"
+)
+
+// zipLayer containing the already built layer packages and the files matching the layer's globs.
+func (b *LambdaBuilder) zipLayer(config *lambgofile.Config, layer *lambgofile.Layer) (*manifest.Artifact, error) {
+	files := make([]*zipper.File, 0, len(layer.Packages))
+	binaryPaths := make([]string, 0, len(layer.Packages))
+	binarySizes := make(map[string]*manifest.BinarySize, len(layer.Packages))
+
+	for _, layerPackage := range layer.Packages {
+		binaryPath := layerPackageOutPath(config, layer, layerPackage)
+		size, err := b.binarySize(layerPackage.Path, binaryPath)
+		if err != nil {
+			return nil, err
+		}
+
+		binarySizes[binaryPath] = size
+		binaryPaths = append(binaryPaths, binaryPath)
+		files = append(files, &zipper.File{
+			Path:       binaryPath,
+			ZippedName: layerPackage.Destination + filepath.Base(layerPackage.Path),
+			Mode:       zipper.ExecutableMode,
+		})
+	}
+
+	for _, layerFiles := range layer.Files {
+		matchedFiles, err := findLayerFiles(config, layer, layerFiles)
+		if err != nil {
+			return nil, err
+		}
+
+		files = append(files, matchedFiles...)
+	}
+
+	outPath := buildOutPath(config, layer.Path())
+
+	// Layers with only data files do not contain any modules to collect licenses for
+	if config.Licenses != nil && len(binaryPaths) > 0 {
+		licensesFile, err := b.collectLicenses(config, layer.Name, binaryPaths, outPath)
+		if err != nil {
+			return nil, erk.WrapWith(ErrLayerLicensesFailed, err, erk.Params{
+				"layer": layer.Name,
+			})
+		}
+
+		if licensesFile != nil {
+			files = append(files, licensesFile)
+		}
+	}
+
+	zipPath := outPath + ".zip"
+	if err := b.Zip.ZipFiles(zipPath, files); err != nil {
+		return nil, erk.WrapWith(ErrLayerZipFailed, err, erk.Params{
+			"layer":   layer.Name,
+			"zipPath": zipPath,
+		})
+	}
+
+	artifact := &manifest.Artifact{
+		Kind:    manifest.KindLayer,
+		Name:    layer.Name,
+		ZipPath: zipPath,
+		Entries: make([]*manifest.Entry, 0, len(files)),
+	}
+
+	if config.Signing != nil {
+		signature, err := b.signZip(config, zipPath)
+		if err != nil {
+			return nil, erk.WrapWith(ErrLayerSigningFailed, err, erk.Params{
+				"layer":   layer.Name,
+				"zipPath": zipPath,
+			})
+		}
+
+		artifact.Signature = signature
+	}
+
+	for _, file := range files {
+		artifact.Entries = append(artifact.Entries, &manifest.Entry{
+			Name:   file.ZippedName,
+			Source: relativeToRoot(config, file.Path),
+			Size:   binarySizes[file.Path],
+		})
+	}
+
+	// Layers with only data files do not contain any modules to list
+	if config.SBOM != nil && len(binaryPaths) > 0 {
+		sbomPath := sbomOutPath(config, outPath)
+		if err := b.writeSBOM(config, layer.Name, binaryPaths, sbomPath); err != nil {
+			return nil, erk.WrapWith(ErrLayerSBOMFailed, err, erk.Params{
+				"layer":    layer.Name,
+				"sbomPath": sbomPath,
+			})
+		}
+
+		artifact.SBOMPath = sbomPath
+	}
+
+	b.Logger.Printf(" - Built layer: '%s' -> '%s'\n", layer.Name, zipPath)
+	return artifact, nil
+}
+
+// findLayerFiles matching the glob, relative to the root of the module.
+// Matched directories are added recursively, keeping the directory's name. Files with an executable bit are zipped as executables.
+func findLayerFiles(config *lambgofile.Config, layer *lambgofile.Layer, layerFiles *lambgofile.LayerFiles) ([]*zipper.File, error) {
+	wrapErr := func(err error) error {
+		return erk.WrapWith(ErrLayerGlobFailed, err, erk.Params{
+			"glob":  layerFiles.Glob,
+			"layer": layer.Name,
+		})
+	}
+
+	matches, err := filepath.Glob(filepath.Join(config.RootPath, layerFiles.Glob))
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	if len(matches) == 0 {
+		return nil, erk.WithParams(ErrLayerGlobNoMatches, erk.Params{
+			"glob":  layerFiles.Glob,
+			"layer": layer.Name,
+		})
+	}
+
+	var files []*zipper.File
+	for _, match := range matches {
+		baseDir := filepath.Dir(match)
+
+		err := filepath.WalkDir(match, func(filePath string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+
+			relPath, err := filepath.Rel(baseDir, filePath)
+			if err != nil {
+				return err
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+
+			// Scripts and other executables keep their executable bit, so they can be run from the layer
+			mode := zipper.RegularMode
+			if info.Mode().Perm()&0o111 != 0 {
+				mode = zipper.ExecutableMode
+			}
+
+			files = append(files, &zipper.File{
+				Path:       filePath,
+				ZippedName: layerFiles.Destination + filepath.ToSlash(relPath),
+				Mode:       mode,
+			})
+
+			return nil
+		})
+		if err != nil {
+			return nil, wrapErr(err)
+		}
+	}
+
+	return files, nil
+}
+
+func layerPackageOutPath(config *lambgofile.Config, layer *lambgofile.Layer, layerPackage *lambgofile.LayerPackage) string {
+	return buildOutPath(config, path.Join(layer.Path(), layerPackage.Path))
+}
+
+// relativeToRoot keeps absolute paths portable in the manifest and the build times, such as paths found by globbing.
+func relativeToRoot(config *lambgofile.Config, filePath string) string {
+	if !filepath.IsAbs(filePath) {
+		return filePath
+	}
+
+	relPath, err := filepath.Rel(config.RootPath, filePath)
+	if err != nil {
+		return filePath
+	}
+
+	return relPath
+}
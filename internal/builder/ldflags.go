@@ -0,0 +1,164 @@
+package builder
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/versioninfo"
+)
+
+// readVersionInfo for the variables in versionInjection, or nil if it is not set.
+func (b *LambdaBuilder) readVersionInfo(config *lambgofile.Config) (*versioninfo.Info, error) {
+	if config.VersionInjection == nil {
+		return nil, nil //nolint:nilnil // Version injection is optional
+	}
+
+	info, err := b.VersionInfo.Read(config.RootPath, config.VersionInjection)
+	if err != nil {
+		return nil, erk.WrapAs(ErrVersionInfoFailed, err)
+	}
+
+	return info, nil
+}
+
+// lambdaBuildFlags are the flags passed to go build for the Lambda, which are its build options, -o, and its build flags.
+// The -tags of the extra flags and build flags are added to the tags of the build options.
+// The ldflags of the build options and the variables from versionInjection are added to the last -ldflags that matches the Lambda.
+func lambdaBuildFlags(config *lambgofile.Config, info *versioninfo.Info, outPath string, lambda *lambgofile.Lambda) []string {
+	options, buildFlags := mergeTags(lambda.BuildOptions, lambda.BuildFlags)
+	flags := append(options.Flags(), "-o", outPath)
+	flags = append(flags, buildFlags...)
+	var optionLDFlags string
+
+	// The go command only uses the last matching -ldflags for the Lambda, so the ldflags of the build options
+	// are moved into the last matching -ldflags of the extra flags or build flags
+	if options != nil && options.LDFlags != "" {
+		index := slices.Index(flags, "-ldflags="+options.LDFlags)
+		if lastLDFlags(flags[index+1:], anyPattern) != -1 {
+			flags = slices.Delete(flags, index, index+1)
+			optionLDFlags = options.LDFlags
+		}
+	}
+
+	return mergeLDFlags(flags, lambdaPatternMatcher(config, lambda), optionLDFlags, versionLDFlags(config, info, lambda))
+}
+
+// versionLDFlags are the -X flags for the variables in versionInjection, or empty if it is not set.
+func versionLDFlags(config *lambgofile.Config, info *versioninfo.Info, lambda *lambgofile.Lambda) string {
+	injection := config.VersionInjection
+	if injection == nil || info == nil {
+		return ""
+	}
+
+	variables := []struct{ name, value string }{
+		{injection.Commit, info.Commit},
+		{injection.Dirty, info.Dirty},
+		{injection.Tag, info.Tag},
+		{injection.BuildTime, info.BuildTime},
+		{injection.LambdaPath, filepath.ToSlash(lambda.Path)},
+	}
+
+	var xFlags []string
+	for _, variable := range variables {
+		if variable.name != "" {
+			xFlags = append(xFlags, "-X", quoteLDFlag(variable.name+"="+variable.value))
+		}
+	}
+
+	return strings.Join(xFlags, " ")
+}
+
+// mergeLDFlags adds the linker flags to the last -ldflags that matches the main package of the Lambda (eg. -ldflags=-s or -ldflags=all=-s),
+// since the go command links the Lambda using the last matching -ldflags.
+// The prefix is added before its linker flags, and the suffix after them. The other -ldflags are kept as they are.
+// When no -ldflags matches, or a later -ldflags has a package pattern that cannot be matched, the linker flags are added as a new -ldflags after them.
+func mergeLDFlags(buildFlags []string, matches patternMatcher, prefix, suffix string) []string {
+	if prefix == "" && suffix == "" {
+		return buildFlags
+	}
+
+	index := lastLDFlags(buildFlags, matches)
+	if index == -1 {
+		return append(buildFlags, "-ldflags="+strings.TrimSpace(prefix+" "+suffix))
+	}
+
+	_, value, hasValue := strings.Cut(buildFlags[index], "=")
+	end := index + 1
+	if !hasValue && end < len(buildFlags) {
+		value = buildFlags[end]
+		end++
+	}
+
+	// The package pattern is kept, so the merged flags match the same packages
+	pattern, value := splitPackagePattern(value)
+	if pattern != "" {
+		pattern += "="
+	}
+
+	merged := "-ldflags=" + pattern + strings.Join(slices.DeleteFunc([]string{prefix, value, suffix}, func(s string) bool { return s == "" }), " ")
+	return slices.Replace(slices.Clone(buildFlags), index, end, merged)
+}
+
+// lastLDFlags returns the index of the last -ldflags that matches the main package of the Lambda.
+// It returns -1 if there is none, or if a later -ldflags has a package pattern that cannot be matched.
+func lastLDFlags(buildFlags []string, matches patternMatcher) int {
+	last := -1
+
+	for i := 0; i < len(buildFlags); i++ {
+		name, value, hasValue := strings.Cut(buildFlags[i], "=")
+		if name != "-ldflags" && name != "--ldflags" {
+			continue
+		}
+
+		start := i
+		if !hasValue && i+1 < len(buildFlags) {
+			i++
+			value = buildFlags[i]
+		}
+
+		pattern, _ := splitPackagePattern(value)
+		matched, known := matches(pattern)
+		switch {
+		case !known:
+			last = -1
+		case matched:
+			last = start
+		}
+	}
+
+	return last
+}
+
+// anyPattern matches every package pattern.
+func anyPattern(string) (bool, bool) {
+	return true, true
+}
+
+// splitPackagePattern of the value of a per-package flag, like -ldflags=all=-s.
+// Like the go command, the flags have no package pattern when they are empty or start with a dash (eg. -ldflags=-s).
+func splitPackagePattern(value string) (string, string) {
+	value = strings.TrimSpace(value)
+	if value == "" || value[0] == '-' {
+		return "", value
+	}
+
+	pattern, flags, _ := strings.Cut(value, "=")
+	return strings.TrimSpace(pattern), flags
+}
+
+// quoteLDFlag quotes the flag if it contains spaces or quotes, since the go command splits -ldflags on spaces.
+// The go command does not support escaping, so double quotes are only used when the flag contains single quotes.
+func quoteLDFlag(flag string) string {
+	if !strings.ContainsAny(flag, " \t\n'\"") {
+		return flag
+	}
+
+	if strings.Contains(flag, "'") {
+		return `"` + flag + `"`
+	}
+
+	return "'" + flag + "'"
+}
@@ -0,0 +1,112 @@
+package builder
+
+import (
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+)
+
+// patternMatcher reports whether the package pattern of a per-package flag (eg. the all of -ldflags=all=-s) matches the main package of a Lambda.
+// It also reports whether the pattern is known, since import path patterns cannot be matched when the module path is unknown.
+// The empty pattern is used by flags without a package pattern, which match the packages on the command line.
+type patternMatcher func(pattern string) (matches, known bool)
+
+// lambdaPatternMatcher matches package patterns against the main package of the Lambda, like the go command.
+func lambdaPatternMatcher(config *lambgofile.Config, lambda *lambgofile.Lambda) patternMatcher {
+	return func(pattern string) (bool, bool) {
+		switch {
+		case pattern == "" || pattern == "all":
+			return true, true
+		case pattern == "std" || pattern == "cmd":
+			return false, true
+		case isRelativePattern(pattern):
+			pwd, buildPath := goPackage(config, lambda)
+			return matchRelativePattern(pattern, pwd, buildPath), true
+		}
+
+		importPath := lambdaImportPath(config, lambda)
+		if importPath == "" {
+			return false, false
+		}
+
+		return matchPattern(pattern, importPath), true
+	}
+}
+
+// isRelativePattern when the pattern is a directory relative to the working directory of the go command.
+func isRelativePattern(pattern string) bool {
+	return pattern == "." || pattern == ".." || strings.HasPrefix(pattern, "./") || strings.HasPrefix(pattern, "../")
+}
+
+// matchRelativePattern matches the directory of the package against the pattern, which are both relative to pwd.
+// The wildcards only match within the directory before the first wildcard (eg. ./lambdas/... only matches within lambdas).
+func matchRelativePattern(pattern, pwd, buildPath string) bool {
+	dir, rest := pattern, ""
+	if i := strings.Index(pattern, "..."); i >= 0 {
+		j := strings.LastIndex(pattern[:i], "/")
+		if j < 0 {
+			return false
+		}
+
+		dir, rest = pattern[:j], pattern[j+1:]
+	}
+
+	dirPath := filepath.Join(pwd, dir)
+	packageDir := filepath.Join(pwd, buildPath)
+	if rest == "" {
+		return dirPath == packageDir
+	}
+
+	relPath, err := filepath.Rel(dirPath, packageDir)
+	if err != nil {
+		return false
+	}
+
+	relPath = filepath.ToSlash(relPath)
+	if relPath == ".." || strings.HasPrefix(relPath, "../") {
+		return false
+	}
+
+	return matchPattern(rest, relPath)
+}
+
+// matchPattern matches the name against the pattern, where ... matches any string, and a trailing /... also matches the empty string.
+func matchPattern(pattern, name string) bool {
+	expression := regexp.QuoteMeta(pattern)
+	if strings.HasSuffix(expression, `/\.\.\.`) {
+		expression = strings.TrimSuffix(expression, `/\.\.\.`) + `(/\.\.\.)?`
+	}
+
+	expression = strings.ReplaceAll(expression, `\.\.\.`, `.*`)
+	return regexp.MustCompile(`^` + expression + `$`).MatchString(name)
+}
+
+// lambdaImportPath is the import path of the main package of the Lambda, or empty if the path of its module is unknown.
+func lambdaImportPath(config *lambgofile.Config, lambda *lambgofile.Lambda) string {
+	moduleDir, modulePath := ".", config.ModulePath
+	if lambda.Module != "" && lambda.Module != "." {
+		moduleDir, modulePath = lambda.Module, ""
+
+		if config.Workspace != nil {
+			for _, module := range config.Workspace.Modules {
+				if module.Dir == lambda.Module {
+					modulePath = module.ModulePath
+				}
+			}
+		}
+	}
+
+	if modulePath == "" {
+		return ""
+	}
+
+	relPath, err := filepath.Rel(moduleDir, lambda.Path)
+	if err != nil {
+		return ""
+	}
+
+	return path.Join(modulePath, filepath.ToSlash(relPath))
+}
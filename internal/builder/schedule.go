@@ -0,0 +1,109 @@
+package builder
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/buildtimes"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+)
+
+// memoryPerBuild is the memory reserved for each concurrent `go build`, which is mostly used when linking.
+// The linker loads the whole program, so linking a Lambda with large dependencies (eg. the AWS SDK) can use several hundred MiB.
+// 1 GiB leaves room for the compiler, which runs in the same `go build`, so builds in small CI containers are not killed for running out of memory.
+// It only lowers the number of builds, and at least one build always runs, so it is not configurable. Use --num-parallel to build fewer at once.
+const memoryPerBuild = 1 << 30 // 1 GiB
+
+// scheduleTargets orders the targets by how long `go build` took for them in the previous build, which is read from BuildTimes.
+// Starting the slowest targets first avoids waiting on a slow target at the end of the build.
+// Targets that have not been built, such as new Lambdas, start first, since they are likely to be slow without a build cache.
+func (b *LambdaBuilder) scheduleTargets(config *lambgofile.Config, targets []*buildTarget) {
+	if b.BuildTimes == nil {
+		return
+	}
+
+	// The order is only an optimization, so the targets are built in the order of the config when the build times cannot be read
+	previousDurations, err := b.BuildTimes.Read(buildtimes.PathFor(config.OutDirectory))
+	if err != nil || len(previousDurations) == 0 {
+		return
+	}
+
+	previousDuration := func(target *buildTarget) time.Duration {
+		// Like recordDuration, the paths are relative to the module root, so they match for absolute outDirectories
+		if duration, ok := previousDurations[relativeToRoot(config, target.outPath)]; ok {
+			return duration
+		}
+
+		return math.MaxInt64
+	}
+
+	slices.SortStableFunc(targets, func(a, b *buildTarget) int {
+		return cmp.Compare(previousDuration(b), previousDuration(a))
+	})
+}
+
+// updateBuildTimes with the durations of this build, so the next build can schedule the slowest targets first.
+func (b *LambdaBuilder) updateBuildTimes(config *lambgofile.Config, durations map[string]time.Duration) error {
+	if b.BuildTimes == nil || len(durations) == 0 {
+		return nil
+	}
+
+	path := buildtimes.PathFor(config.OutDirectory)
+	if err := b.BuildTimes.Update(path, durations); err != nil {
+		return erk.WrapWith(ErrBuildTimesWriteFailed, err, erk.Params{"path": path})
+	}
+
+	return nil
+}
+
+// limitByMemory reduces numParallel, so each concurrent `go build` has memoryPerBuild available.
+// It is not limited when the available memory is unknown.
+func (b *LambdaBuilder) limitByMemory(numParallel int) int {
+	if b.AvailableMemory == nil {
+		return numParallel
+	}
+
+	available, ok := b.AvailableMemory()
+	if !ok {
+		return numParallel
+	}
+
+	limit := max(int(available/memoryPerBuild), 1)
+	if limit >= numParallel {
+		return numParallel
+	}
+
+	b.Logger.Printf("Limiting to %d builds in parallel, since %.1f GiB of memory is available\n", limit, float64(available)/memoryPerBuild)
+	return limit
+}
+
+// procsFlag splits the CPUs between the concurrent `go build` commands with -p, so they do not compete for them.
+// It is empty when only one command runs at a time, or NumCPU is not set, so the go command uses all the CPUs.
+func (b *LambdaBuilder) procsFlag(numConcurrent int) []string {
+	if b.NumCPU == 0 || numConcurrent < 2 { //nolint:mnd
+		return nil
+	}
+
+	return []string{"-p", strconv.Itoa(max(b.NumCPU/numConcurrent, 1))}
+}
+
+// numWorkers that run commands at once, which is one when the builder is Sequential.
+func (b *LambdaBuilder) numWorkers(numConcurrent int) int {
+	if b.Sequential {
+		return 1
+	}
+
+	return numConcurrent
+}
+
+func (b *LambdaBuilder) now() time.Time {
+	if b.Now == nil {
+		return time.Now()
+	}
+
+	return b.Now()
+}
@@ -0,0 +1,39 @@
+package builder
+
+import (
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/binsize"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+)
+
+// binarySize of the binary built for the main package at buildPath, which is recorded in the manifest, so
+// `lambgo size --diff` can compare against it. It returns nil when no SizeAnalyzer is set.
+func (b *LambdaBuilder) binarySize(buildPath, outPath string) (*manifest.BinarySize, error) {
+	if b.SizeAnalyzer == nil {
+		return nil, nil //nolint:nilnil // Sizes are only recorded by real builds
+	}
+
+	analysis, err := b.SizeAnalyzer.Analyze(outPath)
+	if err != nil {
+		return nil, erk.WrapWith(ErrSizeFailed, err, erk.Params{
+			"buildPath": buildPath,
+		})
+	}
+
+	return &manifest.BinarySize{
+		BuildPath: buildPath,
+		FileSize:  analysis.FileSize,
+		Stripped:  analysis.Stripped,
+		Sections:  manifestSizes(analysis.Sections),
+		Packages:  manifestSizes(analysis.Packages),
+	}, nil
+}
+
+func manifestSizes(sizes []*binsize.Size) []*manifest.Size {
+	converted := make([]*manifest.Size, 0, len(sizes))
+	for _, size := range sizes {
+		converted = append(converted, &manifest.Size{Name: size.Name, Size: size.Size})
+	}
+
+	return converted
+}
@@ -0,0 +1,70 @@
+package builder
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+)
+
+// mergeTags adds the tags of the last -tags in the extra flags and in the build flags to the tags of the build options,
+// and removes the -tags from those flags. Otherwise, the go command would only use the last -tags, since it replaces the others.
+// The build flags are used as they are when the build options do not set any tags.
+func mergeTags(options *lambgofile.BuildOptions, buildFlags []string) (*lambgofile.BuildOptions, []string) {
+	if options == nil {
+		return options, buildFlags
+	}
+
+	extraTags, extraFlags, foundExtraTags := extractTags(options.ExtraFlags)
+	if len(options.Tags) == 0 && !foundExtraTags {
+		return options, buildFlags
+	}
+
+	buildTags, remainingFlags, foundBuildTags := extractTags(buildFlags)
+	if !foundExtraTags && !foundBuildTags {
+		return options, buildFlags
+	}
+
+	merged := *options
+	merged.Tags = slices.Clone(options.Tags)
+	merged.ExtraFlags = extraFlags
+	for _, tag := range slices.Concat(extraTags, buildTags) {
+		if !slices.Contains(merged.Tags, tag) {
+			merged.Tags = append(merged.Tags, tag)
+		}
+	}
+
+	return &merged, remainingFlags
+}
+
+// extractTags returns the tags of the last -tags in the flags, the flags without any -tags, and whether any -tags was found.
+func extractTags(flags []string) ([]string, []string, bool) {
+	var (
+		tags      []string
+		foundTags bool
+	)
+
+	remainingFlags := make([]string, 0, len(flags))
+	for i := 0; i < len(flags); i++ {
+		name, value, hasValue := strings.Cut(flags[i], "=")
+		if name != "-tags" && name != "--tags" {
+			remainingFlags = append(remainingFlags, flags[i])
+			continue
+		}
+
+		if !hasValue && i+1 < len(flags) {
+			i++
+			value = flags[i]
+		}
+
+		// Tags are separated by commas, but the go command still accepts spaces
+		foundTags = true
+		tags = strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' })
+	}
+
+	if !foundTags {
+		return nil, flags, false
+	}
+
+	return tags, remainingFlags, true
+}
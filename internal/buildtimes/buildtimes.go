@@ -0,0 +1,90 @@
+// Package buildtimes records how long `go build` took for each binary, so the next build can start the slowest first.
+package buildtimes
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName of the build times, which are written to the root of the outDirectory.
+// They are kept out of the manifest, since they change on every build.
+const FileName = ".lambgo-build-times.json"
+
+// file is the JSON stored in FileName.
+type file struct {
+	// Milliseconds that `go build` took for each binary, by the output path of the binary relative to the module root.
+	Milliseconds map[string]int64 `json:"milliseconds"`
+}
+
+type StoreAPI interface {
+	Read(path string) (map[string]time.Duration, error)
+	Update(path string, durations map[string]time.Duration) error
+}
+
+// Store persists the build times as JSON.
+type Store struct{}
+
+var _ StoreAPI = &Store{}
+
+// PathFor returns the path of the build times for the provided outDirectory.
+func PathFor(outDirectory string) string {
+	return filepath.Join(outDirectory, FileName)
+}
+
+// Read the build times located at path. They are empty when the file does not exist.
+func (s *Store) Read(path string) (map[string]time.Duration, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]time.Duration{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	f := &file{}
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, err
+	}
+
+	durations := make(map[string]time.Duration, len(f.Milliseconds))
+	for binary, milliseconds := range f.Milliseconds {
+		durations[binary] = time.Duration(milliseconds) * time.Millisecond
+	}
+
+	return durations, nil
+}
+
+// Update the build times located at path with the provided durations.
+// Durations replace any existing duration of the same binary, so the durations from previous builds (eg. when using --only) are preserved.
+// An existing file that cannot be read is replaced, since the build times are only used to order the builds.
+func (s *Store) Update(path string, durations map[string]time.Duration) error {
+	existing, err := s.Read(path)
+	if err != nil {
+		existing = map[string]time.Duration{}
+	}
+
+	f := &file{Milliseconds: make(map[string]int64, len(existing)+len(durations))}
+	for binary, duration := range existing {
+		f.Milliseconds[binary] = duration.Milliseconds()
+	}
+
+	for binary, duration := range durations {
+		f.Milliseconds[binary] = duration.Milliseconds()
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:mnd
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o644) //nolint:gosec,mnd // The build times are not sensitive
+}
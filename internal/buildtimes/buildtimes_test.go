@@ -0,0 +1,87 @@
+package buildtimes_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/buildtimes"
+)
+
+func TestUpdate(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("when the build times do not exist", func(ensure ensuring.E) {
+		path := buildtimes.PathFor(filepath.Join(ensure.T().TempDir(), "out"))
+
+		store := &buildtimes.Store{}
+		err := store.Update(path, map[string]time.Duration{"tmp/lambdas/api": 1500 * time.Millisecond})
+		ensure(err).IsNotError()
+
+		data, err := os.ReadFile(path)
+		ensure(err).IsNotError()
+		ensure(string(data)).Equals("{\n  \"milliseconds\": {\n    \"tmp/lambdas/api\": 1500\n  }\n}\n")
+	})
+
+	ensure.Run("when the build times already exist", func(ensure ensuring.E) {
+		path := buildtimes.PathFor(ensure.T().TempDir())
+
+		store := &buildtimes.Store{}
+		err := store.Update(path, map[string]time.Duration{
+			"tmp/lambdas/a": 100 * time.Millisecond,
+			"tmp/lambdas/b": 200 * time.Millisecond,
+		})
+		ensure(err).IsNotError()
+
+		err = store.Update(path, map[string]time.Duration{
+			"tmp/lambdas/b": 300 * time.Millisecond,
+			"tmp/lambdas/c": 400 * time.Millisecond,
+		})
+		ensure(err).IsNotError()
+
+		durations, err := store.Read(path)
+		ensure(err).IsNotError()
+		ensure(durations).Equals(map[string]time.Duration{
+			"tmp/lambdas/a": 100 * time.Millisecond,
+			"tmp/lambdas/b": 300 * time.Millisecond,
+			"tmp/lambdas/c": 400 * time.Millisecond,
+		})
+	})
+
+	ensure.Run("when the existing build times are invalid, they are replaced", func(ensure ensuring.E) {
+		path := buildtimes.PathFor(ensure.T().TempDir())
+		ensure(os.WriteFile(path, []byte("{"), 0o600)).IsNotError()
+
+		store := &buildtimes.Store{}
+		err := store.Update(path, map[string]time.Duration{"tmp/lambdas/api": time.Second})
+		ensure(err).IsNotError()
+
+		durations, err := store.Read(path)
+		ensure(err).IsNotError()
+		ensure(durations).Equals(map[string]time.Duration{"tmp/lambdas/api": time.Second})
+	})
+}
+
+func TestRead(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("when the build times do not exist", func(ensure ensuring.E) {
+		store := &buildtimes.Store{}
+		durations, err := store.Read(buildtimes.PathFor(ensure.T().TempDir()))
+		ensure(err).IsNotError()
+		ensure(durations).Equals(map[string]time.Duration{})
+	})
+
+	ensure.Run("when the build times are invalid", func(ensure ensuring.E) {
+		path := buildtimes.PathFor(ensure.T().TempDir())
+		ensure(os.WriteFile(path, []byte(`{"milliseconds": []}`), 0o600)).IsNotError()
+
+		store := &buildtimes.Store{}
+		durations, err := store.Read(path)
+		ensure(err).IsNotNil()
+		ensure(durations).IsNil()
+	})
+}
@@ -0,0 +1,25 @@
+package buildtimes
+
+import (
+	"log"
+	"time"
+)
+
+// Recorder reads the build times, but prints the update instead of writing it, for dry runs.
+// Reading them allows dry runs to print the builds in the same order as a real build.
+type Recorder struct {
+	Logger *log.Logger
+}
+
+var _ StoreAPI = &Recorder{}
+
+// Read the build times located at path, like Store.
+func (r *Recorder) Read(path string) (map[string]time.Duration, error) {
+	return (&Store{}).Read(path)
+}
+
+// Update prints the build times that would be updated.
+func (r *Recorder) Update(path string, _ map[string]time.Duration) error {
+	r.Logger.Printf("   build times: %s\n", path)
+	return nil
+}
@@ -0,0 +1,32 @@
+package buildtimes_test
+
+import (
+	"bytes"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/buildtimes"
+)
+
+func TestRecorder(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("reads the build times, and prints the update without writing it", func(ensure ensuring.E) {
+		path := buildtimes.PathFor(ensure.T().TempDir())
+		ensure((&buildtimes.Store{}).Update(path, map[string]time.Duration{"tmp/lambdas/api": time.Second})).IsNotError()
+
+		output := &bytes.Buffer{}
+		recorder := &buildtimes.Recorder{Logger: log.New(output, "", 0)}
+
+		err := recorder.Update(path, map[string]time.Duration{"tmp/lambdas/api": 2 * time.Second})
+		ensure(err).IsNotError()
+		ensure(output.String()).Equals("   build times: " + path + "\n")
+
+		durations, err := recorder.Read(path)
+		ensure(err).IsNotError()
+		ensure(durations).Equals(map[string]time.Duration{"tmp/lambdas/api": time.Second})
+	})
+}
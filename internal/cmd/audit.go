@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/audit"
+	"github.com/JosiahWitt/lambgo/internal/builder"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/urfave/cli/v3"
+)
+
+type (
+	ErkInvalidFailOn        struct{ erk.DefaultKind }
+	ErkVulnerabilitiesFound struct{ erk.DefaultKind }
+)
+
+var (
+	ErrInvalidFailOn = erk.New(ErkInvalidFailOn{},
+		"Invalid value ({{.failOn}}) provided for --fail-on. Only {{.severities}} are supported.",
+	)
+
+	ErrVulnerabilitiesFound = erk.New(ErkVulnerabilitiesFound{},
+		"Found {{.numFindings}} reachable vulnerabilities with a severity of {{.failOn}} or higher (or an unknown severity)",
+	)
+)
+
+func (a *App) auditCmd() *cli.Command {
+	return &cli.Command{
+		Name: "audit",
+		Usage: "check the modules and symbols built into each Lambda against a local vulnerability database in the OSV format, " +
+			"without accessing the network",
+
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name: "db",
+				Usage: "The `dir` containing the vulnerability database, as JSON files in the OSV format. " +
+					"For example, an extracted copy of https://vuln.go.dev/vulndb.zip or an OSV export from https://osv.dev.",
+				Sources:  cli.EnvVars("LAMBGO_VULN_DB"),
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "fail-on",
+				Usage: "Exit with an error when a reachable vulnerability has this `severity` or higher: low, medium, high, or critical.",
+				Value: string(audit.SeverityLow),
+			},
+			onlyFlag("audit"),
+		},
+
+		Action: a.runAudit,
+	}
+}
+
+func (a *App) runAudit(_ context.Context, cmd *cli.Command) error {
+	failOn := audit.Severity(cmd.String("fail-on"))
+	if !slices.Contains(audit.Severities, failOn) {
+		return erk.WithParams(ErrInvalidFailOn, erk.Params{"failOn": failOn, "severities": audit.Severities})
+	}
+
+	pwd, err := a.Getwd()
+	if err != nil {
+		return err
+	}
+
+	config, err := a.LambgoFileLoader.LoadConfig(pwd, cmd.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if rawOnlyFlags := cmd.StringSlice("only"); len(rawOnlyFlags) > 0 {
+		if err := filterBuildTargets(config, rawOnlyFlags); err != nil {
+			return err
+		}
+	}
+
+	reports, err := a.Auditor.Audit(config, cmd.String("db"))
+	if err != nil {
+		return err
+	}
+
+	numFailing := 0
+	for _, report := range reports {
+		a.Logger.Println(binaryName(report.Binary))
+		if len(report.Findings) == 0 {
+			a.Logger.Println("  No vulnerabilities found")
+		}
+
+		for _, finding := range report.Findings {
+			if finding.Reachable && finding.Severity.AtLeast(failOn) {
+				numFailing++
+			}
+
+			a.logFinding(finding)
+		}
+	}
+	a.Logger.Println()
+
+	if numFailing > 0 {
+		return erk.WithParams(ErrVulnerabilitiesFound, erk.Params{"numFindings": numFailing, "failOn": failOn})
+	}
+
+	return nil
+}
+
+func (a *App) logFinding(finding *audit.Finding) {
+	fixed := "no fix available"
+	if finding.FixedVersion != "" {
+		fixed = "fixed in " + finding.FixedVersion
+	}
+
+	a.Logger.Printf("  %s (%s): %s@%s, %s\n", finding.ID, finding.Severity, finding.Module, finding.Version, fixed)
+	if finding.Summary != "" {
+		a.Logger.Printf("    %s\n", finding.Summary)
+	}
+
+	switch {
+	case len(finding.Symbols) > 0:
+		a.Logger.Printf("    Reachable: %s\n", strings.Join(finding.Symbols, ", "))
+	case len(finding.PossiblyInlined) > 0:
+		a.Logger.Printf("    Possibly reachable: the vulnerable symbols may be inlined into %s\n", strings.Join(finding.PossiblyInlined, ", "))
+	case finding.Reachable:
+		a.Logger.Println("    Reachable: the whole module is vulnerable")
+	default:
+		a.Logger.Println("    Not reachable: none of the vulnerable symbols are in the binary")
+	}
+}
+
+// binaryName is the Lambda or extension's path, or the layer containing the package.
+func binaryName(binary *builder.Binary) string {
+	if binary.Kind == manifest.KindLayer {
+		return "layer " + binary.Layer + ": " + binary.BuildPath
+	}
+
+	return binary.BuildPath
+}
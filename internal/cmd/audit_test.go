@@ -0,0 +1,234 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/audit"
+	"github.com/JosiahWitt/lambgo/internal/builder"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_audit"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+	"github.com/golang/mock/gomock"
+)
+
+func TestAudit(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		Auditor          *mock_audit.MockAuditorAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	newConfig := func() *lambgofile.Config {
+		return &lambgofile.Config{
+			RootPath: "/some/root/path",
+			Lambdas: []*lambgofile.Lambda{
+				makeLambda("lambdas/api", nil),
+				makeLambda("lambdas/worker", nil),
+			},
+		}
+	}
+
+	reports := []*audit.Report{
+		{
+			Binary: &builder.Binary{Kind: manifest.KindLambda, BuildPath: "lambdas/api"},
+			Findings: []*audit.Finding{
+				{
+					ID:           "GO-2024-0001",
+					Summary:      "Stack exhaustion when parsing in github.com/my/parser",
+					Severity:     audit.SeverityMedium,
+					Module:       "github.com/my/parser",
+					Version:      "v1.1.0",
+					FixedVersion: "v1.2.0",
+					Symbols:      []string{"github.com/my/parser.Parse", "github.com/my/parser.Decoder.Decode"},
+					Reachable:    true,
+				},
+				{
+					ID:       "GO-2024-0002",
+					Severity: audit.SeverityCritical,
+					Module:   "stdlib",
+					Version:  "v1.23.4",
+				},
+			},
+		},
+		{
+			Binary: &builder.Binary{Kind: manifest.KindLambda, BuildPath: "lambdas/worker"},
+		},
+		{
+			Binary: &builder.Binary{Kind: manifest.KindLayer, BuildPath: "cmd/converter", Layer: "tools"},
+			Findings: []*audit.Finding{
+				{
+					ID:        "GHSA-bbbb-bbbb-bbbb",
+					Severity:  audit.SeverityHigh,
+					Module:    "github.com/my/logger",
+					Version:   "v0.5.0",
+					Reachable: true,
+				},
+			},
+		},
+	}
+
+	reportsOutput := "lambdas/api\n" +
+		"  GO-2024-0001 (medium): github.com/my/parser@v1.1.0, fixed in v1.2.0\n" +
+		"    Stack exhaustion when parsing in github.com/my/parser\n" +
+		"    Reachable: github.com/my/parser.Parse, github.com/my/parser.Decoder.Decode\n" +
+		"  GO-2024-0002 (critical): stdlib@v1.23.4, no fix available\n" +
+		"    Not reachable: none of the vulnerable symbols are in the binary\n" +
+		"lambdas/worker\n" +
+		"  No vulnerabilities found\n" +
+		"layer tools: cmd/converter\n" +
+		"  GHSA-bbbb-bbbb-bbbb (high): github.com/my/logger@v0.5.0, no fix available\n" +
+		"    Reachable: the whole module is vulnerable\n" +
+		"\n"
+
+	table := []struct {
+		Name           string
+		Flags          []string
+		ExpectedError  error
+		ExpectedOutput string
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:          "with reachable vulnerabilities",
+			Flags:         []string{"--db", "/vulndb"},
+			ExpectedError: cmd.ErrVulnerabilitiesFound,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.Auditor.EXPECT().Audit(newConfig(), "/vulndb").Return(reports, nil)
+			},
+			ExpectedOutput: reportsOutput,
+		},
+
+		{
+			Name:  "with reachable vulnerabilities below --fail-on",
+			Flags: []string{"--db", "/vulndb", "--fail-on", "critical"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.Auditor.EXPECT().Audit(newConfig(), "/vulndb").Return(reports, nil)
+			},
+			ExpectedOutput: reportsOutput,
+		},
+
+		{
+			Name:          "with reachable vulnerability of unknown severity",
+			Flags:         []string{"--db", "/vulndb", "--fail-on", "critical"},
+			ExpectedError: cmd.ErrVulnerabilitiesFound,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.Auditor.EXPECT().Audit(newConfig(), "/vulndb").Return([]*audit.Report{
+					{
+						Binary: &builder.Binary{Kind: manifest.KindLambda, BuildPath: "lambdas/api"},
+						Findings: []*audit.Finding{
+							{ID: "GO-2024-0003", Severity: audit.SeverityUnknown, Module: "stdlib", Version: "v1.23.4", Reachable: true},
+						},
+					},
+				}, nil)
+			},
+			ExpectedOutput: "lambdas/api\n" +
+				"  GO-2024-0003 (unknown): stdlib@v1.23.4, no fix available\n" +
+				"    Reachable: the whole module is vulnerable\n" +
+				"\n",
+		},
+
+		{
+			Name:          "with vulnerable symbols that may be inlined",
+			Flags:         []string{"--db", "/vulndb"},
+			ExpectedError: cmd.ErrVulnerabilitiesFound,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.Auditor.EXPECT().Audit(newConfig(), "/vulndb").Return([]*audit.Report{
+					{
+						Binary: &builder.Binary{Kind: manifest.KindLambda, BuildPath: "lambdas/api"},
+						Findings: []*audit.Finding{
+							{
+								ID:              "GO-2024-0006",
+								Severity:        audit.SeverityHigh,
+								Module:          "stdlib",
+								Version:         "v1.23.4",
+								PossiblyInlined: []string{"net/http"},
+								Reachable:       true,
+							},
+						},
+					},
+				}, nil)
+			},
+			ExpectedOutput: "lambdas/api\n" +
+				"  GO-2024-0006 (high): stdlib@v1.23.4, no fix available\n" +
+				"    Possibly reachable: the vulnerable symbols may be inlined into net/http\n" +
+				"\n",
+		},
+
+		{
+			Name:  "with --only",
+			Flags: []string{"--db", "/vulndb", "--only", "lambdas/worker"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+
+				expected := newConfig()
+				expected.Lambdas = expected.Lambdas[1:]
+				m.Auditor.EXPECT().Audit(expected, "/vulndb").Return(reports[1:2], nil)
+			},
+			ExpectedOutput: "lambdas/worker\n" +
+				"  No vulnerabilities found\n" +
+				"\n",
+		},
+
+		{
+			Name:          "with invalid --only",
+			Flags:         []string{"--db", "/vulndb", "--only", "lambdas/missing"},
+			ExpectedError: cmd.ErrCannotFilterBuildPaths,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+			},
+		},
+
+		{
+			Name:          "with invalid --fail-on",
+			Flags:         []string{"--db", "/vulndb", "--fail-on", "severe"},
+			ExpectedError: cmd.ErrInvalidFailOn,
+		},
+
+		{
+			Name:          "when loading the config fails",
+			Flags:         []string{"--db", "/vulndb"},
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when auditing fails",
+			Flags:         []string{"--db", "/vulndb"},
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.Auditor.EXPECT().Audit(gomock.Any(), "/vulndb").Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run(append([]string{"lambgo", "audit"}, entry.Flags...))
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
@@ -2,10 +2,13 @@ package cmd
 
 import (
 	"context"
+	"os"
+	"os/signal"
 	"runtime"
 	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/JosiahWitt/erk"
 	"github.com/JosiahWitt/lambgo/internal/lambgofile"
@@ -39,22 +42,28 @@ func (a *App) buildCmd() *cli.Command {
 		Usage: "build Lambdas",
 
 		Flags: []cli.Flag{
+			disableParallelFlag(),
+			onlyFlag("build"),
+			numParallelFlag(),
+			&cli.StringFlag{
+				Name: "changed-since",
+				Usage: "Only build the Lambdas affected by changes since the git `ref`, such as origin/main. " +
+					"Lambdas are affected when a package they depend on changes, when go.mod or go.sum changes, or when their entry in .lambgo.yml changes. " +
+					"Uncommitted changes are included, and it can be combined with --only.",
+			},
 			&cli.BoolFlag{
-				Name:  "disable-parallel",
-				Usage: "Disables building in parallel. Overrides --num-parallel to 1.",
+				Name: "group-builds",
+				Usage: "Build the Lambdas that share a module, output directory, and build flags with one `go build -o <dir>/` command, " +
+					"instead of one command per Lambda. If a grouped build fails, its Lambdas are built separately to find the error.",
 			},
-			&cli.StringSliceFlag{
-				Name: "only",
-				Usage: "Only build the provided `path`, instead of all the paths in .lambgo.yml. " +
-					"If you wish to build all Lambdas in a directory, you can provide a trailing `/`. " +
-					"This flag can be used multiple times to build multiple Lambdas (or Lambda directories).",
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Print the commands, zips, and images for each Lambda, without running or writing them.",
 			},
-			&cli.StringFlag{
-				Name: "num-parallel",
-				Usage: "Number of Lambdas to build in parallel. Defaults to `all`, which builds all Lambdas in parallel at the same time. " +
-					"An `x` suffix multiplies the prefix by the number of CPUs. For example `1.5x` builds `1.5 * <number of CPUs>` Lambdas in parallel. " +
-					"The result is truncated.",
-				Value: allParallel,
+			&cli.BoolFlag{
+				Name: "watch",
+				Usage: "After building, watch the module for changes, and rebuild the Lambdas that depend on the changed packages. " +
+					"Build errors are reported without exiting.",
 			},
 		},
 
@@ -68,82 +77,214 @@ func (a *App) runBuild(ctx context.Context, cmd *cli.Command) error {
 		return err
 	}
 
-	config, err := a.LambgoFileLoader.LoadConfig(pwd)
+	config, err := a.LambgoFileLoader.LoadConfig(pwd, cmd.String("profile"))
 	if err != nil {
 		return err
 	}
 
 	if rawOnlyFlags := cmd.StringSlice("only"); len(rawOnlyFlags) > 0 {
-		filteredLambdas, err := filterLambdas(config.Lambdas, rawOnlyFlags)
-		if err != nil {
+		if err := filterBuildTargets(config, rawOnlyFlags); err != nil {
 			return err
 		}
-
-		config.Lambdas = filteredLambdas
 	}
 
-	if cmd.Bool("disable-parallel") {
-		config.NumParallel = 1
-	} else {
-		numParallel, err := parseNumParallel(config, cmd.String("num-parallel"))
-		if err != nil {
+	if ref := cmd.String("changed-since"); ref != "" {
+		if err := a.filterChangedTargets(config, ref, cmd.String("profile")); err != nil {
 			return err
 		}
 
-		config.NumParallel = numParallel
+		if len(extractBuildPaths(config)) == 0 {
+			a.Logger.Printf("No Lambdas changed since '%s'\n", ref)
+			return nil
+		}
+	}
+
+	if _, err := setNumParallel(config, cmd); err != nil {
+		return err
+	}
+
+	config.GroupBuilds = cmd.Bool("group-builds")
+
+	if cmd.Bool("dry-run") {
+		a.Logger.Println("Dry run: commands are printed instead of run, and nothing is written")
+		return a.DryRunBuilder.BuildBinaries(config)
+	}
+
+	if !cmd.Bool("watch") {
+		return a.Builder.BuildBinaries(config)
 	}
 
-	return a.Builder.BuildBinaries(config)
+	if err := a.Builder.BuildBinaries(config); err != nil {
+		a.Logger.Printf("ERROR: %v\n", err)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return a.watchTargets(ctx, config, buildTargetPaths(config), func(affected []string) error {
+		return a.Builder.BuildBinaries(affectedConfig(config, affected))
+	})
 }
 
-func filterLambdas(lambdas []*lambgofile.Lambda, filters []string) ([]*lambgofile.Lambda, error) {
-	matched := make([]*lambgofile.Lambda, 0, len(lambdas))
-	seenPaths := make(map[string]struct{}, len(lambdas))
+// filterBuildTargets in the config to the ones matching the filters.
+// Lambdas and extensions are matched by their path, and layers are matched by their path in the outDirectory (layers/<name>).
+func filterBuildTargets(config *lambgofile.Config, filters []string) error {
+	var (
+		matchedLambdas    []*lambgofile.Lambda
+		matchedExtensions []*lambgofile.Extension
+		matchedLayers     []*lambgofile.Layer
+	)
+
+	seenPaths := make(map[string]struct{})
+	markSeen := func(path string) bool {
+		_, seen := seenPaths[path]
+		seenPaths[path] = struct{}{}
+		return !seen
+	}
 
 	for _, filter := range filters {
 		foundMatch := false
-		filterIsDir := strings.HasSuffix(filter, "/")
 
-		for _, lambda := range lambdas {
-			isChild := filterIsDir && strings.HasPrefix(lambda.Path, filter)
+		for _, lambda := range config.Lambdas {
+			if matchesFilter(lambda.Path, filter) {
+				foundMatch = true
+
+				if markSeen(lambda.Path) {
+					matchedLambdas = append(matchedLambdas, lambda)
+				}
+			}
+		}
+
+		for _, extension := range config.Extensions {
+			if matchesFilter(extension.Path, filter) {
+				foundMatch = true
+
+				if markSeen(extension.Path) {
+					matchedExtensions = append(matchedExtensions, extension)
+				}
+			}
+		}
 
-			if lambda.Path == filter || isChild {
+		for _, layer := range config.Layers {
+			if matchesFilter(layer.Path(), filter) {
 				foundMatch = true
 
-				if _, seen := seenPaths[lambda.Path]; !seen {
-					matched = append(matched, lambda)
-					seenPaths[lambda.Path] = struct{}{}
+				if markSeen(layer.Path()) {
+					matchedLayers = append(matchedLayers, layer)
 				}
 			}
 		}
 
 		if !foundMatch {
-			return nil, erk.WithParams(ErrCannotFilterBuildPaths, erk.Params{
+			return erk.WithParams(ErrCannotFilterBuildPaths, erk.Params{
 				"filter":     filter,
-				"buildPaths": extractLambdaPaths(lambdas),
+				"buildPaths": extractBuildPaths(config),
 			})
 		}
 	}
 
-	slices.SortFunc(matched, func(a, b *lambgofile.Lambda) int {
+	slices.SortFunc(matchedLambdas, func(a, b *lambgofile.Lambda) int {
 		return strings.Compare(a.Path, b.Path)
 	})
 
-	return matched, nil
+	slices.SortFunc(matchedExtensions, func(a, b *lambgofile.Extension) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	slices.SortFunc(matchedLayers, func(a, b *lambgofile.Layer) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	config.Lambdas = matchedLambdas
+	config.Extensions = matchedExtensions
+	config.Layers = matchedLayers
+	return nil
 }
 
-func extractLambdaPaths(lambdas []*lambgofile.Lambda) []string {
-	paths := make([]string, 0, len(lambdas))
-	for _, lambda := range lambdas {
+func matchesFilter(path, filter string) bool {
+	filterIsDir := strings.HasSuffix(filter, "/")
+	isChild := filterIsDir && strings.HasPrefix(path, filter)
+
+	return path == filter || isChild
+}
+
+func extractBuildPaths(config *lambgofile.Config) []string {
+	paths := make([]string, 0, len(config.Lambdas)+len(config.Extensions)+len(config.Layers))
+	for _, lambda := range config.Lambdas {
 		paths = append(paths, lambda.Path)
 	}
 
+	for _, extension := range config.Extensions {
+		paths = append(paths, extension.Path)
+	}
+
+	for _, layer := range config.Layers {
+		paths = append(paths, layer.Path())
+	}
+
 	return paths
 }
 
+// numBuildTargets is the number of main packages that are built for the config.
+func numBuildTargets(config *lambgofile.Config) int {
+	numTargets := len(config.Lambdas) + len(config.Extensions)
+	for _, layer := range config.Layers {
+		numTargets += len(layer.Packages)
+	}
+
+	return numTargets
+}
+
+func onlyFlag(verb string) cli.Flag {
+	return &cli.StringSliceFlag{
+		Name: "only",
+		Usage: "Only " + verb + " the provided `path`, instead of all the paths in .lambgo.yml. " +
+			"If you wish to " + verb + " all Lambdas in a directory, you can provide a trailing `/`. " +
+			"This flag can be used multiple times to " + verb + " multiple Lambdas (or Lambda directories).",
+	}
+}
+
+func disableParallelFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:  "disable-parallel",
+		Usage: "Disables building in parallel. Overrides --num-parallel to 1.",
+	}
+}
+
+func numParallelFlag() cli.Flag {
+	return &cli.StringFlag{
+		Name: "num-parallel",
+		Usage: "Number of Lambdas to build in parallel. Defaults to `all`, which builds all Lambdas in parallel at the same time. " +
+			"An `x` suffix multiplies the prefix by the number of CPUs. For example `1.5x` builds `1.5 * <number of CPUs>` Lambdas in parallel. " +
+			"The result is truncated. It is also limited to one build per GiB of available memory, " +
+			"and the CPUs are split between the builds with `go build -p`.",
+		Value: allParallel,
+	}
+}
+
+// setNumParallel in the config from the --disable-parallel and --num-parallel flags, and return where it came from.
+func setNumParallel(config *lambgofile.Config, cmd *cli.Command) (*lambgofile.Source, error) {
+	if cmd.Bool("disable-parallel") {
+		config.NumParallel = 1
+		return &lambgofile.Source{Origin: lambgofile.OriginFlag, Key: "--disable-parallel", Raw: "true"}, nil
+	}
+
+	numParallel, err := parseNumParallel(config, cmd.String("num-parallel"))
+	if err != nil {
+		return nil, err
+	}
+
+	config.NumParallel = numParallel
+	if !cmd.IsSet("num-parallel") {
+		return &lambgofile.Source{Origin: lambgofile.OriginDefault, Key: "--num-parallel"}, nil
+	}
+
+	return &lambgofile.Source{Origin: lambgofile.OriginFlag, Key: "--num-parallel", Raw: cmd.String("num-parallel")}, nil
+}
+
 func parseNumParallel(config *lambgofile.Config, numParallel string) (int, error) {
 	if numParallel == allParallel {
-		return len(config.Lambdas), nil
+		return numBuildTargets(config), nil
 	}
 
 	if prefix, matched := strings.CutSuffix(numParallel, cpuParallelSuffix); matched {
@@ -1,16 +1,23 @@
 package cmd_test
 
 import (
+	"bytes"
 	"errors"
+	"log"
 	"runtime"
 	"testing"
 
 	"github.com/JosiahWitt/ensure"
 	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/builder"
 	"github.com/JosiahWitt/lambgo/internal/cmd"
 	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
 	"github.com/JosiahWitt/lambgo/internal/mocks/mock_builder"
 	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/runcmd"
+	"github.com/JosiahWitt/lambgo/internal/zipper"
+	"github.com/golang/mock/gomock"
 )
 
 func TestBuild(t *testing.T) {
@@ -41,7 +48,7 @@ func TestBuild(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -71,7 +78,7 @@ func TestBuild(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -100,7 +107,7 @@ func TestBuild(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -130,7 +137,7 @@ func TestBuild(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -160,7 +167,7 @@ func TestBuild(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -193,7 +200,7 @@ func TestBuild(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -222,7 +229,7 @@ func TestBuild(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -251,7 +258,7 @@ func TestBuild(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -275,13 +282,42 @@ func TestBuild(t *testing.T) {
 			},
 		},
 
+		{
+			Name:  "with valid execution: grouping builds with --group-builds",
+			Flags: []string{"--group-builds"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().
+					LoadConfig("/test", "").
+					Return(&lambgofile.Config{
+						RootPath: "/some/root/path",
+						Lambdas: []*lambgofile.Lambda{
+							makeLambda("path1", nil),
+							makeLambda("path2", nil),
+						},
+					}, nil)
+
+				m.Builder.EXPECT().
+					BuildBinaries(&lambgofile.Config{
+						NumParallel: 2,
+						GroupBuilds: true,
+						RootPath:    "/some/root/path",
+						Lambdas: []*lambgofile.Lambda{
+							makeLambda("path1", nil),
+							makeLambda("path2", nil),
+						},
+					}).
+					Return(nil)
+			},
+		},
+
 		{
 			Name:  "with valid execution: filter using --only flag with per-lambda buildFlags",
 			Flags: []string{"--only", "lambdas/api"},
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -308,7 +344,7 @@ func TestBuild(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -338,7 +374,7 @@ func TestBuild(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -366,7 +402,7 @@ func TestBuild(t *testing.T) {
 			Getwd: defaultWd,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -387,6 +423,77 @@ func TestBuild(t *testing.T) {
 			},
 		},
 
+		{
+			Name:  "with valid execution: filter using --only flag with extensions",
+			Flags: []string{"--only", "extensions/", "--only", "lambdas/api"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().
+					LoadConfig("/test", "").
+					Return(&lambgofile.Config{
+						RootPath: "/some/root/path",
+						Lambdas: []*lambgofile.Lambda{
+							makeLambda("lambdas/api", nil),
+							makeLambda("lambdas/worker", nil),
+						},
+						Extensions: []*lambgofile.Extension{
+							makeExtension("extensions/telemetry", "telemetry"),
+							makeExtension("extensions/logs", "logs"),
+						},
+					}, nil)
+
+				m.Builder.EXPECT().
+					BuildBinaries(&lambgofile.Config{
+						NumParallel: 3,
+						RootPath:    "/some/root/path",
+						Lambdas: []*lambgofile.Lambda{
+							makeLambda("lambdas/api", nil),
+						},
+						Extensions: []*lambgofile.Extension{
+							makeExtension("extensions/logs", "logs"),
+							makeExtension("extensions/telemetry", "telemetry"),
+						},
+					}).
+					Return(nil)
+			},
+		},
+
+		{
+			Name:  "with valid execution: filter using --only flag with layers",
+			Flags: []string{"--only", "layers/shared"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().
+					LoadConfig("/test", "").
+					Return(&lambgofile.Config{
+						RootPath: "/some/root/path",
+						Lambdas: []*lambgofile.Lambda{
+							makeLambda("lambdas/api", nil),
+						},
+						Layers: []*lambgofile.Layer{
+							{Name: "shared", Packages: []*lambgofile.LayerPackage{
+								{Lambda: *makeLambda("tools/converter", nil), Destination: "bin/"},
+								{Lambda: *makeLambda("tools/helper", nil), Destination: "bin/"},
+							}},
+							{Name: "other"},
+						},
+					}, nil)
+
+				m.Builder.EXPECT().
+					BuildBinaries(&lambgofile.Config{
+						NumParallel: 2,
+						RootPath:    "/some/root/path",
+						Layers: []*lambgofile.Layer{
+							{Name: "shared", Packages: []*lambgofile.LayerPackage{
+								{Lambda: *makeLambda("tools/converter", nil), Destination: "bin/"},
+								{Lambda: *makeLambda("tools/helper", nil), Destination: "bin/"},
+							}},
+						},
+					}).
+					Return(nil)
+			},
+		},
+
 		{
 			Name:          "when error loading working directory",
 			Getwd:         func() (string, error) { return "", exampleError },
@@ -398,7 +505,7 @@ func TestBuild(t *testing.T) {
 			Getwd:         defaultWd,
 			ExpectedError: exampleError,
 			SetupMocks: func(m *Mocks) {
-				m.LambgoFileLoader.EXPECT().LoadConfig("/test").Return(nil, exampleError)
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(nil, exampleError)
 			},
 		},
 
@@ -409,7 +516,7 @@ func TestBuild(t *testing.T) {
 			ExpectedError: cmd.ErrCannotFilterBuildPaths,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						NumParallel: 2,
 						RootPath:    "/some/root/path",
@@ -428,7 +535,7 @@ func TestBuild(t *testing.T) {
 			ExpectedError: cmd.ErrInvalidNumParallel,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -446,7 +553,7 @@ func TestBuild(t *testing.T) {
 			ExpectedError: cmd.ErrInvalidNumParallel,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -464,7 +571,7 @@ func TestBuild(t *testing.T) {
 			ExpectedError: cmd.ErrInvalidNumParallel,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -482,7 +589,7 @@ func TestBuild(t *testing.T) {
 			ExpectedError: cmd.ErrInvalidNumParallel,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -500,7 +607,7 @@ func TestBuild(t *testing.T) {
 			ExpectedError: cmd.ErrInvalidNumParallel,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -518,7 +625,7 @@ func TestBuild(t *testing.T) {
 			ExpectedError: cmd.ErrInvalidNumParallel,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -536,7 +643,7 @@ func TestBuild(t *testing.T) {
 			ExpectedError: cmd.ErrInvalidNumParallel,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 						Lambdas: []*lambgofile.Lambda{
@@ -554,7 +661,7 @@ func TestBuild(t *testing.T) {
 			ExpectedError: exampleError,
 			SetupMocks: func(m *Mocks) {
 				m.LambgoFileLoader.EXPECT().
-					LoadConfig("/test").
+					LoadConfig("/test", "").
 					Return(&lambgofile.Config{
 						RootPath: "/some/root/path",
 					}, nil)
@@ -577,9 +684,166 @@ func TestBuild(t *testing.T) {
 	})
 }
 
+func TestBuildDryRun(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		DryRunBuilder    *mock_builder.MockLambdaBuilderAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	table := []struct {
+		Name          string
+		ExpectedError error
+		Flags         []string
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:  "with valid execution",
+			Flags: []string{"--num-parallel", "4"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().
+					LoadConfig("/test", "").
+					Return(&lambgofile.Config{
+						RootPath: "/some/root/path",
+						Lambdas:  []*lambgofile.Lambda{makeLambda("path1", nil), makeLambda("path2", nil)},
+					}, nil)
+
+				m.DryRunBuilder.EXPECT().
+					BuildBinaries(&lambgofile.Config{
+						NumParallel: 4,
+						RootPath:    "/some/root/path",
+						Lambdas:     []*lambgofile.Lambda{makeLambda("path1", nil), makeLambda("path2", nil)},
+					}).
+					Return(nil)
+			},
+		},
+
+		{
+			Name:          "when the dry run fails",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(&lambgofile.Config{RootPath: "/some/root/path"}, nil)
+				m.DryRunBuilder.EXPECT().BuildBinaries(gomock.Any()).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run(append([]string{"lambgo", "build", "--dry-run"}, entry.Flags...))
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals("Dry run: commands are printed instead of run, and nothing is written\n")
+	})
+
+	ensure.Run("prints the -p flag of the concurrent builds", func(ensure ensuring.E) {
+		ctrl := gomock.NewController(ensure.T())
+		loader := mock_lambgofile.NewMockLoaderAPI(ctrl)
+		loader.EXPECT().LoadConfig("/test", "").Return(&lambgofile.Config{
+			RootPath:     "/some/root/path",
+			OutDirectory: "tmp",
+			Lambdas:      []*lambgofile.Lambda{makeLambda("path1", nil), makeLambda("path2", nil)},
+		}, nil)
+
+		output := &bytes.Buffer{}
+		logger := log.New(output, "", 0)
+
+		app := &cmd.App{
+			Getwd:            func() (string, error) { return "/test", nil },
+			LambgoFileLoader: loader,
+			DryRunBuilder: &builder.LambdaBuilder{
+				Cmd:        &runcmd.Recorder{Logger: logger},
+				Zip:        &zipper.Recorder{Logger: logger},
+				Manifest:   &manifest.Recorder{Logger: logger},
+				Logger:     logger,
+				NumCPU:     8,
+				Sequential: true,
+			},
+			Logger: logger,
+		}
+
+		err := app.Run([]string{"lambgo", "build", "--dry-run", "--num-parallel", "2"})
+		ensure(err).IsNotError()
+		ensure(output.String()).Contains("go build -p 4 -trimpath -o tmp/path1 ./path1\n")
+		ensure(output.String()).Contains("go build -p 4 -trimpath -o tmp/path2 ./path2\n")
+	})
+}
+
+func TestBuildProfile(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		Builder          *mock_builder.MockLambdaBuilderAPI
+	}
+
+	table := []struct {
+		Name            string
+		Args            []string
+		EnvProfile      string
+		ExpectedProfile string
+
+		Mocks   *Mocks
+		Subject *cmd.App
+	}{
+		{
+			Name:            "with --profile after the command",
+			Args:            []string{"lambgo", "build", "--profile", "prod"},
+			ExpectedProfile: "prod",
+		},
+		{
+			Name:            "with --profile before the command",
+			Args:            []string{"lambgo", "--profile", "prod", "build"},
+			ExpectedProfile: "prod",
+		},
+		{
+			Name:            "with LAMBGO_PROFILE",
+			Args:            []string{"lambgo", "build"},
+			EnvProfile:      "staging",
+			ExpectedProfile: "staging",
+		},
+		{
+			Name:            "with --profile overriding LAMBGO_PROFILE",
+			Args:            []string{"lambgo", "build", "--profile", "prod"},
+			EnvProfile:      "staging",
+			ExpectedProfile: "prod",
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+		ensure.T().Setenv("LAMBGO_PROFILE", entry.EnvProfile)
+
+		config := &lambgofile.Config{RootPath: "/some/root/path", Lambdas: []*lambgofile.Lambda{makeLambda("path1", nil)}}
+		entry.Mocks.LambgoFileLoader.EXPECT().LoadConfig("/test", entry.ExpectedProfile).Return(config, nil)
+		entry.Mocks.Builder.EXPECT().BuildBinaries(config).Return(nil)
+
+		err := entry.Subject.Run(entry.Args)
+		ensure(err).IsNotError()
+	})
+}
+
 func makeLambda(path string, buildFlags []string) *lambgofile.Lambda {
 	return &lambgofile.Lambda{
 		Path:       path,
 		BuildFlags: buildFlags,
 	}
 }
+
+func makeExtension(path, name string) *lambgofile.Extension {
+	return &lambgofile.Extension{
+		Lambda: *makeLambda(path, nil),
+		Name:   name,
+	}
+}
@@ -0,0 +1,206 @@
+package cmd
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"slices"
+
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+)
+
+// changeSet is the build targets that are affected by changes.
+type changeSet struct {
+	// all targets are affected, such as when goos changes.
+	all bool
+
+	// targets are the paths of the affected Lambdas, extensions, and layer packages.
+	targets map[string]struct{}
+
+	// layers are the names of the layers with affected files or config entries.
+	layers map[string]struct{}
+}
+
+// filterChangedTargets in the config to the ones affected by changes since the ref.
+// Targets are affected when a package they depend on changes, when go.mod or similar files change,
+// or when their .lambgo.yml entry changes. The profile and .lambgo.local.yml are also applied to the .lambgo.yml file at the ref.
+func (a *App) filterChangedTargets(config *lambgofile.Config, ref, profile string) error {
+	changedFiles, err := a.Differ.ChangedFiles(config.RootPath, ref)
+	if err != nil {
+		return err
+	}
+
+	graph, err := a.Grapher.Load(config, buildTargetPaths(config))
+	if err != nil {
+		return err
+	}
+
+	changes, err := a.changedConfigEntries(config, ref, profile, changedFiles)
+	if err != nil {
+		return err
+	}
+
+	for _, targetPath := range graph.Affected(changedFiles) {
+		changes.targets[targetPath] = struct{}{}
+	}
+
+	for _, layer := range config.Layers {
+		if layerFilesChanged(layer, changedFiles) {
+			changes.layers[layer.Name] = struct{}{}
+		}
+	}
+
+	changes.filter(config)
+	return nil
+}
+
+// changedConfigEntries compares the .lambgo.yml file, and the files it includes, to the ones at the ref.
+func (a *App) changedConfigEntries(config *lambgofile.Config, ref, profile string, changedFiles []string) (*changeSet, error) {
+	changes := &changeSet{targets: make(map[string]struct{}), layers: make(map[string]struct{})}
+	configChanged := slices.ContainsFunc(changedFiles, func(changedFile string) bool {
+		return changedFile == lambgofile.ConfigFileName || slices.Contains(config.IncludedFiles, changedFile)
+	})
+	if !configChanged {
+		return changes, nil
+	}
+
+	data, found, err := a.Differ.ReadFile(config.RootPath, ref, lambgofile.ConfigFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	if !found {
+		changes.all = true
+		return changes, nil
+	}
+
+	// When the previous config is invalid, its entries cannot be compared
+	readPreviousFile := func(name string) ([]byte, error) {
+		data, found, err := a.Differ.ReadFile(config.RootPath, ref, name)
+		if err != nil {
+			return nil, err
+		}
+
+		if !found {
+			return nil, fs.ErrNotExist
+		}
+
+		return data, nil
+	}
+
+	// .lambgo.local.yml is not committed, so the current one is also merged onto the previous config
+	localData, err := os.ReadFile(filepath.Join(config.RootPath, lambgofile.LocalConfigFileName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	module := &lambgofile.Module{RootPath: config.RootPath, ModulePath: config.ModulePath, Workspace: config.Workspace}
+	previous, err := lambgofile.ParseConfig(data, localData, module, profile, readPreviousFile)
+	if err != nil {
+		changes.all = true
+		return changes, nil //nolint:nilerr // Treated as changing every entry
+	}
+
+	if previous.OutDirectory != config.OutDirectory || previous.ZippedFileName != config.ZippedFileName ||
+		previous.Goos != config.Goos || previous.Goarch != config.Goarch || !reflect.DeepEqual(previous.Image, config.Image) ||
+		!reflect.DeepEqual(previous.VersionInjection, config.VersionInjection) || !reflect.DeepEqual(previous.SBOM, config.SBOM) ||
+		!reflect.DeepEqual(previous.Licenses, config.Licenses) || !reflect.DeepEqual(previous.Signing, config.Signing) {
+		changes.all = true
+		return changes, nil
+	}
+
+	previousLambdas := make(map[string]*lambgofile.Lambda, len(previous.Lambdas))
+	for _, lambda := range previous.Lambdas {
+		previousLambdas[lambda.Path] = lambda
+	}
+
+	for _, lambda := range config.Lambdas {
+		if !reflect.DeepEqual(previousLambdas[lambda.Path], lambda) {
+			changes.targets[lambda.Path] = struct{}{}
+		}
+	}
+
+	previousExtensions := make(map[string]*lambgofile.Extension, len(previous.Extensions))
+	for _, extension := range previous.Extensions {
+		previousExtensions[extension.Path] = extension
+	}
+
+	for _, extension := range config.Extensions {
+		if !reflect.DeepEqual(previousExtensions[extension.Path], extension) {
+			changes.targets[extension.Path] = struct{}{}
+		}
+	}
+
+	previousLayers := make(map[string]*lambgofile.Layer, len(previous.Layers))
+	for _, layer := range previous.Layers {
+		previousLayers[layer.Name] = layer
+	}
+
+	for _, layer := range config.Layers {
+		if !reflect.DeepEqual(previousLayers[layer.Name], layer) {
+			changes.layers[layer.Name] = struct{}{}
+		}
+	}
+
+	return changes, nil
+}
+
+// filter the config to the affected targets.
+// Layers are affected when any of their packages are affected.
+func (changes *changeSet) filter(config *lambgofile.Config) {
+	if changes.all {
+		return
+	}
+
+	isAffected := func(path string) bool {
+		_, ok := changes.targets[path]
+		return ok
+	}
+
+	var (
+		lambdas    []*lambgofile.Lambda
+		extensions []*lambgofile.Extension
+		layers     []*lambgofile.Layer
+	)
+
+	for _, lambda := range config.Lambdas {
+		if isAffected(lambda.Path) {
+			lambdas = append(lambdas, lambda)
+		}
+	}
+
+	for _, extension := range config.Extensions {
+		if isAffected(extension.Path) {
+			extensions = append(extensions, extension)
+		}
+	}
+
+	for _, layer := range config.Layers {
+		_, layerChanged := changes.layers[layer.Name]
+		if layerChanged || slices.ContainsFunc(layer.Packages, func(pkg *lambgofile.LayerPackage) bool { return isAffected(pkg.Path) }) {
+			layers = append(layers, layer)
+		}
+	}
+
+	config.Lambdas = lambdas
+	config.Extensions = extensions
+	config.Layers = layers
+}
+
+// layerFilesChanged reports if any changed file matches the globs of the layer, or is within a matching directory.
+func layerFilesChanged(layer *lambgofile.Layer, changedFiles []string) bool {
+	for _, files := range layer.Files {
+		for _, changedFile := range changedFiles {
+			for dir := changedFile; dir != "." && dir != "/"; dir = path.Dir(dir) {
+				if matched, _ := path.Match(files.Glob, dir); matched {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
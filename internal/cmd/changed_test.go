@@ -0,0 +1,391 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/depgraph"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_builder"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_depgraph"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_gitdiff"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+	"github.com/golang/mock/gomock"
+)
+
+func TestBuildChangedSince(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		Builder          *mock_builder.MockLambdaBuilderAPI
+		DryRunBuilder    *mock_builder.MockLambdaBuilderAPI `ensure:"ignoreunused"`
+		Differ           *mock_gitdiff.MockDifferAPI
+		Grapher          *mock_depgraph.MockGrapherAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	newConfig := func() *lambgofile.Config {
+		return &lambgofile.Config{
+			RootPath:   "/some/root/path",
+			ModulePath: "github.com/my/app",
+			Goos:       "linux",
+			Goarch:     "amd64",
+			Lambdas: []*lambgofile.Lambda{
+				makeLambda("lambdas/api", nil),
+				makeLambda("lambdas/worker", []string{"-tags", "prod"}),
+				makeLambda("lambdas/cron", nil),
+			},
+			Extensions: []*lambgofile.Extension{makeExtension("extensions/trace", "trace")},
+			Layers: []*lambgofile.Layer{
+				{Name: "tools", Packages: []*lambgofile.LayerPackage{{Lambda: *makeLambda("tools/converter", nil), Destination: "bin/"}}},
+				{Name: "assets", Files: []*lambgofile.LayerFiles{{Glob: "assets/*.json"}, {Glob: "data"}}},
+			},
+		}
+	}
+
+	graph := depgraph.Graph{
+		"lambdas/api":      {{Dir: "lambdas/api"}, {Dir: "internal/store"}},
+		"lambdas/worker":   {{Dir: "lambdas/worker"}, {Dir: "internal/queue"}},
+		"lambdas/cron":     {{Dir: "lambdas/cron"}},
+		"extensions/trace": {{Dir: "extensions/trace"}},
+		"tools/converter":  {{Dir: "tools/converter"}, {Dir: "internal/store"}},
+	}
+	targetPaths := []string{"lambdas/api", "lambdas/worker", "lambdas/cron", "extensions/trace", "tools/converter"}
+
+	// expectChanges since origin/main to be listed
+	expectChanges := func(m *Mocks, changedFiles ...string) {
+		config := newConfig()
+		m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+		m.Differ.EXPECT().ChangedFiles("/some/root/path", "origin/main").Return(changedFiles, nil)
+		m.Grapher.EXPECT().Load(config, targetPaths).Return(graph, nil)
+	}
+
+	// expectBuild of the filtered config
+	expectBuild := func(m *Mocks, filter func(config *lambgofile.Config)) {
+		expected := newConfig()
+		filter(expected)
+		expected.NumParallel = numBuildTargets(expected)
+
+		m.Builder.EXPECT().BuildBinaries(expected).Return(nil)
+	}
+
+	previousConfig := "buildPaths:\n  - lambdas/api\n  - lambdas/cron\n" +
+		"lambdas:\n  - path: lambdas/worker\n    buildFlags: -tags dev\n" +
+		"extensions:\n  - path: extensions/trace\n    name: trace\n" +
+		"layers:\n" +
+		"  - name: tools\n    packages:\n      - path: tools/converter\n" +
+		"  - name: assets\n    files:\n      - glob: assets/*.json\n"
+
+	// localRoot has a .lambgo.local.yml, which is not committed, so it also applies to the previous .lambgo.yml
+	localRoot := t.TempDir()
+	ensure(os.WriteFile(filepath.Join(localRoot, lambgofile.LocalConfigFileName), []byte("goarch: arm64\n"), 0o600)).IsNotError()
+
+	table := []struct {
+		Name           string
+		Flags          []string
+		ExpectedError  error
+		ExpectedOutput string
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name: "with changed packages",
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "internal/store/store.go", "README.md")
+				expectBuild(m, func(config *lambgofile.Config) {
+					config.Lambdas = config.Lambdas[:1]
+					config.Extensions = nil
+					config.Layers = config.Layers[:1]
+				})
+			},
+		},
+
+		{
+			Name: "with changed layer files",
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "assets/users.json", "data/nested/file.txt")
+				expectBuild(m, func(config *lambgofile.Config) {
+					config.Lambdas = nil
+					config.Extensions = nil
+					config.Layers = config.Layers[1:]
+				})
+			},
+		},
+
+		{
+			Name: "with changed go.mod",
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "go.mod")
+				expectBuild(m, func(config *lambgofile.Config) {
+					config.Layers = config.Layers[:1]
+				})
+			},
+		},
+
+		{
+			Name:  "with changed entries in .lambgo.yml, combined with --only",
+			Flags: []string{"--only", "lambdas/", "--only", "layers/assets"},
+			SetupMocks: func(m *Mocks) {
+				config := newConfig()
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+				m.Differ.EXPECT().ChangedFiles("/some/root/path", "origin/main").Return([]string{".lambgo.yml"}, nil)
+				m.Grapher.EXPECT().Load(config, []string{"lambdas/api", "lambdas/cron", "lambdas/worker"}).Return(graph, nil)
+				m.Differ.EXPECT().ReadFile("/some/root/path", "origin/main", ".lambgo.yml").Return([]byte(previousConfig), true, nil)
+
+				m.Builder.EXPECT().
+					BuildBinaries(&lambgofile.Config{
+						NumParallel: 1,
+						RootPath:    "/some/root/path",
+						ModulePath:  "github.com/my/app",
+						Goos:        "linux",
+						Goarch:      "amd64",
+						Lambdas:     []*lambgofile.Lambda{makeLambda("lambdas/worker", []string{"-tags", "prod"})},
+						Layers:      newConfig().Layers[1:],
+					}).
+					Return(nil)
+			},
+		},
+
+		{
+			Name:  "with --profile applied to .lambgo.yml at the ref",
+			Flags: []string{"--profile", "prod"},
+			SetupMocks: func(m *Mocks) {
+				config := newConfig()
+				config.Goarch = "arm64"
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "prod").Return(config, nil)
+				m.Differ.EXPECT().ChangedFiles("/some/root/path", "origin/main").Return([]string{".lambgo.yml"}, nil)
+				m.Grapher.EXPECT().Load(config, targetPaths).Return(graph, nil)
+				m.Differ.EXPECT().
+					ReadFile("/some/root/path", "origin/main", ".lambgo.yml").
+					Return([]byte(previousConfig+"profiles:\n  prod:\n    goarch: arm64\n"), true, nil)
+
+				expected := newConfig()
+				expected.Goarch = "arm64"
+				expected.NumParallel = 1
+				expected.Lambdas = expected.Lambdas[1:2]
+				expected.Extensions = nil
+				expected.Layers = expected.Layers[1:]
+				m.Builder.EXPECT().BuildBinaries(expected).Return(nil)
+			},
+		},
+
+		{
+			Name: "with .lambgo.local.yml applied to .lambgo.yml at the ref",
+			SetupMocks: func(m *Mocks) {
+				config := newConfig()
+				config.RootPath = localRoot
+				config.Goarch = "arm64"
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+				m.Differ.EXPECT().ChangedFiles(localRoot, "origin/main").Return([]string{".lambgo.yml"}, nil)
+				m.Grapher.EXPECT().Load(config, targetPaths).Return(graph, nil)
+				m.Differ.EXPECT().ReadFile(localRoot, "origin/main", ".lambgo.yml").Return([]byte(previousConfig), true, nil)
+
+				expected := newConfig()
+				expected.RootPath = localRoot
+				expected.Goarch = "arm64"
+				expected.NumParallel = 1
+				expected.Lambdas = expected.Lambdas[1:2]
+				expected.Extensions = nil
+				expected.Layers = expected.Layers[1:]
+				m.Builder.EXPECT().BuildBinaries(expected).Return(nil)
+			},
+		},
+
+		{
+			Name: "with changed entries in a file included by .lambgo.yml",
+			SetupMocks: func(m *Mocks) {
+				newIncludedConfig := func() *lambgofile.Config {
+					config := newConfig()
+					config.Lambdas = append(config.Lambdas, makeLambda("services/billing/lambdas/charge", []string{"-tags", "prod"}))
+					config.IncludedFiles = []string{"services/billing/lambgo.yml"}
+					return config
+				}
+
+				config := newIncludedConfig()
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+				m.Differ.EXPECT().ChangedFiles("/some/root/path", "origin/main").Return([]string{"services/billing/lambgo.yml"}, nil)
+				m.Grapher.EXPECT().
+					Load(config, []string{
+						"lambdas/api", "lambdas/worker", "lambdas/cron", "services/billing/lambdas/charge", "extensions/trace", "tools/converter",
+					}).
+					Return(graph, nil)
+				m.Differ.EXPECT().
+					ReadFile("/some/root/path", "origin/main", ".lambgo.yml").
+					Return([]byte(previousConfig+"include:\n  - services/billing\n"), true, nil)
+				m.Differ.EXPECT().
+					ReadFile("/some/root/path", "origin/main", "services/billing/lambgo.yml").
+					Return([]byte("lambdas:\n  - path: lambdas/charge\n"), true, nil)
+
+				expected := newIncludedConfig()
+				expected.NumParallel = 2
+				expected.Lambdas = []*lambgofile.Lambda{
+					makeLambda("lambdas/worker", []string{"-tags", "prod"}),
+					makeLambda("services/billing/lambdas/charge", []string{"-tags", "prod"}),
+				}
+				expected.Extensions = nil
+				expected.Layers = expected.Layers[1:]
+				m.Builder.EXPECT().BuildBinaries(expected).Return(nil)
+			},
+		},
+
+		{
+			Name: "with changed global settings in .lambgo.yml",
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, ".lambgo.yml")
+				m.Differ.EXPECT().
+					ReadFile("/some/root/path", "origin/main", ".lambgo.yml").
+					Return([]byte("goarch: arm64\n"), true, nil)
+				expectBuild(m, func(*lambgofile.Config) {})
+			},
+		},
+
+		{
+			Name: "with changed versionInjection in .lambgo.yml",
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, ".lambgo.yml")
+				m.Differ.EXPECT().
+					ReadFile("/some/root/path", "origin/main", ".lambgo.yml").
+					Return([]byte(previousConfig+"versionInjection:\n  commit: main.Commit\n"), true, nil)
+				expectBuild(m, func(*lambgofile.Config) {})
+			},
+		},
+
+		{
+			Name: "with changed sbom in .lambgo.yml",
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, ".lambgo.yml")
+				m.Differ.EXPECT().
+					ReadFile("/some/root/path", "origin/main", ".lambgo.yml").
+					Return([]byte(previousConfig+"sbom:\n  format: spdx\n"), true, nil)
+				expectBuild(m, func(*lambgofile.Config) {})
+			},
+		},
+
+		{
+			Name: "with changed disallowed licenses in .lambgo.yml",
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, ".lambgo.yml")
+				m.Differ.EXPECT().
+					ReadFile("/some/root/path", "origin/main", ".lambgo.yml").
+					Return([]byte(previousConfig+"licenses:\n  disallowed: [GPL-3.0]\n"), true, nil)
+				expectBuild(m, func(*lambgofile.Config) {})
+			},
+		},
+
+		{
+			Name: "with changed signing in .lambgo.yml",
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, ".lambgo.yml")
+				m.Differ.EXPECT().
+					ReadFile("/some/root/path", "origin/main", ".lambgo.yml").
+					Return([]byte(previousConfig+"signing:\n  keyFile: keys/signing.pem\n"), true, nil)
+				expectBuild(m, func(*lambgofile.Config) {})
+			},
+		},
+
+		{
+			Name: "with .lambgo.yml that is invalid at the ref",
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, ".lambgo.yml")
+				m.Differ.EXPECT().
+					ReadFile("/some/root/path", "origin/main", ".lambgo.yml").
+					Return([]byte("buildPaths: {"), true, nil)
+				expectBuild(m, func(*lambgofile.Config) {})
+			},
+		},
+
+		{
+			Name: "with .lambgo.yml that is missing at the ref",
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, ".lambgo.yml")
+				m.Differ.EXPECT().ReadFile("/some/root/path", "origin/main", ".lambgo.yml").Return(nil, false, nil)
+				expectBuild(m, func(*lambgofile.Config) {})
+			},
+		},
+
+		{
+			Name: "with no changes",
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "README.md")
+			},
+			ExpectedOutput: "No Lambdas changed since 'origin/main'\n",
+		},
+
+		{
+			Name:  "with dry run",
+			Flags: []string{"--dry-run"},
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, "internal/store/store.go")
+
+				expected := newConfig()
+				expected.NumParallel = 2
+				expected.Lambdas = expected.Lambdas[:1]
+				expected.Extensions = nil
+				expected.Layers = expected.Layers[:1]
+				m.DryRunBuilder.EXPECT().BuildBinaries(expected).Return(nil)
+			},
+			ExpectedOutput: "Dry run: commands are printed instead of run, and nothing is written\n",
+		},
+
+		{
+			Name:          "when listing the changes fails",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.Differ.EXPECT().ChangedFiles(gomock.Any(), gomock.Any()).Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when loading the dependencies fails",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.Differ.EXPECT().ChangedFiles(gomock.Any(), gomock.Any()).Return([]string{"go.mod"}, nil)
+				m.Grapher.EXPECT().Load(gomock.Any(), gomock.Any()).Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when reading .lambgo.yml at the ref fails",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				expectChanges(m, ".lambgo.yml")
+				m.Differ.EXPECT().ReadFile(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, false, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+		entry.Subject.DryRunBuilder = entry.Mocks.DryRunBuilder // Shares a type with Builder, so it is not selected automatically
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run(append([]string{"lambgo", "build", "--changed-since", "origin/main"}, entry.Flags...))
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
+
+func numBuildTargets(config *lambgofile.Config) int {
+	numTargets := len(config.Lambdas) + len(config.Extensions)
+	for _, layer := range config.Layers {
+		numTargets += len(layer.Packages)
+	}
+
+	return numTargets
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/urfave/cli/v3"
+)
+
+type ErkCannotExplainConfig struct{ erk.DefaultKind }
+
+var (
+	ErrInvalidExplainArgs = erk.New(ErkCannotExplainConfig{},
+		"Expected at most one path to explain, but received {{.numArgs}}. For example: lambgo config explain lambdas/api",
+	)
+
+	ErrUnknownExplainPath = erk.New(ErkCannotExplainConfig{}, "Cannot explain '{{.path}}', since it does not match any of:\n{{.paths}}")
+)
+
+// explainedField is a resolved field of the config, and where it came from.
+type explainedField struct {
+	key    string
+	value  string
+	source *lambgofile.Source
+}
+
+func (a *App) configCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "inspect the resolved .lambgo.yml configuration",
+
+		Commands: []*cli.Command{
+			{
+				Name: "explain",
+				Usage: "print each resolved field, and where it came from: a default, a top-level key, a per-lambda key, " +
+					"environment variable expansion, or a CLI flag. " +
+					"Provide the path of a Lambda, extension, or layer package (eg. layers/<name>/<path>) to include its fields.",
+				ArgsUsage: "[path]",
+
+				Flags: []cli.Flag{
+					disableParallelFlag(),
+					numParallelFlag(),
+				},
+
+				Action: a.runConfigExplain,
+			},
+		},
+	}
+}
+
+func (a *App) runConfigExplain(_ context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() > 1 {
+		return erk.WithParams(ErrInvalidExplainArgs, erk.Params{"numArgs": cmd.Args().Len()})
+	}
+
+	pwd, err := a.Getwd()
+	if err != nil {
+		return err
+	}
+
+	config, provenance, err := a.LambgoFileLoader.ExplainConfig(pwd, cmd.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	numParallelSource, err := setNumParallel(config, cmd)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Args().Len() == 1 {
+		targetPath := filepath.Clean(cmd.Args().First())
+		title, fields, err := explainTarget(config, provenance, targetPath)
+		if err != nil {
+			return err
+		}
+
+		a.printExplainedFields(title, fields)
+		a.Logger.Println()
+	}
+
+	fields := []*explainedField{
+		{key: "outDirectory", value: fmt.Sprintf("%q", outDirectory(config)), source: provenance.Fields["outDirectory"]},
+		{key: "zippedFileName", value: fmt.Sprintf("%q", config.ZippedFileName), source: provenance.Fields["zippedFileName"]},
+		{key: "goos", value: fmt.Sprintf("%q", config.Goos), source: provenance.Fields["goos"]},
+		{key: "goarch", value: fmt.Sprintf("%q", config.Goarch), source: provenance.Fields["goarch"]},
+		{key: "templatesDirectory", value: fmt.Sprintf("%q", config.TemplatesDirectory), source: provenance.Fields["templatesDirectory"]},
+	}
+
+	if config.Image != nil {
+		fields = append(fields,
+			&explainedField{key: "image.format", value: fmt.Sprintf("%q", config.Image.Format), source: provenance.Fields["image.format"]},
+			&explainedField{key: "image.binaryPath", value: fmt.Sprintf("%q", config.Image.BinaryPath), source: provenance.Fields["image.binaryPath"]},
+		)
+	}
+
+	if config.SBOM != nil {
+		fields = append(fields, &explainedField{key: "sbom.format", value: fmt.Sprintf("%q", config.SBOM.Format), source: provenance.Fields["sbom.format"]})
+	}
+
+	if config.Licenses != nil {
+		fields = append(fields,
+			&explainedField{key: "licenses.bundle", value: fmt.Sprint(config.Licenses.Bundle), source: provenance.Fields["licenses.bundle"]},
+			&explainedField{key: "licenses.disallowed", value: fmt.Sprintf("%q", config.Licenses.Disallowed), source: provenance.Fields["licenses.disallowed"]},
+		)
+	}
+
+	if config.Signing != nil {
+		fields = append(fields,
+			&explainedField{key: "signing.keyFile", value: fmt.Sprintf("%q", config.Signing.KeyFile), source: provenance.Fields["signing.keyFile"]},
+			&explainedField{key: "signing.publicKeyFile", value: fmt.Sprintf("%q", config.Signing.PublicKeyFile), source: provenance.Fields["signing.publicKeyFile"]},
+		)
+	}
+
+	if injection := config.VersionInjection; injection != nil {
+		for _, field := range []struct{ key, variable string }{
+			{"versionInjection.commit", injection.Commit},
+			{"versionInjection.dirty", injection.Dirty},
+			{"versionInjection.tag", injection.Tag},
+			{"versionInjection.buildTime", injection.BuildTime},
+			{"versionInjection.lambdaPath", injection.LambdaPath},
+		} {
+			fields = append(fields, &explainedField{key: field.key, value: fmt.Sprintf("%q", field.variable), source: provenance.Fields[field.key]})
+		}
+	}
+
+	fields = append(fields, &explainedField{key: "numParallel", value: fmt.Sprint(config.NumParallel), source: numParallelSource})
+
+	a.printExplainedFields("Shared by all targets", fields)
+	return nil
+}
+
+// explainTarget finds the Lambda, extension, or layer package at the path, and returns a title and its fields.
+func explainTarget(config *lambgofile.Config, provenance *lambgofile.Provenance, targetPath string) (string, []*explainedField, error) {
+	var paths []string
+
+	for _, lambda := range config.Lambdas {
+		paths = append(paths, lambda.Path)
+		if lambda.Path != targetPath {
+			continue
+		}
+
+		zippedFileName := filepath.Base(lambda.Path)
+		if config.ZippedFileName != "" {
+			zippedFileName = config.ZippedFileName
+		}
+
+		sources := provenance.Targets[lambda.Path]
+		return lambda.Path + " (Lambda)", withBuildOptions(lambda, sources, []*explainedField{
+			{key: "buildFlags", value: fmt.Sprintf("%q", lambda.BuildFlags), source: sources["buildFlags"]},
+			{key: "zippedFileName", value: fmt.Sprintf("%q", zippedFileName), source: provenance.Fields["zippedFileName"]},
+		}), nil
+	}
+
+	for _, extension := range config.Extensions {
+		paths = append(paths, extension.Path)
+		if extension.Path != targetPath {
+			continue
+		}
+
+		sources := provenance.Targets[extension.Path]
+		return extension.Path + " (extension)", withBuildOptions(&extension.Lambda, sources, []*explainedField{
+			{key: "buildFlags", value: fmt.Sprintf("%q", extension.BuildFlags), source: sources["buildFlags"]},
+			{key: "name", value: fmt.Sprintf("%q", extension.Name), source: sources["name"]},
+		}), nil
+	}
+
+	for _, layer := range config.Layers {
+		for _, pkg := range layer.Packages {
+			key := lambgofile.LayerPackageKey(layer, pkg)
+			paths = append(paths, key)
+			if key != targetPath {
+				continue
+			}
+
+			sources := provenance.Targets[key]
+			return key + " (layer package)", withBuildOptions(&pkg.Lambda, sources, []*explainedField{
+				{key: "buildFlags", value: fmt.Sprintf("%q", pkg.BuildFlags), source: sources["buildFlags"]},
+				{key: "destination", value: fmt.Sprintf("%q", pkg.Destination), source: sources["destination"]},
+			}), nil
+		}
+	}
+
+	return "", nil, erk.WithParams(ErrUnknownExplainPath, erk.Params{"path": targetPath, "paths": paths})
+}
+
+// withBuildOptions adds the flags from the build options of the target after its buildFlags, when it has build options.
+func withBuildOptions(lambda *lambgofile.Lambda, sources map[string]*lambgofile.Source, fields []*explainedField) []*explainedField {
+	if lambda.BuildOptions == nil {
+		return fields
+	}
+
+	field := &explainedField{key: "buildOptions", value: fmt.Sprintf("%q", lambda.BuildOptions.Flags()), source: sources["buildOptions"]}
+	return slices.Insert(fields, 1, field)
+}
+
+func (a *App) printExplainedFields(title string, fields []*explainedField) {
+	a.Logger.Println(title)
+
+	for _, field := range fields {
+		a.Logger.Printf("  %s: %s\n", field.key, field.value)
+		a.Logger.Printf("    from %s\n", field.source)
+	}
+}
+
+func outDirectory(config *lambgofile.Config) string {
+	if config.OutDirectory == "" {
+		return lambgofile.DefaultOutDirectory
+	}
+
+	return config.OutDirectory
+}
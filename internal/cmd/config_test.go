@@ -0,0 +1,314 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+)
+
+func TestConfigExplain(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	defaultSource := func(key string) *lambgofile.Source {
+		return &lambgofile.Source{Origin: lambgofile.OriginDefault, Key: key}
+	}
+
+	newConfig := func() *lambgofile.Config {
+		return &lambgofile.Config{
+			RootPath:   "/some/root/path",
+			ModulePath: "github.com/my/app",
+			Goos:       "linux",
+			Goarch:     "arm64",
+			Lambdas: []*lambgofile.Lambda{
+				makeLambda("lambdas/api", []string{"-tags", "prod"}),
+				makeLambda("lambdas/cron", nil),
+			},
+			Extensions: []*lambgofile.Extension{makeExtension("extensions/trace", "tracer")},
+			Layers: []*lambgofile.Layer{
+				{Name: "tools", Packages: []*lambgofile.LayerPackage{{Lambda: *makeLambda("cmd/convert", nil), Destination: "bin/"}}},
+			},
+		}
+	}
+
+	buildFlags := &lambgofile.Source{
+		Origin:  lambgofile.OriginEnvironment,
+		Key:     "buildFlags",
+		Raw:     "-tags $TAGS",
+		EnvVars: []string{"TAGS"},
+	}
+
+	provenance := &lambgofile.Provenance{
+		Fields: map[string]*lambgofile.Source{
+			"outDirectory":       defaultSource("outDirectory"),
+			"zippedFileName":     defaultSource("zippedFileName"),
+			"goos":               defaultSource("goos"),
+			"goarch":             {Origin: lambgofile.OriginTopLevel, Key: "goarch", Raw: "arm64"},
+			"templatesDirectory": defaultSource("templatesDirectory"),
+			"buildFlags":         buildFlags,
+		},
+		Targets: map[string]map[string]*lambgofile.Source{
+			"lambdas/api": {"buildFlags": buildFlags},
+			"lambdas/cron": {
+				"buildFlags": {Origin: lambgofile.OriginPerLambda, Key: "lambdas[0].buildFlags"},
+			},
+			"extensions/trace": {
+				"buildFlags": {Origin: lambgofile.OriginPerLambda, Key: "extensions[0].buildFlags"},
+				"name":       {Origin: lambgofile.OriginPerLambda, Key: "extensions[0].name", Raw: "tracer"},
+			},
+			"layers/tools/cmd/convert": {
+				"buildFlags":  {Origin: lambgofile.OriginPerLambda, Key: "layers[0].packages[0].buildFlags"},
+				"destination": defaultSource("layers[0].packages[0].destination"),
+			},
+		},
+	}
+
+	sharedOutput := func(numParallel string) string {
+		return "Shared by all targets\n" +
+			"  outDirectory: \"tmp\"\n" +
+			"    from default\n" +
+			"  zippedFileName: \"\"\n" +
+			"    from default\n" +
+			"  goos: \"linux\"\n" +
+			"    from default\n" +
+			"  goarch: \"arm64\"\n" +
+			"    from top-level key goarch: \"arm64\"\n" +
+			"  templatesDirectory: \"\"\n" +
+			"    from default\n" +
+			numParallel
+	}
+
+	table := []struct {
+		Name           string
+		Args           []string
+		ExpectedError  error
+		ExpectedOutput string
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name: "with Lambda using expanded top-level build flags",
+			Args: []string{"lambdas/api"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().ExplainConfig("/test", "").Return(newConfig(), provenance, nil)
+			},
+			ExpectedOutput: "lambdas/api (Lambda)\n" +
+				"  buildFlags: [\"-tags\" \"prod\"]\n" +
+				"    from environment variable expansion of $TAGS in buildFlags: \"-tags $TAGS\"\n" +
+				"  zippedFileName: \"api\"\n" +
+				"    from default\n" +
+				"\n" +
+				sharedOutput("  numParallel: 4\n    from default\n"),
+		},
+
+		{
+			Name: "with Lambda overriding build flags with an empty value, and --num-parallel",
+			Args: []string{"--num-parallel", "2", "lambdas/cron/"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().ExplainConfig("/test", "").Return(newConfig(), provenance, nil)
+			},
+			ExpectedOutput: "lambdas/cron (Lambda)\n" +
+				"  buildFlags: []\n" +
+				"    from per-lambda key lambdas[0].buildFlags: \"\"\n" +
+				"  zippedFileName: \"cron\"\n" +
+				"    from default\n" +
+				"\n" +
+				sharedOutput("  numParallel: 2\n    from CLI flag --num-parallel: \"2\"\n"),
+		},
+
+		{
+			Name: "with Lambda using per-lambda build options",
+			Args: []string{"lambdas/cron"},
+			SetupMocks: func(m *Mocks) {
+				config := newConfig()
+				config.Lambdas[1].BuildOptions = &lambgofile.BuildOptions{Tags: []string{"cron"}, Trimpath: true}
+
+				provenance := &lambgofile.Provenance{
+					Fields: provenance.Fields,
+					Targets: map[string]map[string]*lambgofile.Source{
+						"lambdas/cron": {
+							"buildFlags":   {Origin: lambgofile.OriginPerLambda, Key: "lambdas[0].buildFlags"},
+							"buildOptions": {Origin: lambgofile.OriginPerLambda, Key: "lambdas[0].buildOptions", Raw: "tags: [cron]"},
+						},
+					},
+				}
+
+				m.LambgoFileLoader.EXPECT().ExplainConfig("/test", "").Return(config, provenance, nil)
+			},
+			ExpectedOutput: "lambdas/cron (Lambda)\n" +
+				"  buildFlags: []\n" +
+				"    from per-lambda key lambdas[0].buildFlags: \"\"\n" +
+				"  buildOptions: [\"-trimpath\" \"-tags=cron\"]\n" +
+				"    from per-lambda key lambdas[0].buildOptions: \"tags: [cron]\"\n" +
+				"  zippedFileName: \"cron\"\n" +
+				"    from default\n" +
+				"\n" +
+				sharedOutput("  numParallel: 4\n    from default\n"),
+		},
+
+		{
+			Name: "with extension",
+			Args: []string{"extensions/trace"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().ExplainConfig("/test", "").Return(newConfig(), provenance, nil)
+			},
+			ExpectedOutput: "extensions/trace (extension)\n" +
+				"  buildFlags: []\n" +
+				"    from per-lambda key extensions[0].buildFlags: \"\"\n" +
+				"  name: \"tracer\"\n" +
+				"    from per-lambda key extensions[0].name: \"tracer\"\n" +
+				"\n" +
+				sharedOutput("  numParallel: 4\n    from default\n"),
+		},
+
+		{
+			Name: "with layer package, and --disable-parallel",
+			Args: []string{"--disable-parallel", "layers/tools/cmd/convert"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().ExplainConfig("/test", "").Return(newConfig(), provenance, nil)
+			},
+			ExpectedOutput: "layers/tools/cmd/convert (layer package)\n" +
+				"  buildFlags: []\n" +
+				"    from per-lambda key layers[0].packages[0].buildFlags: \"\"\n" +
+				"  destination: \"bin/\"\n" +
+				"    from default\n" +
+				"\n" +
+				sharedOutput("  numParallel: 1\n    from CLI flag --disable-parallel: \"true\"\n"),
+		},
+
+		{
+			Name: "with no path, and an image",
+			SetupMocks: func(m *Mocks) {
+				config := newConfig()
+				config.Image = &lambgofile.Image{Format: lambgofile.ImageFormatTarball, BinaryPath: "/var/runtime/bootstrap"}
+
+				imageProvenance := *provenance
+				imageProvenance.Fields = map[string]*lambgofile.Source{
+					"image.format":     {Origin: lambgofile.OriginTopLevel, Key: "image.format", Raw: "tarball"},
+					"image.binaryPath": defaultSource("image.binaryPath"),
+				}
+				for key, source := range provenance.Fields {
+					imageProvenance.Fields[key] = source
+				}
+
+				m.LambgoFileLoader.EXPECT().ExplainConfig("/test", "").Return(config, &imageProvenance, nil)
+			},
+			ExpectedOutput: sharedOutput("  image.format: \"tarball\"\n" +
+				"    from top-level key image.format: \"tarball\"\n" +
+				"  image.binaryPath: \"/var/runtime/bootstrap\"\n" +
+				"    from default\n" +
+				"  numParallel: 4\n    from default\n"),
+		},
+
+		{
+			Name: "with no path, and licenses",
+			SetupMocks: func(m *Mocks) {
+				config := newConfig()
+				config.Licenses = &lambgofile.Licenses{Disallowed: []string{"GPL-3.0", "Unknown"}}
+
+				licensesProvenance := *provenance
+				licensesProvenance.Fields = map[string]*lambgofile.Source{
+					"licenses.bundle":     defaultSource("licenses.bundle"),
+					"licenses.disallowed": {Origin: lambgofile.OriginTopLevel, Key: "licenses.disallowed", Raw: "GPL-3.0, Unknown"},
+				}
+				for key, source := range provenance.Fields {
+					licensesProvenance.Fields[key] = source
+				}
+
+				m.LambgoFileLoader.EXPECT().ExplainConfig("/test", "").Return(config, &licensesProvenance, nil)
+			},
+			ExpectedOutput: sharedOutput("  licenses.bundle: false\n" +
+				"    from default\n" +
+				"  licenses.disallowed: [\"GPL-3.0\" \"Unknown\"]\n" +
+				"    from top-level key licenses.disallowed: \"GPL-3.0, Unknown\"\n" +
+				"  numParallel: 4\n    from default\n"),
+		},
+
+		{
+			Name: "with no path, and signing",
+			SetupMocks: func(m *Mocks) {
+				config := newConfig()
+				config.Signing = &lambgofile.Signing{KeyFile: "/secrets/signing.pem"}
+
+				signingProvenance := *provenance
+				signingProvenance.Fields = map[string]*lambgofile.Source{
+					"signing.keyFile": {
+						Origin:  lambgofile.OriginEnvironment,
+						Key:     "signing.keyFile",
+						Raw:     "$SIGNING_KEY",
+						EnvVars: []string{"SIGNING_KEY"},
+					},
+					"signing.publicKeyFile": defaultSource("signing.publicKeyFile"),
+				}
+				for key, source := range provenance.Fields {
+					signingProvenance.Fields[key] = source
+				}
+
+				m.LambgoFileLoader.EXPECT().ExplainConfig("/test", "").Return(config, &signingProvenance, nil)
+			},
+			ExpectedOutput: sharedOutput("  signing.keyFile: \"/secrets/signing.pem\"\n" +
+				"    from environment variable expansion of $SIGNING_KEY in signing.keyFile: \"$SIGNING_KEY\"\n" +
+				"  signing.publicKeyFile: \"\"\n" +
+				"    from default\n" +
+				"  numParallel: 4\n    from default\n"),
+		},
+
+		{
+			Name:          "with unknown path",
+			Args:          []string{"lambdas/missing"},
+			ExpectedError: cmd.ErrUnknownExplainPath,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().ExplainConfig("/test", "").Return(newConfig(), provenance, nil)
+			},
+		},
+
+		{
+			Name:          "with too many paths",
+			Args:          []string{"lambdas/api", "lambdas/cron"},
+			ExpectedError: cmd.ErrInvalidExplainArgs,
+		},
+
+		{
+			Name:          "with invalid --num-parallel",
+			Args:          []string{"--num-parallel", "zero"},
+			ExpectedError: cmd.ErrInvalidNumParallel,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().ExplainConfig("/test", "").Return(newConfig(), provenance, nil)
+			},
+		},
+
+		{
+			Name:          "when loading the config fails",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().ExplainConfig("/test", "").Return(nil, nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run(append([]string{"lambgo", "config", "explain"}, entry.Args...))
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
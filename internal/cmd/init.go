@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/JosiahWitt/lambgo/internal/scaffold"
+	"github.com/urfave/cli/v3"
+)
+
+func (a *App) initCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "init",
+		Usage: "create a .lambgo.yml file listing the Lambdas in the current module",
+
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name: "runtime",
+				Usage: "Lambda `runtime` of the Lambdas. Either `provided.al2023`, `provided.al2`, or `go1.x`. " +
+					"The provided runtimes zip each binary as bootstrap.",
+				Value: scaffold.RuntimeProvidedAL2023,
+			},
+			&cli.StringFlag{
+				Name:  "arch",
+				Usage: "Lambda `architecture` of the Lambdas. Either `x86_64` (or `amd64`) or `arm64`.",
+				Value: scaffold.ArchX86,
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "Overwrite the .lambgo.yml file if it already exists.",
+			},
+		},
+
+		Action: a.runInit,
+	}
+}
+
+func (a *App) runInit(ctx context.Context, cmd *cli.Command) error {
+	pwd, err := a.Getwd()
+	if err != nil {
+		return err
+	}
+
+	// The root is found like when loading the config, so the file is written where it is loaded from
+	module, err := a.LambgoFileLoader.FindRoot(pwd)
+	if err != nil {
+		return err
+	}
+
+	var moduleDirs []string
+	if module.Workspace != nil {
+		for _, workspaceModule := range module.Workspace.Modules {
+			moduleDirs = append(moduleDirs, workspaceModule.Dir)
+		}
+	}
+
+	result, err := a.Scaffolder.Init(&scaffold.InitParams{
+		RootPath:   module.RootPath,
+		ModuleDirs: moduleDirs,
+		Runtime:    cmd.String("runtime"),
+		Arch:       cmd.String("arch"),
+		Force:      cmd.Bool("force"),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, skippedPath := range result.SkippedPaths {
+		a.Logger.Printf("Skipped '%s', since its Go files cannot be read: %s\n", skippedPath.Path, skippedPath.Reason)
+	}
+
+	if len(result.BuildPaths) == 0 {
+		a.Logger.Printf("Wrote '%s', but no Lambdas were found. Add their paths to buildPaths.\n", result.ConfigPath)
+		return nil
+	}
+
+	if len(result.BuildPaths) == 1 {
+		a.Logger.Printf("Wrote '%s' with 1 Lambda:\n", result.ConfigPath)
+	} else {
+		a.Logger.Printf("Wrote '%s' with %d Lambdas:\n", result.ConfigPath, len(result.BuildPaths))
+	}
+
+	for _, buildPath := range result.BuildPaths {
+		a.Logger.Printf(" - %s\n", buildPath)
+	}
+
+	return nil
+}
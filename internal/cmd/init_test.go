@@ -0,0 +1,209 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_scaffold"
+	"github.com/JosiahWitt/lambgo/internal/scaffold"
+)
+
+func TestInit(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		Scaffolder       *mock_scaffold.MockScaffolderAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/test/nested", nil
+	}
+
+	expectFindRoot := func(m *Mocks) {
+		m.LambgoFileLoader.EXPECT().
+			FindRoot("/test/nested").
+			Return(&lambgofile.Module{RootPath: "/test", ModulePath: "github.com/my/app"}, nil)
+	}
+
+	table := []struct {
+		Name           string
+		ExpectedError  error
+		ExpectedOutput string
+		Flags          []string
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:  "with valid execution using default flags",
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectFindRoot(m)
+
+				m.Scaffolder.EXPECT().
+					Init(&scaffold.InitParams{
+						RootPath: "/test",
+						Runtime:  scaffold.RuntimeProvidedAL2023,
+						Arch:     scaffold.ArchX86,
+					}).
+					Return(&scaffold.InitResult{
+						ConfigPath: "/test/.lambgo.yml",
+						BuildPaths: []string{"lambdas/api", "lambdas/worker"},
+					}, nil)
+			},
+			ExpectedOutput: "Wrote '/test/.lambgo.yml' with 2 Lambdas:\n - lambdas/api\n - lambdas/worker\n",
+		},
+
+		{
+			Name:  "with valid execution using all flags",
+			Flags: []string{"--runtime", "go1.x", "--arch", "arm64", "--force"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectFindRoot(m)
+
+				m.Scaffolder.EXPECT().
+					Init(&scaffold.InitParams{
+						RootPath: "/test",
+						Runtime:  scaffold.RuntimeGo1x,
+						Arch:     scaffold.ArchArm64,
+						Force:    true,
+					}).
+					Return(&scaffold.InitResult{
+						ConfigPath: "/test/.lambgo.yml",
+						BuildPaths: []string{"lambdas/api"},
+					}, nil)
+			},
+			ExpectedOutput: "Wrote '/test/.lambgo.yml' with 1 Lambda:\n - lambdas/api\n",
+		},
+
+		{
+			Name:  "with valid execution when no Lambdas are found",
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectFindRoot(m)
+
+				m.Scaffolder.EXPECT().
+					Init(&scaffold.InitParams{
+						RootPath: "/test",
+						Runtime:  scaffold.RuntimeProvidedAL2023,
+						Arch:     scaffold.ArchX86,
+					}).
+					Return(&scaffold.InitResult{ConfigPath: "/test/.lambgo.yml"}, nil)
+			},
+			ExpectedOutput: "Wrote '/test/.lambgo.yml', but no Lambdas were found. Add their paths to buildPaths.\n",
+		},
+
+		{
+			Name:  "with valid execution when directories are skipped",
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectFindRoot(m)
+
+				m.Scaffolder.EXPECT().
+					Init(&scaffold.InitParams{
+						RootPath: "/test",
+						Runtime:  scaffold.RuntimeProvidedAL2023,
+						Arch:     scaffold.ArchX86,
+					}).
+					Return(&scaffold.InitResult{
+						ConfigPath: "/test/.lambgo.yml",
+						BuildPaths: []string{"lambdas/api"},
+						SkippedPaths: []*scaffold.SkippedPath{
+							{Path: "tools/mixed", Reason: "found packages a (a.go) and b (b.go) in /test/tools/mixed"},
+						},
+					}, nil)
+			},
+			ExpectedOutput: "Skipped 'tools/mixed', since its Go files cannot be read: found packages a (a.go) and b (b.go) in /test/tools/mixed\n" +
+				"Wrote '/test/.lambgo.yml' with 1 Lambda:\n - lambdas/api\n",
+		},
+
+		{
+			Name:          "with error from Getwd",
+			ExpectedError: exampleError,
+			Getwd: func() (string, error) {
+				return "", exampleError
+			},
+		},
+
+		{
+			Name:  "with a workspace",
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().
+					FindRoot("/test/nested").
+					Return(&lambgofile.Module{
+						RootPath:   "/test",
+						ModulePath: "github.com/my/app",
+						Workspace: &lambgofile.Workspace{
+							FilePath: "/test/go.work",
+							Modules: []*lambgofile.WorkspaceModule{
+								{Dir: ".", ModulePath: "github.com/my/app"},
+								{Dir: "nested", ModulePath: "github.com/my/nested"},
+							},
+						},
+					}, nil)
+
+				m.Scaffolder.EXPECT().
+					Init(&scaffold.InitParams{
+						RootPath:   "/test",
+						ModuleDirs: []string{".", "nested"},
+						Runtime:    scaffold.RuntimeProvidedAL2023,
+						Arch:       scaffold.ArchX86,
+					}).
+					Return(&scaffold.InitResult{ConfigPath: "/test/.lambgo.yml", BuildPaths: []string{"nested/lambdas/api"}}, nil)
+			},
+			ExpectedOutput: "Wrote '/test/.lambgo.yml' with 1 Lambda:\n - nested/lambdas/api\n",
+		},
+
+		{
+			Name:          "with error from FindRoot",
+			ExpectedError: exampleError,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().
+					FindRoot("/test/nested").
+					Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "with error from Init",
+			ExpectedError: exampleError,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectFindRoot(m)
+
+				m.Scaffolder.EXPECT().
+					Init(&scaffold.InitParams{
+						RootPath: "/test",
+						Runtime:  scaffold.RuntimeProvidedAL2023,
+						Arch:     scaffold.ArchX86,
+					}).
+					Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run(append([]string{"lambgo", "init"}, entry.Flags...))
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
@@ -0,0 +1,199 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/runtimeapi"
+	"github.com/urfave/cli/v3"
+)
+
+const (
+	stdinEvent     = "-"
+	defaultEvent   = "{}"
+	defaultTimeout = 30 * time.Second
+)
+
+type ErkCannotInvoke struct{ erk.DefaultKind }
+
+var (
+	ErrInvalidInvokeArgs = erk.New(ErkCannotInvoke{},
+		"Expected exactly one Lambda path to invoke, but received {{.numArgs}}. For example: lambgo invoke lambdas/api --event event.json",
+	)
+
+	ErrUnknownLambda      = erk.New(ErkCannotInvoke{}, "Cannot invoke '{{.path}}', since it is not in buildPaths. Available Lambdas: {{.lambdaPaths}}")
+	ErrCannotReadEvent    = erk.New(ErkCannotInvoke{}, "Cannot read the event from '{{.event}}': {{.err}}")
+	ErrInvocationFailed   = erk.New(ErkCannotInvoke{}, "The Lambda returned an error: {{.errorType}}: {{.errorMessage}}")
+	ErrCannotStopFunction = erk.New(ErkCannotInvoke{}, "Cannot stop the Lambda: {{.err}}")
+)
+
+func (a *App) invokeCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "invoke",
+		Usage:     "build a Lambda for this machine, and invoke it locally using an emulated Lambda Runtime API",
+		ArgsUsage: "<path>",
+
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "event",
+				Usage: "Path to a JSON `file` containing the event to invoke the Lambda with. Use `-` to read the event from stdin. Defaults to `{}`.",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Maximum `duration` of the invocation, which is also provided to the Lambda as its deadline.",
+				Value: defaultTimeout,
+			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Usage: "After invoking, watch the module for changes, and rebuild and invoke the Lambda again with the same event when it is affected.",
+			},
+		},
+
+		Action: a.runInvoke,
+	}
+}
+
+func (a *App) runInvoke(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 1 {
+		return erk.WithParams(ErrInvalidInvokeArgs, erk.Params{"numArgs": cmd.Args().Len()})
+	}
+
+	pwd, err := a.Getwd()
+	if err != nil {
+		return err
+	}
+
+	config, err := a.LambgoFileLoader.LoadConfig(pwd, cmd.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	lambda, err := findLambda(config, cmd.Args().First())
+	if err != nil {
+		return err
+	}
+
+	payload, err := a.readEvent(pwd, cmd.String("event"))
+	if err != nil {
+		return err
+	}
+
+	timeout := cmd.Duration("timeout")
+	if !cmd.Bool("watch") {
+		return a.invokeOnce(ctx, config, lambda, payload, timeout)
+	}
+
+	if err := a.invokeOnce(ctx, config, lambda, payload, timeout); err != nil {
+		a.Logger.Printf("ERROR: %v\n", err)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return a.watchTargets(ctx, config, []string{lambda.Path}, func([]string) error {
+		return a.invokeOnce(ctx, config, lambda, payload, timeout)
+	})
+}
+
+// invokeOnce builds the Lambda, invokes it with the payload, and prints the response.
+func (a *App) invokeOnce(
+	ctx context.Context,
+	config *lambgofile.Config,
+	lambda *lambgofile.Lambda,
+	payload []byte,
+	timeout time.Duration,
+) (err error) {
+	binaryPath, err := a.Builder.BuildForHost(config, lambda)
+	if err != nil {
+		return err
+	}
+
+	function, err := a.Launcher.Launch(&runtimeapi.LaunchParams{
+		BinaryPath:   binaryPath,
+		FunctionName: path.Base(lambda.Path),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := function.Close(); closeErr != nil && err == nil {
+			err = erk.WrapAs(ErrCannotStopFunction, closeErr)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response, err := function.Invoke(ctx, payload)
+	if err != nil {
+		return err
+	}
+
+	if response.Error != nil {
+		return erk.WithParams(ErrInvocationFailed, erk.Params{
+			"errorType":    response.Error.ErrorType,
+			"errorMessage": response.Error.ErrorMessage,
+		})
+	}
+
+	a.Logger.Println(string(response.Payload))
+	return nil
+}
+
+// findLambda in the config matching the path.
+func findLambda(config *lambgofile.Config, lambdaPath string) (*lambgofile.Lambda, error) {
+	cleanPath := path.Clean(filepath.ToSlash(lambdaPath))
+
+	lambdaPaths := make([]string, 0, len(config.Lambdas))
+	for _, lambda := range config.Lambdas {
+		if lambda.Path == cleanPath {
+			return lambda, nil
+		}
+
+		lambdaPaths = append(lambdaPaths, lambda.Path)
+	}
+
+	return nil, erk.WithParams(ErrUnknownLambda, erk.Params{
+		"path":        lambdaPath,
+		"lambdaPaths": strings.Join(lambdaPaths, ", "),
+	})
+}
+
+func (a *App) readEvent(pwd, event string) ([]byte, error) {
+	var (
+		payload []byte
+		err     error
+	)
+
+	switch event {
+	case "":
+		return []byte(defaultEvent), nil
+	case stdinEvent:
+		payload, err = io.ReadAll(a.Stdin)
+	default:
+		if !filepath.IsAbs(event) {
+			event = filepath.Join(pwd, event)
+		}
+
+		payload, err = os.ReadFile(event)
+	}
+
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadEvent, err, erk.Params{"event": event})
+	}
+
+	if strings.TrimSpace(string(payload)) == "" {
+		return []byte(defaultEvent), nil
+	}
+
+	return payload, nil
+}
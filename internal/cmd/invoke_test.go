@@ -0,0 +1,242 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_builder"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_runtimeapi"
+	"github.com/JosiahWitt/lambgo/internal/runtimeapi"
+	"github.com/golang/mock/gomock"
+)
+
+func TestInvoke(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		Builder          *mock_builder.MockLambdaBuilderAPI
+		Launcher         *mock_runtimeapi.MockLauncherAPI
+		Function         *mock_runtimeapi.MockFunctionAPI `ensure:"ignoreunused"`
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	pwd := ensure.T().TempDir()
+	ensure(os.WriteFile(filepath.Join(pwd, "event.json"), []byte(`{"from":"file"}`), 0o600)).IsNotError()
+
+	defaultWd := func() (string, error) {
+		return pwd, nil
+	}
+
+	api := makeLambda("lambdas/api", nil)
+	config := &lambgofile.Config{
+		RootPath: pwd,
+		Lambdas:  []*lambgofile.Lambda{makeLambda("lambdas/worker", nil), api},
+	}
+
+	// expectInvoke expects the api Lambda to be built, launched, and invoked with the payload
+	expectInvoke := func(m *Mocks, payload string, timeout time.Duration) *gomock.Call {
+		m.LambgoFileLoader.EXPECT().LoadConfig(pwd, "").Return(config, nil)
+		m.Builder.EXPECT().BuildForHost(config, api).Return("/tmp/host/lambdas/api", nil)
+		m.Launcher.EXPECT().
+			Launch(&runtimeapi.LaunchParams{BinaryPath: "/tmp/host/lambdas/api", FunctionName: "api"}).
+			Return(m.Function, nil)
+		m.Function.EXPECT().Close().Return(nil)
+
+		return m.Function.EXPECT().
+			Invoke(gomock.Any(), []byte(payload)).
+			DoAndReturn(func(ctx context.Context, _ []byte) (*runtimeapi.Response, error) {
+				deadline, ok := ctx.Deadline()
+				ensure(ok).IsTrue()
+				ensure(time.Until(deadline) <= timeout).IsTrue()
+				return &runtimeapi.Response{Payload: []byte(`{"ok":true}`)}, nil
+			})
+	}
+
+	table := []struct {
+		Name           string
+		ExpectedError  error
+		ExpectedOutput string
+		Args           []string
+		Stdin          string
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:  "with event from file",
+			Args:  []string{"lambdas/api", "--event", "event.json"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectInvoke(m, `{"from":"file"}`, 30*time.Second)
+			},
+			ExpectedOutput: "{\"ok\":true}\n",
+		},
+
+		{
+			Name:  "with event from stdin and timeout",
+			Args:  []string{"--event", "-", "--timeout", "5s", "./lambdas/api/"},
+			Stdin: `{"from":"stdin"}`,
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectInvoke(m, `{"from":"stdin"}`, 5*time.Second)
+			},
+			ExpectedOutput: "{\"ok\":true}\n",
+		},
+
+		{
+			Name:  "with default event",
+			Args:  []string{"lambdas/api"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectInvoke(m, `{}`, 30*time.Second)
+			},
+			ExpectedOutput: "{\"ok\":true}\n",
+		},
+
+		{
+			Name:  "with error returned by the Lambda",
+			Args:  []string{"lambdas/api"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectInvoke(m, `{}`, 30*time.Second).Return(&runtimeapi.Response{
+					Error: &runtimeapi.InvocationError{ErrorMessage: "boom", ErrorType: "errorString"},
+				}, nil)
+			},
+			ExpectedError: cmd.ErrInvocationFailed,
+		},
+
+		{
+			Name:          "with no path",
+			Args:          []string{},
+			Getwd:         defaultWd,
+			ExpectedError: cmd.ErrInvalidInvokeArgs,
+		},
+
+		{
+			Name:          "with multiple paths",
+			Args:          []string{"lambdas/api", "lambdas/worker"},
+			Getwd:         defaultWd,
+			ExpectedError: cmd.ErrInvalidInvokeArgs,
+		},
+
+		{
+			Name:          "with error from Getwd",
+			Args:          []string{"lambdas/api"},
+			ExpectedError: exampleError,
+			Getwd: func() (string, error) {
+				return "", exampleError
+			},
+		},
+
+		{
+			Name:          "with error from LoadConfig",
+			Args:          []string{"lambdas/api"},
+			ExpectedError: exampleError,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig(pwd, "").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "with unknown Lambda",
+			Args:          []string{"lambdas/missing"},
+			ExpectedError: cmd.ErrUnknownLambda,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig(pwd, "").Return(config, nil)
+			},
+		},
+
+		{
+			Name:          "with missing event file",
+			Args:          []string{"lambdas/api", "--event", "missing.json"},
+			ExpectedError: cmd.ErrCannotReadEvent,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig(pwd, "").Return(config, nil)
+			},
+		},
+
+		{
+			Name:          "with error from BuildForHost",
+			Args:          []string{"lambdas/api"},
+			ExpectedError: exampleError,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig(pwd, "").Return(config, nil)
+				m.Builder.EXPECT().BuildForHost(config, api).Return("", exampleError)
+			},
+		},
+
+		{
+			Name:          "with error from Launch",
+			Args:          []string{"lambdas/api"},
+			ExpectedError: exampleError,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig(pwd, "").Return(config, nil)
+				m.Builder.EXPECT().BuildForHost(config, api).Return("/tmp/host/lambdas/api", nil)
+				m.Launcher.EXPECT().
+					Launch(&runtimeapi.LaunchParams{BinaryPath: "/tmp/host/lambdas/api", FunctionName: "api"}).
+					Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "with error from Invoke",
+			Args:          []string{"lambdas/api"},
+			ExpectedError: exampleError,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				expectInvoke(m, `{}`, 30*time.Second).Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "with error from Close",
+			Args:          []string{"lambdas/api"},
+			ExpectedError: cmd.ErrCannotStopFunction,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig(pwd, "").Return(config, nil)
+				m.Builder.EXPECT().BuildForHost(config, api).Return("/tmp/host/lambdas/api", nil)
+				m.Launcher.EXPECT().
+					Launch(&runtimeapi.LaunchParams{BinaryPath: "/tmp/host/lambdas/api", FunctionName: "api"}).
+					Return(m.Function, nil)
+				m.Function.EXPECT().Invoke(gomock.Any(), []byte(`{}`)).Return(&runtimeapi.Response{Payload: []byte(`null`)}, nil)
+				m.Function.EXPECT().Close().Return(exampleError)
+			},
+			ExpectedOutput: "null\n",
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+		entry.Subject.Stdin = strings.NewReader(entry.Stdin)
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run(append([]string{"lambgo", "invoke"}, entry.Args...))
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
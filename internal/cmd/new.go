@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/scaffold"
+	"github.com/urfave/cli/v3"
+)
+
+type ErkInvalidNewArgs struct{ erk.DefaultKind }
+
+var ErrInvalidNewArgs = erk.New(ErkInvalidNewArgs{},
+	"Expected exactly one path for the new Lambda, but received {{.numArgs}}. For example: lambgo new lambdas/orders/create --template api-gateway",
+)
+
+func (a *App) newCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "new",
+		Usage:     "create a new Lambda from a template, and add it to .lambgo.yml",
+		ArgsUsage: "<path>",
+
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name: "template",
+				Usage: "Name of the `template` used to create the Lambda. " +
+					"The built-in templates are: " + strings.Join(scaffold.BuiltinTemplates(), ", ") + ". " +
+					"Templates in the templatesDirectory override the built-in templates with the same name.",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "templates-dir",
+				Usage: "Directory containing user templates, relative to the module root. Overrides templatesDirectory in .lambgo.yml.",
+			},
+		},
+
+		Action: a.runNew,
+	}
+}
+
+func (a *App) runNew(ctx context.Context, cmd *cli.Command) error {
+	if cmd.Args().Len() != 1 {
+		return erk.WithParams(ErrInvalidNewArgs, erk.Params{"numArgs": cmd.Args().Len()})
+	}
+
+	pwd, err := a.Getwd()
+	if err != nil {
+		return err
+	}
+
+	config, err := a.LambgoFileLoader.LoadConfig(pwd, cmd.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	result, err := a.Scaffolder.New(&scaffold.NewParams{
+		Config:             config,
+		Path:               cmd.Args().First(),
+		Template:           cmd.String("template"),
+		TemplatesDirectory: cmd.String("templates-dir"),
+	})
+	if err != nil {
+		return err
+	}
+
+	a.Logger.Printf("Created '%s' from the '%s' template:\n", result.Path, cmd.String("template"))
+	for _, file := range result.Files {
+		a.Logger.Printf(" - %s\n", file)
+	}
+
+	a.Logger.Printf("Added '%s' to '%s'\n", result.Path, result.ConfigPath)
+	return nil
+}
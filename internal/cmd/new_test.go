@@ -0,0 +1,161 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_scaffold"
+	"github.com/JosiahWitt/lambgo/internal/scaffold"
+)
+
+func TestNew(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		Scaffolder       *mock_scaffold.MockScaffolderAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/test", nil
+	}
+
+	config := &lambgofile.Config{
+		RootPath:   "/test",
+		ModulePath: "github.com/my/app",
+		Lambdas:    []*lambgofile.Lambda{makeLambda("lambdas/existing", nil)},
+	}
+
+	table := []struct {
+		Name           string
+		ExpectedError  error
+		ExpectedOutput string
+		Args           []string
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:  "with valid execution",
+			Args:  []string{"lambdas/orders/create", "--template", "api-gateway"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+
+				m.Scaffolder.EXPECT().
+					New(&scaffold.NewParams{
+						Config:   config,
+						Path:     "lambdas/orders/create",
+						Template: "api-gateway",
+					}).
+					Return(&scaffold.NewResult{
+						Path:       "lambdas/orders/create",
+						ConfigPath: "/test/.lambgo.yml",
+						Files:      []string{"lambdas/orders/create/main.go"},
+					}, nil)
+			},
+			ExpectedOutput: "Created 'lambdas/orders/create' from the 'api-gateway' template:\n" +
+				" - lambdas/orders/create/main.go\n" +
+				"Added 'lambdas/orders/create' to '/test/.lambgo.yml'\n",
+		},
+
+		{
+			Name:  "with valid execution using templates directory",
+			Args:  []string{"--template", "worker", "--templates-dir", "templates", "lambdas/worker"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+
+				m.Scaffolder.EXPECT().
+					New(&scaffold.NewParams{
+						Config:             config,
+						Path:               "lambdas/worker",
+						Template:           "worker",
+						TemplatesDirectory: "templates",
+					}).
+					Return(&scaffold.NewResult{
+						Path:       "lambdas/worker",
+						ConfigPath: "/test/.lambgo.yml",
+						Files:      []string{"lambdas/worker/handler.go", "lambdas/worker/main.go"},
+					}, nil)
+			},
+			ExpectedOutput: "Created 'lambdas/worker' from the 'worker' template:\n" +
+				" - lambdas/worker/handler.go\n" +
+				" - lambdas/worker/main.go\n" +
+				"Added 'lambdas/worker' to '/test/.lambgo.yml'\n",
+		},
+
+		{
+			Name:          "with no path",
+			Args:          []string{"--template", "sqs"},
+			Getwd:         defaultWd,
+			ExpectedError: cmd.ErrInvalidNewArgs,
+		},
+
+		{
+			Name:          "with multiple paths",
+			Args:          []string{"--template", "sqs", "lambdas/a", "lambdas/b"},
+			Getwd:         defaultWd,
+			ExpectedError: cmd.ErrInvalidNewArgs,
+		},
+
+		{
+			Name:          "with error from Getwd",
+			Args:          []string{"--template", "sqs", "lambdas/a"},
+			ExpectedError: exampleError,
+			Getwd: func() (string, error) {
+				return "", exampleError
+			},
+		},
+
+		{
+			Name:          "with error from LoadConfig",
+			Args:          []string{"--template", "sqs", "lambdas/a"},
+			ExpectedError: exampleError,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "with error from New",
+			Args:          []string{"--template", "sqs", "lambdas/a"},
+			ExpectedError: exampleError,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+
+				m.Scaffolder.EXPECT().
+					New(&scaffold.NewParams{
+						Config:   config,
+						Path:     "lambdas/a",
+						Template: "sqs",
+					}).
+					Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run(append([]string{"lambgo", "new"}, entry.Args...))
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
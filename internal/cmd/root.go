@@ -2,9 +2,21 @@ package cmd
 
 import (
 	"context"
+	"io"
+	"log"
 
+	"github.com/JosiahWitt/lambgo/internal/audit"
+	"github.com/JosiahWitt/lambgo/internal/binsize"
 	"github.com/JosiahWitt/lambgo/internal/builder"
+	"github.com/JosiahWitt/lambgo/internal/depgraph"
+	"github.com/JosiahWitt/lambgo/internal/devserver"
+	"github.com/JosiahWitt/lambgo/internal/filewatch"
+	"github.com/JosiahWitt/lambgo/internal/gitdiff"
 	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/reproducible"
+	"github.com/JosiahWitt/lambgo/internal/runtimeapi"
+	"github.com/JosiahWitt/lambgo/internal/scaffold"
+	"github.com/JosiahWitt/lambgo/internal/signing"
 	"github.com/urfave/cli/v3"
 )
 
@@ -12,9 +24,22 @@ import (
 type App struct {
 	Version string
 
-	Getwd            func() (string, error)
-	LambgoFileLoader lambgofile.LoaderAPI
-	Builder          builder.LambdaBuilderAPI
+	Getwd             func() (string, error)
+	LambgoFileLoader  lambgofile.LoaderAPI
+	Builder           builder.LambdaBuilderAPI
+	DryRunBuilder     builder.LambdaBuilderAPI
+	Scaffolder        scaffold.ScaffolderAPI
+	Launcher          runtimeapi.LauncherAPI
+	DevServer         devserver.ServerAPI
+	Watcher           filewatch.WatcherAPI
+	Grapher           depgraph.GrapherAPI
+	Differ            gitdiff.DifferAPI
+	Verifier          reproducible.VerifierAPI
+	Auditor           audit.AuditorAPI
+	SizeAnalyzer      binsize.AnalyzerAPI
+	SignatureVerifier signing.VerifierAPI
+	Logger            *log.Logger
+	Stdin             io.Reader
 }
 
 // Run the application given the os.Args array.
@@ -24,8 +49,27 @@ func (a *App) Run(args []string) error {
 		Usage:   "A simple framework for building AWS Lambdas in Go.",
 		Version: a.Version,
 
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "profile",
+				Usage:   "Merge the profile with the provided `name` from the profiles in .lambgo.yml onto the rest of the config.",
+				Sources: cli.EnvVars("LAMBGO_PROFILE"),
+			},
+		},
+
 		Commands: []*cli.Command{
 			a.buildCmd(),
+			a.initCmd(),
+			a.newCmd(),
+			a.invokeCmd(),
+			a.serveCmd(),
+			a.configCmd(),
+			a.schemaCmd(),
+			a.validateCmd(),
+			a.verifyReproducibleCmd(),
+			a.auditCmd(),
+			a.sizeCmd(),
+			a.verifySignaturesCmd(),
 		},
 	}
 
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/urfave/cli/v3"
+)
+
+func (a *App) schemaCmd() *cli.Command {
+	return &cli.Command{
+		Name: "schema",
+		Usage: "print the JSON Schema of .lambgo.yml, for editor completion and validation. " +
+			"For example, with yaml-language-server: lambgo schema > .lambgo.schema.json",
+
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "included",
+				Usage: "Print the schema of the lambgo.yml files that are included by .lambgo.yml, instead.",
+			},
+		},
+
+		Action: a.runSchema,
+	}
+}
+
+func (a *App) runSchema(_ context.Context, cmd *cli.Command) error {
+	schema := lambgofile.ConfigSchema()
+	if cmd.Bool("included") {
+		schema = lambgofile.IncludedFileSchema()
+	}
+
+	// HTML is not escaped, so descriptions like <outDirectory>/<path>.zip stay readable
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(schema) //nolint:errchkjson // Always valid
+
+	a.Logger.Print(data.String())
+	return nil
+}
+
+func (a *App) validateCmd() *cli.Command {
+	return &cli.Command{
+		Name: "validate",
+		Usage: "check .lambgo.yml, .lambgo.local.yml, and the files they include against the schema printed by lambgo schema, " +
+			"and then load the config, to check that paths are unique and routes refer to Lambdas",
+		Action: a.runValidate,
+	}
+}
+
+func (a *App) runValidate(_ context.Context, cmd *cli.Command) error {
+	pwd, err := a.Getwd()
+	if err != nil {
+		return err
+	}
+
+	config, err := a.LambgoFileLoader.ValidateConfig(pwd, cmd.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	switch len(config.IncludedFiles) {
+	case 0:
+		a.Logger.Printf("No problems found in %s\n", lambgofile.ConfigFileName)
+	case 1:
+		a.Logger.Printf("No problems found in %s and 1 included file\n", lambgofile.ConfigFileName)
+	default:
+		a.Logger.Printf("No problems found in %s and %d included files\n", lambgofile.ConfigFileName, len(config.IncludedFiles))
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package cmd_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+)
+
+func TestSchema(t *testing.T) {
+	ensure := ensure.New(t)
+
+	table := []struct {
+		Name           string
+		Flags          []string
+		ExpectedSchema *lambgofile.JSONSchema
+	}{
+		{
+			Name:           "with .lambgo.yml",
+			ExpectedSchema: lambgofile.ConfigSchema(),
+		},
+		{
+			Name:           "with --included",
+			Flags:          []string{"--included"},
+			ExpectedSchema: lambgofile.IncludedFileSchema(),
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		output := &bytes.Buffer{}
+		app := &cmd.App{Logger: log.New(output, "", 0)}
+
+		err := app.Run(append([]string{"lambgo", "schema"}, entry.Flags...))
+		ensure(err).IsNotError()
+		ensure(output.String()).MatchesRegexp(`<outDirectory>/<path>\.zip`)
+
+		expected, err := json.Marshal(entry.ExpectedSchema)
+		ensure(err).IsNotError()
+
+		var actualSchema, expectedSchema any
+		ensure(json.Unmarshal(output.Bytes(), &actualSchema)).IsNotError()
+		ensure(json.Unmarshal(expected, &expectedSchema)).IsNotError()
+		ensure(actualSchema).Equals(expectedSchema)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	table := []struct {
+		Name           string
+		Flags          []string
+		ExpectedError  error
+		ExpectedOutput string
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name: "with valid config",
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().ValidateConfig("/test", "").Return(&lambgofile.Config{}, nil)
+			},
+			ExpectedOutput: "No problems found in .lambgo.yml\n",
+		},
+
+		{
+			Name:  "with included file, and --profile",
+			Flags: []string{"--profile", "prod"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().
+					ValidateConfig("/test", "prod").
+					Return(&lambgofile.Config{IncludedFiles: []string{"services/billing/lambgo.yml"}}, nil)
+			},
+			ExpectedOutput: "No problems found in .lambgo.yml and 1 included file\n",
+		},
+
+		{
+			Name: "with included files",
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().
+					ValidateConfig("/test", "").
+					Return(&lambgofile.Config{IncludedFiles: []string{"services/billing/lambgo.yml", "services/search/lambgo.yml"}}, nil)
+			},
+			ExpectedOutput: "No problems found in .lambgo.yml and 2 included files\n",
+		},
+
+		{
+			Name:          "with invalid config",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().ValidateConfig("/test", "").Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run(append([]string{"lambgo", "validate"}, entry.Flags...))
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/devserver"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/urfave/cli/v3"
+)
+
+const defaultServeAddr = "localhost:3000"
+
+type ErkCannotServe struct{ erk.DefaultKind }
+
+var ErrNoRoutes = erk.New(ErkCannotServe{},
+	"No routes are configured in .lambgo.yml. For example:\n\n"+
+		"routes:\n"+
+		"  - path: /orders/{id}\n"+
+		"    method: GET\n"+
+		"    lambda: lambdas/api\n",
+)
+
+func (a *App) serveCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "build the routed Lambdas for this machine, and serve HTTP requests by invoking them with API Gateway or Function URL events",
+
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "addr",
+				Usage: "`address` to listen on",
+				Value: defaultServeAddr,
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Maximum `duration` of each invocation, which is also provided to the Lambda as its deadline.",
+				Value: defaultTimeout,
+			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Usage: "Watch the module for changes, and rebuild the routed Lambdas that are affected. The next request to a rebuilt Lambda restarts it.",
+			},
+		},
+
+		Action: a.runServe,
+	}
+}
+
+func (a *App) runServe(ctx context.Context, cmd *cli.Command) error {
+	pwd, err := a.Getwd()
+	if err != nil {
+		return err
+	}
+
+	config, err := a.LambgoFileLoader.LoadConfig(pwd, cmd.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if len(config.Routes) == 0 {
+		return ErrNoRoutes
+	}
+
+	binaries := make(map[string]string)
+	for _, lambda := range routedLambdas(config) {
+		binaryPath, err := a.Builder.BuildForHost(config, lambda)
+		if err != nil {
+			return err
+		}
+
+		binaries[lambda.Path] = binaryPath
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	params := &devserver.ServeParams{
+		Addr:     cmd.String("addr"),
+		Routes:   config.Routes,
+		Binaries: binaries,
+		Timeout:  cmd.Duration("timeout"),
+	}
+
+	if !cmd.Bool("watch") {
+		return a.DevServer.Serve(ctx, params)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	restarts := make(chan []string)
+	params.Restarts = restarts
+
+	watchDone := make(chan struct{})
+	go func() {
+		defer close(watchDone)
+
+		if err := a.watchRoutedLambdas(ctx, config, restarts); err != nil {
+			a.Logger.Printf("ERROR: Stopped watching for changes: %v\n", err)
+		}
+	}()
+
+	err = a.DevServer.Serve(ctx, params)
+	cancel()
+	<-watchDone
+	return err
+}
+
+// watchRoutedLambdas for changes, rebuilding the affected Lambdas and sending their paths to restarts.
+func (a *App) watchRoutedLambdas(ctx context.Context, config *lambgofile.Config, restarts chan<- []string) error {
+	routed := routedLambdas(config)
+	lambdas := make(map[string]*lambgofile.Lambda, len(routed))
+	lambdaPaths := make([]string, 0, len(routed))
+	for _, lambda := range routed {
+		lambdas[lambda.Path] = lambda
+		lambdaPaths = append(lambdaPaths, lambda.Path)
+	}
+
+	return a.watchTargets(ctx, config, lambdaPaths, func(affected []string) error {
+		// Lambdas that fail to build keep running their previous binary
+		var rebuilt []string
+		for _, lambdaPath := range affected {
+			if _, err := a.Builder.BuildForHost(config, lambdas[lambdaPath]); err != nil {
+				a.Logger.Printf("ERROR: %v\n", err)
+				continue
+			}
+
+			rebuilt = append(rebuilt, lambdaPath)
+		}
+
+		if len(rebuilt) == 0 {
+			return nil
+		}
+
+		select {
+		case restarts <- rebuilt:
+		case <-ctx.Done():
+		}
+
+		return nil
+	})
+}
+
+// routedLambdas in the order they are first used by a route.
+func routedLambdas(config *lambgofile.Config) []*lambgofile.Lambda {
+	var lambdas []*lambgofile.Lambda
+	seen := make(map[string]struct{})
+
+	for _, route := range config.Routes {
+		if _, ok := seen[route.Lambda.Path]; ok {
+			continue
+		}
+
+		seen[route.Lambda.Path] = struct{}{}
+		lambdas = append(lambdas, route.Lambda)
+	}
+
+	return lambdas
+}
@@ -0,0 +1,165 @@
+package cmd_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/devserver"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_builder"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_devserver"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+	"github.com/golang/mock/gomock"
+)
+
+func TestServe(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		Builder          *mock_builder.MockLambdaBuilderAPI
+		DevServer        *mock_devserver.MockServerAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+	defaultWd := func() (string, error) {
+		return "/test", nil
+	}
+
+	api := makeLambda("lambdas/api", nil)
+	files := makeLambda("lambdas/files", nil)
+	config := &lambgofile.Config{
+		RootPath: "/test",
+		Lambdas:  []*lambgofile.Lambda{api, files, makeLambda("lambdas/worker", nil)},
+		Routes: []*lambgofile.Route{
+			{Path: "/orders/{id}", Method: "GET", Lambda: api, Event: lambgofile.EventFormatAPIGatewayV2},
+			{Path: "/files/{proxy+}", Lambda: files, Event: lambgofile.EventFormatFunctionURL},
+			{Path: "/orders", Method: "POST", Lambda: api, Event: lambgofile.EventFormatAPIGatewayV2},
+		},
+	}
+
+	table := []struct {
+		Name          string
+		ExpectedError error
+		Args          []string
+
+		Getwd      func() (string, error)
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:  "with valid execution",
+			Args:  []string{},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+
+				gomock.InOrder(
+					m.Builder.EXPECT().BuildForHost(config, api).Return("/test/tmp/host/lambdas/api", nil),
+					m.Builder.EXPECT().BuildForHost(config, files).Return("/test/tmp/host/lambdas/files", nil),
+				)
+
+				m.DevServer.EXPECT().
+					Serve(gomock.Any(), &devserver.ServeParams{
+						Addr:   "localhost:3000",
+						Routes: config.Routes,
+						Binaries: map[string]string{
+							"lambdas/api":   "/test/tmp/host/lambdas/api",
+							"lambdas/files": "/test/tmp/host/lambdas/files",
+						},
+						Timeout: 30 * time.Second,
+					}).
+					Return(nil)
+			},
+		},
+
+		{
+			Name:  "with addr and timeout",
+			Args:  []string{"--addr", ":8080", "--timeout", "5s"},
+			Getwd: defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+				m.Builder.EXPECT().BuildForHost(config, api).Return("/test/tmp/host/lambdas/api", nil)
+				m.Builder.EXPECT().BuildForHost(config, files).Return("/test/tmp/host/lambdas/files", nil)
+
+				m.DevServer.EXPECT().
+					Serve(gomock.Any(), &devserver.ServeParams{
+						Addr:   ":8080",
+						Routes: config.Routes,
+						Binaries: map[string]string{
+							"lambdas/api":   "/test/tmp/host/lambdas/api",
+							"lambdas/files": "/test/tmp/host/lambdas/files",
+						},
+						Timeout: 5 * time.Second,
+					}).
+					Return(nil)
+			},
+		},
+
+		{
+			Name:          "with no routes",
+			Args:          []string{},
+			ExpectedError: cmd.ErrNoRoutes,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(&lambgofile.Config{RootPath: "/test"}, nil)
+			},
+		},
+
+		{
+			Name:          "with error from Getwd",
+			Args:          []string{},
+			ExpectedError: exampleError,
+			Getwd: func() (string, error) {
+				return "", exampleError
+			},
+		},
+
+		{
+			Name:          "with error from LoadConfig",
+			Args:          []string{},
+			ExpectedError: exampleError,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "with error from BuildForHost",
+			Args:          []string{},
+			ExpectedError: exampleError,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+				m.Builder.EXPECT().BuildForHost(config, api).Return("", exampleError)
+			},
+		},
+
+		{
+			Name:          "with error from Serve",
+			Args:          []string{},
+			ExpectedError: exampleError,
+			Getwd:         defaultWd,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+				m.Builder.EXPECT().BuildForHost(config, api).Return("/test/tmp/host/lambdas/api", nil)
+				m.Builder.EXPECT().BuildForHost(config, files).Return("/test/tmp/host/lambdas/files", nil)
+				m.DevServer.EXPECT().Serve(gomock.Any(), gomock.Any()).Return(exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = entry.Getwd
+
+		err := entry.Subject.Run(append([]string{"lambgo", "serve"}, entry.Args...))
+		ensure(err).IsError(entry.ExpectedError)
+	})
+}
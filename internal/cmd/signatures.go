@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/urfave/cli/v3"
+)
+
+type ErkInvalidSignatures struct{ erk.DefaultKind }
+
+var ErrInvalidSignatures = erk.New(ErkInvalidSignatures{},
+	"{{.numInvalid}} of the {{.numArtifacts}} artifacts do not have a valid signature",
+)
+
+func (a *App) verifySignaturesCmd() *cli.Command {
+	return &cli.Command{
+		Name: "verify",
+		Usage: "check the signature of each built zip against the public key, and the digest recorded in the manifest, " +
+			"without accessing the network",
+
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name: "public-key",
+				Usage: "PEM `file` containing the Ed25519 or ECDSA public key to verify with. " +
+					"Defaults to signing.publicKeyFile in .lambgo.yml, or the public key of signing.keyFile.",
+			},
+			onlyFlag("verify"),
+		},
+
+		Action: a.runVerifySignatures,
+	}
+}
+
+func (a *App) runVerifySignatures(_ context.Context, cmd *cli.Command) error {
+	pwd, err := a.Getwd()
+	if err != nil {
+		return err
+	}
+
+	config, err := a.LambgoFileLoader.LoadConfig(pwd, cmd.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if rawOnlyFlags := cmd.StringSlice("only"); len(rawOnlyFlags) > 0 {
+		if err := filterBuildTargets(config, rawOnlyFlags); err != nil {
+			return err
+		}
+	}
+
+	results, err := a.SignatureVerifier.Verify(config, cmd.String("public-key"))
+	if err != nil {
+		return err
+	}
+
+	numInvalid := 0
+	for _, result := range results {
+		name := artifactName(result.Artifact)
+		if result.Err != nil {
+			numInvalid++
+			a.Logger.Printf(" - Invalid: '%s': %v\n", name, result.Err)
+			continue
+		}
+
+		signature := result.Artifact.Signature
+		a.Logger.Printf(" - Verified: '%s' -> '%s' (%s, key %s)\n", name, signature.Path, signature.Algorithm, signature.KeyID)
+	}
+	a.Logger.Println()
+
+	if numInvalid > 0 {
+		return erk.WithParams(ErrInvalidSignatures, erk.Params{"numInvalid": numInvalid, "numArtifacts": len(results)})
+	}
+
+	return nil
+}
+
+// artifactName is the Lambda or extension's path, or the layer's name.
+func artifactName(artifact *manifest.Artifact) string {
+	if artifact.Kind == manifest.KindLayer {
+		return "layer " + artifact.Name
+	}
+
+	return artifact.Name
+}
@@ -0,0 +1,170 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_signing"
+	"github.com/JosiahWitt/lambgo/internal/signing"
+	"github.com/golang/mock/gomock"
+)
+
+func TestVerifySignatures(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader  *mock_lambgofile.MockLoaderAPI
+		SignatureVerifier *mock_signing.MockVerifierAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	newConfig := func() *lambgofile.Config {
+		return &lambgofile.Config{
+			RootPath: "/some/root/path",
+			Signing:  &lambgofile.Signing{KeyFile: "keys/signing.pem"},
+			Lambdas: []*lambgofile.Lambda{
+				makeLambda("lambdas/api", nil),
+				makeLambda("lambdas/worker", nil),
+			},
+		}
+	}
+
+	signedArtifact := func(kind manifest.ArtifactKind, name, zipPath string) *manifest.Artifact {
+		return &manifest.Artifact{
+			Kind:    kind,
+			Name:    name,
+			ZipPath: zipPath,
+			Signature: &manifest.Signature{
+				Path:      zipPath + signing.Extension,
+				Algorithm: "ed25519",
+				KeyID:     "sha256:abc",
+				ZipDigest: "sha256:def",
+			},
+		}
+	}
+
+	validResults := []*signing.Result{
+		{Artifact: signedArtifact(manifest.KindLambda, "lambdas/api", "tmp/lambdas/api.zip")},
+		{Artifact: signedArtifact(manifest.KindLambda, "lambdas/worker", "tmp/lambdas/worker.zip")},
+		{Artifact: signedArtifact(manifest.KindLayer, "tools", "tmp/layers/tools.zip")},
+	}
+
+	validOutput := " - Verified: 'lambdas/api' -> 'tmp/lambdas/api.zip.sig' (ed25519, key sha256:abc)\n" +
+		" - Verified: 'lambdas/worker' -> 'tmp/lambdas/worker.zip.sig' (ed25519, key sha256:abc)\n" +
+		" - Verified: 'layer tools' -> 'tmp/layers/tools.zip.sig' (ed25519, key sha256:abc)\n" +
+		"\n"
+
+	table := []struct {
+		Name           string
+		Flags          []string
+		ExpectedError  error
+		ExpectedOutput string
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name: "with valid signatures",
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SignatureVerifier.EXPECT().Verify(newConfig(), "").Return(validResults, nil)
+			},
+			ExpectedOutput: validOutput,
+		},
+
+		{
+			Name:  "with --public-key",
+			Flags: []string{"--public-key", "/keys/signing.pub.pem"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SignatureVerifier.EXPECT().Verify(newConfig(), "/keys/signing.pub.pem").Return(validResults, nil)
+			},
+			ExpectedOutput: validOutput,
+		},
+
+		{
+			Name:          "with invalid signatures",
+			ExpectedError: cmd.ErrInvalidSignatures,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SignatureVerifier.EXPECT().Verify(newConfig(), "").Return([]*signing.Result{
+					{Artifact: validResults[0].Artifact},
+					{
+						Artifact: &manifest.Artifact{Kind: manifest.KindLambda, Name: "lambdas/worker"},
+						Err:      signing.ErrNotSigned,
+					},
+					{
+						Artifact: validResults[2].Artifact,
+						Err:      exampleError,
+					},
+				}, nil)
+			},
+			ExpectedOutput: " - Verified: 'lambdas/api' -> 'tmp/lambdas/api.zip.sig' (ed25519, key sha256:abc)\n" +
+				" - Invalid: 'lambdas/worker': The zip is not signed. Set signing in .lambgo.yml, and run `lambgo build`.\n" +
+				" - Invalid: 'layer tools': something went wrong\n" +
+				"\n",
+		},
+
+		{
+			Name:  "with --only",
+			Flags: []string{"--only", "lambdas/worker"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+
+				expected := newConfig()
+				expected.Lambdas = expected.Lambdas[1:]
+				m.SignatureVerifier.EXPECT().Verify(expected, "").Return(validResults[1:2], nil)
+			},
+			ExpectedOutput: " - Verified: 'lambdas/worker' -> 'tmp/lambdas/worker.zip.sig' (ed25519, key sha256:abc)\n" +
+				"\n",
+		},
+
+		{
+			Name:          "with invalid --only",
+			Flags:         []string{"--only", "lambdas/missing"},
+			ExpectedError: cmd.ErrCannotFilterBuildPaths,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+			},
+		},
+
+		{
+			Name:          "when loading the config fails",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when verifying fails",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SignatureVerifier.EXPECT().Verify(gomock.Any(), "").Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run(append([]string{"lambgo", "verify"}, entry.Flags...))
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
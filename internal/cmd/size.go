@@ -0,0 +1,289 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/binsize"
+	"github.com/JosiahWitt/lambgo/internal/builder"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/urfave/cli/v3"
+)
+
+const defaultSizeTop = 10
+
+type ErkCannotCompareSizes struct{ erk.DefaultKind }
+
+var (
+	ErrInvalidTop = erk.New(ErkCannotCompareSizes{}, "Invalid value ({{.top}}) provided for --top. It must be at least 1.")
+
+	ErrDiffBinaryAmbiguous = erk.New(ErkCannotCompareSizes{},
+		"Cannot compare {{.numBinaries}} binaries against the single binary '{{.path}}'. "+
+			"Provide the outDirectory of the previous build (or its "+manifest.FileName+") instead, or select a single Lambda.",
+	)
+
+	ErrCannotAnalyzePreviousBuild = erk.New(ErkCannotCompareSizes{}, "Cannot analyze the previous build of '{{.buildPath}}': {{.err}}")
+	ErrCannotReadPreviousManifest = erk.New(ErkCannotCompareSizes{}, "Cannot read the manifest of the previous build '{{.path}}': {{.err}}")
+	ErrSizeNotInManifest          = erk.New(ErkCannotCompareSizes{},
+		"The size of '{{.buildPath}}' is not recorded in the manifest '{{.path}}'. "+
+			"Sizes are recorded by `lambgo build`, so provide the outDirectory of the previous build instead, or build it again.",
+	)
+)
+
+func (a *App) sizeCmd() *cli.Command {
+	return &cli.Command{
+		Name: "size",
+		Usage: "break down the size of the built binaries by section, Go package, and symbol, " +
+			"to find what contributes to the size of each Lambda",
+		ArgsUsage: "[path...]",
+
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:  "top",
+				Usage: "Show the `n` largest sections, packages, and symbols (or the largest changes, with --diff).",
+				Value: defaultSizeTop,
+			},
+			&cli.StringFlag{
+				Name: "diff",
+				Usage: "Compare against a previous build, using the `path` of a copy of its outDirectory or its " + manifest.FileName + ". " +
+					"When a single Lambda is selected, the path can also be the previous binary.",
+			},
+		},
+
+		Action: a.runSize,
+	}
+}
+
+func (a *App) runSize(_ context.Context, cmd *cli.Command) error {
+	top := cmd.Int("top")
+	if top < 1 {
+		return erk.WithParams(ErrInvalidTop, erk.Params{"top": top})
+	}
+
+	pwd, err := a.Getwd()
+	if err != nil {
+		return err
+	}
+
+	config, err := a.LambgoFileLoader.LoadConfig(pwd, cmd.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if cmd.Args().Present() {
+		if err := filterBuildTargets(config, cmd.Args().Slice()); err != nil {
+			return err
+		}
+	}
+
+	binaries := builder.Binaries(config)
+	diffPath := cmd.String("diff")
+
+	// The manifest only records the sizes of the sections and packages, so the symbols are not compared
+	var previousManifest *manifest.Manifest
+	if diffPath != "" && filepath.Base(diffPath) == manifest.FileName {
+		previousManifest, err = readPreviousManifest(diffPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, binary := range binaries {
+		analysis, err := a.SizeAnalyzer.Analyze(binary.OutPath)
+		if err != nil {
+			return err
+		}
+
+		if diffPath == "" {
+			a.logAnalysis(binary, analysis, top)
+			continue
+		}
+
+		if previousManifest != nil {
+			previous, err := manifestAnalysis(previousManifest, binary, diffPath)
+			if err != nil {
+				return err
+			}
+
+			a.logComparison(binary, binsize.Compare(previous, analysis), top, false)
+			continue
+		}
+
+		previousPath, err := previousBinaryPath(config, binaries, binary, diffPath)
+		if err != nil {
+			return err
+		}
+
+		previous, err := a.SizeAnalyzer.Analyze(previousPath)
+		if err != nil {
+			return erk.WrapWith(ErrCannotAnalyzePreviousBuild, err, erk.Params{"buildPath": binary.BuildPath})
+		}
+
+		a.logComparison(binary, binsize.Compare(previous, analysis), top, true)
+	}
+
+	return nil
+}
+
+func (a *App) logAnalysis(binary *builder.Binary, analysis *binsize.Analysis, top int) {
+	stripped := ""
+	if analysis.Stripped {
+		stripped = " (stripped, so only functions are listed)"
+	}
+
+	a.Logger.Printf("%s: %s%s\n", binaryName(binary), formatSize(analysis.FileSize), stripped)
+
+	a.Logger.Println("  Sections:")
+	for _, section := range analysis.Sections[:min(top, len(analysis.Sections))] {
+		a.Logger.Printf("    %-40s %10s\n", section.Name, formatSize(section.Size))
+	}
+
+	a.Logger.Println("  Packages:")
+	for _, pkg := range analysis.Packages[:min(top, len(analysis.Packages))] {
+		a.Logger.Printf("    %-60s %10s\n", pkg.Name, formatSize(pkg.Size))
+	}
+
+	a.Logger.Println("  Symbols:")
+	for _, symbol := range analysis.Symbols[:min(top, len(analysis.Symbols))] {
+		a.Logger.Printf("    %-60s %10s\n", symbol.Name, formatSize(symbol.Size))
+	}
+
+	a.Logger.Println()
+}
+
+func (a *App) logComparison(binary *builder.Binary, comparison *binsize.Comparison, top int, hasSymbols bool) {
+	total := &binsize.Change{Before: comparison.Before.FileSize, After: comparison.After.FileSize}
+	a.Logger.Printf("%s: %s\n", binaryName(binary), formatChange(total))
+
+	a.Logger.Println("  Packages:")
+	a.logChanges(comparison.Packages, top)
+
+	a.Logger.Println("  Symbols:")
+	if !hasSymbols {
+		a.Logger.Println("    Not recorded in the manifest")
+	} else {
+		a.logChanges(comparison.Symbols, top)
+	}
+
+	a.Logger.Println()
+}
+
+func (a *App) logChanges(changes []*binsize.Change, top int) {
+	if len(changes) == 0 {
+		a.Logger.Println("    No changes")
+		return
+	}
+
+	for _, change := range changes[:min(top, len(changes))] {
+		a.Logger.Printf("    %-60s %s\n", change.Name, formatChange(change))
+	}
+}
+
+// previousBinaryPath is the path of the binary in the previous build, which is at the same path relative
+// to the previous outDirectory as the binary is to the current outDirectory.
+func previousBinaryPath(config *lambgofile.Config, binaries []*builder.Binary, binary *builder.Binary, diffPath string) (string, error) {
+	info, err := os.Stat(diffPath)
+	if err != nil {
+		return "", erk.WrapWith(ErrCannotAnalyzePreviousBuild, err, erk.Params{"buildPath": binary.BuildPath})
+	}
+
+	if !info.IsDir() {
+		if len(binaries) != 1 {
+			return "", erk.WithParams(ErrDiffBinaryAmbiguous, erk.Params{"numBinaries": len(binaries), "path": diffPath})
+		}
+
+		return diffPath, nil
+	}
+
+	outDirectory := config.OutDirectory
+	if !filepath.IsAbs(outDirectory) {
+		outDirectory = filepath.Join(config.RootPath, outDirectory)
+	}
+
+	relPath, err := filepath.Rel(outDirectory, binary.OutPath)
+	if err != nil {
+		return "", erk.WrapWith(ErrCannotAnalyzePreviousBuild, err, erk.Params{"buildPath": binary.BuildPath})
+	}
+
+	return filepath.Join(diffPath, relPath), nil
+}
+
+// readPreviousManifest at path, which must exist, since a missing manifest would be read as empty.
+func readPreviousManifest(path string) (*manifest.Manifest, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, erk.WrapWith(ErrCannotReadPreviousManifest, err, erk.Params{"path": path})
+	}
+
+	m, err := manifest.Read(path)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadPreviousManifest, err, erk.Params{"path": path})
+	}
+
+	return m, nil
+}
+
+// manifestAnalysis is the size of the binary recorded in the manifest of the previous build, without its symbols.
+// Layer packages are recorded in the artifact of their layer.
+func manifestAnalysis(m *manifest.Manifest, binary *builder.Binary, path string) (*binsize.Analysis, error) {
+	artifactName := binary.BuildPath
+	if binary.Layer != "" {
+		artifactName = binary.Layer
+	}
+
+	size := m.BinarySize(binary.Kind, artifactName, binary.BuildPath)
+	if size == nil {
+		return nil, erk.WithParams(ErrSizeNotInManifest, erk.Params{"buildPath": binary.BuildPath, "path": path})
+	}
+
+	return &binsize.Analysis{
+		Path:     path,
+		FileSize: size.FileSize,
+		Stripped: size.Stripped,
+		Sections: analysisSizes(size.Sections),
+		Packages: analysisSizes(size.Packages),
+	}, nil
+}
+
+func analysisSizes(sizes []*manifest.Size) []*binsize.Size {
+	converted := make([]*binsize.Size, 0, len(sizes))
+	for _, size := range sizes {
+		converted = append(converted, &binsize.Size{Name: size.Name, Size: size.Size})
+	}
+
+	return converted
+}
+
+// formatChange as the size before and after, and the difference.
+func formatChange(change *binsize.Change) string {
+	delta := change.Delta()
+	sign := "+"
+	if delta < 0 {
+		sign = "-"
+		delta = -delta
+	}
+
+	return fmt.Sprintf("%s -> %s (%s%s)", formatSize(change.Before), formatSize(change.After), sign, formatSize(delta))
+}
+
+// formatSize in bytes, using binary units (eg. KiB) for larger sizes.
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	value := float64(size) / unit
+	for _, suffix := range []string{"KiB", "MiB"} {
+		if value < unit {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+
+		value /= unit
+	}
+
+	return fmt.Sprintf("%.1f GiB", value)
+}
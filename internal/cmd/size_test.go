@@ -0,0 +1,328 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/binsize"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_binsize"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+	"github.com/golang/mock/gomock"
+)
+
+func TestSize(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		SizeAnalyzer     *mock_binsize.MockAnalyzerAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	newConfig := func() *lambgofile.Config {
+		return &lambgofile.Config{
+			RootPath:     "/some/root/path",
+			OutDirectory: "out",
+			Lambdas: []*lambgofile.Lambda{
+				makeLambda("lambdas/api", nil),
+				makeLambda("lambdas/worker", nil),
+			},
+		}
+	}
+
+	previousBuild := t.TempDir()
+	previousBinary := filepath.Join(previousBuild, "api")
+	ensure(os.WriteFile(previousBinary, nil, 0o600)).IsNotError()
+
+	previousManifest := manifest.PathFor(previousBuild)
+	ensure(os.WriteFile(previousManifest, []byte(`{
+		"artifacts": [
+			{
+				"kind": "lambda",
+				"name": "lambdas/api",
+				"entries": [
+					{
+						"name": "api",
+						"source": "out/lambdas/api",
+						"size": {
+							"buildPath": "lambdas/api",
+							"fileSize": 2097152,
+							"sections": [],
+							"packages": [
+								{"name": "runtime", "size": 1048576},
+								{"name": "github.com/my/app", "size": 1024},
+								{"name": "encoding/json", "size": 4096}
+							]
+						}
+					}
+				]
+			},
+			{
+				"kind": "lambda",
+				"name": "lambdas/worker",
+				"entries": [{"name": "worker", "source": "out/lambdas/worker"}]
+			}
+		]
+	}`), 0o600)).IsNotError()
+
+	apiAnalysis := &binsize.Analysis{
+		FileSize: 3 * 1024 * 1024,
+		Sections: []*binsize.Size{{Name: ".text", Size: 1536 * 1024}, {Name: ".rodata", Size: 512}},
+		Packages: []*binsize.Size{
+			{Name: "runtime", Size: 1024 * 1024},
+			{Name: "github.com/my/app", Size: 2048},
+			{Name: "fmt", Size: 100},
+		},
+		Symbols: []*binsize.Symbol{
+			{Name: "runtime.mallocgc", Package: "runtime", Size: 4096},
+			{Name: "github.com/my/app.main", Package: "github.com/my/app", Size: 2048},
+			{Name: "fmt.Println", Package: "fmt", Size: 100},
+		},
+	}
+
+	previousAPIAnalysis := &binsize.Analysis{
+		FileSize: 2 * 1024 * 1024,
+		Packages: []*binsize.Size{
+			{Name: "runtime", Size: 1024 * 1024},
+			{Name: "github.com/my/app", Size: 1024},
+			{Name: "encoding/json", Size: 4096},
+		},
+		Symbols: []*binsize.Symbol{
+			{Name: "runtime.mallocgc", Package: "runtime", Size: 4096},
+			{Name: "github.com/my/app.main", Package: "github.com/my/app", Size: 1024},
+		},
+	}
+
+	workerAnalysis := &binsize.Analysis{
+		FileSize: 1000,
+		Stripped: true,
+		Packages: []*binsize.Size{{Name: "main", Size: 10}},
+		Symbols:  []*binsize.Symbol{{Name: "main.main", Package: "main", Size: 10}},
+	}
+
+	apiOutput := "lambdas/api: 3.0 MiB\n" +
+		"  Sections:\n" +
+		"    .text                                       1.5 MiB\n" +
+		"    .rodata                                       512 B\n" +
+		"  Packages:\n" +
+		"    runtime                                                         1.0 MiB\n" +
+		"    github.com/my/app                                               2.0 KiB\n" +
+		"  Symbols:\n" +
+		"    runtime.mallocgc                                                4.0 KiB\n" +
+		"    github.com/my/app.main                                          2.0 KiB\n" +
+		"\n"
+
+	workerOutput := "lambdas/worker: 1000 B (stripped, so only functions are listed)\n" +
+		"  Sections:\n" +
+		"  Packages:\n" +
+		"    main                                                               10 B\n" +
+		"  Symbols:\n" +
+		"    main.main                                                          10 B\n" +
+		"\n"
+
+	apiDiffOutput := "lambdas/api: 2.0 MiB -> 3.0 MiB (+1.0 MiB)\n" +
+		"  Packages:\n" +
+		"    encoding/json                                                4.0 KiB -> 0 B (-4.0 KiB)\n" +
+		"    github.com/my/app                                            1.0 KiB -> 2.0 KiB (+1.0 KiB)\n" +
+		"  Symbols:\n" +
+		"    github.com/my/app.main                                       1.0 KiB -> 2.0 KiB (+1.0 KiB)\n" +
+		"    fmt.Println                                                  0 B -> 100 B (+100 B)\n" +
+		"\n"
+
+	table := []struct {
+		Name           string
+		Args           []string
+		ExpectedError  error
+		ExpectedOutput string
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name: "with all Lambdas",
+			Args: []string{"--top", "2"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				gomock.InOrder(
+					m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/api").Return(apiAnalysis, nil),
+					m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/worker").Return(workerAnalysis, nil),
+				)
+			},
+			ExpectedOutput: apiOutput + workerOutput,
+		},
+
+		{
+			Name: "with a path",
+			Args: []string{"lambdas/worker"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/worker").Return(workerAnalysis, nil)
+			},
+			ExpectedOutput: workerOutput,
+		},
+
+		{
+			Name: "with --diff of a previous outDirectory",
+			Args: []string{"--top", "2", "--diff", previousBuild, "lambdas/api"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/api").Return(apiAnalysis, nil)
+				m.SizeAnalyzer.EXPECT().Analyze(filepath.Join(previousBuild, "lambdas/api")).Return(previousAPIAnalysis, nil)
+			},
+			ExpectedOutput: apiDiffOutput,
+		},
+
+		{
+			Name: "with --diff of a previous outDirectory for multiple Lambdas",
+			Args: []string{"--top", "2", "--diff", previousBuild},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				gomock.InOrder(
+					m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/api").Return(apiAnalysis, nil),
+					m.SizeAnalyzer.EXPECT().Analyze(filepath.Join(previousBuild, "lambdas/api")).Return(previousAPIAnalysis, nil),
+					m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/worker").Return(workerAnalysis, nil),
+					m.SizeAnalyzer.EXPECT().Analyze(filepath.Join(previousBuild, "lambdas/worker")).Return(workerAnalysis, nil),
+				)
+			},
+			ExpectedOutput: apiDiffOutput +
+				"lambdas/worker: 1000 B -> 1000 B (+0 B)\n" +
+				"  Packages:\n" +
+				"    No changes\n" +
+				"  Symbols:\n" +
+				"    No changes\n" +
+				"\n",
+		},
+
+		{
+			Name: "with --diff of a previous manifest",
+			Args: []string{"--top", "2", "--diff", previousManifest, "lambdas/api"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/api").Return(apiAnalysis, nil)
+			},
+			ExpectedOutput: "lambdas/api: 2.0 MiB -> 3.0 MiB (+1.0 MiB)\n" +
+				"  Packages:\n" +
+				"    encoding/json                                                4.0 KiB -> 0 B (-4.0 KiB)\n" +
+				"    github.com/my/app                                            1.0 KiB -> 2.0 KiB (+1.0 KiB)\n" +
+				"  Symbols:\n" +
+				"    Not recorded in the manifest\n" +
+				"\n",
+		},
+
+		{
+			Name:          "with --diff of a previous manifest without the size of the Lambda",
+			Args:          []string{"--diff", previousManifest, "lambdas/worker"},
+			ExpectedError: cmd.ErrSizeNotInManifest,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/worker").Return(workerAnalysis, nil)
+			},
+		},
+
+		{
+			Name:          "with --diff of a missing manifest",
+			Args:          []string{"--diff", manifest.PathFor(filepath.Join(previousBuild, "missing"))},
+			ExpectedError: cmd.ErrCannotReadPreviousManifest,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+			},
+		},
+
+		{
+			Name: "with --diff of a previous binary",
+			Args: []string{"--top", "2", "--diff", previousBinary, "lambdas/api"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/api").Return(apiAnalysis, nil)
+				m.SizeAnalyzer.EXPECT().Analyze(previousBinary).Return(previousAPIAnalysis, nil)
+			},
+			ExpectedOutput: apiDiffOutput,
+		},
+
+		{
+			Name:          "with --diff of a previous binary for multiple Lambdas",
+			Args:          []string{"--diff", previousBinary},
+			ExpectedError: cmd.ErrDiffBinaryAmbiguous,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/api").Return(apiAnalysis, nil)
+			},
+		},
+
+		{
+			Name:          "with --diff of a missing path",
+			Args:          []string{"--diff", filepath.Join(previousBuild, "missing"), "lambdas/api"},
+			ExpectedError: cmd.ErrCannotAnalyzePreviousBuild,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/api").Return(apiAnalysis, nil)
+			},
+		},
+
+		{
+			Name:          "when analyzing the previous build fails",
+			Args:          []string{"--diff", previousBuild, "lambdas/api"},
+			ExpectedError: cmd.ErrCannotAnalyzePreviousBuild,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/api").Return(apiAnalysis, nil)
+				m.SizeAnalyzer.EXPECT().Analyze(filepath.Join(previousBuild, "lambdas/api")).Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "with an unknown path",
+			Args:          []string{"lambdas/missing"},
+			ExpectedError: cmd.ErrCannotFilterBuildPaths,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+			},
+		},
+
+		{
+			Name:          "with invalid --top",
+			Args:          []string{"--top", "0"},
+			ExpectedError: cmd.ErrInvalidTop,
+		},
+
+		{
+			Name:          "when loading the config fails",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when analyzing fails",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.SizeAnalyzer.EXPECT().Analyze("/some/root/path/out/lambdas/api").Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run(append([]string{"lambgo", "size"}, entry.Args...))
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
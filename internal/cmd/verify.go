@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/urfave/cli/v3"
+)
+
+type ErkNotReproducible struct{ erk.DefaultKind }
+
+var ErrNotReproducible = erk.New(ErkNotReproducible{},
+	"The builds are not reproducible, since {{.numFiles}} of their files differ. The builds are kept in: {{.dir}}",
+)
+
+func (a *App) verifyReproducibleCmd() *cli.Command {
+	return &cli.Command{
+		Name: "verify-reproducible",
+		Usage: "build Lambdas twice, using separate output directories and empty build caches, " +
+			"and check that the binaries and zips are identical byte for byte",
+
+		Flags: []cli.Flag{
+			disableParallelFlag(),
+			onlyFlag("verify"),
+			numParallelFlag(),
+		},
+
+		Action: a.runVerifyReproducible,
+	}
+}
+
+func (a *App) runVerifyReproducible(_ context.Context, cmd *cli.Command) error {
+	pwd, err := a.Getwd()
+	if err != nil {
+		return err
+	}
+
+	config, err := a.LambgoFileLoader.LoadConfig(pwd, cmd.String("profile"))
+	if err != nil {
+		return err
+	}
+
+	if rawOnlyFlags := cmd.StringSlice("only"); len(rawOnlyFlags) > 0 {
+		if err := filterBuildTargets(config, rawOnlyFlags); err != nil {
+			return err
+		}
+	}
+
+	if _, err := setNumParallel(config, cmd); err != nil {
+		return err
+	}
+
+	result, err := a.Verifier.Verify(config)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Differences) == 0 {
+		a.Logger.Printf("Reproducible: the %d files of both builds are identical\n", result.NumFiles)
+		return nil
+	}
+
+	a.Logger.Println("Differences between the builds:")
+	for _, difference := range result.Differences {
+		a.Logger.Printf("  %s\n", difference.Path)
+		a.Logger.Printf("    %s\n", strings.Join(difference.Details, "\n    "))
+	}
+	a.Logger.Println()
+
+	return erk.WithParams(ErrNotReproducible, erk.Params{"numFiles": len(result.Differences), "dir": result.Dir})
+}
@@ -0,0 +1,138 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_reproducible"
+	"github.com/JosiahWitt/lambgo/internal/reproducible"
+	"github.com/golang/mock/gomock"
+)
+
+func TestVerifyReproducible(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		Verifier         *mock_reproducible.MockVerifierAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	newConfig := func() *lambgofile.Config {
+		return &lambgofile.Config{
+			RootPath: "/some/root/path",
+			Lambdas: []*lambgofile.Lambda{
+				makeLambda("lambdas/api", nil),
+				makeLambda("lambdas/worker", nil),
+			},
+			Extensions: []*lambgofile.Extension{makeExtension("extensions/trace", "trace")},
+		}
+	}
+
+	table := []struct {
+		Name           string
+		Flags          []string
+		ExpectedError  error
+		ExpectedOutput string
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name: "with reproducible builds",
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+
+				expected := newConfig()
+				expected.NumParallel = 3
+				m.Verifier.EXPECT().Verify(expected).Return(&reproducible.Result{NumFiles: 3}, nil)
+			},
+			ExpectedOutput: "Reproducible: the 3 files of both builds are identical\n",
+		},
+
+		{
+			Name:  "with --only and --disable-parallel",
+			Flags: []string{"--only", "lambdas/worker", "--disable-parallel"},
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+
+				expected := newConfig()
+				expected.NumParallel = 1
+				expected.Lambdas = expected.Lambdas[1:]
+				expected.Extensions = nil
+				m.Verifier.EXPECT().Verify(expected).Return(&reproducible.Result{NumFiles: 1}, nil)
+			},
+			ExpectedOutput: "Reproducible: the 1 files of both builds are identical\n",
+		},
+
+		{
+			Name:          "with differences",
+			ExpectedError: cmd.ErrNotReproducible,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.Verifier.EXPECT().Verify(gomock.Any()).Return(&reproducible.Result{
+					NumFiles: 3,
+					Differences: []*reproducible.Difference{
+						{Path: "lambdas/api/bootstrap", Details: []string{"ELF section .go.buildinfo differs", "ELF section .rodata differs"}},
+						{Path: "lambdas/api/bootstrap.zip", Details: []string{"zip entry bootstrap has different contents"}},
+					},
+					Dir: "/tmp/lambgo-reproducible-123",
+				}, nil)
+			},
+			ExpectedOutput: "Differences between the builds:\n" +
+				"  lambdas/api/bootstrap\n" +
+				"    ELF section .go.buildinfo differs\n" +
+				"    ELF section .rodata differs\n" +
+				"  lambdas/api/bootstrap.zip\n" +
+				"    zip entry bootstrap has different contents\n" +
+				"\n",
+		},
+
+		{
+			Name:          "with invalid --only",
+			Flags:         []string{"--only", "lambdas/missing"},
+			ExpectedError: cmd.ErrCannotFilterBuildPaths,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+			},
+		},
+
+		{
+			Name:          "when loading the config fails",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(nil, exampleError)
+			},
+		},
+
+		{
+			Name:          "when verifying fails",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.Verifier.EXPECT().Verify(gomock.Any()).Return(nil, exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run(append([]string{"lambgo", "verify-reproducible"}, entry.Flags...))
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/lambgo/internal/filewatch"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+)
+
+// watchTargets until the context is done, calling onChange with the sorted paths of the targets affected by each change.
+// Errors from onChange are logged, so a broken build does not stop watching.
+func (a *App) watchTargets(ctx context.Context, config *lambgofile.Config, targetPaths []string, onChange func(affected []string) error) error {
+	var excludeDirs []string
+	if config.OutDirectory != "" {
+		excludeDirs = append(excludeDirs, config.OutDirectory)
+	}
+
+	changes, err := a.Watcher.Watch(&filewatch.WatchParams{RootPath: config.RootPath, ExcludeDirs: excludeDirs})
+	if err != nil {
+		return err
+	}
+
+	for {
+		a.Logger.Println()
+		a.Logger.Println("Watching for changes...")
+
+		changedFiles, err := changes.Next(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			return err
+		}
+
+		configFileNames := append([]string{lambgofile.ConfigFileName, lambgofile.LocalConfigFileName}, config.IncludedFiles...)
+		for _, configFileName := range configFileNames {
+			if slices.Contains(changedFiles, configFileName) {
+				a.Logger.Printf("Changes to %s are applied when lambgo is restarted\n", configFileName)
+			}
+		}
+
+		// The graph is loaded for every change, since imports may have been added or removed
+		graph, err := a.Grapher.Load(config, targetPaths)
+		if err != nil {
+			a.Logger.Printf("ERROR: %v\n", err)
+			continue
+		}
+
+		affected := graph.Affected(changedFiles)
+		if len(affected) == 0 {
+			a.Logger.Println("No Lambdas are affected by the changes")
+			continue
+		}
+
+		a.Logger.Printf("Changes affect: %s\n", strings.Join(affected, ", "))
+		if err := onChange(affected); err != nil {
+			a.Logger.Printf("ERROR: %v\n", err)
+		}
+	}
+}
+
+// buildTargetPaths are the paths of the main packages that are built for the config.
+func buildTargetPaths(config *lambgofile.Config) []string {
+	paths := make([]string, 0, numBuildTargets(config))
+	for _, lambda := range config.Lambdas {
+		paths = append(paths, lambda.Path)
+	}
+
+	for _, extension := range config.Extensions {
+		paths = append(paths, extension.Path)
+	}
+
+	for _, layer := range config.Layers {
+		for _, pkg := range layer.Packages {
+			paths = append(paths, pkg.Path)
+		}
+	}
+
+	return paths
+}
+
+// affectedConfig is a copy of the config with only the affected Lambdas, extensions, and layers.
+func affectedConfig(config *lambgofile.Config, affected []string) *lambgofile.Config {
+	changes := &changeSet{targets: make(map[string]struct{}, len(affected))}
+	for _, targetPath := range affected {
+		changes.targets[targetPath] = struct{}{}
+	}
+
+	affectedConfig := *config
+	changes.filter(&affectedConfig)
+	return &affectedConfig
+}
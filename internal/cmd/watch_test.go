@@ -0,0 +1,408 @@
+package cmd_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/cmd"
+	"github.com/JosiahWitt/lambgo/internal/depgraph"
+	"github.com/JosiahWitt/lambgo/internal/devserver"
+	"github.com/JosiahWitt/lambgo/internal/filewatch"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_builder"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_depgraph"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_devserver"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_filewatch"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_runtimeapi"
+	"github.com/JosiahWitt/lambgo/internal/runtimeapi"
+	"github.com/golang/mock/gomock"
+)
+
+func TestBuildWatch(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		Builder          *mock_builder.MockLambdaBuilderAPI
+		Watcher          *mock_filewatch.MockWatcherAPI
+		Changes          *mock_filewatch.MockChangesAPI `ensure:"ignoreunused"`
+		Grapher          *mock_depgraph.MockGrapherAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	newConfig := func() *lambgofile.Config {
+		return &lambgofile.Config{
+			RootPath:     "/some/root/path",
+			OutDirectory: "out",
+			Lambdas:      []*lambgofile.Lambda{makeLambda("lambdas/api", nil), makeLambda("lambdas/worker", nil)},
+			Extensions:   []*lambgofile.Extension{makeExtension("extensions/trace", "trace")},
+			Layers: []*lambgofile.Layer{
+				{Name: "shared", Packages: []*lambgofile.LayerPackage{{Lambda: *makeLambda("tools/converter", nil)}}},
+				{Name: "assets"},
+			},
+			IncludedFiles: []string{"services/billing/lambgo.yml"},
+		}
+	}
+
+	targetPaths := []string{"lambdas/api", "lambdas/worker", "extensions/trace", "tools/converter"}
+	graph := depgraph.Graph{
+		"lambdas/api":      {{Dir: "lambdas/api"}, {Dir: "internal/store"}},
+		"lambdas/worker":   {{Dir: "lambdas/worker"}},
+		"extensions/trace": {{Dir: "extensions/trace"}, {Dir: "internal/store"}},
+		"tools/converter":  {{Dir: "tools/converter"}, {Dir: "internal/store"}},
+	}
+
+	// expectWatch expects watching to start after the initial build, and returns the configs that were built
+	expectWatch := func(m *Mocks, initialBuildErr error) *[]*lambgofile.Config {
+		var built []*lambgofile.Config
+
+		m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+		m.Builder.EXPECT().
+			BuildBinaries(gomock.Any()).
+			DoAndReturn(func(config *lambgofile.Config) error {
+				built = append(built, config)
+				return initialBuildErr
+			})
+
+		m.Watcher.EXPECT().
+			Watch(&filewatch.WatchParams{RootPath: "/some/root/path", ExcludeDirs: []string{"out"}}).
+			Return(m.Changes, nil)
+
+		return &built
+	}
+
+	table := []struct {
+		Name           string
+		ExpectedError  error
+		ExpectedOutput string
+		ExpectedBuilds func(config *lambgofile.Config) []*lambgofile.Config
+
+		Mocks      *Mocks
+		SetupWatch func(*Mocks) *[]*lambgofile.Config
+		Subject    *cmd.App
+	}{
+		{
+			Name:          "with changes, it rebuilds the affected targets",
+			ExpectedError: exampleError,
+			SetupWatch: func(m *Mocks) *[]*lambgofile.Config {
+				built := expectWatch(m, nil)
+
+				gomock.InOrder(
+					m.Changes.EXPECT().Next(gomock.Any()).Return([]string{"internal/store/store.go"}, nil),
+					m.Grapher.EXPECT().Load(gomock.Any(), targetPaths).Return(graph, nil),
+					m.Builder.EXPECT().
+						BuildBinaries(gomock.Any()).
+						DoAndReturn(func(config *lambgofile.Config) error {
+							*built = append(*built, config)
+							return exampleError
+						}),
+					m.Changes.EXPECT().Next(gomock.Any()).Return(nil, exampleError),
+				)
+
+				return built
+			},
+			ExpectedBuilds: func(config *lambgofile.Config) []*lambgofile.Config {
+				affected := *config
+				affected.Lambdas = config.Lambdas[:1]
+				affected.Layers = config.Layers[:1]
+
+				return []*lambgofile.Config{config, &affected}
+			},
+			ExpectedOutput: "\nWatching for changes...\n" +
+				"Changes affect: extensions/trace, lambdas/api, tools/converter\n" +
+				"ERROR: something went wrong\n" +
+				"\nWatching for changes...\n",
+		},
+
+		{
+			Name:          "with changes that do not affect any targets",
+			ExpectedError: exampleError,
+			SetupWatch: func(m *Mocks) *[]*lambgofile.Config {
+				built := expectWatch(m, exampleError)
+
+				gomock.InOrder(
+					m.Changes.EXPECT().Next(gomock.Any()).Return([]string{
+						".lambgo.local.yml", ".lambgo.yml", "internal/unused/unused.go", "services/billing/lambgo.yml",
+					}, nil),
+					m.Grapher.EXPECT().Load(gomock.Any(), targetPaths).Return(graph, nil),
+					m.Changes.EXPECT().Next(gomock.Any()).Return(nil, exampleError),
+				)
+
+				return built
+			},
+			ExpectedBuilds: func(config *lambgofile.Config) []*lambgofile.Config {
+				return []*lambgofile.Config{config}
+			},
+			ExpectedOutput: "ERROR: something went wrong\n" +
+				"\nWatching for changes...\n" +
+				"Changes to .lambgo.yml are applied when lambgo is restarted\n" +
+				"Changes to .lambgo.local.yml are applied when lambgo is restarted\n" +
+				"Changes to services/billing/lambgo.yml are applied when lambgo is restarted\n" +
+				"No Lambdas are affected by the changes\n" +
+				"\nWatching for changes...\n",
+		},
+
+		{
+			Name:          "when the dependencies cannot be loaded",
+			ExpectedError: exampleError,
+			SetupWatch: func(m *Mocks) *[]*lambgofile.Config {
+				built := expectWatch(m, nil)
+
+				gomock.InOrder(
+					m.Changes.EXPECT().Next(gomock.Any()).Return([]string{"lambdas/api/main.go"}, nil),
+					m.Grapher.EXPECT().Load(gomock.Any(), targetPaths).Return(nil, exampleError),
+					m.Changes.EXPECT().Next(gomock.Any()).Return(nil, exampleError),
+				)
+
+				return built
+			},
+			ExpectedBuilds: func(config *lambgofile.Config) []*lambgofile.Config {
+				return []*lambgofile.Config{config}
+			},
+			ExpectedOutput: "\nWatching for changes...\n" +
+				"ERROR: something went wrong\n" +
+				"\nWatching for changes...\n",
+		},
+
+		{
+			Name:          "when the module cannot be watched",
+			ExpectedError: exampleError,
+			SetupWatch: func(m *Mocks) *[]*lambgofile.Config {
+				var built []*lambgofile.Config
+
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(newConfig(), nil)
+				m.Builder.EXPECT().
+					BuildBinaries(gomock.Any()).
+					DoAndReturn(func(config *lambgofile.Config) error {
+						built = append(built, config)
+						return nil
+					})
+				m.Watcher.EXPECT().Watch(gomock.Any()).Return(nil, exampleError)
+
+				return &built
+			},
+			ExpectedBuilds: func(config *lambgofile.Config) []*lambgofile.Config {
+				return []*lambgofile.Config{config}
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		built := entry.SetupWatch(entry.Mocks)
+
+		err := entry.Subject.Run([]string{"lambgo", "build", "--watch"})
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+
+		expectedConfig := newConfig()
+		expectedConfig.NumParallel = 4
+		ensure(*built).Equals(entry.ExpectedBuilds(expectedConfig))
+	})
+}
+
+func TestInvokeWatch(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		LambgoFileLoader *mock_lambgofile.MockLoaderAPI
+		Builder          *mock_builder.MockLambdaBuilderAPI
+		Launcher         *mock_runtimeapi.MockLauncherAPI
+		Function         *mock_runtimeapi.MockFunctionAPI `ensure:"ignoreunused"`
+		Watcher          *mock_filewatch.MockWatcherAPI
+		Changes          *mock_filewatch.MockChangesAPI `ensure:"ignoreunused"`
+		Grapher          *mock_depgraph.MockGrapherAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	api := makeLambda("lambdas/api", nil)
+	config := &lambgofile.Config{RootPath: "/test", Lambdas: []*lambgofile.Lambda{api}}
+
+	expectInvoke := func(m *Mocks, buildErr error, payload string) []*gomock.Call {
+		if buildErr != nil {
+			return []*gomock.Call{m.Builder.EXPECT().BuildForHost(config, api).Return("", buildErr)}
+		}
+
+		return []*gomock.Call{
+			m.Builder.EXPECT().BuildForHost(config, api).Return("/test/tmp/host/lambdas/api", nil),
+			m.Launcher.EXPECT().
+				Launch(&runtimeapi.LaunchParams{BinaryPath: "/test/tmp/host/lambdas/api", FunctionName: "api"}).
+				Return(m.Function, nil),
+			m.Function.EXPECT().Invoke(gomock.Any(), []byte(`{}`)).Return(&runtimeapi.Response{Payload: []byte(payload)}, nil),
+			m.Function.EXPECT().Close().Return(nil),
+		}
+	}
+
+	table := []struct {
+		Name           string
+		ExpectedError  error
+		ExpectedOutput string
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *cmd.App
+	}{
+		{
+			Name:          "with changes, it invokes the Lambda again",
+			ExpectedError: exampleError,
+			SetupMocks: func(m *Mocks) {
+				m.LambgoFileLoader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+
+				calls := expectInvoke(m, exampleError, "")
+				calls = append(calls,
+					m.Watcher.EXPECT().Watch(&filewatch.WatchParams{RootPath: "/test"}).Return(m.Changes, nil),
+					m.Changes.EXPECT().Next(gomock.Any()).Return([]string{"lambdas/api/main.go"}, nil),
+					m.Grapher.EXPECT().Load(config, []string{"lambdas/api"}).Return(depgraph.Graph{"lambdas/api": {{Dir: "lambdas/api"}}}, nil),
+				)
+				calls = append(calls, expectInvoke(m, nil, `{"ok":true}`)...)
+				calls = append(calls, m.Changes.EXPECT().Next(gomock.Any()).Return(nil, exampleError))
+				gomock.InOrder(calls...)
+			},
+			ExpectedOutput: "ERROR: something went wrong\n" +
+				"\nWatching for changes...\n" +
+				"Changes affect: lambdas/api\n" +
+				"{\"ok\":true}\n" +
+				"\nWatching for changes...\n",
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.Getwd = func() (string, error) { return "/test", nil }
+		entry.Subject.Stdin = strings.NewReader("")
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		err := entry.Subject.Run([]string{"lambgo", "invoke", "--watch", "lambdas/api"})
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+	})
+}
+
+func TestServeWatch(t *testing.T) {
+	ensure := ensure.New(t)
+
+	exampleError := errors.New("something went wrong")
+
+	api := makeLambda("lambdas/api", nil)
+	files := makeLambda("lambdas/files", nil)
+	config := &lambgofile.Config{
+		RootPath: "/test",
+		Lambdas:  []*lambgofile.Lambda{api, files},
+		Routes: []*lambgofile.Route{
+			{Path: "/orders", Lambda: api, Event: lambgofile.EventFormatAPIGatewayV2},
+			{Path: "/files/{proxy+}", Lambda: files, Event: lambgofile.EventFormatFunctionURL},
+		},
+	}
+
+	ensure.Run("with changes, it rebuilds and restarts the affected Lambdas", func(ensure ensuring.E) {
+		ctrl := gomock.NewController(ensure.T())
+		loader := mock_lambgofile.NewMockLoaderAPI(ctrl)
+		builder := mock_builder.NewMockLambdaBuilderAPI(ctrl)
+		server := mock_devserver.NewMockServerAPI(ctrl)
+		watcher := mock_filewatch.NewMockWatcherAPI(ctrl)
+		changes := mock_filewatch.NewMockChangesAPI(ctrl)
+		grapher := mock_depgraph.NewMockGrapherAPI(ctrl)
+
+		loader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+		builder.EXPECT().BuildForHost(config, api).Return("/test/tmp/host/lambdas/api", nil)
+		builder.EXPECT().BuildForHost(config, files).Return("/test/tmp/host/lambdas/files", nil)
+
+		watcher.EXPECT().Watch(&filewatch.WatchParams{RootPath: "/test"}).Return(changes, nil)
+		gomock.InOrder(
+			changes.EXPECT().Next(gomock.Any()).Return([]string{"internal/store/store.go"}, nil),
+			grapher.EXPECT().
+				Load(config, []string{"lambdas/api", "lambdas/files"}).
+				Return(depgraph.Graph{"lambdas/api": {{Dir: "lambdas/api"}, {Dir: "internal/store"}}, "lambdas/files": {{Dir: "lambdas/files"}, {Dir: "internal/store"}}}, nil),
+			builder.EXPECT().BuildForHost(config, api).Return("/test/tmp/host/lambdas/api", nil),
+			builder.EXPECT().BuildForHost(config, files).Return("", exampleError),
+			changes.EXPECT().
+				Next(gomock.Any()).
+				DoAndReturn(func(ctx context.Context) ([]string, error) {
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}),
+		)
+
+		server.EXPECT().
+			Serve(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, params *devserver.ServeParams) error {
+				ensure(<-params.Restarts).Equals([]string{"lambdas/api"})
+				return nil
+			})
+
+		output := &bytes.Buffer{}
+		subject := &cmd.App{
+			Getwd:            func() (string, error) { return "/test", nil },
+			LambgoFileLoader: loader,
+			Builder:          builder,
+			DevServer:        server,
+			Watcher:          watcher,
+			Grapher:          grapher,
+			Logger:           log.New(output, "", 0),
+		}
+
+		ensure(subject.Run([]string{"lambgo", "serve", "--watch"})).IsNotError()
+		ensure(output.String()).Equals(
+			"\nWatching for changes...\n" +
+				"Changes affect: lambdas/api, lambdas/files\n" +
+				"ERROR: something went wrong\n" +
+				"\nWatching for changes...\n",
+		)
+	})
+
+	ensure.Run("when the module cannot be watched, it continues serving", func(ensure ensuring.E) {
+		ctrl := gomock.NewController(ensure.T())
+		loader := mock_lambgofile.NewMockLoaderAPI(ctrl)
+		builder := mock_builder.NewMockLambdaBuilderAPI(ctrl)
+		server := mock_devserver.NewMockServerAPI(ctrl)
+		watcher := mock_filewatch.NewMockWatcherAPI(ctrl)
+
+		loader.EXPECT().LoadConfig("/test", "").Return(config, nil)
+		builder.EXPECT().BuildForHost(config, api).Return("/test/tmp/host/lambdas/api", nil)
+		builder.EXPECT().BuildForHost(config, files).Return("/test/tmp/host/lambdas/files", nil)
+
+		watched := make(chan struct{})
+		watcher.EXPECT().
+			Watch(gomock.Any()).
+			DoAndReturn(func(*filewatch.WatchParams) (filewatch.ChangesAPI, error) {
+				close(watched)
+				return nil, exampleError
+			})
+
+		server.EXPECT().
+			Serve(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(context.Context, *devserver.ServeParams) error {
+				<-watched
+				return nil
+			})
+
+		output := &bytes.Buffer{}
+		subject := &cmd.App{
+			Getwd:            func() (string, error) { return "/test", nil },
+			LambgoFileLoader: loader,
+			Builder:          builder,
+			DevServer:        server,
+			Watcher:          watcher,
+			Logger:           log.New(output, "", 0),
+		}
+
+		ensure(subject.Run([]string{"lambgo", "serve", "--watch"})).IsNotError()
+		ensure(output.String()).Equals("ERROR: Stopped watching for changes: something went wrong\n")
+	})
+}
@@ -0,0 +1,196 @@
+// Package depgraph finds which build targets depend on the packages and files in the module or workspace, using `go list -deps`.
+package depgraph
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/runcmd"
+)
+
+type ErkCannotLoadGraph struct{ erk.DefaultKind }
+
+var ErrGoListFailed = erk.New(ErkCannotLoadGraph{}, "Unable to list the dependencies of the Lambdas: {{.err}}")
+
+// moduleFileNames affect the dependencies of every package when they change.
+//
+//nolint:gochecknoglobals // Constant set of file names
+var moduleFileNames = []string{"go.mod", "go.sum", "go.work", "go.work.sum"}
+
+// fileFields are the fields of `go list` with the files used to build each package, other than tests.
+//
+//nolint:gochecknoglobals // Constant set of field names
+var fileFields = []string{
+	"GoFiles", "CgoFiles", "CFiles", "CXXFiles", "MFiles", "HFiles", "FFiles",
+	"SFiles", "SwigFiles", "SwigCXXFiles", "SysoFiles", "EmbedFiles",
+}
+
+// sourceExtensions are the extensions of the files the go command builds from the directory of a package.
+// Adding or removing these files changes the package, even though they are not listed by `go list` beforehand or afterwards.
+//
+//nolint:gochecknoglobals // Constant set of extensions
+var sourceExtensions = []string{
+	".go", ".c", ".cc", ".cpp", ".cxx", ".m", ".h", ".hh", ".hpp", ".hxx",
+	".f", ".F", ".for", ".f90", ".s", ".S", ".sx", ".swig", ".swigcxx", ".syso",
+}
+
+// Graph maps the path of each target to the packages it depends on within the module, including itself.
+// In a workspace, packages in any of its modules are included.
+type Graph map[string][]*Package
+
+// Package that a target depends on.
+type Package struct {
+	// Dir of the package, relative to the root of the module, using forward slashes.
+	Dir string
+
+	// Files used to build the package, such as Go, assembly, and embedded files, relative to Dir.
+	Files []string
+}
+
+type GrapherAPI interface {
+	Load(config *lambgofile.Config, targetPaths []string) (Graph, error)
+}
+
+// Grapher loads the dependency graph with the go command.
+type Grapher struct {
+	Cmd runcmd.RunnerAPI
+}
+
+var _ GrapherAPI = &Grapher{}
+
+// Load the packages each target depends on.
+// Dependencies are listed for the goos and goarch in the config, so build constraints match the build.
+func (g *Grapher) Load(config *lambgofile.Config, targetPaths []string) (Graph, error) {
+	graph := make(Graph, len(targetPaths))
+	if len(targetPaths) == 0 {
+		return graph, nil
+	}
+
+	args := []string{"list", "-e", "-deps", "-f", listTemplate()}
+	importPaths := make(map[string]string, len(targetPaths))
+	for _, targetPath := range targetPaths {
+		args = append(args, "./"+targetPath)
+		importPaths[config.ImportPath(targetPath)] = targetPath
+	}
+
+	envVars := map[string]string{
+		"GOOS":   config.Goos,
+		"GOARCH": config.Goarch,
+	}
+
+	if config.Workspace != nil {
+		envVars["GOWORK"] = config.Workspace.FilePath
+	}
+
+	out, err := g.Cmd.Exec(&runcmd.ExecParams{
+		PWD:  config.RootPath,
+		CMD:  "go",
+		Args: args,
+
+		EnvVars: envVars,
+	})
+	if err != nil {
+		return nil, erk.WrapAs(ErrGoListFailed, err)
+	}
+
+	packages := make(map[string]*Package)
+	targetDeps := make(map[string][]string, len(targetPaths))
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\t")
+		names := strings.Fields(fields[0])
+		if len(names) == 0 {
+			continue
+		}
+
+		if dir, ok := config.PackageDir(names[0]); ok {
+			packages[names[0]] = &Package{Dir: dir, Files: fields[1:]}
+		}
+
+		if _, ok := importPaths[names[0]]; ok {
+			targetDeps[names[0]] = names[1:]
+		}
+	}
+
+	for importPath, deps := range targetDeps {
+		targetPath := importPaths[importPath]
+		pkgs := []*Package{{Dir: targetPath}}
+		if pkg, ok := packages[importPath]; ok {
+			pkgs[0].Files = pkg.Files
+		}
+
+		for _, dep := range deps {
+			if pkg, ok := packages[dep]; ok {
+				pkgs = append(pkgs, pkg)
+			}
+		}
+
+		graph[targetPath] = pkgs
+	}
+
+	return graph, nil
+}
+
+// listTemplate prints a line for each package, with the import path, the dependencies of the targets, and the files of the package.
+// The files are separated by tabs, since their names can contain spaces.
+func listTemplate() string {
+	template := "{{.ImportPath}}{{if not .DepOnly}}{{range .Deps}} {{.}}{{end}}{{end}}"
+	for _, field := range fileFields {
+		template += "{{range ." + field + "}}\t{{.}}{{end}}"
+	}
+
+	return template
+}
+
+// Affected returns the sorted paths of the targets that depend on any of the changed files.
+// Changed files are relative to the root of the module. Changes to go.mod and similar files affect every target,
+// including the go.mod files of other modules in a workspace.
+func (graph Graph) Affected(changedFiles []string) []string {
+	if slices.ContainsFunc(changedFiles, func(changedFile string) bool { return slices.Contains(moduleFileNames, path.Base(changedFile)) }) {
+		return graph.targetPaths()
+	}
+
+	var affected []string
+	for targetPath, pkgs := range graph {
+		if slices.ContainsFunc(pkgs, func(pkg *Package) bool { return slices.ContainsFunc(changedFiles, pkg.uses) }) {
+			affected = append(affected, targetPath)
+		}
+	}
+
+	slices.Sort(affected)
+	return affected
+}
+
+// uses returns true if the file is used to build the package, or is a source file added to or removed from its directory.
+// Tests are not built into the targets, so they are ignored.
+func (pkg *Package) uses(changedFile string) bool {
+	if strings.HasSuffix(changedFile, "_test.go") {
+		return false
+	}
+
+	if path.Dir(changedFile) == pkg.Dir && slices.Contains(sourceExtensions, path.Ext(changedFile)) {
+		return true
+	}
+
+	relPath := changedFile
+	if pkg.Dir != "." {
+		var ok bool
+		if relPath, ok = strings.CutPrefix(changedFile, pkg.Dir+"/"); !ok {
+			return false
+		}
+	}
+
+	return slices.Contains(pkg.Files, relPath)
+}
+
+func (graph Graph) targetPaths() []string {
+	paths := make([]string, 0, len(graph))
+	for targetPath := range graph {
+		paths = append(paths, targetPath)
+	}
+
+	slices.Sort(paths)
+	return paths
+}
@@ -0,0 +1,280 @@
+package depgraph_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/depgraph"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/lambgo/internal/runcmd"
+)
+
+func TestLoad(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Cmd *mock_runcmd.MockRunnerAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	const listTemplate = "{{.ImportPath}}{{if not .DepOnly}}{{range .Deps}} {{.}}{{end}}{{end}}" +
+		"{{range .GoFiles}}\t{{.}}{{end}}{{range .CgoFiles}}\t{{.}}{{end}}{{range .CFiles}}\t{{.}}{{end}}" +
+		"{{range .CXXFiles}}\t{{.}}{{end}}{{range .MFiles}}\t{{.}}{{end}}{{range .HFiles}}\t{{.}}{{end}}" +
+		"{{range .FFiles}}\t{{.}}{{end}}{{range .SFiles}}\t{{.}}{{end}}{{range .SwigFiles}}\t{{.}}{{end}}" +
+		"{{range .SwigCXXFiles}}\t{{.}}{{end}}{{range .SysoFiles}}\t{{.}}{{end}}{{range .EmbedFiles}}\t{{.}}{{end}}"
+
+	config := &lambgofile.Config{
+		RootPath:   "/my/app",
+		ModulePath: "github.com/my/app",
+		Goos:       "linux",
+		Goarch:     "arm64",
+	}
+
+	expectGoList := func(m *Mocks, out string, err error) {
+		m.Cmd.EXPECT().
+			Exec(&runcmd.ExecParams{
+				PWD:  "/my/app",
+				CMD:  "go",
+				Args: []string{"list", "-e", "-deps", "-f", listTemplate, "./lambdas/api", "./lambdas/worker"},
+
+				EnvVars: map[string]string{
+					"GOOS":   "linux",
+					"GOARCH": "arm64",
+				},
+			}).
+			Return(out, err)
+	}
+
+	table := []struct {
+		Name          string
+		TargetPaths   []string
+		ExpectedGraph depgraph.Graph
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *depgraph.Grapher
+	}{
+		{
+			Name:        "with dependencies",
+			TargetPaths: []string{"lambdas/api", "lambdas/worker"},
+			SetupMocks: func(m *Mocks) {
+				expectGoList(m,
+					"context\tcontext.go\n"+
+						"github.com/my/app\tconfig.go\n"+
+						"github.com/my/app/internal/store\tstore.go\tstore_amd64.s\tschema/init.sql\n"+
+						"github.com/my/app/lambdas/api context fmt github.com/aws/aws-lambda-go/lambda github.com/my/app github.com/my/app/internal/store\tmain.go\n"+
+						"github.com/my/app/internal/queue\tqueue.go\n"+
+						"github.com/my/app/lambdas/worker context github.com/my/app/internal/queue\tmain.go\n",
+					nil,
+				)
+			},
+			ExpectedGraph: depgraph.Graph{
+				"lambdas/api": {
+					{Dir: "lambdas/api", Files: []string{"main.go"}},
+					{Dir: ".", Files: []string{"config.go"}},
+					{Dir: "internal/store", Files: []string{"store.go", "store_amd64.s", "schema/init.sql"}},
+				},
+				"lambdas/worker": {
+					{Dir: "lambdas/worker", Files: []string{"main.go"}},
+					{Dir: "internal/queue", Files: []string{"queue.go"}},
+				},
+			},
+		},
+
+		{
+			Name:        "with unrelated output",
+			TargetPaths: []string{"lambdas/api", "lambdas/worker"},
+			SetupMocks: func(m *Mocks) {
+				expectGoList(m,
+					"go: downloading github.com/aws/aws-lambda-go v1.50.0\n"+
+						"github.com/my/app/lambdas/api\n"+
+						"\n"+
+						"github.com/my/app/internal/queue\tqueue.go\n"+
+						"github.com/my/app/lambdas/worker github.com/my/app/internal/queue\tmain.go\n",
+					nil,
+				)
+			},
+			ExpectedGraph: depgraph.Graph{
+				"lambdas/api": {{Dir: "lambdas/api", Files: []string{}}},
+				"lambdas/worker": {
+					{Dir: "lambdas/worker", Files: []string{"main.go"}},
+					{Dir: "internal/queue", Files: []string{"queue.go"}},
+				},
+			},
+		},
+
+		{
+			Name:          "with no targets",
+			TargetPaths:   []string{},
+			ExpectedGraph: depgraph.Graph{},
+		},
+
+		{
+			Name:          "when go list fails",
+			TargetPaths:   []string{"lambdas/api", "lambdas/worker"},
+			ExpectedError: depgraph.ErrGoListFailed,
+			SetupMocks: func(m *Mocks) {
+				expectGoList(m, "", exampleError)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		graph, err := entry.Subject.Load(config, entry.TargetPaths)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(graph).Equals(entry.ExpectedGraph)
+	})
+
+	ensure.Run("with workspace modules", func(ensure ensuring.E) {
+		mocks := &Mocks{Cmd: mock_runcmd.NewMockRunnerAPI(ensure.GoMockController())}
+
+		workspaceConfig := &lambgofile.Config{
+			RootPath:   "/my/repo",
+			ModulePath: "github.com/my/repo",
+			Goos:       "linux",
+			Goarch:     "amd64",
+			Workspace: &lambgofile.Workspace{
+				FilePath: "/my/repo/go.work",
+				Modules: []*lambgofile.WorkspaceModule{
+					{Dir: ".", ModulePath: "github.com/my/repo"},
+					{Dir: "services/billing", ModulePath: "github.com/my/repo/services/billing"},
+					{Dir: "services/search", ModulePath: "example.com/search"},
+				},
+			},
+		}
+
+		mocks.Cmd.EXPECT().
+			Exec(&runcmd.ExecParams{
+				PWD:  "/my/repo",
+				CMD:  "go",
+				Args: []string{"list", "-e", "-deps", "-f", listTemplate, "./services/billing/cmd/charge", "./services/search/cmd/api"},
+
+				EnvVars: map[string]string{
+					"GOOS":   "linux",
+					"GOARCH": "amd64",
+					"GOWORK": "/my/repo/go.work",
+				},
+			}).
+			Return(
+				"github.com/my/repo/internal/auth\tauth.go\n"+
+					"github.com/my/repo/services/billing/ledger\tledger.go\n"+
+					"github.com/my/repo/services/billing/cmd/charge github.com/my/repo/internal/auth github.com/my/repo/services/billing/ledger\tmain.go\n"+
+					"example.com/search/index\tindex.go\n"+
+					"example.com/search/cmd/api example.com/search/index github.com/my/repo/internal/auth\tmain.go\n",
+				nil,
+			)
+
+		graph, err := (&depgraph.Grapher{Cmd: mocks.Cmd}).Load(workspaceConfig, []string{"services/billing/cmd/charge", "services/search/cmd/api"})
+		ensure(err).IsNotError()
+		ensure(graph).Equals(depgraph.Graph{
+			"services/billing/cmd/charge": {
+				{Dir: "services/billing/cmd/charge", Files: []string{"main.go"}},
+				{Dir: "internal/auth", Files: []string{"auth.go"}},
+				{Dir: "services/billing/ledger", Files: []string{"ledger.go"}},
+			},
+			"services/search/cmd/api": {
+				{Dir: "services/search/cmd/api", Files: []string{"main.go"}},
+				{Dir: "services/search/index", Files: []string{"index.go"}},
+				{Dir: "internal/auth", Files: []string{"auth.go"}},
+			},
+		})
+	})
+}
+
+func TestAffected(t *testing.T) {
+	ensure := ensure.New(t)
+
+	graph := depgraph.Graph{
+		"lambdas/api": {
+			{Dir: "lambdas/api", Files: []string{"main.go"}},
+			{Dir: ".", Files: []string{"config.go"}},
+			{Dir: "internal/store", Files: []string{"store.go", "store_amd64.s", "schema/init.sql"}},
+		},
+		"lambdas/worker": {
+			{Dir: "lambdas/worker", Files: []string{"main.go"}},
+			{Dir: "internal/queue", Files: []string{"queue.go"}},
+			{Dir: "internal/store", Files: []string{"store.go", "store_amd64.s", "schema/init.sql"}},
+		},
+		"extensions/trace": {{Dir: "extensions/trace", Files: []string{"main.go", "trace.syso"}}},
+	}
+
+	table := []struct {
+		Name             string
+		ChangedFiles     []string
+		ExpectedAffected []string
+	}{
+		{
+			Name:             "with change to a target",
+			ChangedFiles:     []string{"lambdas/worker/main.go"},
+			ExpectedAffected: []string{"lambdas/worker"},
+		},
+		{
+			Name:             "with change to a shared package",
+			ChangedFiles:     []string{"internal/store/store.go"},
+			ExpectedAffected: []string{"lambdas/api", "lambdas/worker"},
+		},
+		{
+			Name:             "with change to the root package",
+			ChangedFiles:     []string{"config.go"},
+			ExpectedAffected: []string{"lambdas/api"},
+		},
+		{
+			Name:             "with change to go.mod",
+			ChangedFiles:     []string{"internal/queue/queue.go", "go.mod"},
+			ExpectedAffected: []string{"extensions/trace", "lambdas/api", "lambdas/worker"},
+		},
+		{
+			Name:             "with change to go.sum of a workspace module",
+			ChangedFiles:     []string{"services/billing/go.sum"},
+			ExpectedAffected: []string{"extensions/trace", "lambdas/api", "lambdas/worker"},
+		},
+		{
+			Name:         "with change to an unused package",
+			ChangedFiles: []string{"internal/unused/unused.go"},
+		},
+		{
+			Name:             "with change to an embedded file",
+			ChangedFiles:     []string{"internal/store/schema/init.sql"},
+			ExpectedAffected: []string{"lambdas/api", "lambdas/worker"},
+		},
+		{
+			Name:             "with change to an assembly file",
+			ChangedFiles:     []string{"internal/store/store_amd64.s"},
+			ExpectedAffected: []string{"lambdas/api", "lambdas/worker"},
+		},
+		{
+			Name:             "with change to a syso file",
+			ChangedFiles:     []string{"extensions/trace/trace.syso"},
+			ExpectedAffected: []string{"extensions/trace"},
+		},
+		{
+			Name:             "with new source file in a package",
+			ChangedFiles:     []string{"internal/queue/queue_arm64.s"},
+			ExpectedAffected: []string{"lambdas/worker"},
+		},
+		{
+			Name:         "with change to a file that is not embedded",
+			ChangedFiles: []string{"internal/store/schema/README.md", "internal/store/README.md"},
+		},
+		{
+			Name:         "with change to a test",
+			ChangedFiles: []string{"internal/store/store_test.go"},
+		},
+		{
+			Name:         "with change to a file that is not Go",
+			ChangedFiles: []string{".lambgo.yml"},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		ensure(graph.Affected(entry.ChangedFiles)).Equals(entry.ExpectedAffected)
+	})
+}
@@ -0,0 +1,365 @@
+// Package devserver serves local HTTP requests by invoking Lambdas with API Gateway or Function URL events.
+package devserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/erk/erg"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/runtimeapi"
+)
+
+const (
+	// maxRequestSize matches the maximum payload size of API Gateway.
+	maxRequestSize = 10 * 1024 * 1024
+
+	shutdownTimeout = 5 * time.Second
+)
+
+type ErkDevServerError struct{ erk.DefaultKind }
+
+var (
+	ErrCannotListen          = erk.New(ErkDevServerError{}, "Cannot listen on '{{.addr}}': {{.err}}")
+	ErrConflictingRoutes     = erk.New(ErkDevServerError{}, "Cannot serve the route '{{.route}}': {{.err}}")
+	ErrMissingBinary         = erk.New(ErkDevServerError{}, "No binary was built for the Lambda '{{.lambda}}'")
+	ErrServerFailed          = erk.New(ErkDevServerError{}, "The server stopped unexpectedly: {{.err}}")
+	ErrMalformedResponse     = erk.New(ErkDevServerError{}, "Malformed Lambda proxy response: {{.err}}")
+	ErrMultipleCloseFailures = erk.New(ErkDevServerError{}, "Cannot stop the Lambdas")
+
+	errMissingStatusCode = errors.New("missing statusCode")
+)
+
+// ServeParams configures the routes that are served.
+type ServeParams struct {
+	// Addr to listen on, such as localhost:3000.
+	Addr string
+
+	Routes []*lambgofile.Route
+
+	// Binaries built for the host, keyed by the path of the Lambda.
+	Binaries map[string]string
+
+	// Timeout of each invocation.
+	Timeout time.Duration
+
+	// Restarts receives the paths of Lambdas that were rebuilt, so they are relaunched on their next request.
+	// It is optional, and only used in watch mode.
+	Restarts <-chan []string
+}
+
+type ServerAPI interface {
+	Serve(ctx context.Context, params *ServeParams) error
+}
+
+// Server listens for HTTP requests, and invokes the Lambda of the matching route.
+type Server struct {
+	Launcher runtimeapi.LauncherAPI
+	Logger   *log.Logger
+}
+
+var _ ServerAPI = &Server{}
+
+// Serve the routes until the context is done.
+func (s *Server) Serve(ctx context.Context, params *ServeParams) (err error) {
+	handler, err := NewHandler(s.Launcher, s.Logger, params)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := handler.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+
+	listener, err := net.Listen("tcp", params.Addr)
+	if err != nil {
+		return erk.WrapWith(ErrCannotListen, err, erk.Params{"addr": params.Addr})
+	}
+
+	if len(params.Routes) == 1 {
+		s.Logger.Printf("Serving 1 route on http://%s\n", listener.Addr())
+	} else {
+		s.Logger.Printf("Serving %d routes on http://%s\n", len(params.Routes), listener.Addr())
+	}
+
+	for _, route := range params.Routes {
+		s.Logger.Printf(" - %s -> %s (%s)\n", routeKeyV2(route), route.Lambda.Path, route.Event)
+	}
+
+	httpServer := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second} //nolint:mnd
+
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- httpServer.Serve(listener) }()
+
+	if err := s.wait(ctx, handler, params.Restarts, serveErr); err != nil {
+		return err
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// In-flight requests are aborted when the Lambdas are closed, so it is safe to ignore shutdown timeouts
+	httpServer.Shutdown(shutdownCtx) //nolint:errcheck,gosec
+	return nil
+}
+
+// wait until the context is done, restarting Lambdas when they are rebuilt.
+func (s *Server) wait(ctx context.Context, handler *Handler, restarts <-chan []string, serveErr <-chan error) error {
+	for {
+		select {
+		case err := <-serveErr:
+			return erk.WrapAs(ErrServerFailed, err)
+		case lambdaPaths := <-restarts:
+			if err := handler.Restart(lambdaPaths); err != nil {
+				s.Logger.Printf("Cannot restart the Lambdas: %v\n", err)
+				continue
+			}
+
+			s.Logger.Printf("Restarted %s\n", strings.Join(lambdaPaths, ", "))
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Handler routes HTTP requests to Lambdas, which are launched on the first request.
+type Handler struct {
+	// Now is the time of each request. Defaults to time.Now.
+	Now func() time.Time
+
+	mux       *http.ServeMux
+	logger    *log.Logger
+	timeout   time.Duration
+	functions map[string]*function
+}
+
+// function is a Lambda, which is relaunched if an invocation fails.
+type function struct {
+	launcher   runtimeapi.LauncherAPI
+	lambdaPath string
+	binaryPath string
+
+	// mu ensures the Lambda only handles one invocation at a time, like in AWS.
+	mu      sync.Mutex
+	running runtimeapi.FunctionAPI
+}
+
+// NewHandler for the routes.
+func NewHandler(launcher runtimeapi.LauncherAPI, logger *log.Logger, params *ServeParams) (*Handler, error) {
+	h := &Handler{
+		Now:       time.Now,
+		mux:       http.NewServeMux(),
+		logger:    logger,
+		timeout:   params.Timeout,
+		functions: make(map[string]*function),
+	}
+
+	for _, route := range params.Routes {
+		fn, ok := h.functions[route.Lambda.Path]
+		if !ok {
+			binaryPath, ok := params.Binaries[route.Lambda.Path]
+			if !ok {
+				return nil, erk.WithParams(ErrMissingBinary, erk.Params{"lambda": route.Lambda.Path})
+			}
+
+			fn = &function{launcher: launcher, lambdaPath: route.Lambda.Path, binaryPath: binaryPath}
+			h.functions[route.Lambda.Path] = fn
+		}
+
+		if err := h.handle(route, fn); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// ServeHTTP by invoking the Lambda of the matching route.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// Close the running Lambdas.
+func (h *Handler) Close() error {
+	errs := erg.NewAs(ErrMultipleCloseFailures)
+	for _, fn := range h.functions {
+		if err := fn.close(); err != nil {
+			errs = erg.Append(errs, err)
+		}
+	}
+
+	if erg.Any(errs) {
+		return errs
+	}
+
+	return nil
+}
+
+// Restart the Lambdas, so the next request launches their rebuilt binary.
+// In-flight requests finish before their Lambda is stopped.
+func (h *Handler) Restart(lambdaPaths []string) error {
+	errs := erg.NewAs(ErrMultipleCloseFailures)
+	for _, lambdaPath := range lambdaPaths {
+		fn, ok := h.functions[lambdaPath]
+		if !ok {
+			continue
+		}
+
+		if err := fn.close(); err != nil {
+			errs = erg.Append(errs, err)
+		}
+	}
+
+	if erg.Any(errs) {
+		return errs
+	}
+
+	return nil
+}
+
+// handle the route, converting a conflicting route into an error instead of a panic.
+func (h *Handler) handle(route *lambgofile.Route, fn *function) (err error) {
+	pattern := muxPattern(route)
+	defer func() {
+		if r := recover(); r != nil {
+			err = erk.WithParams(ErrConflictingRoutes, erk.Params{
+				"route": routeKeyV2(route),
+				"err":   fmt.Sprint(r),
+			})
+		}
+	}()
+
+	h.mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		statusCode := h.invoke(w, r, route, fn)
+		h.logger.Printf("%s %s -> %s: %d\n", r.Method, r.URL.RequestURI(), route.Lambda.Path, statusCode)
+	})
+
+	return nil
+}
+
+func (h *Handler) invoke(w http.ResponseWriter, r *http.Request, route *lambgofile.Route, fn *function) int {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		return writeMessage(w, http.StatusRequestEntityTooLarge, "Request Too Long")
+	}
+
+	event, err := newEvent(route, r, body, h.Now())
+	if err != nil {
+		h.logger.Printf("Cannot create the event for '%s': %v\n", route.Lambda.Path, err)
+		return writeMessage(w, http.StatusInternalServerError, "Internal Server Error")
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), h.timeout)
+	defer cancel()
+
+	response, err := fn.invoke(ctx, event)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			h.logger.Printf("The Lambda '%s' timed out after %s\n", route.Lambda.Path, h.timeout)
+			return writeMessage(w, http.StatusGatewayTimeout, "Endpoint request timed out")
+		}
+
+		h.logger.Printf("Cannot invoke the Lambda '%s': %v\n", route.Lambda.Path, err)
+		return writeMessage(w, http.StatusBadGateway, "Internal Server Error")
+	}
+
+	if response.Error != nil {
+		h.logger.Printf("The Lambda '%s' returned an error: %s: %s\n", route.Lambda.Path, response.Error.ErrorType, response.Error.ErrorMessage)
+		return writeMessage(w, http.StatusBadGateway, "Internal Server Error")
+	}
+
+	httpResp, err := newHTTPResponse(route.Event, response.Payload)
+	if err != nil {
+		h.logger.Printf("The Lambda '%s' returned an invalid response: %v\n", route.Lambda.Path, err)
+		return writeMessage(w, http.StatusBadGateway, "Internal Server Error")
+	}
+
+	for key, values := range httpResp.header {
+		w.Header()[key] = values
+	}
+
+	w.WriteHeader(httpResp.statusCode)
+	w.Write(httpResp.body) //nolint:errcheck,gosec // The client may have disconnected
+	return httpResp.statusCode
+}
+
+func (fn *function) invoke(ctx context.Context, payload []byte) (*runtimeapi.Response, error) {
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	if fn.running == nil {
+		running, err := fn.launcher.Launch(&runtimeapi.LaunchParams{
+			BinaryPath:   fn.binaryPath,
+			FunctionName: path.Base(fn.lambdaPath),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		fn.running = running
+	}
+
+	response, err := fn.running.Invoke(ctx, payload)
+	if err != nil {
+		// The Lambda may have exited or be stuck, so it is relaunched for the next request
+		fn.running.Close() //nolint:errcheck,gosec // Already returning an error
+		fn.running = nil
+
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (fn *function) close() error {
+	fn.mu.Lock()
+	defer fn.mu.Unlock()
+
+	if fn.running == nil {
+		return nil
+	}
+
+	err := fn.running.Close()
+	fn.running = nil
+	return err
+}
+
+// muxPattern converts the route to a http.ServeMux pattern.
+// For example, GET /files/{proxy+} becomes GET /files/{proxy...}.
+func muxPattern(route *lambgofile.Route) string {
+	muxPath := route.Path
+	if muxPath == "/" {
+		// Only match the root, instead of every path
+		muxPath = "/{$}"
+	}
+
+	muxPath = strings.ReplaceAll(muxPath, "+}", "...}")
+
+	if route.Method == "" {
+		return muxPath
+	}
+
+	return route.Method + " " + muxPath
+}
+
+// writeMessage in the format used by API Gateway for its own errors.
+func writeMessage(w http.ResponseWriter, statusCode int, message string) int {
+	body, _ := json.Marshal(map[string]string{"message": message}) //nolint:errchkjson // Always valid
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	w.Write(body) //nolint:errcheck,gosec // The client may have disconnected
+	return statusCode
+}
@@ -0,0 +1,497 @@
+package devserver_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/devserver"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_runtimeapi"
+	"github.com/JosiahWitt/lambgo/internal/runtimeapi"
+	"github.com/golang/mock/gomock"
+)
+
+var requestTime = time.Date(2024, 3, 5, 14, 30, 15, 0, time.UTC)
+
+type testHarness struct {
+	handler  *devserver.Handler
+	launcher *mock_runtimeapi.MockLauncherAPI
+	function *mock_runtimeapi.MockFunctionAPI
+	logs     *bytes.Buffer
+}
+
+func newHarness(ensure ensuring.E, routes ...*lambgofile.Route) *testHarness {
+	ctrl := gomock.NewController(ensure.T())
+	h := &testHarness{
+		launcher: mock_runtimeapi.NewMockLauncherAPI(ctrl),
+		function: mock_runtimeapi.NewMockFunctionAPI(ctrl),
+		logs:     &bytes.Buffer{},
+	}
+
+	handler, err := devserver.NewHandler(h.launcher, log.New(h.logs, "", 0), &devserver.ServeParams{
+		Routes:   routes,
+		Binaries: map[string]string{"lambdas/api": "/out/host/lambdas/api"},
+		Timeout:  time.Second,
+	})
+	ensure(err).IsNotError()
+
+	handler.Now = func() time.Time { return requestTime }
+	h.handler = handler
+
+	return h
+}
+
+// expectInvoke launches the Lambda, and returns the response to the invocation.
+// The event is decoded into event, without its random request ID.
+func (h *testHarness) expectInvoke(ensure ensuring.E, event *map[string]any, payload string) {
+	h.launcher.EXPECT().
+		Launch(&runtimeapi.LaunchParams{BinaryPath: "/out/host/lambdas/api", FunctionName: "api"}).
+		Return(h.function, nil)
+
+	h.function.EXPECT().
+		Invoke(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, rawEvent []byte) (*runtimeapi.Response, error) {
+			ensure(json.Unmarshal(rawEvent, event)).IsNotError()
+
+			requestContext := (*event)["requestContext"].(map[string]any)
+			ensure(len(requestContext["requestId"].(string))).Equals(36)
+			delete(requestContext, "requestId")
+
+			return &runtimeapi.Response{Payload: []byte(payload)}, nil
+		})
+}
+
+func (h *testHarness) do(r *http.Request) *httptest.ResponseRecorder {
+	r.RemoteAddr = "192.0.2.1:54321"
+
+	w := httptest.NewRecorder()
+	h.handler.ServeHTTP(w, r)
+	return w
+}
+
+func decodeJSON(ensure ensuring.E, data string) map[string]any {
+	decoded := map[string]any{}
+	ensure(json.Unmarshal([]byte(data), &decoded)).IsNotError()
+	return decoded
+}
+
+func apiRoute(routePath, method string, event lambgofile.EventFormat) *lambgofile.Route {
+	return &lambgofile.Route{
+		Path:   routePath,
+		Method: method,
+		Lambda: &lambgofile.Lambda{Path: "lambdas/api"},
+		Event:  event,
+	}
+}
+
+func TestHandlerAPIGatewayV2(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("with structured response", func(ensure ensuring.E) {
+		h := newHarness(ensure, apiRoute("/orders/{id}/{rest+}", "POST", lambgofile.EventFormatAPIGatewayV2))
+
+		event := map[string]any{}
+		h.expectInvoke(ensure, &event, `{
+			"statusCode": 201,
+			"headers": {"Content-Type": "text/plain", "X-Custom": "yes"},
+			"cookies": ["a=1", "b=2"],
+			"body": "created"
+		}`)
+
+		r := httptest.NewRequest(http.MethodPost, "http://localhost:3000/orders/42/items/7?expand=true&tag=a&tag=b", strings.NewReader(`{"qty":1}`))
+		r.Header.Set("User-Agent", "test-agent")
+		r.Header.Add("X-Multi", "one")
+		r.Header.Add("X-Multi", "two")
+		r.Header.Set("Cookie", "session=abc; theme=dark")
+
+		w := h.do(r)
+		ensure(w.Code).Equals(http.StatusCreated)
+		ensure(w.Body.String()).Equals("created")
+		ensure(w.Header().Get("Content-Type")).Equals("text/plain")
+		ensure(w.Header().Get("X-Custom")).Equals("yes")
+		ensure(w.Header().Values("Set-Cookie")).Equals([]string{"a=1", "b=2"})
+		ensure(h.logs.String()).Equals("POST /orders/42/items/7?expand=true&tag=a&tag=b -> lambdas/api: 201\n")
+
+		ensure(event).Equals(decodeJSON(ensure, `{
+			"version": "2.0",
+			"routeKey": "POST /orders/{id}/{rest+}",
+			"rawPath": "/orders/42/items/7",
+			"rawQueryString": "expand=true&tag=a&tag=b",
+			"cookies": ["session=abc", "theme=dark"],
+			"headers": {"host": "localhost:3000", "user-agent": "test-agent", "x-multi": "one,two"},
+			"queryStringParameters": {"expand": "true", "tag": "a,b"},
+			"pathParameters": {"id": "42", "rest": "items/7"},
+			"requestContext": {
+				"accountId": "000000000000",
+				"apiId": "local",
+				"domainName": "localhost:3000",
+				"domainPrefix": "localhost",
+				"http": {
+					"method": "POST",
+					"path": "/orders/42/items/7",
+					"protocol": "HTTP/1.1",
+					"sourceIp": "192.0.2.1",
+					"userAgent": "test-agent"
+				},
+				"routeKey": "POST /orders/{id}/{rest+}",
+				"stage": "$default",
+				"time": "05/Mar/2024:14:30:15 +0000",
+				"timeEpoch": 1709649015000
+			},
+			"body": "{\"qty\":1}",
+			"isBase64Encoded": false
+		}`))
+	})
+
+	ensure.Run("with binary request and base64 encoded response", func(ensure ensuring.E) {
+		h := newHarness(ensure, apiRoute("/upload", "", lambgofile.EventFormatAPIGatewayV2))
+
+		event := map[string]any{}
+		h.expectInvoke(ensure, &event, `{"statusCode": 200, "body": "AP8=", "isBase64Encoded": true}`)
+
+		w := h.do(httptest.NewRequest(http.MethodPut, "/upload", bytes.NewReader([]byte{0xff, 0xfe})))
+		ensure(w.Code).Equals(http.StatusOK)
+		ensure(w.Body.Bytes()).Equals([]byte{0x00, 0xff})
+
+		ensure(event["routeKey"]).Equals("ANY /upload")
+		ensure(event["body"]).Equals("//4=")
+		ensure(event["isBase64Encoded"]).Equals(true)
+	})
+
+	ensure.Run("with response that is not structured", func(ensure ensuring.E) {
+		h := newHarness(ensure, apiRoute("/", "GET", lambgofile.EventFormatAPIGatewayV2))
+
+		event := map[string]any{}
+		h.expectInvoke(ensure, &event, `"Hello, World!"`)
+
+		w := h.do(httptest.NewRequest(http.MethodGet, "/", nil))
+		ensure(w.Code).Equals(http.StatusOK)
+		ensure(w.Body.String()).Equals(`"Hello, World!"`)
+		ensure(w.Header().Get("Content-Type")).Equals("application/json")
+
+		ensure(event["routeKey"]).Equals("GET /")
+		ensure(event["pathParameters"]).IsNil()
+	})
+}
+
+func TestHandlerAPIGateway(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("with structured response", func(ensure ensuring.E) {
+		h := newHarness(ensure, apiRoute("/orders/{id}", "GET", lambgofile.EventFormatAPIGateway))
+
+		event := map[string]any{}
+		h.expectInvoke(ensure, &event, `{
+			"statusCode": 200,
+			"headers": {"Content-Type": "application/json"},
+			"multiValueHeaders": {"X-Multi": ["one", "two"]},
+			"body": "{\"id\":\"42\"}"
+		}`)
+
+		r := httptest.NewRequest(http.MethodGet, "http://localhost:3000/orders/42?tag=a&tag=b", nil)
+		r.Header.Set("User-Agent", "test-agent")
+
+		w := h.do(r)
+		ensure(w.Code).Equals(http.StatusOK)
+		ensure(w.Body.String()).Equals(`{"id":"42"}`)
+		ensure(w.Header().Get("Content-Type")).Equals("application/json")
+		ensure(w.Header().Values("X-Multi")).Equals([]string{"one", "two"})
+
+		ensure(event).Equals(decodeJSON(ensure, `{
+			"resource": "/orders/{id}",
+			"path": "/orders/42",
+			"httpMethod": "GET",
+			"headers": {"Host": "localhost:3000", "User-Agent": "test-agent"},
+			"multiValueHeaders": {"Host": ["localhost:3000"], "User-Agent": ["test-agent"]},
+			"queryStringParameters": {"tag": "b"},
+			"multiValueQueryStringParameters": {"tag": ["a", "b"]},
+			"pathParameters": {"id": "42"},
+			"stageVariables": null,
+			"requestContext": {
+				"accountId": "000000000000",
+				"apiId": "local",
+				"domainName": "localhost:3000",
+				"httpMethod": "GET",
+				"identity": {"sourceIp": "192.0.2.1", "userAgent": "test-agent"},
+				"path": "/local/orders/42",
+				"protocol": "HTTP/1.1",
+				"requestTimeEpoch": 1709649015000,
+				"resourceId": "local",
+				"resourcePath": "/orders/{id}",
+				"stage": "local"
+			},
+			"body": "",
+			"isBase64Encoded": false
+		}`))
+	})
+
+	ensure.Run("with response missing statusCode", func(ensure ensuring.E) {
+		h := newHarness(ensure, apiRoute("/orders", "", lambgofile.EventFormatAPIGateway))
+
+		event := map[string]any{}
+		h.expectInvoke(ensure, &event, `"Hello, World!"`)
+
+		w := h.do(httptest.NewRequest(http.MethodGet, "/orders", nil))
+		ensure(w.Code).Equals(http.StatusBadGateway)
+		ensure(w.Body.String()).Equals(`{"message":"Internal Server Error"}`)
+		ensure(strings.Contains(h.logs.String(), "The Lambda 'lambdas/api' returned an invalid response: Malformed Lambda proxy response")).IsTrue()
+	})
+}
+
+func TestHandlerFunctionURL(t *testing.T) {
+	ensure := ensure.New(t)
+
+	h := newHarness(ensure, apiRoute("/{proxy+}", "", lambgofile.EventFormatFunctionURL))
+
+	event := map[string]any{}
+	h.expectInvoke(ensure, &event, `{"statusCode": 204}`)
+
+	w := h.do(httptest.NewRequest(http.MethodDelete, "http://abc123.lambda-url.us-east-1.on.aws/orders/42", nil))
+	ensure(w.Code).Equals(http.StatusNoContent)
+
+	requestContext := event["requestContext"].(map[string]any)
+	ensure(event["routeKey"]).Equals("$default")
+	ensure(event["pathParameters"]).IsNil()
+	ensure(requestContext["routeKey"]).Equals("$default")
+	ensure(requestContext["domainPrefix"]).Equals("abc123")
+}
+
+func TestHandlerErrors(t *testing.T) {
+	ensure := ensure.New(t)
+
+	exampleError := errors.New("something went wrong")
+	route := apiRoute("/orders", "GET", lambgofile.EventFormatAPIGatewayV2)
+
+	ensure.Run("when the Lambda returns an error", func(ensure ensuring.E) {
+		h := newHarness(ensure, route)
+
+		h.launcher.EXPECT().Launch(gomock.Any()).Return(h.function, nil)
+		h.function.EXPECT().Invoke(gomock.Any(), gomock.Any()).Return(&runtimeapi.Response{
+			Error: &runtimeapi.InvocationError{ErrorMessage: "boom", ErrorType: "errorString"},
+		}, nil)
+
+		w := h.do(httptest.NewRequest(http.MethodGet, "/orders", nil))
+		ensure(w.Code).Equals(http.StatusBadGateway)
+		ensure(w.Body.String()).Equals(`{"message":"Internal Server Error"}`)
+		ensure(h.logs.String()).Equals(
+			"The Lambda 'lambdas/api' returned an error: errorString: boom\n" +
+				"GET /orders -> lambdas/api: 502\n",
+		)
+	})
+
+	ensure.Run("when the Lambda fails, it is relaunched for the next request", func(ensure ensuring.E) {
+		h := newHarness(ensure, route)
+
+		secondFunction := mock_runtimeapi.NewMockFunctionAPI(gomock.NewController(ensure.T()))
+		gomock.InOrder(
+			h.launcher.EXPECT().Launch(gomock.Any()).Return(h.function, nil),
+			h.function.EXPECT().Invoke(gomock.Any(), gomock.Any()).Return(nil, exampleError),
+			h.function.EXPECT().Close().Return(nil),
+			h.launcher.EXPECT().Launch(gomock.Any()).Return(secondFunction, nil),
+			secondFunction.EXPECT().Invoke(gomock.Any(), gomock.Any()).Return(&runtimeapi.Response{Payload: []byte(`{}`)}, nil),
+		)
+
+		w := h.do(httptest.NewRequest(http.MethodGet, "/orders", nil))
+		ensure(w.Code).Equals(http.StatusBadGateway)
+
+		w = h.do(httptest.NewRequest(http.MethodGet, "/orders", nil))
+		ensure(w.Code).Equals(http.StatusOK)
+
+		secondFunction.EXPECT().Close().Return(nil)
+		ensure(h.handler.Close()).IsNotError()
+	})
+
+	ensure.Run("when the Lambda times out", func(ensure ensuring.E) {
+		h := newHarness(ensure, route)
+
+		h.launcher.EXPECT().Launch(gomock.Any()).Return(h.function, nil)
+		h.function.EXPECT().
+			Invoke(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, _ []byte) (*runtimeapi.Response, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			})
+		h.function.EXPECT().Close().Return(nil)
+
+		w := h.do(httptest.NewRequest(http.MethodGet, "/orders", nil))
+		ensure(w.Code).Equals(http.StatusGatewayTimeout)
+		ensure(w.Body.String()).Equals(`{"message":"Endpoint request timed out"}`)
+	})
+
+	ensure.Run("when the Lambda cannot be launched", func(ensure ensuring.E) {
+		h := newHarness(ensure, route)
+
+		h.launcher.EXPECT().Launch(gomock.Any()).Return(nil, exampleError)
+
+		w := h.do(httptest.NewRequest(http.MethodGet, "/orders", nil))
+		ensure(w.Code).Equals(http.StatusBadGateway)
+		ensure(h.handler.Close()).IsNotError()
+	})
+
+	ensure.Run("when no route matches", func(ensure ensuring.E) {
+		h := newHarness(ensure, route, apiRoute("/", "", lambgofile.EventFormatAPIGatewayV2))
+
+		ensure(h.do(httptest.NewRequest(http.MethodGet, "/missing", nil)).Code).Equals(http.StatusNotFound)
+		ensure(h.do(httptest.NewRequest(http.MethodPost, "/orders", nil)).Code).Equals(http.StatusMethodNotAllowed)
+	})
+
+	ensure.Run("when closing the Lambda fails", func(ensure ensuring.E) {
+		h := newHarness(ensure, route)
+
+		h.launcher.EXPECT().Launch(gomock.Any()).Return(h.function, nil)
+		h.function.EXPECT().Invoke(gomock.Any(), gomock.Any()).Return(&runtimeapi.Response{Payload: []byte(`{}`)}, nil)
+		h.function.EXPECT().Close().Return(exampleError)
+
+		h.do(httptest.NewRequest(http.MethodGet, "/orders", nil))
+		ensure(h.handler.Close()).IsError(devserver.ErrMultipleCloseFailures)
+	})
+}
+
+func TestHandlerRestart(t *testing.T) {
+	ensure := ensure.New(t)
+
+	route := apiRoute("/orders", "GET", lambgofile.EventFormatAPIGatewayV2)
+	exampleError := errors.New("something went wrong")
+
+	ensure.Run("with running Lambda, it is relaunched on the next request", func(ensure ensuring.E) {
+		h := newHarness(ensure, route)
+
+		secondFunction := mock_runtimeapi.NewMockFunctionAPI(gomock.NewController(ensure.T()))
+		gomock.InOrder(
+			h.launcher.EXPECT().Launch(gomock.Any()).Return(h.function, nil),
+			h.function.EXPECT().Invoke(gomock.Any(), gomock.Any()).Return(&runtimeapi.Response{Payload: []byte(`{}`)}, nil),
+			h.function.EXPECT().Close().Return(nil),
+			h.launcher.EXPECT().Launch(gomock.Any()).Return(secondFunction, nil),
+			secondFunction.EXPECT().Invoke(gomock.Any(), gomock.Any()).Return(&runtimeapi.Response{Payload: []byte(`{}`)}, nil),
+		)
+
+		ensure(h.do(httptest.NewRequest(http.MethodGet, "/orders", nil)).Code).Equals(http.StatusOK)
+		ensure(h.handler.Restart([]string{"lambdas/api", "lambdas/unrouted"})).IsNotError()
+		ensure(h.do(httptest.NewRequest(http.MethodGet, "/orders", nil)).Code).Equals(http.StatusOK)
+
+		secondFunction.EXPECT().Close().Return(nil)
+		ensure(h.handler.Close()).IsNotError()
+	})
+
+	ensure.Run("with Lambda that is not running", func(ensure ensuring.E) {
+		h := newHarness(ensure, route)
+		ensure(h.handler.Restart([]string{"lambdas/api"})).IsNotError()
+	})
+
+	ensure.Run("when stopping the Lambda fails", func(ensure ensuring.E) {
+		h := newHarness(ensure, route)
+
+		h.launcher.EXPECT().Launch(gomock.Any()).Return(h.function, nil)
+		h.function.EXPECT().Invoke(gomock.Any(), gomock.Any()).Return(&runtimeapi.Response{Payload: []byte(`{}`)}, nil)
+		h.function.EXPECT().Close().Return(exampleError)
+
+		h.do(httptest.NewRequest(http.MethodGet, "/orders", nil))
+		ensure(h.handler.Restart([]string{"lambdas/api"})).IsError(devserver.ErrMultipleCloseFailures)
+	})
+}
+
+func TestNewHandler(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("when a binary is missing", func(ensure ensuring.E) {
+		_, err := devserver.NewHandler(nil, log.New(io.Discard, "", 0), &devserver.ServeParams{
+			Routes: []*lambgofile.Route{apiRoute("/orders", "", lambgofile.EventFormatAPIGatewayV2)},
+		})
+		ensure(err).IsError(devserver.ErrMissingBinary)
+	})
+
+	ensure.Run("when routes conflict", func(ensure ensuring.E) {
+		_, err := devserver.NewHandler(nil, log.New(io.Discard, "", 0), &devserver.ServeParams{
+			Routes: []*lambgofile.Route{
+				apiRoute("/orders/{id}", "GET", lambgofile.EventFormatAPIGatewayV2),
+				apiRoute("/orders/{orderID}", "GET", lambgofile.EventFormatAPIGatewayV2),
+			},
+			Binaries: map[string]string{"lambdas/api": "/out/host/lambdas/api"},
+		})
+		ensure(err).IsError(devserver.ErrConflictingRoutes)
+	})
+}
+
+func TestServe(t *testing.T) {
+	ensure := ensure.New(t)
+
+	params := &devserver.ServeParams{
+		Addr:     "127.0.0.1:0",
+		Routes:   []*lambgofile.Route{apiRoute("/orders/{id}", "GET", lambgofile.EventFormatAPIGatewayV2)},
+		Binaries: map[string]string{"lambdas/api": "/out/host/lambdas/api"},
+		Timeout:  time.Second,
+	}
+
+	ensure.Run("when the context is done", func(ensure ensuring.E) {
+		logs := &bytes.Buffer{}
+		server := &devserver.Server{Logger: log.New(logs, "", 0)}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		ensure(server.Serve(ctx, params)).IsNotError()
+		ensure(strings.HasPrefix(logs.String(), "Serving 1 route on http://127.0.0.1:")).IsTrue()
+		ensure(strings.HasSuffix(logs.String(), " - GET /orders/{id} -> lambdas/api (apigateway-v2)\n")).IsTrue()
+	})
+
+	ensure.Run("with restarts", func(ensure ensuring.E) {
+		logs := &syncBuffer{}
+		server := &devserver.Server{Logger: log.New(logs, "", 0)}
+
+		restarts := make(chan []string)
+		restartParams := *params
+		restartParams.Restarts = restarts
+
+		ctx, cancel := context.WithCancel(context.Background())
+		served := make(chan error, 1)
+		go func() { served <- server.Serve(ctx, &restartParams) }()
+
+		restarts <- []string{"lambdas/api"}
+		restarts <- []string{"lambdas/api"} // Waits for the first restart to be handled
+		cancel()
+
+		ensure(<-served).IsNotError()
+		ensure(strings.Contains(logs.String(), "Restarted lambdas/api\n")).IsTrue()
+	})
+
+	ensure.Run("when the address is invalid", func(ensure ensuring.E) {
+		server := &devserver.Server{Logger: log.New(io.Discard, "", 0)}
+
+		invalidParams := *params
+		invalidParams.Addr = "127.0.0.1:-1"
+
+		ensure(server.Serve(context.Background(), &invalidParams)).IsError(devserver.ErrCannotListen)
+	})
+}
+
+// syncBuffer is a bytes.Buffer that can be written while the server is running.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
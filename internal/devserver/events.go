@@ -0,0 +1,410 @@
+package devserver
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"maps"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/runtimeapi"
+)
+
+const (
+	accountID = "000000000000"
+	apiID     = "local"
+
+	// stageV1 is the stage of REST APIs, which is the first element of the invoke URL.
+	stageV1 = "local"
+
+	// routeKeyDefault is used by Function URLs, which do not have routes.
+	routeKeyDefault = "$default"
+
+	timeFormatV2 = "02/Jan/2006:15:04:05 -0700"
+)
+
+// eventV1 is the API Gateway REST API (v1) proxy integration event.
+// See: https://docs.aws.amazon.com/apigateway/latest/developerguide/set-up-lambda-proxy-integrations.html
+type eventV1 struct {
+	Resource                        string              `json:"resource"`
+	Path                            string              `json:"path"`
+	HTTPMethod                      string              `json:"httpMethod"`
+	Headers                         map[string]string   `json:"headers"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	PathParameters                  map[string]string   `json:"pathParameters"`
+	StageVariables                  map[string]string   `json:"stageVariables"`
+	RequestContext                  requestContextV1    `json:"requestContext"`
+	Body                            string              `json:"body"`
+	IsBase64Encoded                 bool                `json:"isBase64Encoded"`
+}
+
+type requestContextV1 struct {
+	AccountID        string     `json:"accountId"`
+	APIID            string     `json:"apiId"`
+	DomainName       string     `json:"domainName"`
+	HTTPMethod       string     `json:"httpMethod"`
+	Identity         identityV1 `json:"identity"`
+	Path             string     `json:"path"`
+	Protocol         string     `json:"protocol"`
+	RequestID        string     `json:"requestId"`
+	RequestTimeEpoch int64      `json:"requestTimeEpoch"`
+	ResourceID       string     `json:"resourceId"`
+	ResourcePath     string     `json:"resourcePath"`
+	Stage            string     `json:"stage"`
+}
+
+type identityV1 struct {
+	SourceIP  string `json:"sourceIp"`
+	UserAgent string `json:"userAgent"`
+}
+
+// eventV2 is the API Gateway HTTP API (v2) event, which is also used by Function URLs.
+// See: https://docs.aws.amazon.com/apigateway/latest/developerguide/http-api-develop-integrations-lambda.html
+type eventV2 struct {
+	Version               string            `json:"version"`
+	RouteKey              string            `json:"routeKey"`
+	RawPath               string            `json:"rawPath"`
+	RawQueryString        string            `json:"rawQueryString"`
+	Cookies               []string          `json:"cookies,omitempty"`
+	Headers               map[string]string `json:"headers"`
+	QueryStringParameters map[string]string `json:"queryStringParameters,omitempty"`
+	PathParameters        map[string]string `json:"pathParameters,omitempty"`
+	RequestContext        requestContextV2  `json:"requestContext"`
+	Body                  string            `json:"body,omitempty"`
+	IsBase64Encoded       bool              `json:"isBase64Encoded"`
+}
+
+type requestContextV2 struct {
+	AccountID    string `json:"accountId"`
+	APIID        string `json:"apiId"`
+	DomainName   string `json:"domainName"`
+	DomainPrefix string `json:"domainPrefix"`
+	HTTP         httpV2 `json:"http"`
+	RequestID    string `json:"requestId"`
+	RouteKey     string `json:"routeKey"`
+	Stage        string `json:"stage"`
+	Time         string `json:"time"`
+	TimeEpoch    int64  `json:"timeEpoch"`
+}
+
+type httpV2 struct {
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Protocol  string `json:"protocol"`
+	SourceIP  string `json:"sourceIp"`
+	UserAgent string `json:"userAgent"`
+}
+
+// responseV1 is returned by Lambdas for REST APIs.
+type responseV1 struct {
+	StatusCode        int                 `json:"statusCode"`
+	Headers           map[string]string   `json:"headers"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders"`
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// responseV2 is returned by Lambdas for HTTP APIs and Function URLs.
+type responseV2 struct {
+	StatusCode      int               `json:"statusCode"`
+	Headers         map[string]string `json:"headers"`
+	Cookies         []string          `json:"cookies"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// httpResponse is written to the client.
+type httpResponse struct {
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+// newEvent for the route, from the request.
+func newEvent(route *lambgofile.Route, r *http.Request, body []byte, now time.Time) ([]byte, error) {
+	if route.Event == lambgofile.EventFormatAPIGateway {
+		return json.Marshal(newEventV1(route, r, body, now))
+	}
+
+	return json.Marshal(newEventV2(route, r, body, now))
+}
+
+func newEventV1(route *lambgofile.Route, r *http.Request, body []byte, now time.Time) *eventV1 {
+	header := requestHeader(r)
+	query := r.URL.Query()
+	encodedBody, isBase64Encoded := encodeBody(body)
+
+	return &eventV1{
+		Resource:                        route.Path,
+		Path:                            r.URL.Path,
+		HTTPMethod:                      r.Method,
+		Headers:                         lastValues(header),
+		MultiValueHeaders:               nilIfEmpty(header),
+		QueryStringParameters:           lastValues(query),
+		MultiValueQueryStringParameters: nilIfEmpty(query),
+		PathParameters:                  pathParameters(route, r),
+		RequestContext: requestContextV1{
+			AccountID:  accountID,
+			APIID:      apiID,
+			DomainName: r.Host,
+			HTTPMethod: r.Method,
+			Identity: identityV1{
+				SourceIP:  sourceIP(r),
+				UserAgent: r.UserAgent(),
+			},
+			Path:             "/" + stageV1 + r.URL.Path,
+			Protocol:         r.Proto,
+			RequestID:        runtimeapi.NewRequestID(),
+			RequestTimeEpoch: now.UnixMilli(),
+			ResourceID:       apiID,
+			ResourcePath:     route.Path,
+			Stage:            stageV1,
+		},
+		Body:            encodedBody,
+		IsBase64Encoded: isBase64Encoded,
+	}
+}
+
+func newEventV2(route *lambgofile.Route, r *http.Request, body []byte, now time.Time) *eventV2 {
+	routeKey := routeKeyDefault
+	var pathParams map[string]string
+	if route.Event == lambgofile.EventFormatAPIGatewayV2 {
+		routeKey = routeKeyV2(route)
+		pathParams = pathParameters(route, r)
+	}
+
+	// HTTP APIs combine repeated headers and query parameters with commas, and move cookies to their own field
+	headers := make(map[string]string)
+	var cookies []string
+	for key, values := range requestHeader(r) {
+		key = strings.ToLower(key)
+		if key == "cookie" {
+			for _, value := range values {
+				cookies = append(cookies, strings.Split(value, "; ")...)
+			}
+
+			continue
+		}
+
+		headers[key] = strings.Join(values, ",")
+	}
+
+	var queryParams map[string]string
+	for key, values := range r.URL.Query() {
+		if queryParams == nil {
+			queryParams = make(map[string]string)
+		}
+
+		queryParams[key] = strings.Join(values, ",")
+	}
+
+	encodedBody, isBase64Encoded := encodeBody(body)
+
+	return &eventV2{
+		Version:               "2.0",
+		RouteKey:              routeKey,
+		RawPath:               r.URL.Path,
+		RawQueryString:        r.URL.RawQuery,
+		Cookies:               cookies,
+		Headers:               headers,
+		QueryStringParameters: queryParams,
+		PathParameters:        pathParams,
+		RequestContext: requestContextV2{
+			AccountID:    accountID,
+			APIID:        apiID,
+			DomainName:   r.Host,
+			DomainPrefix: domainPrefix(r.Host),
+			HTTP: httpV2{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  r.Proto,
+				SourceIP:  sourceIP(r),
+				UserAgent: r.UserAgent(),
+			},
+			RequestID: runtimeapi.NewRequestID(),
+			RouteKey:  routeKey,
+			Stage:     routeKeyDefault,
+			Time:      now.Format(timeFormatV2),
+			TimeEpoch: now.UnixMilli(),
+		},
+		Body:            encodedBody,
+		IsBase64Encoded: isBase64Encoded,
+	}
+}
+
+// newHTTPResponse from the payload returned by the Lambda.
+func newHTTPResponse(event lambgofile.EventFormat, payload []byte) (*httpResponse, error) {
+	if event == lambgofile.EventFormatAPIGateway {
+		return newHTTPResponseV1(payload)
+	}
+
+	return newHTTPResponseV2(payload)
+}
+
+func newHTTPResponseV1(payload []byte) (*httpResponse, error) {
+	response := &responseV1{}
+	if err := json.Unmarshal(payload, response); err != nil {
+		return nil, erk.WrapAs(ErrMalformedResponse, err)
+	}
+
+	if response.StatusCode == 0 {
+		return nil, erk.WrapAs(ErrMalformedResponse, errMissingStatusCode)
+	}
+
+	body, err := decodeBody(response.Body, response.IsBase64Encoded)
+	if err != nil {
+		return nil, erk.WrapAs(ErrMalformedResponse, err)
+	}
+
+	header := http.Header{}
+	for key, values := range response.MultiValueHeaders {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	for key, value := range response.Headers {
+		header.Set(key, value)
+	}
+
+	return &httpResponse{statusCode: response.StatusCode, header: header, body: body}, nil
+}
+
+func newHTTPResponseV2(payload []byte) (*httpResponse, error) {
+	// When the payload is not an object with a statusCode, it is the body of a successful response
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(payload, &fields); err != nil || fields["statusCode"] == nil {
+		header := http.Header{}
+		header.Set("Content-Type", "application/json")
+		return &httpResponse{statusCode: http.StatusOK, header: header, body: payload}, nil
+	}
+
+	response := &responseV2{}
+	if err := json.Unmarshal(payload, response); err != nil {
+		return nil, erk.WrapAs(ErrMalformedResponse, err)
+	}
+
+	body, err := decodeBody(response.Body, response.IsBase64Encoded)
+	if err != nil {
+		return nil, erk.WrapAs(ErrMalformedResponse, err)
+	}
+
+	header := http.Header{}
+	for key, value := range response.Headers {
+		header.Set(key, value)
+	}
+
+	for _, cookie := range response.Cookies {
+		header.Add("Set-Cookie", cookie)
+	}
+
+	return &httpResponse{statusCode: response.StatusCode, header: header, body: body}, nil
+}
+
+// routeKeyV2 is the method and path of the route, such as GET /orders/{id}.
+func routeKeyV2(route *lambgofile.Route) string {
+	method := route.Method
+	if method == "" {
+		method = "ANY"
+	}
+
+	return method + " " + route.Path
+}
+
+// pathParameters of the route, from the request.
+func pathParameters(route *lambgofile.Route, r *http.Request) map[string]string {
+	var params map[string]string
+
+	for _, name := range routeParameterNames(route.Path) {
+		if params == nil {
+			params = make(map[string]string)
+		}
+
+		params[name] = r.PathValue(name)
+	}
+
+	return params
+}
+
+// routeParameterNames in the route path, such as id for {id}, or proxy for {proxy+}.
+func routeParameterNames(routePath string) []string {
+	var names []string
+
+	for _, segment := range strings.Split(routePath, "/") {
+		if strings.HasPrefix(segment, "{") {
+			names = append(names, strings.TrimSuffix(strings.Trim(segment, "{}"), "+"))
+		}
+	}
+
+	return names
+}
+
+// requestHeader including the Host header, which Go removes from the request headers.
+func requestHeader(r *http.Request) http.Header {
+	header := r.Header.Clone()
+	header.Set("Host", r.Host)
+	return header
+}
+
+// domainPrefix is the first label of the host, such as the URL ID of a Function URL.
+func domainPrefix(host string) string {
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+
+	return strings.Split(host, ".")[0]
+}
+
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+func lastValues(values map[string][]string) map[string]string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	last := make(map[string]string, len(values))
+	for key, vals := range values {
+		last[key] = vals[len(vals)-1]
+	}
+
+	return last
+}
+
+func nilIfEmpty(values map[string][]string) map[string][]string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	return maps.Clone(values)
+}
+
+// encodeBody as base64 when it is not valid UTF-8, since JSON strings cannot contain binary data.
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+func decodeBody(body string, isBase64Encoded bool) ([]byte, error) {
+	if !isBase64Encoded {
+		return []byte(body), nil
+	}
+
+	return base64.StdEncoding.DecodeString(body)
+}
@@ -0,0 +1,235 @@
+// Package filewatch watches a Go module for changes to the files that can affect builds.
+package filewatch
+
+import (
+	"context"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+)
+
+const (
+	defaultInterval = 250 * time.Millisecond
+	defaultDebounce = 300 * time.Millisecond
+)
+
+type ErkCannotWatch struct{ erk.DefaultKind }
+
+var ErrCannotScan = erk.New(ErkCannotWatch{}, "Cannot scan '{{.path}}' for changes: {{.err}}")
+
+// watchedFileNames affect builds, and are watched even though some are hidden.
+//
+//nolint:gochecknoglobals // Constant set of file names
+var watchedFileNames = []string{
+	"go.mod", "go.sum", "go.work", "go.work.sum",
+	lambgofile.ConfigFileName, lambgofile.LocalConfigFileName, lambgofile.IncludedFileName,
+}
+
+// WatchParams configures which files are watched.
+type WatchParams struct {
+	// RootPath of the module.
+	RootPath string
+
+	// ExcludeDirs are absolute or relative to RootPath, such as the outDirectory.
+	ExcludeDirs []string
+}
+
+type WatcherAPI interface {
+	Watch(params *WatchParams) (ChangesAPI, error)
+}
+
+type ChangesAPI interface {
+	Next(ctx context.Context) ([]string, error)
+}
+
+// Watcher polls the module for changes, since it does not require any platform specific APIs.
+type Watcher struct {
+	// Interval between scans. Defaults to 250ms.
+	Interval time.Duration
+
+	// Debounce is how long the files must be unchanged before the changes are reported. Defaults to 300ms.
+	Debounce time.Duration
+}
+
+var _ WatcherAPI = &Watcher{}
+
+// Changes to the watched files, which are reported by Next.
+type Changes struct {
+	rootPath    string
+	excludeDirs map[string]struct{}
+	interval    time.Duration
+	debounce    time.Duration
+
+	files map[string]fileState
+}
+
+var _ ChangesAPI = &Changes{}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Watch the module, starting from the current state of its files.
+func (w *Watcher) Watch(params *WatchParams) (ChangesAPI, error) {
+	excludeDirs := make(map[string]struct{}, len(params.ExcludeDirs))
+	for _, excludeDir := range params.ExcludeDirs {
+		if !filepath.IsAbs(excludeDir) {
+			excludeDir = filepath.Join(params.RootPath, excludeDir)
+		}
+
+		excludeDirs[filepath.Clean(excludeDir)] = struct{}{}
+	}
+
+	interval := w.Interval
+	if interval == 0 {
+		interval = defaultInterval
+	}
+
+	debounce := w.Debounce
+	if debounce == 0 {
+		debounce = defaultDebounce
+	}
+
+	c := &Changes{rootPath: params.RootPath, excludeDirs: excludeDirs, interval: interval, debounce: debounce}
+
+	files, err := c.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	c.files = files
+	return c, nil
+}
+
+// Next waits for files to change, and returns their paths relative to the RootPath.
+// Changes are collected until no files have changed for the debounce duration, so saving many files results in one change.
+func (c *Changes) Next(ctx context.Context) ([]string, error) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	changed := make(map[string]struct{})
+	var lastChange time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, context.Cause(ctx)
+		case <-ticker.C:
+		}
+
+		files, err := c.scan()
+		if err != nil {
+			return nil, err
+		}
+
+		if diff := diffFiles(c.files, files); len(diff) > 0 {
+			for _, path := range diff {
+				changed[path] = struct{}{}
+			}
+
+			c.files = files
+			lastChange = time.Now()
+			continue
+		}
+
+		if len(changed) > 0 && time.Since(lastChange) >= c.debounce {
+			paths := make([]string, 0, len(changed))
+			for path := range changed {
+				paths = append(paths, path)
+			}
+
+			slices.Sort(paths)
+			return paths, nil
+		}
+	}
+}
+
+// scan the state of the watched files.
+func (c *Changes) scan() (map[string]fileState, error) {
+	files := make(map[string]fileState)
+
+	err := filepath.WalkDir(c.rootPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != c.rootPath && c.skipDir(path, d.Name()) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !isWatched(d.Name()) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(c.rootPath, path)
+		if err != nil {
+			return err
+		}
+
+		files[filepath.ToSlash(relPath)] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotScan, err, erk.Params{"path": c.rootPath})
+	}
+
+	return files, nil
+}
+
+// skipDir matches the directories ignored by the go command, and the excluded directories.
+func (c *Changes) skipDir(path, name string) bool {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" || name == "node_modules" {
+		return true
+	}
+
+	_, excluded := c.excludeDirs[path]
+	return excluded
+}
+
+// isWatched when the file can affect builds, which is any file other than tests and the files ignored by the go command,
+// since packages can embed other files, or be built from assembly and other source files.
+func isWatched(name string) bool {
+	if slices.Contains(watchedFileNames, name) {
+		return true
+	}
+
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+		return false
+	}
+
+	return !strings.HasSuffix(name, "_test.go")
+}
+
+// diffFiles returns the paths that were added, removed, or modified.
+func diffFiles(before, after map[string]fileState) []string {
+	var diff []string
+
+	for path, state := range after {
+		if beforeState, ok := before[path]; !ok || !beforeState.modTime.Equal(state.modTime) || beforeState.size != state.size {
+			diff = append(diff, path)
+		}
+	}
+
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			diff = append(diff, path)
+		}
+	}
+
+	return diff
+}
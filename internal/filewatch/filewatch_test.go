@@ -0,0 +1,107 @@
+package filewatch_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/filewatch"
+)
+
+func TestWatch(t *testing.T) {
+	ensure := ensure.New(t)
+
+	watcher := &filewatch.Watcher{Interval: 5 * time.Millisecond, Debounce: 50 * time.Millisecond}
+
+	writeFile := func(ensure ensuring.E, root, name, contents string) {
+		path := filepath.Join(root, name)
+		ensure(os.MkdirAll(filepath.Dir(path), 0o755)).IsNotError()
+		ensure(os.WriteFile(path, []byte(contents), 0o600)).IsNotError()
+	}
+
+	setup := func(ensure ensuring.E) string {
+		root := ensure.T().TempDir()
+		writeFile(ensure, root, "go.mod", "module github.com/my/app\n")
+		writeFile(ensure, root, "lambdas/api/main.go", "package main\n")
+		writeFile(ensure, root, "internal/store/store.go", "package store\n")
+		writeFile(ensure, root, "internal/store/store_test.go", "package store\n")
+		return root
+	}
+
+	nextWithTimeout := func(changes filewatch.ChangesAPI, timeout time.Duration) ([]string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		return changes.Next(ctx)
+	}
+
+	ensure.Run("with changes to watched files", func(ensure ensuring.E) {
+		root := setup(ensure)
+
+		changes, err := watcher.Watch(&filewatch.WatchParams{RootPath: root})
+		ensure(err).IsNotError()
+
+		writeFile(ensure, root, "internal/store/store.go", "package store\n\nvar X = 1\n")
+		writeFile(ensure, root, "internal/store/new.go", "package store\n")
+		writeFile(ensure, root, "internal/store/schema/init.sql", "CREATE TABLE items;\n")
+		writeFile(ensure, root, "go.mod", "module github.com/my/app\n\ngo 1.23\n")
+		ensure(os.Remove(filepath.Join(root, "lambdas/api/main.go"))).IsNotError()
+
+		paths, err := nextWithTimeout(changes, 5*time.Second)
+		ensure(err).IsNotError()
+		ensure(paths).Equals([]string{
+			"go.mod", "internal/store/new.go", "internal/store/schema/init.sql", "internal/store/store.go", "lambdas/api/main.go",
+		})
+	})
+
+	ensure.Run("with changes that are debounced", func(ensure ensuring.E) {
+		root := setup(ensure)
+
+		changes, err := watcher.Watch(&filewatch.WatchParams{RootPath: root})
+		ensure(err).IsNotError()
+
+		go func() {
+			for i := range 5 {
+				time.Sleep(10 * time.Millisecond)
+				os.WriteFile(filepath.Join(root, "internal/store/store.go"), []byte("package store\n"+string(rune('a'+i))), 0o600) //nolint:errcheck,gosec
+			}
+
+			os.WriteFile(filepath.Join(root, ".lambgo.yml"), []byte("buildPaths: []\n"), 0o600)               //nolint:errcheck,gosec
+			os.WriteFile(filepath.Join(root, ".lambgo.local.yml"), []byte("goarch: arm64\n"), 0o600)          //nolint:errcheck,gosec
+			os.WriteFile(filepath.Join(root, "internal/store/lambgo.yml"), []byte("buildPaths: []\n"), 0o600) //nolint:errcheck,gosec
+		}()
+
+		paths, err := nextWithTimeout(changes, 5*time.Second)
+		ensure(err).IsNotError()
+		ensure(paths).Equals([]string{".lambgo.local.yml", ".lambgo.yml", "internal/store/lambgo.yml", "internal/store/store.go"})
+	})
+
+	ensure.Run("with changes to ignored files", func(ensure ensuring.E) {
+		root := setup(ensure)
+
+		changes, err := watcher.Watch(&filewatch.WatchParams{RootPath: root, ExcludeDirs: []string{"tmp", filepath.Join(root, "build")}})
+		ensure(err).IsNotError()
+
+		writeFile(ensure, root, "internal/store/store_test.go", "package store\n\nvar Y = 1\n")
+		writeFile(ensure, root, ".editorconfig", "root = true\n")
+		writeFile(ensure, root, "internal/store/_notes.txt", "notes\n")
+		writeFile(ensure, root, "tmp/lambdas/api/main.go", "package main\n")
+		writeFile(ensure, root, "build/main.go", "package main\n")
+		writeFile(ensure, root, ".git/main.go", "package main\n")
+		writeFile(ensure, root, "testdata/main.go", "package main\n")
+
+		paths, err := nextWithTimeout(changes, 200*time.Millisecond)
+		ensure(err).IsError(context.DeadlineExceeded)
+		ensure(paths).IsEmpty()
+	})
+
+	ensure.Run("when the root does not exist", func(ensure ensuring.E) {
+		changes, err := watcher.Watch(&filewatch.WatchParams{RootPath: filepath.Join(ensure.T().TempDir(), "missing")})
+		ensure(err).IsError(filewatch.ErrCannotScan)
+		ensure(changes).IsNil()
+	})
+}
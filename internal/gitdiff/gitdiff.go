@@ -0,0 +1,90 @@
+// Package gitdiff lists the files that changed since a git ref.
+package gitdiff
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/runcmd"
+)
+
+type ErkGitFailed struct{ erk.DefaultKind }
+
+var (
+	ErrCannotListChanges = erk.New(ErkGitFailed{}, "Cannot list the files changed since '{{.ref}}': {{.err}}")
+	ErrCannotReadFile    = erk.New(ErkGitFailed{}, "Cannot read '{{.path}}' at '{{.ref}}': {{.err}}")
+)
+
+type DifferAPI interface {
+	ChangedFiles(rootPath, ref string) ([]string, error)
+	ReadFile(rootPath, ref, path string) ([]byte, bool, error)
+}
+
+// Differ runs git in the module, which may be nested within the repository.
+type Differ struct {
+	Cmd runcmd.RunnerAPI
+}
+
+var _ DifferAPI = &Differ{}
+
+// ChangedFiles returns the sorted paths of the files in rootPath that were added, modified, or deleted since the ref,
+// including uncommitted and untracked files. Paths are relative to rootPath, and use forward slashes.
+func (d *Differ) ChangedFiles(rootPath, ref string) ([]string, error) {
+	// Renames are listed as a deletion and an addition, so the packages at both paths are changed
+	changed, err := d.git(rootPath, "diff", "--name-only", "--no-renames", "--relative", ref, "--")
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotListChanges, err, erk.Params{"ref": ref})
+	}
+
+	untracked, err := d.git(rootPath, "ls-files", "--others", "--exclude-standard")
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotListChanges, err, erk.Params{"ref": ref})
+	}
+
+	files := append(lines(changed), lines(untracked)...)
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// ReadFile at the ref, where path is relative to rootPath.
+// It returns false if the file did not exist at the ref.
+func (d *Differ) ReadFile(rootPath, ref, path string) ([]byte, bool, error) {
+	params := erk.Params{"ref": ref, "path": path}
+
+	// Paths starting with ./ are relative to rootPath, instead of the root of the repository
+	listed, err := d.git(rootPath, "ls-tree", "--name-only", ref, "--", "./"+path)
+	if err != nil {
+		return nil, false, erk.WrapWith(ErrCannotReadFile, err, params)
+	}
+
+	if len(lines(listed)) == 0 {
+		return nil, false, nil
+	}
+
+	data, err := d.git(rootPath, "show", ref+":./"+path)
+	if err != nil {
+		return nil, false, erk.WrapWith(ErrCannotReadFile, err, params)
+	}
+
+	return []byte(data), true, nil
+}
+
+func (d *Differ) git(rootPath string, args ...string) (string, error) {
+	return d.Cmd.Exec(&runcmd.ExecParams{
+		PWD:  rootPath,
+		CMD:  "git",
+		Args: args,
+	})
+}
+
+func lines(out string) []string {
+	var result []string
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			result = append(result, line)
+		}
+	}
+
+	return result
+}
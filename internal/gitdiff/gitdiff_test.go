@@ -0,0 +1,148 @@
+package gitdiff_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/gitdiff"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/lambgo/internal/runcmd"
+	"github.com/golang/mock/gomock"
+)
+
+func expectGit(cmd *mock_runcmd.MockRunnerAPI, out string, err error, args ...string) *gomock.Call {
+	return cmd.EXPECT().
+		Exec(&runcmd.ExecParams{PWD: "/my/app", CMD: "git", Args: args}).
+		Return(out, err)
+}
+
+func TestChangedFiles(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Cmd *mock_runcmd.MockRunnerAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+	diffArgs := []string{"diff", "--name-only", "--no-renames", "--relative", "origin/main", "--"}
+	untrackedArgs := []string{"ls-files", "--others", "--exclude-standard"}
+
+	table := []struct {
+		Name          string
+		ExpectedFiles []string
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *gitdiff.Differ
+	}{
+		{
+			Name: "with changed and untracked files",
+			SetupMocks: func(m *Mocks) {
+				expectGit(m.Cmd, "lambdas/api/main.go\ngo.mod\n", nil, diffArgs...)
+				expectGit(m.Cmd, "internal/store/new.go\ngo.mod\n", nil, untrackedArgs...)
+			},
+			ExpectedFiles: []string{"go.mod", "internal/store/new.go", "lambdas/api/main.go"},
+		},
+
+		{
+			Name: "with no changes",
+			SetupMocks: func(m *Mocks) {
+				expectGit(m.Cmd, "", nil, diffArgs...)
+				expectGit(m.Cmd, "", nil, untrackedArgs...)
+			},
+		},
+
+		{
+			Name:          "when the diff fails",
+			ExpectedError: gitdiff.ErrCannotListChanges,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m.Cmd, "", exampleError, diffArgs...)
+			},
+		},
+
+		{
+			Name:          "when listing untracked files fails",
+			ExpectedError: gitdiff.ErrCannotListChanges,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m.Cmd, "", nil, diffArgs...)
+				expectGit(m.Cmd, "", exampleError, untrackedArgs...)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		files, err := entry.Subject.ChangedFiles("/my/app", "origin/main")
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(files).Equals(entry.ExpectedFiles)
+	})
+}
+
+func TestReadFile(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Cmd *mock_runcmd.MockRunnerAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+	lsTreeArgs := []string{"ls-tree", "--name-only", "origin/main", "--", "./.lambgo.yml"}
+
+	table := []struct {
+		Name          string
+		ExpectedData  []byte
+		ExpectedFound bool
+		ExpectedError error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *gitdiff.Differ
+	}{
+		{
+			Name: "with file at the ref",
+			SetupMocks: func(m *Mocks) {
+				expectGit(m.Cmd, ".lambgo.yml\n", nil, lsTreeArgs...)
+				expectGit(m.Cmd, "buildPaths: []\n", nil, "show", "origin/main:./.lambgo.yml")
+			},
+			ExpectedData:  []byte("buildPaths: []\n"),
+			ExpectedFound: true,
+		},
+
+		{
+			Name: "with file missing at the ref",
+			SetupMocks: func(m *Mocks) {
+				expectGit(m.Cmd, "", nil, lsTreeArgs...)
+			},
+		},
+
+		{
+			Name:          "when listing the file fails",
+			ExpectedError: gitdiff.ErrCannotReadFile,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m.Cmd, "", exampleError, lsTreeArgs...)
+			},
+		},
+
+		{
+			Name:          "when reading the file fails",
+			ExpectedError: gitdiff.ErrCannotReadFile,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m.Cmd, ".lambgo.yml\n", nil, lsTreeArgs...)
+				expectGit(m.Cmd, "", exampleError, "show", "origin/main:./.lambgo.yml")
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		data, found, err := entry.Subject.ReadFile("/my/app", "origin/main", ".lambgo.yml")
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(data).Equals(entry.ExpectedData)
+		ensure(found).Equals(entry.ExpectedFound)
+	})
+}
@@ -0,0 +1,183 @@
+package lambgofile
+
+import (
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"mvdan.cc/sh/v3/shell"
+)
+
+// rawBuildOptions is the internal struct used for unmarshaling buildOptions, at the top level and for each Lambda.
+// Unset values are nil or empty, so they can be inherited from the top level.
+type rawBuildOptions struct {
+	Tags       []string `yaml:"tags"`
+	LDFlags    string   `yaml:"ldflags"`
+	GCFlags    string   `yaml:"gcflags"`
+	Trimpath   *bool    `yaml:"trimpath"`
+	Race       *bool    `yaml:"race"`
+	Mod        string   `yaml:"mod"`
+	ExtraFlags string   `yaml:"extraFlags"`
+}
+
+// BuildOptions are the structured options passed to "go build", after merging the per-lambda buildOptions onto the top-level buildOptions.
+type BuildOptions struct {
+	Tags       []string
+	LDFlags    string
+	GCFlags    string
+	Trimpath   bool
+	Race       bool
+	Mod        string
+	ExtraFlags []string
+}
+
+// Flags for "go build", which are passed before the buildFlags of the Lambda.
+// When the options are nil, only -trimpath is used, which is the default.
+func (options *BuildOptions) Flags() []string {
+	if options == nil {
+		return []string{"-trimpath"}
+	}
+
+	var flags []string
+	if options.Trimpath {
+		flags = append(flags, "-trimpath")
+	}
+
+	if options.Race {
+		flags = append(flags, "-race")
+	}
+
+	if options.Mod != "" {
+		flags = append(flags, "-mod="+options.Mod)
+	}
+
+	if len(options.Tags) > 0 {
+		flags = append(flags, "-tags="+strings.Join(options.Tags, ","))
+	}
+
+	if options.GCFlags != "" {
+		flags = append(flags, "-gcflags="+options.GCFlags)
+	}
+
+	if options.LDFlags != "" {
+		flags = append(flags, "-ldflags="+options.LDFlags)
+	}
+
+	return append(flags, options.ExtraFlags...)
+}
+
+// buildModes are the allowed values of the mod option, which is passed to -mod.
+//
+//nolint:gochecknoglobals // Constant list of values
+var buildModes = []string{"readonly", "vendor", "mod"}
+
+// mergeBuildOptions of the Lambda onto the top-level options, and returns nil when neither is set.
+// Tags are combined, ldflags and extraFlags are appended, and the other options of the Lambda replace the top-level options.
+func mergeBuildOptions(defaults, lambdaOptions *rawBuildOptions, lambdaPath string) (*BuildOptions, error) {
+	if defaults == nil && lambdaOptions == nil {
+		return nil, nil //nolint:nilnil // Build options are optional
+	}
+
+	for _, raw := range []*rawBuildOptions{defaults, lambdaOptions} {
+		if raw != nil && raw.Mod != "" && !slices.Contains(buildModes, raw.Mod) {
+			return nil, erk.WithParams(ErrInvalidBuildMod, erk.Params{"mod": raw.Mod})
+		}
+	}
+
+	options := &BuildOptions{Trimpath: true}
+
+	if defaults != nil {
+		if err := options.merge(defaults); err != nil {
+			return nil, erk.WrapWith(ErrCannotParseBuildOptions, err, erk.Params{"options": defaults.String()})
+		}
+	}
+
+	if lambdaOptions != nil {
+		if err := options.merge(lambdaOptions); err != nil {
+			return nil, erk.WrapWith(ErrCannotParsePerLambdaBuildOptions, err, erk.Params{
+				"path":    lambdaPath,
+				"options": lambdaOptions.String(),
+			})
+		}
+	}
+
+	return options, nil
+}
+
+func (options *BuildOptions) merge(raw *rawBuildOptions) error {
+	for _, rawTag := range raw.Tags {
+		tag, err := shell.Expand(rawTag, os.Getenv)
+		if err != nil {
+			return err
+		}
+
+		if tag != "" && !slices.Contains(options.Tags, tag) {
+			options.Tags = append(options.Tags, tag)
+		}
+	}
+
+	ldflags, err := shell.Expand(raw.LDFlags, os.Getenv)
+	if err != nil {
+		return err
+	}
+	options.LDFlags = strings.TrimSpace(options.LDFlags + " " + ldflags)
+
+	if raw.GCFlags != "" {
+		if options.GCFlags, err = shell.Expand(raw.GCFlags, os.Getenv); err != nil {
+			return err
+		}
+	}
+
+	if raw.Trimpath != nil {
+		options.Trimpath = *raw.Trimpath
+	}
+
+	if raw.Race != nil {
+		options.Race = *raw.Race
+	}
+
+	if raw.Mod != "" {
+		options.Mod = raw.Mod
+	}
+
+	extraFlags, err := parseBuildFlags(raw.ExtraFlags)
+	if err != nil {
+		return err
+	}
+	options.ExtraFlags = append(options.ExtraFlags, extraFlags...)
+
+	return nil
+}
+
+// String describes the options that are set, such as: tags: [prod], ldflags: -s -w.
+// It is empty when the options are nil.
+func (raw *rawBuildOptions) String() string {
+	if raw == nil {
+		return ""
+	}
+
+	var fields []string
+	addField := func(key, value string) {
+		if value != "" {
+			fields = append(fields, key+": "+value)
+		}
+	}
+
+	if len(raw.Tags) > 0 {
+		addField("tags", "["+strings.Join(raw.Tags, ", ")+"]")
+	}
+	addField("ldflags", raw.LDFlags)
+	addField("gcflags", raw.GCFlags)
+	if raw.Trimpath != nil {
+		addField("trimpath", strconv.FormatBool(*raw.Trimpath))
+	}
+	if raw.Race != nil {
+		addField("race", strconv.FormatBool(*raw.Race))
+	}
+	addField("mod", raw.Mod)
+	addField("extraFlags", raw.ExtraFlags)
+
+	return strings.Join(fields, ", ")
+}
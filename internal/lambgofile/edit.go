@@ -0,0 +1,168 @@
+package lambgofile
+
+import (
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+)
+
+const buildPathsKey = "buildPaths"
+
+type ErkCannotEditConfig struct{ erk.DefaultKind }
+
+var (
+	ErrCannotParseConfig     = erk.New(ErkCannotEditConfig{}, "Cannot parse the config to add '{{.path}}': {{.err}}")
+	ErrUnexpectedConfigShape = erk.New(ErkCannotEditConfig{}, "Cannot add '{{.path}}', since the config is not a YAML mapping")
+	ErrCannotAddBuildPath    = erk.New(ErkCannotEditConfig{}, "Cannot add '{{.path}}' to buildPaths: {{.err}}")
+)
+
+// AddBuildPath to the buildPaths of the .lambgo.yml file data, and return the updated file data.
+//
+// The file's comments and formatting are preserved when buildPaths is a block sequence.
+// Otherwise, buildPaths is rewritten as a block sequence, or added to the end of the file if it is missing.
+func AddBuildPath(data []byte, buildPath string) ([]byte, error) {
+	params := erk.Params{"path": buildPath}
+
+	file, err := parser.ParseBytes(data, parser.ParseComments)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotParseConfig, err, params)
+	}
+
+	quotedPath, err := yaml.Marshal(buildPath)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotAddBuildPath, err, params)
+	}
+	newEntry := strings.TrimSpace(string(quotedPath))
+
+	root, err := rootMapping(file)
+	if err != nil {
+		return nil, erk.WithParams(err, params)
+	}
+
+	// The file is empty or only contains comments, so buildPaths can be added to the end
+	if root == nil {
+		existing := strings.TrimRight(string(data), "\n")
+		if existing != "" {
+			existing += "\n"
+		}
+
+		return []byte(existing + buildPathsSnippet([]string{newEntry})), nil
+	}
+
+	var buildPathsNode *ast.MappingValueNode
+	for _, mappingValue := range root.Values {
+		if mappingValue.Key.String() == buildPathsKey {
+			buildPathsNode = mappingValue
+			break
+		}
+	}
+
+	switch {
+	case buildPathsNode == nil:
+		err = appendBuildPaths(file, []string{newEntry})
+	case isBlockSequence(buildPathsNode.Value):
+		err = mergeBuildPath(file, newEntry)
+	default:
+		err = replaceBuildPaths(root, buildPathsNode, newEntry)
+	}
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotAddBuildPath, err, params)
+	}
+
+	return []byte(strings.TrimRight(file.String(), "\n") + "\n"), nil
+}
+
+// rootMapping of the file, which is nil if the file is empty or only contains comments.
+func rootMapping(file *ast.File) (*ast.MappingNode, error) {
+	if len(file.Docs) == 0 {
+		return nil, nil //nolint:nilnil // There is no root mapping
+	}
+
+	switch body := file.Docs[0].Body.(type) {
+	case nil, *ast.CommentGroupNode:
+		return nil, nil //nolint:nilnil // There is no root mapping
+	case *ast.MappingNode:
+		return body, nil
+	default:
+		return nil, ErrUnexpectedConfigShape
+	}
+}
+
+func isBlockSequence(node ast.Node) bool {
+	sequence, ok := node.(*ast.SequenceNode)
+	return ok && !sequence.IsFlowStyle
+}
+
+// mergeBuildPath into the existing block sequence, which preserves all comments.
+func mergeBuildPath(file *ast.File, entry string) error {
+	buildPathsPath, err := yaml.PathString("$." + buildPathsKey)
+	if err != nil {
+		return err
+	}
+
+	return buildPathsPath.MergeFromReader(file, strings.NewReader("- "+entry+"\n"))
+}
+
+// appendBuildPaths to the end of the file.
+func appendBuildPaths(file *ast.File, entries []string) error {
+	snippet, err := parseBuildPaths(entries)
+	if err != nil {
+		return err
+	}
+
+	rootPath, err := yaml.PathString("$")
+	if err != nil {
+		return err
+	}
+
+	return rootPath.MergeFromFile(file, snippet)
+}
+
+// replaceBuildPaths with a block sequence containing the existing entries and the new entry.
+// This is used when buildPaths is a flow sequence or is empty, since those cannot be merged into.
+func replaceBuildPaths(root *ast.MappingNode, buildPathsNode *ast.MappingValueNode, entry string) error {
+	var entries []string
+	if sequence, ok := buildPathsNode.Value.(*ast.SequenceNode); ok {
+		for _, value := range sequence.Values {
+			entries = append(entries, value.String())
+		}
+	}
+	entries = append(entries, entry)
+
+	snippet, err := parseBuildPaths(entries)
+	if err != nil {
+		return err
+	}
+
+	replacement := snippet.Docs[0].Body.(*ast.MappingNode).Values[0] //nolint:forcetypeassert // Parsed from a known snippet
+	if comment := buildPathsNode.GetComment(); comment != nil {
+		if err := replacement.SetComment(comment); err != nil {
+			return err
+		}
+	}
+
+	for i, mappingValue := range root.Values {
+		if mappingValue == buildPathsNode {
+			root.Values[i] = replacement
+		}
+	}
+
+	return nil
+}
+
+func parseBuildPaths(entries []string) (*ast.File, error) {
+	return parser.ParseBytes([]byte(buildPathsSnippet(entries)), 0)
+}
+
+func buildPathsSnippet(entries []string) string {
+	snippet := &strings.Builder{}
+	snippet.WriteString(buildPathsKey + ":\n")
+	for _, entry := range entries {
+		snippet.WriteString("  - " + entry + "\n")
+	}
+
+	return snippet.String()
+}
@@ -0,0 +1,168 @@
+package lambgofile_test
+
+import (
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+)
+
+func TestAddBuildPath(t *testing.T) {
+	ensure := ensure.New(t)
+
+	table := []struct {
+		Name      string
+		Data      string
+		BuildPath string
+
+		ExpectedData  string
+		ExpectedError error
+	}{
+		{
+			Name:      "with block sequence",
+			BuildPath: "lambdas/orders/create",
+			Data: `# Root comment
+outDirectory: tmp # Trailing comment
+
+# Paths to build
+buildPaths:
+  - lambdas/hello_world # Some Lambda
+
+# After comment
+goarch: arm64
+`,
+			ExpectedData: `# Root comment
+outDirectory: tmp # Trailing comment
+
+# Paths to build
+buildPaths:
+  - lambdas/hello_world # Some Lambda
+  - lambdas/orders/create
+
+# After comment
+goarch: arm64
+`,
+		},
+
+		{
+			Name:      "with empty flow sequence",
+			BuildPath: "lambdas/orders/create",
+			Data: `outDirectory: tmp
+
+# Paths to build
+buildPaths: []
+
+# After comment
+goarch: arm64
+`,
+			ExpectedData: `outDirectory: tmp
+
+# Paths to build
+buildPaths:
+  - lambdas/orders/create
+
+# After comment
+goarch: arm64
+`,
+		},
+
+		{
+			Name:      "with non-empty flow sequence",
+			BuildPath: "lambdas/orders/create",
+			Data: `buildPaths: [lambdas/a, "lambdas/b"]
+goarch: arm64
+`,
+			ExpectedData: `buildPaths:
+  - lambdas/a
+  - "lambdas/b"
+  - lambdas/orders/create
+goarch: arm64
+`,
+		},
+
+		{
+			Name:      "with empty value",
+			BuildPath: "lambdas/orders/create",
+			Data: `buildPaths:
+goarch: arm64
+`,
+			ExpectedData: `buildPaths:
+  - lambdas/orders/create
+goarch: arm64
+`,
+		},
+
+		{
+			Name:      "without buildPaths",
+			BuildPath: "lambdas/orders/create",
+			Data: `# Root comment
+outDirectory: tmp
+
+# Lambdas
+lambdas:
+  - path: lambdas/api
+`,
+			ExpectedData: `# Root comment
+outDirectory: tmp
+
+# Lambdas
+lambdas:
+  - path: lambdas/api
+buildPaths:
+  - lambdas/orders/create
+`,
+		},
+
+		{
+			Name:         "with empty file",
+			BuildPath:    "lambdas/orders/create",
+			Data:         "",
+			ExpectedData: "buildPaths:\n  - lambdas/orders/create\n",
+		},
+
+		{
+			Name:         "with only comments",
+			BuildPath:    "lambdas/orders/create",
+			Data:         "# Some comment\n",
+			ExpectedData: "# Some comment\nbuildPaths:\n  - lambdas/orders/create\n",
+		},
+
+		{
+			Name:      "with path that needs quoting",
+			BuildPath: "lambdas/#orders",
+			Data: `buildPaths:
+  - lambdas/a
+`,
+			ExpectedData: `buildPaths:
+  - lambdas/a
+  - "lambdas/#orders"
+`,
+		},
+
+		{
+			Name:          "with invalid YAML",
+			BuildPath:     "lambdas/orders/create",
+			Data:          "buildPaths: [",
+			ExpectedError: lambgofile.ErrCannotParseConfig,
+		},
+
+		{
+			Name:          "with non-mapping YAML",
+			BuildPath:     "lambdas/orders/create",
+			Data:          "- lambdas/a\n",
+			ExpectedError: lambgofile.ErrUnexpectedConfigShape,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		data, err := lambgofile.AddBuildPath([]byte(entry.Data), entry.BuildPath)
+		ensure(err).IsError(entry.ExpectedError)
+
+		if entry.ExpectedError == nil {
+			ensure(string(data)).Equals(entry.ExpectedData)
+		}
+	})
+}
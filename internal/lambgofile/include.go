@@ -0,0 +1,252 @@
+package lambgofile
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+)
+
+const (
+	includeKey = "include"
+
+	// IncludedFileName is the name of the config file in each directory listed by include.
+	IncludedFileName = "lambgo.yml"
+)
+
+// includedFileKeys are the keys that are supported in included files.
+//
+//nolint:gochecknoglobals // Constant list of keys
+var includedFileKeys = []string{includeKey, "buildPaths", "lambdas", "extensions", "layers", "routes"}
+
+// includer merges included files into a document.
+type includer struct {
+	doc *mergedDocument
+
+	// readFile relative to the module root.
+	readFile func(name string) ([]byte, error)
+
+	// owners are the files that define each Lambda path and layer name, for reporting duplicates.
+	owners map[string]string
+
+	visited map[string]struct{}
+}
+
+// includeFiles listed by the document, and the files they include.
+// Paths in included files are relative to their directory, and are rewritten to be relative to the module root.
+// Their entries are appended to the document, after the entries of the file that includes them.
+func (doc *mergedDocument) includeFiles(readFile func(name string) ([]byte, error)) error {
+	inc := &includer{
+		doc:      doc,
+		readFile: readFile,
+		owners:   make(map[string]string),
+		visited:  make(map[string]struct{}),
+	}
+
+	// Duplicates within .lambgo.yml are reported after merging, like they are without includes
+	_ = forEachEntryKey(doc.data, func(key string) error {
+		if _, exists := inc.owners[key]; !exists {
+			inc.owners[key] = ConfigFileName
+		}
+
+		return nil
+	})
+
+	return inc.include(doc.data, ConfigFileName, []string{ConfigFileName})
+}
+
+func (inc *includer) include(data map[string]any, filePath string, stack []string) error {
+	rawIncludes, ok := data[includeKey]
+	if !ok {
+		return nil
+	}
+	delete(data, includeKey)
+
+	includes, ok := rawIncludes.([]any)
+	if !ok {
+		return erk.WithParams(ErrInvalidInclude, erk.Params{"include": rawIncludes, "path": filePath})
+	}
+
+	for _, rawInclude := range includes {
+		includedPath, err := includedFilePath(rawInclude, filePath)
+		if err != nil {
+			return err
+		}
+
+		if slices.Contains(stack, includedPath) {
+			return erk.WithParams(ErrIncludeCycle, erk.Params{"cycle": strings.Join(append(stack, includedPath), " -> ")})
+		}
+
+		// Files that are included more than once, such as shared files, are only merged the first time
+		if _, visited := inc.visited[includedPath]; visited {
+			continue
+		}
+		inc.visited[includedPath] = struct{}{}
+
+		fileData, err := inc.readFile(includedPath)
+		if err != nil {
+			return erk.WrapWith(ErrCannotReadInclude, err, erk.Params{"path": includedPath, "includedBy": filePath})
+		}
+
+		fragment, err := (&configFile{path: includedPath, data: fileData}).document()
+		if err != nil {
+			return err
+		}
+
+		for key := range fragment {
+			if !slices.Contains(includedFileKeys, key) {
+				return erk.WithParams(ErrUnsupportedIncludedKey, erk.Params{"key": key, "path": includedPath})
+			}
+		}
+
+		rebaseFragment(fragment, path.Dir(includedPath))
+		if err := inc.appendEntries(fragment, includedPath); err != nil {
+			return err
+		}
+
+		if err := inc.include(fragment, includedPath, append(slices.Clone(stack), includedPath)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// includedFilePath of the include entry, relative to the module root.
+func includedFilePath(rawInclude any, includedBy string) (string, error) {
+	include, ok := rawInclude.(string)
+	if !ok || include == "" || path.IsAbs(include) {
+		return "", erk.WithParams(ErrInvalidInclude, erk.Params{"include": rawInclude, "path": includedBy})
+	}
+
+	includedPath := path.Join(path.Dir(includedBy), include, IncludedFileName)
+	if strings.HasPrefix(includedPath, "../") {
+		return "", erk.WithParams(ErrInvalidInclude, erk.Params{"include": rawInclude, "path": includedBy})
+	}
+
+	return includedPath, nil
+}
+
+// appendEntries of the included file to the document.
+func (inc *includer) appendEntries(fragment map[string]any, filePath string) error {
+	err := forEachEntryKey(fragment, func(key string) error {
+		if owner, exists := inc.owners[key]; exists && owner != filePath {
+			kind, name, _ := strings.Cut(key, ":")
+			return erk.WithParams(ErrDuplicateIncludedEntry, erk.Params{"kind": kind, "name": name, "first": owner, "second": filePath})
+		}
+
+		inc.owners[key] = filePath
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range includedFileKeys {
+		if key == includeKey {
+			continue
+		}
+
+		rawEntries, ok := fragment[key]
+		if !ok || rawEntries == nil {
+			continue
+		}
+
+		entries, ok := rawEntries.([]any)
+		if !ok {
+			return erk.WithParams(ErrInvalidIncludedList, erk.Params{"key": key, "path": filePath})
+		}
+
+		existing, _ := inc.doc.data[key].([]any)
+		for _, entry := range entries {
+			existing = append(existing, entry)
+			inc.doc.markSet(indexKey(key, len(existing)-1), entry, filePath)
+		}
+		inc.doc.data[key] = existing
+	}
+
+	inc.doc.includedFiles = append(inc.doc.includedFiles, filePath)
+	return nil
+}
+
+// forEachEntryKey calls fn with the key of each Lambda path (eg. Lambda path:lambdas/api) and layer name (eg. layer name:tools).
+// Lambdas and extensions share the same paths, since they are built to the same directory.
+func forEachEntryKey(data map[string]any, fn func(key string) error) error {
+	for _, listKey := range []string{"buildPaths", "lambdas", "extensions", "layers"} {
+		kind := "Lambda path"
+		if listKey == "layers" {
+			kind = "layer name"
+		}
+
+		entryKey := listEntryKey(listKey)
+		entries, _ := data[listKey].([]any)
+		for _, entry := range entries {
+			if name := entryKey(entry); name != "" {
+				if err := fn(kind + ":" + name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// rebaseFragment rewrites the paths in the included file to be relative to the module root, instead of dir.
+func rebaseFragment(fragment map[string]any, dir string) {
+	rebase := func(value any) any {
+		if relativePath, ok := value.(string); ok && relativePath != "" {
+			return path.Join(dir, relativePath)
+		}
+
+		return value
+	}
+
+	rebaseField := func(entry any, field string) {
+		if fields, ok := entry.(map[string]any); ok {
+			if _, exists := fields[field]; exists {
+				fields[field] = rebase(fields[field])
+			}
+		}
+	}
+
+	buildPaths, _ := fragment["buildPaths"].([]any)
+	for i, buildPath := range buildPaths {
+		buildPaths[i] = rebase(buildPath)
+	}
+
+	// Paths of entries with a module are relative to the module, instead of the file
+	rebasePath := func(entry any) {
+		if entryField(entry, "module") == "" {
+			rebaseField(entry, "path")
+		}
+	}
+
+	for _, key := range []string{"lambdas", "extensions"} {
+		entries, _ := fragment[key].([]any)
+		for _, entry := range entries {
+			rebasePath(entry)
+		}
+	}
+
+	layers, _ := fragment["layers"].([]any)
+	for _, layer := range layers {
+		fields, _ := layer.(map[string]any)
+
+		packages, _ := fields["packages"].([]any)
+		for _, pkg := range packages {
+			rebasePath(pkg)
+		}
+
+		files, _ := fields["files"].([]any)
+		for _, layerFiles := range files {
+			rebaseField(layerFiles, "glob")
+		}
+	}
+
+	routes, _ := fragment["routes"].([]any)
+	for _, route := range routes {
+		rebaseField(route, "lambda")
+	}
+}
@@ -3,13 +3,16 @@ package lambgofile
 
 import (
 	"errors"
+	"go/token"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/JosiahWitt/erk"
-	"github.com/goccy/go-yaml"
+	"github.com/JosiahWitt/lambgo/internal/licenses"
 	"golang.org/x/mod/modfile"
 	"mvdan.cc/sh/v3/shell"
 )
@@ -30,13 +33,55 @@ outDirectory: tmp
 # This serves as the default for all lambdas unless overridden per-lambda.
 # buildFlags: -tags extra,tags -ldflags="-linker -flags"
 
+# Structured options passed to "go build", which are passed before buildFlags.
+# Each Lambda can set buildOptions, which are merged onto these: tags are combined, ldflags and extraFlags are appended,
+# and the other options of the Lambda replace these.
+# Supports environment variable expansion: $VAR or ${VAR}.
+# Optional, only -trimpath is used by default.
+# buildOptions:
+#   tags: [prod, lambda] # Optional, build tags passed to -tags, which are combined with the last -tags in extraFlags and buildFlags
+#   ldflags: -s -w # Optional, linker flags passed to -ldflags, which are combined with the last matching -ldflags in extraFlags or buildFlags
+#   gcflags: all=-N -l # Optional, compiler flags passed to -gcflags
+#   trimpath: true # Optional, removes file system paths from the binary. Defaults to true
+#   race: false # Optional, enables the race detector. Defaults to false
+#   mod: readonly # Optional, passed to -mod. Either readonly, vendor, or mod
+#   extraFlags: -v -p 4 # Optional, other flags passed to "go build", which are split like buildFlags
+
 # Allow overriding the GOOS and GOARCH environment variables to
 # cross compile for a different operating system or architecture.
 # Optional, defaults to GOOS=linux and GOARCH=amd64.
 # goos: linux
 # goarch: amd64
 
-# Option 1: Simple paths
+# Write each Lambda as an OCI container image, in addition to the zip.
+# Useful for Lambdas that exceed the zip size limits. Does not require Docker.
+# The image only contains the binary, and uses goos and goarch for its platform.
+# Optional, images are not written by default.
+# image:
+#   format: layout # Either layout (<outDirectory>/<path>.oci/) or tarball (<outDirectory>/<path>.oci.tar)
+#   binaryPath: /var/runtime/bootstrap # Optional, defaults to /var/runtime/bootstrap
+
+# Write an SBOM (software bill of materials) next to the zip of each Lambda, extension, and layer.
+# It lists the main module, dependencies, Go version, and build settings embedded in each binary.
+# Optional, SBOMs are not written by default.
+# sbom:
+#   format: spdx # Either spdx (<outDirectory>/<path>.spdx.json) or cyclonedx (<outDirectory>/<path>.cdx.json)
+
+# Collect the licenses of the third-party modules built into each Lambda, extension, and layer from the module cache.
+# The license files are classified by their text (eg. MIT, Apache-2.0, or BSD-3-Clause), or Unknown if they are not recognized.
+# Optional, licenses are not collected by default.
+# licenses:
+#   bundle: true # Optional, adds a THIRD_PARTY_LICENSES file to the root of each zip. Defaults to false
+#   disallowed: [GPL-3.0, AGPL-3.0] # Optional, licenses that fail the build, which can include Unknown and None
+
+# Sign each zip with a local private key, and write a detached signature next to it (<outDirectory>/<path>.zip.sig).
+# The signature and the SHA-256 digest of the zip are recorded in the manifest, and can be checked with "lambgo verify".
+# Optional, zips are not signed by default.
+# signing:
+#   keyFile: $LAMBGO_SIGNING_KEY # PEM file containing an Ed25519 or ECDSA private key, relative to the module root. Supports environment variable expansion
+#   publicKeyFile: keys/signing.pub.pem # Optional, PEM file containing the public key used by "lambgo verify". Defaults to the public key of keyFile
+
+# Option 1: Simple paths.
 # Paths to build into Lambda zip files.
 # Each path should contain a main package.
 # The artifacts are built to: <outDirectory>/<buildPath>.zip
@@ -45,19 +90,134 @@ buildPaths:
 
 # Option 2: Per-lambda configuration with custom build flags.
 lambdas:
-  - path: lambdas/api
-    buildFlags: -tags prod -ldflags="-s -w"
+  - path: lambdas/api # Path of a main package, relative to the module root
+    buildFlags: -tags prod -ldflags="-s -w" # Optional, inherits top-level buildFlags if not specified
   - path: lambdas/worker
     buildFlags: "" # Forces no flags, even if some are defined on the top-level option
   - path: lambdas/simple
     # Inherits top-level buildFlags if not specified
+    # module: services/search # Optional, a go.work module (directory or module path) that the path is relative to
+    # buildOptions: # Optional, merged onto the top-level buildOptions
+    #   tags: [api]
 
 # Both buildPaths and lambdas can be used together, but duplicate paths will result in an error.
+# When .lambgo.yml is next to a go.work file, paths can be in any module used by the workspace.
+
+# Go variables to set with -ldflags="-X importpath.name=value" when building, using git metadata and the build time.
+# Each value is the import path and name of a string variable, such as main.Version.
+# The variables are added to the last -ldflags in buildFlags that matches the Lambda (eg. -s or all=-w), instead of replacing it.
+# Optional, no variables are set by default.
+# versionInjection:
+#   commit: main.Commit # Optional, the full hash of the git commit
+#   dirty: main.Dirty # Optional, true when there are uncommitted changes, otherwise false
+#   tag: main.Version # Optional, the nearest tag from "git describe --tags --always" (eg. v1.2.3 or v1.2.3-4-gabc1234)
+#   buildTime: main.BuildTime # Optional, the build time in RFC 3339 format, which uses SOURCE_DATE_EPOCH when it is set
+#   lambdaPath: main.LambdaPath # Optional, the path of the Lambda (eg. lambdas/api)
+
+# Lambda extensions to build and package as layers.
+# Each path should contain a main package, which is built like a Lambda.
+# The binary is zipped to extensions/<name>, which is where Lambda looks for external extensions.
+# The artifacts are built to: <outDirectory>/<path>.zip
+# extensions:
+#   - path: extensions/telemetry # Path of a main package, relative to the module root
+#     name: telemetry # Optional, defaults to the name of the extension's directory
+#     buildFlags: -tags prod # Optional, inherits top-level buildFlags if not specified
+#     module: services/search # Optional, a go.work module (directory or module path) that the path is relative to
+#     buildOptions: # Optional, merged onto the top-level buildOptions
+#       ldflags: -X main.Extension=telemetry
+
+# Lambda layers to build from main packages and data files.
+# Layers are extracted to /opt, so destinations are relative to /opt.
+# The artifacts are built to: <outDirectory>/layers/<name>.zip
+# layers:
+#   - name: shared-tools # Name of the layer, which is used for its zip file
+#     packages: # Main packages to build into the layer
+#       - path: tools/converter # Path of a main package, relative to the module root
+#         destination: bin/ # Optional, defaults to bin/
+#         buildFlags: -tags prod # Optional, inherits top-level buildFlags if not specified
+#         module: services/search # Optional, a go.work module (directory or module path) that the path is relative to
+#         buildOptions: # Optional, merged onto the top-level buildOptions
+#           race: false
+#     files: # Data files to add to the layer
+#       - glob: assets/*.json # Files to add to the layer, relative to (and within) the module root
+#         destination: lib/data/ # Optional, defaults to the root of the layer
+
+# Directory containing templates for "lambgo new", relative to the module root.
+# Each subdirectory is a template, whose files are rendered with Go's text/template.
+# A template overrides the built-in template with the same name.
+# Optional, only the built-in templates are available by default.
+# templatesDirectory: templates
+
+# Routes served by "lambgo serve", which maps local HTTP requests to Lambdas.
+# Paths use API Gateway syntax: {name} matches one segment, and {name+} matches the rest of the path.
+# routes:
+#   - path: /orders/{id} # Path of the HTTP request
+#     method: GET # Optional, defaults to any method
+#     lambda: lambdas/api # Must be one of the Lambdas above
+#     event: apigateway-v2 # Optional, either apigateway (REST API), apigateway-v2 (HTTP API), or function-url. Defaults to apigateway-v2
+
+# Directories containing a lambgo.yml file to include, relative to this file.
+# Useful in monorepos, so each service can define its own Lambdas next to its code.
+# Included files can only contain include, buildPaths, lambdas, extensions, layers, and routes,
+# and their paths are relative to their own directory.
+# Optional, no files are included by default.
+# include:
+#   - services/billing
+
+# Variants of this file, selected with "lambgo --profile <name>" or the LAMBGO_PROFILE environment variable.
+# The selected profile is deep-merged onto the rest of this file, and .lambgo.local.yml is merged after it.
+# Lists of buildPaths, lambdas, extensions, layers, layer packages, and routes are merged by path, name, or method and path.
+# Other lists, such as the files of a layer, are replaced.
+# Optional, no profile is applied by default.
+# profiles:
+#   prod:
+#     goarch: arm64
+#     buildFlags: -tags prod -ldflags="-s -w"
+#     lambdas:
+#       - path: lambdas/api
+#         buildFlags: -tags prod,api
 `
 
 const (
-	gomodFileName  = "go.mod"
-	configFileName = ".lambgo.yml"
+	gomodFileName = "go.mod"
+
+	// ConfigFileName is the name of the config file, which is located in the module root.
+	ConfigFileName = ".lambgo.yml"
+
+	// LocalConfigFileName is the name of the optional config file that is merged onto the config file.
+	// It is located in the module root, and is meant to be ignored by git.
+	LocalConfigFileName = ".lambgo.local.yml"
+
+	// DefaultOutDirectory is used for build artifacts when outDirectory is not set.
+	DefaultOutDirectory = "tmp"
+
+	// LayersDirectory within the outDirectory where layers are built.
+	LayersDirectory = "layers"
+
+	defaultLayerPackageDestination = "bin/"
+	defaultImageBinaryPath         = "/var/runtime/bootstrap"
+)
+
+type ImageFormat string
+
+const (
+	ImageFormatLayout  ImageFormat = "layout"
+	ImageFormatTarball ImageFormat = "tarball"
+)
+
+type SBOMFormat string
+
+const (
+	SBOMFormatSPDX      SBOMFormat = "spdx"
+	SBOMFormatCycloneDX SBOMFormat = "cyclonedx"
+)
+
+type EventFormat string
+
+const (
+	EventFormatAPIGateway   EventFormat = "apigateway"
+	EventFormatAPIGatewayV2 EventFormat = "apigateway-v2"
+	EventFormatFunctionURL  EventFormat = "function-url"
 )
 
 type ErkCannotLoadConfig struct{ erk.DefaultKind }
@@ -71,10 +231,82 @@ var (
 	ErrCannotParsePerLambdaFlags = erk.New(ErkCannotLoadConfig{}, "Cannot parse build flags for lambda '{{.path}}' with flags '{{.flags}}': {{.err}}")
 	ErrDuplicatePaths            = erk.New(ErkCannotLoadConfig{}, "Duplicate lambda paths found: {{.paths}}")
 	ErrEmptyLambdaPath           = erk.New(ErkCannotLoadConfig{}, "Lambda has an empty path")
+	ErrEmptyLayerName            = erk.New(ErkCannotLoadConfig{}, "Layer has an empty name")
+	ErrInvalidLayerName          = erk.New(ErkCannotLoadConfig{}, "Layer name '{{.name}}' cannot contain path separators")
+	ErrInvalidExtensionName      = erk.New(ErkCannotLoadConfig{},
+		"Extension name '{{.name}}' for '{{.path}}' cannot contain path separators, since it is the file name within the extensions/ directory",
+	)
+	ErrDuplicateLayerNames     = erk.New(ErkCannotLoadConfig{}, "Duplicate layer names found: {{.names}}")
+	ErrEmptyLayerGlob          = erk.New(ErkCannotLoadConfig{}, "Layer '{{.name}}' has files with an empty glob")
+	ErrInvalidLayerGlob        = erk.New(ErkCannotLoadConfig{}, "Layer '{{.name}}' has an invalid glob '{{.glob}}', since it must be within the module root")
+	ErrInvalidLayerDestination = erk.New(ErkCannotLoadConfig{},
+		"Layer '{{.name}}' has an invalid destination '{{.destination}}', since it must be a relative path within the layer",
+	)
+	ErrInvalidImageFormat     = erk.New(ErkCannotLoadConfig{}, "Invalid image format '{{.format}}'. Only `layout` or `tarball` are supported.")
+	ErrInvalidImageBinaryPath = erk.New(ErkCannotLoadConfig{}, "Invalid image binaryPath '{{.binaryPath}}', since it must be an absolute path")
+	ErrInvalidSBOMFormat      = erk.New(ErkCannotLoadConfig{}, "Invalid sbom format '{{.format}}'. Only `spdx` or `cyclonedx` are supported.")
+	ErrInvalidLicense         = erk.New(ErkCannotLoadConfig{}, "Invalid disallowed license '{{.license}}'. Supported licenses: {{.licenses}}")
+	ErrMissingSigningKey      = erk.New(ErkCannotLoadConfig{}, "Missing signing keyFile. It is required when signing is set.")
+	ErrInvalidSigningKeyFile  = erk.New(ErkCannotLoadConfig{}, "Invalid signing key file '{{.keyFile}}': {{.err}}")
+	ErrInvalidRoutePath       = erk.New(ErkCannotLoadConfig{},
+		"Invalid route path '{{.path}}'. Paths must start with /, and can contain {name} parameters, or a {name+} parameter as the last segment",
+	)
+	ErrUnknownRouteLambda = erk.New(ErkCannotLoadConfig{}, "Route '{{.path}}' uses the Lambda '{{.lambda}}', which is not in buildPaths or lambdas")
+	ErrInvalidRouteEvent  = erk.New(ErkCannotLoadConfig{},
+		"Invalid event '{{.event}}' for route '{{.path}}'. Only `apigateway`, `apigateway-v2`, or `function-url` are supported.",
+	)
+	ErrDuplicateRoutes = erk.New(ErkCannotLoadConfig{}, "Duplicate routes found: {{.routes}}")
+	ErrUnknownProfile  = erk.New(ErkCannotLoadConfig{}, "Cannot find the profile '{{.profile}}' in .lambgo.yml. Available profiles: {{.profiles}}")
+	ErrInvalidProfile  = erk.New(ErkCannotLoadConfig{}, "Invalid profile '{{.profile}}', since it must be a mapping of .lambgo.yml keys")
+
+	ErrUnexpectedProfiles = erk.New(ErkCannotLoadConfig{},
+		"Unexpected profiles in {{.source}}, since profiles can only be defined at the top level of .lambgo.yml",
+	)
+	ErrCannotMergeFiles = erk.New(ErkCannotLoadConfig{}, "Cannot parse the config merged from {{.sources}}: {{.err}}")
+
+	ErrInvalidInclude = erk.New(ErkCannotLoadConfig{},
+		"Invalid include '{{.include}}' in {{.path}}. Includes must be a list of directories within the module, relative to the file",
+	)
+	ErrCannotReadInclude      = erk.New(ErkCannotLoadConfig{}, "Cannot read '{{.path}}', which is included by {{.includedBy}}: {{.err}}")
+	ErrIncludeCycle           = erk.New(ErkCannotLoadConfig{}, "Included files form a cycle: {{.cycle}}")
+	ErrDuplicateIncludedEntry = erk.New(ErkCannotLoadConfig{}, "Duplicate {{.kind}} '{{.name}}' found in {{.first}} and {{.second}}")
+	ErrInvalidIncludedList    = erk.New(ErkCannotLoadConfig{}, "Invalid '{{.key}}' in {{.path}}, since it must be a list")
+	ErrUnsupportedIncludedKey = erk.New(ErkCannotLoadConfig{},
+		"Unsupported key '{{.key}}' in {{.path}}. Included files can only contain include, buildPaths, lambdas, extensions, layers, and routes",
+	)
+	ErrUnexpectedInclude = erk.New(ErkCannotLoadConfig{},
+		"Unexpected include in {{.source}}, since files can only be included by .lambgo.yml and the files it includes",
+	)
+
+	ErrSchemaViolations = erk.New(ErkCannotLoadConfig{}, "The config does not match the schema:{{.problems}}")
+
+	ErrCannotParseBuildOptions          = erk.New(ErkCannotLoadConfig{}, "Cannot parse buildOptions '{{.options}}': {{.err}}")
+	ErrCannotParsePerLambdaBuildOptions = erk.New(ErkCannotLoadConfig{},
+		"Cannot parse buildOptions for lambda '{{.path}}' with options '{{.options}}': {{.err}}",
+	)
+	ErrInvalidBuildMod = erk.New(ErkCannotLoadConfig{}, "Invalid buildOptions mod '{{.mod}}'. Only `readonly`, `vendor`, or `mod` are supported.")
+
+	ErrInvalidVersionVariable = erk.New(ErkCannotLoadConfig{},
+		"Invalid versionInjection.{{.key}} '{{.variable}}', since it must be the import path and name of a string variable, such as main.Version",
+	)
+
+	ErrCannotParseGoWork      = erk.New(ErkCannotLoadConfig{}, "Cannot parse the go.work file '{{.path}}': {{.err}}")
+	ErrModuleWithoutWorkspace = erk.New(ErkCannotLoadConfig{},
+		"Lambda '{{.path}}' sets the module '{{.module}}', but modules can only be set when .lambgo.yml is next to a go.work file",
+	)
+	ErrUnknownWorkspaceModule = erk.New(ErkCannotLoadConfig{},
+		"Lambda '{{.path}}' uses the module '{{.module}}', which is not used by go.work. Available modules: {{.modules}}",
+	)
+	ErrPathOutsideWorkspaceModules = erk.New(ErkCannotLoadConfig{},
+		"Lambda '{{.path}}' is not in a module used by go.work. Available modules: {{.modules}}",
+	)
 )
 
 type LoaderAPI interface {
-	LoadConfig(pwd string) (*Config, error)
+	LoadConfig(pwd, profile string) (*Config, error)
+	ExplainConfig(pwd, profile string) (*Config, *Provenance, error)
+	ValidateConfig(pwd, profile string) (*Config, error)
+	FindRoot(pwd string) (*Module, error)
 }
 
 // Loader allows loading the project's .lambgo.yml file.
@@ -87,19 +319,97 @@ var _ LoaderAPI = &Loader{}
 // rawConfig is the internal struct used for unmarshaling from .lambgo.yml.
 // It contains all the YAML tags and raw fields that need processing.
 type rawConfig struct {
-	OutDirectory   string       `yaml:"outDirectory"`
-	ZippedFileName string       `yaml:"zippedFileName"`
-	RawBuildFlags  string       `yaml:"buildFlags"`
-	Goos           string       `yaml:"goos"`
-	Goarch         string       `yaml:"goarch"`
-	BuildPaths     []string     `yaml:"buildPaths"`
-	RawLambdas     []*rawLambda `yaml:"lambdas"`
+	OutDirectory   string          `yaml:"outDirectory"`
+	ZippedFileName string          `yaml:"zippedFileName"`
+	RawBuildFlags  string          `yaml:"buildFlags"`
+	Goos           string          `yaml:"goos"`
+	Goarch         string          `yaml:"goarch"`
+	BuildPaths     []string        `yaml:"buildPaths"`
+	RawLambdas     []*rawLambda    `yaml:"lambdas"`
+	RawExtensions  []*rawExtension `yaml:"extensions"`
+	RawLayers      []*rawLayer     `yaml:"layers"`
+	RawImage       *rawImage       `yaml:"image"`
+	RawSBOM        *rawSBOM        `yaml:"sbom"`
+	RawLicenses    *rawLicenses    `yaml:"licenses"`
+	RawSigning     *rawSigning     `yaml:"signing"`
+	RawRoutes      []*rawRoute     `yaml:"routes"`
+	Include        []string        `yaml:"include"`
+
+	RawBuildOptions     *rawBuildOptions     `yaml:"buildOptions"`
+	RawVersionInjection *rawVersionInjection `yaml:"versionInjection"`
+
+	TemplatesDirectory string `yaml:"templatesDirectory"`
 }
 
 // rawLambda is the internal struct used for unmarshaling lambda configurations.
 type rawLambda struct {
-	Path          string  `yaml:"path"`
-	RawBuildFlags *string `yaml:"buildFlags,omitempty"`
+	Path            string           `yaml:"path"`
+	Module          string           `yaml:"module"`
+	RawBuildFlags   *string          `yaml:"buildFlags,omitempty"`
+	RawBuildOptions *rawBuildOptions `yaml:"buildOptions"`
+}
+
+// rawExtension is the internal struct used for unmarshaling extension configurations.
+type rawExtension struct {
+	Path            string           `yaml:"path"`
+	Module          string           `yaml:"module"`
+	Name            string           `yaml:"name"`
+	RawBuildFlags   *string          `yaml:"buildFlags,omitempty"`
+	RawBuildOptions *rawBuildOptions `yaml:"buildOptions"`
+}
+
+// rawLayer is the internal struct used for unmarshaling layer configurations.
+type rawLayer struct {
+	Name     string             `yaml:"name"`
+	Packages []*rawLayerPackage `yaml:"packages"`
+	Files    []*rawLayerFiles   `yaml:"files"`
+}
+
+type rawLayerPackage struct {
+	Path            string           `yaml:"path"`
+	Module          string           `yaml:"module"`
+	Destination     string           `yaml:"destination"`
+	RawBuildFlags   *string          `yaml:"buildFlags,omitempty"`
+	RawBuildOptions *rawBuildOptions `yaml:"buildOptions"`
+}
+
+type rawLayerFiles struct {
+	Glob        string `yaml:"glob"`
+	Destination string `yaml:"destination"`
+}
+
+type rawImage struct {
+	Format     string `yaml:"format"`
+	BinaryPath string `yaml:"binaryPath"`
+}
+
+type rawSBOM struct {
+	Format string `yaml:"format"`
+}
+
+type rawLicenses struct {
+	Bundle     bool     `yaml:"bundle"`
+	Disallowed []string `yaml:"disallowed"`
+}
+
+type rawSigning struct {
+	KeyFile       string `yaml:"keyFile"`
+	PublicKeyFile string `yaml:"publicKeyFile"`
+}
+
+type rawVersionInjection struct {
+	Commit     string `yaml:"commit"`
+	Dirty      string `yaml:"dirty"`
+	Tag        string `yaml:"tag"`
+	BuildTime  string `yaml:"buildTime"`
+	LambdaPath string `yaml:"lambdaPath"`
+}
+
+type rawRoute struct {
+	Path   string `yaml:"path"`
+	Method string `yaml:"method"`
+	Lambda string `yaml:"lambda"`
+	Event  string `yaml:"event"`
 }
 
 // Config is the root configuration after processing .lambgo.yml.
@@ -112,16 +422,205 @@ type Config struct {
 	Goos           string
 	Goarch         string
 	Lambdas        []*Lambda
+	Extensions     []*Extension
+	Layers         []*Layer
+	Image          *Image
+	SBOM           *SBOM
+	Licenses       *Licenses
+	Signing        *Signing
+	Routes         []*Route
+
+	// TemplatesDirectory contains user templates for `lambgo new`, relative to RootPath.
+	TemplatesDirectory string
+
+	// IncludedFiles are the files included by .lambgo.yml, relative to RootPath.
+	IncludedFiles []string
+
+	// VersionInjection sets Go variables when building, or is nil when no variables are set.
+	VersionInjection *VersionInjection
+
+	// GoCache is used for GOCACHE when building, or is empty to use the default build cache.
+	// It is not set by .lambgo.yml.
+	GoCache string
+
+	// GroupBuilds builds the Lambdas sharing a module, output directory, and build flags with one `go build` command.
+	// It is not set by .lambgo.yml.
+	GroupBuilds bool
+
+	// Workspace is set when .lambgo.yml is next to a go.work file.
+	// ModulePath is then the module at RootPath, which is empty if go.work does not use it.
+	Workspace *Workspace
 }
 
 // Lambda represents a single lambda function with its build configuration.
 type Lambda struct {
 	Path       string
 	BuildFlags []string
+
+	// BuildOptions are merged from the top-level and per-lambda buildOptions, or nil when neither is set.
+	BuildOptions *BuildOptions
+
+	// Module is the directory of the workspace module containing the Lambda, relative to RootPath.
+	// It is empty when the config is not in a workspace.
+	Module string
+}
+
+// Extension represents a Lambda extension, which is built like a Lambda but
+// zipped into the extensions directory of a layer.
+type Extension struct {
+	Lambda
+
+	Name string
+}
+
+// Layer represents a Lambda layer, which contains built binaries and data files.
+type Layer struct {
+	Name     string
+	Packages []*LayerPackage
+	Files    []*LayerFiles
+}
+
+// LayerPackage is a main package that is built and added to a layer.
+type LayerPackage struct {
+	Lambda
+
+	// Destination is the directory within the layer, with a trailing slash.
+	// It is empty for the root of the layer.
+	Destination string
+}
+
+// LayerFiles are the files matching a glob that are added to a layer.
+type LayerFiles struct {
+	// Glob is relative to the root of the module.
+	Glob string
+
+	// Destination is the directory within the layer, with a trailing slash.
+	// It is empty for the root of the layer.
+	Destination string
+}
+
+// Image configures writing Lambdas as OCI container images.
+type Image struct {
+	Format     ImageFormat
+	BinaryPath string
+}
+
+// SBOM configures writing software bills of materials for the built artifacts.
+type SBOM struct {
+	Format SBOMFormat
+}
+
+// Licenses configures collecting the licenses of the third-party modules built into each artifact.
+type Licenses struct {
+	// Bundle a THIRD_PARTY_LICENSES file into each zip.
+	Bundle bool
+
+	// Disallowed licenses, which fail the build when any module uses them.
+	Disallowed []string
+}
+
+// Signing configures signing each zip with a local private key.
+type Signing struct {
+	// KeyFile is a PEM file containing the private key, relative to RootPath.
+	// It is empty when its environment variables are not set, so the config can still be verified with PublicKeyFile.
+	KeyFile string
+
+	// PublicKeyFile is a PEM file containing the public key for verifying, relative to RootPath.
+	// It is empty when the public key of KeyFile is used.
+	PublicKeyFile string
+}
+
+// VersionInjection is the Go variables to set with -ldflags -X when building.
+// Each field is the import path and name of a variable (eg. main.Version), or empty if it is not set.
+type VersionInjection struct {
+	Commit     string
+	Dirty      string
+	Tag        string
+	BuildTime  string
+	LambdaPath string
+}
+
+// Route maps local HTTP requests to a Lambda for `lambgo serve`.
+type Route struct {
+	// Path in API Gateway syntax, such as /orders/{id} or /files/{proxy+}.
+	Path string
+
+	// Method is uppercase, or empty to match any method.
+	Method string
+
+	Lambda *Lambda
+	Event  EventFormat
+}
+
+// Path of the layer relative to the outDirectory, without the .zip extension.
+func (layer *Layer) Path() string {
+	return path.Join(LayersDirectory, layer.Name)
+}
+
+// Module is the Go module containing a .lambgo.yml file.
+type Module struct {
+	RootPath   string
+	ModulePath string
+
+	// Workspace is set when the module is the directory of a go.work file.
+	Workspace *Workspace
 }
 
 // LoadConfig from the .lambgo.yml file that is located in pwd or a parent of pwd.
-func (l *Loader) LoadConfig(pwd string) (*Config, error) {
+// When .lambgo.yml is next to a go.work file, the directory of go.work is the root, and Lambdas can be in any of its modules.
+// The profile from .lambgo.yml is merged onto it when it is not empty, followed by the .lambgo.local.yml file if it exists.
+func (l *Loader) LoadConfig(pwd, profile string) (*Config, error) {
+	module, err := l.FindRoot(pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	config, _, err := l.parseConfigFile(module, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// ExplainConfig loads the config like LoadConfig, and also returns the source of each resolved value.
+func (l *Loader) ExplainConfig(pwd, profile string) (*Config, *Provenance, error) {
+	module, err := l.FindRoot(pwd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return l.parseConfigFile(module, profile)
+}
+
+// ValidateConfig checks .lambgo.yml, .lambgo.local.yml, and the files they include against their schemas.
+// It then loads the config like LoadConfig, which checks the rules that the schemas cannot describe, such as duplicate paths.
+func (l *Loader) ValidateConfig(pwd, profile string) (*Config, error) {
+	module, err := l.FindRoot(pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	rootPath := strings.TrimPrefix(module.RootPath, "/")
+	problems, err := l.schemaProblems(rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(problems) > 0 {
+		return nil, erk.WithParams(ErrSchemaViolations, erk.Params{"problems": "\n  - " + strings.Join(problems, "\n  - ")})
+	}
+
+	config, _, err := l.parseConfigFile(module, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// FindModule containing pwd, by searching pwd and its parents for a go.mod file.
+func (l *Loader) FindModule(pwd string) (*Module, error) {
 	pwd = strings.TrimPrefix(pwd, "/")
 	gomodFilePath := filepath.Join(pwd, gomodFileName)
 
@@ -132,7 +631,7 @@ func (l *Loader) LoadConfig(pwd string) (*Config, error) {
 			return nil, ErrCannotFindGoModule
 		}
 
-		return l.LoadConfig(newPWD)
+		return l.FindModule(newPWD)
 	}
 
 	if err != nil {
@@ -148,108 +647,228 @@ func (l *Loader) LoadConfig(pwd string) (*Config, error) {
 		})
 	}
 
-	config, err := l.parseConfigFile(pwd, modulePath)
-	if err != nil {
-		return nil, err
-	}
-
-	return config, nil
+	return &Module{RootPath: "/" + pwd, ModulePath: modulePath}, nil
 }
 
-func (l *Loader) parseConfigFile(pwd, modulePath string) (*Config, error) {
-	configFilePath := filepath.Join(pwd, configFileName)
+func (l *Loader) parseConfigFile(module *Module, profile string) (*Config, *Provenance, error) {
+	pwd := strings.TrimPrefix(module.RootPath, "/")
+	configFilePath := filepath.Join(pwd, ConfigFileName)
 	configFileData, err := fs.ReadFile(l.FS, configFilePath)
 	if err != nil {
-		return nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+		return nil, nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
 			"path": configFilePath,
 		})
 	}
 
-	rawCfg := rawConfig{}
-	if err := yaml.Unmarshal(configFileData, &rawCfg); err != nil {
-		return nil, erk.WrapWith(ErrCannotUnmarshalFile, err, erk.Params{
-			"path": configFilePath,
+	var localFile *configFile
+	localFilePath := filepath.Join(pwd, LocalConfigFileName)
+	localFileData, err := fs.ReadFile(l.FS, localFilePath)
+	switch {
+	case err == nil:
+		localFile = &configFile{path: localFilePath, data: localFileData}
+	case !errors.Is(err, fs.ErrNotExist):
+		return nil, nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{
+			"path": localFilePath,
 		})
 	}
 
+	sources := &configSources{
+		file:      &configFile{path: configFilePath, data: configFileData},
+		localFile: localFile,
+		profile:   profile,
+		readFile: func(name string) ([]byte, error) {
+			return fs.ReadFile(l.FS, filepath.Join(pwd, name))
+		},
+	}
+
+	return parseConfig(sources, module)
+}
+
+// ParseConfig from the data of a .lambgo.yml file in the module, such as a previous version of the file.
+// The profile is merged onto it when it is not empty, followed by the data of the .lambgo.local.yml file when it is not nil.
+// Included files are read using readFile, with paths relative to the module root.
+func ParseConfig(data, localData []byte, module *Module, profile string, readFile func(name string) ([]byte, error)) (*Config, error) {
+	sources := &configSources{
+		file:     &configFile{path: filepath.Join(module.RootPath, ConfigFileName), data: data},
+		profile:  profile,
+		readFile: readFile,
+	}
+
+	if localData != nil {
+		sources.localFile = &configFile{path: filepath.Join(module.RootPath, LocalConfigFileName), data: localData}
+	}
+
+	config, _, err := parseConfig(sources, module)
+	return config, err
+}
+
+func parseConfig(sources *configSources, module *Module) (*Config, *Provenance, error) {
+	rawCfg, doc, err := unmarshalConfig(sources)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	buildFlags, err := parseBuildFlags(rawCfg.RawBuildFlags)
 	if err != nil {
-		return nil, erk.WrapWith(ErrCannotParseFlags, err, erk.Params{
+		return nil, nil, erk.WrapWith(ErrCannotParseFlags, err, erk.Params{
 			"flags": rawCfg.RawBuildFlags,
 		})
 	}
 
-	lambdas, err := rawCfg.mergeLambdas(buildFlags)
+	// The top-level buildOptions are checked even when no Lambdas inherit them
+	if _, err := mergeBuildOptions(rawCfg.RawBuildOptions, nil, ""); err != nil {
+		return nil, nil, err
+	}
+
+	lambdas, extensions, err := rawCfg.mergeLambdas(buildFlags, rawCfg.RawBuildOptions, module.Workspace)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	layers, err := rawCfg.transformLayers(buildFlags, rawCfg.RawBuildOptions, module.Workspace)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	image, err := rawCfg.RawImage.transform()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sbom, err := rawCfg.RawSBOM.transform()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	licensesConfig, err := rawCfg.RawLicenses.transform()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signing, err := rawCfg.RawSigning.transform()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	routes, err := rawCfg.transformRoutes(lambdas)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	versionInjection, err := rawCfg.RawVersionInjection.transform()
+	if err != nil {
+		return nil, nil, err
 	}
 
 	config := &Config{
-		RootPath:       "/" + pwd,
-		ModulePath:     modulePath,
+		RootPath:       module.RootPath,
+		ModulePath:     module.ModulePath,
 		OutDirectory:   rawCfg.OutDirectory,
 		ZippedFileName: rawCfg.ZippedFileName,
 		Goos:           rawCfg.Goos,
 		Goarch:         rawCfg.Goarch,
 		Lambdas:        lambdas,
+		Extensions:     extensions,
+		Layers:         layers,
+		Image:          image,
+		SBOM:           sbom,
+		Licenses:       licensesConfig,
+		Signing:        signing,
+		Routes:         routes,
+
+		VersionInjection:   versionInjection,
+		TemplatesDirectory: rawCfg.TemplatesDirectory,
+		IncludedFiles:      doc.includedFiles,
+		Workspace:          module.Workspace,
 	}
 
 	config.setDefaults()
-	return config, nil
+	return config, rawCfg.provenance(doc.origins, module.Workspace), nil
 }
 
-func (raw *rawConfig) mergeLambdas(defaultBuildFlags []string) ([]*Lambda, error) {
+func (raw *rawConfig) mergeLambdas(defaultBuildFlags []string, defaultBuildOptions *rawBuildOptions, workspace *Workspace) ([]*Lambda, []*Extension, error) {
 	lambdas := make([]*Lambda, 0, len(raw.BuildPaths)+len(raw.RawLambdas))
 
 	for _, buildPath := range raw.BuildPaths {
-		lambda, err := transformBuildPathToLambda(buildPath, defaultBuildFlags)
+		lambda, err := transformBuildPathToLambda(buildPath, defaultBuildFlags, defaultBuildOptions, workspace)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		lambdas = append(lambdas, lambda)
 	}
 
 	for _, rawLambda := range raw.RawLambdas {
-		lambda, err := rawLambda.transform(defaultBuildFlags)
+		lambda, err := rawLambda.transform(defaultBuildFlags, defaultBuildOptions, workspace)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		lambdas = append(lambdas, lambda)
 	}
 
+	var extensions []*Extension
+	for _, rawExtension := range raw.RawExtensions {
+		extension, err := rawExtension.transform(defaultBuildFlags, defaultBuildOptions, workspace)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		extensions = append(extensions, extension)
+	}
+
+	// Extensions are built to the same output directory as Lambdas, so their paths must also be unique
 	seenPaths := make(map[string]struct{})
 	var duplicates []string
-	for _, lambda := range lambdas {
-		if _, exists := seenPaths[lambda.Path]; exists {
-			duplicates = append(duplicates, lambda.Path)
+	checkDuplicate := func(path string) {
+		if _, exists := seenPaths[path]; exists {
+			duplicates = append(duplicates, path)
 		}
-		seenPaths[lambda.Path] = struct{}{}
+		seenPaths[path] = struct{}{}
+	}
+
+	for _, lambda := range lambdas {
+		checkDuplicate(lambda.Path)
+	}
+
+	for _, extension := range extensions {
+		checkDuplicate(extension.Path)
 	}
 
 	if len(duplicates) > 0 {
-		return nil, erk.WithParams(ErrDuplicatePaths, erk.Params{
+		return nil, nil, erk.WithParams(ErrDuplicatePaths, erk.Params{
 			"paths": strings.Join(duplicates, ", "),
 		})
 	}
 
-	return lambdas, nil
+	return lambdas, extensions, nil
 }
 
-func transformBuildPathToLambda(buildPath string, defaultBuildFlags []string) (*Lambda, error) {
+func transformBuildPathToLambda(buildPath string, defaultBuildFlags []string, defaultBuildOptions *rawBuildOptions, workspace *Workspace) (*Lambda, error) {
 	if buildPath == "" {
 		return nil, ErrEmptyLambdaPath
 	}
 
 	normalizedPath := filepath.Clean(buildPath)
-	return &Lambda{
-		Path:       normalizedPath,
-		BuildFlags: defaultBuildFlags,
-	}, nil
+	buildOptions, err := mergeBuildOptions(defaultBuildOptions, nil, normalizedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	lambda := &Lambda{
+		Path:         normalizedPath,
+		BuildFlags:   defaultBuildFlags,
+		BuildOptions: buildOptions,
+	}
+
+	if err := lambda.setModule(workspace, ""); err != nil {
+		return nil, err
+	}
+
+	return lambda, nil
 }
 
-func (rawLambda *rawLambda) transform(defaultBuildFlags []string) (*Lambda, error) {
+func (rawLambda *rawLambda) transform(defaultBuildFlags []string, defaultBuildOptions *rawBuildOptions, workspace *Workspace) (*Lambda, error) {
 	if rawLambda.Path == "" {
 		return nil, ErrEmptyLambdaPath
 	}
@@ -271,9 +890,376 @@ func (rawLambda *rawLambda) transform(defaultBuildFlags []string) (*Lambda, erro
 		lambda.BuildFlags = buildFlags
 	}
 
+	buildOptions, err := mergeBuildOptions(defaultBuildOptions, rawLambda.RawBuildOptions, rawLambda.Path)
+	if err != nil {
+		return nil, err
+	}
+	lambda.BuildOptions = buildOptions
+
+	if err := lambda.setModule(workspace, rawLambda.Module); err != nil {
+		return nil, err
+	}
+
 	return lambda, nil
 }
 
+func (rawExtension *rawExtension) transform(defaultBuildFlags []string, defaultBuildOptions *rawBuildOptions, workspace *Workspace) (*Extension, error) {
+	rawLambda := &rawLambda{
+		Path:            rawExtension.Path,
+		Module:          rawExtension.Module,
+		RawBuildFlags:   rawExtension.RawBuildFlags,
+		RawBuildOptions: rawExtension.RawBuildOptions,
+	}
+	lambda, err := rawLambda.transform(defaultBuildFlags, defaultBuildOptions, workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	name := rawExtension.Name
+	if name == "" {
+		name = filepath.Base(lambda.Path)
+	}
+
+	if !isValidFileName(name) {
+		return nil, erk.WithParams(ErrInvalidExtensionName, erk.Params{"name": name, "path": lambda.Path})
+	}
+
+	return &Extension{
+		Lambda: *lambda,
+		Name:   name,
+	}, nil
+}
+
+// isValidFileName when the name is a single path element, so it cannot escape the directory it is joined to.
+func isValidFileName(name string) bool {
+	return !strings.ContainsAny(name, `/\`) && name != "." && name != ".."
+}
+
+func (raw *rawConfig) transformLayers(defaultBuildFlags []string, defaultBuildOptions *rawBuildOptions, workspace *Workspace) ([]*Layer, error) {
+	var layers []*Layer
+	seenNames := make(map[string]struct{})
+	var duplicates []string
+
+	for _, rawLayer := range raw.RawLayers {
+		layer, err := rawLayer.transform(defaultBuildFlags, defaultBuildOptions, workspace)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, exists := seenNames[layer.Name]; exists {
+			duplicates = append(duplicates, layer.Name)
+		}
+		seenNames[layer.Name] = struct{}{}
+
+		layers = append(layers, layer)
+	}
+
+	if len(duplicates) > 0 {
+		return nil, erk.WithParams(ErrDuplicateLayerNames, erk.Params{
+			"names": strings.Join(duplicates, ", "),
+		})
+	}
+
+	return layers, nil
+}
+
+func (rawLayer *rawLayer) transform(defaultBuildFlags []string, defaultBuildOptions *rawBuildOptions, workspace *Workspace) (*Layer, error) {
+	if rawLayer.Name == "" {
+		return nil, ErrEmptyLayerName
+	}
+
+	if !isValidFileName(rawLayer.Name) {
+		return nil, erk.WithParams(ErrInvalidLayerName, erk.Params{"name": rawLayer.Name})
+	}
+
+	layer := &Layer{Name: rawLayer.Name}
+
+	for _, rawPackage := range rawLayer.Packages {
+		rawLambda := &rawLambda{
+			Path:            rawPackage.Path,
+			Module:          rawPackage.Module,
+			RawBuildFlags:   rawPackage.RawBuildFlags,
+			RawBuildOptions: rawPackage.RawBuildOptions,
+		}
+		lambda, err := rawLambda.transform(defaultBuildFlags, defaultBuildOptions, workspace)
+		if err != nil {
+			return nil, err
+		}
+
+		destination, err := rawLayer.normalizeDestination(rawPackage.Destination, defaultLayerPackageDestination)
+		if err != nil {
+			return nil, err
+		}
+
+		layer.Packages = append(layer.Packages, &LayerPackage{Lambda: *lambda, Destination: destination})
+	}
+
+	for _, rawFiles := range rawLayer.Files {
+		if rawFiles.Glob == "" {
+			return nil, erk.WithParams(ErrEmptyLayerGlob, erk.Params{"name": rawLayer.Name})
+		}
+
+		glob := filepath.Clean(rawFiles.Glob)
+		if glob == ".." || strings.HasPrefix(glob, ".."+string(filepath.Separator)) {
+			return nil, erk.WithParams(ErrInvalidLayerGlob, erk.Params{"name": rawLayer.Name, "glob": rawFiles.Glob})
+		}
+
+		destination, err := rawLayer.normalizeDestination(rawFiles.Destination, "")
+		if err != nil {
+			return nil, err
+		}
+
+		layer.Files = append(layer.Files, &LayerFiles{Glob: glob, Destination: destination})
+	}
+
+	return layer, nil
+}
+
+func (rawLayer *rawLayer) normalizeDestination(destination, defaultDestination string) (string, error) {
+	if destination == "" {
+		return defaultDestination, nil
+	}
+
+	cleaned := path.Clean(destination)
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", erk.WithParams(ErrInvalidLayerDestination, erk.Params{
+			"name":        rawLayer.Name,
+			"destination": destination,
+		})
+	}
+
+	if cleaned == "." {
+		return "", nil
+	}
+
+	return cleaned + "/", nil
+}
+
+func (rawImage *rawImage) transform() (*Image, error) {
+	if rawImage == nil {
+		return nil, nil //nolint:nilnil // Images are optional
+	}
+
+	format := ImageFormat(rawImage.Format)
+	if format != ImageFormatLayout && format != ImageFormatTarball {
+		return nil, erk.WithParams(ErrInvalidImageFormat, erk.Params{"format": rawImage.Format})
+	}
+
+	binaryPath := rawImage.BinaryPath
+	if binaryPath == "" {
+		binaryPath = defaultImageBinaryPath
+	}
+
+	if !path.IsAbs(binaryPath) || path.Clean(binaryPath) == "/" {
+		return nil, erk.WithParams(ErrInvalidImageBinaryPath, erk.Params{"binaryPath": rawImage.BinaryPath})
+	}
+
+	return &Image{
+		Format:     format,
+		BinaryPath: path.Clean(binaryPath),
+	}, nil
+}
+
+func (rawSBOM *rawSBOM) transform() (*SBOM, error) {
+	if rawSBOM == nil {
+		return nil, nil //nolint:nilnil // SBOMs are optional
+	}
+
+	format := SBOMFormat(rawSBOM.Format)
+	if format != SBOMFormatSPDX && format != SBOMFormatCycloneDX {
+		return nil, erk.WithParams(ErrInvalidSBOMFormat, erk.Params{"format": rawSBOM.Format})
+	}
+
+	return &SBOM{Format: format}, nil
+}
+
+func (rawLicenses *rawLicenses) transform() (*Licenses, error) {
+	if rawLicenses == nil {
+		return nil, nil //nolint:nilnil // Licenses are optional
+	}
+
+	for _, license := range rawLicenses.Disallowed {
+		if !slices.Contains(licenses.IDs, license) {
+			return nil, erk.WithParams(ErrInvalidLicense, erk.Params{"license": license, "licenses": strings.Join(licenses.IDs, ", ")})
+		}
+	}
+
+	return &Licenses{
+		Bundle:     rawLicenses.Bundle,
+		Disallowed: rawLicenses.Disallowed,
+	}, nil
+}
+
+func (rawSigning *rawSigning) transform() (*Signing, error) {
+	if rawSigning == nil {
+		return nil, nil //nolint:nilnil // Signing is optional
+	}
+
+	if rawSigning.KeyFile == "" {
+		return nil, ErrMissingSigningKey
+	}
+
+	keyFile, err := shell.Expand(rawSigning.KeyFile, os.Getenv)
+	if err != nil {
+		return nil, erk.WrapWith(ErrInvalidSigningKeyFile, err, erk.Params{"keyFile": rawSigning.KeyFile})
+	}
+
+	publicKeyFile, err := shell.Expand(rawSigning.PublicKeyFile, os.Getenv)
+	if err != nil {
+		return nil, erk.WrapWith(ErrInvalidSigningKeyFile, err, erk.Params{"keyFile": rawSigning.PublicKeyFile})
+	}
+
+	return &Signing{
+		KeyFile:       keyFile,
+		PublicKeyFile: publicKeyFile,
+	}, nil
+}
+
+func (rawVersionInjection *rawVersionInjection) transform() (*VersionInjection, error) {
+	if rawVersionInjection == nil {
+		return nil, nil //nolint:nilnil // Version injection is optional
+	}
+
+	variables := []struct{ key, variable string }{
+		{"commit", rawVersionInjection.Commit},
+		{"dirty", rawVersionInjection.Dirty},
+		{"tag", rawVersionInjection.Tag},
+		{"buildTime", rawVersionInjection.BuildTime},
+		{"lambdaPath", rawVersionInjection.LambdaPath},
+	}
+
+	for _, v := range variables {
+		if v.variable != "" && !isValidVariableName(v.variable) {
+			return nil, erk.WithParams(ErrInvalidVersionVariable, erk.Params{"key": v.key, "variable": v.variable})
+		}
+	}
+
+	return &VersionInjection{
+		Commit:     rawVersionInjection.Commit,
+		Dirty:      rawVersionInjection.Dirty,
+		Tag:        rawVersionInjection.Tag,
+		BuildTime:  rawVersionInjection.BuildTime,
+		LambdaPath: rawVersionInjection.LambdaPath,
+	}, nil
+}
+
+// isValidVariableName checks that the name is an import path followed by a dot and an identifier, like the linker's -X flag expects.
+func isValidVariableName(name string) bool {
+	dot := strings.LastIndex(name, ".")
+	if dot <= 0 || !token.IsIdentifier(name[dot+1:]) {
+		return false
+	}
+
+	return !strings.ContainsAny(name, " \t\n'\"=")
+}
+
+func (raw *rawConfig) transformRoutes(lambdas []*Lambda) ([]*Route, error) {
+	lambdasByPath := make(map[string]*Lambda, len(lambdas))
+	for _, lambda := range lambdas {
+		lambdasByPath[lambda.Path] = lambda
+	}
+
+	var routes []*Route
+	seenRoutes := make(map[string]struct{})
+	var duplicates []string
+
+	for _, rawRoute := range raw.RawRoutes {
+		route, err := rawRoute.transform(lambdasByPath)
+		if err != nil {
+			return nil, err
+		}
+
+		key := route.Method + " " + route.Path
+		if route.Method == "" {
+			key = "ANY " + route.Path
+		}
+
+		if _, exists := seenRoutes[key]; exists {
+			duplicates = append(duplicates, key)
+		}
+		seenRoutes[key] = struct{}{}
+
+		routes = append(routes, route)
+	}
+
+	if len(duplicates) > 0 {
+		return nil, erk.WithParams(ErrDuplicateRoutes, erk.Params{
+			"routes": strings.Join(duplicates, ", "),
+		})
+	}
+
+	return routes, nil
+}
+
+func (rawRoute *rawRoute) transform(lambdasByPath map[string]*Lambda) (*Route, error) {
+	if !isValidRoutePath(rawRoute.Path) {
+		return nil, erk.WithParams(ErrInvalidRoutePath, erk.Params{"path": rawRoute.Path})
+	}
+
+	routePath := path.Clean(rawRoute.Path)
+
+	lambda, ok := lambdasByPath[filepath.Clean(rawRoute.Lambda)]
+	if !ok {
+		return nil, erk.WithParams(ErrUnknownRouteLambda, erk.Params{
+			"path":   rawRoute.Path,
+			"lambda": rawRoute.Lambda,
+		})
+	}
+
+	event := EventFormat(rawRoute.Event)
+	switch event {
+	case "":
+		event = EventFormatAPIGatewayV2
+	case EventFormatAPIGateway, EventFormatAPIGatewayV2, EventFormatFunctionURL:
+	default:
+		return nil, erk.WithParams(ErrInvalidRouteEvent, erk.Params{
+			"path":  rawRoute.Path,
+			"event": rawRoute.Event,
+		})
+	}
+
+	method := strings.ToUpper(rawRoute.Method)
+	if method == "ANY" {
+		method = ""
+	}
+
+	return &Route{
+		Path:   routePath,
+		Method: method,
+		Lambda: lambda,
+		Event:  event,
+	}, nil
+}
+
+// isValidRoutePath checks that the path is absolute, and only contains valid parameters.
+func isValidRoutePath(routePath string) bool {
+	if !strings.HasPrefix(routePath, "/") {
+		return false
+	}
+
+	segments := strings.Split(strings.Trim(path.Clean(routePath), "/"), "/")
+	for i, segment := range segments {
+		if !strings.ContainsAny(segment, "{}") {
+			continue
+		}
+
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			return false
+		}
+
+		name := strings.TrimSuffix(segment[1:len(segment)-1], "+")
+		if strings.HasSuffix(segment, "+}") && i != len(segments)-1 {
+			return false
+		}
+
+		if !token.IsIdentifier(name) {
+			return false
+		}
+	}
+
+	return true
+}
+
 func parseBuildFlags(rawFlags string) ([]string, error) {
 	if rawFlags == "" {
 		return nil, nil
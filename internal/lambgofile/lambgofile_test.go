@@ -8,10 +8,55 @@ import (
 	"github.com/JosiahWitt/ensure"
 	"github.com/JosiahWitt/ensure/ensuring"
 	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/licenses"
 	"github.com/JosiahWitt/lambgo/internal/mocks/io/mock_fs"
 	"github.com/golang/mock/gomock"
 )
 
+const profilesFile = `
+goarch: amd64
+buildFlags: -tags dev
+buildPaths:
+  - lambdas/api
+lambdas:
+  - path: lambdas/worker
+    buildFlags: -tags worker
+layers:
+  - name: assets
+    files:
+      - glob: assets/*.json
+      - glob: data
+image:
+  format: layout
+routes:
+  - path: /orders
+    method: GET
+    lambda: lambdas/api
+profiles:
+  dev:
+  prod:
+    goarch: arm64
+    buildFlags: -tags prod
+    buildPaths:
+      - lambdas/api
+      - ./lambdas/admin
+    lambdas:
+      - path: lambdas/worker/
+        buildFlags: -tags prod,worker
+    layers:
+      - name: assets
+        files:
+          - glob: prod/*.json
+    image:
+      binaryPath: /opt/bootstrap
+    routes:
+      - path: /orders
+        method: get
+        lambda: lambdas/worker
+      - path: /admin
+        lambda: lambdas/admin
+`
+
 func TestLoadConfig(t *testing.T) {
 	ensure := ensure.New(t)
 
@@ -47,6 +92,7 @@ func TestLoadConfig(t *testing.T) {
 		Name string
 
 		PWD     string
+		Profile string
 		EnvVars map[string]string
 
 		ExpectedConfig *lambgofile.Config
@@ -927,414 +973,2498 @@ lambdas:
 		},
 
 		{
-			Name: "with complex config including all fields and comments",
+			Name: "with extensions",
 
 			PWD: "/my/app",
-			EnvVars: map[string]string{
-				"VERSION":    "v2.0.0",
-				"GIT_COMMIT": "abc123",
-			},
 
 			ExpectedConfig: &lambgofile.Config{
-				RootPath:       "/my/app",
-				ModulePath:     "github.com/my/app",
-				OutDirectory:   "build",
-				ZippedFileName: "bootstrap",
-				Goos:           "linux",
-				Goarch:         "arm64",
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "tmp",
+				Goos:         "linux",
+				Goarch:       "amd64",
 				Lambdas: []*lambgofile.Lambda{
-					makeLambda("lambdas/legacy", []string{"-ldflags=-s -w"}),
-					makeLambda("lambdas/api", []string{"-tags", "prod", "-ldflags=-s -w -X main.version=v2.0.0 -X main.commit=abc123"}),
-					makeLambda("lambdas/worker", nil),
-					makeLambda("lambdas/simple", []string{"-ldflags=-s -w"}),
+					makeLambda("lambdas/api", []string{"-ldflags=-s -w"}),
+				},
+				Extensions: []*lambgofile.Extension{
+					makeExtension("extensions/telemetry", "telemetry", []string{"-ldflags=-s -w"}),
+					makeExtension("extensions/secrets", "secrets-cache", []string{"-tags", "prod"}),
+					makeExtension("extensions/logs", "logs", nil),
 				},
 			},
+
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
-				"my/app/.lambgo.yml": `# Production configuration
-# This is a comment
-outDirectory: build
-
-# Using bootstrap for provided.al2 runtime
-zippedFileName: bootstrap
-
-# Build flags for production
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
 buildFlags: -ldflags="-s -w"
-
-# Target ARM64 architecture
-goos: linux
-goarch: arm64
-
-# Lambda functions to build
-buildPaths:
-  - lambdas/legacy
 lambdas:
   - path: lambdas/api
-    buildFlags: -tags prod -ldflags="-s -w -X main.version=$VERSION -X main.commit=$GIT_COMMIT"
-  - path: lambdas/worker
+extensions:
+  - path: ./extensions/telemetry/
+  - path: extensions/secrets
+    name: secrets-cache
+    buildFlags: -tags prod
+  - path: extensions/logs
     buildFlags: ""
-  - path: lambdas/simple
 `,
 			}),
-		}, {
-			Name: "with various whitespace and formatting",
+		},
+
+		{
+			Name: "with extension names containing dots",
 
 			PWD: "/my/app",
 
 			ExpectedConfig: &lambgofile.Config{
 				RootPath:     "/my/app",
 				ModulePath:   "github.com/my/app",
-				OutDirectory: "output",
+				OutDirectory: "tmp",
 				Goos:         "linux",
 				Goarch:       "amd64",
-				Lambdas: []*lambgofile.Lambda{
-					makeLambda("lambdas/func1", nil),
-					makeLambda("lambdas/func2", nil),
+				Lambdas:      []*lambgofile.Lambda{},
+				Extensions: []*lambgofile.Extension{
+					makeExtension("extensions/telemetry", "telemetry.v2", nil),
+					makeExtension("extensions/logs", "..logs", nil),
 				},
 			},
+
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
 				"my/app/.lambgo.yml": `
-outDirectory:    output
-
-
-buildPaths:
-
-  -    lambdas/func1
-  -  lambdas/func2
-
-
+outDirectory: tmp
+extensions:
+  - path: extensions/telemetry
+    name: telemetry.v2
+  - path: extensions/logs
+    name: ..logs
 `,
 			}),
 		},
 
 		{
-			Name: "when missing go.mod file",
-
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrCannotFindGoModule,
-
-			SetupMocks: setupMapFS(mapFS{
-				"my/app/.lambgo.yml": lambgofile.ExampleFile,
-			}),
-		},
-
-		{
-			Name: "when cannot open go.mod file",
-
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrCannotOpenFile,
-
-			SetupMocks: setupMapFS(mapFS{
-				"my/app/go.mod":      fs.ErrPermission,
-				"my/app/.lambgo.yml": lambgofile.ExampleFile,
-			}),
-		},
-
-		{
-			Name: "when cannot parse go.mod file",
-
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrCannotParseGoModule,
-
-			SetupMocks: setupMapFS(mapFS{
-				"my/app/go.mod":      "something is broken",
-				"my/app/.lambgo.yml": lambgofile.ExampleFile,
-			}),
-		},
+			Name: "with layers",
 
-		{
-			Name: "when cannot find .lambgo.yml file",
+			PWD: "/my/app",
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrCannotOpenFile,
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "tmp",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", []string{"-ldflags=-s -w"}),
+				},
+				Layers: []*lambgofile.Layer{
+					{
+						Name: "shared-tools",
+						Packages: []*lambgofile.LayerPackage{
+							{Lambda: *makeLambda("tools/converter", []string{"-ldflags=-s -w"}), Destination: "bin/"},
+							{Lambda: *makeLambda("tools/helper", []string{"-tags", "prod"}), Destination: "lib/tools/"},
+							{Lambda: *makeLambda("tools/root", nil), Destination: ""},
+						},
+						Files: []*lambgofile.LayerFiles{
+							{Glob: "assets/*.json", Destination: "lib/data/"},
+							{Glob: "config", Destination: ""},
+						},
+					},
+					{
+						Name: "data-only",
+						Files: []*lambgofile.LayerFiles{
+							{Glob: "data/*", Destination: "data/"},
+						},
+					},
+				},
+			},
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildFlags: -ldflags="-s -w"
+lambdas:
+  - path: lambdas/api
+layers:
+  - name: shared-tools
+    packages:
+      - path: tools/converter
+      - path: ./tools/helper
+        destination: lib//tools
+        buildFlags: -tags prod
+      - path: tools/root
+        destination: ./
+        buildFlags: ""
+    files:
+      - glob: ./assets/*.json
+        destination: lib/data/
+      - glob: config/
+  - name: data-only
+    files:
+      - glob: data/*
+        destination: data
+`,
 			}),
 		},
 
 		{
-			Name: "when cannot open .lambgo.yml file",
-
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrCannotOpenFile,
-
-			SetupMocks: setupMapFS(mapFS{
-				"my/app/go.mod":      defaultGoModFile,
-				"my/app/.lambgo.yml": fs.ErrPermission,
-			}),
-		},
-
-		{
-			Name: "when cannot parse .lambgo.yml file",
-
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrCannotUnmarshalFile,
-
-			SetupMocks: setupMapFS(mapFS{
-				"my/app/go.mod":      defaultGoModFile,
-				"my/app/.lambgo.yml": "{{{{{{ Not YAML",
-			}),
-		},
-
-		{
-			Name: "when .lambgo.yml file has bad flag syntax",
-
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrCannotParseFlags,
+			Name: "with buildOptions",
 
-			SetupMocks: setupMapFS(mapFS{
-				"my/app/go.mod":      defaultGoModFile,
-				"my/app/.lambgo.yml": "buildFlags: foo'",
-			}),
-		},
-
-		{
-			Name: "when duplicate path between buildPaths and lambdas",
+			PWD: "/my/app",
+			EnvVars: map[string]string{
+				"LAMBGO_TEST_VERSION": "v1.2.3",
+			},
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrDuplicatePaths,
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "tmp",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{
+						Path:       "lambdas/api",
+						BuildFlags: []string{"-v"},
+						BuildOptions: &lambgofile.BuildOptions{
+							Tags:       []string{"prod", "lambda"},
+							LDFlags:    "-s -w -X main.Version=v1.2.3",
+							Trimpath:   true,
+							Mod:        "readonly",
+							ExtraFlags: []string{"-p", "4"},
+						},
+					},
+					{
+						Path:       "lambdas/debug",
+						BuildFlags: []string{"-v"},
+						BuildOptions: &lambgofile.BuildOptions{
+							Tags:       []string{"prod", "lambda", "debug"},
+							LDFlags:    "-s -w -X main.Version=v1.2.3 -X main.Debug=true",
+							GCFlags:    "all=-N -l",
+							Trimpath:   false,
+							Race:       true,
+							Mod:        "mod",
+							ExtraFlags: []string{"-p", "4", "-a"},
+						},
+					},
+				},
+				Extensions: []*lambgofile.Extension{
+					{
+						Lambda: lambgofile.Lambda{
+							Path: "extensions/telemetry",
+							BuildOptions: &lambgofile.BuildOptions{
+								Tags:       []string{"prod", "lambda", "extension"},
+								LDFlags:    "-s -w -X main.Version=v1.2.3",
+								Trimpath:   true,
+								Mod:        "readonly",
+								ExtraFlags: []string{"-p", "4"},
+							},
+						},
+						Name: "telemetry",
+					},
+				},
+				Layers: []*lambgofile.Layer{
+					{
+						Name: "tools",
+						Packages: []*lambgofile.LayerPackage{
+							{
+								Lambda: lambgofile.Lambda{
+									Path:       "tools/converter",
+									BuildFlags: []string{"-v"},
+									BuildOptions: &lambgofile.BuildOptions{
+										Tags:       []string{"prod", "lambda"},
+										LDFlags:    "-s -w -X main.Version=v1.2.3",
+										Trimpath:   true,
+										Mod:        "readonly",
+										ExtraFlags: []string{"-p", "4"},
+									},
+								},
+								Destination: "bin/",
+							},
+						},
+					},
+				},
+			},
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
 				"my/app/.lambgo.yml": `
 outDirectory: tmp
+buildFlags: -v
+buildOptions:
+  tags: [prod, lambda]
+  ldflags: -s -w -X main.Version=${LAMBGO_TEST_VERSION}
+  mod: readonly
+  extraFlags: -p 4
 buildPaths:
-  - lambdas/duplicate
+  - lambdas/api
 lambdas:
-  - path: lambdas/duplicate
+  - path: lambdas/debug
+    buildOptions:
+      tags: [debug, prod]
+      ldflags: -X main.Debug=true
+      gcflags: all=-N -l
+      trimpath: false
+      race: true
+      mod: mod
+      extraFlags: -a
+extensions:
+  - path: extensions/telemetry
+    buildFlags: ""
+    buildOptions:
+      tags: [extension]
+layers:
+  - name: tools
+    packages:
+      - path: tools/converter
 `,
 			}),
 		},
 
 		{
-			Name: "when duplicate path within lambdas array",
+			Name: "with per-lambda buildOptions and no top-level buildOptions",
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrDuplicatePaths,
+			PWD: "/my/app",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "tmp",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", nil),
+					{Path: "lambdas/worker", BuildOptions: &lambgofile.BuildOptions{Tags: []string{"worker"}, Trimpath: true}},
+				},
+			},
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
 				"my/app/.lambgo.yml": `
 outDirectory: tmp
+buildPaths:
+  - lambdas/api
 lambdas:
-  - path: lambdas/duplicate
-  - path: lambdas/duplicate
+  - path: lambdas/worker
+    buildOptions:
+      tags: [worker]
 `,
 			}),
 		},
 
 		{
-			Name: "when duplicate path within buildPaths array",
+			Name: "with image output",
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrDuplicatePaths,
+			PWD: "/my/app",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "tmp",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Image:        &lambgofile.Image{Format: lambgofile.ImageFormatTarball, BinaryPath: "/app/main"},
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", nil),
+				},
+			},
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
 				"my/app/.lambgo.yml": `
 outDirectory: tmp
+image:
+  format: tarball
+  binaryPath: /app//main
 buildPaths:
-  - lambdas/duplicate
-  - lambdas/duplicate
+  - lambdas/api
 `,
 			}),
 		},
 
 		{
-			Name: "when multiple duplicate paths",
+			Name: "with image output using the default binaryPath",
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrDuplicatePaths,
+			PWD: "/my/app",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "tmp",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Image:        &lambgofile.Image{Format: lambgofile.ImageFormatLayout, BinaryPath: "/var/runtime/bootstrap"},
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", nil),
+				},
+			},
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
 				"my/app/.lambgo.yml": `
 outDirectory: tmp
+image:
+  format: layout
 buildPaths:
-  - lambdas/dup1
-  - lambdas/dup1
-  - lambdas/dup2
-lambdas:
-  - path: lambdas/dup2
+  - lambdas/api
 `,
 			}),
 		},
 
 		{
-			Name: "when paths differ only by leading ./",
+			Name: "with sbom output",
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrDuplicatePaths,
+			PWD: "/my/app",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "tmp",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				SBOM:         &lambgofile.SBOM{Format: lambgofile.SBOMFormatCycloneDX},
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", nil),
+				},
+			},
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
 				"my/app/.lambgo.yml": `
 outDirectory: tmp
+sbom:
+  format: cyclonedx
 buildPaths:
-  - lambdas/hello_world
-  - ./lambdas/hello_world
+  - lambdas/api
 `,
 			}),
 		},
 
 		{
-			Name: "when paths differ only by trailing /",
+			Name: "with licenses",
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrDuplicatePaths,
+			PWD: "/my/app",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "tmp",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Licenses: &lambgofile.Licenses{
+					Bundle:     true,
+					Disallowed: []string{licenses.GPL30, licenses.Unknown},
+				},
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", nil),
+				},
+			},
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
 				"my/app/.lambgo.yml": `
 outDirectory: tmp
+licenses:
+  bundle: true
+  disallowed: [GPL-3.0, Unknown]
 buildPaths:
-  - lambdas/hello_world
-  - lambdas/hello_world/
+  - lambdas/api
 `,
 			}),
 		},
 
 		{
-			Name: "when paths have multiple variations that normalize to same path",
+			Name: "with signing",
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrDuplicatePaths,
+			PWD: "/my/app",
+			EnvVars: map[string]string{
+				"SIGNING_KEY": "/secrets/signing.pem",
+			},
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "tmp",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Signing: &lambgofile.Signing{
+					KeyFile:       "/secrets/signing.pem",
+					PublicKeyFile: "keys/signing.pub.pem",
+				},
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", nil),
+				},
+			},
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
 				"my/app/.lambgo.yml": `
 outDirectory: tmp
+signing:
+  keyFile: $SIGNING_KEY
+  publicKeyFile: keys/signing.pub.pem
 buildPaths:
-  - ./lambdas/api
-  - lambdas/api/
-lambdas:
-  - path: lambdas/api
+  - lambdas/api
 `,
 			}),
 		},
 
 		{
-			Name: "when buildPath is empty",
+			Name: "with templatesDirectory",
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrEmptyLambdaPath,
+			PWD: "/my/app",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:           "/my/app",
+				ModulePath:         "github.com/my/app",
+				OutDirectory:       "tmp",
+				Goos:               "linux",
+				Goarch:             "amd64",
+				TemplatesDirectory: "templates/lambdas",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", nil),
+				},
+			},
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
 				"my/app/.lambgo.yml": `
 outDirectory: tmp
+templatesDirectory: templates/lambdas
 buildPaths:
-  - ""
+  - lambdas/api
 `,
 			}),
 		},
 
 		{
-			Name: "when lambda has empty path",
+			Name: "with versionInjection",
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrEmptyLambdaPath,
+			PWD: "/my/app",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "tmp",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", nil),
+				},
+				VersionInjection: &lambgofile.VersionInjection{
+					Commit:     "main.Commit",
+					Tag:        "github.com/my/app/internal/build.Version",
+					LambdaPath: "github.com/my/app/internal/build.Lambda",
+				},
+			},
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
 				"my/app/.lambgo.yml": `
 outDirectory: tmp
-lambdas:
-  - path: ""
-    buildFlags: -tags prod
+buildPaths:
+  - lambdas/api
+versionInjection:
+  commit: main.Commit
+  tag: github.com/my/app/internal/build.Version
+  lambdaPath: github.com/my/app/internal/build.Lambda
 `,
 			}),
 		},
 
 		{
-			Name: "when lambdas section has paths with leading ./ that normalize to same path",
+			Name: "with routes",
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrDuplicatePaths,
+			PWD: "/my/app",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "tmp",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", nil),
+					makeLambda("lambdas/files", nil),
+				},
+				Routes: []*lambgofile.Route{
+					{
+						Path:   "/orders/{id}",
+						Method: "GET",
+						Lambda: makeLambda("lambdas/api", nil),
+						Event:  lambgofile.EventFormatAPIGatewayV2,
+					},
+					{
+						Path:   "/orders",
+						Lambda: makeLambda("lambdas/api", nil),
+						Event:  lambgofile.EventFormatAPIGateway,
+					},
+					{
+						Path:   "/files/{proxy+}",
+						Method: "POST",
+						Lambda: makeLambda("lambdas/files", nil),
+						Event:  lambgofile.EventFormatFunctionURL,
+					},
+					{
+						Path:   "/",
+						Lambda: makeLambda("lambdas/files", nil),
+						Event:  lambgofile.EventFormatAPIGatewayV2,
+					},
+				},
+			},
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
 				"my/app/.lambgo.yml": `
 outDirectory: tmp
-lambdas:
-  - path: lambdas/api
-    buildFlags: -tags prod
-  - path: ./lambdas/api
-    buildFlags: -tags dev
+buildPaths:
+  - lambdas/api
+  - lambdas/files
+routes:
+  - path: /orders/{id}
+    method: get
+    lambda: lambdas/api
+  - path: /orders/
+    method: ANY
+    lambda: ./lambdas/api
+    event: apigateway
+  - path: /files/{proxy+}
+    method: POST
+    lambda: lambdas/files
+    event: function-url
+  - path: /
+    lambda: lambdas/files
 `,
 			}),
 		},
 
 		{
-			Name: "when lambdas section has paths with trailing / that normalize to same path",
+			Name: "with complex config including all fields and comments",
 
-			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrDuplicatePaths,
+			PWD: "/my/app",
+			EnvVars: map[string]string{
+				"VERSION":    "v2.0.0",
+				"GIT_COMMIT": "abc123",
+			},
 
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:       "/my/app",
+				ModulePath:     "github.com/my/app",
+				OutDirectory:   "build",
+				ZippedFileName: "bootstrap",
+				Goos:           "linux",
+				Goarch:         "arm64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/legacy", []string{"-ldflags=-s -w"}),
+					makeLambda("lambdas/api", []string{"-tags", "prod", "-ldflags=-s -w -X main.version=v2.0.0 -X main.commit=abc123"}),
+					makeLambda("lambdas/worker", nil),
+					makeLambda("lambdas/simple", []string{"-ldflags=-s -w"}),
+				},
+			},
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
-				"my/app/.lambgo.yml": `
-outDirectory: tmp
+				"my/app/.lambgo.yml": `# Production configuration
+# This is a comment
+outDirectory: build
+
+# Using bootstrap for provided.al2 runtime
+zippedFileName: bootstrap
+
+# Build flags for production
+buildFlags: -ldflags="-s -w"
+
+# Target ARM64 architecture
+goos: linux
+goarch: arm64
+
+# Lambda functions to build
+buildPaths:
+  - lambdas/legacy
 lambdas:
+  - path: lambdas/api
+    buildFlags: -tags prod -ldflags="-s -w -X main.version=$VERSION -X main.commit=$GIT_COMMIT"
   - path: lambdas/worker
-  - path: lambdas/worker/
     buildFlags: ""
+  - path: lambdas/simple
+`,
+			}),
+		}, {
+			Name: "with various whitespace and formatting",
+
+			PWD: "/my/app",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "output",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/func1", nil),
+					makeLambda("lambdas/func2", nil),
+				},
+			},
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory:    output
+
+
+buildPaths:
+
+  -    lambdas/func1
+  -  lambdas/func2
+
+
 `,
 			}),
 		},
 
 		{
-			Name: "when lambda has missing path field",
+			Name: "when missing go.mod file",
 
 			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrEmptyLambdaPath,
+			ExpectedError: lambgofile.ErrCannotFindGoModule,
 
 			SetupMocks: setupMapFS(mapFS{
-				"my/app/go.mod": defaultGoModFile,
-				"my/app/.lambgo.yml": `
-outDirectory: tmp
-lambdas:
-  - buildFlags: -tags prod
-`,
+				"my/app/.lambgo.yml": lambgofile.ExampleFile,
 			}),
 		},
 
 		{
-			Name: "when per-lambda buildFlags has invalid syntax",
+			Name: "when cannot open go.mod file",
 
 			PWD:           "/my/app",
-			ExpectedError: lambgofile.ErrCannotParsePerLambdaFlags,
+			ExpectedError: lambgofile.ErrCannotOpenFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      fs.ErrPermission,
+				"my/app/.lambgo.yml": lambgofile.ExampleFile,
+			}),
+		},
+
+		{
+			Name: "when cannot parse go.mod file",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotParseGoModule,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      "something is broken",
+				"my/app/.lambgo.yml": lambgofile.ExampleFile,
+			}),
+		},
+
+		{
+			Name: "when cannot find .lambgo.yml file",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotOpenFile,
 
 			SetupMocks: setupMapFS(mapFS{
 				"my/app/go.mod": defaultGoModFile,
-				"my/app/.lambgo.yml": `
-outDirectory: tmp
-lambdas:
-  - path: lambdas/api
-    buildFlags: foo'
-`,
 			}),
 		},
-	}
 
-	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
-		entry := table[i]
+		{
+			Name: "when cannot open .lambgo.yml file",
 
-		for key, value := range entry.EnvVars {
-			ensure.T().Setenv(key, value)
-		}
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotOpenFile,
 
-		config, err := entry.Subject.LoadConfig(entry.PWD)
-		ensure(err).IsError(entry.ExpectedError)
-		ensure(config).Equals(entry.ExpectedConfig)
-	})
-}
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": fs.ErrPermission,
+			}),
+		},
 
-func makeLambda(path string, buildFlags []string) *lambgofile.Lambda {
-	return &lambgofile.Lambda{
-		Path:       path,
-		BuildFlags: buildFlags,
+		{
+			Name: "when cannot parse .lambgo.yml file",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotUnmarshalFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "{{{{{{ Not YAML",
+			}),
+		},
+
+		{
+			Name: "when .lambgo.yml file has bad flag syntax",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotParseFlags,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "buildFlags: foo'",
+			}),
+		},
+
+		{
+			Name: "when versionInjection has a variable without an import path",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidVersionVariable,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "versionInjection:\n  commit: Commit",
+			}),
+		},
+
+		{
+			Name: "when versionInjection has a variable containing a space",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidVersionVariable,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "versionInjection:\n  tag: main.Version Tag",
+			}),
+		},
+
+		{
+			Name: "when buildOptions has an invalid mod",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidBuildMod,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "buildOptions:\n  mod: vendored",
+			}),
+		},
+
+		{
+			Name: "when buildOptions has bad extraFlags syntax",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotParseBuildOptions,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "buildOptions:\n  extraFlags: foo'",
+			}),
+		},
+
+		{
+			Name: "when per-lambda buildOptions has bad ldflags syntax",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotParsePerLambdaBuildOptions,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "lambdas:\n  - path: lambdas/api\n    buildOptions:\n      ldflags: -X main.Version=${VERSION",
+			}),
+		},
+
+		{
+			Name: "when duplicate path between buildPaths and lambdas",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicatePaths,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/duplicate
+lambdas:
+  - path: lambdas/duplicate
+`,
+			}),
+		},
+
+		{
+			Name: "when duplicate path between lambdas and extensions",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicatePaths,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+lambdas:
+  - path: lambdas/duplicate
+extensions:
+  - path: lambdas/duplicate
+`,
+			}),
+		},
+
+		{
+			Name: "when duplicate path within lambdas array",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicatePaths,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+lambdas:
+  - path: lambdas/duplicate
+  - path: lambdas/duplicate
+`,
+			}),
+		},
+
+		{
+			Name: "when duplicate path within buildPaths array",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicatePaths,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/duplicate
+  - lambdas/duplicate
+`,
+			}),
+		},
+
+		{
+			Name: "when multiple duplicate paths",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicatePaths,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/dup1
+  - lambdas/dup1
+  - lambdas/dup2
+lambdas:
+  - path: lambdas/dup2
+`,
+			}),
+		},
+
+		{
+			Name: "when paths differ only by leading ./",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicatePaths,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/hello_world
+  - ./lambdas/hello_world
+`,
+			}),
+		},
+
+		{
+			Name: "when paths differ only by trailing /",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicatePaths,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/hello_world
+  - lambdas/hello_world/
+`,
+			}),
+		},
+
+		{
+			Name: "when paths have multiple variations that normalize to same path",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicatePaths,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - ./lambdas/api
+  - lambdas/api/
+lambdas:
+  - path: lambdas/api
+`,
+			}),
+		},
+
+		{
+			Name: "when buildPath is empty",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrEmptyLambdaPath,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - ""
+`,
+			}),
+		},
+
+		{
+			Name: "when lambda has empty path",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrEmptyLambdaPath,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+lambdas:
+  - path: ""
+    buildFlags: -tags prod
+`,
+			}),
+		},
+
+		{
+			Name: "when lambdas section has paths with leading ./ that normalize to same path",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicatePaths,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+lambdas:
+  - path: lambdas/api
+    buildFlags: -tags prod
+  - path: ./lambdas/api
+    buildFlags: -tags dev
+`,
+			}),
+		},
+
+		{
+			Name: "when lambdas section has paths with trailing / that normalize to same path",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicatePaths,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+lambdas:
+  - path: lambdas/worker
+  - path: lambdas/worker/
+    buildFlags: ""
+`,
+			}),
+		},
+
+		{
+			Name: "when lambda has missing path field",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrEmptyLambdaPath,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+lambdas:
+  - buildFlags: -tags prod
+`,
+			}),
+		},
+
+		{
+			Name: "when extension has empty path",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrEmptyLambdaPath,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+extensions:
+  - name: telemetry
+`,
+			}),
+		},
+
+		{
+			Name: "when layer has empty name",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrEmptyLayerName,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+layers:
+  - files:
+      - glob: data/*
+`,
+			}),
+		},
+
+		{
+			Name: "when layer name contains a path separator",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidLayerName,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+layers:
+  - name: nested/layer
+    files:
+      - glob: data/*
+`,
+			}),
+		},
+
+		{
+			Name: "when extension name is escaping the extensions directory",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidExtensionName,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+extensions:
+  - path: extensions/telemetry
+    name: ../../x
+`,
+			}),
+		},
+
+		{
+			Name: "when extension name is containing a path separator",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidExtensionName,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+extensions:
+  - path: extensions/telemetry
+    name: nested/name
+`,
+			}),
+		},
+
+		{
+			Name: "when extension name is containing a backslash",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidExtensionName,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+extensions:
+  - path: extensions/telemetry
+    name: nested\\name
+`,
+			}),
+		},
+
+		{
+			Name: "when extension name is that is ..",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidExtensionName,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+extensions:
+  - path: extensions/telemetry
+    name: ".."
+`,
+			}),
+		},
+
+		{
+			Name: "when extension name is that is .",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidExtensionName,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+extensions:
+  - path: extensions/telemetry
+    name: "."
+`,
+			}),
+		},
+
+		{
+			Name: "when duplicate layer names",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicateLayerNames,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+layers:
+  - name: shared
+    files:
+      - glob: data/*
+  - name: shared
+    files:
+      - glob: assets/*
+`,
+			}),
+		},
+
+		{
+			Name: "when layer package has empty path",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrEmptyLambdaPath,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+layers:
+  - name: shared
+    packages:
+      - destination: bin/
+`,
+			}),
+		},
+
+		{
+			Name: "when layer files have empty glob",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrEmptyLayerGlob,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+layers:
+  - name: shared
+    files:
+      - destination: data/
+`,
+			}),
+		},
+
+		{
+			Name: "when layer glob is outside the module",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidLayerGlob,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+layers:
+  - name: shared
+    files:
+      - glob: data/../../secrets/*
+`,
+			}),
+		},
+
+		{
+			Name: "when layer destination is absolute",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidLayerDestination,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+layers:
+  - name: shared
+    files:
+      - glob: data/*
+        destination: /opt/data
+`,
+			}),
+		},
+
+		{
+			Name: "when layer destination is outside the layer",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidLayerDestination,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+layers:
+  - name: shared
+    packages:
+      - path: tools/converter
+        destination: bin/../../
+`,
+			}),
+		},
+
+		{
+			Name: "when image format is invalid",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidImageFormat,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+image:
+  format: docker
+`,
+			}),
+		},
+
+		{
+			Name: "when sbom format is invalid",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidSBOMFormat,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+sbom:
+  format: swid
+`,
+			}),
+		},
+
+		{
+			Name: "when signing is missing keyFile",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrMissingSigningKey,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+signing:
+  publicKeyFile: keys/signing.pub.pem
+`,
+			}),
+		},
+
+		{
+			Name: "when signing keyFile cannot be expanded",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidSigningKeyFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+signing:
+  keyFile: ${SIGNING_KEY
+`,
+			}),
+		},
+
+		{
+			Name: "when a disallowed license is not supported",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidLicense,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+licenses:
+  disallowed: [GPLv3]
+`,
+			}),
+		},
+
+		{
+			Name: "when image format is missing",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidImageFormat,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+image:
+  binaryPath: /var/runtime/bootstrap
+`,
+			}),
+		},
+
+		{
+			Name: "when image binaryPath is relative",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidImageBinaryPath,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+image:
+  format: layout
+  binaryPath: bootstrap
+`,
+			}),
+		},
+
+		{
+			Name: "when image binaryPath is the root directory",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidImageBinaryPath,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+image:
+  format: layout
+  binaryPath: /
+`,
+			}),
+		},
+
+		{
+			Name: "when route path is relative",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidRoutePath,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/api
+routes:
+  - path: orders
+    lambda: lambdas/api
+`,
+			}),
+		},
+
+		{
+			Name: "when route path has a greedy parameter that is not last",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidRoutePath,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/api
+routes:
+  - path: /files/{proxy+}/info
+    lambda: lambdas/api
+`,
+			}),
+		},
+
+		{
+			Name: "when route path has an invalid parameter",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidRoutePath,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/api
+routes:
+  - path: /orders/id-{id}
+    lambda: lambdas/api
+`,
+			}),
+		},
+
+		{
+			Name: "when route path has an empty parameter",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidRoutePath,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/api
+routes:
+  - path: /orders/{}
+    lambda: lambdas/api
+`,
+			}),
+		},
+
+		{
+			Name: "when route Lambda is unknown",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrUnknownRouteLambda,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/api
+routes:
+  - path: /orders
+    lambda: lambdas/missing
+`,
+			}),
+		},
+
+		{
+			Name: "when route event is invalid",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidRouteEvent,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/api
+routes:
+  - path: /orders
+    lambda: lambdas/api
+    event: alb
+`,
+			}),
+		},
+
+		{
+			Name: "when routes are duplicated",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicateRoutes,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+buildPaths:
+  - lambdas/api
+routes:
+  - path: /orders
+    method: GET
+    lambda: lambdas/api
+  - path: /orders/
+    method: get
+    lambda: lambdas/api
+`,
+			}),
+		},
+
+		{
+			Name: "when per-lambda buildFlags has invalid syntax",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotParsePerLambdaFlags,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+outDirectory: tmp
+lambdas:
+  - path: lambdas/api
+    buildFlags: foo'
+`,
+			}),
+		},
+
+		{
+			Name: "with profile merged onto the config",
+
+			PWD:     "/my/app",
+			Profile: "prod",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				Goos:       "linux",
+				Goarch:     "arm64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", []string{"-tags", "prod"}),
+					makeLambda("lambdas/admin", []string{"-tags", "prod"}),
+					makeLambda("lambdas/worker", []string{"-tags", "prod,worker"}),
+				},
+				Layers: []*lambgofile.Layer{
+					{Name: "assets", Files: []*lambgofile.LayerFiles{{Glob: "prod/*.json"}}},
+				},
+				Image: &lambgofile.Image{Format: lambgofile.ImageFormatLayout, BinaryPath: "/opt/bootstrap"},
+				Routes: []*lambgofile.Route{
+					{Path: "/orders", Method: "GET", Lambda: makeLambda("lambdas/worker", []string{"-tags", "prod,worker"}), Event: lambgofile.EventFormatAPIGatewayV2},
+					{Path: "/admin", Lambda: makeLambda("lambdas/admin", []string{"-tags", "prod"}), Event: lambgofile.EventFormatAPIGatewayV2},
+				},
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": profilesFile,
+			}),
+		},
+
+		{
+			Name: "with empty profile",
+
+			PWD:     "/my/app",
+			Profile: "dev",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				Goos:       "linux",
+				Goarch:     "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", []string{"-tags", "dev"}),
+					makeLambda("lambdas/worker", []string{"-tags", "worker"}),
+				},
+				Layers: []*lambgofile.Layer{
+					{Name: "assets", Files: []*lambgofile.LayerFiles{{Glob: "assets/*.json"}, {Glob: "data"}}},
+				},
+				Image: &lambgofile.Image{Format: lambgofile.ImageFormatLayout, BinaryPath: "/var/runtime/bootstrap"},
+				Routes: []*lambgofile.Route{
+					{Path: "/orders", Method: "GET", Lambda: makeLambda("lambdas/api", []string{"-tags", "dev"}), Event: lambgofile.EventFormatAPIGatewayV2},
+				},
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": profilesFile,
+			}),
+		},
+
+		{
+			Name: "with .lambgo.local.yml merged after the profile",
+
+			PWD:     "/my/app",
+			Profile: "prod",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "local",
+				Goos:         "linux",
+				Goarch:       "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", []string{"-tags", "prod"}),
+					makeLambda("lambdas/admin", []string{"-tags", "prod"}),
+					makeLambda("lambdas/worker", nil),
+				},
+				Layers: []*lambgofile.Layer{
+					{Name: "assets", Files: []*lambgofile.LayerFiles{{Glob: "prod/*.json"}}},
+				},
+				Image: &lambgofile.Image{Format: lambgofile.ImageFormatTarball, BinaryPath: "/opt/bootstrap"},
+				Routes: []*lambgofile.Route{
+					{Path: "/orders", Method: "GET", Lambda: makeLambda("lambdas/worker", nil), Event: lambgofile.EventFormatAPIGatewayV2},
+					{Path: "/admin", Lambda: makeLambda("lambdas/admin", []string{"-tags", "prod"}), Event: lambgofile.EventFormatAPIGatewayV2},
+				},
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": profilesFile,
+				"my/app/.lambgo.local.yml": `
+outDirectory: local
+goarch: amd64
+lambdas:
+  - path: lambdas/worker
+    buildFlags: ""
+image:
+  format: tarball
+`,
+			}),
+		},
+
+		{
+			Name: "with empty .lambgo.local.yml and no profile",
+
+			PWD: "/my/app",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				Goos:       "linux",
+				Goarch:     "amd64",
+				Lambdas:    []*lambgofile.Lambda{makeLambda("lambdas/api", nil)},
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":            defaultGoModFile,
+				"my/app/.lambgo.yml":       "buildPaths:\n  - lambdas/api\n",
+				"my/app/.lambgo.local.yml": "# Nothing to override\n",
+			}),
+		},
+
+		{
+			Name: "when the profile does not exist",
+
+			PWD:           "/my/app",
+			Profile:       "staging",
+			ExpectedError: lambgofile.ErrUnknownProfile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": profilesFile,
+			}),
+		},
+
+		{
+			Name: "when the profile is not a mapping",
+
+			PWD:           "/my/app",
+			Profile:       "prod",
+			ExpectedError: lambgofile.ErrInvalidProfile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "profiles:\n  prod: arm64\n",
+			}),
+		},
+
+		{
+			Name: "when the profile contains profiles",
+
+			PWD:           "/my/app",
+			Profile:       "prod",
+			ExpectedError: lambgofile.ErrUnexpectedProfiles,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "profiles:\n  prod:\n    profiles:\n      nested: {}\n",
+			}),
+		},
+
+		{
+			Name: "when .lambgo.local.yml contains profiles",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrUnexpectedProfiles,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":            defaultGoModFile,
+				"my/app/.lambgo.yml":       "buildPaths:\n  - lambdas/api\n",
+				"my/app/.lambgo.local.yml": "profiles:\n  prod: {}\n",
+			}),
+		},
+
+		{
+			Name: "when .lambgo.local.yml is invalid",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotUnmarshalFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":            defaultGoModFile,
+				"my/app/.lambgo.yml":       "buildPaths:\n  - lambdas/api\n",
+				"my/app/.lambgo.local.yml": "buildPaths: {",
+			}),
+		},
+
+		{
+			Name: "when .lambgo.local.yml cannot be read",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotOpenFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":            defaultGoModFile,
+				"my/app/.lambgo.yml":       "buildPaths:\n  - lambdas/api\n",
+				"my/app/.lambgo.local.yml": errors.New("permission denied"),
+			}),
+		},
+
+		{
+			Name: "when the merged config has the wrong types",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotMergeFiles,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":            defaultGoModFile,
+				"my/app/.lambgo.yml":       "buildPaths:\n  - lambdas/api\n",
+				"my/app/.lambgo.local.yml": "buildPaths:\n  api: lambdas/api\n",
+			}),
+		},
+
+		{
+			Name: "with included files, with paths relative to their directory",
+
+			PWD:     "/my/app",
+			Profile: "prod",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				Goos:       "linux",
+				Goarch:     "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", nil),
+					makeLambda("services/billing/lambdas/charge", nil),
+					makeLambda("services/billing/invoices/lambdas/send", nil),
+					makeLambda("services/search/lambdas/query", nil),
+					makeLambda("services/billing/lambdas/refund", []string{"-tags", "prod"}),
+				},
+				Extensions: []*lambgofile.Extension{makeExtension("services/search/extensions/cache", "cache", nil)},
+				Layers: []*lambgofile.Layer{
+					{
+						Name: "search",
+						Packages: []*lambgofile.LayerPackage{
+							{Lambda: *makeLambda("services/search/cmd/index", nil), Destination: "bin/"},
+						},
+						Files: []*lambgofile.LayerFiles{{Glob: "services/search/data/*.json"}},
+					},
+				},
+				Routes: []*lambgofile.Route{
+					{
+						Path:   "/charges",
+						Method: "POST",
+						Lambda: makeLambda("services/billing/lambdas/charge", nil),
+						Event:  lambgofile.EventFormatAPIGatewayV2,
+					},
+				},
+				IncludedFiles: []string{
+					"services/billing/lambgo.yml",
+					"services/billing/invoices/lambgo.yml",
+					"services/search/lambgo.yml",
+				},
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod": defaultGoModFile,
+				"my/app/.lambgo.yml": `
+include:
+  - services/billing
+  - ./services/search/
+buildPaths:
+  - lambdas/api
+profiles:
+  prod:
+    lambdas:
+      - path: services/billing/lambdas/refund
+        buildFlags: -tags prod
+`,
+				"my/app/services/billing/lambgo.yml": `
+include:
+  - invoices
+buildPaths:
+  - lambdas/charge
+lambdas:
+  - path: lambdas/refund
+    buildFlags: -tags refund
+routes:
+  - path: /charges
+    method: POST
+    lambda: lambdas/charge
+`,
+				"my/app/services/billing/invoices/lambgo.yml": `
+include:
+  - ../../search
+buildPaths:
+  - lambdas/send
+`,
+				"my/app/services/search/lambgo.yml": `
+buildPaths:
+  - lambdas/query
+extensions:
+  - path: extensions/cache
+layers:
+  - name: search
+    packages:
+      - path: cmd/index
+        destination: bin
+    files:
+      - glob: data/*.json
+`,
+			}),
+		},
+
+		{
+			Name: "when included files form a cycle",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrIncludeCycle,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":                defaultGoModFile,
+				"my/app/.lambgo.yml":           "include:\n  - services/a\n",
+				"my/app/services/a/lambgo.yml": "include:\n  - ../b\n",
+				"my/app/services/b/lambgo.yml": "include:\n  - ../a\n",
+			}),
+		},
+
+		{
+			Name: "when included files define the same Lambda path",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicateIncludedEntry,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":                      defaultGoModFile,
+				"my/app/.lambgo.yml":                 "include:\n  - services/billing\n  - services\n",
+				"my/app/services/billing/lambgo.yml": "buildPaths:\n  - lambdas/charge\n",
+				"my/app/services/lambgo.yml":         "lambdas:\n  - path: billing/lambdas/charge\n",
+			}),
+		},
+
+		{
+			Name: "when an included file defines a layer defined by .lambgo.yml",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrDuplicateIncludedEntry,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":                     defaultGoModFile,
+				"my/app/.lambgo.yml":                "include:\n  - services/search\nlayers:\n  - name: search\n",
+				"my/app/services/search/lambgo.yml": "layers:\n  - name: search\n",
+			}),
+		},
+
+		{
+			Name: "when an included file has an unsupported key",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrUnsupportedIncludedKey,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":                      defaultGoModFile,
+				"my/app/.lambgo.yml":                 "include:\n  - services/billing\n",
+				"my/app/services/billing/lambgo.yml": "goarch: arm64\n",
+			}),
+		},
+
+		{
+			Name: "when an included file has an invalid list",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidIncludedList,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":                      defaultGoModFile,
+				"my/app/.lambgo.yml":                 "include:\n  - services/billing\n",
+				"my/app/services/billing/lambgo.yml": "buildPaths: lambdas/charge\n",
+			}),
+		},
+
+		{
+			Name: "when an included file is missing",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotReadInclude,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "include:\n  - services/billing\n",
+			}),
+		},
+
+		{
+			Name: "when an included file is invalid",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrCannotUnmarshalFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":                      defaultGoModFile,
+				"my/app/.lambgo.yml":                 "include:\n  - services/billing\n",
+				"my/app/services/billing/lambgo.yml": "buildPaths: {",
+			}),
+		},
+
+		{
+			Name: "when include is outside the module",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidInclude,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "include:\n  - ../other\n",
+			}),
+		},
+
+		{
+			Name: "when include is empty",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrInvalidInclude,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "include:\n  - \"\"\n",
+			}),
+		},
+
+		{
+			Name: "when .lambgo.local.yml contains include",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrUnexpectedInclude,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":            defaultGoModFile,
+				"my/app/.lambgo.yml":       "buildPaths:\n  - lambdas/api\n",
+				"my/app/.lambgo.local.yml": "include:\n  - services/billing\n",
+			}),
+		},
+
+		{
+			Name: "with go.work next to .lambgo.yml",
+
+			PWD: "/my/repo/services/billing/cmd",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/repo",
+				ModulePath: "github.com/my/repo",
+				Goos:       "linux",
+				Goarch:     "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "services/billing/cmd/charge", Module: "services/billing"},
+					{Path: "tools/migrate", Module: "."},
+					{Path: "services/search/cmd/api", Module: "services/search"},
+				},
+				Extensions: []*lambgofile.Extension{
+					{
+						Lambda: lambgofile.Lambda{Path: "services/billing/extensions/telemetry", Module: "services/billing"},
+						Name:   "telemetry",
+					},
+				},
+				Layers: []*lambgofile.Layer{
+					{
+						Name: "tools",
+						Packages: []*lambgofile.LayerPackage{
+							{
+								Lambda:      lambgofile.Lambda{Path: "services/search/cmd/indexer", Module: "services/search"},
+								Destination: "bin/",
+							},
+						},
+					},
+				},
+				Workspace: workspaceFixture,
+			},
+
+			SetupMocks: setupMapFS(workspaceFiles(mapFS{
+				"my/repo/.lambgo.yml": `
+buildPaths:
+  - services/billing/cmd/charge
+  - tools/migrate
+lambdas:
+  - path: cmd/api
+    module: example.com/search
+extensions:
+  - path: extensions/telemetry
+    module: services/billing
+layers:
+  - name: tools
+    packages:
+      - path: cmd/indexer
+        module: ./services/search
+`,
+			})),
+		},
+
+		{
+			Name: "with included file in a workspace",
+
+			PWD: "/my/repo",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/repo",
+				ModulePath: "github.com/my/repo",
+				Goos:       "linux",
+				Goarch:     "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "services/billing/cmd/charge", Module: "services/billing"},
+					{Path: "services/search/cmd/api", Module: "services/search"},
+				},
+				IncludedFiles: []string{"services/billing/lambgo.yml"},
+				Workspace:     workspaceFixture,
+			},
+
+			SetupMocks: setupMapFS(workspaceFiles(mapFS{
+				"my/repo/.lambgo.yml": "include:\n  - services/billing\n",
+				"my/repo/services/billing/lambgo.yml": `
+buildPaths:
+  - cmd/charge
+lambdas:
+  - path: cmd/api
+    module: services/search
+`,
+			})),
+		},
+
+		{
+			Name: "with go.work from GOWORK",
+
+			PWD: "/somewhere/else",
+			EnvVars: map[string]string{
+				"GOWORK": "/my/repo/go.work",
+			},
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/repo",
+				ModulePath: "github.com/my/repo",
+				Goos:       "linux",
+				Goarch:     "amd64",
+				Lambdas:    []*lambgofile.Lambda{{Path: "services/search/cmd/api", Module: "services/search"}},
+				Workspace:  workspaceFixture,
+			},
+
+			SetupMocks: setupMapFS(workspaceFiles(mapFS{
+				"my/repo/.lambgo.yml": "buildPaths:\n  - services/search/cmd/api\n",
+			})),
+		},
+
+		{
+			Name: "with go.work that does not use the root directory",
+
+			PWD: "/my/repo",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath: "/my/repo",
+				Goos:     "linux",
+				Goarch:   "amd64",
+				Lambdas:  []*lambgofile.Lambda{{Path: "services/search/cmd/api", Module: "services/search"}},
+				Workspace: &lambgofile.Workspace{
+					FilePath: "/my/repo/go.work",
+					Modules:  []*lambgofile.WorkspaceModule{{Dir: "services/search", ModulePath: "example.com/search"}},
+				},
+			},
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/repo/go.work":                "go 1.23\n\nuse ./services/search\n",
+				"my/repo/services/search/go.mod": "module example.com/search",
+				"my/repo/.lambgo.yml":            "buildPaths:\n  - services/search/cmd/api\n",
+			}),
+		},
+
+		{
+			Name: "with go.work that is not next to .lambgo.yml",
+
+			PWD: "/my/repo/services/billing",
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/repo/services/billing",
+				ModulePath: "github.com/my/repo/services/billing",
+				Goos:       "linux",
+				Goarch:     "amd64",
+				Lambdas:    []*lambgofile.Lambda{makeLambda("cmd/charge", nil)},
+			},
+
+			SetupMocks: setupMapFS(workspaceFiles(mapFS{
+				"my/repo/services/billing/.lambgo.yml": "buildPaths:\n  - cmd/charge\n",
+			})),
+		},
+
+		{
+			Name: "with GOWORK=off",
+
+			PWD: "/my/repo",
+			EnvVars: map[string]string{
+				"GOWORK": "off",
+			},
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/repo",
+				ModulePath: "github.com/my/repo",
+				Goos:       "linux",
+				Goarch:     "amd64",
+				Lambdas:    []*lambgofile.Lambda{makeLambda("tools/migrate", nil)},
+			},
+
+			SetupMocks: setupMapFS(workspaceFiles(mapFS{
+				"my/repo/.lambgo.yml": "buildPaths:\n  - tools/migrate\n",
+			})),
+		},
+
+		{
+			Name: "when module is set outside of a workspace",
+
+			PWD:           "/my/app",
+			ExpectedError: lambgofile.ErrModuleWithoutWorkspace,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/app/go.mod":      defaultGoModFile,
+				"my/app/.lambgo.yml": "lambdas:\n  - path: cmd/api\n    module: services/search\n",
+			}),
+		},
+
+		{
+			Name: "when module is not used by go.work",
+
+			PWD:           "/my/repo",
+			ExpectedError: lambgofile.ErrUnknownWorkspaceModule,
+
+			SetupMocks: setupMapFS(workspaceFiles(mapFS{
+				"my/repo/.lambgo.yml": "extensions:\n  - path: cmd/telemetry\n    module: services/shipping\n",
+			})),
+		},
+
+		{
+			Name: "when path is not in a module used by go.work",
+
+			PWD:           "/my/repo",
+			ExpectedError: lambgofile.ErrPathOutsideWorkspaceModules,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/repo/go.work":                "go 1.23\n\nuse ./services/search\n",
+				"my/repo/services/search/go.mod": "module example.com/search",
+				"my/repo/.lambgo.yml":            "buildPaths:\n  - tools/migrate\n",
+			}),
+		},
+
+		{
+			Name: "when module paths are duplicated",
+
+			PWD:           "/my/repo",
+			ExpectedError: lambgofile.ErrDuplicatePaths,
+
+			SetupMocks: setupMapFS(workspaceFiles(mapFS{
+				"my/repo/.lambgo.yml": `
+buildPaths:
+  - services/search/cmd/api
+lambdas:
+  - path: cmd/api
+    module: services/search
+`,
+			})),
+		},
+
+		{
+			Name: "when go.work is invalid",
+
+			PWD:           "/my/repo",
+			ExpectedError: lambgofile.ErrCannotParseGoWork,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/repo/go.work":     "use (\n",
+				"my/repo/.lambgo.yml": "buildPaths:\n  - tools/migrate\n",
+			}),
+		},
+
+		{
+			Name: "when a module used by go.work is missing go.mod",
+
+			PWD:           "/my/repo",
+			ExpectedError: lambgofile.ErrCannotOpenFile,
+
+			SetupMocks: setupMapFS(mapFS{
+				"my/repo/go.work":     "go 1.23\n\nuse ./services/search\n",
+				"my/repo/.lambgo.yml": "buildPaths:\n  - tools/migrate\n",
+			}),
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		for key, value := range entry.EnvVars {
+			ensure.T().Setenv(key, value)
+		}
+
+		config, err := entry.Subject.LoadConfig(entry.PWD, entry.Profile)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(config).Equals(entry.ExpectedConfig)
+	})
+}
+
+func TestFindModule(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	setupFiles := func(files map[string]string) func(*Mocks) {
+		return func(m *Mocks) {
+			m.FS.EXPECT().ReadFile(gomock.Any()).AnyTimes().
+				DoAndReturn(func(name string) ([]byte, error) {
+					data, ok := files[name]
+					if !ok {
+						return nil, fs.ErrNotExist
+					}
+
+					return []byte(data), nil
+				})
+		}
+	}
+
+	table := []struct {
+		Name string
+		PWD  string
+
+		ExpectedModule *lambgofile.Module
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *lambgofile.Loader
+	}{
+		{
+			Name: "with go.mod in current directory",
+			PWD:  "/my/app",
+
+			ExpectedModule: &lambgofile.Module{RootPath: "/my/app", ModulePath: "github.com/my/app"},
+			SetupMocks: setupFiles(map[string]string{
+				"my/app/go.mod": "module github.com/my/app",
+			}),
+		},
+
+		{
+			Name: "with go.mod in parent directory, even without a .lambgo.yml file",
+			PWD:  "/my/app/some/nested/pkg",
+
+			ExpectedModule: &lambgofile.Module{RootPath: "/my/app", ModulePath: "github.com/my/app"},
+			SetupMocks: setupFiles(map[string]string{
+				"my/app/go.mod": "module github.com/my/app",
+			}),
+		},
+
+		{
+			Name: "with no go.mod",
+			PWD:  "/my/app",
+
+			ExpectedError: lambgofile.ErrCannotFindGoModule,
+			SetupMocks:    setupFiles(map[string]string{}),
+		},
+
+		{
+			Name: "with go.mod missing the module path",
+			PWD:  "/my/app",
+
+			ExpectedError: lambgofile.ErrCannotParseGoModule,
+			SetupMocks: setupFiles(map[string]string{
+				"my/app/go.mod": "go 1.23",
+			}),
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		module, err := entry.Subject.FindModule(entry.PWD)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(module).Equals(entry.ExpectedModule)
+	})
+}
+
+func TestFindRoot(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	setupFiles := func(files map[string]any) func(*Mocks) {
+		return func(m *Mocks) {
+			m.FS.EXPECT().ReadFile(gomock.Any()).AnyTimes().
+				DoAndReturn(func(name string) ([]byte, error) {
+					data, ok := files[name].(string)
+					if !ok {
+						return nil, fs.ErrNotExist
+					}
+
+					return []byte(data), nil
+				})
+		}
+	}
+
+	table := []struct {
+		Name           string
+		PWD            string
+		ExpectedModule *lambgofile.Module
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *lambgofile.Loader
+	}{
+		{
+			Name: "with .lambgo.yml next to go.work",
+			PWD:  "/my/repo/services/billing/cmd",
+			ExpectedModule: &lambgofile.Module{
+				RootPath:   "/my/repo",
+				ModulePath: "github.com/my/repo",
+				Workspace:  workspaceFixture,
+			},
+			SetupMocks: setupFiles(workspaceFiles(map[string]any{"my/repo/.lambgo.yml": ""})),
+		},
+		{
+			Name: "without .lambgo.yml next to go.work",
+			PWD:  "/my/repo/services/billing/cmd",
+			ExpectedModule: &lambgofile.Module{
+				RootPath:   "/my/repo/services/billing",
+				ModulePath: "github.com/my/repo/services/billing",
+			},
+			SetupMocks: setupFiles(workspaceFiles(map[string]any{})),
+		},
+		{
+			Name:          "without a module",
+			PWD:           "/my/repo",
+			ExpectedError: lambgofile.ErrCannotFindGoModule,
+			SetupMocks:    setupFiles(map[string]any{}),
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		ensure.T().Setenv("GOWORK", "")
+
+		module, err := entry.Subject.FindRoot(entry.PWD)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(module).Equals(entry.ExpectedModule)
+	})
+}
+
+func TestParseConfig(t *testing.T) {
+	ensure := ensure.New(t)
+
+	module := &lambgofile.Module{RootPath: "/my/app", ModulePath: "github.com/my/app"}
+	readFile := func(name string) ([]byte, error) {
+		if name == "services/billing/lambgo.yml" {
+			return []byte("buildPaths:\n  - lambdas/charge\n"), nil
+		}
+
+		return nil, fs.ErrNotExist
+	}
+
+	ensure.Run("with valid config", func(ensure ensuring.E) {
+		config, err := lambgofile.ParseConfig([]byte("buildFlags: -tags prod\nbuildPaths:\n  - lambdas/api\n"), nil, module, "", readFile)
+		ensure(err).IsNotError()
+		ensure(config).Equals(&lambgofile.Config{
+			RootPath:   "/my/app",
+			ModulePath: "github.com/my/app",
+			Goos:       "linux",
+			Goarch:     "amd64",
+			Lambdas:    []*lambgofile.Lambda{makeLambda("lambdas/api", []string{"-tags", "prod"})},
+		})
+	})
+
+	ensure.Run("with profile and .lambgo.local.yml", func(ensure ensuring.E) {
+		data := []byte("buildPaths:\n  - lambdas/api\nprofiles:\n  prod:\n    goarch: arm64\n")
+		config, err := lambgofile.ParseConfig(data, []byte("goos: darwin\n"), module, "prod", readFile)
+		ensure(err).IsNotError()
+		ensure(config).Equals(&lambgofile.Config{
+			RootPath:   "/my/app",
+			ModulePath: "github.com/my/app",
+			Goos:       "darwin",
+			Goarch:     "arm64",
+			Lambdas:    []*lambgofile.Lambda{makeLambda("lambdas/api", nil)},
+		})
+	})
+
+	ensure.Run("with invalid config", func(ensure ensuring.E) {
+		config, err := lambgofile.ParseConfig([]byte("buildPaths: {"), nil, module, "", readFile)
+		ensure(err).IsError(lambgofile.ErrCannotUnmarshalFile)
+		ensure(config).IsNil()
+	})
+
+	ensure.Run("with included file", func(ensure ensuring.E) {
+		config, err := lambgofile.ParseConfig([]byte("include:\n  - services/billing\n"), nil, module, "", readFile)
+		ensure(err).IsNotError()
+		ensure(config).Equals(&lambgofile.Config{
+			RootPath:      "/my/app",
+			ModulePath:    "github.com/my/app",
+			Goos:          "linux",
+			Goarch:        "amd64",
+			Lambdas:       []*lambgofile.Lambda{makeLambda("services/billing/lambdas/charge", nil)},
+			IncludedFiles: []string{"services/billing/lambgo.yml"},
+		})
+	})
+
+	ensure.Run("with missing included file", func(ensure ensuring.E) {
+		config, err := lambgofile.ParseConfig([]byte("include:\n  - services/missing\n"), nil, module, "", readFile)
+		ensure(err).IsError(lambgofile.ErrCannotReadInclude)
+		ensure(config).IsNil()
+	})
+}
+
+// workspaceFixture is the workspace of the files from workspaceFiles.
+//
+//nolint:gochecknoglobals // Shared test fixture
+var workspaceFixture = &lambgofile.Workspace{
+	FilePath: "/my/repo/go.work",
+	Modules: []*lambgofile.WorkspaceModule{
+		{Dir: ".", ModulePath: "github.com/my/repo"},
+		{Dir: "services/billing", ModulePath: "github.com/my/repo/services/billing"},
+		{Dir: "services/search", ModulePath: "example.com/search"},
+	},
+}
+
+// workspaceFiles adds a go.work file to the files, which uses the root module and two service modules.
+// It also uses a module outside the root, which is ignored.
+func workspaceFiles[T ~map[string]any](files T) T {
+	files["my/repo/go.work"] = "go 1.23\n\nuse (\n\t.\n\t./services/billing\n\t./services/search\n\t../shared\n)\n"
+	files["my/repo/go.mod"] = "module github.com/my/repo"
+	files["my/repo/services/billing/go.mod"] = "module github.com/my/repo/services/billing"
+	files["my/repo/services/search/go.mod"] = "module example.com/search"
+	files["my/shared/go.mod"] = "module github.com/my/shared"
+
+	return files
+}
+
+func makeLambda(path string, buildFlags []string) *lambgofile.Lambda {
+	return &lambgofile.Lambda{
+		Path:       path,
+		BuildFlags: buildFlags,
+	}
+}
+
+func makeExtension(path, name string, buildFlags []string) *lambgofile.Extension {
+	return &lambgofile.Extension{
+		Lambda: *makeLambda(path, buildFlags),
+		Name:   name,
 	}
 }
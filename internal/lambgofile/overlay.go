@@ -0,0 +1,301 @@
+package lambgofile
+
+import (
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/goccy/go-yaml"
+)
+
+const profilesKey = "profiles"
+
+var layerPackagesKeyPattern = regexp.MustCompile(`^layers\[\d+\]\.packages$`)
+
+// configFile is the data of a config file, and its path for error messages.
+type configFile struct {
+	path string
+	data []byte
+}
+
+// configSources are merged into the config.
+type configSources struct {
+	file *configFile
+
+	// localFile is nil if .lambgo.local.yml does not exist.
+	localFile *configFile
+
+	// profile is empty if one was not selected.
+	profile string
+
+	// readFile relative to the module root, which is used for included files.
+	readFile func(name string) ([]byte, error)
+}
+
+// mergedDocument is a .lambgo.yml document, with its included files, a profile, and .lambgo.local.yml merged onto it.
+type mergedDocument struct {
+	data map[string]any
+
+	// origins describe the file or profile that last set each key, such as profile prod for lambdas[1].buildFlags.
+	// Keys that are missing were set by .lambgo.yml.
+	origins map[string]string
+
+	// includedFiles are relative to the module root, in the order they were included.
+	includedFiles []string
+}
+
+// unmarshalConfig from the config file, after merging its included files, and deep-merging the profile and the local file onto it.
+//
+// Maps are merged key by key, and other values replace the existing value.
+// Lists of entries with a key are merged by that key: buildPaths by the path itself, lambdas, extensions,
+// and layer packages by path, layers by name, and routes by method and path.
+// An entry with the same key is merged onto the existing entry, and new entries are appended.
+// All other lists, such as the files of a layer, replace the existing list.
+func unmarshalConfig(sources *configSources) (*rawConfig, *mergedDocument, error) {
+	rawCfg := &rawConfig{}
+
+	// Without includes or overlays, the file is parsed directly, so errors include its line numbers
+	if err := yaml.Unmarshal(sources.file.data, rawCfg); err != nil {
+		return nil, nil, erk.WrapWith(ErrCannotUnmarshalFile, err, erk.Params{"path": sources.file.path})
+	}
+
+	if len(rawCfg.Include) == 0 && sources.localFile == nil && sources.profile == "" {
+		return rawCfg, &mergedDocument{}, nil
+	}
+
+	data, err := sources.file.document()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rawProfiles := data[profilesKey]
+	delete(data, profilesKey)
+
+	doc := &mergedDocument{data: data, origins: make(map[string]string)}
+	if err := doc.includeFiles(sources.readFile); err != nil {
+		return nil, nil, err
+	}
+
+	mergedSources := append([]string{sources.file.path}, doc.includedFiles...)
+
+	if sources.profile != "" {
+		profileData, err := findProfile(rawProfiles, sources.profile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		name := "profile " + sources.profile
+		if err := checkOverlayKeys(profileData, name); err != nil {
+			return nil, nil, err
+		}
+
+		doc.mergeMap(doc.data, profileData, "", name)
+		mergedSources = append(mergedSources, name)
+	}
+
+	if sources.localFile != nil {
+		localData, err := sources.localFile.document()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if err := checkOverlayKeys(localData, sources.localFile.path); err != nil {
+			return nil, nil, err
+		}
+
+		doc.mergeMap(doc.data, localData, "", LocalConfigFileName)
+		mergedSources = append(mergedSources, sources.localFile.path)
+	}
+
+	params := erk.Params{"sources": strings.Join(mergedSources, ", ")}
+	merged, err := yaml.Marshal(doc.data)
+	if err != nil {
+		return nil, nil, erk.WrapWith(ErrCannotMergeFiles, err, params)
+	}
+
+	rawCfg = &rawConfig{}
+	if err := yaml.Unmarshal(merged, rawCfg); err != nil {
+		return nil, nil, erk.WrapWith(ErrCannotMergeFiles, err, params)
+	}
+
+	return rawCfg, doc, nil
+}
+
+// checkOverlayKeys for keys that are only supported in .lambgo.yml.
+func checkOverlayKeys(data map[string]any, source string) error {
+	if _, ok := data[profilesKey]; ok {
+		return erk.WithParams(ErrUnexpectedProfiles, erk.Params{"source": source})
+	}
+
+	if _, ok := data[includeKey]; ok {
+		return erk.WithParams(ErrUnexpectedInclude, erk.Params{"source": source})
+	}
+
+	return nil
+}
+
+func (file *configFile) document() (map[string]any, error) {
+	var data map[string]any
+	if err := yaml.Unmarshal(file.data, &data); err != nil {
+		return nil, erk.WrapWith(ErrCannotUnmarshalFile, err, erk.Params{"path": file.path})
+	}
+
+	if data == nil {
+		data = make(map[string]any)
+	}
+
+	return data, nil
+}
+
+func findProfile(rawProfiles any, profile string) (map[string]any, error) {
+	profiles, _ := rawProfiles.(map[string]any)
+
+	rawProfile, ok := profiles[profile]
+	if !ok {
+		names := slices.Sorted(maps.Keys(profiles))
+		if len(names) == 0 {
+			names = []string{"none"}
+		}
+
+		return nil, erk.WithParams(ErrUnknownProfile, erk.Params{"profile": profile, "profiles": strings.Join(names, ", ")})
+	}
+
+	// An empty profile is allowed, so profiles can be selected even when they match .lambgo.yml
+	if rawProfile == nil {
+		return map[string]any{}, nil
+	}
+
+	profileData, ok := rawProfile.(map[string]any)
+	if !ok {
+		return nil, erk.WithParams(ErrInvalidProfile, erk.Params{"profile": profile})
+	}
+
+	return profileData, nil
+}
+
+func (doc *mergedDocument) mergeMap(base, overlay map[string]any, prefix, name string) {
+	for _, key := range slices.Sorted(maps.Keys(overlay)) {
+		value := overlay[key]
+		keyPath := joinKey(prefix, key)
+		baseValue, exists := base[key]
+
+		if entryKey := listEntryKey(keyPath); entryKey != nil {
+			baseList, baseIsList := baseValue.([]any)
+			overlayList, overlayIsList := value.([]any)
+			if overlayIsList && (baseIsList || !exists) {
+				base[key] = doc.mergeList(baseList, overlayList, keyPath, name, entryKey)
+				continue
+			}
+		}
+
+		baseMap, baseIsMap := baseValue.(map[string]any)
+		overlayMap, overlayIsMap := value.(map[string]any)
+		if baseIsMap && overlayIsMap {
+			doc.mergeMap(baseMap, overlayMap, keyPath, name)
+			continue
+		}
+
+		base[key] = value
+		doc.markSet(keyPath, value, name)
+	}
+}
+
+func (doc *mergedDocument) mergeList(base, overlay []any, keyPath, name string, entryKey func(any) string) []any {
+	for _, entry := range overlay {
+		key := entryKey(entry)
+		i := slices.IndexFunc(base, func(baseEntry any) bool { return key != "" && entryKey(baseEntry) == key })
+		if i < 0 {
+			base = append(base, entry)
+			doc.markSet(indexKey(keyPath, len(base)-1), entry, name)
+			continue
+		}
+
+		// Entries of buildPaths are their own key, so there is nothing to merge
+		baseMap, baseIsMap := base[i].(map[string]any)
+		overlayMap, overlayIsMap := entry.(map[string]any)
+		if baseIsMap && overlayIsMap {
+			doc.mergeMap(baseMap, overlayMap, indexKey(keyPath, i), name)
+		}
+	}
+
+	return base
+}
+
+// markSet records that the overlay set the key, and everything within it.
+func (doc *mergedDocument) markSet(keyPath string, value any, name string) {
+	doc.origins[keyPath] = name
+
+	switch value := value.(type) {
+	case map[string]any:
+		for key, nested := range value {
+			doc.markSet(joinKey(keyPath, key), nested, name)
+		}
+	case []any:
+		for i, nested := range value {
+			doc.markSet(indexKey(keyPath, i), nested, name)
+		}
+	}
+}
+
+// listEntryKey returns a function for the key of each entry in the list, or nil if the list is replaced instead of merged.
+func listEntryKey(keyPath string) func(any) string {
+	switch {
+	case keyPath == "buildPaths":
+		return func(entry any) string { return cleanEntryPath(entry) }
+	case keyPath == "lambdas" || keyPath == "extensions" || layerPackagesKeyPattern.MatchString(keyPath):
+		return lambdaEntryKey
+	case keyPath == "layers":
+		return func(entry any) string { return fmt.Sprint(entryField(entry, "name")) }
+	case keyPath == "routes":
+		return func(entry any) string {
+			return strings.ToUpper(fmt.Sprint(entryField(entry, "method"))) + " " + fmt.Sprint(entryField(entry, "path"))
+		}
+	default:
+		return nil
+	}
+}
+
+// entryField returns the field of a list entry, or an empty string if it is missing.
+func entryField(entry any, field string) any {
+	fields, _ := entry.(map[string]any)
+	if value, ok := fields[field]; ok && value != nil {
+		return value
+	}
+
+	return ""
+}
+
+// lambdaEntryKey is the path of the Lambda, which is within its module when the module is set.
+func lambdaEntryKey(entry any) string {
+	entryPath := cleanEntryPath(entryField(entry, "path"))
+	if module, ok := entryField(entry, "module").(string); ok && module != "" && entryPath != "" {
+		return filepath.Join(module, entryPath)
+	}
+
+	return entryPath
+}
+
+func cleanEntryPath(entry any) string {
+	entryPath, ok := entry.(string)
+	if !ok || entryPath == "" {
+		return ""
+	}
+
+	return filepath.Clean(entryPath)
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+
+	return prefix + "." + key
+}
+
+func indexKey(keyPath string, i int) string {
+	return fmt.Sprintf("%s[%d]", keyPath, i)
+}
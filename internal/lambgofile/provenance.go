@@ -0,0 +1,259 @@
+package lambgofile
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Origin of a resolved config value.
+type Origin string
+
+const (
+	OriginDefault     Origin = "default"
+	OriginTopLevel    Origin = "top-level key"
+	OriginPerLambda   Origin = "per-lambda key"
+	OriginEnvironment Origin = "environment variable expansion"
+	OriginFlag        Origin = "CLI flag"
+)
+
+// Source of a resolved config value.
+type Source struct {
+	Origin Origin
+
+	// Key in .lambgo.yml that sets the value, such as buildFlags or lambdas[2].buildFlags.
+	// For CLI flags, it is the name of the flag, such as --num-parallel.
+	Key string
+
+	// Raw value of the key, before environment variables are expanded.
+	Raw string
+
+	// EnvVars that are expanded in the raw value.
+	EnvVars []string
+
+	// Overlay that set the key, such as an included file, profile prod, or .lambgo.local.yml.
+	// It is empty when the key was set by .lambgo.yml.
+	Overlay string
+}
+
+// Provenance records the source of each resolved value in the config.
+type Provenance struct {
+	// Fields shared by all targets, keyed by their .lambgo.yml key, such as goos.
+	Fields map[string]*Source
+
+	// Targets are keyed by their path, and then by their .lambgo.yml key, such as buildFlags.
+	// Lambdas and extensions use their path, and layer packages use their path within the layer (eg. layers/tools/cmd/convert).
+	Targets map[string]map[string]*Source
+}
+
+// LayerPackageKey is the key of the layer package in Provenance.Targets.
+func LayerPackageKey(layer *Layer, pkg *LayerPackage) string {
+	return path.Join(layer.Path(), pkg.Path)
+}
+
+// String describes the source, such as: per-lambda key lambdas[2].buildFlags: "-tags prod".
+func (source *Source) String() string {
+	key := source.Key
+	if source.Overlay != "" {
+		key += " (" + source.Overlay + ")"
+	}
+
+	switch source.Origin {
+	case OriginDefault:
+		return string(OriginDefault)
+	case OriginEnvironment:
+		return fmt.Sprintf("%s of $%s in %s: %q", source.Origin, strings.Join(source.EnvVars, ", $"), key, source.Raw)
+	default:
+		return fmt.Sprintf("%s %s: %q", source.Origin, key, source.Raw)
+	}
+}
+
+// provenance of the values in the raw config, where origins are the overlays that set each key.
+// Paths of entries with a module are resolved using the workspace, like when the config is parsed.
+func (raw *rawConfig) provenance(origins map[string]string, workspace *Workspace) *Provenance {
+	provenance := &Provenance{
+		Fields: map[string]*Source{
+			"outDirectory":       topLevelSource("outDirectory", raw.OutDirectory),
+			"zippedFileName":     topLevelSource("zippedFileName", raw.ZippedFileName),
+			"goos":               topLevelSource("goos", raw.Goos),
+			"goarch":             topLevelSource("goarch", raw.Goarch),
+			"templatesDirectory": topLevelSource("templatesDirectory", raw.TemplatesDirectory),
+		},
+		Targets: make(map[string]map[string]*Source),
+	}
+
+	buildFlags := expandedSource(topLevelSource("buildFlags", raw.RawBuildFlags))
+	provenance.Fields["buildFlags"] = buildFlags
+
+	// Build options are only explained when they are set, since the default is only -trimpath
+	var buildOptions *Source
+	if raw.RawBuildOptions != nil {
+		buildOptions = expandedSource(topLevelSource("buildOptions", raw.RawBuildOptions.String()))
+		provenance.Fields["buildOptions"] = buildOptions
+	}
+
+	if raw.RawImage != nil {
+		provenance.Fields["image.format"] = topLevelSource("image.format", raw.RawImage.Format)
+		provenance.Fields["image.binaryPath"] = topLevelSource("image.binaryPath", raw.RawImage.BinaryPath)
+	}
+
+	if raw.RawSBOM != nil {
+		provenance.Fields["sbom.format"] = topLevelSource("sbom.format", raw.RawSBOM.Format)
+	}
+
+	if raw.RawLicenses != nil {
+		bundle := ""
+		if raw.RawLicenses.Bundle {
+			bundle = "true"
+		}
+
+		provenance.Fields["licenses.bundle"] = topLevelSource("licenses.bundle", bundle)
+		provenance.Fields["licenses.disallowed"] = topLevelSource("licenses.disallowed", strings.Join(raw.RawLicenses.Disallowed, ", "))
+	}
+
+	if raw.RawSigning != nil {
+		provenance.Fields["signing.keyFile"] = expandedSource(topLevelSource("signing.keyFile", raw.RawSigning.KeyFile))
+		provenance.Fields["signing.publicKeyFile"] = expandedSource(topLevelSource("signing.publicKeyFile", raw.RawSigning.PublicKeyFile))
+	}
+
+	if raw.RawVersionInjection != nil {
+		provenance.Fields["versionInjection.commit"] = topLevelSource("versionInjection.commit", raw.RawVersionInjection.Commit)
+		provenance.Fields["versionInjection.dirty"] = topLevelSource("versionInjection.dirty", raw.RawVersionInjection.Dirty)
+		provenance.Fields["versionInjection.tag"] = topLevelSource("versionInjection.tag", raw.RawVersionInjection.Tag)
+		provenance.Fields["versionInjection.buildTime"] = topLevelSource("versionInjection.buildTime", raw.RawVersionInjection.BuildTime)
+		provenance.Fields["versionInjection.lambdaPath"] = topLevelSource("versionInjection.lambdaPath", raw.RawVersionInjection.LambdaPath)
+	}
+
+	for _, buildPath := range raw.BuildPaths {
+		targetSources := map[string]*Source{"buildFlags": buildFlags}
+		setBuildOptionsSource(targetSources, "", nil, buildOptions)
+		provenance.Targets[filepath.Clean(buildPath)] = targetSources
+	}
+
+	for i, rawLambda := range raw.RawLambdas {
+		key := fmt.Sprintf("lambdas[%d]", i)
+		targetSources := map[string]*Source{
+			"buildFlags": perLambdaFlagsSource(key+".buildFlags", rawLambda.RawBuildFlags, buildFlags),
+		}
+		setBuildOptionsSource(targetSources, key+".buildOptions", rawLambda.RawBuildOptions, buildOptions)
+		provenance.Targets[workspace.resolvePath(rawLambda.Module, rawLambda.Path)] = targetSources
+	}
+
+	for i, rawExtension := range raw.RawExtensions {
+		key := fmt.Sprintf("extensions[%d]", i)
+		targetSources := map[string]*Source{
+			"buildFlags": perLambdaFlagsSource(key+".buildFlags", rawExtension.RawBuildFlags, buildFlags),
+			"name":       perLambdaSource(key+".name", rawExtension.Name),
+		}
+		setBuildOptionsSource(targetSources, key+".buildOptions", rawExtension.RawBuildOptions, buildOptions)
+		provenance.Targets[workspace.resolvePath(rawExtension.Module, rawExtension.Path)] = targetSources
+	}
+
+	for i, rawLayer := range raw.RawLayers {
+		layer := &Layer{Name: rawLayer.Name}
+
+		for j, rawPackage := range rawLayer.Packages {
+			key := fmt.Sprintf("layers[%d].packages[%d]", i, j)
+			pkg := &LayerPackage{Lambda: Lambda{Path: workspace.resolvePath(rawPackage.Module, rawPackage.Path)}}
+			targetSources := map[string]*Source{
+				"buildFlags":  perLambdaFlagsSource(key+".buildFlags", rawPackage.RawBuildFlags, buildFlags),
+				"destination": perLambdaSource(key+".destination", rawPackage.Destination),
+			}
+			setBuildOptionsSource(targetSources, key+".buildOptions", rawPackage.RawBuildOptions, buildOptions)
+			provenance.Targets[LayerPackageKey(layer, pkg)] = targetSources
+		}
+	}
+
+	for _, source := range provenance.sources() {
+		if source.Origin != OriginDefault {
+			source.Overlay = origins[source.Key]
+		}
+	}
+
+	return provenance
+}
+
+func (provenance *Provenance) sources() []*Source {
+	sources := slices.Collect(maps.Values(provenance.Fields))
+	for _, targetSources := range provenance.Targets {
+		sources = slices.AppendSeq(sources, maps.Values(targetSources))
+	}
+
+	return sources
+}
+
+// topLevelSource of a value, which is a default if the key is not set.
+func topLevelSource(key, raw string) *Source {
+	if raw == "" {
+		return &Source{Origin: OriginDefault, Key: key}
+	}
+
+	return &Source{Origin: OriginTopLevel, Key: key, Raw: raw}
+}
+
+// perLambdaSource of a value, which is a default if the key is not set.
+func perLambdaSource(key, raw string) *Source {
+	if raw == "" {
+		return &Source{Origin: OriginDefault, Key: key}
+	}
+
+	return &Source{Origin: OriginPerLambda, Key: key, Raw: raw}
+}
+
+// perLambdaFlagsSource of build flags, which are inherited from the top-level buildFlags if the key is not set.
+// Explicitly setting the key to "" overrides the top-level buildFlags.
+func perLambdaFlagsSource(key string, rawFlags *string, defaultSource *Source) *Source {
+	if rawFlags == nil {
+		return defaultSource
+	}
+
+	return expandedSource(&Source{Origin: OriginPerLambda, Key: key, Raw: *rawFlags})
+}
+
+// setBuildOptionsSource of the target, which is the per-lambda key when it is set, since it is merged onto the top-level buildOptions.
+// The source is not set when neither are set.
+func setBuildOptionsSource(targetSources map[string]*Source, key string, rawOptions *rawBuildOptions, defaultSource *Source) {
+	switch {
+	case rawOptions != nil:
+		targetSources["buildOptions"] = expandedSource(&Source{Origin: OriginPerLambda, Key: key, Raw: rawOptions.String()})
+	case defaultSource != nil:
+		targetSources["buildOptions"] = defaultSource
+	}
+}
+
+// expandedSource marks the source as coming from environment variable expansion, if the raw value references any.
+func expandedSource(source *Source) *Source {
+	envVars := referencedEnvVars(source.Raw)
+	if len(envVars) == 0 {
+		return source
+	}
+
+	source.Origin = OriginEnvironment
+	source.EnvVars = envVars
+	return source
+}
+
+// referencedEnvVars in the raw build flags, which have already been parsed successfully.
+func referencedEnvVars(rawFlags string) []string {
+	word, err := syntax.NewParser().Document(strings.NewReader(rawFlags))
+	if err != nil || word == nil {
+		return nil
+	}
+
+	var envVars []string
+	syntax.Walk(word, func(node syntax.Node) bool {
+		if paramExp, ok := node.(*syntax.ParamExp); ok {
+			envVars = append(envVars, paramExp.Param.Value)
+		}
+
+		return true
+	})
+
+	slices.Sort(envVars)
+	return slices.Compact(envVars)
+}
@@ -0,0 +1,426 @@
+package lambgofile_test
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/io/mock_fs"
+	"github.com/golang/mock/gomock"
+)
+
+func TestExplainConfig(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	setupFiles := func(files map[string]string) func(*Mocks) {
+		return func(m *Mocks) {
+			m.FS.EXPECT().ReadFile(gomock.Any()).AnyTimes().
+				DoAndReturn(func(name string) ([]byte, error) {
+					data, ok := files[name]
+					if !ok {
+						return nil, fs.ErrNotExist
+					}
+
+					return []byte(data), nil
+				})
+		}
+	}
+
+	defaultFields := func(buildFlags *lambgofile.Source) map[string]*lambgofile.Source {
+		return map[string]*lambgofile.Source{
+			"outDirectory":       {Origin: lambgofile.OriginDefault, Key: "outDirectory"},
+			"zippedFileName":     {Origin: lambgofile.OriginDefault, Key: "zippedFileName"},
+			"goos":               {Origin: lambgofile.OriginDefault, Key: "goos"},
+			"goarch":             {Origin: lambgofile.OriginDefault, Key: "goarch"},
+			"templatesDirectory": {Origin: lambgofile.OriginDefault, Key: "templatesDirectory"},
+			"buildFlags":         buildFlags,
+		}
+	}
+
+	table := []struct {
+		Name    string
+		Profile string
+
+		ExpectedConfig     *lambgofile.Config
+		ExpectedProvenance *lambgofile.Provenance
+		ExpectedError      error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *lambgofile.Loader
+	}{
+		{
+			Name: "with top-level and per-lambda keys",
+			SetupMocks: setupFiles(map[string]string{
+				"my/app/go.mod": "module github.com/my/app",
+				"my/app/.lambgo.yml": `
+goarch: arm64
+outDirectory: dist
+buildFlags: -tags $LAMBGO_TEST_TAGS
+buildPaths:
+  - lambdas/api
+lambdas:
+  - path: lambdas/worker
+    buildFlags: -tags worker
+  - path: lambdas/cron
+    buildFlags: ""
+extensions:
+  - path: extensions/trace
+layers:
+  - name: tools
+    packages:
+      - path: cmd/convert
+        destination: tools
+image:
+  format: layout
+`,
+			}),
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:     "/my/app",
+				ModulePath:   "github.com/my/app",
+				OutDirectory: "dist",
+				Goos:         "linux",
+				Goarch:       "arm64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", []string{"-tags", "prod"}),
+					makeLambda("lambdas/worker", []string{"-tags", "worker"}),
+					makeLambda("lambdas/cron", nil),
+				},
+				Extensions: []*lambgofile.Extension{makeExtension("extensions/trace", "trace", []string{"-tags", "prod"})},
+				Layers: []*lambgofile.Layer{
+					{
+						Name: "tools",
+						Packages: []*lambgofile.LayerPackage{
+							{Lambda: *makeLambda("cmd/convert", []string{"-tags", "prod"}), Destination: "tools/"},
+						},
+					},
+				},
+				Image: &lambgofile.Image{Format: lambgofile.ImageFormatLayout, BinaryPath: "/var/runtime/bootstrap"},
+			},
+
+			ExpectedProvenance: func() *lambgofile.Provenance {
+				buildFlags := &lambgofile.Source{
+					Origin:  lambgofile.OriginEnvironment,
+					Key:     "buildFlags",
+					Raw:     "-tags $LAMBGO_TEST_TAGS",
+					EnvVars: []string{"LAMBGO_TEST_TAGS"},
+				}
+
+				fields := defaultFields(buildFlags)
+				fields["goarch"] = &lambgofile.Source{Origin: lambgofile.OriginTopLevel, Key: "goarch", Raw: "arm64"}
+				fields["outDirectory"] = &lambgofile.Source{Origin: lambgofile.OriginTopLevel, Key: "outDirectory", Raw: "dist"}
+				fields["image.format"] = &lambgofile.Source{Origin: lambgofile.OriginTopLevel, Key: "image.format", Raw: "layout"}
+				fields["image.binaryPath"] = &lambgofile.Source{Origin: lambgofile.OriginDefault, Key: "image.binaryPath"}
+
+				return &lambgofile.Provenance{
+					Fields: fields,
+					Targets: map[string]map[string]*lambgofile.Source{
+						"lambdas/api": {"buildFlags": buildFlags},
+						"lambdas/worker": {
+							"buildFlags": {Origin: lambgofile.OriginPerLambda, Key: "lambdas[0].buildFlags", Raw: "-tags worker"},
+						},
+						"lambdas/cron": {
+							"buildFlags": {Origin: lambgofile.OriginPerLambda, Key: "lambdas[1].buildFlags", Raw: ""},
+						},
+						"extensions/trace": {
+							"buildFlags": buildFlags,
+							"name":       {Origin: lambgofile.OriginDefault, Key: "extensions[0].name"},
+						},
+						"layers/tools/cmd/convert": {
+							"buildFlags":  buildFlags,
+							"destination": {Origin: lambgofile.OriginPerLambda, Key: "layers[0].packages[0].destination", Raw: "tools"},
+						},
+					},
+				}
+			}(),
+		},
+
+		{
+			Name: "with defaults",
+			SetupMocks: setupFiles(map[string]string{
+				"my/app/go.mod":      "module github.com/my/app",
+				"my/app/.lambgo.yml": "buildPaths:\n  - lambdas/api\n",
+			}),
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				Goos:       "linux",
+				Goarch:     "amd64",
+				Lambdas:    []*lambgofile.Lambda{makeLambda("lambdas/api", nil)},
+			},
+
+			ExpectedProvenance: &lambgofile.Provenance{
+				Fields: defaultFields(&lambgofile.Source{Origin: lambgofile.OriginDefault, Key: "buildFlags"}),
+				Targets: map[string]map[string]*lambgofile.Source{
+					"lambdas/api": {"buildFlags": {Origin: lambgofile.OriginDefault, Key: "buildFlags"}},
+				},
+			},
+		},
+
+		{
+			Name:    "with profile and .lambgo.local.yml",
+			Profile: "prod",
+			SetupMocks: setupFiles(map[string]string{
+				"my/app/go.mod": "module github.com/my/app",
+				"my/app/.lambgo.yml": `
+buildPaths:
+  - lambdas/api
+lambdas:
+  - path: lambdas/worker
+profiles:
+  prod:
+    goarch: arm64
+    lambdas:
+      - path: lambdas/worker
+        buildFlags: -tags prod
+`,
+				"my/app/.lambgo.local.yml": "goos: darwin\n",
+			}),
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				Goos:       "darwin",
+				Goarch:     "arm64",
+				Lambdas: []*lambgofile.Lambda{
+					makeLambda("lambdas/api", nil),
+					makeLambda("lambdas/worker", []string{"-tags", "prod"}),
+				},
+			},
+
+			ExpectedProvenance: func() *lambgofile.Provenance {
+				buildFlags := &lambgofile.Source{Origin: lambgofile.OriginDefault, Key: "buildFlags"}
+
+				fields := defaultFields(buildFlags)
+				fields["goos"] = &lambgofile.Source{Origin: lambgofile.OriginTopLevel, Key: "goos", Raw: "darwin", Overlay: ".lambgo.local.yml"}
+				fields["goarch"] = &lambgofile.Source{Origin: lambgofile.OriginTopLevel, Key: "goarch", Raw: "arm64", Overlay: "profile prod"}
+
+				return &lambgofile.Provenance{
+					Fields: fields,
+					Targets: map[string]map[string]*lambgofile.Source{
+						"lambdas/api": {"buildFlags": buildFlags},
+						"lambdas/worker": {
+							"buildFlags": {Origin: lambgofile.OriginPerLambda, Key: "lambdas[0].buildFlags", Raw: "-tags prod", Overlay: "profile prod"},
+						},
+					},
+				}
+			}(),
+		},
+
+		{
+			Name: "with included file",
+			SetupMocks: setupFiles(map[string]string{
+				"my/app/go.mod":      "module github.com/my/app",
+				"my/app/.lambgo.yml": "include:\n  - services/billing\n",
+				"my/app/services/billing/lambgo.yml": `
+lambdas:
+  - path: lambdas/charge
+    buildFlags: -tags billing
+`,
+			}),
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:      "/my/app",
+				ModulePath:    "github.com/my/app",
+				Goos:          "linux",
+				Goarch:        "amd64",
+				Lambdas:       []*lambgofile.Lambda{makeLambda("services/billing/lambdas/charge", []string{"-tags", "billing"})},
+				IncludedFiles: []string{"services/billing/lambgo.yml"},
+			},
+
+			ExpectedProvenance: &lambgofile.Provenance{
+				Fields: defaultFields(&lambgofile.Source{Origin: lambgofile.OriginDefault, Key: "buildFlags"}),
+				Targets: map[string]map[string]*lambgofile.Source{
+					"services/billing/lambdas/charge": {
+						"buildFlags": {
+							Origin:  lambgofile.OriginPerLambda,
+							Key:     "lambdas[0].buildFlags",
+							Raw:     "-tags billing",
+							Overlay: "services/billing/lambgo.yml",
+						},
+					},
+				},
+			},
+		},
+
+		{
+			Name: "with workspace module",
+			SetupMocks: setupFiles(map[string]string{
+				"my/app/go.work":                "go 1.23\n\nuse (\n\t.\n\t./services/search\n)\n",
+				"my/app/go.mod":                 "module github.com/my/app",
+				"my/app/services/search/go.mod": "module example.com/search",
+				"my/app/.lambgo.yml": `
+lambdas:
+  - path: cmd/api
+    module: example.com/search
+    buildFlags: -tags search
+`,
+			}),
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				Goos:       "linux",
+				Goarch:     "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "services/search/cmd/api", BuildFlags: []string{"-tags", "search"}, Module: "services/search"},
+				},
+				Workspace: &lambgofile.Workspace{
+					FilePath: "/my/app/go.work",
+					Modules: []*lambgofile.WorkspaceModule{
+						{Dir: ".", ModulePath: "github.com/my/app"},
+						{Dir: "services/search", ModulePath: "example.com/search"},
+					},
+				},
+			},
+
+			ExpectedProvenance: &lambgofile.Provenance{
+				Fields: defaultFields(&lambgofile.Source{Origin: lambgofile.OriginDefault, Key: "buildFlags"}),
+				Targets: map[string]map[string]*lambgofile.Source{
+					"services/search/cmd/api": {
+						"buildFlags": {Origin: lambgofile.OriginPerLambda, Key: "lambdas[0].buildFlags", Raw: "-tags search"},
+					},
+				},
+			},
+		},
+
+		{
+			Name: "with buildOptions",
+			SetupMocks: setupFiles(map[string]string{
+				"my/app/go.mod": "module github.com/my/app",
+				"my/app/.lambgo.yml": `
+buildOptions:
+  tags: [$LAMBGO_TEST_TAGS]
+  trimpath: false
+buildPaths:
+  - lambdas/api
+lambdas:
+  - path: lambdas/worker
+    buildOptions:
+      ldflags: -s -w
+`,
+			}),
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:   "/my/app",
+				ModulePath: "github.com/my/app",
+				Goos:       "linux",
+				Goarch:     "amd64",
+				Lambdas: []*lambgofile.Lambda{
+					{Path: "lambdas/api", BuildOptions: &lambgofile.BuildOptions{Tags: []string{"prod"}}},
+					{Path: "lambdas/worker", BuildOptions: &lambgofile.BuildOptions{Tags: []string{"prod"}, LDFlags: "-s -w"}},
+				},
+			},
+
+			ExpectedProvenance: func() *lambgofile.Provenance {
+				buildOptions := &lambgofile.Source{
+					Origin:  lambgofile.OriginEnvironment,
+					Key:     "buildOptions",
+					Raw:     "tags: [$LAMBGO_TEST_TAGS], trimpath: false",
+					EnvVars: []string{"LAMBGO_TEST_TAGS"},
+				}
+
+				fields := defaultFields(&lambgofile.Source{Origin: lambgofile.OriginDefault, Key: "buildFlags"})
+				fields["buildOptions"] = buildOptions
+
+				return &lambgofile.Provenance{
+					Fields: fields,
+					Targets: map[string]map[string]*lambgofile.Source{
+						"lambdas/api": {
+							"buildFlags":   fields["buildFlags"],
+							"buildOptions": buildOptions,
+						},
+						"lambdas/worker": {
+							"buildFlags":   fields["buildFlags"],
+							"buildOptions": {Origin: lambgofile.OriginPerLambda, Key: "lambdas[0].buildOptions", Raw: "ldflags: -s -w"},
+						},
+					},
+				}
+			}(),
+		},
+
+		{
+			Name:          "with invalid config",
+			ExpectedError: lambgofile.ErrCannotUnmarshalFile,
+			SetupMocks: setupFiles(map[string]string{
+				"my/app/go.mod":      "module github.com/my/app",
+				"my/app/.lambgo.yml": "buildPaths: {",
+			}),
+		},
+
+		{
+			Name:          "with no go.mod",
+			ExpectedError: lambgofile.ErrCannotFindGoModule,
+			SetupMocks:    setupFiles(map[string]string{}),
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		ensure.T().Setenv("LAMBGO_TEST_TAGS", "prod")
+
+		config, provenance, err := entry.Subject.ExplainConfig("/my/app", entry.Profile)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(config).Equals(entry.ExpectedConfig)
+		ensure(provenance).Equals(entry.ExpectedProvenance)
+	})
+}
+
+func TestSourceString(t *testing.T) {
+	ensure := ensure.New(t)
+
+	table := []struct {
+		Name     string
+		Source   *lambgofile.Source
+		Expected string
+	}{
+		{
+			Name:     "default",
+			Source:   &lambgofile.Source{Origin: lambgofile.OriginDefault, Key: "goos"},
+			Expected: "default",
+		},
+		{
+			Name:     "top-level key",
+			Source:   &lambgofile.Source{Origin: lambgofile.OriginTopLevel, Key: "goos", Raw: "darwin"},
+			Expected: `top-level key goos: "darwin"`,
+		},
+		{
+			Name:     "per-lambda key overriding with an empty value",
+			Source:   &lambgofile.Source{Origin: lambgofile.OriginPerLambda, Key: "lambdas[1].buildFlags"},
+			Expected: `per-lambda key lambdas[1].buildFlags: ""`,
+		},
+		{
+			Name: "environment variable expansion",
+			Source: &lambgofile.Source{
+				Origin:  lambgofile.OriginEnvironment,
+				Key:     "buildFlags",
+				Raw:     "-tags $TAGS -ldflags=${LDFLAGS}",
+				EnvVars: []string{"LDFLAGS", "TAGS"},
+			},
+			Expected: `environment variable expansion of $LDFLAGS, $TAGS in buildFlags: "-tags $TAGS -ldflags=${LDFLAGS}"`,
+		},
+		{
+			Name:     "key set by an overlay",
+			Source:   &lambgofile.Source{Origin: lambgofile.OriginTopLevel, Key: "goarch", Raw: "arm64", Overlay: "profile prod"},
+			Expected: `top-level key goarch (profile prod): "arm64"`,
+		},
+		{
+			Name:     "CLI flag",
+			Source:   &lambgofile.Source{Origin: lambgofile.OriginFlag, Key: "--num-parallel", Raw: "4"},
+			Expected: `CLI flag --num-parallel: "4"`,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		ensure(entry.Source.String()).Equals(entry.Expected)
+	})
+}
@@ -0,0 +1,439 @@
+package lambgofile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/licenses"
+)
+
+const (
+	schemaVersion = "http://json-schema.org/draft-07/schema#"
+
+	schemaTypeObject  = "object"
+	schemaTypeArray   = "array"
+	schemaTypeString  = "string"
+	schemaTypeBoolean = "boolean"
+	schemaTypeNull    = "null"
+)
+
+// exampleKeyPattern matches keys in ExampleFile, including keys that are commented out, and keys of list entries.
+// For example: `#   - path: lambdas/api # Optional` has the key path, and a description.
+// Keys can also be commented out after their indentation, such as `    # module: services/search # Optional`.
+var exampleKeyPattern = regexp.MustCompile(`^(# )?( *)(?:# )?( *)(- )?([a-z][A-Za-z]*):(?: (.*?))??(?: # (.*))?$`)
+
+// schemaEnums are the allowed values of keys, by the key path in the schema.
+//
+//nolint:gochecknoglobals // Constant lists of values
+var schemaEnums = map[string][]string{
+	"image.format":     {string(ImageFormatLayout), string(ImageFormatTarball)},
+	"sbom.format":      {string(SBOMFormatSPDX), string(SBOMFormatCycloneDX)},
+	"routes.event":     {string(EventFormatAPIGateway), string(EventFormatAPIGatewayV2), string(EventFormatFunctionURL)},
+	"buildOptions.mod": buildModes,
+
+	"licenses.disallowed": licenses.IDs,
+}
+
+// requiredSchemaKeys must be set on the object containing them, by the key path in the schema.
+//
+//nolint:gochecknoglobals // Constant list of keys
+var requiredSchemaKeys = []string{
+	"lambdas.path",
+	"extensions.path",
+	"layers.name",
+	"layers.packages.path",
+	"layers.files.glob",
+	"image.format",
+	"sbom.format",
+	"signing.keyFile",
+	"routes.path",
+	"routes.lambda",
+}
+
+// overlayRequiredSchemaKeys are still required in profiles and .lambgo.local.yml,
+// since they identify the entries that the overlay is merged onto.
+//
+//nolint:gochecknoglobals // Constant list of keys
+var overlayRequiredSchemaKeys = []string{
+	"lambdas.path",
+	"extensions.path",
+	"layers.name",
+	"layers.packages.path",
+	"routes.path",
+}
+
+// JSONSchema describes the keys of a config file, using a subset of JSON Schema.
+// It is generated from the structs used to unmarshal .lambgo.yml, with descriptions from the comments in ExampleFile.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 SchemaType             `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+}
+
+// SchemaType is a list of the allowed JSON Schema types, which is written as a string when there is only one.
+type SchemaType []string
+
+// MarshalJSON writes a single type as a string, and multiple types as a list.
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+
+	return json.Marshal([]string(t))
+}
+
+// ConfigSchema for .lambgo.yml.
+func ConfigSchema() *JSONSchema {
+	descriptions := exampleDescriptions()
+	schema := structSchema(reflect.TypeFor[rawConfig](), "", descriptions)
+	schema.Schema = schemaVersion
+	schema.Title = ConfigFileName
+
+	// Profiles are not unmarshaled with the rest of the config, since only the selected profile is parsed
+	profile := overlaySchema(schema)
+	profile.Type = SchemaType{schemaTypeObject, schemaTypeNull}
+	schema.Properties[profilesKey] = &JSONSchema{
+		Description:          descriptions[profilesKey],
+		Type:                 SchemaType{schemaTypeObject},
+		AdditionalProperties: profile,
+	}
+
+	return schema
+}
+
+// IncludedFileSchema for the lambgo.yml files that are included by .lambgo.yml.
+func IncludedFileSchema() *JSONSchema {
+	config := ConfigSchema()
+
+	schema := &JSONSchema{
+		Schema:               schemaVersion,
+		Title:                IncludedFileName,
+		Description:          "Lambdas included by " + ConfigFileName + ", with paths relative to the directory of this file.",
+		Type:                 SchemaType{schemaTypeObject},
+		Properties:           make(map[string]*JSONSchema),
+		AdditionalProperties: false,
+	}
+
+	for _, key := range includedFileKeys {
+		schema.Properties[key] = config.Properties[key]
+	}
+
+	return schema
+}
+
+// overlaySchema for profiles and .lambgo.local.yml, which can contain any key except profiles and include.
+// Since they are merged onto the rest of the config, only the keys used to merge entries are required.
+func overlaySchema(config *JSONSchema) *JSONSchema {
+	overlay := *config
+	overlay.Schema = ""
+	overlay.Title = ""
+	overlay.Properties = make(map[string]*JSONSchema, len(config.Properties))
+
+	for key, property := range config.Properties {
+		if key != profilesKey && key != includeKey {
+			overlay.Properties[key] = overlayPropertySchema(property, key)
+		}
+	}
+
+	return &overlay
+}
+
+// overlayPropertySchema copies the schema, keeping only the required keys in overlayRequiredSchemaKeys.
+func overlayPropertySchema(schema *JSONSchema, keyPath string) *JSONSchema {
+	overlay := *schema
+
+	if schema.Items != nil {
+		overlay.Items = overlayPropertySchema(schema.Items, keyPath)
+	}
+
+	if schema.Properties != nil {
+		overlay.Properties = make(map[string]*JSONSchema, len(schema.Properties))
+		for key, property := range schema.Properties {
+			overlay.Properties[key] = overlayPropertySchema(property, joinKey(keyPath, key))
+		}
+	}
+
+	overlay.Required = nil
+	for _, key := range schema.Required {
+		if slices.Contains(overlayRequiredSchemaKeys, joinKey(keyPath, key)) {
+			overlay.Required = append(overlay.Required, key)
+		}
+	}
+
+	return &overlay
+}
+
+// structSchema for the struct type, whose fields have yaml tags.
+func structSchema(structType reflect.Type, keyPath string, descriptions map[string]string) *JSONSchema {
+	schema := &JSONSchema{
+		Description:          lookupKey(descriptions, keyPath),
+		Type:                 SchemaType{schemaTypeObject},
+		Properties:           make(map[string]*JSONSchema),
+		AdditionalProperties: false,
+	}
+
+	for i := range structType.NumField() {
+		field := structType.Field(i)
+		key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if key == "" || key == "-" {
+			continue
+		}
+
+		fieldKeyPath := joinKey(keyPath, key)
+		schema.Properties[key] = typeSchema(field.Type, fieldKeyPath, descriptions)
+
+		if slices.Contains(requiredSchemaKeys, fieldKeyPath) {
+			schema.Required = append(schema.Required, key)
+		}
+	}
+
+	return schema
+}
+
+func typeSchema(fieldType reflect.Type, keyPath string, descriptions map[string]string) *JSONSchema {
+	if fieldType.Kind() == reflect.Pointer {
+		fieldType = fieldType.Elem()
+	}
+
+	switch fieldType.Kind() { //nolint:exhaustive // Only the kinds used by the raw structs are supported
+	case reflect.Struct:
+		return structSchema(fieldType, keyPath, descriptions)
+
+	case reflect.Slice:
+		items := typeSchema(fieldType.Elem(), keyPath, descriptions)
+		items.Description = ""
+
+		return &JSONSchema{Description: lookupKey(descriptions, keyPath), Type: SchemaType{schemaTypeArray}, Items: items}
+
+	case reflect.String:
+		return &JSONSchema{
+			Description: lookupKey(descriptions, keyPath),
+			Type:        SchemaType{schemaTypeString},
+			Enum:        lookupKey(schemaEnums, keyPath),
+		}
+
+	case reflect.Bool:
+		return &JSONSchema{Description: lookupKey(descriptions, keyPath), Type: SchemaType{schemaTypeBoolean}}
+
+	default:
+		panic(fmt.Sprintf("unsupported type %s for %s in the schema", fieldType, keyPath))
+	}
+}
+
+// lookupKey returns the value of the key path, or of its longest suffix that has a value.
+// This allows nested keys like lambdas.buildOptions.mod to share the description and values of buildOptions.mod.
+func lookupKey[V any](values map[string]V, keyPath string) V {
+	for {
+		if value, ok := values[keyPath]; ok {
+			return value
+		}
+
+		var found bool
+		if _, keyPath, found = strings.Cut(keyPath, "."); !found {
+			var zero V
+			return zero
+		}
+	}
+}
+
+// exampleDescriptions of each key in ExampleFile, by the key path in the schema (eg. layers.packages.destination).
+// Top-level keys are described by the comments above them, and nested keys are described by the comments after them.
+func exampleDescriptions() map[string]string {
+	descriptions := make(map[string]string)
+
+	type parentKey struct {
+		column int
+		key    string
+	}
+
+	var (
+		comments   []string
+		afterKey   bool
+		parentKeys []parentKey
+	)
+
+	for _, line := range strings.Split(ExampleFile, "\n") {
+		match := exampleKeyPattern.FindStringSubmatch(line)
+		if match == nil {
+			// Comments after keys start a new description, such as the comments above goos that follow buildFlags
+			if afterKey {
+				comments = nil
+				afterKey = false
+			}
+
+			if comment, ok := strings.CutPrefix(line, "# "); ok {
+				comments = append(comments, comment)
+			} else {
+				comments = nil
+			}
+
+			continue
+		}
+
+		column := len(match[2]) + len(match[3]) + len(match[4])
+		key := match[5]
+
+		for len(parentKeys) > 0 && parentKeys[len(parentKeys)-1].column >= column {
+			parentKeys = parentKeys[:len(parentKeys)-1]
+		}
+
+		keys := make([]string, 0, len(parentKeys)+1)
+		for _, parent := range parentKeys {
+			keys = append(keys, parent.key)
+		}
+		keyPath := strings.Join(append(keys, key), ".")
+		parentKeys = append(parentKeys, parentKey{column: column, key: key})
+
+		description := match[7]
+		if column == 0 {
+			description = strings.Join(comments, " ")
+			afterKey = true
+		}
+
+		if _, exists := descriptions[keyPath]; !exists && description != "" {
+			descriptions[keyPath] = description
+		}
+	}
+
+	return descriptions
+}
+
+// Validate the document against the schema, and return a problem for each value that does not match it.
+// The document is unmarshaled from YAML, so it contains maps, lists, and scalars.
+func (schema *JSONSchema) Validate(document any) []string {
+	var problems []string
+	schema.validate(document, "", &problems)
+	return problems
+}
+
+func (schema *JSONSchema) validate(value any, keyPath string, problems *[]string) {
+	location := keyPath
+	if location == "" {
+		location = "top level"
+	}
+
+	valueType := documentType(value)
+	if !slices.Contains(schema.Type, valueType) {
+		*problems = append(*problems, fmt.Sprintf("%s: expected %s, but found %s", location, strings.Join(schema.Type, " or "), valueType))
+		return
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		for _, key := range schema.Required {
+			if _, ok := value[key]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: missing required key '%s'", location, key))
+			}
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			property, ok := schema.Properties[key]
+			if !ok {
+				property, ok = schema.AdditionalProperties.(*JSONSchema)
+			}
+
+			if !ok {
+				*problems = append(*problems, fmt.Sprintf("%s: unknown key", joinKey(keyPath, key)))
+				continue
+			}
+
+			property.validate(value[key], joinKey(keyPath, key), problems)
+		}
+
+	case []any:
+		for i, item := range value {
+			schema.Items.validate(item, indexKey(keyPath, i), problems)
+		}
+
+	case string:
+		if len(schema.Enum) > 0 && !slices.Contains(schema.Enum, value) {
+			*problems = append(*problems, fmt.Sprintf("%s: expected one of %s, but found %q", location, strings.Join(schema.Enum, ", "), value))
+		}
+	}
+}
+
+// documentType is the JSON Schema type of the unmarshaled YAML value.
+func documentType(value any) string {
+	switch value.(type) {
+	case map[string]any:
+		return schemaTypeObject
+	case []any:
+		return schemaTypeArray
+	case string:
+		return schemaTypeString
+	case nil:
+		return schemaTypeNull
+	case bool:
+		return schemaTypeBoolean
+	default:
+		return "number"
+	}
+}
+
+// schemaProblems in the config files of the module, prefixed by the path of the file relative to the module root.
+// Missing files other than .lambgo.yml are skipped, since they are reported when the config is loaded.
+func (l *Loader) schemaProblems(rootPath string) ([]string, error) {
+	type schemaFile struct {
+		path   string
+		schema *JSONSchema
+	}
+
+	config := ConfigSchema()
+	included := IncludedFileSchema()
+
+	files := []*schemaFile{{path: ConfigFileName, schema: config}, {path: LocalConfigFileName, schema: overlaySchema(config)}}
+	visited := map[string]struct{}{ConfigFileName: {}}
+
+	var problems []string
+	for i := 0; i < len(files); i++ {
+		file := files[i]
+
+		filePath := filepath.Join(rootPath, file.path)
+		data, err := fs.ReadFile(l.FS, filePath)
+		if errors.Is(err, fs.ErrNotExist) && file.path != ConfigFileName {
+			continue
+		}
+
+		if err != nil {
+			return nil, erk.WrapWith(ErrCannotOpenFile, err, erk.Params{"path": filePath})
+		}
+
+		document, err := (&configFile{path: filePath, data: data}).document()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, problem := range file.schema.Validate(document) {
+			problems = append(problems, file.path+": "+problem)
+		}
+
+		// Invalid includes are skipped, since they are reported when the config is loaded
+		includes, _ := document[includeKey].([]any)
+		for _, include := range includes {
+			includedPath, err := includedFilePath(include, file.path)
+			if _, ok := visited[includedPath]; err != nil || ok {
+				continue
+			}
+
+			visited[includedPath] = struct{}{}
+			files = append(files, &schemaFile{path: includedPath, schema: included})
+		}
+	}
+
+	return problems, nil
+}
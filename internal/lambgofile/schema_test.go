@@ -0,0 +1,365 @@
+package lambgofile_test
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/licenses"
+	"github.com/JosiahWitt/lambgo/internal/mocks/io/mock_fs"
+	"github.com/goccy/go-yaml"
+	"github.com/golang/mock/gomock"
+)
+
+func TestConfigSchema(t *testing.T) {
+	ensure := ensure.New(t)
+
+	schema := lambgofile.ConfigSchema()
+
+	ensure.Run("describes every key using ExampleFile", func(ensure ensuring.E) {
+		var missing []string
+
+		var walk func(schema *lambgofile.JSONSchema, keyPath string)
+		walk = func(schema *lambgofile.JSONSchema, keyPath string) {
+			if schema.Items != nil {
+				walk(schema.Items, keyPath)
+			}
+
+			for key, property := range schema.Properties {
+				if property.Description == "" {
+					missing = append(missing, keyPath+key)
+				}
+
+				walk(property, keyPath+key+".")
+			}
+		}
+		walk(schema, "")
+
+		ensure(missing).IsEmpty()
+		ensure(schema.Properties["image"].Properties["format"].Description).
+			Equals("Either layout (<outDirectory>/<path>.oci/) or tarball (<outDirectory>/<path>.oci.tar)")
+		ensure(schema.Properties["outDirectory"].Description).Equals("Directory to use as the root for build artifacts. Optional, defaults to tmp.")
+		ensure(schema.Properties["lambdas"].Items.Properties["buildOptions"].Properties["tags"]).
+			Equals(schema.Properties["buildOptions"].Properties["tags"])
+	})
+
+	ensure.Run("has no problems with ExampleFile", func(ensure ensuring.E) {
+		var document any
+		ensure(yaml.Unmarshal([]byte(lambgofile.ExampleFile), &document)).IsNotError()
+		ensure(schema.Validate(document)).IsEmpty()
+	})
+
+	ensure.Run("includes the keys of included files", func(ensure ensuring.E) {
+		included := lambgofile.IncludedFileSchema()
+		ensure(included.Title).Equals("lambgo.yml")
+		ensure(len(included.Properties)).Equals(6)
+		ensure(included.Properties["lambdas"]).Equals(schema.Properties["lambdas"])
+	})
+}
+
+func TestJSONSchemaValidate(t *testing.T) {
+	ensure := ensure.New(t)
+
+	table := []struct {
+		Name             string
+		Document         string
+		Included         bool
+		ExpectedProblems []string
+	}{
+		{
+			Name: "with valid document",
+			Document: `
+goarch: arm64
+buildPaths:
+  - lambdas/api
+lambdas:
+  - path: lambdas/worker
+    buildFlags: ""
+image:
+  format: tarball
+profiles:
+  dev:
+  prod:
+    goarch: arm64
+    layers:
+      - name: tools
+        files:
+          - glob: tools/*
+`,
+		},
+
+		{
+			Name: "with unknown keys",
+			Document: `
+goarhc: arm64
+lambdas:
+  - path: lambdas/api
+    buildFlag: -tags prod
+profiles:
+  prod:
+    include:
+      - services/billing
+`,
+			ExpectedProblems: []string{
+				"goarhc: unknown key",
+				"lambdas[0].buildFlag: unknown key",
+				"profiles.prod.include: unknown key",
+			},
+		},
+
+		{
+			Name: "with wrong types",
+			Document: `
+goarch: 64
+buildPaths: lambdas/api
+layers:
+  - lambdas/api
+profiles:
+  prod: arm64
+`,
+			ExpectedProblems: []string{
+				"buildPaths: expected array, but found string",
+				"goarch: expected string, but found number",
+				"layers[0]: expected object, but found string",
+				"profiles.prod: expected object or null, but found string",
+			},
+		},
+
+		{
+			Name: "with missing required keys, and invalid values",
+			Document: `
+image:
+  binaryPath: /bootstrap
+routes:
+  - path: /orders
+    lambda: lambdas/api
+    event: sqs
+`,
+			ExpectedProblems: []string{
+				"image: missing required key 'format'",
+				`routes[0].event: expected one of apigateway, apigateway-v2, function-url, but found "sqs"`,
+			},
+		},
+
+		{
+			Name: "with partial entries in a profile",
+			Document: `
+profiles:
+  prod:
+    image:
+      binaryPath: /app
+    signing:
+      publicKeyFile: keys/signing.pub.pem
+    routes:
+      - path: /orders
+        method: POST
+    layers:
+      - name: tools
+        packages:
+          - destination: bin
+`,
+			ExpectedProblems: []string{"profiles.prod.layers[0].packages[0]: missing required key 'path'"},
+		},
+
+		{
+			Name: "with invalid buildOptions",
+			Document: `
+buildOptions:
+  trimpath: "no"
+  tags: prod
+lambdas:
+  - path: lambdas/api
+    buildOptions:
+      race: true
+      mod: vendored
+`,
+			ExpectedProblems: []string{
+				"buildOptions.tags: expected array, but found string",
+				"buildOptions.trimpath: expected boolean, but found string",
+				`lambdas[0].buildOptions.mod: expected one of readonly, vendor, mod, but found "vendored"`,
+			},
+		},
+
+		{
+			Name: "with invalid licenses",
+			Document: `
+licenses:
+  bundle: yes please
+  disallowed: [GPL-3.0, GPLv2]
+`,
+			ExpectedProblems: []string{
+				"licenses.bundle: expected boolean, but found string",
+				`licenses.disallowed[1]: expected one of ` + strings.Join(licenses.IDs, ", ") + `, but found "GPLv2"`,
+			},
+		},
+
+		{
+			Name: "with signing missing keyFile",
+			Document: `
+signing:
+  publicKeyFile: keys/signing.pub.pem
+`,
+			ExpectedProblems: []string{"signing: missing required key 'keyFile'"},
+		},
+
+		{
+			Name:             "with empty document",
+			ExpectedProblems: []string{"top level: expected object, but found null"},
+		},
+
+		{
+			Name:             "with settings in an included file",
+			Included:         true,
+			Document:         "goarch: arm64\nbuildPaths:\n  - lambdas/api\n",
+			ExpectedProblems: []string{"goarch: unknown key"},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		var document any
+		ensure(yaml.Unmarshal([]byte(entry.Document), &document)).IsNotError()
+
+		schema := lambgofile.ConfigSchema()
+		if entry.Included {
+			schema = lambgofile.IncludedFileSchema()
+		}
+
+		ensure(schema.Validate(document)).Equals(entry.ExpectedProblems)
+	})
+}
+
+func TestValidateConfig(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		FS *mock_fs.MockReadFileFS
+	}
+
+	setupFiles := func(files map[string]any) func(*Mocks) {
+		return func(m *Mocks) {
+			m.FS.EXPECT().ReadFile(gomock.Any()).AnyTimes().
+				DoAndReturn(func(name string) ([]byte, error) {
+					switch data := files[name].(type) {
+					case string:
+						return []byte(data), nil
+					case error:
+						return nil, data
+					default:
+						return nil, fs.ErrNotExist
+					}
+				})
+		}
+	}
+
+	table := []struct {
+		Name    string
+		Profile string
+
+		ExpectedConfig *lambgofile.Config
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *lambgofile.Loader
+	}{
+		{
+			Name:    "with valid files",
+			Profile: "prod",
+			SetupMocks: setupFiles(map[string]any{
+				"my/app/go.mod":                      "module github.com/my/app",
+				"my/app/.lambgo.yml":                 "include:\n  - services/billing\nimage:\n  format: tarball\nprofiles:\n  prod:\n    goarch: arm64\n",
+				"my/app/.lambgo.local.yml":           "goos: darwin\nimage:\n  binaryPath: /app\n",
+				"my/app/services/billing/lambgo.yml": "buildPaths:\n  - lambdas/charge\n",
+			}),
+
+			ExpectedConfig: &lambgofile.Config{
+				RootPath:      "/my/app",
+				ModulePath:    "github.com/my/app",
+				Goos:          "darwin",
+				Goarch:        "arm64",
+				Lambdas:       []*lambgofile.Lambda{makeLambda("services/billing/lambdas/charge", nil)},
+				Image:         &lambgofile.Image{Format: lambgofile.ImageFormatTarball, BinaryPath: "/app"},
+				IncludedFiles: []string{"services/billing/lambgo.yml"},
+			},
+		},
+
+		{
+			Name:          "with problems in each file",
+			ExpectedError: lambgofile.ErrSchemaViolations,
+			SetupMocks: setupFiles(map[string]any{
+				"my/app/go.mod":                      "module github.com/my/app",
+				"my/app/.lambgo.yml":                 "include:\n  - services/billing\n  - services/billing\ngoarhc: arm64\n",
+				"my/app/.lambgo.local.yml":           "profiles: {}\n",
+				"my/app/services/billing/lambgo.yml": "outDirectory: dist\n",
+			}),
+		},
+
+		{
+			Name:          "with rules that are checked when loading the config",
+			ExpectedError: lambgofile.ErrCannotReadInclude,
+			SetupMocks: setupFiles(map[string]any{
+				"my/app/go.mod":      "module github.com/my/app",
+				"my/app/.lambgo.yml": "include:\n  - services/missing\n",
+			}),
+		},
+
+		{
+			Name:          "with invalid YAML",
+			ExpectedError: lambgofile.ErrCannotUnmarshalFile,
+			SetupMocks: setupFiles(map[string]any{
+				"my/app/go.mod":      "module github.com/my/app",
+				"my/app/.lambgo.yml": "buildPaths: {",
+			}),
+		},
+
+		{
+			Name:          "with unreadable file",
+			ExpectedError: lambgofile.ErrCannotOpenFile,
+			SetupMocks: setupFiles(map[string]any{
+				"my/app/go.mod":            "module github.com/my/app",
+				"my/app/.lambgo.yml":       "buildPaths: []\n",
+				"my/app/.lambgo.local.yml": errors.New("permission denied"),
+			}),
+		},
+
+		{
+			Name:          "with missing .lambgo.yml",
+			ExpectedError: lambgofile.ErrCannotOpenFile,
+			SetupMocks: setupFiles(map[string]any{
+				"my/app/go.mod": "module github.com/my/app",
+			}),
+		},
+
+		{
+			Name:          "with no go.mod",
+			ExpectedError: lambgofile.ErrCannotFindGoModule,
+			SetupMocks:    setupFiles(map[string]any{}),
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		config, err := entry.Subject.ValidateConfig("/my/app", entry.Profile)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(config).Equals(entry.ExpectedConfig)
+	})
+
+	ensure.Run("lists the problems in each file", func(ensure ensuring.E) {
+		ctrl := gomock.NewController(ensure.T())
+		mocks := &Mocks{FS: mock_fs.NewMockReadFileFS(ctrl)}
+		table[1].SetupMocks(mocks)
+
+		_, err := (&lambgofile.Loader{FS: mocks.FS}).ValidateConfig("/my/app", "")
+		ensure(err.Error()).Equals("The config does not match the schema:\n" +
+			"  - .lambgo.yml: goarhc: unknown key\n" +
+			"  - .lambgo.local.yml: profiles: unknown key\n" +
+			"  - services/billing/lambgo.yml: outDirectory: unknown key")
+	})
+}
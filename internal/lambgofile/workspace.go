@@ -0,0 +1,260 @@
+package lambgofile
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/JosiahWitt/erk"
+	"golang.org/x/mod/modfile"
+)
+
+const goworkFileName = "go.work"
+
+// Workspace is the go.work file next to .lambgo.yml, which allows Lambdas to be in any module of the workspace.
+type Workspace struct {
+	// FilePath of the go.work file, which is used for GOWORK when building.
+	FilePath string
+
+	// Modules used by the workspace, which are within RootPath.
+	Modules []*WorkspaceModule
+}
+
+// WorkspaceModule is a module used by the workspace.
+type WorkspaceModule struct {
+	// Dir of the module, relative to RootPath. It is . for a module at RootPath.
+	Dir string
+
+	// ModulePath of the module (eg. github.com/my/app/services/billing).
+	ModulePath string
+}
+
+// FindRoot of the config for pwd, like LoadConfig, which is the directory of go.work when it contains .lambgo.yml.
+// Otherwise, it is the module containing pwd.
+func (l *Loader) FindRoot(pwd string) (*Module, error) {
+	workspace, rootPath, err := l.findWorkspace(pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	if workspace == nil {
+		return l.FindModule(pwd)
+	}
+
+	module := &Module{RootPath: "/" + rootPath, Workspace: workspace}
+	if rootModule := workspace.findModule("."); rootModule != nil {
+		module.ModulePath = rootModule.ModulePath
+	}
+
+	return module, nil
+}
+
+// findWorkspace searches pwd and its parents for a go.work file, or uses GOWORK when it is set, like the go command.
+// The workspace is nil when go.work is not next to .lambgo.yml, or when GOWORK=off.
+func (l *Loader) findWorkspace(pwd string) (*Workspace, string, error) {
+	var goworkPath string
+
+	switch gowork := os.Getenv("GOWORK"); gowork {
+	case "off":
+		return nil, "", nil
+
+	case "":
+		for dir := strings.TrimPrefix(pwd, "/"); ; dir = filepath.Dir(dir) {
+			if l.fileExists(filepath.Join(dir, goworkFileName)) {
+				goworkPath = filepath.Join(dir, goworkFileName)
+				break
+			}
+
+			if dir == filepath.Dir(dir) {
+				return nil, "", nil
+			}
+		}
+
+	default:
+		goworkPath = strings.TrimPrefix(gowork, "/")
+	}
+
+	rootPath := filepath.Dir(goworkPath)
+	if !l.fileExists(filepath.Join(rootPath, ConfigFileName)) {
+		return nil, "", nil
+	}
+
+	goworkData, err := fs.ReadFile(l.FS, goworkPath)
+	if err != nil {
+		return nil, "", erk.WrapWith(ErrCannotOpenFile, err, erk.Params{"path": goworkPath})
+	}
+
+	workFile, err := modfile.ParseWork(goworkPath, goworkData, nil)
+	if err != nil {
+		return nil, "", erk.WrapWith(ErrCannotParseGoWork, err, erk.Params{"path": goworkPath})
+	}
+
+	workspace := &Workspace{FilePath: "/" + goworkPath}
+	for _, use := range workFile.Use {
+		// Modules outside the root cannot contain Lambdas, since their paths would be outside the outDirectory
+		dir := filepath.ToSlash(filepath.Clean(use.Path))
+		if filepath.IsAbs(use.Path) || dir == ".." || strings.HasPrefix(dir, "../") {
+			continue
+		}
+
+		gomodPath := filepath.Join(rootPath, dir, gomodFileName)
+		gomodData, err := fs.ReadFile(l.FS, gomodPath)
+		if err != nil {
+			return nil, "", erk.WrapWith(ErrCannotOpenFile, err, erk.Params{"path": gomodPath})
+		}
+
+		modulePath := modfile.ModulePath(gomodData)
+		if modulePath == "" {
+			return nil, "", erk.WithParams(ErrCannotParseGoModule, erk.Params{"path": gomodPath})
+		}
+
+		workspace.Modules = append(workspace.Modules, &WorkspaceModule{Dir: dir, ModulePath: modulePath})
+	}
+
+	return workspace, rootPath, nil
+}
+
+func (l *Loader) fileExists(name string) bool {
+	_, err := fs.ReadFile(l.FS, name)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// findModule with the module path or directory.
+func (workspace *Workspace) findModule(module string) *WorkspaceModule {
+	dir := path.Clean(module)
+	for _, workspaceModule := range workspace.Modules {
+		if workspaceModule.ModulePath == module || workspaceModule.Dir == dir {
+			return workspaceModule
+		}
+	}
+
+	return nil
+}
+
+// moduleContaining the directory, which is the module with the longest matching directory, since modules can be nested.
+func (workspace *Workspace) moduleContaining(dir string) *WorkspaceModule {
+	var containing *WorkspaceModule
+	for _, workspaceModule := range workspace.Modules {
+		if !isWithinDir(dir, workspaceModule.Dir) {
+			continue
+		}
+
+		if containing == nil || len(workspaceModule.Dir) > len(containing.Dir) {
+			containing = workspaceModule
+		}
+	}
+
+	return containing
+}
+
+func (workspace *Workspace) moduleDescriptions() string {
+	descriptions := make([]string, 0, len(workspace.Modules))
+	for _, workspaceModule := range workspace.Modules {
+		descriptions = append(descriptions, workspaceModule.ModulePath+" ("+workspaceModule.Dir+")")
+	}
+
+	return strings.Join(descriptions, ", ")
+}
+
+// setModule of the Lambda to the workspace module containing it.
+// When module is set, it is the path or directory of a workspace module, and the path of the Lambda is relative to it.
+func (lambda *Lambda) setModule(workspace *Workspace, module string) error {
+	if workspace == nil {
+		if module != "" {
+			return erk.WithParams(ErrModuleWithoutWorkspace, erk.Params{"module": module, "path": lambda.Path})
+		}
+
+		return nil
+	}
+
+	if module != "" {
+		workspaceModule := workspace.findModule(module)
+		if workspaceModule == nil {
+			return erk.WithParams(ErrUnknownWorkspaceModule, erk.Params{
+				"module":  module,
+				"path":    lambda.Path,
+				"modules": workspace.moduleDescriptions(),
+			})
+		}
+
+		lambda.Path = workspace.resolvePath(module, lambda.Path)
+		lambda.Module = workspaceModule.Dir
+		return nil
+	}
+
+	workspaceModule := workspace.moduleContaining(lambda.Path)
+	if workspaceModule == nil {
+		return erk.WithParams(ErrPathOutsideWorkspaceModules, erk.Params{
+			"path":    lambda.Path,
+			"modules": workspace.moduleDescriptions(),
+		})
+	}
+
+	lambda.Module = workspaceModule.Dir
+	return nil
+}
+
+// resolvePath of a Lambda relative to RootPath, where the path is relative to the module when it is set.
+// Unknown modules are left for setModule to report.
+func (workspace *Workspace) resolvePath(module, lambdaPath string) string {
+	if workspace == nil || module == "" {
+		return filepath.Clean(lambdaPath)
+	}
+
+	workspaceModule := workspace.findModule(module)
+	if workspaceModule == nil {
+		return filepath.Clean(lambdaPath)
+	}
+
+	return filepath.Join(workspaceModule.Dir, lambdaPath)
+}
+
+// ImportPath of the package in the directory, which is relative to RootPath.
+func (config *Config) ImportPath(dir string) string {
+	module := &WorkspaceModule{Dir: ".", ModulePath: config.ModulePath}
+	if config.Workspace != nil {
+		if workspaceModule := config.Workspace.moduleContaining(dir); workspaceModule != nil {
+			module = workspaceModule
+		}
+	}
+
+	relPath, err := filepath.Rel(module.Dir, dir)
+	if err != nil || relPath == "." {
+		return module.ModulePath
+	}
+
+	return module.ModulePath + "/" + filepath.ToSlash(relPath)
+}
+
+// PackageDir of the import path, relative to RootPath.
+// It returns false when the package is not in the module, or in a module of the workspace.
+func (config *Config) PackageDir(importPath string) (string, bool) {
+	modules := []*WorkspaceModule{{Dir: ".", ModulePath: config.ModulePath}}
+	if config.Workspace != nil {
+		modules = config.Workspace.Modules
+	}
+
+	var dir string
+	var longestModulePath string
+	for _, module := range modules {
+		if module.ModulePath == "" || len(module.ModulePath) <= len(longestModulePath) {
+			continue
+		}
+
+		if importPath == module.ModulePath {
+			dir, longestModulePath = module.Dir, module.ModulePath
+		} else if relPath, ok := strings.CutPrefix(importPath, module.ModulePath+"/"); ok {
+			dir, longestModulePath = path.Join(module.Dir, relPath), module.ModulePath
+		}
+	}
+
+	return dir, longestModulePath != ""
+}
+
+// isWithinDir returns true if the path is the directory, or is within it.
+func isWithinDir(filePath, dir string) bool {
+	return dir == "." || filePath == dir || strings.HasPrefix(filePath, dir+"/")
+}
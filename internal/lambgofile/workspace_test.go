@@ -0,0 +1,109 @@
+package lambgofile_test
+
+import (
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+)
+
+func TestConfigImportPath(t *testing.T) {
+	ensure := ensure.New(t)
+
+	table := []struct {
+		Name               string
+		Config             *lambgofile.Config
+		Dir                string
+		ExpectedImportPath string
+	}{
+		{
+			Name:               "with package in module",
+			Config:             &lambgofile.Config{ModulePath: "github.com/my/app"},
+			Dir:                "lambdas/api",
+			ExpectedImportPath: "github.com/my/app/lambdas/api",
+		},
+		{
+			Name:               "with root package",
+			Config:             &lambgofile.Config{ModulePath: "github.com/my/app"},
+			Dir:                ".",
+			ExpectedImportPath: "github.com/my/app",
+		},
+		{
+			Name:               "with package in workspace module",
+			Config:             &lambgofile.Config{ModulePath: "github.com/my/repo", Workspace: workspaceFixture},
+			Dir:                "services/search/cmd/api",
+			ExpectedImportPath: "example.com/search/cmd/api",
+		},
+		{
+			Name:               "with package in root workspace module",
+			Config:             &lambgofile.Config{ModulePath: "github.com/my/repo", Workspace: workspaceFixture},
+			Dir:                "tools/migrate",
+			ExpectedImportPath: "github.com/my/repo/tools/migrate",
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		ensure(entry.Config.ImportPath(entry.Dir)).Equals(entry.ExpectedImportPath)
+	})
+}
+
+func TestConfigPackageDir(t *testing.T) {
+	ensure := ensure.New(t)
+
+	table := []struct {
+		Name        string
+		Config      *lambgofile.Config
+		ImportPath  string
+		ExpectedDir string
+		ExpectedOK  bool
+	}{
+		{
+			Name:        "with package in module",
+			Config:      &lambgofile.Config{ModulePath: "github.com/my/app"},
+			ImportPath:  "github.com/my/app/internal/queue",
+			ExpectedDir: "internal/queue",
+			ExpectedOK:  true,
+		},
+		{
+			Name:        "with root package",
+			Config:      &lambgofile.Config{ModulePath: "github.com/my/app"},
+			ImportPath:  "github.com/my/app",
+			ExpectedDir: ".",
+			ExpectedOK:  true,
+		},
+		{
+			Name:       "with package outside module",
+			Config:     &lambgofile.Config{ModulePath: "github.com/my/app"},
+			ImportPath: "github.com/my/application",
+		},
+		{
+			Name:        "with package in nested workspace module",
+			Config:      &lambgofile.Config{ModulePath: "github.com/my/repo", Workspace: workspaceFixture},
+			ImportPath:  "github.com/my/repo/services/billing/internal/ledger",
+			ExpectedDir: "services/billing/internal/ledger",
+			ExpectedOK:  true,
+		},
+		{
+			Name:        "with package in workspace module with another path",
+			Config:      &lambgofile.Config{ModulePath: "github.com/my/repo", Workspace: workspaceFixture},
+			ImportPath:  "example.com/search/index",
+			ExpectedDir: "services/search/index",
+			ExpectedOK:  true,
+		},
+		{
+			Name:       "with package outside workspace",
+			Config:     &lambgofile.Config{ModulePath: "github.com/my/repo", Workspace: workspaceFixture},
+			ImportPath: "github.com/my/shared/auth",
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		dir, ok := entry.Config.PackageDir(entry.ImportPath)
+		ensure(dir).Equals(entry.ExpectedDir)
+		ensure(ok).Equals(entry.ExpectedOK)
+	})
+}
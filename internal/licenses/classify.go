@@ -0,0 +1,81 @@
+package licenses
+
+import "strings"
+
+// SPDX identifiers of the licenses that can be classified.
+const (
+	Apache20   = "Apache-2.0"
+	MIT        = "MIT"
+	BSD2Clause = "BSD-2-Clause"
+	BSD3Clause = "BSD-3-Clause"
+	ISC        = "ISC"
+	MPL20      = "MPL-2.0"
+	LGPL21     = "LGPL-2.1"
+	LGPL30     = "LGPL-3.0"
+	GPL20      = "GPL-2.0"
+	GPL30      = "GPL-3.0"
+	AGPL30     = "AGPL-3.0"
+	Unlicense  = "Unlicense"
+	CC010      = "CC0-1.0"
+	Unknown    = "Unknown"
+	NoLicense  = "None"
+)
+
+// IDs of the licenses that modules can be classified as, including Unknown when the license
+// file is not recognized, and None when the module does not have a license file.
+//
+//nolint:gochecknoglobals // Constant list of values
+var IDs = []string{
+	Apache20, MIT, BSD2Clause, BSD3Clause, ISC, MPL20, LGPL21, LGPL30, GPL20, GPL30, AGPL30, Unlicense, CC010, Unknown, NoLicense,
+}
+
+// licenseRule identifies a license by phrases that are all in its text.
+type licenseRule struct {
+	id      string
+	phrases []string
+}
+
+// licenseRules are checked in order, so licenses that mention other licenses are checked first.
+// For example, the LGPL mentions the GPL, and the BSD 3-Clause license contains the BSD 2-Clause license.
+//
+//nolint:gochecknoglobals // Constant list of rules
+var licenseRules = []licenseRule{
+	{AGPL30, []string{"gnu affero general public license"}},
+	{LGPL30, []string{"gnu lesser general public license", "version 3"}},
+	{LGPL21, []string{"gnu lesser general public license"}},
+	{GPL30, []string{"gnu general public license", "version 3"}},
+	{GPL20, []string{"gnu general public license"}},
+	{MPL20, []string{"mozilla public license", "2.0"}},
+	{Apache20, []string{"apache license", "version 2.0"}},
+	{MIT, []string{"permission is hereby granted, free of charge"}},
+	{BSD3Clause, []string{"redistribution and use in source and binary forms", "neither the name"}},
+	{BSD3Clause, []string{"redistribution and use in source and binary forms", "names of its contributors"}},
+	{BSD2Clause, []string{"redistribution and use in source and binary forms"}},
+	{ISC, []string{"permission to use, copy, modify, and", "distribute this software for any purpose with or without fee is hereby granted"}},
+	{Unlicense, []string{"this is free and unencumbered software released into the public domain"}},
+	{CC010, []string{"cc0 1.0 universal"}},
+}
+
+// Classify the text of a license file, ignoring case and differences in whitespace.
+// It returns Unknown when the license is not recognized.
+func Classify(text string) string {
+	normalized := strings.ToLower(strings.Join(strings.Fields(text), " "))
+
+	for _, rule := range licenseRules {
+		if containsAll(normalized, rule.phrases) {
+			return rule.id
+		}
+	}
+
+	return Unknown
+}
+
+func containsAll(text string, phrases []string) bool {
+	for _, phrase := range phrases {
+		if !strings.Contains(text, phrase) {
+			return false
+		}
+	}
+
+	return true
+}
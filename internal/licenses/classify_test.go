@@ -0,0 +1,99 @@
+package licenses_test
+
+import (
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/licenses"
+)
+
+func TestClassify(t *testing.T) {
+	ensure := ensure.New(t)
+
+	table := []struct {
+		Name       string
+		Text       string
+		ExpectedID string
+	}{
+		{
+			Name:       "with Apache 2.0",
+			Text:       "                                 Apache License\n                           Version 2.0, January 2004\n",
+			ExpectedID: licenses.Apache20,
+		},
+		{
+			Name: "with MIT",
+			Text: "MIT License\n\nCopyright (c) 2020 Someone\n\nPermission is hereby granted, free of charge, to any person obtaining a copy\n" +
+				"of this software and associated documentation files",
+			ExpectedID: licenses.MIT,
+		},
+		{
+			Name: "with BSD 3-Clause",
+			Text: "Redistribution and use in source and binary forms, with or without\nmodification, are permitted provided that...\n" +
+				"   * Neither the name of Google Inc. nor the names of its\ncontributors may be used to endorse",
+			ExpectedID: licenses.BSD3Clause,
+		},
+		{
+			Name:       "with BSD 2-Clause",
+			Text:       "Redistribution and use in source and binary forms, with or without\nmodification, are permitted provided that...",
+			ExpectedID: licenses.BSD2Clause,
+		},
+		{
+			Name: "with ISC",
+			Text: "Permission to use, copy, modify, and/or distribute this software for any\n" +
+				"purpose with or without fee is hereby granted, provided that the above",
+			ExpectedID: licenses.ISC,
+		},
+		{
+			Name:       "with MPL 2.0",
+			Text:       "Mozilla Public License Version 2.0\n==================================",
+			ExpectedID: licenses.MPL20,
+		},
+		{
+			Name:       "with LGPL 3.0",
+			Text:       "GNU LESSER GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007\n\nThis version of the GNU Lesser General Public License",
+			ExpectedID: licenses.LGPL30,
+		},
+		{
+			Name:       "with LGPL 2.1",
+			Text:       "GNU LESSER GENERAL PUBLIC LICENSE\nVersion 2.1, February 1999",
+			ExpectedID: licenses.LGPL21,
+		},
+		{
+			Name:       "with GPL 3.0",
+			Text:       "GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007",
+			ExpectedID: licenses.GPL30,
+		},
+		{
+			Name:       "with GPL 2.0",
+			Text:       "GNU GENERAL PUBLIC LICENSE\nVersion 2, June 1991",
+			ExpectedID: licenses.GPL20,
+		},
+		{
+			Name:       "with AGPL 3.0",
+			Text:       "GNU AFFERO GENERAL PUBLIC LICENSE\nVersion 3, 19 November 2007",
+			ExpectedID: licenses.AGPL30,
+		},
+		{
+			Name:       "with Unlicense",
+			Text:       "This is free and unencumbered software released into the public domain.",
+			ExpectedID: licenses.Unlicense,
+		},
+		{
+			Name:       "with CC0 1.0",
+			Text:       "Creative Commons Legal Code\n\nCC0 1.0 Universal",
+			ExpectedID: licenses.CC010,
+		},
+		{
+			Name:       "with unknown license",
+			Text:       "All rights reserved.",
+			ExpectedID: licenses.Unknown,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		ensure(licenses.Classify(entry.Text)).Equals(entry.ExpectedID)
+	})
+}
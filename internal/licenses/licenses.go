@@ -0,0 +1,312 @@
+// Package licenses collects the licenses of the third-party modules built into each binary, from the module cache.
+package licenses
+
+import (
+	"debug/buildinfo"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/runcmd"
+	"golang.org/x/mod/module"
+)
+
+type ErkCannotCollectLicenses struct{ erk.DefaultKind }
+
+var (
+	ErrCannotReadBuildInfo = erk.New(ErkCannotCollectLicenses{}, "Cannot read the build information embedded in '{{.path}}': {{.err}}")
+	ErrCannotReadGoEnv     = erk.New(ErkCannotCollectLicenses{}, "Cannot find the module cache with `go env`: {{.err}}")
+	ErrModuleNotFound      = erk.New(ErkCannotCollectLicenses{},
+		"Cannot find the files of {{.module}} in the module cache or vendor directory. Run `go mod download` first.",
+	)
+	ErrCannotReadLicense = erk.New(ErkCannotCollectLicenses{}, "Cannot read the license files of {{.module}}: {{.err}}")
+	ErrDisallowed        = erk.New(ErkCannotCollectLicenses{}, "Modules use disallowed licenses: {{.modules}}")
+)
+
+const (
+	// develVersion is the version of modules that are not downloaded, such as the main module and modules in the workspace.
+	develVersion = "(devel)"
+
+	// standardLibrary is the name used for the Go standard library and runtime, which are built into every binary.
+	standardLibrary = "Go"
+)
+
+// licenseFilePattern matches the names of the files containing licenses and notices, such as LICENSE, LICENSE.md, or COPYING.
+var licenseFilePattern = regexp.MustCompile(`(?i)^(licen[cs]e|copying|unlicense|notice)([-._].*)?$`)
+
+// noticeFilePattern matches the NOTICE files that the Apache license requires to be distributed, which are not licenses.
+var noticeFilePattern = regexp.MustCompile(`(?i)^notice([-._].*)?$`)
+
+// Params configures collecting the licenses of the binaries in an artifact.
+type Params struct {
+	// Name of the artifact, such as the path of the Lambda or the name of the layer.
+	Name string
+
+	// RootPath of the module, which is used to find vendored modules.
+	RootPath string
+
+	// BinaryPaths of the built binaries in the artifact. Layers can contain more than one binary.
+	BinaryPaths []string
+
+	// OutPath of the THIRD_PARTY_LICENSES file, or empty when the licenses are only checked.
+	OutPath string
+
+	// Disallowed licenses, which fail when any module uses them.
+	Disallowed []string
+}
+
+// Module built into a binary, and the license files in its root directory.
+type Module struct {
+	Path    string
+	Version string
+	Files   []*File
+}
+
+// File containing a license or notice.
+type File struct {
+	Name string
+	Text string
+
+	// License that the text was classified as, which is empty for NOTICE files.
+	License string
+}
+
+// Licenses of the module, without duplicates. It is None when the module does not have any license files.
+func (m *Module) Licenses() []string {
+	var ids []string
+	for _, file := range m.Files {
+		if file.License != "" && !slices.Contains(ids, file.License) {
+			ids = append(ids, file.License)
+		}
+	}
+
+	if len(ids) == 0 {
+		return []string{NoLicense}
+	}
+
+	return ids
+}
+
+type CollectorAPI interface {
+	Collect(params *Params) error
+}
+
+// Collector finds the license files of each module in the module cache, and classifies them.
+// Only third-party modules are included, so the main module and modules in the workspace are skipped.
+type Collector struct {
+	Cmd runcmd.RunnerAPI
+
+	// ReadBuildInfo embedded in a binary by the go command. Defaults to buildinfo.ReadFile.
+	ReadBuildInfo func(path string) (*debug.BuildInfo, error)
+
+	goEnvOnce sync.Once
+	goEnv     *goEnv
+	goEnvErr  error
+}
+
+var _ CollectorAPI = &Collector{}
+
+// goEnv contains the directories that the go command downloads modules and the standard library to.
+type goEnv struct {
+	modCache string
+	goRoot   string
+}
+
+// Collect the licenses of the modules built into the binaries, and write them to the OutPath when it is set.
+// It fails when any module uses a disallowed license.
+func (c *Collector) Collect(params *Params) error {
+	env, err := c.readGoEnv(params.RootPath)
+	if err != nil {
+		return err
+	}
+
+	var modules []*Module
+	for _, binaryPath := range params.BinaryPaths {
+		info, err := c.readBuildInfo(binaryPath)
+		if err != nil {
+			return erk.WrapWith(ErrCannotReadBuildInfo, err, erk.Params{"path": binaryPath})
+		}
+
+		for _, dependency := range dependencies(info) {
+			if slices.ContainsFunc(modules, func(existing *Module) bool {
+				return existing.Path == dependency.Path && existing.Version == dependency.Version
+			}) {
+				continue
+			}
+
+			dependencyModule, err := readModule(env, params.RootPath, dependency)
+			if err != nil {
+				return err
+			}
+
+			modules = append(modules, dependencyModule)
+		}
+	}
+
+	slices.SortFunc(modules, func(a, b *Module) int {
+		// The standard library is listed first, since it is in every binary
+		if (a.Path == standardLibrary) != (b.Path == standardLibrary) {
+			if a.Path == standardLibrary {
+				return -1
+			}
+
+			return 1
+		}
+
+		if byPath := strings.Compare(a.Path, b.Path); byPath != 0 {
+			return byPath
+		}
+
+		return strings.Compare(a.Version, b.Version)
+	})
+
+	if params.OutPath != "" {
+		if err := writeNotice(params.OutPath, params.Name, modules); err != nil {
+			return err
+		}
+	}
+
+	return checkDisallowed(modules, params.Disallowed)
+}
+
+func (c *Collector) readBuildInfo(path string) (*debug.BuildInfo, error) {
+	if c.ReadBuildInfo == nil {
+		return buildinfo.ReadFile(path)
+	}
+
+	return c.ReadBuildInfo(path)
+}
+
+// readGoEnv once, since Lambdas are built in parallel, and the directories are the same for each of them.
+func (c *Collector) readGoEnv(rootPath string) (*goEnv, error) {
+	c.goEnvOnce.Do(func() {
+		out, err := c.Cmd.Exec(&runcmd.ExecParams{
+			PWD:  rootPath,
+			CMD:  "go",
+			Args: []string{"env", "GOMODCACHE", "GOROOT"},
+		})
+		if err != nil {
+			c.goEnvErr = erk.WrapAs(ErrCannotReadGoEnv, err)
+			return
+		}
+
+		lines := strings.Split(strings.TrimSpace(out), "\n")
+		if len(lines) != 2 { //nolint:mnd
+			c.goEnvErr = erk.WrapAs(ErrCannotReadGoEnv, errors.New("unexpected output: "+out)) //nolint:err113
+			return
+		}
+
+		c.goEnv = &goEnv{modCache: strings.TrimSpace(lines[0]), goRoot: strings.TrimSpace(lines[1])}
+	})
+
+	return c.goEnv, c.goEnvErr
+}
+
+// dependencies of the binary that are downloaded, and the standard library.
+// Replaced modules are listed as their replacement, since that is the code in the binary.
+// Modules replaced by local directories are part of the project, like the main module, so they are skipped.
+func dependencies(info *debug.BuildInfo) []*debug.Module {
+	modules := []*debug.Module{{Path: standardLibrary, Version: info.GoVersion}}
+	for _, dependency := range info.Deps {
+		if dependency.Replace != nil {
+			dependency = dependency.Replace
+		}
+
+		if dependency.Version != "" && dependency.Version != develVersion {
+			modules = append(modules, dependency)
+		}
+	}
+
+	return modules
+}
+
+// readModule finds the license files in the root of the module, which is in the module cache, or vendored.
+// The standard library's license is in GOROOT.
+func readModule(env *goEnv, rootPath string, dependency *debug.Module) (*Module, error) {
+	moduleName := dependency.Path + "@" + dependency.Version
+	dir, err := moduleDir(env, rootPath, dependency)
+	if err != nil {
+		return nil, erk.WithParams(ErrModuleNotFound, erk.Params{"module": moduleName})
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadLicense, err, erk.Params{"module": moduleName})
+	}
+
+	m := &Module{Path: dependency.Path, Version: dependency.Version}
+	for _, entry := range entries {
+		if entry.IsDir() || !licenseFilePattern.MatchString(entry.Name()) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, erk.WrapWith(ErrCannotReadLicense, err, erk.Params{"module": moduleName})
+		}
+
+		file := &File{Name: entry.Name(), Text: string(data)}
+		if !noticeFilePattern.MatchString(entry.Name()) {
+			file.License = Classify(file.Text)
+		}
+
+		m.Files = append(m.Files, file)
+	}
+
+	return m, nil
+}
+
+func moduleDir(env *goEnv, rootPath string, dependency *debug.Module) (string, error) {
+	if dependency.Path == standardLibrary {
+		return env.goRoot, nil
+	}
+
+	escapedPath, err := module.EscapePath(dependency.Path)
+	if err != nil {
+		return "", err
+	}
+
+	escapedVersion, err := module.EscapeVersion(dependency.Version)
+	if err != nil {
+		return "", err
+	}
+
+	dirs := []string{
+		filepath.Join(env.modCache, escapedPath+"@"+escapedVersion),
+		filepath.Join(rootPath, "vendor", filepath.FromSlash(dependency.Path)),
+	}
+
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir, nil
+		}
+	}
+
+	return "", fs.ErrNotExist
+}
+
+// checkDisallowed fails when any license of a module is disallowed. Modules with more than one license
+// (eg. LICENSE-MIT and LICENSE-APACHE) fail when any of them is disallowed, since they may all apply.
+func checkDisallowed(modules []*Module, disallowed []string) error {
+	var violations []string
+	for _, m := range modules {
+		for _, id := range m.Licenses() {
+			if slices.Contains(disallowed, id) {
+				violations = append(violations, m.Path+"@"+m.Version+" ("+id+")")
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return erk.WithParams(ErrDisallowed, erk.Params{"modules": strings.Join(violations, ", ")})
+	}
+
+	return nil
+}
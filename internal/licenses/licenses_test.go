@@ -0,0 +1,286 @@
+package licenses_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/licenses"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/lambgo/internal/runcmd"
+	"github.com/golang/mock/gomock"
+)
+
+func TestCollect(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Cmd *mock_runcmd.MockRunnerAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	const (
+		bsd3    = "Redistribution and use in source and binary forms, with or without modification...\nNeither the name of Google LLC"
+		apache  = "Apache License\nVersion 2.0, January 2004"
+		mit     = "Permission is hereby granted, free of charge, to any person obtaining a copy"
+		isc     = "Permission to use, copy, modify, and/or distribute this software for any purpose with or without fee is hereby granted"
+		gpl3    = "GNU GENERAL PUBLIC LICENSE\nVersion 3, 29 June 2007"
+		noticeA = "AWS Lambda for Go\nCopyright 2018 Amazon.com, Inc. or its affiliates."
+	)
+
+	dir := t.TempDir()
+	goRoot := filepath.Join(dir, "goroot")
+	modCache := filepath.Join(dir, "modcache")
+	rootPath := filepath.Join(dir, "root")
+
+	files := map[string]string{
+		"goroot/LICENSE":   bsd3,
+		"goroot/README.md": "The Go Programming Language",
+		"modcache/github.com/aws/aws-lambda-go@v1.47.0/LICENSE": apache,
+		"modcache/github.com/aws/aws-lambda-go@v1.47.0/NOTICE":  noticeA,
+		"modcache/github.com/!burnt!sushi/toml@v1.3.2/COPYING":  mit,
+		"modcache/github.com/my/dual@v1.0.0/LICENSE-APACHE":     apache,
+		"modcache/github.com/my/dual@v1.0.0/LICENSE-MIT":        mit,
+		"modcache/github.com/my/dual@v1.0.0/licenses/LICENSE":   gpl3,
+		"modcache/github.com/my/unlicensed@v0.1.0/README.md":    "No license",
+		"modcache/github.com/other/fork@v2.0.0/LICENSE":         gpl3,
+		"root/vendor/github.com/my/vendored/LICENSE.txt":        isc,
+	}
+
+	for path, contents := range files {
+		fullPath := filepath.Join(dir, path)
+		ensure(os.MkdirAll(filepath.Dir(fullPath), 0o755)).IsNotError()
+		ensure(os.WriteFile(fullPath, []byte(contents), 0o600)).IsNotError()
+	}
+
+	buildInfos := map[string]*debug.BuildInfo{
+		"out/lambdas/api": {
+			GoVersion: "go1.23.4",
+			Main:      debug.Module{Path: "github.com/my/app", Version: "(devel)"},
+			Deps: []*debug.Module{
+				{Path: "github.com/aws/aws-lambda-go", Version: "v1.47.0"},
+				{Path: "github.com/BurntSushi/toml", Version: "v1.3.2"},
+				{Path: "github.com/my/shared", Version: "v1.0.0", Replace: &debug.Module{Path: "../shared"}},
+				{Path: "github.com/my/workspace", Version: "(devel)"},
+			},
+		},
+		"out/layers/tools/converter": {
+			GoVersion: "go1.23.4",
+			Main:      debug.Module{Path: "github.com/my/tools", Version: "(devel)"},
+			Deps: []*debug.Module{
+				{Path: "github.com/my/dual", Version: "v1.0.0"},
+				{Path: "github.com/my/vendored", Version: "v0.2.0"},
+			},
+		},
+		"out/layers/tools/helper": {
+			GoVersion: "go1.23.4",
+			Main:      debug.Module{Path: "github.com/my/tools", Version: "(devel)"},
+			Deps: []*debug.Module{
+				{Path: "github.com/my/dual", Version: "v1.0.0"},
+				{Path: "github.com/my/unlicensed", Version: "v0.1.0"},
+			},
+		},
+		"out/lambdas/forked": {
+			GoVersion: "go1.23.4",
+			Main:      debug.Module{Path: "github.com/my/app", Version: "(devel)"},
+			Deps: []*debug.Module{
+				{Path: "github.com/my/fork", Version: "v1.0.0", Replace: &debug.Module{Path: "github.com/other/fork", Version: "v2.0.0"}},
+			},
+		},
+		"out/lambdas/missing-module": {
+			GoVersion: "go1.23.4",
+			Main:      debug.Module{Path: "github.com/my/app", Version: "(devel)"},
+			Deps:      []*debug.Module{{Path: "github.com/not/downloaded", Version: "v1.0.0"}},
+		},
+	}
+
+	expectGoEnv := func(m *Mocks) {
+		m.Cmd.EXPECT().Exec(&runcmd.ExecParams{PWD: rootPath, CMD: "go", Args: []string{"env", "GOMODCACHE", "GOROOT"}}).
+			Return(modCache+"\n"+goRoot+"\n", nil)
+	}
+
+	table := []struct {
+		Name           string
+		Params         *licenses.Params
+		Bundle         bool
+		ExpectedNotice string
+		ExpectedError  error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *licenses.Collector
+	}{
+		{
+			Name:       "with Lambda",
+			Params:     &licenses.Params{Name: "lambdas/api", BinaryPaths: []string{"out/lambdas/api"}, Disallowed: []string{licenses.GPL30}},
+			Bundle:     true,
+			SetupMocks: expectGoEnv,
+			ExpectedNotice: "Third-party licenses for lambdas/api\n" +
+				"\n" +
+				"================================================================================\n" +
+				"Go go1.23.4\n" +
+				"License: BSD-3-Clause\n" +
+				"================================================================================\n" +
+				"\n" +
+				"--- LICENSE ---\n" +
+				"\n" +
+				bsd3 + "\n" +
+				"\n" +
+				"================================================================================\n" +
+				"github.com/BurntSushi/toml v1.3.2\n" +
+				"License: MIT\n" +
+				"================================================================================\n" +
+				"\n" +
+				"--- COPYING ---\n" +
+				"\n" +
+				mit + "\n" +
+				"\n" +
+				"================================================================================\n" +
+				"github.com/aws/aws-lambda-go v1.47.0\n" +
+				"License: Apache-2.0\n" +
+				"================================================================================\n" +
+				"\n" +
+				"--- LICENSE ---\n" +
+				"\n" +
+				apache + "\n" +
+				"\n" +
+				"--- NOTICE ---\n" +
+				"\n" +
+				noticeA + "\n",
+		},
+
+		{
+			Name: "with layer containing more than one binary",
+			Params: &licenses.Params{
+				Name:        "tools",
+				BinaryPaths: []string{"out/layers/tools/converter", "out/layers/tools/helper"},
+			},
+			Bundle:     true,
+			SetupMocks: expectGoEnv,
+			ExpectedNotice: "Third-party licenses for tools\n" +
+				"\n" +
+				"================================================================================\n" +
+				"Go go1.23.4\n" +
+				"License: BSD-3-Clause\n" +
+				"================================================================================\n" +
+				"\n" +
+				"--- LICENSE ---\n" +
+				"\n" +
+				bsd3 + "\n" +
+				"\n" +
+				"================================================================================\n" +
+				"github.com/my/dual v1.0.0\n" +
+				"License: Apache-2.0, MIT\n" +
+				"================================================================================\n" +
+				"\n" +
+				"--- LICENSE-APACHE ---\n" +
+				"\n" +
+				apache + "\n" +
+				"\n" +
+				"--- LICENSE-MIT ---\n" +
+				"\n" +
+				mit + "\n" +
+				"\n" +
+				"================================================================================\n" +
+				"github.com/my/unlicensed v0.1.0\n" +
+				"License: None\n" +
+				"================================================================================\n" +
+				"\n" +
+				"================================================================================\n" +
+				"github.com/my/vendored v0.2.0\n" +
+				"License: ISC\n" +
+				"================================================================================\n" +
+				"\n" +
+				"--- LICENSE.txt ---\n" +
+				"\n" +
+				isc + "\n",
+		},
+
+		{
+			Name:          "with disallowed license of a replacement, without bundling",
+			Params:        &licenses.Params{Name: "lambdas/forked", BinaryPaths: []string{"out/lambdas/forked"}, Disallowed: []string{licenses.GPL30}},
+			SetupMocks:    expectGoEnv,
+			ExpectedError: licenses.ErrDisallowed,
+		},
+
+		{
+			Name: "with disallowed missing license",
+			Params: &licenses.Params{
+				Name:        "tools",
+				BinaryPaths: []string{"out/layers/tools/helper"},
+				Disallowed:  []string{licenses.NoLicense},
+			},
+			Bundle:        true,
+			SetupMocks:    expectGoEnv,
+			ExpectedError: licenses.ErrDisallowed,
+		},
+
+		{
+			Name:          "when the module is not downloaded",
+			Params:        &licenses.Params{Name: "lambdas/missing-module", BinaryPaths: []string{"out/lambdas/missing-module"}},
+			SetupMocks:    expectGoEnv,
+			ExpectedError: licenses.ErrModuleNotFound,
+		},
+
+		{
+			Name:          "when the build info cannot be read",
+			Params:        &licenses.Params{Name: "lambdas/missing", BinaryPaths: []string{"out/lambdas/missing"}},
+			SetupMocks:    expectGoEnv,
+			ExpectedError: licenses.ErrCannotReadBuildInfo,
+		},
+
+		{
+			Name:          "when go env fails",
+			Params:        &licenses.Params{Name: "lambdas/api", BinaryPaths: []string{"out/lambdas/api"}},
+			ExpectedError: licenses.ErrCannotReadGoEnv,
+			SetupMocks: func(m *Mocks) {
+				m.Cmd.EXPECT().Exec(gomock.Any()).Return("", exampleError)
+			},
+		},
+
+		{
+			Name:          "when go env has unexpected output",
+			Params:        &licenses.Params{Name: "lambdas/api", BinaryPaths: []string{"out/lambdas/api"}},
+			ExpectedError: licenses.ErrCannotReadGoEnv,
+			SetupMocks: func(m *Mocks) {
+				m.Cmd.EXPECT().Exec(gomock.Any()).Return("go: downloading go1.23.4\n"+modCache+"\n"+goRoot+"\n", nil)
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		entry.Subject.ReadBuildInfo = func(path string) (*debug.BuildInfo, error) {
+			if info, ok := buildInfos[path]; ok {
+				return info, nil
+			}
+
+			return nil, exampleError
+		}
+
+		params := *entry.Params
+		params.RootPath = rootPath
+		if entry.Bundle {
+			params.OutPath = filepath.Join(ensure.T().TempDir(), "THIRD_PARTY_LICENSES")
+		}
+
+		// go env is only run once, even when collecting the licenses of more than one artifact
+		ensure(entry.Subject.Collect(&params)).IsError(entry.ExpectedError)
+		if entry.ExpectedError != nil {
+			return
+		}
+
+		ensure(entry.Subject.Collect(&params)).IsNotError()
+
+		if entry.Bundle {
+			notice, err := os.ReadFile(params.OutPath)
+			ensure(err).IsNotError()
+			ensure(string(notice)).Equals(entry.ExpectedNotice)
+		}
+	})
+}
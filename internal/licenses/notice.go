@@ -0,0 +1,32 @@
+package licenses
+
+import (
+	"os"
+	"strings"
+)
+
+// ZippedFileName of the file listing the licenses, which is added to the root of each zip.
+const ZippedFileName = "THIRD_PARTY_LICENSES"
+
+// noticeSeparator separates the modules in the THIRD_PARTY_LICENSES file.
+var noticeSeparator = strings.Repeat("=", 80) //nolint:gochecknoglobals,mnd // Constant separator
+
+// writeNotice lists the licenses of each module, followed by the text of its license and notice files.
+func writeNotice(path, name string, modules []*Module) error {
+	var notice strings.Builder
+	notice.WriteString("Third-party licenses for " + name + "\n")
+
+	for _, m := range modules {
+		notice.WriteString("\n" + noticeSeparator + "\n")
+		notice.WriteString(m.Path + " " + m.Version + "\n")
+		notice.WriteString("License: " + strings.Join(m.Licenses(), ", ") + "\n")
+		notice.WriteString(noticeSeparator + "\n")
+
+		for _, file := range m.Files {
+			notice.WriteString("\n--- " + file.Name + " ---\n\n")
+			notice.WriteString(strings.TrimRight(file.Text, "\n") + "\n")
+		}
+	}
+
+	return os.WriteFile(path, []byte(notice.String()), 0o644) //nolint:gosec,mnd // Licenses are not secret
+}
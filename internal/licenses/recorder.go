@@ -0,0 +1,24 @@
+package licenses
+
+import (
+	"log"
+	"strings"
+)
+
+// Recorder prints the licenses that would be collected instead of collecting them, for dry runs.
+type Recorder struct {
+	Logger *log.Logger
+}
+
+var _ CollectorAPI = &Recorder{}
+
+// Collect prints the THIRD_PARTY_LICENSES file that would be written, or that the licenses would be checked.
+func (r *Recorder) Collect(params *Params) error {
+	outPath := params.OutPath
+	if outPath == "" {
+		outPath = "(check only)"
+	}
+
+	r.Logger.Printf("   licenses: %s <- %s\n", outPath, strings.Join(params.BinaryPaths, ", "))
+	return nil
+}
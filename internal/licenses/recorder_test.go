@@ -0,0 +1,40 @@
+package licenses_test
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/licenses"
+)
+
+func TestRecorderCollect(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("prints the THIRD_PARTY_LICENSES file without writing it", func(ensure ensuring.E) {
+		output := &bytes.Buffer{}
+		recorder := &licenses.Recorder{Logger: log.New(output, "", 0)}
+
+		err := recorder.Collect(&licenses.Params{
+			Name:        "tools",
+			BinaryPaths: []string{"tmp/layers/tools/converter", "tmp/layers/tools/helper"},
+			OutPath:     "tmp/layers/tools.THIRD_PARTY_LICENSES",
+		})
+		ensure(err).IsNotError()
+		ensure(output.String()).Equals("   licenses: tmp/layers/tools.THIRD_PARTY_LICENSES <- tmp/layers/tools/converter, tmp/layers/tools/helper\n")
+	})
+
+	ensure.Run("prints that the licenses are only checked", func(ensure ensuring.E) {
+		output := &bytes.Buffer{}
+		recorder := &licenses.Recorder{Logger: log.New(output, "", 0)}
+
+		err := recorder.Collect(&licenses.Params{
+			Name:        "lambdas/api",
+			BinaryPaths: []string{"tmp/lambdas/api/bootstrap"},
+		})
+		ensure(err).IsNotError()
+		ensure(output.String()).Equals("   licenses: (check only) <- tmp/lambdas/api/bootstrap\n")
+	})
+}
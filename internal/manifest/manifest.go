@@ -0,0 +1,191 @@
+// Package manifest records the artifacts produced by building the .lambgo.yml file.
+package manifest
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FileName of the manifest, which is written to the root of the outDirectory.
+const FileName = "lambgo-manifest.json"
+
+type ArtifactKind string
+
+const (
+	KindLambda    ArtifactKind = "lambda"
+	KindExtension ArtifactKind = "extension"
+	KindLayer     ArtifactKind = "layer"
+)
+
+// Manifest lists the artifacts built into the outDirectory.
+type Manifest struct {
+	Artifacts []*Artifact `json:"artifacts"`
+}
+
+// Artifact is a single zip file produced by the build.
+type Artifact struct {
+	Kind    ArtifactKind `json:"kind"`
+	Name    string       `json:"name"`
+	ZipPath string       `json:"zipPath"`
+	Entries []*Entry     `json:"entries"`
+	Image   *Image       `json:"image,omitempty"`
+
+	// SBOMPath is the software bill of materials written alongside the zip, or empty when none is written.
+	SBOMPath string `json:"sbomPath,omitempty"`
+
+	// Signature of the zip, or nil when the zip is not signed.
+	Signature *Signature `json:"signature,omitempty"`
+}
+
+// Image is the OCI container image written alongside an artifact's zip.
+type Image struct {
+	Path   string `json:"path"`
+	Digest string `json:"digest"`
+}
+
+// Signature is the detached signature written alongside an artifact's zip.
+type Signature struct {
+	Path      string `json:"path"`
+	Algorithm string `json:"algorithm"`
+
+	// KeyID is the SHA-256 digest of the public key, in its PKIX DER form (eg. sha256:<hex>).
+	KeyID string `json:"keyId"`
+
+	// ZipDigest is the SHA-256 digest of the signed zip (eg. sha256:<hex>).
+	ZipDigest string `json:"zipDigest"`
+}
+
+// Entry is a file contained in an artifact's zip.
+type Entry struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+
+	// Size of the binary, or nil when the entry is not a binary built by lambgo.
+	Size *BinarySize `json:"size,omitempty"`
+}
+
+// BinarySize breaks down the size of a built binary, so later builds can be compared against it with `lambgo size --diff`.
+type BinarySize struct {
+	// BuildPath of the main package, relative to the root of the module.
+	BuildPath string `json:"buildPath"`
+	FileSize  int64  `json:"fileSize"`
+
+	// Stripped is true when the binary does not have an ELF symbol table, so only the sizes of functions are known.
+	Stripped bool `json:"stripped,omitempty"`
+
+	// Sections and Packages are sorted from largest to smallest.
+	Sections []*Size `json:"sections"`
+	Packages []*Size `json:"packages"`
+}
+
+// Size of a section or package in a binary.
+type Size struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+type StoreAPI interface {
+	Update(path string, artifacts []*Artifact) error
+}
+
+// Store persists the manifest as JSON.
+type Store struct{}
+
+var _ StoreAPI = &Store{}
+
+// PathFor returns the path of the manifest for the provided outDirectory.
+func PathFor(outDirectory string) string {
+	return filepath.Join(outDirectory, FileName)
+}
+
+// Update the manifest located at path with the provided artifacts.
+// Artifacts replace any existing artifact with the same kind and name, so
+// artifacts from previous builds (eg. when using --only) are preserved.
+func (s *Store) Update(path string, artifacts []*Artifact) error {
+	m, err := Read(path)
+	if err != nil {
+		return err
+	}
+
+	m.upsert(artifacts)
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil { //nolint:mnd
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o644) //nolint:gosec,mnd // The manifest is not sensitive
+}
+
+// Read the manifest located at path. The manifest is empty when it does not exist.
+func Read(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Manifest{}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *Manifest) upsert(artifacts []*Artifact) {
+	for _, artifact := range artifacts {
+		index := slices.IndexFunc(m.Artifacts, func(existing *Artifact) bool {
+			return existing.Kind == artifact.Kind && existing.Name == artifact.Name
+		})
+
+		if index >= 0 {
+			m.Artifacts[index] = artifact
+		} else {
+			m.Artifacts = append(m.Artifacts, artifact)
+		}
+	}
+
+	SortArtifacts(m.Artifacts)
+}
+
+// BinarySize returns the size of the binary built for the main package at buildPath, which is zipped into the artifact
+// with the kind and name, or nil if its size is not recorded.
+func (m *Manifest) BinarySize(kind ArtifactKind, artifactName, buildPath string) *BinarySize {
+	for _, artifact := range m.Artifacts {
+		if artifact.Kind != kind || artifact.Name != artifactName {
+			continue
+		}
+
+		for _, entry := range artifact.Entries {
+			if entry.Size != nil && entry.Size.BuildPath == buildPath {
+				return entry.Size
+			}
+		}
+	}
+
+	return nil
+}
+
+// SortArtifacts by kind and then name, so the manifest is deterministic.
+func SortArtifacts(artifacts []*Artifact) {
+	slices.SortFunc(artifacts, func(a, b *Artifact) int {
+		if a.Kind != b.Kind {
+			return strings.Compare(string(a.Kind), string(b.Kind))
+		}
+
+		return strings.Compare(a.Name, b.Name)
+	})
+}
@@ -0,0 +1,153 @@
+package manifest_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+)
+
+func TestUpdate(t *testing.T) {
+	ensure := ensure.New(t)
+
+	readManifest := func(ensure ensuring.E, path string) *manifest.Manifest {
+		data, err := os.ReadFile(path)
+		ensure(err).IsNotError()
+
+		m := &manifest.Manifest{}
+		ensure(json.Unmarshal(data, m)).IsNotError()
+		return m
+	}
+
+	lambdaArtifact := func(name, zippedFileName string) *manifest.Artifact {
+		return &manifest.Artifact{
+			Kind:    manifest.KindLambda,
+			Name:    name,
+			ZipPath: "tmp/" + name + ".zip",
+			Entries: []*manifest.Entry{{Name: zippedFileName, Source: "tmp/" + name}},
+		}
+	}
+
+	ensure.Run("when the manifest does not exist", func(ensure ensuring.E) {
+		path := manifest.PathFor(filepath.Join(ensure.T().TempDir(), "out"))
+
+		store := manifest.Store{}
+		err := store.Update(path, []*manifest.Artifact{
+			lambdaArtifact("lambdas/b", "b"),
+			{Kind: manifest.KindLayer, Name: "shared", ZipPath: "tmp/layers/shared.zip"},
+			lambdaArtifact("lambdas/a", "a"),
+		})
+		ensure(err).IsNotError()
+
+		ensure(readManifest(ensure, path)).Equals(&manifest.Manifest{
+			Artifacts: []*manifest.Artifact{
+				lambdaArtifact("lambdas/a", "a"),
+				lambdaArtifact("lambdas/b", "b"),
+				{Kind: manifest.KindLayer, Name: "shared", ZipPath: "tmp/layers/shared.zip"},
+			},
+		})
+	})
+
+	ensure.Run("when the manifest already exists", func(ensure ensuring.E) {
+		path := manifest.PathFor(ensure.T().TempDir())
+
+		store := manifest.Store{}
+		err := store.Update(path, []*manifest.Artifact{
+			lambdaArtifact("lambdas/a", "a"),
+			lambdaArtifact("lambdas/b", "b"),
+		})
+		ensure(err).IsNotError()
+
+		err = store.Update(path, []*manifest.Artifact{
+			lambdaArtifact("lambdas/b", "bootstrap"),
+			lambdaArtifact("lambdas/c", "c"),
+		})
+		ensure(err).IsNotError()
+
+		ensure(readManifest(ensure, path)).Equals(&manifest.Manifest{
+			Artifacts: []*manifest.Artifact{
+				lambdaArtifact("lambdas/a", "a"),
+				lambdaArtifact("lambdas/b", "bootstrap"),
+				lambdaArtifact("lambdas/c", "c"),
+			},
+		})
+	})
+
+	ensure.Run("when the existing manifest is invalid", func(ensure ensuring.E) {
+		path := manifest.PathFor(ensure.T().TempDir())
+		ensure(os.WriteFile(path, []byte("not json"), 0o600)).IsNotError()
+
+		store := manifest.Store{}
+		err := store.Update(path, []*manifest.Artifact{lambdaArtifact("lambdas/a", "a")})
+		ensure(err).IsNotNil()
+	})
+}
+
+func TestBinarySize(t *testing.T) {
+	ensure := ensure.New(t)
+
+	apiSize := &manifest.BinarySize{BuildPath: "lambdas/api", FileSize: 1024, Packages: []*manifest.Size{{Name: "runtime", Size: 512}}}
+	converterSize := &manifest.BinarySize{BuildPath: "tools/converter", FileSize: 2048}
+
+	m := &manifest.Manifest{
+		Artifacts: []*manifest.Artifact{
+			{
+				Kind:    manifest.KindLambda,
+				Name:    "lambdas/api",
+				Entries: []*manifest.Entry{{Name: "bootstrap", Source: "tmp/lambdas/api", Size: apiSize}},
+			},
+			{
+				Kind: manifest.KindLayer,
+				Name: "shared",
+				Entries: []*manifest.Entry{
+					{Name: "data/config.json", Source: "data/config.json"},
+					{Name: "bin/converter", Source: "tmp/layers/shared/tools/converter", Size: converterSize},
+				},
+			},
+		},
+	}
+
+	table := []struct {
+		Name         string
+		Kind         manifest.ArtifactKind
+		ArtifactName string
+		BuildPath    string
+		Expected     *manifest.BinarySize
+	}{
+		{
+			Name:         "with Lambda",
+			Kind:         manifest.KindLambda,
+			ArtifactName: "lambdas/api",
+			BuildPath:    "lambdas/api",
+			Expected:     apiSize,
+		},
+		{
+			Name:         "with layer package",
+			Kind:         manifest.KindLayer,
+			ArtifactName: "shared",
+			BuildPath:    "tools/converter",
+			Expected:     converterSize,
+		},
+		{
+			Name:         "with missing artifact",
+			Kind:         manifest.KindExtension,
+			ArtifactName: "lambdas/api",
+			BuildPath:    "lambdas/api",
+		},
+		{
+			Name:         "with missing binary",
+			Kind:         manifest.KindLayer,
+			ArtifactName: "shared",
+			BuildPath:    "tools/other",
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		ensure(m.BinarySize(entry.Kind, entry.ArtifactName, entry.BuildPath)).Equals(entry.Expected)
+	})
+}
@@ -0,0 +1,20 @@
+package manifest
+
+import "log"
+
+// Recorder prints the manifest update instead of writing it, for dry runs.
+type Recorder struct {
+	Logger *log.Logger
+}
+
+var _ StoreAPI = &Recorder{}
+
+// Update prints the manifest that would be updated, and the names of the artifacts.
+func (r *Recorder) Update(path string, artifacts []*Artifact) error {
+	r.Logger.Printf("   manifest: %s\n", path)
+	for _, artifact := range artifacts {
+		r.Logger.Printf("     %s %s -> %s\n", artifact.Kind, artifact.Name, artifact.ZipPath)
+	}
+
+	return nil
+}
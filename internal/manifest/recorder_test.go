@@ -0,0 +1,37 @@
+package manifest_test
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+)
+
+func TestRecorderUpdate(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("prints the manifest without writing it", func(ensure ensuring.E) {
+		output := &bytes.Buffer{}
+		recorder := &manifest.Recorder{Logger: log.New(output, "", 0)}
+
+		path := filepath.Join(ensure.T().TempDir(), manifest.FileName)
+		err := recorder.Update(path, []*manifest.Artifact{
+			{Kind: manifest.KindLambda, Name: "lambdas/api", ZipPath: "tmp/lambdas/api.zip"},
+			{Kind: manifest.KindLayer, Name: "shared", ZipPath: "tmp/layers/shared.zip"},
+		})
+		ensure(err).IsNotError()
+		ensure(output.String()).Equals(
+			"   manifest: " + path + "\n" +
+				"     lambda lambdas/api -> tmp/lambdas/api.zip\n" +
+				"     layer shared -> tmp/layers/shared.zip\n",
+		)
+
+		matches, err := filepath.Glob(path)
+		ensure(err).IsNotError()
+		ensure(matches).IsEmpty()
+	})
+}
@@ -62,3 +62,31 @@ func (mr *MockLambdaBuilderAPIMockRecorder) BuildBinaries(_config interface{}) *
 	inputs := []interface{}{_config}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildBinaries", reflect.TypeOf((*MockLambdaBuilderAPI)(nil).BuildBinaries), inputs...)
 }
+
+// BuildForHost mocks BuildForHost on LambdaBuilderAPI.
+func (m *MockLambdaBuilderAPI) BuildForHost(_config *lambgofile.Config, _lambda *lambgofile.Lambda) (string, error) {
+	m.ctrl.T.Helper()
+	inputs := []interface{}{_config, _lambda}
+	ret := m.ctrl.Call(m, "BuildForHost", inputs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// BuildForHost sets up expectations for calls to BuildForHost.
+// Calling this method multiple times allows expecting multiple calls to BuildForHost with a variety of parameters.
+//
+// Inputs:
+//
+//	config *lambgofile.Config
+//	lambda *lambgofile.Lambda
+//
+// Outputs:
+//
+//	string
+//	error
+func (mr *MockLambdaBuilderAPIMockRecorder) BuildForHost(_config interface{}, _lambda interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	inputs := []interface{}{_config, _lambda}
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuildForHost", reflect.TypeOf((*MockLambdaBuilderAPI)(nil).BuildForHost), inputs...)
+}
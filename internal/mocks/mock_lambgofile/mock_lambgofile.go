@@ -38,10 +38,67 @@ func (m *MockLoaderAPI) EXPECT() *MockLoaderAPIMockRecorder {
 	return m.recorder
 }
 
-// LoadConfig mocks LoadConfig on LoaderAPI.
-func (m *MockLoaderAPI) LoadConfig(_pwd string) (*lambgofile.Config, error) {
+// ExplainConfig mocks ExplainConfig on LoaderAPI.
+func (m *MockLoaderAPI) ExplainConfig(_pwd string, _profile string) (*lambgofile.Config, *lambgofile.Provenance, error) {
 	m.ctrl.T.Helper()
+	inputs := []interface{}{_pwd, _profile}
+	ret := m.ctrl.Call(m, "ExplainConfig", inputs...)
+	ret0, _ := ret[0].(*lambgofile.Config)
+	ret1, _ := ret[1].(*lambgofile.Provenance)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ExplainConfig sets up expectations for calls to ExplainConfig.
+// Calling this method multiple times allows expecting multiple calls to ExplainConfig with a variety of parameters.
+//
+// Inputs:
+//
+//	pwd string
+//	profile string
+//
+// Outputs:
+//
+//	*lambgofile.Config
+//	*lambgofile.Provenance
+//	error
+func (mr *MockLoaderAPIMockRecorder) ExplainConfig(_pwd interface{}, _profile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	inputs := []interface{}{_pwd, _profile}
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExplainConfig", reflect.TypeOf((*MockLoaderAPI)(nil).ExplainConfig), inputs...)
+}
+
+// FindRoot mocks FindRoot on LoaderAPI.
+func (m *MockLoaderAPI) FindRoot(_pwd string) (*lambgofile.Module, error) {
+	m.ctrl.T.Helper()
+	inputs := []interface{}{_pwd}
+	ret := m.ctrl.Call(m, "FindRoot", inputs...)
+	ret0, _ := ret[0].(*lambgofile.Module)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindRoot sets up expectations for calls to FindRoot.
+// Calling this method multiple times allows expecting multiple calls to FindRoot with a variety of parameters.
+//
+// Inputs:
+//
+//	pwd string
+//
+// Outputs:
+//
+//	*lambgofile.Module
+//	error
+func (mr *MockLoaderAPIMockRecorder) FindRoot(_pwd interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
 	inputs := []interface{}{_pwd}
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindRoot", reflect.TypeOf((*MockLoaderAPI)(nil).FindRoot), inputs...)
+}
+
+// LoadConfig mocks LoadConfig on LoaderAPI.
+func (m *MockLoaderAPI) LoadConfig(_pwd string, _profile string) (*lambgofile.Config, error) {
+	m.ctrl.T.Helper()
+	inputs := []interface{}{_pwd, _profile}
 	ret := m.ctrl.Call(m, "LoadConfig", inputs...)
 	ret0, _ := ret[0].(*lambgofile.Config)
 	ret1, _ := ret[1].(error)
@@ -54,13 +111,42 @@ func (m *MockLoaderAPI) LoadConfig(_pwd string) (*lambgofile.Config, error) {
 // Inputs:
 //
 //	pwd string
+//	profile string
 //
 // Outputs:
 //
 //	*lambgofile.Config
 //	error
-func (mr *MockLoaderAPIMockRecorder) LoadConfig(_pwd interface{}) *gomock.Call {
+func (mr *MockLoaderAPIMockRecorder) LoadConfig(_pwd interface{}, _profile interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	inputs := []interface{}{_pwd}
+	inputs := []interface{}{_pwd, _profile}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LoadConfig", reflect.TypeOf((*MockLoaderAPI)(nil).LoadConfig), inputs...)
 }
+
+// ValidateConfig mocks ValidateConfig on LoaderAPI.
+func (m *MockLoaderAPI) ValidateConfig(_pwd string, _profile string) (*lambgofile.Config, error) {
+	m.ctrl.T.Helper()
+	inputs := []interface{}{_pwd, _profile}
+	ret := m.ctrl.Call(m, "ValidateConfig", inputs...)
+	ret0, _ := ret[0].(*lambgofile.Config)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ValidateConfig sets up expectations for calls to ValidateConfig.
+// Calling this method multiple times allows expecting multiple calls to ValidateConfig with a variety of parameters.
+//
+// Inputs:
+//
+//	pwd string
+//	profile string
+//
+// Outputs:
+//
+//	*lambgofile.Config
+//	error
+func (mr *MockLoaderAPIMockRecorder) ValidateConfig(_pwd interface{}, _profile interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	inputs := []interface{}{_pwd, _profile}
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateConfig", reflect.TypeOf((*MockLoaderAPI)(nil).ValidateConfig), inputs...)
+}
@@ -5,6 +5,7 @@
 package mock_zipper
 
 import (
+	"github.com/JosiahWitt/lambgo/internal/zipper"
 	"github.com/golang/mock/gomock"
 	"reflect"
 )
@@ -62,3 +63,29 @@ func (mr *MockZipAPIMockRecorder) ZipFile(_path interface{}, _zippedFileName int
 	inputs := []interface{}{_path, _zippedFileName}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZipFile", reflect.TypeOf((*MockZipAPI)(nil).ZipFile), inputs...)
 }
+
+// ZipFiles mocks ZipFiles on ZipAPI.
+func (m *MockZipAPI) ZipFiles(_zipPath string, _files []*zipper.File) error {
+	m.ctrl.T.Helper()
+	inputs := []interface{}{_zipPath, _files}
+	ret := m.ctrl.Call(m, "ZipFiles", inputs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ZipFiles sets up expectations for calls to ZipFiles.
+// Calling this method multiple times allows expecting multiple calls to ZipFiles with a variety of parameters.
+//
+// Inputs:
+//
+//	zipPath string
+//	files []*zipper.File
+//
+// Outputs:
+//
+//	error
+func (mr *MockZipAPIMockRecorder) ZipFiles(_zipPath interface{}, _files interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	inputs := []interface{}{_zipPath, _files}
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ZipFiles", reflect.TypeOf((*MockZipAPI)(nil).ZipFiles), inputs...)
+}
@@ -0,0 +1,364 @@
+// Package ociimage writes Lambda binaries as OCI container images, without needing a container runtime.
+package ociimage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	mediaTypeIndex    = "application/vnd.oci.image.index.v1+json"
+	mediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeConfig   = "application/vnd.oci.image.config.v1+json"
+	mediaTypeLayer    = "application/vnd.oci.image.layer.v1.tar+gzip"
+
+	layoutFileName  = "oci-layout"
+	indexFileName   = "index.json"
+	blobsDirectory  = "blobs/sha256"
+	refNameTag      = "latest"
+	refNameKey      = "org.opencontainers.image.ref.name"
+	layoutVersion   = "1.0.0"
+	executableMode  = 0o755
+	regularFileMode = 0o644
+)
+
+// ImageParams configures how an image is written.
+type ImageParams struct {
+	// BinaryPath is the path to the built binary on disk.
+	BinaryPath string
+
+	// ImageBinaryPath is the absolute path of the binary within the image, which is used as the entrypoint.
+	ImageBinaryPath string
+
+	// OutPath is the directory of the OCI image layout, or the path of the tarball.
+	OutPath string
+
+	// Tarball writes the OCI image layout as a tarball instead of a directory.
+	Tarball bool
+
+	OS           string
+	Architecture string
+}
+
+type WriterAPI interface {
+	WriteImage(params *ImageParams) (string, error)
+}
+
+// Writer writes reproducible OCI images, so the image digests are stable across builds.
+type Writer struct{}
+
+var _ WriterAPI = &Writer{}
+
+// WriteImage containing only the binary, and return the image's manifest digest.
+func (w *Writer) WriteImage(params *ImageParams) (string, error) {
+	binary, err := os.ReadFile(params.BinaryPath)
+	if err != nil {
+		return "", err
+	}
+
+	layout, manifestDigest, err := buildLayout(params, binary)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.RemoveAll(params.OutPath); err != nil {
+		return "", err
+	}
+
+	if params.Tarball {
+		return manifestDigest, writeTarball(params.OutPath, layout)
+	}
+
+	return manifestDigest, writeDirectory(params.OutPath, layout)
+}
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int               `json:"size"`
+	Platform    *platform         `json:"platform,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+type imageConfig struct {
+	Created      string           `json:"created"`
+	Architecture string           `json:"architecture"`
+	OS           string           `json:"os"`
+	Variant      string           `json:"variant,omitempty"`
+	Config       containerConfig  `json:"config"`
+	RootFS       rootFS           `json:"rootfs"`
+	History      []historyElement `json:"history"`
+}
+
+type containerConfig struct {
+	Entrypoint []string `json:"Entrypoint"`
+	WorkingDir string   `json:"WorkingDir"`
+}
+
+type rootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"` //nolint:tagliatelle // Defined by the OCI spec
+}
+
+type historyElement struct {
+	Created   string `json:"created"`
+	CreatedBy string `json:"created_by"` //nolint:tagliatelle // Defined by the OCI spec
+}
+
+type manifest struct {
+	SchemaVersion int           `json:"schemaVersion"`
+	MediaType     string        `json:"mediaType"`
+	Config        *descriptor   `json:"config"`
+	Layers        []*descriptor `json:"layers"`
+}
+
+type index struct {
+	SchemaVersion int           `json:"schemaVersion"`
+	MediaType     string        `json:"mediaType"`
+	Manifests     []*descriptor `json:"manifests"`
+}
+
+// buildLayout returns the files in the OCI image layout, keyed by their path within the layout.
+func buildLayout(params *ImageParams, binary []byte) (map[string][]byte, string, error) {
+	layout := make(map[string][]byte)
+	addBlob := func(mediaType string, data []byte) *descriptor {
+		digest := sha256Digest(data)
+		layout[path.Join(blobsDirectory, strings.TrimPrefix(digest, "sha256:"))] = data
+		return &descriptor{MediaType: mediaType, Digest: digest, Size: len(data)}
+	}
+
+	layerTar, err := buildLayerTar(params.ImageBinaryPath, binary)
+	if err != nil {
+		return nil, "", err
+	}
+
+	layerGzip, err := compress(layerTar)
+	if err != nil {
+		return nil, "", err
+	}
+
+	layerDescriptor := addBlob(mediaTypeLayer, layerGzip)
+	imagePlatform := newPlatform(params)
+	created := reproducibleModTime().Format(time.RFC3339)
+
+	configData, err := json.Marshal(&imageConfig{
+		Created:      created,
+		Architecture: imagePlatform.Architecture,
+		OS:           imagePlatform.OS,
+		Variant:      imagePlatform.Variant,
+		Config: containerConfig{
+			Entrypoint: []string{params.ImageBinaryPath},
+			WorkingDir: "/",
+		},
+		RootFS: rootFS{
+			Type:    "layers",
+			DiffIDs: []string{sha256Digest(layerTar)},
+		},
+		History: []historyElement{
+			{Created: created, CreatedBy: "lambgo"},
+		},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	manifestData, err := json.Marshal(&manifest{
+		SchemaVersion: 2, //nolint:mnd
+		MediaType:     mediaTypeManifest,
+		Config:        addBlob(mediaTypeConfig, configData),
+		Layers:        []*descriptor{layerDescriptor},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	manifestDescriptor := addBlob(mediaTypeManifest, manifestData)
+	manifestDescriptor.Platform = imagePlatform
+	manifestDescriptor.Annotations = map[string]string{refNameKey: refNameTag}
+
+	indexData, err := json.Marshal(&index{
+		SchemaVersion: 2, //nolint:mnd
+		MediaType:     mediaTypeIndex,
+		Manifests:     []*descriptor{manifestDescriptor},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	layout[indexFileName] = indexData
+	layout[layoutFileName] = []byte(`{"imageLayoutVersion":"` + layoutVersion + `"}`)
+
+	return layout, manifestDescriptor.Digest, nil
+}
+
+// buildLayerTar containing the binary and its parent directories.
+func buildLayerTar(imageBinaryPath string, binary []byte) ([]byte, error) {
+	binaryName := strings.TrimPrefix(path.Clean(imageBinaryPath), "/")
+
+	buf := &bytes.Buffer{}
+	tarWriter := tar.NewWriter(buf)
+
+	for _, dir := range parentDirs(binaryName) {
+		if err := writeTarEntry(tarWriter, dir+"/", tar.TypeDir, executableMode, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeTarEntry(tarWriter, binaryName, tar.TypeReg, executableMode, binary); err != nil {
+		return nil, err
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeTarEntry(tarWriter *tar.Writer, name string, typeflag byte, mode int64, data []byte) error {
+	err := tarWriter.WriteHeader(&tar.Header{
+		Typeflag: typeflag,
+		Name:     name,
+		Mode:     mode,
+		Size:     int64(len(data)),
+		ModTime:  reproducibleModTime(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tarWriter.Write(data)
+	return err
+}
+
+func compress(data []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+
+	// The gzip header does not include a name or modification time, so the output is reproducible
+	gzipWriter, err := gzip.NewWriterLevel(buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := gzipWriter.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := gzipWriter.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func writeDirectory(outPath string, layout map[string][]byte) error {
+	for _, name := range sortedNames(layout) {
+		filePath := filepath.Join(outPath, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filePath), executableMode); err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filePath, layout[name], regularFileMode); err != nil { //nolint:gosec // Images are not sensitive
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeTarball(outPath string, layout map[string][]byte) (err error) {
+	if err := os.MkdirAll(filepath.Dir(outPath), executableMode); err != nil {
+		return err
+	}
+
+	tarFile, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		nestedErr := tarFile.Close()
+		if err == nil { // Only set err if it is not already set
+			err = nestedErr
+		}
+	}()
+
+	tarWriter := tar.NewWriter(tarFile)
+
+	writtenDirs := make(map[string]struct{})
+	for _, name := range sortedNames(layout) {
+		for _, dir := range parentDirs(name) {
+			if _, written := writtenDirs[dir]; written {
+				continue
+			}
+
+			if err := writeTarEntry(tarWriter, dir+"/", tar.TypeDir, executableMode, nil); err != nil {
+				return err
+			}
+			writtenDirs[dir] = struct{}{}
+		}
+
+		if err := writeTarEntry(tarWriter, name, tar.TypeReg, regularFileMode, layout[name]); err != nil {
+			return err
+		}
+	}
+
+	return tarWriter.Close()
+}
+
+// parentDirs of the name, starting with the outermost directory.
+func parentDirs(name string) []string {
+	var dirs []string
+	for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+		dirs = append(dirs, dir)
+	}
+
+	slices.Reverse(dirs)
+	return dirs
+}
+
+func sortedNames(layout map[string][]byte) []string {
+	names := make([]string, 0, len(layout))
+	for name := range layout {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+func newPlatform(params *ImageParams) *platform {
+	p := &platform{Architecture: params.Architecture, OS: params.OS}
+
+	// Lambda only supports the v8 variant of arm64
+	if params.Architecture == "arm64" {
+		p.Variant = "v8"
+	}
+
+	return p
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// reproducibleModTime matches the timestamp used by the zipper, so all artifacts are reproducible.
+func reproducibleModTime() time.Time {
+	return time.Date(2009, 11, 10, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,262 @@
+package ociimage_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/ociimage"
+)
+
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int               `json:"size"`
+	Annotations map[string]string `json:"annotations"`
+	Platform    map[string]string `json:"platform"`
+}
+
+type tarEntry struct {
+	Name string
+	Mode int64
+	Data string
+}
+
+func TestWriteImage(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const binaryContents = "#!/bin/fake-binary"
+
+	writeBinary := func(ensure ensuring.E) (string, string) {
+		dir := ensure.T().TempDir()
+		binaryPath := filepath.Join(dir, "binary")
+		ensure(os.WriteFile(binaryPath, []byte(binaryContents), 0o600)).IsNotError()
+
+		return dir, binaryPath
+	}
+
+	readBlob := func(ensure ensuring.E, layout map[string][]byte, desc *descriptor) []byte {
+		data, ok := layout[filepath.Join("blobs/sha256", strings.TrimPrefix(desc.Digest, "sha256:"))]
+		ensure(ok).IsTrue()
+		ensure(len(data)).Equals(desc.Size)
+		ensure(sha256Digest(data)).Equals(desc.Digest)
+		return data
+	}
+
+	// verifyLayout checks the image is internally consistent, and returns the image config
+	verifyLayout := func(ensure ensuring.E, layout map[string][]byte, digest string) (map[string]any, []tarEntry) {
+		ensure(string(layout["oci-layout"])).Equals(`{"imageLayoutVersion":"1.0.0"}`)
+
+		index := struct {
+			SchemaVersion int           `json:"schemaVersion"`
+			Manifests     []*descriptor `json:"manifests"`
+		}{}
+		ensure(json.Unmarshal(layout["index.json"], &index)).IsNotError()
+		ensure(index.SchemaVersion).Equals(2)
+		ensure(len(index.Manifests)).Equals(1)
+		ensure(index.Manifests[0].Digest).Equals(digest)
+		ensure(index.Manifests[0].Annotations).Equals(map[string]string{"org.opencontainers.image.ref.name": "latest"})
+
+		manifest := struct {
+			Config *descriptor   `json:"config"`
+			Layers []*descriptor `json:"layers"`
+		}{}
+		ensure(json.Unmarshal(readBlob(ensure, layout, index.Manifests[0]), &manifest)).IsNotError()
+		ensure(len(manifest.Layers)).Equals(1)
+		ensure(manifest.Layers[0].MediaType).Equals("application/vnd.oci.image.layer.v1.tar+gzip")
+
+		config := map[string]any{}
+		ensure(json.Unmarshal(readBlob(ensure, layout, manifest.Config), &config)).IsNotError()
+
+		gzipReader, err := gzip.NewReader(bytes.NewReader(readBlob(ensure, layout, manifest.Layers[0])))
+		ensure(err).IsNotError()
+		layerTar, err := io.ReadAll(gzipReader)
+		ensure(err).IsNotError()
+		ensure(config["rootfs"]).Equals(map[string]any{"type": "layers", "diff_ids": []any{sha256Digest(layerTar)}})
+
+		return config, readTar(ensure, layerTar)
+	}
+
+	ensure.Run("when writing an image layout", func(ensure ensuring.E) {
+		dir, binaryPath := writeBinary(ensure)
+		outPath := filepath.Join(dir, "lambda.oci")
+
+		writer := ociimage.Writer{}
+		digest, err := writer.WriteImage(&ociimage.ImageParams{
+			BinaryPath:      binaryPath,
+			ImageBinaryPath: "/var/runtime/bootstrap",
+			OutPath:         outPath,
+			OS:              "linux",
+			Architecture:    "amd64",
+		})
+		ensure(err).IsNotError()
+
+		config, layerEntries := verifyLayout(ensure, readDirectory(ensure, outPath), digest)
+		ensure(config["architecture"]).Equals("amd64")
+		ensure(config["os"]).Equals("linux")
+		ensure(config["variant"]).IsNil()
+		ensure(config["config"]).Equals(map[string]any{"Entrypoint": []any{"/var/runtime/bootstrap"}, "WorkingDir": "/"})
+
+		ensure(layerEntries).Equals([]tarEntry{
+			{Name: "var/", Mode: 0o755},
+			{Name: "var/runtime/", Mode: 0o755},
+			{Name: "var/runtime/bootstrap", Mode: 0o755, Data: binaryContents},
+		})
+	})
+
+	ensure.Run("when writing an image tarball for arm64", func(ensure ensuring.E) {
+		dir, binaryPath := writeBinary(ensure)
+		outPath := filepath.Join(dir, "nested", "lambda.oci.tar")
+
+		writer := ociimage.Writer{}
+		digest, err := writer.WriteImage(&ociimage.ImageParams{
+			BinaryPath:      binaryPath,
+			ImageBinaryPath: "/main",
+			OutPath:         outPath,
+			Tarball:         true,
+			OS:              "linux",
+			Architecture:    "arm64",
+		})
+		ensure(err).IsNotError()
+
+		tarData, err := os.ReadFile(outPath)
+		ensure(err).IsNotError()
+
+		layout := map[string][]byte{}
+		for _, entry := range readTar(ensure, tarData) {
+			if !strings.HasSuffix(entry.Name, "/") {
+				layout[entry.Name] = []byte(entry.Data)
+			}
+		}
+
+		config, layerEntries := verifyLayout(ensure, layout, digest)
+		ensure(config["architecture"]).Equals("arm64")
+		ensure(config["variant"]).Equals("v8")
+		ensure(config["config"]).Equals(map[string]any{"Entrypoint": []any{"/main"}, "WorkingDir": "/"})
+
+		ensure(layerEntries).Equals([]tarEntry{
+			{Name: "main", Mode: 0o755, Data: binaryContents},
+		})
+	})
+
+	ensure.Run("when writing the same binary multiple times", func(ensure ensuring.E) {
+		dir, binaryPath := writeBinary(ensure)
+
+		writer := ociimage.Writer{}
+		params := &ociimage.ImageParams{
+			BinaryPath:      binaryPath,
+			ImageBinaryPath: "/var/runtime/bootstrap",
+			OutPath:         filepath.Join(dir, "first.oci.tar"),
+			Tarball:         true,
+			OS:              "linux",
+			Architecture:    "amd64",
+		}
+
+		firstDigest, err := writer.WriteImage(params)
+		ensure(err).IsNotError()
+
+		params.OutPath = filepath.Join(dir, "second.oci.tar")
+		secondDigest, err := writer.WriteImage(params)
+		ensure(err).IsNotError()
+
+		ensure(firstDigest).Equals(secondDigest)
+
+		first, err := os.ReadFile(filepath.Join(dir, "first.oci.tar"))
+		ensure(err).IsNotError()
+		second, err := os.ReadFile(filepath.Join(dir, "second.oci.tar"))
+		ensure(err).IsNotError()
+		ensure(first).Equals(second)
+	})
+
+	ensure.Run("when overwriting an existing image layout", func(ensure ensuring.E) {
+		dir, binaryPath := writeBinary(ensure)
+		outPath := filepath.Join(dir, "lambda.oci")
+
+		stalePath := filepath.Join(outPath, "blobs", "sha256", "stale")
+		ensure(os.MkdirAll(filepath.Dir(stalePath), 0o755)).IsNotError()
+		ensure(os.WriteFile(stalePath, []byte("stale"), 0o600)).IsNotError()
+
+		writer := ociimage.Writer{}
+		_, err := writer.WriteImage(&ociimage.ImageParams{
+			BinaryPath:      binaryPath,
+			ImageBinaryPath: "/var/runtime/bootstrap",
+			OutPath:         outPath,
+			OS:              "linux",
+			Architecture:    "amd64",
+		})
+		ensure(err).IsNotError()
+
+		_, err = os.Stat(stalePath)
+		ensure(os.IsNotExist(err)).IsTrue()
+	})
+
+	ensure.Run("when the binary does not exist", func(ensure ensuring.E) {
+		dir := ensure.T().TempDir()
+
+		writer := ociimage.Writer{}
+		_, err := writer.WriteImage(&ociimage.ImageParams{
+			BinaryPath:      filepath.Join(dir, "missing"),
+			ImageBinaryPath: "/var/runtime/bootstrap",
+			OutPath:         filepath.Join(dir, "lambda.oci"),
+		})
+		ensure(err).IsNotNil()
+	})
+}
+
+func readDirectory(ensure ensuring.E, dir string) map[string][]byte {
+	layout := map[string][]byte{}
+
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		layout[relPath] = data
+		return err
+	})
+	ensure(err).IsNotError()
+
+	return layout
+}
+
+func readTar(ensure ensuring.E, data []byte) []tarEntry {
+	var entries []tarEntry
+
+	tarReader := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		ensure(err).IsNotError()
+		ensure(header.ModTime.Year()).Equals(2009)
+
+		contents, err := io.ReadAll(tarReader)
+		ensure(err).IsNotError()
+
+		entries = append(entries, tarEntry{Name: header.Name, Mode: header.Mode, Data: string(contents)})
+	}
+
+	return entries
+}
+
+func sha256Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
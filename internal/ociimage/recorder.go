@@ -0,0 +1,25 @@
+package ociimage
+
+import "log"
+
+// Recorder prints the images instead of writing them, for dry runs.
+type Recorder struct {
+	Logger *log.Logger
+}
+
+var _ WriterAPI = &Recorder{}
+
+// WriteImage prints the image that would be written.
+// The digest is empty, since it depends on the contents of the binary.
+func (r *Recorder) WriteImage(params *ImageParams) (string, error) {
+	format := "layout"
+	if params.Tarball {
+		format = "tarball"
+	}
+
+	r.Logger.Printf("   image: %s (%s, %s/%s) <- %s as %s\n",
+		params.OutPath, format, params.OS, params.Architecture, params.BinaryPath, params.ImageBinaryPath,
+	)
+
+	return "", nil
+}
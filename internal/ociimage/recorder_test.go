@@ -0,0 +1,32 @@
+package ociimage_test
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/ociimage"
+)
+
+func TestRecorderWriteImage(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("prints the image without writing it", func(ensure ensuring.E) {
+		output := &bytes.Buffer{}
+		recorder := &ociimage.Recorder{Logger: log.New(output, "", 0)}
+
+		digest, err := recorder.WriteImage(&ociimage.ImageParams{
+			BinaryPath:      "tmp/lambdas/api",
+			ImageBinaryPath: "/var/runtime/bootstrap",
+			OutPath:         "tmp/lambdas/api.oci.tar",
+			Tarball:         true,
+			OS:              "linux",
+			Architecture:    "arm64",
+		})
+		ensure(err).IsNotError()
+		ensure(digest).IsEmpty()
+		ensure(output.String()).Equals("   image: tmp/lambdas/api.oci.tar (tarball, linux/arm64) <- tmp/lambdas/api as /var/runtime/bootstrap\n")
+	})
+}
@@ -0,0 +1,183 @@
+package reproducible
+
+import (
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+)
+
+// compareFiles byte for byte, and describe how they differ, or return nil when they are identical.
+// Zips are described by their entries, and ELF binaries by their sections.
+func compareFiles(firstPath, secondPath string) ([]string, error) {
+	first, err := os.ReadFile(firstPath)
+	if err != nil {
+		return nil, err
+	}
+
+	second, err := os.ReadFile(secondPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(first, second) {
+		return nil, nil
+	}
+
+	var details []string
+	switch {
+	case isZip(first) && isZip(second):
+		details = compareZips(first, second)
+	case isELF(first) && isELF(second):
+		details = compareELFs(first, second)
+	}
+
+	if len(details) == 0 {
+		details = append(details, fmt.Sprintf("contents differ (%d and %d bytes)", len(first), len(second)))
+	}
+
+	return details, nil
+}
+
+func isZip(data []byte) bool {
+	return bytes.HasPrefix(data, []byte("PK\x03\x04"))
+}
+
+func isELF(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(elf.ELFMAG))
+}
+
+// compareZips by their entries, including their order, names, modes, modified times, and contents.
+func compareZips(first, second []byte) []string {
+	firstReader, err := zip.NewReader(bytes.NewReader(first), int64(len(first)))
+	if err != nil {
+		return nil
+	}
+
+	secondReader, err := zip.NewReader(bytes.NewReader(second), int64(len(second)))
+	if err != nil {
+		return nil
+	}
+
+	secondFiles := make(map[string]*zip.File, len(secondReader.File))
+	var secondNames []string
+	for _, file := range secondReader.File {
+		secondFiles[file.Name] = file
+		secondNames = append(secondNames, file.Name)
+	}
+
+	var details []string
+	var firstNames []string
+	for _, firstFile := range firstReader.File {
+		firstNames = append(firstNames, firstFile.Name)
+
+		secondFile, ok := secondFiles[firstFile.Name]
+		if !ok {
+			details = append(details, fmt.Sprintf("zip entry %s is only in the first build", firstFile.Name))
+			continue
+		}
+
+		details = append(details, compareZipEntries(firstFile, secondFile)...)
+	}
+
+	for _, secondName := range secondNames {
+		if !slices.Contains(firstNames, secondName) {
+			details = append(details, fmt.Sprintf("zip entry %s is only in the second build", secondName))
+		}
+	}
+
+	if len(details) == 0 && !slices.Equal(firstNames, secondNames) {
+		details = append(details, "zip entries are in a different order")
+	}
+
+	return details
+}
+
+func compareZipEntries(first, second *zip.File) []string {
+	var details []string
+
+	if first.Mode() != second.Mode() {
+		details = append(details, fmt.Sprintf("zip entry %s has different modes (%s and %s)", first.Name, first.Mode(), second.Mode()))
+	}
+
+	if !first.Modified.Equal(second.Modified) {
+		details = append(details, fmt.Sprintf("zip entry %s has different modified times (%s and %s)",
+			first.Name, first.Modified.Format(time.RFC3339), second.Modified.Format(time.RFC3339)))
+	}
+
+	firstData, firstErr := readZipEntry(first)
+	secondData, secondErr := readZipEntry(second)
+	if firstErr != nil || secondErr != nil || !bytes.Equal(firstData, secondData) {
+		details = append(details, fmt.Sprintf("zip entry %s has different contents", first.Name))
+	}
+
+	return details
+}
+
+func readZipEntry(file *zip.File) ([]byte, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return io.ReadAll(reader)
+}
+
+// compareELFs by the contents of their sections.
+func compareELFs(first, second []byte) []string {
+	firstFile, err := elf.NewFile(bytes.NewReader(first))
+	if err != nil {
+		return nil
+	}
+
+	secondFile, err := elf.NewFile(bytes.NewReader(second))
+	if err != nil {
+		return nil
+	}
+
+	var details []string
+	for _, firstSection := range firstFile.Sections {
+		if firstSection.Name == "" {
+			continue
+		}
+
+		secondSection := secondFile.Section(firstSection.Name)
+		if secondSection == nil {
+			details = append(details, fmt.Sprintf("ELF section %s is only in the first build", firstSection.Name))
+			continue
+		}
+
+		if !sameSection(firstSection, secondSection) {
+			details = append(details, fmt.Sprintf("ELF section %s differs", firstSection.Name))
+		}
+	}
+
+	for _, secondSection := range secondFile.Sections {
+		if secondSection.Name != "" && firstFile.Section(secondSection.Name) == nil {
+			details = append(details, fmt.Sprintf("ELF section %s is only in the second build", secondSection.Name))
+		}
+	}
+
+	return details
+}
+
+// sameSection compares the addresses and contents of the sections.
+// Sections without data in the file, like .bss, only have their size compared.
+func sameSection(first, second *elf.Section) bool {
+	if first.Addr != second.Addr || first.Size != second.Size {
+		return false
+	}
+
+	if first.Type == elf.SHT_NOBITS || second.Type == elf.SHT_NOBITS {
+		return first.Type == second.Type
+	}
+
+	firstData, firstErr := first.Data()
+	secondData, secondErr := second.Data()
+	return firstErr == nil && secondErr == nil && bytes.Equal(firstData, secondData)
+}
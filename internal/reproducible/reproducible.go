@@ -0,0 +1,218 @@
+// Package reproducible verifies that building the .lambgo.yml file twice produces identical artifacts.
+package reproducible
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/builder"
+	"github.com/JosiahWitt/lambgo/internal/buildtimes"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+)
+
+type ErkCannotVerify struct{ erk.DefaultKind }
+
+var (
+	ErrCannotCreateBuildDir = erk.New(ErkCannotVerify{}, "Cannot create the directory for the builds: {{.err}}")
+	ErrBuildFailed          = erk.New(ErkCannotVerify{}, "The {{.build}} build failed: {{.err}}")
+	ErrCannotCompareBuilds  = erk.New(ErkCannotVerify{}, "Cannot compare the builds: {{.err}}")
+)
+
+// Result of verifying the builds.
+type Result struct {
+	// NumFiles that were compared, which excludes the manifest.
+	NumFiles int
+
+	// Differences between the files of the builds, sorted by path. It is empty when the builds are reproducible.
+	Differences []*Difference
+
+	// Dir contains the first and second builds when they differ, so they can be inspected.
+	// It is empty when the builds are reproducible, since they are removed.
+	Dir string
+}
+
+// Difference between a file in the first and second builds.
+type Difference struct {
+	// Path of the file, relative to the outDirectory.
+	Path string
+
+	// Details of the difference, such as the zip entries or ELF sections that differ.
+	Details []string
+}
+
+type VerifierAPI interface {
+	Verify(config *lambgofile.Config) (*Result, error)
+}
+
+// Verifier builds the config twice, and compares the artifacts byte for byte.
+type Verifier struct {
+	Builder builder.LambdaBuilderAPI
+	Logger  *log.Logger
+
+	// Now is used for SOURCE_DATE_EPOCH when it is not set. Defaults to time.Now.
+	Now func() time.Time
+}
+
+var _ VerifierAPI = &Verifier{}
+
+// Verify the Lambdas, extensions, and layers in the config are reproducible.
+// Each build uses a separate outDirectory and an empty GOCACHE, so nothing is reused between them.
+// When SOURCE_DATE_EPOCH is not set, it is set to the current time for both builds.
+func (v *Verifier) Verify(config *lambgofile.Config) (*Result, error) {
+	dir, err := os.MkdirTemp("", "lambgo-reproducible-")
+	if err != nil {
+		return nil, erk.WrapAs(ErrCannotCreateBuildDir, err)
+	}
+
+	restore := v.pinSourceDateEpoch()
+	result, err := v.verify(config, dir)
+	restore()
+
+	if err != nil || len(result.Differences) == 0 {
+		_ = os.RemoveAll(dir)
+		return result, err
+	}
+
+	result.Dir = dir
+	return result, nil
+}
+
+func (v *Verifier) verify(config *lambgofile.Config, dir string) (*Result, error) {
+	builds := []string{"first", "second"}
+
+	for _, build := range builds {
+		v.Logger.Printf("Starting the %s build...\n", build)
+
+		buildConfig := *config
+		buildConfig.OutDirectory = filepath.Join(dir, build)
+		buildConfig.GoCache = filepath.Join(dir, build+"-gocache")
+
+		// ECDSA signatures are randomized, and the signed zips are already compared
+		buildConfig.Signing = nil
+
+		err := v.Builder.BuildBinaries(&buildConfig)
+
+		// The build cache is not needed to inspect the builds, and it is much larger than them
+		_ = os.RemoveAll(buildConfig.GoCache)
+
+		if err != nil {
+			return nil, erk.WrapWith(ErrBuildFailed, err, erk.Params{"build": build})
+		}
+
+		v.Logger.Println()
+	}
+
+	result, err := compareDirs(filepath.Join(dir, builds[0]), filepath.Join(dir, builds[1]))
+	if err != nil {
+		return nil, erk.WrapAs(ErrCannotCompareBuilds, err)
+	}
+
+	return result, nil
+}
+
+// pinSourceDateEpoch to the current time when it is not set, so both builds use the same time for the build time
+// injected with versionInjection and the creation time of SBOMs. It returns a function that restores SOURCE_DATE_EPOCH.
+func (v *Verifier) pinSourceDateEpoch() func() {
+	previous, wasSet := os.LookupEnv("SOURCE_DATE_EPOCH")
+	if previous != "" {
+		return func() {}
+	}
+
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+
+	_ = os.Setenv("SOURCE_DATE_EPOCH", strconv.FormatInt(now().Unix(), 10))
+
+	return func() {
+		if wasSet {
+			_ = os.Setenv("SOURCE_DATE_EPOCH", previous)
+		} else {
+			_ = os.Unsetenv("SOURCE_DATE_EPOCH")
+		}
+	}
+}
+
+// compareDirs byte for byte, and describe the differences between the files.
+// The manifest is skipped, since it contains the paths of the outDirectory, and so are the build times, which differ between builds.
+func compareDirs(firstDir, secondDir string) (*Result, error) {
+	firstFiles, err := listFiles(firstDir)
+	if err != nil {
+		return nil, err
+	}
+
+	secondFiles, err := listFiles(secondDir)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	for _, file := range firstFiles {
+		if !slices.Contains(secondFiles, file) {
+			result.Differences = append(result.Differences, &Difference{Path: file, Details: []string{"only in the first build"}})
+			continue
+		}
+
+		result.NumFiles++
+
+		details, err := compareFiles(filepath.Join(firstDir, file), filepath.Join(secondDir, file))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(details) > 0 {
+			result.Differences = append(result.Differences, &Difference{Path: file, Details: details})
+		}
+	}
+
+	for _, file := range secondFiles {
+		if !slices.Contains(firstFiles, file) {
+			result.Differences = append(result.Differences, &Difference{Path: file, Details: []string{"only in the second build"}})
+		}
+	}
+
+	slices.SortFunc(result.Differences, func(a, b *Difference) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return result, nil
+}
+
+// listFiles in the directory, relative to it, and sorted.
+func listFiles(dir string) ([]string, error) {
+	var files []string
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == dir {
+			return fs.SkipAll
+		}
+
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if relPath != manifest.FileName && relPath != buildtimes.FileName {
+			files = append(files, filepath.ToSlash(relPath))
+		}
+
+		return nil
+	})
+
+	slices.Sort(files)
+	return files, err
+}
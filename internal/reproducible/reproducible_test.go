@@ -0,0 +1,371 @@
+package reproducible_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"debug/elf"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_builder"
+	"github.com/JosiahWitt/lambgo/internal/reproducible"
+	"github.com/golang/mock/gomock"
+)
+
+func TestVerify(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Builder *mock_builder.MockLambdaBuilderAPI
+	}
+
+	exampleError := errors.New("something went wrong")
+
+	config := &lambgofile.Config{
+		RootPath:     "/some/root/path",
+		OutDirectory: "/some/root/path/dist",
+		Lambdas:      []*lambgofile.Lambda{{Path: "lambdas/api"}},
+	}
+
+	// files of a build, by their path relative to the outDirectory
+	type files map[string][]byte
+
+	// expectBuilds writes the files of each build to its outDirectory, after checking the GOCACHE is separate
+	expectBuilds := func(m *Mocks, builds ...files) {
+		var calls []*gomock.Call
+		var previousGoCache string
+
+		for i, build := range builds {
+			calls = append(calls, m.Builder.EXPECT().BuildBinaries(gomock.Any()).DoAndReturn(func(buildConfig *lambgofile.Config) error {
+				if buildConfig.GoCache == "" || buildConfig.GoCache == previousGoCache {
+					return errors.New("the GOCACHE is not separate")
+				}
+				previousGoCache = buildConfig.GoCache
+
+				if buildConfig.RootPath != config.RootPath || buildConfig.OutDirectory == config.OutDirectory {
+					return errors.New("the config is not copied")
+				}
+
+				if !strings.HasSuffix(buildConfig.OutDirectory, []string{"first", "second"}[i]) {
+					return errors.New("unexpected outDirectory: " + buildConfig.OutDirectory)
+				}
+
+				for path, contents := range build {
+					fullPath := filepath.Join(buildConfig.OutDirectory, path)
+					if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+						return err
+					}
+
+					if err := os.WriteFile(fullPath, contents, 0o600); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			}))
+		}
+
+		gomock.InOrder(calls...)
+	}
+
+	modified := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	binary := []byte("binary")
+	zipFile := makeZip(t, zipEntry{name: "bootstrap", contents: binary, modified: modified})
+	builtFiles := files{
+		"lambdas/api/bootstrap":     binary,
+		"lambdas/api/bootstrap.zip": zipFile,
+		"lambgo-manifest.json":      []byte(`{"outDirectory": "first"}`),
+		".lambgo-build-times.json":  []byte(`{"milliseconds": {"first/lambdas/api/bootstrap": 1500}}`),
+	}
+
+	elfBinary, elfSection := readExecutable(t)
+
+	table := []struct {
+		Name           string
+		RequiresELF    bool
+		ExpectedResult *reproducible.Result
+		ExpectedError  error
+		ExpectedOutput string
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *reproducible.Verifier
+	}{
+		{
+			Name: "with identical builds",
+			SetupMocks: func(m *Mocks) {
+				secondFiles := files{}
+				for path, contents := range builtFiles {
+					secondFiles[path] = contents
+				}
+				secondFiles["lambgo-manifest.json"] = []byte(`{"outDirectory": "second"}`)
+				secondFiles[".lambgo-build-times.json"] = []byte(`{"milliseconds": {"second/lambdas/api/bootstrap": 900}}`)
+
+				expectBuilds(m, builtFiles, secondFiles)
+			},
+			ExpectedResult: &reproducible.Result{NumFiles: 2},
+			ExpectedOutput: "Starting the first build...\n\nStarting the second build...\n\n",
+		},
+
+		{
+			Name: "with different zip entries",
+			SetupMocks: func(m *Mocks) {
+				expectBuilds(m,
+					files{
+						"bootstrap.zip": makeZip(t,
+							zipEntry{name: "bootstrap", contents: binary, modified: modified},
+							zipEntry{name: "config.json", contents: []byte("{}"), modified: modified},
+							zipEntry{name: "removed.txt", contents: []byte("removed"), modified: modified},
+						),
+						"ordered.zip": makeZip(t,
+							zipEntry{name: "a", contents: binary, modified: modified},
+							zipEntry{name: "b", contents: binary, modified: modified},
+						),
+					},
+					files{
+						"bootstrap.zip": makeZip(t,
+							zipEntry{name: "bootstrap", contents: []byte("other binary"), modified: modified},
+							zipEntry{name: "config.json", contents: []byte("{}"), modified: modified.Add(time.Hour), executable: true},
+							zipEntry{name: "added.txt", contents: []byte("added"), modified: modified},
+						),
+						"ordered.zip": makeZip(t,
+							zipEntry{name: "b", contents: binary, modified: modified},
+							zipEntry{name: "a", contents: binary, modified: modified},
+						),
+					},
+				)
+			},
+			ExpectedResult: &reproducible.Result{
+				NumFiles: 2,
+				Differences: []*reproducible.Difference{
+					{
+						Path: "bootstrap.zip",
+						Details: []string{
+							"zip entry bootstrap has different contents",
+							"zip entry config.json has different modes (-rw-r--r-- and -rwxr-xr-x)",
+							"zip entry config.json has different modified times (2024-05-06T07:08:09Z and 2024-05-06T08:08:09Z)",
+							"zip entry removed.txt is only in the first build",
+							"zip entry added.txt is only in the second build",
+						},
+					},
+					{Path: "ordered.zip", Details: []string{"zip entries are in a different order"}},
+				},
+			},
+			ExpectedOutput: "Starting the first build...\n\nStarting the second build...\n\n",
+		},
+
+		{
+			Name:        "with different ELF sections",
+			RequiresELF: true,
+			SetupMocks: func(m *Mocks) {
+				if elfBinary == nil {
+					return
+				}
+
+				changedBinary := bytes.Clone(elfBinary)
+				changedBinary[elfSection.Offset] ^= 0xff
+
+				expectBuilds(m, files{"bootstrap": elfBinary}, files{"bootstrap": changedBinary})
+			},
+			ExpectedResult: &reproducible.Result{
+				NumFiles:    1,
+				Differences: []*reproducible.Difference{{Path: "bootstrap", Details: []string{"ELF section .rodata differs"}}},
+			},
+			ExpectedOutput: "Starting the first build...\n\nStarting the second build...\n\n",
+		},
+
+		{
+			Name: "with different files",
+			SetupMocks: func(m *Mocks) {
+				expectBuilds(m,
+					files{"first.txt": []byte("first"), "shared.txt": []byte("12345")},
+					files{"second.txt": []byte("second"), "shared.txt": []byte("123")},
+				)
+			},
+			ExpectedResult: &reproducible.Result{
+				NumFiles: 1,
+				Differences: []*reproducible.Difference{
+					{Path: "first.txt", Details: []string{"only in the first build"}},
+					{Path: "second.txt", Details: []string{"only in the second build"}},
+					{Path: "shared.txt", Details: []string{"contents differ (5 and 3 bytes)"}},
+				},
+			},
+			ExpectedOutput: "Starting the first build...\n\nStarting the second build...\n\n",
+		},
+
+		{
+			Name:          "when the second build fails",
+			ExpectedError: reproducible.ErrBuildFailed,
+			SetupMocks: func(m *Mocks) {
+				gomock.InOrder(
+					m.Builder.EXPECT().BuildBinaries(gomock.Any()).Return(nil),
+					m.Builder.EXPECT().BuildBinaries(gomock.Any()).Return(exampleError),
+				)
+			},
+			ExpectedOutput: "Starting the first build...\n\nStarting the second build...\n",
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		if entry.RequiresELF && elfBinary == nil {
+			ensure.T().Skip("the test binary is not an ELF binary with a .rodata section")
+		}
+
+		tmpDir := ensure.T().TempDir()
+		ensure.T().Setenv("TMPDIR", tmpDir)
+		ensure.T().Setenv("SOURCE_DATE_EPOCH", "1715000000")
+
+		output := &bytes.Buffer{}
+		entry.Subject.Logger = log.New(output, "", 0)
+
+		result, err := entry.Subject.Verify(config)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(output.String()).Equals(entry.ExpectedOutput)
+
+		remaining, readErr := os.ReadDir(tmpDir)
+		ensure(readErr).IsNotError()
+
+		// The builds are only kept when they differ
+		if result == nil || len(result.Differences) == 0 {
+			ensure(remaining).IsEmpty()
+			ensure(result).Equals(entry.ExpectedResult)
+			return
+		}
+
+		ensure(len(remaining)).Equals(1)
+		ensure(result.Dir).Equals(filepath.Join(tmpDir, remaining[0].Name()))
+
+		// Only the builds are kept, not their build caches
+		builds, readErr := os.ReadDir(result.Dir)
+		ensure(readErr).IsNotError()
+		ensure(len(builds)).Equals(2)
+
+		result.Dir = ""
+		ensure(result).Equals(entry.ExpectedResult)
+	})
+
+	ensure.Run("when SOURCE_DATE_EPOCH is not set", func(ensure ensuring.E) {
+		ensure.T().Setenv("TMPDIR", ensure.T().TempDir())
+		ensure.T().Setenv("SOURCE_DATE_EPOCH", "")
+
+		// Both builds use the same time, so a build time injected with versionInjection does not differ
+		builder := mock_builder.NewMockLambdaBuilderAPI(ensure.GoMockController())
+		builder.EXPECT().BuildBinaries(gomock.Any()).Times(2).DoAndReturn(func(*lambgofile.Config) error {
+			if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "1715000000" {
+				return errors.New("unexpected SOURCE_DATE_EPOCH: " + epoch)
+			}
+
+			return nil
+		})
+
+		verifier := &reproducible.Verifier{
+			Builder: builder,
+			Logger:  log.New(&bytes.Buffer{}, "", 0),
+			Now:     func() time.Time { return time.Unix(1715000000, 0) },
+		}
+
+		result, err := verifier.Verify(config)
+		ensure(err).IsNotError()
+		ensure(result).Equals(&reproducible.Result{})
+		ensure(os.Getenv("SOURCE_DATE_EPOCH")).Equals("")
+	})
+
+	ensure.Run("when SOURCE_DATE_EPOCH is set", func(ensure ensuring.E) {
+		ensure.T().Setenv("TMPDIR", ensure.T().TempDir())
+		ensure.T().Setenv("SOURCE_DATE_EPOCH", "1600000000")
+
+		builder := mock_builder.NewMockLambdaBuilderAPI(ensure.GoMockController())
+		builder.EXPECT().BuildBinaries(gomock.Any()).Times(2).DoAndReturn(func(*lambgofile.Config) error {
+			if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "1600000000" {
+				return errors.New("unexpected SOURCE_DATE_EPOCH: " + epoch)
+			}
+
+			return nil
+		})
+
+		verifier := &reproducible.Verifier{
+			Builder: builder,
+			Logger:  log.New(&bytes.Buffer{}, "", 0),
+			Now:     func() time.Time { return time.Unix(1715000000, 0) },
+		}
+
+		result, err := verifier.Verify(config)
+		ensure(err).IsNotError()
+		ensure(result).Equals(&reproducible.Result{})
+		ensure(os.Getenv("SOURCE_DATE_EPOCH")).Equals("1600000000")
+	})
+}
+
+type zipEntry struct {
+	name       string
+	contents   []byte
+	modified   time.Time
+	executable bool
+}
+
+func makeZip(t *testing.T, entries ...zipEntry) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	writer := zip.NewWriter(buf)
+
+	for _, entry := range entries {
+		header := &zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: entry.modified}
+		header.SetMode(0o644)
+		if entry.executable {
+			header.SetMode(0o755)
+		}
+
+		file, err := writer.CreateHeader(header)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := file.Write(entry.contents); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+// readExecutable of the test, and its .rodata section, so an ELF binary can be compared.
+// It returns nil when the test binary is not an ELF binary, such as on macOS.
+func readExecutable(t *testing.T) ([]byte, *elf.Section) {
+	t.Helper()
+
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	binary, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := elf.NewFile(bytes.NewReader(binary))
+	if err != nil {
+		return nil, nil
+	}
+
+	section := file.Section(".rodata")
+	if section == nil || section.Type == elf.SHT_NOBITS || section.Size == 0 {
+		return nil, nil
+	}
+
+	return binary, section
+}
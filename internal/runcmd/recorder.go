@@ -0,0 +1,55 @@
+package runcmd
+
+import (
+	"log"
+	"slices"
+	"strings"
+
+	"mvdan.cc/sh/v3/syntax"
+)
+
+// Recorder prints the commands instead of running them, for dry runs.
+type Recorder struct {
+	Logger *log.Logger
+}
+
+var _ RunnerAPI = &Recorder{}
+
+// Exec prints the command as a shell command line, including its working directory and environment overrides.
+func (r *Recorder) Exec(params *ExecParams) (string, error) {
+	r.Logger.Printf("   $ %s\n", commandLine(params))
+	return "", nil
+}
+
+// commandLine formats the params as a shell command line, which can be copied into a POSIX shell.
+// Environment variables are sorted, so the output is stable.
+func commandLine(params *ExecParams) string {
+	words := []string{"cd", quote(params.PWD), "&&"}
+
+	envKeys := make([]string, 0, len(params.EnvVars))
+	for key := range params.EnvVars {
+		envKeys = append(envKeys, key)
+	}
+
+	slices.Sort(envKeys)
+	for _, key := range envKeys {
+		words = append(words, key+"="+quote(params.EnvVars[key]))
+	}
+
+	words = append(words, quote(params.CMD))
+	for _, arg := range params.Args {
+		words = append(words, quote(arg))
+	}
+
+	return strings.Join(words, " ")
+}
+
+func quote(s string) string {
+	quoted, err := syntax.Quote(s, syntax.LangPOSIX)
+	if err != nil {
+		// Only strings containing null bytes cannot be quoted, which are not valid arguments anyway
+		return s
+	}
+
+	return quoted
+}
@@ -0,0 +1,34 @@
+package runcmd_test
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/runcmd"
+)
+
+func TestRecorderExec(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("prints the command without running it", func(ensure ensuring.E) {
+		output := &bytes.Buffer{}
+		recorder := runcmd.Recorder{Logger: log.New(output, "", 0)}
+
+		result, err := recorder.Exec(&runcmd.ExecParams{
+			PWD:  "/my root",
+			CMD:  "go",
+			Args: []string{"build", "-trimpath", "-o", "tmp/lambdas/api", "-ldflags=-X 'main.Version=v1.2.3'", "./lambdas/api"},
+
+			EnvVars: map[string]string{"GOOS": "linux", "GOARCH": "arm64"},
+		})
+
+		ensure(err).IsNotError()
+		ensure(result).IsEmpty()
+		ensure(output.String()).Equals(
+			`   $ cd '/my root' && GOARCH=arm64 GOOS=linux go build -trimpath -o tmp/lambdas/api "-ldflags=-X 'main.Version=v1.2.3'" ./lambdas/api` + "\n",
+		)
+	})
+}
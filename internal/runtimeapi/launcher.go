@@ -0,0 +1,171 @@
+package runtimeapi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/JosiahWitt/erk"
+)
+
+// closeTimeout is how long the Lambda has to exit after it is interrupted, before it is killed.
+const closeTimeout = 2 * time.Second
+
+type ErkFunctionError struct{ erk.DefaultKind }
+
+var (
+	ErrCannotStartFunction = erk.New(ErkFunctionError{}, "Cannot start the Lambda '{{.binaryPath}}': {{.err}}")
+	ErrFunctionExited      = erk.New(ErkFunctionError{}, "The Lambda exited before it responded: {{.err}}")
+)
+
+// LaunchParams configures the Lambda process.
+type LaunchParams struct {
+	// BinaryPath of the Lambda, which must be built for the host.
+	BinaryPath string
+
+	// FunctionName reported to the Lambda.
+	FunctionName string
+
+	// EnvVars added to the environment of the Lambda, in addition to the environment of lambgo.
+	EnvVars map[string]string
+}
+
+type LauncherAPI interface {
+	Launch(params *LaunchParams) (FunctionAPI, error)
+}
+
+type FunctionAPI interface {
+	Invoke(ctx context.Context, payload []byte) (*Response, error)
+	Close() error
+}
+
+// Launcher starts Lambdas connected to a local Runtime API server.
+type Launcher struct {
+	// Logs receives the stdout and stderr of each Lambda.
+	Logs io.Writer
+}
+
+var _ LauncherAPI = &Launcher{}
+
+// Function is a running Lambda, which can be invoked multiple times.
+type Function struct {
+	server *Server
+	cmd    *exec.Cmd
+
+	exited  chan struct{}
+	exitErr error
+
+	closeOnce sync.Once
+}
+
+var _ FunctionAPI = &Function{}
+
+// Launch the Lambda binary, with AWS_LAMBDA_RUNTIME_API pointing at a new local server.
+func (l *Launcher) Launch(params *LaunchParams) (FunctionAPI, error) {
+	server, err := NewServer(params.FunctionName)
+	if err != nil {
+		return nil, err
+	}
+
+	envVars := map[string]string{
+		"AWS_LAMBDA_RUNTIME_API":          server.Address(),
+		"AWS_LAMBDA_FUNCTION_NAME":        params.FunctionName,
+		"AWS_LAMBDA_FUNCTION_VERSION":     "$LATEST",
+		"AWS_LAMBDA_FUNCTION_MEMORY_SIZE": "128",
+		"AWS_LAMBDA_LOG_GROUP_NAME":       "/aws/lambda/" + params.FunctionName,
+		"AWS_LAMBDA_LOG_STREAM_NAME":      "local",
+	}
+	for key, value := range params.EnvVars {
+		envVars[key] = value
+	}
+
+	cmd := exec.Command(params.BinaryPath) //nolint:gosec // The binary was built by lambgo
+	cmd.Env = buildEnv(envVars)
+	cmd.Stdout = l.Logs
+	cmd.Stderr = l.Logs
+
+	if err := cmd.Start(); err != nil {
+		server.Close() //nolint:errcheck,gosec // Already returning an error
+		return nil, erk.WrapWith(ErrCannotStartFunction, err, erk.Params{"binaryPath": params.BinaryPath})
+	}
+
+	f := &Function{server: server, cmd: cmd, exited: make(chan struct{})}
+	go func() {
+		f.exitErr = cmd.Wait()
+		close(f.exited)
+	}()
+
+	return f, nil
+}
+
+// Invoke the Lambda with the payload, and wait for its response.
+// An error is returned if the Lambda exits or the context is done before the Lambda responds.
+func (f *Function) Invoke(ctx context.Context, payload []byte) (*Response, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	go func() {
+		select {
+		case <-f.exited:
+			cancel(erk.WrapAs(ErrFunctionExited, exitReason(f.exitErr)))
+		case <-ctx.Done():
+		}
+	}()
+
+	response, err := f.server.Invoke(ctx, payload)
+	if err != nil {
+		// Report why the Lambda exited, instead of the aborted invocation
+		if cause := context.Cause(ctx); errors.Is(cause, ErrFunctionExited) && !errors.Is(err, ErrInitFailed) {
+			return nil, cause
+		}
+
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Close the Lambda by interrupting it, and killing it if it does not exit in time.
+func (f *Function) Close() error {
+	var err error
+	f.closeOnce.Do(func() {
+		select {
+		case <-f.exited:
+		default:
+			f.cmd.Process.Signal(os.Interrupt) //nolint:errcheck,gosec // The process is killed if it does not exit
+
+			select {
+			case <-f.exited:
+			case <-time.After(closeTimeout):
+				f.cmd.Process.Kill() //nolint:errcheck,gosec // Only fails if the process already exited
+				<-f.exited
+			}
+		}
+
+		err = f.server.Close()
+	})
+
+	return err
+}
+
+func exitReason(exitErr error) error {
+	if exitErr == nil {
+		return errors.New("exit status 0") //nolint:err113 // Only used as the reason for ErrFunctionExited
+	}
+
+	return exitErr
+}
+
+func buildEnv(envVars map[string]string) []string {
+	env := os.Environ()
+
+	for key, value := range envVars {
+		env = append(env, key+"="+value)
+	}
+
+	return env
+}
@@ -0,0 +1,188 @@
+package runtimeapi_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/runtimeapi"
+)
+
+// fakeLambdaEnvVar makes the test binary act as a Lambda, which implements the behavior named by its value.
+const fakeLambdaEnvVar = "LAMBGO_TEST_FAKE_LAMBDA"
+
+func TestMain(m *testing.M) {
+	if behavior := os.Getenv(fakeLambdaEnvVar); behavior != "" {
+		runFakeLambda(behavior)
+		return
+	}
+
+	os.Exit(m.Run())
+}
+
+// runFakeLambda implements a minimal Lambda runtime client, like the one in github.com/aws/aws-lambda-go.
+func runFakeLambda(behavior string) {
+	baseURL := "http://" + os.Getenv("AWS_LAMBDA_RUNTIME_API") + "/2018-06-01/runtime"
+	post := func(path, body string) {
+		resp, err := http.Post(baseURL+path, "application/json", strings.NewReader(body)) //nolint:noctx
+		if err != nil {
+			os.Exit(2)
+		}
+		resp.Body.Close()
+	}
+
+	switch behavior {
+	case "init-error":
+		post("/init/error", `{"errorMessage":"missing config","errorType":"ConfigError"}`)
+		os.Exit(1)
+	case "exit":
+		fmt.Fprintln(os.Stderr, "crashing")
+		os.Exit(3)
+	}
+
+	for {
+		resp, err := http.Get(baseURL + "/invocation/next") //nolint:noctx
+		if err != nil {
+			os.Exit(2)
+		}
+
+		payload, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		requestID := resp.Header.Get("Lambda-Runtime-Aws-Request-Id")
+
+		switch behavior {
+		case "echo":
+			fmt.Println("invoked " + os.Getenv("AWS_LAMBDA_FUNCTION_NAME") + " with " + os.Getenv("EXTRA_VAR"))
+			post("/invocation/"+requestID+"/response", fmt.Sprintf(`{"payload":%s,"arn":%q,"hasDeadline":%t}`,
+				payload, resp.Header.Get("Lambda-Runtime-Invoked-Function-Arn"), resp.Header.Get("Lambda-Runtime-Deadline-Ms") != ""))
+		case "error":
+			post("/invocation/"+requestID+"/error", `{"errorMessage":"something failed","errorType":"errorString"}`)
+		case "crash-on-invoke":
+			os.Exit(4)
+		case "hang":
+			time.Sleep(time.Hour)
+		}
+	}
+}
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestLaunch(t *testing.T) {
+	ensure := ensure.New(t)
+
+	testBinary, err := os.Executable()
+	ensure(err).IsNotError()
+
+	launch := func(ensure ensuring.E, behavior string, logs io.Writer) runtimeapi.FunctionAPI {
+		launcher := runtimeapi.Launcher{Logs: logs}
+		function, err := launcher.Launch(&runtimeapi.LaunchParams{
+			BinaryPath:   testBinary,
+			FunctionName: "my-function",
+			EnvVars:      map[string]string{fakeLambdaEnvVar: behavior, "EXTRA_VAR": "extra"},
+		})
+		ensure(err).IsNotError()
+		ensure.T().Cleanup(func() { function.Close() })
+
+		return function
+	}
+
+	ensure.Run("with successful invocations", func(ensure ensuring.E) {
+		logs := &syncBuffer{}
+		function := launch(ensure, "echo", logs)
+
+		for _, payload := range []string{`{"first":true}`, `{"second":true}`} {
+			response, err := function.Invoke(context.Background(), []byte(payload))
+			ensure(err).IsNotError()
+			ensure(response).Equals(&runtimeapi.Response{
+				Payload: []byte(`{"payload":` + payload + `,"arn":"arn:aws:lambda:us-east-1:000000000000:function:my-function","hasDeadline":true}`),
+			})
+		}
+
+		ensure(function.Close()).IsNotError()
+		ensure(logs.String()).Equals("invoked my-function with extra\ninvoked my-function with extra\n")
+	})
+
+	ensure.Run("with invocation error", func(ensure ensuring.E) {
+		function := launch(ensure, "error", io.Discard)
+
+		response, err := function.Invoke(context.Background(), []byte(`{}`))
+		ensure(err).IsNotError()
+		ensure(response).Equals(&runtimeapi.Response{
+			Error: &runtimeapi.InvocationError{ErrorMessage: "something failed", ErrorType: "errorString"},
+		})
+	})
+
+	ensure.Run("with init error", func(ensure ensuring.E) {
+		function := launch(ensure, "init-error", io.Discard)
+
+		response, err := function.Invoke(context.Background(), []byte(`{}`))
+		ensure(err).IsError(runtimeapi.ErrInitFailed)
+		ensure(err.Error()).Equals("The Lambda failed to initialize: ConfigError: missing config")
+		ensure(response).IsNil()
+	})
+
+	ensure.Run("when the Lambda exits before requesting an invocation", func(ensure ensuring.E) {
+		logs := &syncBuffer{}
+		function := launch(ensure, "exit", logs)
+
+		response, err := function.Invoke(context.Background(), []byte(`{}`))
+		ensure(err).IsError(runtimeapi.ErrFunctionExited)
+		ensure(err.Error()).Equals("The Lambda exited before it responded: exit status 3")
+		ensure(response).IsNil()
+		ensure(logs.String()).Equals("crashing\n")
+	})
+
+	ensure.Run("when the Lambda exits during an invocation", func(ensure ensuring.E) {
+		function := launch(ensure, "crash-on-invoke", io.Discard)
+
+		response, err := function.Invoke(context.Background(), []byte(`{}`))
+		ensure(err).IsError(runtimeapi.ErrFunctionExited)
+		ensure(response).IsNil()
+	})
+
+	ensure.Run("when the invocation times out", func(ensure ensuring.E) {
+		function := launch(ensure, "hang", io.Discard)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		response, err := function.Invoke(ctx, []byte(`{}`))
+		ensure(err).IsError(runtimeapi.ErrInvocationAborted)
+		ensure(response).IsNil()
+		ensure(function.Close()).IsNotError()
+	})
+
+	ensure.Run("when the binary does not exist", func(ensure ensuring.E) {
+		launcher := runtimeapi.Launcher{Logs: io.Discard}
+		function, err := launcher.Launch(&runtimeapi.LaunchParams{
+			BinaryPath:   "/does/not/exist",
+			FunctionName: "my-function",
+		})
+		ensure(err).IsError(runtimeapi.ErrCannotStartFunction)
+		ensure(function).IsNil()
+	})
+}
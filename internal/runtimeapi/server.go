@@ -0,0 +1,305 @@
+// Package runtimeapi emulates the Lambda Runtime API, so built binaries can be invoked locally.
+//
+// See: https://docs.aws.amazon.com/lambda/latest/dg/runtimes-api.html
+package runtimeapi
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/JosiahWitt/erk"
+)
+
+const (
+	apiVersion = "/2018-06-01"
+
+	headerRequestID          = "Lambda-Runtime-Aws-Request-Id"
+	headerDeadlineMs         = "Lambda-Runtime-Deadline-Ms"
+	headerInvokedFunctionArn = "Lambda-Runtime-Invoked-Function-Arn"
+	headerErrorType          = "Lambda-Runtime-Function-Error-Type"
+
+	// defaultDeadline matches the maximum timeout of a Lambda, and is used when the invocation has no deadline.
+	defaultDeadline = 15 * time.Minute
+
+	// maxPayloadSize matches the maximum synchronous response size of a Lambda.
+	maxPayloadSize = 6 * 1024 * 1024
+)
+
+type ErkServerError struct{ erk.DefaultKind }
+
+var (
+	ErrCannotListen      = erk.New(ErkServerError{}, "Cannot start the Lambda Runtime API server: {{.err}}")
+	ErrInitFailed        = erk.New(ErkServerError{}, "The Lambda failed to initialize: {{.errorType}}: {{.errorMessage}}")
+	ErrInvocationAborted = erk.New(ErkServerError{}, "The invocation was aborted before the Lambda responded: {{.err}}")
+)
+
+// Response from an invocation.
+type Response struct {
+	// Payload returned by the Lambda. It is empty when Error is set.
+	Payload []byte
+
+	// Error returned by the Lambda, if the invocation failed.
+	Error *InvocationError
+}
+
+// InvocationError reported by the Lambda to the /error endpoint.
+type InvocationError struct {
+	ErrorMessage string          `json:"errorMessage"`
+	ErrorType    string          `json:"errorType"`
+	StackTrace   json.RawMessage `json:"stackTrace,omitempty"`
+}
+
+// Server implements the Lambda Runtime API endpoints used by Lambda runtimes.
+// Invocations are queued with Invoke, and are sent to the Lambda when it requests the next invocation.
+type Server struct {
+	functionName string
+	listener     net.Listener
+	httpServer   *http.Server
+
+	pending chan *invocation
+
+	mu        sync.Mutex
+	active    map[string]*invocation
+	initErr   *InvocationError
+	initAbort chan struct{}
+}
+
+type invocation struct {
+	requestID string
+	payload   []byte
+	deadline  time.Time
+	result    chan *Response
+}
+
+// NewServer listening on a random local port.
+func NewServer(functionName string) (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, erk.WrapAs(ErrCannotListen, err)
+	}
+
+	s := &Server{
+		functionName: functionName,
+		listener:     listener,
+		pending:      make(chan *invocation),
+		active:       make(map[string]*invocation),
+		initAbort:    make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+apiVersion+"/runtime/invocation/next", s.handleNext)
+	mux.HandleFunc("POST "+apiVersion+"/runtime/invocation/{requestID}/response", s.handleResponse)
+	mux.HandleFunc("POST "+apiVersion+"/runtime/invocation/{requestID}/error", s.handleError)
+	mux.HandleFunc("POST "+apiVersion+"/runtime/init/error", s.handleInitError)
+
+	s.httpServer = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second} //nolint:mnd
+
+	// Serve always returns an error once the server is closed, so it can be ignored
+	go s.httpServer.Serve(listener) //nolint:errcheck
+
+	return s, nil
+}
+
+// Address of the server, which is the value of AWS_LAMBDA_RUNTIME_API.
+func (s *Server) Address() string {
+	return s.listener.Addr().String()
+}
+
+// Invoke the Lambda with the payload, and wait for its response.
+// The deadline of the context is sent to the Lambda.
+func (s *Server) Invoke(ctx context.Context, payload []byte) (*Response, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultDeadline)
+	}
+
+	inv := &invocation{
+		requestID: NewRequestID(),
+		payload:   payload,
+		deadline:  deadline,
+		result:    make(chan *Response, 1),
+	}
+
+	select {
+	case s.pending <- inv:
+	case <-s.initAbort:
+		return nil, s.initError()
+	case <-ctx.Done():
+		return nil, s.abortedError(ctx)
+	}
+
+	select {
+	case response := <-inv.result:
+		return response, nil
+	case <-s.initAbort:
+		return nil, s.initError()
+	case <-ctx.Done():
+		s.mu.Lock()
+		delete(s.active, inv.requestID)
+		s.mu.Unlock()
+
+		return nil, s.abortedError(ctx)
+	}
+}
+
+// Close the server, which aborts any requests that are waiting for the next invocation.
+func (s *Server) Close() error {
+	return s.httpServer.Close()
+}
+
+func (s *Server) handleNext(w http.ResponseWriter, r *http.Request) {
+	var inv *invocation
+	select {
+	case inv = <-s.pending:
+	case <-r.Context().Done():
+		return
+	}
+
+	s.mu.Lock()
+	s.active[inv.requestID] = inv
+	s.mu.Unlock()
+
+	w.Header().Set(headerRequestID, inv.requestID)
+	w.Header().Set(headerDeadlineMs, strconv.FormatInt(inv.deadline.UnixMilli(), 10))
+	w.Header().Set(headerInvokedFunctionArn, "arn:aws:lambda:us-east-1:000000000000:function:"+s.functionName)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(inv.payload) //nolint:errcheck,gosec // The Lambda will request the invocation again if it is not received
+}
+
+func (s *Server) handleResponse(w http.ResponseWriter, r *http.Request) {
+	payload, ok := readPayload(w, r)
+	if !ok {
+		return
+	}
+
+	s.complete(w, r.PathValue("requestID"), &Response{Payload: payload})
+}
+
+func (s *Server) handleError(w http.ResponseWriter, r *http.Request) {
+	invocationErr, ok := readInvocationError(w, r)
+	if !ok {
+		return
+	}
+
+	s.complete(w, r.PathValue("requestID"), &Response{Error: invocationErr})
+}
+
+func (s *Server) handleInitError(w http.ResponseWriter, r *http.Request) {
+	invocationErr, ok := readInvocationError(w, r)
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.initErr == nil {
+		s.initErr = invocationErr
+		close(s.initAbort)
+	}
+
+	writeStatus(w, http.StatusAccepted)
+}
+
+func (s *Server) complete(w http.ResponseWriter, requestID string, response *Response) {
+	s.mu.Lock()
+	inv, ok := s.active[requestID]
+	delete(s.active, requestID)
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusBadRequest, "InvalidRequestID", "Unknown request ID: "+requestID)
+		return
+	}
+
+	inv.result <- response
+	writeStatus(w, http.StatusAccepted)
+}
+
+// abortedError prefers reporting the init error, since the Lambda usually exits after reporting it.
+func (s *Server) abortedError(ctx context.Context) error {
+	select {
+	case <-s.initAbort:
+		return s.initError()
+	default:
+		return erk.WrapAs(ErrInvocationAborted, context.Cause(ctx))
+	}
+}
+
+func (s *Server) initError() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return erk.WithParams(ErrInitFailed, erk.Params{
+		"errorType":    s.initErr.ErrorType,
+		"errorMessage": s.initErr.ErrorMessage,
+	})
+}
+
+func readPayload(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPayloadSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, "RequestEntityTooLarge", "The payload exceeds the maximum size")
+			return nil, false
+		}
+
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return nil, false
+	}
+
+	return payload, true
+}
+
+func readInvocationError(w http.ResponseWriter, r *http.Request) (*InvocationError, bool) {
+	payload, ok := readPayload(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	invocationErr := &InvocationError{}
+	if err := json.Unmarshal(payload, invocationErr); err != nil || invocationErr.ErrorType == "" && invocationErr.ErrorMessage == "" {
+		// Runtimes may send any payload, so fall back to the raw payload
+		invocationErr = &InvocationError{ErrorMessage: string(payload)}
+	}
+
+	if invocationErr.ErrorType == "" {
+		invocationErr.ErrorType = r.Header.Get(headerErrorType)
+	}
+
+	return invocationErr, true
+}
+
+func writeStatus(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(`{"status":"OK"}`)) //nolint:errcheck,gosec // Runtimes ignore the body
+}
+
+func writeError(w http.ResponseWriter, status int, errorType, message string) {
+	body, _ := json.Marshal(&InvocationError{ErrorType: errorType, ErrorMessage: message}) //nolint:errchkjson // Always valid
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body) //nolint:errcheck,gosec // Runtimes ignore the body
+}
+
+// NewRequestID formatted as a UUID, like the request IDs generated by Lambda.
+func NewRequestID() string {
+	b := make([]byte, 16) //nolint:mnd
+	rand.Read(b)          //nolint:errcheck,gosec // crypto/rand.Read never returns an error
+
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32]
+}
@@ -0,0 +1,122 @@
+package runtimeapi_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/runtimeapi"
+)
+
+func TestServer(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type result struct {
+		response *runtimeapi.Response
+		err      error
+	}
+
+	setup := func(ensure ensuring.E) (*runtimeapi.Server, string) {
+		server, err := runtimeapi.NewServer("my-function")
+		ensure(err).IsNotError()
+		ensure.T().Cleanup(func() { server.Close() })
+
+		return server, "http://" + server.Address() + "/2018-06-01/runtime"
+	}
+
+	invokeAsync := func(server *runtimeapi.Server, ctx context.Context, payload string) chan *result {
+		ch := make(chan *result, 1)
+		go func() {
+			response, err := server.Invoke(ctx, []byte(payload))
+			ch <- &result{response: response, err: err}
+		}()
+
+		return ch
+	}
+
+	next := func(ensure ensuring.E, baseURL string) (string, *http.Response, string) {
+		resp, err := http.Get(baseURL + "/invocation/next") //nolint:noctx
+		ensure(err).IsNotError()
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		ensure(err).IsNotError()
+
+		return resp.Header.Get("Lambda-Runtime-Aws-Request-Id"), resp, string(body)
+	}
+
+	post := func(ensure ensuring.E, url, errorType, body string) int {
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body)) //nolint:noctx
+		ensure(err).IsNotError()
+
+		if errorType != "" {
+			req.Header.Set("Lambda-Runtime-Function-Error-Type", errorType)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		ensure(err).IsNotError()
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	ensure.Run("with response", func(ensure ensuring.E) {
+		server, baseURL := setup(ensure)
+
+		deadline := time.Now().Add(time.Minute)
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		defer cancel()
+
+		ch := invokeAsync(server, ctx, `{"hello":"world"}`)
+		requestID, resp, body := next(ensure, baseURL)
+		ensure(len(requestID)).Equals(36)
+		ensure(resp.Header.Get("Lambda-Runtime-Deadline-Ms")).Equals(strconv.FormatInt(deadline.UnixMilli(), 10))
+		ensure(resp.Header.Get("Lambda-Runtime-Invoked-Function-Arn")).Equals("arn:aws:lambda:us-east-1:000000000000:function:my-function")
+		ensure(body).Equals(`{"hello":"world"}`)
+
+		ensure(post(ensure, baseURL+"/invocation/"+requestID+"/response", "", `"done"`)).Equals(http.StatusAccepted)
+
+		r := <-ch
+		ensure(r.err).IsNotError()
+		ensure(r.response).Equals(&runtimeapi.Response{Payload: []byte(`"done"`)})
+	})
+
+	ensure.Run("with error that is not JSON", func(ensure ensuring.E) {
+		server, baseURL := setup(ensure)
+
+		ch := invokeAsync(server, context.Background(), `{}`)
+		requestID, _, _ := next(ensure, baseURL)
+
+		ensure(post(ensure, baseURL+"/invocation/"+requestID+"/error", "Runtime.Panic", "panicked")).Equals(http.StatusAccepted)
+
+		r := <-ch
+		ensure(r.err).IsNotError()
+		ensure(r.response).Equals(&runtimeapi.Response{
+			Error: &runtimeapi.InvocationError{ErrorMessage: "panicked", ErrorType: "Runtime.Panic"},
+		})
+	})
+
+	ensure.Run("with unknown request ID", func(ensure ensuring.E) {
+		_, baseURL := setup(ensure)
+
+		ensure(post(ensure, baseURL+"/invocation/unknown/response", "", `{}`)).Equals(http.StatusBadRequest)
+		ensure(post(ensure, baseURL+"/invocation/unknown/error", "", `{}`)).Equals(http.StatusBadRequest)
+	})
+
+	ensure.Run("with response for a completed request ID", func(ensure ensuring.E) {
+		server, baseURL := setup(ensure)
+
+		ch := invokeAsync(server, context.Background(), `{}`)
+		requestID, _, _ := next(ensure, baseURL)
+
+		ensure(post(ensure, baseURL+"/invocation/"+requestID+"/response", "", `{}`)).Equals(http.StatusAccepted)
+		ensure(post(ensure, baseURL+"/invocation/"+requestID+"/response", "", `{}`)).Equals(http.StatusBadRequest)
+		ensure((<-ch).err).IsNotError()
+	})
+}
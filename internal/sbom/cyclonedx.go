@@ -0,0 +1,142 @@
+package sbom
+
+import (
+	"runtime/debug"
+	"slices"
+	"time"
+)
+
+const (
+	cycloneDXFormat      = "CycloneDX"
+	cycloneDXSpecVersion = "1.5"
+	cycloneDXApplication = "application"
+	cycloneDXLibrary     = "library"
+	cycloneDXHashSHA256  = "SHA-256"
+)
+
+type cycloneDXDocument struct {
+	BOMFormat    string                 `json:"bomFormat"`
+	SpecVersion  string                 `json:"specVersion"`
+	Version      int                    `json:"version"`
+	Metadata     *cycloneDXMetadata     `json:"metadata"`
+	Components   []*cycloneDXComponent  `json:"components"`
+	Dependencies []*cycloneDXDependency `json:"dependencies"`
+}
+
+type cycloneDXMetadata struct {
+	Timestamp string              `json:"timestamp"`
+	Tools     *cycloneDXTools     `json:"tools"`
+	Component *cycloneDXComponent `json:"component"`
+}
+
+type cycloneDXTools struct {
+	Components []*cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	BOMRef     string               `json:"bom-ref,omitempty"`
+	Type       string               `json:"type"`
+	Name       string               `json:"name"`
+	Version    string               `json:"version,omitempty"`
+	PURL       string               `json:"purl,omitempty"`
+	Hashes     []*cycloneDXHash     `json:"hashes,omitempty"`
+	Properties []*cycloneDXProperty `json:"properties,omitempty"`
+}
+
+type cycloneDXHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cycloneDXDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// newCycloneDX document for the artifact, which depends on each binary.
+// Each binary depends on the standard library and its modules, and has its build settings as properties.
+func newCycloneDX(name string, created time.Time, binaries []*debug.BuildInfo) *cycloneDXDocument {
+	artifact := &cycloneDXComponent{BOMRef: name, Type: cycloneDXApplication, Name: name}
+
+	document := &cycloneDXDocument{
+		BOMFormat:   cycloneDXFormat,
+		SpecVersion: cycloneDXSpecVersion,
+		Version:     1,
+		Metadata: &cycloneDXMetadata{
+			Timestamp: created.Format(time.RFC3339),
+			Tools:     &cycloneDXTools{Components: []*cycloneDXComponent{{Type: cycloneDXApplication, Name: toolName}}},
+			Component: artifact,
+		},
+	}
+
+	artifactDependency := &cycloneDXDependency{Ref: artifact.BOMRef, DependsOn: []string{}}
+	document.Dependencies = append(document.Dependencies, artifactDependency)
+
+	for _, binary := range binaries {
+		binaryComponent := cycloneDXBinaryComponent(binary)
+		document.Components = append(document.Components, binaryComponent)
+		artifactDependency.DependsOn = append(artifactDependency.DependsOn, binaryComponent.BOMRef)
+
+		binaryDependency := &cycloneDXDependency{Ref: binaryComponent.BOMRef, DependsOn: []string{cycloneDXStdlibComponent(binary).BOMRef}}
+		for _, module := range binary.Deps {
+			binaryDependency.DependsOn = append(binaryDependency.DependsOn, cycloneDXModuleComponent(resolveReplace(module)).BOMRef)
+		}
+		document.Dependencies = append(document.Dependencies, binaryDependency)
+	}
+
+	for _, binary := range binaries {
+		stdlibComponent := cycloneDXStdlibComponent(binary)
+		if !slices.ContainsFunc(document.Components, func(existing *cycloneDXComponent) bool { return existing.BOMRef == stdlibComponent.BOMRef }) {
+			document.Components = append(document.Components, stdlibComponent)
+		}
+	}
+
+	for _, module := range dependencies(binaries) {
+		document.Components = append(document.Components, cycloneDXModuleComponent(module))
+	}
+
+	return document
+}
+
+func cycloneDXBinaryComponent(info *debug.BuildInfo) *cycloneDXComponent {
+	version := mainVersion(info)
+	purl := packageURL(info.Main.Path, version, info.Path)
+
+	component := &cycloneDXComponent{
+		BOMRef:     purl,
+		Type:       cycloneDXApplication,
+		Name:       info.Path,
+		Version:    version,
+		PURL:       purl,
+		Properties: []*cycloneDXProperty{{Name: "go:version", Value: info.GoVersion}},
+	}
+
+	for _, setting := range info.Settings {
+		component.Properties = append(component.Properties, &cycloneDXProperty{Name: "go:build:" + setting.Key, Value: setting.Value})
+	}
+
+	return component
+}
+
+func cycloneDXStdlibComponent(info *debug.BuildInfo) *cycloneDXComponent {
+	version := goVersion(info)
+	purl := packageURL(standardLibrary, version, "")
+
+	return &cycloneDXComponent{BOMRef: purl, Type: cycloneDXLibrary, Name: standardLibrary, Version: version, PURL: purl}
+}
+
+func cycloneDXModuleComponent(module *debug.Module) *cycloneDXComponent {
+	purl := packageURL(module.Path, module.Version, "")
+	component := &cycloneDXComponent{BOMRef: purl, Type: cycloneDXLibrary, Name: module.Path, Version: module.Version, PURL: purl}
+
+	if hash := sumSHA256(module.Sum); hash != "" {
+		component.Hashes = []*cycloneDXHash{{Algorithm: cycloneDXHashSHA256, Content: hash}}
+	}
+
+	return component
+}
@@ -0,0 +1,24 @@
+package sbom
+
+import (
+	"log"
+	"strings"
+)
+
+// Recorder prints the SBOMs instead of writing them, for dry runs.
+type Recorder struct {
+	Logger *log.Logger
+}
+
+var _ WriterAPI = &Recorder{}
+
+// WriteSBOM prints the SBOM that would be written.
+func (r *Recorder) WriteSBOM(params *Params) error {
+	format := "spdx"
+	if params.CycloneDX {
+		format = "cyclonedx"
+	}
+
+	r.Logger.Printf("   sbom: %s (%s) <- %s\n", params.OutPath, format, strings.Join(params.BinaryPaths, ", "))
+	return nil
+}
@@ -0,0 +1,29 @@
+package sbom_test
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/sbom"
+)
+
+func TestRecorderWriteSBOM(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("prints the SBOM without writing it", func(ensure ensuring.E) {
+		output := &bytes.Buffer{}
+		recorder := &sbom.Recorder{Logger: log.New(output, "", 0)}
+
+		err := recorder.WriteSBOM(&sbom.Params{
+			Name:        "tools",
+			BinaryPaths: []string{"tmp/layers/tools/converter", "tmp/layers/tools/helper"},
+			OutPath:     "tmp/layers/tools.cdx.json",
+			CycloneDX:   true,
+		})
+		ensure(err).IsNotError()
+		ensure(output.String()).Equals("   sbom: tmp/layers/tools.cdx.json (cyclonedx) <- tmp/layers/tools/converter, tmp/layers/tools/helper\n")
+	})
+}
@@ -0,0 +1,214 @@
+// Package sbom writes software bills of materials for built binaries, from the module information embedded by the go command.
+package sbom
+
+import (
+	"crypto/sha256"
+	"debug/buildinfo"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/versioninfo"
+)
+
+type ErkCannotWriteSBOM struct{ erk.DefaultKind }
+
+var ErrCannotReadBuildInfo = erk.New(ErkCannotWriteSBOM{}, "Cannot read the build information embedded in '{{.path}}': {{.err}}")
+
+const (
+	// toolName is listed as the creator of the SBOMs.
+	toolName = "lambgo"
+
+	// develVersion is the version of the main module when it is not built from a module proxy or tagged commit.
+	develVersion = "(devel)"
+
+	// standardLibrary is the name of the Go standard library in SBOMs, which vulnerability scanners recognize.
+	standardLibrary = "stdlib"
+)
+
+// Params configures how an SBOM is written.
+type Params struct {
+	// Name of the artifact, such as the path of the Lambda or the name of the layer.
+	Name string
+
+	// BinaryPaths of the built binaries in the artifact. Layers can contain more than one binary.
+	BinaryPaths []string
+
+	// OutPath of the SBOM, which is a JSON file.
+	OutPath string
+
+	// CycloneDX writes a CycloneDX SBOM instead of an SPDX SBOM.
+	CycloneDX bool
+}
+
+type WriterAPI interface {
+	WriteSBOM(params *Params) error
+}
+
+// Writer writes SPDX 2.3 or CycloneDX 1.5 SBOMs, listing the main module, dependencies, Go version, and build settings of each binary.
+type Writer struct {
+	// Now is used for the creation time when SOURCE_DATE_EPOCH is not set, and the binaries do not contain the
+	// time of their git commit. Defaults to time.Now.
+	Now func() time.Time
+
+	// ReadBuildInfo embedded in a binary by the go command. Defaults to buildinfo.ReadFile.
+	ReadBuildInfo func(path string) (*debug.BuildInfo, error)
+}
+
+var _ WriterAPI = &Writer{}
+
+// WriteSBOM for the binaries to the OutPath.
+func (w *Writer) WriteSBOM(params *Params) error {
+	binaries := make([]*debug.BuildInfo, 0, len(params.BinaryPaths))
+	for _, path := range params.BinaryPaths {
+		info, err := w.readBuildInfo(path)
+		if err != nil {
+			return erk.WrapWith(ErrCannotReadBuildInfo, err, erk.Params{"path": path})
+		}
+
+		binaries = append(binaries, info)
+	}
+
+	created, err := w.created(binaries)
+	if err != nil {
+		return err
+	}
+
+	var document any
+	if params.CycloneDX {
+		document = newCycloneDX(params.Name, created, binaries)
+	} else {
+		document = newSPDX(params.Name, created, binaries)
+	}
+
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(params.OutPath, append(data, '\n'), 0o644) //nolint:gosec // SBOMs are not secret
+}
+
+func (w *Writer) readBuildInfo(path string) (*debug.BuildInfo, error) {
+	if w.ReadBuildInfo == nil {
+		return buildinfo.ReadFile(path)
+	}
+
+	return w.ReadBuildInfo(path)
+}
+
+// created returns when the SBOM was created, preferring SOURCE_DATE_EPOCH and then the time of the git commit,
+// so the SBOM is reproducible like the binaries.
+func (w *Writer) created(binaries []*debug.BuildInfo) (time.Time, error) {
+	if epoch, ok, err := versioninfo.SourceDateEpoch(); ok || err != nil {
+		return epoch.UTC(), err
+	}
+
+	for _, binary := range binaries {
+		if commitTime, err := time.Parse(time.RFC3339, buildSetting(binary, "vcs.time")); err == nil {
+			return commitTime.UTC(), nil
+		}
+	}
+
+	if w.Now == nil {
+		return time.Now().UTC().Truncate(time.Second), nil
+	}
+
+	return w.Now().UTC().Truncate(time.Second), nil
+}
+
+func buildSetting(info *debug.BuildInfo, key string) string {
+	for _, setting := range info.Settings {
+		if setting.Key == key {
+			return setting.Value
+		}
+	}
+
+	return ""
+}
+
+// dependencies of the binaries, without duplicates, and sorted by path and version.
+// Replaced modules are listed as their replacement, since that is the code in the binary.
+func dependencies(binaries []*debug.BuildInfo) []*debug.Module {
+	var modules []*debug.Module
+	for _, binary := range binaries {
+		for _, module := range binary.Deps {
+			module = resolveReplace(module)
+
+			if !slices.ContainsFunc(modules, func(existing *debug.Module) bool { return sameModule(existing, module) }) {
+				modules = append(modules, module)
+			}
+		}
+	}
+
+	slices.SortFunc(modules, func(a, b *debug.Module) int {
+		if byPath := strings.Compare(a.Path, b.Path); byPath != 0 {
+			return byPath
+		}
+
+		return strings.Compare(a.Version, b.Version)
+	})
+
+	return modules
+}
+
+func resolveReplace(module *debug.Module) *debug.Module {
+	if module.Replace != nil {
+		return module.Replace
+	}
+
+	return module
+}
+
+func sameModule(a, b *debug.Module) bool {
+	return a.Path == b.Path && a.Version == b.Version
+}
+
+// mainVersion of the binary's main module, which is empty when it is a development build.
+func mainVersion(info *debug.BuildInfo) string {
+	if info.Main.Version == develVersion {
+		return ""
+	}
+
+	return info.Main.Version
+}
+
+// goVersion of the standard library, without the go prefix (eg. 1.23.0).
+func goVersion(info *debug.BuildInfo) string {
+	return strings.TrimPrefix(info.GoVersion, "go")
+}
+
+// packageURL of a Go module, and optionally a package within it, as defined by https://github.com/package-url/purl-spec.
+func packageURL(modulePath, version, packagePath string) string {
+	purl := "pkg:golang/" + modulePath
+	if version != "" {
+		purl += "@" + version
+	}
+
+	if subpath, ok := strings.CutPrefix(packagePath, modulePath+"/"); ok {
+		purl += "#" + subpath
+	}
+
+	return purl
+}
+
+// sumSHA256 returns the hex SHA-256 hash of a go.sum hash (eg. h1:base64), or empty when it is not an h1 hash.
+func sumSHA256(sum string) string {
+	encoded, ok := strings.CutPrefix(sum, "h1:")
+	if !ok {
+		return ""
+	}
+
+	hash, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(hash) != sha256.Size {
+		return ""
+	}
+
+	return hex.EncodeToString(hash)
+}
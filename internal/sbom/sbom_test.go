@@ -0,0 +1,354 @@
+package sbom_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/sbom"
+	"github.com/JosiahWitt/lambgo/internal/versioninfo"
+)
+
+func TestWriteSBOM(t *testing.T) {
+	ensure := ensure.New(t)
+
+	exampleError := errors.New("not a Go binary")
+
+	lambdaModule := &debug.Module{
+		Path:    "github.com/aws/aws-lambda-go",
+		Version: "v1.47.0",
+		Sum:     "h1:VU555lKynzoB3PmOSUuZKKHcEsyDuD5zBXTJs3ymNZ8=",
+	}
+
+	sharedModule := &debug.Module{
+		Path:    "github.com/my/shared",
+		Version: "v1.0.0",
+		Sum:     "h1:pNJoaAF8DM/+Lv5QlE70IRg0ZgzKg0xun4bexqiCRvo=",
+		Replace: &debug.Module{
+			Path:    "github.com/my/shared",
+			Version: "v1.1.0",
+			Sum:     "h1:yTM2vBg8llq7kGpxt9dbdAvNn62QmmqGYBpQnG/xhRk=",
+		},
+	}
+
+	buildInfos := map[string]*debug.BuildInfo{
+		"out/lambdas/api": {
+			GoVersion: "go1.23.4",
+			Path:      "github.com/my/app/lambdas/api",
+			Main:      debug.Module{Path: "github.com/my/app", Version: "(devel)"},
+			Deps:      []*debug.Module{lambdaModule, sharedModule},
+			Settings: []debug.BuildSetting{
+				{Key: "-trimpath", Value: "true"},
+				{Key: "GOOS", Value: "linux"},
+				{Key: "vcs.time", Value: "2024-05-06T07:08:09Z"},
+			},
+		},
+		"out/layers/tools/converter": {
+			GoVersion: "go1.23.4",
+			Path:      "github.com/my/tools/cmd/converter",
+			Main:      debug.Module{Path: "github.com/my/tools", Version: "v0.3.0"},
+			Deps:      []*debug.Module{sharedModule},
+		},
+		"out/layers/tools/tools": {
+			GoVersion: "go1.23.4",
+			Path:      "github.com/my/tools",
+			Main:      debug.Module{Path: "github.com/my/tools", Version: "v0.3.0"},
+		},
+	}
+
+	table := []struct {
+		Name            string
+		Params          *sbom.Params
+		SourceDateEpoch string
+		ExpectedSBOM    string
+		ExpectedError   error
+	}{
+		{
+			Name: "with SPDX, created at the time of the git commit",
+			Params: &sbom.Params{
+				Name:        "lambdas/api",
+				BinaryPaths: []string{"out/lambdas/api"},
+			},
+			ExpectedSBOM: `{
+  "spdxVersion": "SPDX-2.3",
+  "dataLicense": "CC0-1.0",
+  "SPDXID": "SPDXRef-DOCUMENT",
+  "name": "lambdas/api",
+  "documentNamespace": "https://github.com/JosiahWitt/lambgo/spdx/lambdas-api-357b14de04b527b320f362e495275394df5dee885ee0814eaad9ced8dc1b55c2",
+  "creationInfo": {
+    "created": "2024-05-06T07:08:09Z",
+    "creators": [
+      "Tool: lambgo"
+    ]
+  },
+  "packages": [
+    {
+      "name": "github.com/my/app/lambdas/api",
+      "SPDXID": "SPDXRef-Binary-github.com-my-app-lambdas-api",
+      "downloadLocation": "NOASSERTION",
+      "filesAnalyzed": false,
+      "primaryPackagePurpose": "APPLICATION",
+      "externalRefs": [
+        {
+          "referenceCategory": "PACKAGE-MANAGER",
+          "referenceType": "purl",
+          "referenceLocator": "pkg:golang/github.com/my/app#lambdas/api"
+        }
+      ],
+      "comment": "Built with go1.23.4 using: -trimpath=true, GOOS=linux, vcs.time=2024-05-06T07:08:09Z"
+    },
+    {
+      "name": "stdlib",
+      "SPDXID": "SPDXRef-Stdlib-1.23.4",
+      "versionInfo": "1.23.4",
+      "downloadLocation": "NOASSERTION",
+      "filesAnalyzed": false,
+      "primaryPackagePurpose": "LIBRARY",
+      "externalRefs": [
+        {
+          "referenceCategory": "PACKAGE-MANAGER",
+          "referenceType": "purl",
+          "referenceLocator": "pkg:golang/stdlib@1.23.4"
+        }
+      ]
+    },
+    {
+      "name": "github.com/aws/aws-lambda-go",
+      "SPDXID": "SPDXRef-Module-github.com-aws-aws-lambda-go-v1.47.0",
+      "versionInfo": "v1.47.0",
+      "downloadLocation": "NOASSERTION",
+      "filesAnalyzed": false,
+      "primaryPackagePurpose": "LIBRARY",
+      "checksums": [
+        {
+          "algorithm": "SHA256",
+          "checksumValue": "554e79e652b29f3a01dcf98e494b9928a1dc12cc83b83e730574c9b37ca6359f"
+        }
+      ],
+      "externalRefs": [
+        {
+          "referenceCategory": "PACKAGE-MANAGER",
+          "referenceType": "purl",
+          "referenceLocator": "pkg:golang/github.com/aws/aws-lambda-go@v1.47.0"
+        }
+      ]
+    },
+    {
+      "name": "github.com/my/shared",
+      "SPDXID": "SPDXRef-Module-github.com-my-shared-v1.1.0",
+      "versionInfo": "v1.1.0",
+      "downloadLocation": "NOASSERTION",
+      "filesAnalyzed": false,
+      "primaryPackagePurpose": "LIBRARY",
+      "checksums": [
+        {
+          "algorithm": "SHA256",
+          "checksumValue": "c93336bc183c965abb906a71b7d75b740bcd9fad909a6a86601a509c6ff18519"
+        }
+      ],
+      "externalRefs": [
+        {
+          "referenceCategory": "PACKAGE-MANAGER",
+          "referenceType": "purl",
+          "referenceLocator": "pkg:golang/github.com/my/shared@v1.1.0"
+        }
+      ]
+    }
+  ],
+  "relationships": [
+    {
+      "spdxElementId": "SPDXRef-DOCUMENT",
+      "relationshipType": "DESCRIBES",
+      "relatedSpdxElement": "SPDXRef-Binary-github.com-my-app-lambdas-api"
+    },
+    {
+      "spdxElementId": "SPDXRef-Binary-github.com-my-app-lambdas-api",
+      "relationshipType": "DEPENDS_ON",
+      "relatedSpdxElement": "SPDXRef-Stdlib-1.23.4"
+    },
+    {
+      "spdxElementId": "SPDXRef-Binary-github.com-my-app-lambdas-api",
+      "relationshipType": "DEPENDS_ON",
+      "relatedSpdxElement": "SPDXRef-Module-github.com-aws-aws-lambda-go-v1.47.0"
+    },
+    {
+      "spdxElementId": "SPDXRef-Binary-github.com-my-app-lambdas-api",
+      "relationshipType": "DEPENDS_ON",
+      "relatedSpdxElement": "SPDXRef-Module-github.com-my-shared-v1.1.0"
+    }
+  ]
+}
+`,
+		},
+
+		{
+			Name: "with CycloneDX for a layer with more than one binary, created now",
+			Params: &sbom.Params{
+				Name:        "tools",
+				BinaryPaths: []string{"out/layers/tools/converter", "out/layers/tools/tools"},
+				CycloneDX:   true,
+			},
+			ExpectedSBOM: `{
+  "bomFormat": "CycloneDX",
+  "specVersion": "1.5",
+  "version": 1,
+  "metadata": {
+    "timestamp": "2024-05-06T09:08:09Z",
+    "tools": {
+      "components": [
+        {
+          "type": "application",
+          "name": "lambgo"
+        }
+      ]
+    },
+    "component": {
+      "bom-ref": "tools",
+      "type": "application",
+      "name": "tools"
+    }
+  },
+  "components": [
+    {
+      "bom-ref": "pkg:golang/github.com/my/tools@v0.3.0#cmd/converter",
+      "type": "application",
+      "name": "github.com/my/tools/cmd/converter",
+      "version": "v0.3.0",
+      "purl": "pkg:golang/github.com/my/tools@v0.3.0#cmd/converter",
+      "properties": [
+        {
+          "name": "go:version",
+          "value": "go1.23.4"
+        }
+      ]
+    },
+    {
+      "bom-ref": "pkg:golang/github.com/my/tools@v0.3.0",
+      "type": "application",
+      "name": "github.com/my/tools",
+      "version": "v0.3.0",
+      "purl": "pkg:golang/github.com/my/tools@v0.3.0",
+      "properties": [
+        {
+          "name": "go:version",
+          "value": "go1.23.4"
+        }
+      ]
+    },
+    {
+      "bom-ref": "pkg:golang/stdlib@1.23.4",
+      "type": "library",
+      "name": "stdlib",
+      "version": "1.23.4",
+      "purl": "pkg:golang/stdlib@1.23.4"
+    },
+    {
+      "bom-ref": "pkg:golang/github.com/my/shared@v1.1.0",
+      "type": "library",
+      "name": "github.com/my/shared",
+      "version": "v1.1.0",
+      "purl": "pkg:golang/github.com/my/shared@v1.1.0",
+      "hashes": [
+        {
+          "alg": "SHA-256",
+          "content": "c93336bc183c965abb906a71b7d75b740bcd9fad909a6a86601a509c6ff18519"
+        }
+      ]
+    }
+  ],
+  "dependencies": [
+    {
+      "ref": "tools",
+      "dependsOn": [
+        "pkg:golang/github.com/my/tools@v0.3.0#cmd/converter",
+        "pkg:golang/github.com/my/tools@v0.3.0"
+      ]
+    },
+    {
+      "ref": "pkg:golang/github.com/my/tools@v0.3.0#cmd/converter",
+      "dependsOn": [
+        "pkg:golang/stdlib@1.23.4",
+        "pkg:golang/github.com/my/shared@v1.1.0"
+      ]
+    },
+    {
+      "ref": "pkg:golang/github.com/my/tools@v0.3.0",
+      "dependsOn": [
+        "pkg:golang/stdlib@1.23.4"
+      ]
+    }
+  ]
+}
+`,
+		},
+
+		{
+			Name: "with SOURCE_DATE_EPOCH, which is used instead of the time of the git commit",
+			Params: &sbom.Params{
+				Name:        "lambdas/api",
+				BinaryPaths: []string{"out/lambdas/api"},
+				CycloneDX:   true,
+			},
+			SourceDateEpoch: "1700000000",
+		},
+
+		{
+			Name: "with invalid SOURCE_DATE_EPOCH",
+			Params: &sbom.Params{
+				Name:        "lambdas/api",
+				BinaryPaths: []string{"out/lambdas/api"},
+			},
+			SourceDateEpoch: "yesterday",
+			ExpectedError:   versioninfo.ErrInvalidSourceDateEpoch,
+		},
+
+		{
+			Name: "when the build info cannot be read",
+			Params: &sbom.Params{
+				Name:        "lambdas/missing",
+				BinaryPaths: []string{"out/lambdas/missing"},
+			},
+			ExpectedError: sbom.ErrCannotReadBuildInfo,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		ensure.T().Setenv("SOURCE_DATE_EPOCH", entry.SourceDateEpoch)
+
+		subject := &sbom.Writer{
+			Now: func() time.Time { return time.Date(2024, 5, 6, 11, 8, 9, 123, time.FixedZone("CEST", 2*60*60)) },
+			ReadBuildInfo: func(path string) (*debug.BuildInfo, error) {
+				if info, ok := buildInfos[path]; ok {
+					return info, nil
+				}
+
+				return nil, exampleError
+			},
+		}
+
+		params := *entry.Params
+		params.OutPath = filepath.Join(ensure.T().TempDir(), "sbom.json")
+
+		err := subject.WriteSBOM(&params)
+		ensure(err).IsError(entry.ExpectedError)
+		if entry.ExpectedError != nil {
+			return
+		}
+
+		data, err := os.ReadFile(params.OutPath)
+		ensure(err).IsNotError()
+
+		if entry.ExpectedSBOM == "" {
+			ensure(string(data)).MatchesRegexp(`"timestamp": "2023-11-14T22:13:20Z"`)
+			return
+		}
+
+		ensure(string(data)).Equals(entry.ExpectedSBOM)
+	})
+}
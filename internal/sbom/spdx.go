@@ -0,0 +1,206 @@
+package sbom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	spdxVersion         = "SPDX-2.3"
+	spdxDataLicense     = "CC0-1.0"
+	spdxDocumentID      = "SPDXRef-DOCUMENT"
+	spdxNamespacePrefix = "https://github.com/JosiahWitt/lambgo/spdx/"
+	spdxNoAssertion     = "NOASSERTION"
+	spdxRelDescribes    = "DESCRIBES"
+	spdxRelDependsOn    = "DEPENDS_ON"
+	spdxPurposeApp      = "APPLICATION"
+	spdxPurposeLibrary  = "LIBRARY"
+	spdxChecksumSHA256  = "SHA256"
+)
+
+// spdxInvalidIDChars are replaced in SPDX IDs, which can only contain letters, numbers, . and -.
+var spdxInvalidIDChars = regexp.MustCompile(`[^A-Za-z0-9.-]+`)
+
+type spdxDocument struct {
+	SPDXVersion       string              `json:"spdxVersion"`
+	DataLicense       string              `json:"dataLicense"`
+	SPDXID            string              `json:"SPDXID"`
+	Name              string              `json:"name"`
+	DocumentNamespace string              `json:"documentNamespace"`
+	CreationInfo      *spdxCreationInfo   `json:"creationInfo"`
+	Packages          []*spdxPackage      `json:"packages"`
+	Relationships     []*spdxRelationship `json:"relationships"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	Name                  string             `json:"name"`
+	SPDXID                string             `json:"SPDXID"`
+	VersionInfo           string             `json:"versionInfo,omitempty"`
+	DownloadLocation      string             `json:"downloadLocation"`
+	FilesAnalyzed         bool               `json:"filesAnalyzed"`
+	PrimaryPackagePurpose string             `json:"primaryPackagePurpose"`
+	Checksums             []*spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs          []*spdxExternalRef `json:"externalRefs"`
+	Comment               string             `json:"comment,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// newSPDX document describing each binary, which depends on the standard library and its modules.
+func newSPDX(name string, created time.Time, binaries []*debug.BuildInfo) *spdxDocument {
+	document := &spdxDocument{
+		SPDXVersion:       spdxVersion,
+		DataLicense:       spdxDataLicense,
+		SPDXID:            spdxDocumentID,
+		Name:              name,
+		DocumentNamespace: spdxNamespace(name, binaries),
+		CreationInfo: &spdxCreationInfo{
+			Created:  created.Format(time.RFC3339),
+			Creators: []string{"Tool: " + toolName},
+		},
+	}
+
+	for _, binary := range binaries {
+		binaryPackage := spdxBinaryPackage(binary)
+		document.Packages = append(document.Packages, binaryPackage)
+		document.addRelationship(spdxDocumentID, spdxRelDescribes, binaryPackage.SPDXID)
+	}
+
+	var stdlibPackages []*spdxPackage
+	for _, binary := range binaries {
+		stdlibPackage := spdxStdlibPackage(binary)
+		if !slices.ContainsFunc(stdlibPackages, func(existing *spdxPackage) bool { return existing.SPDXID == stdlibPackage.SPDXID }) {
+			stdlibPackages = append(stdlibPackages, stdlibPackage)
+		}
+	}
+	document.Packages = append(document.Packages, stdlibPackages...)
+
+	for _, module := range dependencies(binaries) {
+		document.Packages = append(document.Packages, spdxModulePackage(module))
+	}
+
+	for i, binary := range binaries {
+		binaryID := document.Packages[i].SPDXID
+		document.addRelationship(binaryID, spdxRelDependsOn, spdxStdlibPackage(binary).SPDXID)
+
+		for _, module := range binary.Deps {
+			document.addRelationship(binaryID, spdxRelDependsOn, spdxModuleID(resolveReplace(module)))
+		}
+	}
+
+	return document
+}
+
+func (document *spdxDocument) addRelationship(elementID, relationshipType, relatedElementID string) {
+	document.Relationships = append(document.Relationships, &spdxRelationship{
+		SPDXElementID:      elementID,
+		RelationshipType:   relationshipType,
+		RelatedSPDXElement: relatedElementID,
+	})
+}
+
+func spdxBinaryPackage(info *debug.BuildInfo) *spdxPackage {
+	version := mainVersion(info)
+
+	return &spdxPackage{
+		Name:                  info.Path,
+		SPDXID:                spdxID("SPDXRef-Binary-" + info.Path),
+		VersionInfo:           version,
+		DownloadLocation:      spdxNoAssertion,
+		PrimaryPackagePurpose: spdxPurposeApp,
+		ExternalRefs:          []*spdxExternalRef{spdxPackageURL(packageURL(info.Main.Path, version, info.Path))},
+		Comment:               spdxBuildComment(info),
+	}
+}
+
+func spdxStdlibPackage(info *debug.BuildInfo) *spdxPackage {
+	version := goVersion(info)
+
+	return &spdxPackage{
+		Name:                  standardLibrary,
+		SPDXID:                spdxID("SPDXRef-Stdlib-" + version),
+		VersionInfo:           version,
+		DownloadLocation:      spdxNoAssertion,
+		PrimaryPackagePurpose: spdxPurposeLibrary,
+		ExternalRefs:          []*spdxExternalRef{spdxPackageURL(packageURL(standardLibrary, version, ""))},
+	}
+}
+
+func spdxModulePackage(module *debug.Module) *spdxPackage {
+	modulePackage := &spdxPackage{
+		Name:                  module.Path,
+		SPDXID:                spdxModuleID(module),
+		VersionInfo:           module.Version,
+		DownloadLocation:      spdxNoAssertion,
+		PrimaryPackagePurpose: spdxPurposeLibrary,
+		ExternalRefs:          []*spdxExternalRef{spdxPackageURL(packageURL(module.Path, module.Version, ""))},
+	}
+
+	if hash := sumSHA256(module.Sum); hash != "" {
+		modulePackage.Checksums = []*spdxChecksum{{Algorithm: spdxChecksumSHA256, ChecksumValue: hash}}
+	}
+
+	return modulePackage
+}
+
+func spdxModuleID(module *debug.Module) string {
+	return spdxID("SPDXRef-Module-" + module.Path + "-" + module.Version)
+}
+
+func spdxID(id string) string {
+	return spdxInvalidIDChars.ReplaceAllString(id, "-")
+}
+
+func spdxPackageURL(purl string) *spdxExternalRef {
+	return &spdxExternalRef{ReferenceCategory: "PACKAGE-MANAGER", ReferenceType: "purl", ReferenceLocator: purl}
+}
+
+// spdxBuildComment lists the Go version and build settings, since SPDX packages do not have properties.
+func spdxBuildComment(info *debug.BuildInfo) string {
+	settings := make([]string, 0, len(info.Settings))
+	for _, setting := range info.Settings {
+		settings = append(settings, setting.Key+"="+setting.Value)
+	}
+
+	comment := "Built with " + info.GoVersion
+	if len(settings) > 0 {
+		comment += " using: " + strings.Join(settings, ", ")
+	}
+
+	return comment
+}
+
+// spdxNamespace is unique to the name and contents of the binaries, so it only changes when they do.
+func spdxNamespace(name string, binaries []*debug.BuildInfo) string {
+	hash := sha256.New()
+	for _, binary := range binaries {
+		hash.Write([]byte(binary.String()))
+	}
+
+	return spdxNamespacePrefix + strings.Trim(spdxID(name), "-") + "-" + hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,282 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+)
+
+const (
+	builtinTemplatesDirectory = "templates"
+	templateSuffix            = ".tmpl"
+)
+
+//go:embed templates
+var builtinTemplates embed.FS
+
+var (
+	ErrInvalidPath           = erk.New(ErkCannotScaffold{}, "Invalid path '{{.path}}', since it must be a relative path within the module")
+	ErrPathAlreadyConfigured = erk.New(ErkCannotScaffold{}, "The path '{{.path}}' is already configured in {{.configPath}}")
+	ErrUnknownTemplate       = erk.New(ErkCannotScaffold{}, "Unknown template '{{.template}}'. Available templates: {{.templates}}")
+	ErrCannotReadTemplates   = erk.New(ErkCannotScaffold{}, "Cannot read templates from '{{.path}}': {{.err}}")
+	ErrCannotRenderTemplate  = erk.New(ErkCannotScaffold{}, "Cannot render '{{.file}}' from template '{{.template}}': {{.err}}")
+	ErrFileExists            = erk.New(ErkCannotScaffold{}, "The file '{{.path}}' already exists")
+	ErrCannotReadConfig      = erk.New(ErkCannotScaffold{}, "Cannot read the file '{{.path}}': {{.err}}")
+	ErrCannotWriteFile       = erk.New(ErkCannotScaffold{}, "Cannot write the file '{{.path}}': {{.err}}")
+)
+
+// TemplateData is available to each file in a template.
+type TemplateData struct {
+	// Path of the new Lambda, relative to the module root (eg. lambdas/orders/create).
+	Path string
+
+	// Name of the new Lambda, which is the last element of the path (eg. create).
+	Name string
+
+	// ModulePath of the Go module (eg. github.com/my/app).
+	ModulePath string
+
+	// ImportPath of the new Lambda (eg. github.com/my/app/lambdas/orders/create).
+	ImportPath string
+}
+
+// NewParams configures the new Lambda.
+type NewParams struct {
+	Config *lambgofile.Config
+
+	// Path of the new Lambda, relative to the module root.
+	Path string
+
+	// Template is the name of a built-in or user template.
+	Template string
+
+	// TemplatesDirectory overrides the templatesDirectory in the config, and is relative to the module root.
+	TemplatesDirectory string
+}
+
+// NewResult describes the created Lambda.
+type NewResult struct {
+	Path       string
+	ConfigPath string
+
+	// Files that were created, relative to the module root.
+	Files []string
+}
+
+// New Lambda created from a template, which is added to the buildPaths in the .lambgo.yml file.
+func (s *Scaffolder) New(params *NewParams) (*NewResult, error) {
+	config := params.Config
+	configPath := filepath.Join(config.RootPath, lambgofile.ConfigFileName)
+
+	buildPath := path.Clean(filepath.ToSlash(params.Path))
+	if !filepath.IsLocal(buildPath) || buildPath == "." {
+		return nil, erk.WithParams(ErrInvalidPath, erk.Params{"path": params.Path})
+	}
+
+	if isConfigured(config, buildPath) {
+		return nil, erk.WithParams(ErrPathAlreadyConfigured, erk.Params{"path": buildPath, "configPath": configPath})
+	}
+
+	templatesDirectory := config.TemplatesDirectory
+	if params.TemplatesDirectory != "" {
+		templatesDirectory = params.TemplatesDirectory
+	}
+
+	templateFS, err := findTemplate(config.RootPath, templatesDirectory, params.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := renderTemplate(templateFS, params.Template, &TemplateData{
+		Path:       buildPath,
+		Name:       path.Base(buildPath),
+		ModulePath: config.ModulePath,
+		ImportPath: config.ImportPath(buildPath),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	configData, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadConfig, err, erk.Params{"path": configPath})
+	}
+
+	updatedConfigData, err := lambgofile.AddBuildPath(configData, buildPath)
+	if err != nil {
+		return nil, err
+	}
+
+	createdFiles, err := writeNewFiles(config.RootPath, buildPath, files)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(configPath, updatedConfigData, 0o644); err != nil { //nolint:gosec,mnd // Config files are not sensitive
+		return nil, erk.WrapWith(ErrCannotWriteConfig, err, erk.Params{"path": configPath})
+	}
+
+	return &NewResult{Path: buildPath, ConfigPath: configPath, Files: createdFiles}, nil
+}
+
+// BuiltinTemplates returns the sorted names of the built-in templates.
+func BuiltinTemplates() []string {
+	return templateNames(builtinTemplates, builtinTemplatesDirectory)
+}
+
+func isConfigured(config *lambgofile.Config, buildPath string) bool {
+	for _, lambda := range config.Lambdas {
+		if lambda.Path == buildPath {
+			return true
+		}
+	}
+
+	for _, extension := range config.Extensions {
+		if extension.Path == buildPath {
+			return true
+		}
+	}
+
+	return false
+}
+
+// findTemplate in the templates directory, falling back to the built-in templates.
+func findTemplate(rootPath, templatesDirectory, name string) (fs.FS, error) {
+	available := BuiltinTemplates()
+	unknownTemplateErr := func() error {
+		return erk.WithParams(ErrUnknownTemplate, erk.Params{
+			"template":  name,
+			"templates": strings.Join(available, ", "),
+		})
+	}
+
+	// Templates are directly within the templates directory
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return nil, unknownTemplateErr()
+	}
+
+	if templatesDirectory != "" {
+		userTemplatesPath := filepath.Join(rootPath, templatesDirectory)
+		userTemplates := os.DirFS(userTemplatesPath)
+
+		if _, err := fs.ReadDir(userTemplates, "."); err != nil {
+			return nil, erk.WrapWith(ErrCannotReadTemplates, err, erk.Params{"path": userTemplatesPath})
+		}
+
+		if isTemplate(userTemplates, name) {
+			return fs.Sub(userTemplates, name)
+		}
+
+		available = append(available, templateNames(userTemplates, ".")...)
+		slices.Sort(available)
+		available = slices.Compact(available)
+	}
+
+	if isTemplate(builtinTemplates, path.Join(builtinTemplatesDirectory, name)) {
+		return fs.Sub(builtinTemplates, path.Join(builtinTemplatesDirectory, name))
+	}
+
+	return nil, unknownTemplateErr()
+}
+
+func isTemplate(fsys fs.FS, name string) bool {
+	info, err := fs.Stat(fsys, name)
+	return err == nil && info.IsDir()
+}
+
+func templateNames(fsys fs.FS, dir string) []string {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+
+	return names
+}
+
+// renderTemplate files, keyed by their path relative to the new Lambda.
+// The .tmpl suffix is removed from rendered files.
+func renderTemplate(templateFS fs.FS, templateName string, data *TemplateData) (map[string][]byte, error) {
+	files := make(map[string][]byte)
+
+	err := fs.WalkDir(templateFS, ".", func(name string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return erk.WrapWith(ErrCannotRenderTemplate, err, erk.Params{"file": name, "template": templateName})
+		}
+
+		if entry.IsDir() {
+			return nil
+		}
+
+		rawTemplate, err := fs.ReadFile(templateFS, name)
+		if err != nil {
+			return erk.WrapWith(ErrCannotRenderTemplate, err, erk.Params{"file": name, "template": templateName})
+		}
+
+		fileTemplate, err := template.New(name).Option("missingkey=error").Parse(string(rawTemplate))
+		if err != nil {
+			return erk.WrapWith(ErrCannotRenderTemplate, err, erk.Params{"file": name, "template": templateName})
+		}
+
+		buf := &bytes.Buffer{}
+		if err := fileTemplate.Execute(buf, data); err != nil {
+			return erk.WrapWith(ErrCannotRenderTemplate, err, erk.Params{"file": name, "template": templateName})
+		}
+
+		files[strings.TrimSuffix(name, templateSuffix)] = buf.Bytes()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return files, nil
+}
+
+// writeNewFiles to the new Lambda's directory, without overwriting any existing files.
+// The returned paths are sorted, and are relative to rootPath.
+func writeNewFiles(rootPath, buildPath string, files map[string][]byte) ([]string, error) {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		filePath := filepath.Join(rootPath, buildPath, name)
+		if _, err := os.Stat(filePath); err == nil {
+			return nil, erk.WithParams(ErrFileExists, erk.Params{"path": filePath})
+		}
+	}
+
+	createdFiles := make([]string, 0, len(names))
+	for _, name := range names {
+		filePath := filepath.Join(rootPath, buildPath, name)
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil { //nolint:mnd
+			return nil, erk.WrapWith(ErrCannotWriteFile, err, erk.Params{"path": filePath})
+		}
+
+		if err := os.WriteFile(filePath, files[name], 0o644); err != nil { //nolint:gosec,mnd // Source files are not sensitive
+			return nil, erk.WrapWith(ErrCannotWriteFile, err, erk.Params{"path": filePath})
+		}
+
+		createdFiles = append(createdFiles, path.Join(buildPath, name))
+	}
+
+	return createdFiles, nil
+}
@@ -0,0 +1,248 @@
+package scaffold_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/scaffold"
+)
+
+func TestNew(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const configData = `# Lambdas to build
+buildPaths:
+  - lambdas/existing # An existing Lambda
+
+# Per-Lambda config
+lambdas:
+  - path: lambdas/configured
+`
+
+	setupModule := func(ensure ensuring.E, files map[string]string) (string, *lambgofile.Config) {
+		root := ensure.T().TempDir()
+		writeFiles(ensure, root, map[string]string{
+			"go.mod":                  "module github.com/my/app\n",
+			lambgofile.ConfigFileName: configData,
+		})
+		writeFiles(ensure, root, files)
+
+		config, err := (&lambgofile.Loader{FS: os.DirFS("/")}).LoadConfig(root, "")
+		ensure(err).IsNotError()
+
+		return root, config
+	}
+
+	readFile := func(ensure ensuring.E, filePath string) string {
+		data, err := os.ReadFile(filePath)
+		ensure(err).IsNotError()
+		return string(data)
+	}
+
+	ensure.Run("with built-in template", func(ensure ensuring.E) {
+		root, config := setupModule(ensure, nil)
+
+		s := scaffold.Scaffolder{}
+		result, err := s.New(&scaffold.NewParams{
+			Config:   config,
+			Path:     "lambdas/orders/create/",
+			Template: "api-gateway",
+		})
+		ensure(err).IsNotError()
+		ensure(result).Equals(&scaffold.NewResult{
+			Path:       "lambdas/orders/create",
+			ConfigPath: filepath.Join(root, lambgofile.ConfigFileName),
+			Files:      []string{"lambdas/orders/create/main.go"},
+		})
+
+		mainFile := readFile(ensure, filepath.Join(root, "lambdas/orders/create/main.go"))
+		ensure(strings.Contains(mainFile, "events.APIGatewayProxyRequest")).IsTrue()
+		ensure(strings.Contains(mainFile, "Hello from create")).IsTrue()
+
+		ensure(readFile(ensure, result.ConfigPath)).Equals(`# Lambdas to build
+buildPaths:
+  - lambdas/existing # An existing Lambda
+  - lambdas/orders/create
+
+# Per-Lambda config
+lambdas:
+  - path: lambdas/configured
+`)
+	})
+
+	ensure.Run("with each built-in template", func(ensure ensuring.E) {
+		templates := scaffold.BuiltinTemplates()
+		ensure(templates).Equals([]string{
+			"api-gateway", "api-gateway-v2", "custom", "eventbridge", "s3", "scheduled", "sns", "sqs",
+		})
+
+		root, config := setupModule(ensure, nil)
+
+		for _, template := range templates {
+			s := scaffold.Scaffolder{}
+			result, err := s.New(&scaffold.NewParams{
+				Config:   config,
+				Path:     "lambdas/" + template,
+				Template: template,
+			})
+			ensure(err).IsNotError()
+
+			mainFile := readFile(ensure, filepath.Join(root, result.Files[0]))
+			ensure(strings.Contains(mainFile, "lambda.Start(handler)")).IsTrue()
+		}
+	})
+
+	ensure.Run("with user template from the config", func(ensure ensuring.E) {
+		root, config := setupModule(ensure, map[string]string{
+			"templates/api-gateway/main.go.tmpl":       "package main // {{.ImportPath}}\n",
+			"templates/api-gateway/handler/handler.go": "package handler // {{.Name}} in {{.ModulePath}}\n",
+			"templates/worker/main.go.tmpl":            "package main\n",
+		})
+		config.TemplatesDirectory = "templates"
+
+		s := scaffold.Scaffolder{}
+		result, err := s.New(&scaffold.NewParams{
+			Config:   config,
+			Path:     "lambdas/orders/create",
+			Template: "api-gateway",
+		})
+		ensure(err).IsNotError()
+		ensure(result.Files).Equals([]string{
+			"lambdas/orders/create/handler/handler.go",
+			"lambdas/orders/create/main.go",
+		})
+
+		ensure(readFile(ensure, filepath.Join(root, "lambdas/orders/create/main.go"))).
+			Equals("package main // github.com/my/app/lambdas/orders/create\n")
+		ensure(readFile(ensure, filepath.Join(root, "lambdas/orders/create/handler/handler.go"))).
+			Equals("package handler // create in github.com/my/app\n")
+	})
+
+	ensure.Run("with templates directory override falling back to built-in template", func(ensure ensuring.E) {
+		root, config := setupModule(ensure, map[string]string{
+			"other/worker/main.go.tmpl": "package main\n",
+		})
+		config.TemplatesDirectory = "missing"
+
+		s := scaffold.Scaffolder{}
+		result, err := s.New(&scaffold.NewParams{
+			Config:             config,
+			Path:               "lambdas/queue",
+			Template:           "sqs",
+			TemplatesDirectory: "other",
+		})
+		ensure(err).IsNotError()
+		ensure(result.Files).Equals([]string{"lambdas/queue/main.go"})
+		ensure(strings.Contains(readFile(ensure, filepath.Join(root, "lambdas/queue/main.go")), "events.SQSEvent")).IsTrue()
+	})
+
+	ensure.Run("with unknown template", func(ensure ensuring.E) {
+		root, config := setupModule(ensure, map[string]string{
+			"templates/worker/main.go.tmpl": "package main\n",
+		})
+		config.TemplatesDirectory = "templates"
+
+		s := scaffold.Scaffolder{}
+		result, err := s.New(&scaffold.NewParams{
+			Config:   config,
+			Path:     "lambdas/orders/create",
+			Template: "missing",
+		})
+		ensure(err).IsError(scaffold.ErrUnknownTemplate)
+		ensure(err.Error()).Equals("Unknown template 'missing'. Available templates: " +
+			"api-gateway, api-gateway-v2, custom, eventbridge, s3, scheduled, sns, sqs, worker")
+		ensure(result).IsNil()
+		ensure(readFile(ensure, filepath.Join(root, lambgofile.ConfigFileName))).Equals(configData)
+	})
+
+	ensure.Run("with template name that is a path", func(ensure ensuring.E) {
+		_, config := setupModule(ensure, nil)
+
+		s := scaffold.Scaffolder{}
+		result, err := s.New(&scaffold.NewParams{
+			Config:   config,
+			Path:     "lambdas/orders/create",
+			Template: "../templates",
+		})
+		ensure(err).IsError(scaffold.ErrUnknownTemplate)
+		ensure(result).IsNil()
+	})
+
+	ensure.Run("with missing templates directory", func(ensure ensuring.E) {
+		_, config := setupModule(ensure, nil)
+		config.TemplatesDirectory = "templates"
+
+		s := scaffold.Scaffolder{}
+		result, err := s.New(&scaffold.NewParams{
+			Config:   config,
+			Path:     "lambdas/orders/create",
+			Template: "sqs",
+		})
+		ensure(err).IsError(scaffold.ErrCannotReadTemplates)
+		ensure(result).IsNil()
+	})
+
+	ensure.Run("with invalid template", func(ensure ensuring.E) {
+		_, config := setupModule(ensure, map[string]string{
+			"templates/broken/main.go.tmpl": "package main // {{.Missing}}\n",
+		})
+		config.TemplatesDirectory = "templates"
+
+		s := scaffold.Scaffolder{}
+		result, err := s.New(&scaffold.NewParams{
+			Config:   config,
+			Path:     "lambdas/orders/create",
+			Template: "broken",
+		})
+		ensure(err).IsError(scaffold.ErrCannotRenderTemplate)
+		ensure(result).IsNil()
+	})
+
+	ensure.Run("with path outside the module", func(ensure ensuring.E) {
+		_, config := setupModule(ensure, nil)
+
+		s := scaffold.Scaffolder{}
+		result, err := s.New(&scaffold.NewParams{
+			Config:   config,
+			Path:     "../lambdas/orders/create",
+			Template: "sqs",
+		})
+		ensure(err).IsError(scaffold.ErrInvalidPath)
+		ensure(result).IsNil()
+	})
+
+	ensure.Run("with path that is already configured", func(ensure ensuring.E) {
+		_, config := setupModule(ensure, nil)
+
+		s := scaffold.Scaffolder{}
+		result, err := s.New(&scaffold.NewParams{
+			Config:   config,
+			Path:     "lambdas/configured",
+			Template: "sqs",
+		})
+		ensure(err).IsError(scaffold.ErrPathAlreadyConfigured)
+		ensure(result).IsNil()
+	})
+
+	ensure.Run("with file that already exists", func(ensure ensuring.E) {
+		root, config := setupModule(ensure, map[string]string{
+			"lambdas/orders/create/main.go": "package main\n",
+		})
+
+		s := scaffold.Scaffolder{}
+		result, err := s.New(&scaffold.NewParams{
+			Config:   config,
+			Path:     "lambdas/orders/create",
+			Template: "sqs",
+		})
+		ensure(err).IsError(scaffold.ErrFileExists)
+		ensure(result).IsNil()
+		ensure(readFile(ensure, filepath.Join(root, "lambdas/orders/create/main.go"))).Equals("package main\n")
+		ensure(readFile(ensure, filepath.Join(root, lambgofile.ConfigFileName))).Equals(configData)
+	})
+}
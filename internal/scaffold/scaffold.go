@@ -0,0 +1,262 @@
+// Package scaffold creates .lambgo.yml files for existing Go modules.
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"go/build"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+)
+
+// LambdaImportPath is the package imported by the main package of each Lambda.
+const LambdaImportPath = "github.com/aws/aws-lambda-go/lambda"
+
+// Supported Lambda runtimes.
+const (
+	RuntimeProvidedAL2023 = "provided.al2023"
+	RuntimeProvidedAL2    = "provided.al2"
+	RuntimeGo1x           = "go1.x"
+)
+
+// Supported Lambda architectures.
+const (
+	ArchX86   = "x86_64"
+	ArchArm64 = "arm64"
+)
+
+type ErkCannotScaffold struct{ erk.DefaultKind }
+
+var (
+	ErrConfigExists       = erk.New(ErkCannotScaffold{}, "The file '{{.path}}' already exists. Use --force to overwrite it.")
+	ErrCannotScan         = erk.New(ErkCannotScaffold{}, "Cannot scan '{{.path}}' for Lambdas: {{.err}}")
+	ErrCannotWriteConfig  = erk.New(ErkCannotScaffold{}, "Cannot write the file '{{.path}}': {{.err}}")
+	ErrUnsupportedRuntime = erk.New(ErkCannotScaffold{}, "Unsupported runtime '{{.runtime}}'. Only `provided.al2023`, `provided.al2`, or `go1.x` are supported.")
+	ErrUnsupportedArch    = erk.New(ErkCannotScaffold{}, "Unsupported architecture '{{.arch}}'. Only `x86_64` (or `amd64`) and `arm64` are supported.")
+	ErrCannotRenderConfig = erk.New(ErkCannotScaffold{}, "Cannot render the config file: {{.err}}")
+)
+
+// InitParams configures the generated .lambgo.yml file.
+type InitParams struct {
+	// RootPath of the Go module, where the .lambgo.yml file is written.
+	// It is the directory of go.work when the config is loaded from a workspace.
+	RootPath string
+
+	// ModuleDirs are the directories of the modules used by go.work, relative to RootPath.
+	// They are scanned for Lambdas, even though they are nested modules. It is empty outside of a workspace.
+	ModuleDirs []string
+
+	// Runtime of the Lambdas, which determines the name of the zipped binary.
+	Runtime string
+
+	// Arch of the Lambdas, either using the Lambda name (x86_64) or the Go name (amd64).
+	Arch string
+
+	// Force overwriting an existing .lambgo.yml file.
+	Force bool
+}
+
+// InitResult describes the written .lambgo.yml file.
+type InitResult struct {
+	ConfigPath   string
+	BuildPaths   []string
+	SkippedPaths []*SkippedPath
+}
+
+// SkippedPath is a directory that was not scanned for a Lambda, since its Go files could not be read.
+// For example, it contains files from multiple packages.
+type SkippedPath struct {
+	Path   string
+	Reason string
+}
+
+type ScaffolderAPI interface {
+	Init(params *InitParams) (*InitResult, error)
+	New(params *NewParams) (*NewResult, error)
+}
+
+// Scaffolder creates .lambgo.yml files and new Lambdas.
+type Scaffolder struct{}
+
+var _ ScaffolderAPI = &Scaffolder{}
+
+// Init writes a .lambgo.yml file to the module root, listing each main package that imports the Lambda package.
+func (s *Scaffolder) Init(params *InitParams) (*InitResult, error) {
+	if !slices.Contains([]string{RuntimeProvidedAL2023, RuntimeProvidedAL2, RuntimeGo1x}, params.Runtime) {
+		return nil, erk.WithParams(ErrUnsupportedRuntime, erk.Params{"runtime": params.Runtime})
+	}
+
+	goarch, err := parseArch(params.Arch)
+	if err != nil {
+		return nil, err
+	}
+
+	configPath := filepath.Join(params.RootPath, lambgofile.ConfigFileName)
+	if !params.Force {
+		if _, err := os.Stat(configPath); err == nil {
+			return nil, erk.WithParams(ErrConfigExists, erk.Params{"path": configPath})
+		}
+	}
+
+	buildPaths, skippedPaths, err := FindLambdas(params.RootPath, goarch, params.ModuleDirs)
+	if err != nil {
+		return nil, err
+	}
+
+	configData, err := renderConfig(&configTemplateData{
+		Runtime:    params.Runtime,
+		Bootstrap:  params.Runtime != RuntimeGo1x,
+		Goarch:     goarch,
+		BuildPaths: buildPaths,
+	})
+	if err != nil {
+		return nil, erk.WrapAs(ErrCannotRenderConfig, err)
+	}
+
+	if err := os.WriteFile(configPath, configData, 0o644); err != nil { //nolint:gosec,mnd // Config files are not sensitive
+		return nil, erk.WrapWith(ErrCannotWriteConfig, err, erk.Params{"path": configPath})
+	}
+
+	return &InitResult{ConfigPath: configPath, BuildPaths: buildPaths, SkippedPaths: skippedPaths}, nil
+}
+
+// FindLambdas within the module located at rootPath.
+// The returned paths are relative to rootPath, and are sorted.
+//
+// Hidden directories, vendor and testdata directories, and nested modules are skipped, except for the moduleDirs of a workspace.
+// Directories whose Go files cannot be read are also skipped, and returned as skipped paths.
+// Build constraints are evaluated for linux and goarch.
+func FindLambdas(rootPath, goarch string, moduleDirs []string) ([]string, []*SkippedPath, error) {
+	buildContext := build.Default
+	buildContext.GOOS = "linux"
+	buildContext.GOARCH = goarch
+	buildContext.CgoEnabled = false
+
+	var buildPaths []string
+	var skippedPaths []*SkippedPath
+	err := filepath.WalkDir(rootPath, func(dirPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !entry.IsDir() {
+			return nil
+		}
+
+		if dirPath != rootPath && shouldSkipDir(rootPath, dirPath, moduleDirs) {
+			return filepath.SkipDir
+		}
+
+		pkg, err := buildContext.ImportDir(dirPath, 0)
+		if err != nil {
+			var noGoErr *build.NoGoError
+			if errors.As(err, &noGoErr) {
+				return nil
+			}
+
+			relativePath, relErr := filepath.Rel(rootPath, dirPath)
+			if relErr != nil {
+				return relErr
+			}
+
+			skippedPaths = append(skippedPaths, &SkippedPath{Path: filepath.ToSlash(relativePath), Reason: err.Error()})
+			return nil
+		}
+
+		if pkg.Name != "main" || !slices.Contains(pkg.Imports, LambdaImportPath) {
+			return nil
+		}
+
+		relativePath, err := filepath.Rel(rootPath, dirPath)
+		if err != nil {
+			return err
+		}
+
+		buildPaths = append(buildPaths, filepath.ToSlash(relativePath))
+		return nil
+	})
+	if err != nil {
+		return nil, nil, erk.WrapWith(ErrCannotScan, err, erk.Params{"path": rootPath})
+	}
+
+	return buildPaths, skippedPaths, nil
+}
+
+func shouldSkipDir(rootPath, dirPath string, moduleDirs []string) bool {
+	name := filepath.Base(dirPath)
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "vendor" || name == "testdata" {
+		return true
+	}
+
+	// Nested modules are built separately, so they cannot be listed in this module's .lambgo.yml, unless they are used by go.work
+	if _, err := os.Stat(filepath.Join(dirPath, "go.mod")); err == nil {
+		relativePath, err := filepath.Rel(rootPath, dirPath)
+		return err != nil || !slices.Contains(moduleDirs, filepath.ToSlash(relativePath))
+	}
+
+	return false
+}
+
+func parseArch(arch string) (string, error) {
+	switch arch {
+	case ArchX86, "amd64":
+		return "amd64", nil
+	case ArchArm64:
+		return "arm64", nil
+	default:
+		return "", erk.WithParams(ErrUnsupportedArch, erk.Params{"arch": arch})
+	}
+}
+
+type configTemplateData struct {
+	Runtime    string
+	Bootstrap  bool
+	Goarch     string
+	BuildPaths []string
+}
+
+//nolint:gochecknoglobals // The template is parsed once
+var configTemplate = template.Must(template.New(lambgofile.ConfigFileName).Parse(`# Generated by lambgo init.
+# See https://github.com/JosiahWitt/lambgo#configuring-lambgo for all the available options.
+
+# Directory to use as the root for build artifacts.
+outDirectory: tmp
+{{- if .Bootstrap}}
+
+# The {{.Runtime}} runtime runs the binary named bootstrap.
+zippedFileName: bootstrap
+{{- end}}
+
+# Operating system and architecture of the Lambda runtime.
+goos: linux
+goarch: {{.Goarch}}
+
+# Paths to build into Lambda zip files.
+# Each path contains a main package that imports github.com/aws/aws-lambda-go/lambda.
+# The artifacts are built to: <outDirectory>/<buildPath>.zip
+{{- if .BuildPaths}}
+buildPaths:
+{{- range .BuildPaths}}
+  - {{.}}
+{{- end}}
+{{- else}}
+# No Lambdas were found, so add their paths here.
+buildPaths: []
+{{- end}}
+`))
+
+func renderConfig(data *configTemplateData) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := configTemplate.Execute(buf, data); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
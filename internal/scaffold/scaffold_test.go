@@ -0,0 +1,252 @@
+package scaffold_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/scaffold"
+)
+
+const lambdaMain = `package main
+
+import "github.com/aws/aws-lambda-go/lambda"
+
+func main() { lambda.Start(func() error { return nil }) }
+`
+
+func TestInit(t *testing.T) {
+	ensure := ensure.New(t)
+
+	setupModule := func(ensure ensuring.E) string {
+		root := ensure.T().TempDir()
+		writeFiles(ensure, root, map[string]string{
+			"go.mod":                        "module github.com/my/app\n",
+			"lambdas/api/main.go":           lambdaMain,
+			"lambdas/worker/main.go":        lambdaMain,
+			"lambdas/worker/main_test.go":   "package main\n",
+			"cmd/tool/main.go":              "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n",
+			"handlers/handlers.go":          "package handlers\n\nimport _ \"github.com/aws/aws-lambda-go/lambda\"\n",
+			"scripts/gen/main.go":           "//go:build ignore\n\n" + lambdaMain,
+			"vendor/some/dep/main.go":       lambdaMain,
+			"internal/testdata/x/main.go":   lambdaMain,
+			".hidden/lambda/main.go":        lambdaMain,
+			"nested/go.mod":                 "module github.com/my/nested\n",
+			"nested/lambdas/other/main.go":  lambdaMain,
+			"lambdas/windows_only/main.go":  "//go:build windows\n\n" + lambdaMain,
+			"lambdas/windows_only/empty.go": "package main\n",
+		})
+
+		return root
+	}
+
+	readConfig := func(ensure ensuring.E, root string) string {
+		data, err := os.ReadFile(filepath.Join(root, lambgofile.ConfigFileName))
+		ensure(err).IsNotError()
+		return string(data)
+	}
+
+	ensure.Run("with provided.al2023 runtime", func(ensure ensuring.E) {
+		root := setupModule(ensure)
+
+		s := scaffold.Scaffolder{}
+		result, err := s.Init(&scaffold.InitParams{
+			RootPath: root,
+			Runtime:  scaffold.RuntimeProvidedAL2023,
+			Arch:     scaffold.ArchArm64,
+		})
+		ensure(err).IsNotError()
+		ensure(result).Equals(&scaffold.InitResult{
+			ConfigPath: filepath.Join(root, lambgofile.ConfigFileName),
+			BuildPaths: []string{"lambdas/api", "lambdas/worker"},
+		})
+
+		ensure(readConfig(ensure, root)).Equals(`# Generated by lambgo init.
+# See https://github.com/JosiahWitt/lambgo#configuring-lambgo for all the available options.
+
+# Directory to use as the root for build artifacts.
+outDirectory: tmp
+
+# The provided.al2023 runtime runs the binary named bootstrap.
+zippedFileName: bootstrap
+
+# Operating system and architecture of the Lambda runtime.
+goos: linux
+goarch: arm64
+
+# Paths to build into Lambda zip files.
+# Each path contains a main package that imports github.com/aws/aws-lambda-go/lambda.
+# The artifacts are built to: <outDirectory>/<buildPath>.zip
+buildPaths:
+  - lambdas/api
+  - lambdas/worker
+`)
+	})
+
+	ensure.Run("with go1.x runtime", func(ensure ensuring.E) {
+		root := setupModule(ensure)
+
+		s := scaffold.Scaffolder{}
+		_, err := s.Init(&scaffold.InitParams{
+			RootPath: root,
+			Runtime:  scaffold.RuntimeGo1x,
+			Arch:     "amd64",
+		})
+		ensure(err).IsNotError()
+
+		ensure(readConfig(ensure, root)).Equals(`# Generated by lambgo init.
+# See https://github.com/JosiahWitt/lambgo#configuring-lambgo for all the available options.
+
+# Directory to use as the root for build artifacts.
+outDirectory: tmp
+
+# Operating system and architecture of the Lambda runtime.
+goos: linux
+goarch: amd64
+
+# Paths to build into Lambda zip files.
+# Each path contains a main package that imports github.com/aws/aws-lambda-go/lambda.
+# The artifacts are built to: <outDirectory>/<buildPath>.zip
+buildPaths:
+  - lambdas/api
+  - lambdas/worker
+`)
+	})
+
+	ensure.Run("with the modules of a workspace", func(ensure ensuring.E) {
+		root := setupModule(ensure)
+
+		s := scaffold.Scaffolder{}
+		result, err := s.Init(&scaffold.InitParams{
+			RootPath:   root,
+			ModuleDirs: []string{".", "nested"},
+			Runtime:    scaffold.RuntimeProvidedAL2023,
+			Arch:       scaffold.ArchX86,
+		})
+		ensure(err).IsNotError()
+		ensure(result.BuildPaths).Equals([]string{"lambdas/api", "lambdas/worker", "nested/lambdas/other"})
+	})
+
+	ensure.Run("when no Lambdas are found", func(ensure ensuring.E) {
+		root := ensure.T().TempDir()
+		writeFiles(ensure, root, map[string]string{"go.mod": "module github.com/my/app\n"})
+
+		s := scaffold.Scaffolder{}
+		result, err := s.Init(&scaffold.InitParams{
+			RootPath: root,
+			Runtime:  scaffold.RuntimeProvidedAL2,
+			Arch:     scaffold.ArchX86,
+		})
+		ensure(err).IsNotError()
+		ensure(result.BuildPaths).IsEmpty()
+
+		// The config can still be loaded, so the paths can be added later
+		config, err := (&lambgofile.Loader{FS: os.DirFS("/")}).LoadConfig(root, "")
+		ensure(err).IsNotError()
+		ensure(config.ZippedFileName).Equals("bootstrap")
+		ensure(config.Lambdas).IsEmpty()
+	})
+
+	ensure.Run("with a directory containing multiple packages", func(ensure ensuring.E) {
+		root := setupModule(ensure)
+		writeFiles(ensure, root, map[string]string{
+			"tools/mixed/a.go": "package a\n",
+			"tools/mixed/b.go": "package b\n",
+		})
+
+		s := scaffold.Scaffolder{}
+		result, err := s.Init(&scaffold.InitParams{
+			RootPath: root,
+			Runtime:  scaffold.RuntimeProvidedAL2023,
+			Arch:     scaffold.ArchX86,
+		})
+		ensure(err).IsNotError()
+		ensure(result.BuildPaths).Equals([]string{"lambdas/api", "lambdas/worker"})
+		ensure(len(result.SkippedPaths)).Equals(1)
+		ensure(result.SkippedPaths[0].Path).Equals("tools/mixed")
+		ensure(result.SkippedPaths[0].Reason).Contains("found packages a (a.go) and b (b.go)")
+	})
+
+	ensure.Run("when the generated config is loaded", func(ensure ensuring.E) {
+		root := setupModule(ensure)
+
+		s := scaffold.Scaffolder{}
+		_, err := s.Init(&scaffold.InitParams{
+			RootPath: root,
+			Runtime:  scaffold.RuntimeProvidedAL2023,
+			Arch:     scaffold.ArchArm64,
+		})
+		ensure(err).IsNotError()
+
+		config, err := (&lambgofile.Loader{FS: os.DirFS("/")}).LoadConfig(root, "")
+		ensure(err).IsNotError()
+		ensure(config.Goarch).Equals("arm64")
+		ensure(config.Lambdas).Equals([]*lambgofile.Lambda{
+			{Path: "lambdas/api"},
+			{Path: "lambdas/worker"},
+		})
+	})
+
+	ensure.Run("when the config already exists", func(ensure ensuring.E) {
+		root := setupModule(ensure)
+		writeFiles(ensure, root, map[string]string{lambgofile.ConfigFileName: "existing"})
+
+		s := scaffold.Scaffolder{}
+		result, err := s.Init(&scaffold.InitParams{
+			RootPath: root,
+			Runtime:  scaffold.RuntimeProvidedAL2023,
+			Arch:     scaffold.ArchX86,
+		})
+		ensure(err).IsError(scaffold.ErrConfigExists)
+		ensure(result).IsNil()
+		ensure(readConfig(ensure, root)).Equals("existing")
+	})
+
+	ensure.Run("when the config already exists and force is set", func(ensure ensuring.E) {
+		root := setupModule(ensure)
+		writeFiles(ensure, root, map[string]string{lambgofile.ConfigFileName: "existing"})
+
+		s := scaffold.Scaffolder{}
+		result, err := s.Init(&scaffold.InitParams{
+			RootPath: root,
+			Runtime:  scaffold.RuntimeProvidedAL2023,
+			Arch:     scaffold.ArchX86,
+			Force:    true,
+		})
+		ensure(err).IsNotError()
+		ensure(result.BuildPaths).Equals([]string{"lambdas/api", "lambdas/worker"})
+	})
+
+	ensure.Run("with unsupported runtime", func(ensure ensuring.E) {
+		s := scaffold.Scaffolder{}
+		result, err := s.Init(&scaffold.InitParams{
+			RootPath: ensure.T().TempDir(),
+			Runtime:  "nodejs20.x",
+			Arch:     scaffold.ArchX86,
+		})
+		ensure(err).IsError(scaffold.ErrUnsupportedRuntime)
+		ensure(result).IsNil()
+	})
+
+	ensure.Run("with unsupported arch", func(ensure ensuring.E) {
+		s := scaffold.Scaffolder{}
+		result, err := s.Init(&scaffold.InitParams{
+			RootPath: ensure.T().TempDir(),
+			Runtime:  scaffold.RuntimeProvidedAL2023,
+			Arch:     "386",
+		})
+		ensure(err).IsError(scaffold.ErrUnsupportedArch)
+		ensure(result).IsNil()
+	})
+}
+
+func writeFiles(ensure ensuring.E, root string, files map[string]string) {
+	for name, data := range files {
+		filePath := filepath.Join(root, filepath.FromSlash(name))
+		ensure(os.MkdirAll(filepath.Dir(filePath), 0o755)).IsNotError()
+		ensure(os.WriteFile(filePath, []byte(data), 0o600)).IsNotError()
+	}
+}
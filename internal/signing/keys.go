@@ -0,0 +1,149 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"os"
+	"strings"
+)
+
+const (
+	pemPrivateKey   = "PRIVATE KEY"
+	pemECPrivateKey = "EC PRIVATE KEY"
+	pemPublicKey    = "PUBLIC KEY"
+)
+
+var (
+	errNoPEMBlock         = errors.New("the file does not contain a PEM block")
+	errUnsupportedPEMType = errors.New("the PEM block must be a PRIVATE KEY, EC PRIVATE KEY, or PUBLIC KEY")
+	errUnsupportedKey     = errors.New("only Ed25519 and ECDSA keys are supported")
+)
+
+// readPrivateKey from a PEM file containing a PKCS #8 or SEC 1 private key.
+func readPrivateKey(path string) (crypto.Signer, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var key any
+	switch block.Type {
+	case pemPrivateKey:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case pemECPrivateKey:
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, errUnsupportedPEMType
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, errUnsupportedKey
+	}
+}
+
+// readPublicKey from a PEM file containing a PKIX public key, or the public key of a private key.
+func readPublicKey(path string) (crypto.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if block.Type != pemPublicKey {
+		privateKey, err := readPrivateKey(path)
+		if err != nil {
+			return nil, err
+		}
+
+		return privateKey.Public(), nil
+	}
+
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch key.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, errUnsupportedKey
+	}
+}
+
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errNoPEMBlock
+	}
+
+	return block, nil
+}
+
+// algorithm used to sign with the key, such as ed25519 or ecdsa-p256-sha256.
+func algorithm(publicKey crypto.PublicKey) string {
+	if key, ok := publicKey.(*ecdsa.PublicKey); ok {
+		curve := strings.ToLower(strings.ReplaceAll(key.Curve.Params().Name, "-", ""))
+		return "ecdsa-" + curve + "-sha256"
+	}
+
+	return "ed25519"
+}
+
+// keyID is the SHA-256 digest of the public key, in its PKIX DER form.
+func keyID(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+
+	return digest(der), nil
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// sign the data. Ed25519 signs the data itself, and ECDSA signs its SHA-256 digest, using the ASN.1 DER encoding,
+// so the signatures can also be verified with OpenSSL.
+func sign(key crypto.Signer, data []byte) ([]byte, error) {
+	if key, ok := key.(*ecdsa.PrivateKey); ok {
+		sum := sha256.Sum256(data)
+		return ecdsa.SignASN1(rand.Reader, key, sum[:])
+	}
+
+	return key.Sign(rand.Reader, data, crypto.Hash(0))
+}
+
+func verify(publicKey crypto.PublicKey, data, signature []byte) bool {
+	switch key := publicKey.(type) {
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(key, sum[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, data, signature)
+	default:
+		return false
+	}
+}
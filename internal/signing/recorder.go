@@ -0,0 +1,21 @@
+package signing
+
+import (
+	"log"
+
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+)
+
+// Recorder prints the signatures instead of writing them, for dry runs.
+type Recorder struct {
+	Logger *log.Logger
+}
+
+var _ SignerAPI = &Recorder{}
+
+// Sign prints the signature that would be written.
+// Only the path of the signature is returned, since the rest depends on the contents of the zip and key.
+func (r *Recorder) Sign(params *Params) (*manifest.Signature, error) {
+	r.Logger.Printf("   signature: %s (%s) <- %s\n", params.OutPath, params.KeyPath, params.ZipPath)
+	return &manifest.Signature{Path: params.OutPath}, nil
+}
@@ -0,0 +1,30 @@
+package signing_test
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/JosiahWitt/lambgo/internal/signing"
+)
+
+func TestRecorderSign(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("prints the signature without writing it", func(ensure ensuring.E) {
+		output := &bytes.Buffer{}
+		recorder := &signing.Recorder{Logger: log.New(output, "", 0)}
+
+		signature, err := recorder.Sign(&signing.Params{
+			ZipPath: "tmp/lambdas/api.zip",
+			KeyPath: "/my/root/keys/signing.pem",
+			OutPath: "tmp/lambdas/api.zip.sig",
+		})
+		ensure(err).IsNotError()
+		ensure(signature).Equals(&manifest.Signature{Path: "tmp/lambdas/api.zip.sig"})
+		ensure(output.String()).Equals("   signature: tmp/lambdas/api.zip.sig (/my/root/keys/signing.pem) <- tmp/lambdas/api.zip\n")
+	})
+}
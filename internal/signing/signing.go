@@ -0,0 +1,82 @@
+// Package signing signs zips with a local private key, and verifies the signatures recorded in the manifest.
+package signing
+
+import (
+	"os"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+)
+
+// Extension of the detached signature, which is written next to the zip.
+const Extension = ".sig"
+
+type ErkCannotSign struct{ erk.DefaultKind }
+
+var (
+	ErrNoSigningKey         = erk.New(ErkCannotSign{}, "The signing keyFile is empty. Check that the environment variables it uses are set.")
+	ErrCannotReadKey        = erk.New(ErkCannotSign{}, "Cannot read the key '{{.path}}': {{.err}}")
+	ErrCannotReadZip        = erk.New(ErkCannotSign{}, "Cannot read the zip '{{.path}}': {{.err}}")
+	ErrCannotSign           = erk.New(ErkCannotSign{}, "Cannot sign '{{.path}}': {{.err}}")
+	ErrCannotWriteSignature = erk.New(ErkCannotSign{}, "Cannot write the signature '{{.path}}': {{.err}}")
+)
+
+// Params configures how a zip is signed.
+type Params struct {
+	// ZipPath of the zip to sign.
+	ZipPath string
+
+	// KeyPath of the PEM file containing the private key.
+	KeyPath string
+
+	// OutPath of the detached signature.
+	OutPath string
+}
+
+type SignerAPI interface {
+	Sign(params *Params) (*manifest.Signature, error)
+}
+
+// Signer writes detached Ed25519 or ECDSA signatures of zips, without accessing the network.
+type Signer struct{}
+
+var _ SignerAPI = &Signer{}
+
+// Sign the zip with the private key, and write the signature to the OutPath.
+// The signature contains the raw signature bytes, so it can be verified with OpenSSL.
+func (s *Signer) Sign(params *Params) (*manifest.Signature, error) {
+	if params.KeyPath == "" {
+		return nil, ErrNoSigningKey
+	}
+
+	key, err := readPrivateKey(params.KeyPath)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadKey, err, erk.Params{"path": params.KeyPath})
+	}
+
+	id, err := keyID(key.Public())
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadKey, err, erk.Params{"path": params.KeyPath})
+	}
+
+	zip, err := os.ReadFile(params.ZipPath)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadZip, err, erk.Params{"path": params.ZipPath})
+	}
+
+	signature, err := sign(key, zip)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotSign, err, erk.Params{"path": params.ZipPath})
+	}
+
+	if err := os.WriteFile(params.OutPath, signature, 0o644); err != nil { //nolint:gosec,mnd // Signatures are not sensitive
+		return nil, erk.WrapWith(ErrCannotWriteSignature, err, erk.Params{"path": params.OutPath})
+	}
+
+	return &manifest.Signature{
+		Path:      params.OutPath,
+		Algorithm: algorithm(key.Public()),
+		KeyID:     id,
+		ZipDigest: digest(zip),
+	}, nil
+}
@@ -0,0 +1,181 @@
+package signing_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/JosiahWitt/lambgo/internal/signing"
+)
+
+func TestSign(t *testing.T) {
+	ensure := ensure.New(t)
+
+	zip := []byte("PK\x03\x04 not really a zip")
+	zipDigest := sha256.Sum256(zip)
+
+	ed25519Public, ed25519Private, err := ed25519.GenerateKey(rand.Reader)
+	ensure(err).IsNotError()
+
+	ecdsaPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ensure(err).IsNotError()
+
+	ecdsaP384Private, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	ensure(err).IsNotError()
+
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 1024) //nolint:gosec // Only used to check that RSA is rejected
+	ensure(err).IsNotError()
+
+	sec1, err := x509.MarshalECPrivateKey(ecdsaPrivate)
+	ensure(err).IsNotError()
+
+	verifyECDSA := func(key *ecdsa.PrivateKey) func(ensuring.E, []byte) {
+		return func(ensure ensuring.E, signature []byte) {
+			ensure(ecdsa.VerifyASN1(&key.PublicKey, zipDigest[:], signature)).IsTrue()
+		}
+	}
+
+	table := []struct {
+		Name              string
+		KeyPEM            []byte
+		MissingZip        bool
+		ExpectedAlgorithm string
+		ExpectedKeyID     string
+		ExpectedError     error
+		Verify            func(ensuring.E, []byte)
+	}{
+		{
+			Name:              "with an Ed25519 key",
+			KeyPEM:            pkcs8PEM(ensure, ed25519Private),
+			ExpectedAlgorithm: "ed25519",
+			ExpectedKeyID:     keyID(ensure, ed25519Public),
+			Verify: func(ensure ensuring.E, signature []byte) {
+				ensure(ed25519.Verify(ed25519Public, zip, signature)).IsTrue()
+			},
+		},
+		{
+			Name:              "with an ECDSA P-256 key",
+			KeyPEM:            pkcs8PEM(ensure, ecdsaPrivate),
+			ExpectedAlgorithm: "ecdsa-p256-sha256",
+			ExpectedKeyID:     keyID(ensure, &ecdsaPrivate.PublicKey),
+			Verify:            verifyECDSA(ecdsaPrivate),
+		},
+		{
+			Name:              "with an ECDSA P-384 key",
+			KeyPEM:            pkcs8PEM(ensure, ecdsaP384Private),
+			ExpectedAlgorithm: "ecdsa-p384-sha256",
+			ExpectedKeyID:     keyID(ensure, &ecdsaP384Private.PublicKey),
+			Verify:            verifyECDSA(ecdsaP384Private),
+		},
+		{
+			Name:              "with an ECDSA key in the SEC 1 format",
+			KeyPEM:            pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}),
+			ExpectedAlgorithm: "ecdsa-p256-sha256",
+			ExpectedKeyID:     keyID(ensure, &ecdsaPrivate.PublicKey),
+			Verify:            verifyECDSA(ecdsaPrivate),
+		},
+		{
+			Name:          "with an RSA key",
+			KeyPEM:        pkcs8PEM(ensure, rsaPrivate),
+			ExpectedError: signing.ErrCannotReadKey,
+		},
+		{
+			Name:          "with a public key",
+			KeyPEM:        publicPEM(ensure, ed25519Public),
+			ExpectedError: signing.ErrCannotReadKey,
+		},
+		{
+			Name:          "with a file that is not PEM",
+			KeyPEM:        []byte("not a key"),
+			ExpectedError: signing.ErrCannotReadKey,
+		},
+		{
+			Name:          "with a missing key",
+			ExpectedError: signing.ErrCannotReadKey,
+		},
+		{
+			Name:          "with a missing zip",
+			KeyPEM:        pkcs8PEM(ensure, ed25519Private),
+			MissingZip:    true,
+			ExpectedError: signing.ErrCannotReadZip,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		dir := ensure.T().TempDir()
+
+		params := &signing.Params{
+			ZipPath: filepath.Join(dir, "api.zip"),
+			KeyPath: filepath.Join(dir, "signing.pem"),
+			OutPath: filepath.Join(dir, "api.zip.sig"),
+		}
+
+		if !entry.MissingZip {
+			ensure(os.WriteFile(params.ZipPath, zip, 0o600)).IsNotError()
+		}
+
+		if entry.KeyPEM != nil {
+			ensure(os.WriteFile(params.KeyPath, entry.KeyPEM, 0o600)).IsNotError()
+		}
+
+		subject := &signing.Signer{}
+		signature, err := subject.Sign(params)
+		ensure(err).IsError(entry.ExpectedError)
+		if entry.ExpectedError != nil {
+			ensure(signature).IsNil()
+			return
+		}
+
+		ensure(signature).Equals(&manifest.Signature{
+			Path:      params.OutPath,
+			Algorithm: entry.ExpectedAlgorithm,
+			KeyID:     entry.ExpectedKeyID,
+			ZipDigest: "sha256:" + hex.EncodeToString(zipDigest[:]),
+		})
+
+		written, err := os.ReadFile(params.OutPath)
+		ensure(err).IsNotError()
+		entry.Verify(ensure, written)
+	})
+
+	ensure.Run("with an empty key path", func(ensure ensuring.E) {
+		subject := &signing.Signer{}
+		signature, err := subject.Sign(&signing.Params{ZipPath: "api.zip", OutPath: "api.zip.sig"})
+		ensure(err).IsError(signing.ErrNoSigningKey)
+		ensure(signature).IsNil()
+	})
+}
+
+func pkcs8PEM(ensure ensuring.E, key crypto.PrivateKey) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	ensure(err).IsNotError()
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func publicPEM(ensure ensuring.E, key crypto.PublicKey) []byte {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	ensure(err).IsNotError()
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+func keyID(ensure ensuring.E, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	ensure(err).IsNotError()
+
+	sum := sha256.Sum256(der)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
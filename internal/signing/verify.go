@@ -0,0 +1,171 @@
+package signing
+
+import (
+	"crypto"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+)
+
+type ErkInvalidSignature struct{ erk.DefaultKind }
+
+var (
+	ErrNoPublicKey        = erk.New(ErkCannotSign{}, "No public key to verify with. Set signing in .lambgo.yml, or provide --public-key.")
+	ErrCannotReadManifest = erk.New(ErkCannotSign{}, "Cannot read the build manifest '{{.path}}': {{.err}}")
+
+	ErrNotBuilt          = erk.New(ErkInvalidSignature{}, "The artifact is not in the build manifest. Run `lambgo build` first.")
+	ErrNotSigned         = erk.New(ErkInvalidSignature{}, "The zip is not signed. Set signing in .lambgo.yml, and run `lambgo build`.")
+	ErrWrongKey          = erk.New(ErkInvalidSignature{}, "The zip was signed with the key {{.signedKeyID}}, instead of {{.keyID}}")
+	ErrZipModified       = erk.New(ErkInvalidSignature{}, "The zip was modified after it was signed. Expected the digest {{.expected}}, but found {{.actual}}")
+	ErrCannotReadFile    = erk.New(ErkInvalidSignature{}, "Cannot read '{{.path}}': {{.err}}")
+	ErrSignatureMismatch = erk.New(ErkInvalidSignature{}, "The signature '{{.path}}' does not match the zip")
+)
+
+// Result of verifying the signature of an artifact.
+type Result struct {
+	Artifact *manifest.Artifact
+
+	// Err describes why the signature is invalid, which is nil when it is valid.
+	Err error
+}
+
+type VerifierAPI interface {
+	Verify(config *lambgofile.Config, publicKeyPath string) ([]*Result, error)
+}
+
+// Verifier checks the signatures recorded in the manifest against the zips and a public key.
+type Verifier struct{}
+
+var _ VerifierAPI = &Verifier{}
+
+// Verify the signatures of the Lambdas, extensions, and layers in the config, which have already been built.
+// The public key is read from publicKeyPath, or signing in the config when it is empty.
+// An error is only returned when the verification cannot run. Invalid signatures are described by each Result.
+func (v *Verifier) Verify(config *lambgofile.Config, publicKeyPath string) ([]*Result, error) {
+	if publicKeyPath == "" {
+		publicKeyPath = configPublicKeyPath(config)
+		if publicKeyPath == "" {
+			return nil, ErrNoPublicKey
+		}
+	}
+
+	publicKey, err := readPublicKey(publicKeyPath)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadKey, err, erk.Params{"path": publicKeyPath})
+	}
+
+	id, err := keyID(publicKey)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadKey, err, erk.Params{"path": publicKeyPath})
+	}
+
+	outDirectory := config.OutDirectory
+	if outDirectory == "" {
+		outDirectory = lambgofile.DefaultOutDirectory
+	}
+
+	manifestPath := rootPath(config, manifest.PathFor(outDirectory))
+	m, err := manifest.Read(manifestPath)
+	if err != nil {
+		return nil, erk.WrapWith(ErrCannotReadManifest, err, erk.Params{"path": manifestPath})
+	}
+
+	expected := expectedArtifacts(config)
+	results := make([]*Result, 0, len(expected))
+	for _, artifact := range expected {
+		index := slices.IndexFunc(m.Artifacts, func(built *manifest.Artifact) bool {
+			return built.Kind == artifact.Kind && built.Name == artifact.Name
+		})
+
+		if index < 0 {
+			results = append(results, &Result{Artifact: artifact, Err: ErrNotBuilt})
+			continue
+		}
+
+		artifact = m.Artifacts[index]
+		results = append(results, &Result{Artifact: artifact, Err: verifyArtifact(config, publicKey, id, artifact)})
+	}
+
+	return results, nil
+}
+
+func verifyArtifact(config *lambgofile.Config, publicKey crypto.PublicKey, id string, artifact *manifest.Artifact) error {
+	signature := artifact.Signature
+	if signature == nil {
+		return ErrNotSigned
+	}
+
+	if signature.KeyID != id {
+		return erk.WithParams(ErrWrongKey, erk.Params{"signedKeyID": signature.KeyID, "keyID": id})
+	}
+
+	zipPath := rootPath(config, artifact.ZipPath)
+	zip, err := os.ReadFile(zipPath)
+	if err != nil {
+		return erk.WrapWith(ErrCannotReadFile, err, erk.Params{"path": zipPath})
+	}
+
+	if zipDigest := digest(zip); zipDigest != signature.ZipDigest {
+		return erk.WithParams(ErrZipModified, erk.Params{"expected": signature.ZipDigest, "actual": zipDigest})
+	}
+
+	signaturePath := rootPath(config, signature.Path)
+	signatureBytes, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return erk.WrapWith(ErrCannotReadFile, err, erk.Params{"path": signaturePath})
+	}
+
+	if !verify(publicKey, zip, signatureBytes) {
+		return erk.WithParams(ErrSignatureMismatch, erk.Params{"path": signaturePath})
+	}
+
+	return nil
+}
+
+// expectedArtifacts lists the kind and name of the artifacts built for the config, in the order they are built.
+func expectedArtifacts(config *lambgofile.Config) []*manifest.Artifact {
+	artifacts := make([]*manifest.Artifact, 0, len(config.Lambdas)+len(config.Extensions)+len(config.Layers))
+	for _, lambda := range config.Lambdas {
+		artifacts = append(artifacts, &manifest.Artifact{Kind: manifest.KindLambda, Name: lambda.Path})
+	}
+
+	for _, extension := range config.Extensions {
+		artifacts = append(artifacts, &manifest.Artifact{Kind: manifest.KindExtension, Name: extension.Path})
+	}
+
+	for _, layer := range config.Layers {
+		artifacts = append(artifacts, &manifest.Artifact{Kind: manifest.KindLayer, Name: layer.Name})
+	}
+
+	return artifacts
+}
+
+// configPublicKeyPath is the publicKeyFile, or the keyFile when it is not set, relative to the root.
+func configPublicKeyPath(config *lambgofile.Config) string {
+	if config.Signing == nil {
+		return ""
+	}
+
+	if config.Signing.PublicKeyFile != "" {
+		return rootPath(config, config.Signing.PublicKeyFile)
+	}
+
+	if config.Signing.KeyFile != "" {
+		return rootPath(config, config.Signing.KeyFile)
+	}
+
+	return ""
+}
+
+// rootPath resolves paths relative to the root of the config.
+func rootPath(config *lambgofile.Config, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(config.RootPath, path)
+}
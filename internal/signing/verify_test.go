@@ -0,0 +1,212 @@
+package signing_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/manifest"
+	"github.com/JosiahWitt/lambgo/internal/signing"
+)
+
+func TestVerify(t *testing.T) {
+	ensure := ensure.New(t)
+
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	ensure(err).IsNotError()
+
+	otherPublicKey, otherPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	ensure(err).IsNotError()
+
+	// setupBuild signs the zips of the Lambda and layer, and records them in the manifest, like `lambgo build`.
+	setupBuild := func(ensure ensuring.E) (*lambgofile.Config, []*manifest.Artifact) {
+		rootPath := ensure.T().TempDir()
+		writeFile(ensure, filepath.Join(rootPath, "keys/signing.pem"), pkcs8PEM(ensure, privateKey))
+		writeFile(ensure, filepath.Join(rootPath, "keys/signing.pub.pem"), publicPEM(ensure, publicKey))
+		writeFile(ensure, filepath.Join(rootPath, "keys/other.pem"), pkcs8PEM(ensure, otherPrivateKey))
+
+		artifacts := []*manifest.Artifact{
+			{Kind: manifest.KindLambda, Name: "lambdas/api", ZipPath: "out/lambdas/api.zip"},
+			{Kind: manifest.KindLayer, Name: "tools", ZipPath: "out/layers/tools.zip"},
+		}
+
+		signer := &signing.Signer{}
+		for _, artifact := range artifacts {
+			zipPath := filepath.Join(rootPath, artifact.ZipPath)
+			writeFile(ensure, zipPath, []byte(artifact.Name))
+
+			signature, err := signer.Sign(&signing.Params{
+				ZipPath: zipPath,
+				KeyPath: filepath.Join(rootPath, "keys/signing.pem"),
+				OutPath: zipPath + signing.Extension,
+			})
+			ensure(err).IsNotError()
+
+			// The builder records paths relative to the root
+			signature.Path = artifact.ZipPath + signing.Extension
+			artifact.Signature = signature
+		}
+
+		store := &manifest.Store{}
+		ensure(store.Update(filepath.Join(rootPath, "out", manifest.FileName), artifacts)).IsNotError()
+
+		config := &lambgofile.Config{
+			RootPath:     rootPath,
+			OutDirectory: "out",
+			Signing:      &lambgofile.Signing{KeyFile: "keys/signing.pem"},
+			Lambdas:      []*lambgofile.Lambda{{Path: "lambdas/api"}},
+			Layers:       []*lambgofile.Layer{{Name: "tools"}},
+		}
+
+		return config, artifacts
+	}
+
+	ensure.Run("with valid signatures", func(ensure ensuring.E) {
+		config, artifacts := setupBuild(ensure)
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, "")
+		ensure(err).IsNotError()
+		ensure(results).Equals([]*signing.Result{
+			{Artifact: artifacts[0]},
+			{Artifact: artifacts[1]},
+		})
+	})
+
+	ensure.Run("with publicKeyFile", func(ensure ensuring.E) {
+		config, artifacts := setupBuild(ensure)
+		config.Signing = &lambgofile.Signing{PublicKeyFile: "keys/signing.pub.pem"}
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, "")
+		ensure(err).IsNotError()
+		ensure(results).Equals([]*signing.Result{
+			{Artifact: artifacts[0]},
+			{Artifact: artifacts[1]},
+		})
+	})
+
+	ensure.Run("with a provided public key", func(ensure ensuring.E) {
+		config, artifacts := setupBuild(ensure)
+		config.Signing = nil
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, filepath.Join(config.RootPath, "keys/signing.pub.pem"))
+		ensure(err).IsNotError()
+		ensure(results).Equals([]*signing.Result{
+			{Artifact: artifacts[0]},
+			{Artifact: artifacts[1]},
+		})
+	})
+
+	ensure.Run("with a different key", func(ensure ensuring.E) {
+		config, _ := setupBuild(ensure)
+		config.Signing = &lambgofile.Signing{KeyFile: "keys/other.pem"}
+		config.Layers = nil
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, "")
+		ensure(err).IsNotError()
+		ensure(len(results)).Equals(1)
+		ensure(results[0].Err).IsError(signing.ErrWrongKey)
+		ensure(results[0].Err.Error()).Contains(keyID(ensure, otherPublicKey))
+	})
+
+	ensure.Run("with a zip modified after signing", func(ensure ensuring.E) {
+		config, artifacts := setupBuild(ensure)
+		writeFile(ensure, filepath.Join(config.RootPath, artifacts[1].ZipPath), []byte("modified"))
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, "")
+		ensure(err).IsNotError()
+		ensure(len(results)).Equals(2)
+		ensure(results[0].Err).IsNotError()
+		ensure(results[1].Err).IsError(signing.ErrZipModified)
+	})
+
+	ensure.Run("with a signature that does not match", func(ensure ensuring.E) {
+		config, artifacts := setupBuild(ensure)
+		writeFile(ensure, filepath.Join(config.RootPath, artifacts[0].Signature.Path), make([]byte, ed25519.SignatureSize))
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, "")
+		ensure(err).IsNotError()
+		ensure(results[0].Err).IsError(signing.ErrSignatureMismatch)
+		ensure(results[1].Err).IsNotError()
+	})
+
+	ensure.Run("with a missing signature", func(ensure ensuring.E) {
+		config, artifacts := setupBuild(ensure)
+		ensure(os.Remove(filepath.Join(config.RootPath, artifacts[0].Signature.Path))).IsNotError()
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, "")
+		ensure(err).IsNotError()
+		ensure(results[0].Err).IsError(signing.ErrCannotReadFile)
+	})
+
+	ensure.Run("with an unsigned zip", func(ensure ensuring.E) {
+		config, artifacts := setupBuild(ensure)
+		artifacts[0].Signature = nil
+
+		store := &manifest.Store{}
+		ensure(store.Update(filepath.Join(config.RootPath, "out", manifest.FileName), artifacts[:1])).IsNotError()
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, "")
+		ensure(err).IsNotError()
+		ensure(results[0].Err).IsError(signing.ErrNotSigned)
+		ensure(results[1].Err).IsNotError()
+	})
+
+	ensure.Run("with an artifact that is not built", func(ensure ensuring.E) {
+		config, _ := setupBuild(ensure)
+		config.Extensions = []*lambgofile.Extension{{Lambda: lambgofile.Lambda{Path: "extensions/telemetry"}, Name: "telemetry"}}
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, "")
+		ensure(err).IsNotError()
+		ensure(len(results)).Equals(3)
+		ensure(results[1].Artifact).Equals(&manifest.Artifact{Kind: manifest.KindExtension, Name: "extensions/telemetry"})
+		ensure(results[1].Err).IsError(signing.ErrNotBuilt)
+	})
+
+	ensure.Run("without a public key", func(ensure ensuring.E) {
+		config, _ := setupBuild(ensure)
+		config.Signing = nil
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, "")
+		ensure(err).IsError(signing.ErrNoPublicKey)
+		ensure(results).IsNil()
+	})
+
+	ensure.Run("with an invalid public key", func(ensure ensuring.E) {
+		config, _ := setupBuild(ensure)
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, filepath.Join(config.RootPath, "out/lambdas/api.zip"))
+		ensure(err).IsError(signing.ErrCannotReadKey)
+		ensure(results).IsNil()
+	})
+
+	ensure.Run("with an invalid manifest", func(ensure ensuring.E) {
+		config, _ := setupBuild(ensure)
+		writeFile(ensure, filepath.Join(config.RootPath, "out", manifest.FileName), []byte("{"))
+
+		subject := &signing.Verifier{}
+		results, err := subject.Verify(config, "")
+		ensure(err).IsError(signing.ErrCannotReadManifest)
+		ensure(results).IsNil()
+	})
+}
+
+func writeFile(ensure ensuring.E, path string, contents []byte) {
+	ensure(os.MkdirAll(filepath.Dir(path), 0o755)).IsNotError()
+	ensure(os.WriteFile(path, contents, 0o600)).IsNotError()
+}
@@ -0,0 +1,101 @@
+// Package sysmem reads how much memory is available on the host or in its container, to limit concurrent builds.
+package sysmem
+
+import (
+	"bufio"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+const (
+	memInfoPath  = "proc/meminfo"
+	memAvailable = "MemAvailable:"
+	bytesPerKB   = 1024
+
+	// The cgroup of a container is mounted at /sys/fs/cgroup, with the files of cgroup v2 at its root,
+	// and the files of the memory controller of cgroup v1 in its memory directory.
+	cgroupV2LimitPath = "sys/fs/cgroup/memory.max"
+	cgroupV1LimitPath = "sys/fs/cgroup/memory/memory.limit_in_bytes"
+
+	// cgroupV1Unlimited is the smallest limit that cgroup v1 uses when there is no limit (the maximum int64, rounded to a page).
+	cgroupV1Unlimited = 1 << 62
+)
+
+// Reader of the available memory.
+type Reader struct {
+	// FS is the root of the file system.
+	FS fs.FS
+}
+
+// Available returns the bytes of memory that can be used without swapping, and whether it is known.
+// It is the smaller of MemAvailable in /proc/meminfo, and the memory limit of the cgroup (eg. of a container),
+// since /proc/meminfo describes the host even within a container. It is only known on Linux.
+func (r *Reader) Available() (uint64, bool) {
+	available, known := r.memInfoAvailable()
+
+	if limit, ok := r.cgroupLimit(); ok && (!known || limit < available) {
+		return limit, true
+	}
+
+	return available, known
+}
+
+// memInfoAvailable reads MemAvailable from /proc/meminfo, which is the memory available on the host.
+func (r *Reader) memInfoAvailable() (uint64, bool) {
+	file, err := r.FS.Open(memInfoPath)
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		value, found := strings.CutPrefix(scanner.Text(), memAvailable)
+		if !found {
+			continue
+		}
+
+		// The value is always in kB, eg. "MemAvailable:   12345678 kB"
+		kilobytes, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimSpace(value), " kB"), 10, 64)
+		if err != nil {
+			return 0, false
+		}
+
+		return kilobytes * bytesPerKB, true
+	}
+
+	return 0, false
+}
+
+// cgroupLimit reads the memory limit of the cgroup from cgroup v2, or cgroup v1 when v2 is not used.
+// The usage of the cgroup is not subtracted, since it includes the page cache, which is freed when builds need the memory.
+// It is not known when the cgroup has no limit.
+func (r *Reader) cgroupLimit() (uint64, bool) {
+	// cgroup v2 uses "max" when there is no limit, which is not a number
+	if _, err := fs.Stat(r.FS, cgroupV2LimitPath); err == nil {
+		return r.readBytes(cgroupV2LimitPath)
+	}
+
+	limit, ok := r.readBytes(cgroupV1LimitPath)
+	if !ok || limit >= cgroupV1Unlimited {
+		return 0, false
+	}
+
+	return limit, true
+}
+
+// readBytes from a cgroup file containing a single number of bytes.
+func (r *Reader) readBytes(path string) (uint64, bool) {
+	data, err := fs.ReadFile(r.FS, path)
+	if err != nil {
+		return 0, false
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return value, true
+}
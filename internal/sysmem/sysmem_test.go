@@ -0,0 +1,112 @@
+package sysmem_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/sysmem"
+)
+
+func TestAvailable(t *testing.T) {
+	ensure := ensure.New(t)
+
+	const memInfo = "MemTotal:       32791636 kB\n" +
+		"MemFree:         1234567 kB\n" +
+		"MemAvailable:   16384000 kB\n" +
+		"Buffers:          123456 kB\n"
+
+	table := []struct {
+		Name              string
+		Files             map[string]string
+		ExpectedAvailable uint64
+		ExpectedKnown     bool
+	}{
+		{
+			Name:              "with MemAvailable",
+			Files:             map[string]string{"proc/meminfo": memInfo},
+			ExpectedAvailable: 16384000 * 1024,
+			ExpectedKnown:     true,
+		},
+		{
+			Name:  "without MemAvailable",
+			Files: map[string]string{"proc/meminfo": "MemTotal:       32791636 kB\nMemFree:         1234567 kB\n"},
+		},
+		{
+			Name:  "with invalid MemAvailable",
+			Files: map[string]string{"proc/meminfo": "MemAvailable:   lots kB\n"},
+		},
+		{
+			Name: "without /proc/meminfo",
+		},
+		{
+			Name: "with a smaller cgroup v2 limit",
+			Files: map[string]string{
+				"proc/meminfo":             memInfo,
+				"sys/fs/cgroup/memory.max": "4294967296\n",
+			},
+			ExpectedAvailable: 4 << 30,
+			ExpectedKnown:     true,
+		},
+		{
+			Name: "with a larger cgroup v2 limit",
+			Files: map[string]string{
+				"proc/meminfo":             memInfo,
+				"sys/fs/cgroup/memory.max": "68719476736\n",
+			},
+			ExpectedAvailable: 16384000 * 1024,
+			ExpectedKnown:     true,
+		},
+		{
+			Name: "without a cgroup v2 limit",
+			Files: map[string]string{
+				"proc/meminfo":             memInfo,
+				"sys/fs/cgroup/memory.max": "max\n",
+
+				// cgroup v1 is not used when cgroup v2 is mounted
+				"sys/fs/cgroup/memory/memory.limit_in_bytes": "1073741824\n",
+			},
+			ExpectedAvailable: 16384000 * 1024,
+			ExpectedKnown:     true,
+		},
+		{
+			Name: "with a smaller cgroup v1 limit",
+			Files: map[string]string{
+				"proc/meminfo": memInfo,
+				"sys/fs/cgroup/memory/memory.limit_in_bytes": "2147483648\n",
+			},
+			ExpectedAvailable: 2 << 30,
+			ExpectedKnown:     true,
+		},
+		{
+			Name: "without a cgroup v1 limit",
+			Files: map[string]string{
+				"proc/meminfo": memInfo,
+				"sys/fs/cgroup/memory/memory.limit_in_bytes": "9223372036854771712\n",
+			},
+			ExpectedAvailable: 16384000 * 1024,
+			ExpectedKnown:     true,
+		},
+		{
+			Name:              "with a cgroup limit, without /proc/meminfo",
+			Files:             map[string]string{"sys/fs/cgroup/memory.max": "4294967296\n"},
+			ExpectedAvailable: 4 << 30,
+			ExpectedKnown:     true,
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+
+		fsys := fstest.MapFS{}
+		for path, data := range entry.Files {
+			fsys[path] = &fstest.MapFile{Data: []byte(data)}
+		}
+
+		reader := &sysmem.Reader{FS: fsys}
+		available, known := reader.Available()
+		ensure(available).Equals(entry.ExpectedAvailable)
+		ensure(known).Equals(entry.ExpectedKnown)
+	})
+}
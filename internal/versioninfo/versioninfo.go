@@ -0,0 +1,131 @@
+// Package versioninfo reads the git metadata and build time that are injected into Lambdas with -ldflags -X.
+package versioninfo
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JosiahWitt/erk"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/runcmd"
+)
+
+type ErkCannotReadInfo struct{ erk.DefaultKind }
+
+var (
+	ErrCannotReadGit          = erk.New(ErkCannotReadInfo{}, "Cannot read the git {{.field}}: {{.err}}")
+	ErrInvalidSourceDateEpoch = erk.New(ErkCannotReadInfo{},
+		"Invalid SOURCE_DATE_EPOCH '{{.value}}', since it must be the number of seconds since the Unix epoch",
+	)
+)
+
+// Info contains the values of the variables in versionInjection.
+// Values are empty when their variable is not set, so git is only run when it is needed.
+type Info struct {
+	Commit    string
+	Dirty     string
+	Tag       string
+	BuildTime string
+}
+
+type ReaderAPI interface {
+	Read(rootPath string, injection *lambgofile.VersionInjection) (*Info, error)
+}
+
+// Reader runs git in the module, which may be nested within the repository.
+type Reader struct {
+	Cmd runcmd.RunnerAPI
+
+	// Now is used for the build time when SOURCE_DATE_EPOCH is not set. Defaults to time.Now.
+	Now func() time.Time
+}
+
+var _ ReaderAPI = &Reader{}
+
+// Read the values of the variables that are set in the injection.
+// The build time is in RFC 3339 format, and uses SOURCE_DATE_EPOCH when it is set, so builds can be reproduced.
+func (r *Reader) Read(rootPath string, injection *lambgofile.VersionInjection) (*Info, error) {
+	info := &Info{}
+
+	if injection.Commit != "" {
+		commit, err := r.git(rootPath, "commit", "rev-parse", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+
+		info.Commit = commit
+	}
+
+	if injection.Dirty != "" {
+		// Untracked files are included, like the vcs.modified setting that the go command embeds
+		status, err := r.git(rootPath, "status", "status", "--porcelain")
+		if err != nil {
+			return nil, err
+		}
+
+		info.Dirty = strconv.FormatBool(status != "")
+	}
+
+	if injection.Tag != "" {
+		tag, err := r.git(rootPath, "tag", "describe", "--tags", "--always")
+		if err != nil {
+			return nil, err
+		}
+
+		info.Tag = tag
+	}
+
+	if injection.BuildTime != "" {
+		buildTime, err := r.buildTime()
+		if err != nil {
+			return nil, err
+		}
+
+		info.BuildTime = buildTime.UTC().Format(time.RFC3339)
+	}
+
+	return info, nil
+}
+
+func (r *Reader) buildTime() (time.Time, error) {
+	if epoch, ok, err := SourceDateEpoch(); ok || err != nil {
+		return epoch, err
+	}
+
+	if r.Now == nil {
+		return time.Now(), nil
+	}
+
+	return r.Now(), nil
+}
+
+// SourceDateEpoch returns the time in the SOURCE_DATE_EPOCH environment variable, and whether it is set.
+// It is used instead of the current time, so builds can be reproduced.
+func SourceDateEpoch() (time.Time, bool, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return time.Time{}, false, nil
+	}
+
+	seconds, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, false, erk.WrapWith(ErrInvalidSourceDateEpoch, err, erk.Params{"value": epoch})
+	}
+
+	return time.Unix(seconds, 0), true, nil
+}
+
+func (r *Reader) git(rootPath, field string, args ...string) (string, error) {
+	out, err := r.Cmd.Exec(&runcmd.ExecParams{
+		PWD:  rootPath,
+		CMD:  "git",
+		Args: args,
+	})
+	if err != nil {
+		return "", erk.WrapWith(ErrCannotReadGit, err, erk.Params{"field": field})
+	}
+
+	return strings.TrimSpace(out), nil
+}
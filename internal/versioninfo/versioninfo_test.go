@@ -0,0 +1,109 @@
+package versioninfo_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/lambgofile"
+	"github.com/JosiahWitt/lambgo/internal/mocks/mock_runcmd"
+	"github.com/JosiahWitt/lambgo/internal/runcmd"
+	"github.com/JosiahWitt/lambgo/internal/versioninfo"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRead(t *testing.T) {
+	ensure := ensure.New(t)
+
+	type Mocks struct {
+		Cmd *mock_runcmd.MockRunnerAPI
+	}
+
+	exampleError := errors.New("not a git repository")
+
+	allVariables := &lambgofile.VersionInjection{
+		Commit:     "main.Commit",
+		Dirty:      "main.Dirty",
+		Tag:        "main.Version",
+		BuildTime:  "main.BuildTime",
+		LambdaPath: "main.LambdaPath",
+	}
+
+	expectGit := func(m *Mocks, out string, err error, args ...string) *gomock.Call {
+		return m.Cmd.EXPECT().Exec(&runcmd.ExecParams{PWD: "/my/app", CMD: "git", Args: args}).Return(out, err)
+	}
+
+	table := []struct {
+		Name            string
+		Injection       *lambgofile.VersionInjection
+		SourceDateEpoch string
+		ExpectedInfo    *versioninfo.Info
+		ExpectedError   error
+
+		Mocks      *Mocks
+		SetupMocks func(*Mocks)
+		Subject    *versioninfo.Reader
+	}{
+		{
+			Name:      "with all variables",
+			Injection: allVariables,
+			SetupMocks: func(m *Mocks) {
+				gomock.InOrder(
+					expectGit(m, "4f2a9c1e8b7d6a5f4e3d2c1b0a9f8e7d6c5b4a39\n", nil, "rev-parse", "HEAD"),
+					expectGit(m, " M lambdas/api/main.go\n", nil, "status", "--porcelain"),
+					expectGit(m, "v1.2.3-4-g4f2a9c1\n", nil, "describe", "--tags", "--always"),
+				)
+			},
+			ExpectedInfo: &versioninfo.Info{
+				Commit:    "4f2a9c1e8b7d6a5f4e3d2c1b0a9f8e7d6c5b4a39",
+				Dirty:     "true",
+				Tag:       "v1.2.3-4-g4f2a9c1",
+				BuildTime: "2024-05-06T07:08:09Z",
+			},
+		},
+
+		{
+			Name:            "with SOURCE_DATE_EPOCH",
+			Injection:       &lambgofile.VersionInjection{Dirty: "main.Dirty", BuildTime: "main.BuildTime"},
+			SourceDateEpoch: "1700000000",
+			SetupMocks: func(m *Mocks) {
+				expectGit(m, "", nil, "status", "--porcelain")
+			},
+			ExpectedInfo: &versioninfo.Info{Dirty: "false", BuildTime: "2023-11-14T22:13:20Z"},
+		},
+
+		{
+			Name:         "with only the Lambda path, which does not run git",
+			Injection:    &lambgofile.VersionInjection{LambdaPath: "main.LambdaPath"},
+			ExpectedInfo: &versioninfo.Info{},
+		},
+
+		{
+			Name:            "with invalid SOURCE_DATE_EPOCH",
+			Injection:       &lambgofile.VersionInjection{BuildTime: "main.BuildTime"},
+			SourceDateEpoch: "yesterday",
+			ExpectedError:   versioninfo.ErrInvalidSourceDateEpoch,
+		},
+
+		{
+			Name:          "when git fails",
+			Injection:     allVariables,
+			ExpectedError: versioninfo.ErrCannotReadGit,
+			SetupMocks: func(m *Mocks) {
+				expectGit(m, "", exampleError, "rev-parse", "HEAD")
+			},
+		},
+	}
+
+	ensure.RunTableByIndex(table, func(ensure ensuring.E, i int) {
+		entry := table[i]
+		ensure.T().Setenv("SOURCE_DATE_EPOCH", entry.SourceDateEpoch)
+		entry.Subject.Now = func() time.Time { return time.Date(2024, 5, 6, 9, 8, 9, 0, time.FixedZone("CEST", 2*60*60)) }
+
+		info, err := entry.Subject.Read("/my/app", entry.Injection)
+		ensure(err).IsError(entry.ExpectedError)
+		ensure(info).Equals(entry.ExpectedInfo)
+	})
+}
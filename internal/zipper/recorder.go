@@ -0,0 +1,27 @@
+package zipper
+
+import "log"
+
+// Recorder prints the zips instead of writing them, for dry runs.
+type Recorder struct {
+	Logger *log.Logger
+}
+
+var _ ZipAPI = &Recorder{}
+
+// ZipFile prints the zip that would be written to <path>.zip.
+func (r *Recorder) ZipFile(path, zippedFileName string) error {
+	return r.ZipFiles(path+".zip", []*File{
+		{Path: path, ZippedName: zippedFileName, Mode: ExecutableMode},
+	})
+}
+
+// ZipFiles prints the zip that would be written to zipPath, and the files it would contain.
+func (r *Recorder) ZipFiles(zipPath string, files []*File) error {
+	r.Logger.Printf("   zip: %s\n", zipPath)
+	for _, file := range files {
+		r.Logger.Printf("     %s %s <- %s\n", file.Mode, file.ZippedName, file.Path)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package zipper_test
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"testing"
+
+	"github.com/JosiahWitt/ensure"
+	"github.com/JosiahWitt/ensure/ensuring"
+	"github.com/JosiahWitt/lambgo/internal/zipper"
+)
+
+func TestRecorder(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("ZipFile prints the zip without writing it", func(ensure ensuring.E) {
+		output := &bytes.Buffer{}
+		recorder := &zipper.Recorder{Logger: log.New(output, "", 0)}
+
+		path := filepath.Join(ensure.T().TempDir(), "api")
+		ensure(recorder.ZipFile(path, "bootstrap")).IsNotError()
+		ensure(output.String()).Equals("   zip: " + path + ".zip\n     -rwxr-xr-x bootstrap <- " + path + "\n")
+
+		matches, err := filepath.Glob(path + "*")
+		ensure(err).IsNotError()
+		ensure(matches).IsEmpty()
+	})
+
+	ensure.Run("ZipFiles prints each file", func(ensure ensuring.E) {
+		output := &bytes.Buffer{}
+		recorder := &zipper.Recorder{Logger: log.New(output, "", 0)}
+
+		err := recorder.ZipFiles("tmp/layers/shared.zip", []*zipper.File{
+			{Path: "tmp/layers/shared/tools/converter", ZippedName: "bin/converter", Mode: zipper.ExecutableMode},
+			{Path: "/my/root/assets/a.json", ZippedName: "lib/a.json", Mode: zipper.RegularMode},
+		})
+		ensure(err).IsNotError()
+		ensure(output.String()).Equals(
+			"   zip: tmp/layers/shared.zip\n" +
+				"     -rwxr-xr-x bin/converter <- tmp/layers/shared/tools/converter\n" +
+				"     -rw-r--r-- lib/a.json <- /my/root/assets/a.json\n",
+		)
+	})
+}
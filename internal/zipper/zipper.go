@@ -3,12 +3,45 @@ package zipper
 import (
 	"archive/zip"
 	"io"
+	"io/fs"
 	"os"
+	"path"
+	"slices"
+	"strings"
 	"time"
+
+	"github.com/JosiahWitt/erk"
+)
+
+const (
+	// ExecutableMode is used for binaries, since Lambda needs to be able to execute
+	// them, regardless of the umask they were built with.
+	ExecutableMode fs.FileMode = 0o755
+
+	// RegularMode is used for data files.
+	RegularMode fs.FileMode = 0o644
 )
 
+type ErkCannotZip struct{ erk.DefaultKind }
+
+var ErrDuplicateZippedName = erk.New(ErkCannotZip{}, "Multiple files are zipped to the same name: {{.zippedName}}")
+
 type ZipAPI interface {
 	ZipFile(path, zippedFileName string) error
+	ZipFiles(zipPath string, files []*File) error
+}
+
+// File to include in a zip.
+type File struct {
+	// Path to the file on disk.
+	Path string
+
+	// ZippedName is the name of the file once it is zipped.
+	// It can be a nested path (eg. bin/my-tool).
+	ZippedName string
+
+	// Mode of the file once it is zipped.
+	Mode fs.FileMode
 }
 
 type Zip struct{}
@@ -18,8 +51,30 @@ var _ ZipAPI = &Zip{}
 // ZipFile located at path to <path>.zip.
 //
 // zippedFileName is the name of the file once it is zipped.
-func (z *Zip) ZipFile(path, zippedFileName string) (err error) {
-	zipPath := path + ".zip"
+// It can be a nested path (eg. extensions/my-extension), in which case the
+// parent directories are also added to the zip.
+func (z *Zip) ZipFile(path, zippedFileName string) error {
+	return z.ZipFiles(path+".zip", []*File{
+		{Path: path, ZippedName: zippedFileName, Mode: ExecutableMode},
+	})
+}
+
+// ZipFiles into a zip located at zipPath.
+//
+// Entries are sorted by their zipped name, and parent directories are added
+// for nested names, so the zip is reproducible regardless of the order of files.
+func (z *Zip) ZipFiles(zipPath string, files []*File) (err error) {
+	sortedFiles := slices.Clone(files)
+	slices.SortFunc(sortedFiles, func(a, b *File) int {
+		return strings.Compare(a.ZippedName, b.ZippedName)
+	})
+
+	for i := 1; i < len(sortedFiles); i++ {
+		if sortedFiles[i-1].ZippedName == sortedFiles[i].ZippedName {
+			return erk.WithParams(ErrDuplicateZippedName, erk.Params{"zippedName": sortedFiles[i].ZippedName})
+		}
+	}
+
 	zipFile, err := os.Create(zipPath)
 	if err != nil {
 		return err
@@ -39,18 +94,33 @@ func (z *Zip) ZipFile(path, zippedFileName string) (err error) {
 		}
 	}()
 
-	binaryFile, err := os.Open(path)
+	writtenDirs := make(map[string]struct{})
+	for _, file := range sortedFiles {
+		if err := writeParentDirs(zipWriter, file.ZippedName, writtenDirs); err != nil {
+			return err
+		}
+
+		if err := writeFile(zipWriter, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeFile(zipWriter *zip.Writer, file *File) (err error) {
+	sourceFile, err := os.Open(file.Path)
 	if err != nil {
 		return err
 	}
 	defer func() {
-		nestedErr := binaryFile.Close()
+		nestedErr := sourceFile.Close()
 		if err == nil { // Only set err if it is not already set
 			err = nestedErr
 		}
 	}()
 
-	fileInfo, err := binaryFile.Stat()
+	fileInfo, err := sourceFile.Stat()
 	if err != nil {
 		return err
 	}
@@ -60,20 +130,53 @@ func (z *Zip) ZipFile(path, zippedFileName string) (err error) {
 		return err
 	}
 
-	// Hardcode the date to keep builds reproducible
-	fileHeader.Modified = time.Date(2009, 11, 10, 0, 0, 0, 0, time.UTC)
+	fileHeader.Modified = reproducibleModTime()
 	fileHeader.Method = zip.Deflate
-	fileHeader.Name = zippedFileName
+	fileHeader.Name = file.ZippedName
+	fileHeader.SetMode(file.Mode)
 
 	fileHolder, err := zipWriter.CreateHeader(fileHeader)
 	if err != nil {
 		return err
 	}
 
-	_, err = io.Copy(fileHolder, binaryFile)
+	_, err = io.Copy(fileHolder, sourceFile)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func writeParentDirs(zipWriter *zip.Writer, name string, writtenDirs map[string]struct{}) error {
+	dir := path.Dir(name)
+	if dir == "." || dir == "/" {
+		return nil
+	}
+
+	if _, written := writtenDirs[dir]; written {
+		return nil
+	}
+
+	if err := writeParentDirs(zipWriter, dir, writtenDirs); err != nil {
+		return err
+	}
+
+	dirHeader := &zip.FileHeader{
+		Name:     dir + "/",
+		Modified: reproducibleModTime(),
+	}
+	dirHeader.SetMode(fs.ModeDir | ExecutableMode)
+
+	if _, err := zipWriter.CreateHeader(dirHeader); err != nil {
+		return err
+	}
+
+	writtenDirs[dir] = struct{}{}
+	return nil
+}
+
+// reproducibleModTime is hardcoded to keep builds reproducible.
+func reproducibleModTime() time.Time {
+	return time.Date(2009, 11, 10, 0, 0, 0, 0, time.UTC)
+}
@@ -1,6 +1,7 @@
 package zipper_test
 
 import (
+	"archive/zip"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -61,6 +62,51 @@ func TestZipFile(t *testing.T) {
 		ensure(fileInfo.ModTime()).Equals(time.Date(2009, 11, 10, 0, 0, 0, 0, time.UTC))
 	})
 
+	ensure.Run("when successfully zipping file to a nested path", func(ensure ensuring.E) {
+		dir := ensure.T().TempDir()
+
+		const fileName = "test-file"
+		const zippedFileName = "extensions/test-extension"
+		path := filepath.Join(dir, fileName)
+		outDir := filepath.Join(dir, "out")
+		zipPath := path + ".zip"
+
+		// Write file without the executable bit
+		err := os.WriteFile(path, []byte(sampleFile), 0o644)
+		ensure(err).IsNotError()
+
+		// Zip file
+		z := zipper.Zip{}
+		err = z.ZipFile(path, zippedFileName)
+		ensure(err).IsNotError()
+
+		// Ensure the parent directory is listed before the file
+		zipReader, err := zip.OpenReader(zipPath)
+		ensure(err).IsNotError()
+		defer zipReader.Close()
+
+		names := make([]string, 0, len(zipReader.File))
+		for _, file := range zipReader.File {
+			names = append(names, file.Name)
+		}
+		ensure(names).Equals([]string{"extensions/", zippedFileName})
+
+		// Unzip file
+		cmd := exec.Command("unzip", zipPath, "-d", outDir)
+		err = cmd.Run()
+		ensure(err).IsNotError()
+
+		// Ensure unzipped file equals original and is executable
+		outPath := filepath.Join(outDir, zippedFileName)
+		data, err := os.ReadFile(outPath)
+		ensure(err).IsNotError()
+		ensure(string(data)).Equals(sampleFile)
+
+		fileInfo, err := os.Stat(outPath)
+		ensure(err).IsNotError()
+		ensure(fileInfo.Mode().Perm()).Equals(os.FileMode(0o755))
+	})
+
 	ensure.Run("when zip file cannot be created", func(ensure ensuring.E) {
 		dir := ensure.T().TempDir()
 
@@ -84,3 +130,105 @@ func TestZipFile(t *testing.T) {
 
 	// TODO: More tests
 }
+
+func TestZipFiles(t *testing.T) {
+	ensure := ensure.New(t)
+
+	ensure.Run("when successfully zipping files", func(ensure ensuring.E) {
+		dir := ensure.T().TempDir()
+		zipPath := filepath.Join(dir, "layer.zip")
+
+		binaryPath := filepath.Join(dir, "binary")
+		err := os.WriteFile(binaryPath, []byte(sampleFile), 0o600)
+		ensure(err).IsNotError()
+
+		dataPath := filepath.Join(dir, "data.json")
+		err = os.WriteFile(dataPath, []byte("{}"), 0o777)
+		ensure(err).IsNotError()
+
+		z := zipper.Zip{}
+		err = z.ZipFiles(zipPath, []*zipper.File{
+			{Path: dataPath, ZippedName: "lib/data/data.json", Mode: zipper.RegularMode},
+			{Path: binaryPath, ZippedName: "bin/binary", Mode: zipper.ExecutableMode},
+			{Path: dataPath, ZippedName: "lib/other.json", Mode: zipper.RegularMode},
+		})
+		ensure(err).IsNotError()
+
+		zipReader, err := zip.OpenReader(zipPath)
+		ensure(err).IsNotError()
+		defer zipReader.Close()
+
+		type entry struct {
+			Name string
+			Mode os.FileMode
+		}
+
+		entries := make([]entry, 0, len(zipReader.File))
+		for _, file := range zipReader.File {
+			entries = append(entries, entry{Name: file.Name, Mode: file.Mode()})
+			ensure(file.Modified.Equal(time.Date(2009, 11, 10, 0, 0, 0, 0, time.UTC))).IsTrue()
+		}
+
+		ensure(entries).Equals([]entry{
+			{Name: "bin/", Mode: os.ModeDir | 0o755},
+			{Name: "bin/binary", Mode: 0o755},
+			{Name: "lib/", Mode: os.ModeDir | 0o755},
+			{Name: "lib/data/", Mode: os.ModeDir | 0o755},
+			{Name: "lib/data/data.json", Mode: 0o644},
+			{Name: "lib/other.json", Mode: 0o644},
+		})
+	})
+
+	ensure.Run("when zipping the same files in a different order", func(ensure ensuring.E) {
+		dir := ensure.T().TempDir()
+
+		firstPath := filepath.Join(dir, "first")
+		err := os.WriteFile(firstPath, []byte("first"), 0o600)
+		ensure(err).IsNotError()
+
+		secondPath := filepath.Join(dir, "second")
+		err = os.WriteFile(secondPath, []byte("second"), 0o600)
+		ensure(err).IsNotError()
+
+		files := []*zipper.File{
+			{Path: firstPath, ZippedName: "a/first", Mode: zipper.RegularMode},
+			{Path: secondPath, ZippedName: "b/second", Mode: zipper.ExecutableMode},
+		}
+
+		z := zipper.Zip{}
+		err = z.ZipFiles(filepath.Join(dir, "forward.zip"), files)
+		ensure(err).IsNotError()
+
+		err = z.ZipFiles(filepath.Join(dir, "reversed.zip"), []*zipper.File{files[1], files[0]})
+		ensure(err).IsNotError()
+
+		forward, err := os.ReadFile(filepath.Join(dir, "forward.zip"))
+		ensure(err).IsNotError()
+
+		reversed, err := os.ReadFile(filepath.Join(dir, "reversed.zip"))
+		ensure(err).IsNotError()
+
+		ensure(forward).Equals(reversed)
+	})
+
+	ensure.Run("when multiple files have the same zipped name", func(ensure ensuring.E) {
+		dir := ensure.T().TempDir()
+
+		z := zipper.Zip{}
+		err := z.ZipFiles(filepath.Join(dir, "layer.zip"), []*zipper.File{
+			{Path: "first", ZippedName: "bin/tool"},
+			{Path: "second", ZippedName: "bin/tool"},
+		})
+		ensure(err).IsError(zipper.ErrDuplicateZippedName)
+	})
+
+	ensure.Run("when a file does not exist", func(ensure ensuring.E) {
+		dir := ensure.T().TempDir()
+
+		z := zipper.Zip{}
+		err := z.ZipFiles(filepath.Join(dir, "layer.zip"), []*zipper.File{
+			{Path: filepath.Join(dir, "missing"), ZippedName: "bin/tool"},
+		})
+		ensure(err).IsNotNil()
+	})
+}